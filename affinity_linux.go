@@ -0,0 +1,47 @@
+//go:build linux
+
+package goripgrep
+
+import (
+	"path/filepath"
+	"runtime"
+
+	"golang.org/x/sys/unix"
+)
+
+// allowedCPUs lists the CPUs the process may run on, in order
+func allowedCPUs() []int {
+	var set unix.CPUSet
+	if err := unix.SchedGetaffinity(0, &set); err != nil {
+		return nil
+	}
+	var cpus []int
+	for cpu := 0; len(cpus) < set.Count(); cpu++ {
+		if set.IsSet(cpu) {
+			cpus = append(cpus, cpu)
+		}
+	}
+	return cpus
+}
+
+// pinThread locks the calling goroutine to its thread and restricts the
+// thread to cpus. The goroutine must never unlock it: the thread then exits
+// with the goroutine instead of going back to the scheduler pinned.
+func pinThread(cpus []int) {
+	runtime.LockOSThread()
+	var set unix.CPUSet
+	for _, cpu := range cpus {
+		set.Set(cpu)
+	}
+	// Pinning is only a hint; the worker runs unpinned if it fails
+	_ = unix.SchedSetaffinity(0, &set)
+}
+
+// numaNodes returns the number of NUMA nodes, 1 when it cannot be read
+func numaNodes() int {
+	nodes, err := filepath.Glob("/sys/devices/system/node/node[0-9]*")
+	if err != nil || len(nodes) == 0 {
+		return 1
+	}
+	return len(nodes)
+}
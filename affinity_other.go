@@ -0,0 +1,17 @@
+//go:build !linux
+
+package goripgrep
+
+// allowedCPUs reports no CPUs where affinity cannot be set, so worker
+// groups are never pinned
+func allowedCPUs() []int {
+	return nil
+}
+
+// pinThread does nothing where affinity cannot be set
+func pinThread(cpus []int) {}
+
+// numaNodes assumes a single NUMA node where it cannot be read
+func numaNodes() int {
+	return 1
+}
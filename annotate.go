@@ -0,0 +1,220 @@
+package goripgrep
+
+import (
+	"bufio"
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Annotation is lightweight code context for a match, such as the function
+// it sits in, added by the Annotate stage
+type Annotation struct {
+	Function string // Enclosing Go function, with methods written as T.Name or (*T).Name
+	Heading  string // Nearest Markdown heading above the match
+}
+
+// annotator finds the context of a line within one file
+type annotator func(line int) *Annotation
+
+// Annotate adds an Annotation to each content match in a Go or Markdown file:
+// the enclosing function for Go files, parsed with go/parser, and the nearest
+// preceding heading for Markdown. Each file is read once; matches in other or
+// unreadable files are left without an annotation.
+func Annotate() Stage {
+	return func(matches []Match) []Match {
+		annotators := make(map[string]annotator)
+
+		for i, match := range matches {
+			if match.Kind != MatchContent || match.Line < 1 {
+				continue
+			}
+
+			annotate, ok := annotators[match.File]
+			if !ok {
+				annotate = newAnnotator(match.File)
+				annotators[match.File] = annotate
+			}
+			if annotate != nil {
+				matches[i].Annotation = annotate(match.Line)
+			}
+		}
+
+		return matches
+	}
+}
+
+// newAnnotator reads and indexes a file, returning nil when the file type
+// is not supported or the file cannot be read
+func newAnnotator(file string) annotator {
+	var build func(file string, src []byte) annotator
+	switch {
+	case strings.EqualFold(filepath.Ext(file), ".go"):
+		build = goAnnotator
+	case isMarkdown(file):
+		build = markdownAnnotator
+	default:
+		return nil
+	}
+
+	src, err := os.ReadFile(file)
+	if err != nil {
+		return nil
+	}
+	return build(file, stripBOM(src))
+}
+
+// funcRange is the span of lines covered by a function declaration
+type funcRange struct {
+	name       string
+	start, end int
+}
+
+// goAnnotator maps lines to the function declaration containing them. Files
+// with syntax errors are annotated as far as the parser gets.
+func goAnnotator(file string, src []byte) annotator {
+	fset := token.NewFileSet()
+	parsed, _ := parser.ParseFile(fset, file, src, parser.SkipObjectResolution)
+	if parsed == nil {
+		return nil
+	}
+
+	var funcs []funcRange
+	for _, decl := range parsed.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok {
+			funcs = append(funcs, funcRange{
+				name:  funcName(fn),
+				start: fset.Position(fn.Pos()).Line,
+				end:   fset.Position(fn.End()).Line,
+			})
+		}
+	}
+
+	return func(line int) *Annotation {
+		for _, fn := range funcs {
+			if line >= fn.start && line <= fn.end {
+				return &Annotation{Function: fn.name}
+			}
+		}
+		return nil
+	}
+}
+
+// funcName returns the name of a function declaration, qualified by the
+// receiver type for methods
+func funcName(fn *ast.FuncDecl) string {
+	if fn.Recv == nil || len(fn.Recv.List) == 0 {
+		return fn.Name.Name
+	}
+
+	recv := fn.Recv.List[0].Type
+	pointer := false
+	if star, ok := recv.(*ast.StarExpr); ok {
+		recv, pointer = star.X, true
+	}
+	// Drop type parameters from generic receivers
+	switch expr := recv.(type) {
+	case *ast.IndexExpr:
+		recv = expr.X
+	case *ast.IndexListExpr:
+		recv = expr.X
+	}
+
+	typeName := "?"
+	if ident, ok := recv.(*ast.Ident); ok {
+		typeName = ident.Name
+	}
+	if pointer {
+		return "(*" + typeName + ")." + fn.Name.Name
+	}
+	return typeName + "." + fn.Name.Name
+}
+
+// markdownHeading matches an ATX heading, capturing its text without the
+// optional closing hashes
+var markdownHeading = regexp.MustCompile(`^ {0,3}#{1,6}(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+
+// markdownAnnotator maps lines to the nearest heading above them
+func markdownAnnotator(_ string, src []byte) annotator {
+	headings := markdownHeadings(src)
+	return func(line int) *Annotation {
+		if line > len(headings) || headings[line-1] == "" {
+			return nil
+		}
+		return &Annotation{Heading: headings[line-1]}
+	}
+}
+
+// isMarkdown reports whether file is named like a Markdown document
+func isMarkdown(file string) bool {
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".md", ".markdown":
+		return true
+	}
+	return false
+}
+
+// Sections sets Section on each content match in a Markdown file to the
+// nearest heading above it. Each file is read once; matches in other or
+// unreadable files are left unchanged.
+func Sections() Stage {
+	return func(matches []Match) []Match {
+		headings := make(map[string][]string)
+
+		for i, match := range matches {
+			if match.Kind != MatchContent || match.Line < 1 || !isMarkdown(match.File) {
+				continue
+			}
+
+			fileHeadings, ok := headings[match.File]
+			if !ok {
+				if src, err := os.ReadFile(match.File); err == nil {
+					fileHeadings = markdownHeadings(stripBOM(src))
+				}
+				headings[match.File] = fileHeadings
+			}
+			if match.Line <= len(fileHeadings) {
+				matches[i].Section = fileHeadings[match.Line-1]
+			}
+		}
+
+		return matches
+	}
+}
+
+// markdownHeadings returns the heading in effect on each line of a Markdown
+// document, ignoring lines inside fenced code blocks. Lines before the first
+// heading have an empty one.
+func markdownHeadings(src []byte) []string {
+	// headings[i] is the heading in effect on line i+1
+	var headings []string
+	heading, fence := "", ""
+
+	scanner := bufio.NewScanner(bytes.NewReader(src))
+	scanner.Buffer(make([]byte, 64*1024), len(src)+1)
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimLeft(line, " ")
+
+		switch {
+		case fence != "":
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+		case strings.HasPrefix(trimmed, "```"), strings.HasPrefix(trimmed, "~~~"):
+			fence = trimmed[:3]
+		default:
+			if groups := markdownHeading.FindStringSubmatch(line); groups != nil {
+				heading = groups[1]
+			}
+		}
+		headings = append(headings, heading)
+	}
+
+	return headings
+}
@@ -0,0 +1,123 @@
+package goripgrep
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAnnotateStage(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"code.go": `package code
+
+// TODO at package level
+var x = 1
+
+func Plain() {
+	// TODO in plain
+}
+
+func (s *Server) Start() { /* TODO pointer */ }
+
+func (l List[T]) Len() int {
+	return 0 // TODO generic
+}
+`,
+		"README.md": "TODO before any heading\n\n# Title #\n\nTODO intro\n\n```sh\n# TODO not a heading\n```\n\n## Setup\nTODO setup\n",
+		"notes.txt": "# Heading\nTODO plain text\n",
+		"broken.go": "package broken\n\nfunc Fine() {\n\t// TODO fine\n}\n\nfunc Broken( {\n",
+	})
+
+	results, err := Find("TODO", dir)
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	annotated := results.Transform(Annotate())
+
+	expected := map[string]Annotation{
+		"TODO at package level":   {},
+		"TODO in plain":           {Function: "Plain"},
+		"TODO pointer":            {Function: "(*Server).Start"},
+		"TODO generic":            {Function: "List.Len"},
+		"TODO fine":               {Function: "Fine"},
+		"TODO before any heading": {},
+		"TODO intro":              {Heading: "Title"},
+		"TODO not a heading":      {Heading: "Title"},
+		"TODO setup":              {Heading: "Setup"},
+		"TODO plain text":         {},
+	}
+
+	found := 0
+	for _, match := range annotated.Matches {
+		for text, want := range expected {
+			if !strings.Contains(match.Content, text) {
+				continue
+			}
+			found++
+			var got Annotation
+			if match.Annotation != nil {
+				got = *match.Annotation
+			}
+			if got != want {
+				t.Errorf("%q in %s: expected annotation %+v, got %+v", text, filepath.Base(match.File), want, got)
+			}
+		}
+	}
+	if found != len(expected) {
+		t.Errorf("Expected %d annotated matches, found %d", len(expected), found)
+	}
+
+	// The original results are left untouched
+	for _, match := range results.Matches {
+		if match.Annotation != nil {
+			t.Fatalf("Annotate modified the original results: %+v", match)
+		}
+	}
+
+	t.Run("MissingFile", func(t *testing.T) {
+		matches := Annotate()([]Match{{File: filepath.Join(dir, "gone.go"), Line: 1}})
+		if matches[0].Annotation != nil {
+			t.Errorf("Expected no annotation for a missing file, got %+v", matches[0].Annotation)
+		}
+	})
+}
+
+func TestFindSections(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"guide.md":  "intro deprecated\n\n# Install\n\n~~~\n## deprecated in a fence\n~~~\n\n## Upgrading ##\nThe old flag is deprecated.\n",
+		"notes.txt": "# Heading\ndeprecated\n",
+	})
+
+	results, err := Find("deprecated", dir, WithSections())
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+
+	expected := map[string]string{
+		"intro deprecated":            "",
+		"## deprecated in a fence":    "Install",
+		"The old flag is deprecated.": "Upgrading",
+		"deprecated":                  "",
+	}
+	if results.Count() != len(expected) {
+		t.Fatalf("Expected %d matches, got %d", len(expected), results.Count())
+	}
+	for _, match := range results.Matches {
+		if want := expected[match.Content]; match.Section != want {
+			t.Errorf("%s:%d: expected section %q, got %q", filepath.Base(match.File), match.Line, want, match.Section)
+		}
+	}
+
+	// Sections are only looked up when asked for
+	results, err = Find("deprecated", filepath.Join(dir, "guide.md"))
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	for _, match := range results.Matches {
+		if match.Section != "" {
+			t.Errorf("Expected no section without WithSections, got %q", match.Section)
+		}
+	}
+}
@@ -3,9 +3,15 @@ package goripgrep
 import (
 	"context"
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
 	"regexp"
+	"strings"
 	"time"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/unicode/norm"
 )
 
 // Option represents a functional option for configuring searches
@@ -15,18 +21,86 @@ type Option func(*searchOptions)
 type searchOptions struct {
 	ctx           context.Context
 	workers       int
+	workerGroups  int
+	pinCPUs       bool
 	bufferSize    int
 	maxResults    int
+	quitAfter     int
+	maxFiles      int
+	maxPerFile    int
+	fileTimings   int
+	strictErrors  bool
+	countOnly     bool
+	deterministic bool
 	optimization  bool
 	gitignore     bool
 	ignoreCase    bool
+	smartCase     bool
 	caseSensitive bool
 	hidden        bool
 	symlinks      bool
+	dedupeLinks   bool
+	allDirs       bool
+	binaryMode    BinaryMode
+	columnMode    ColumnMode
 	recursive     bool
+	maxDepth      int
+	maxPathDepth  int
 	filePattern   string
+	includeGlobs  []string
+	excludeGlobs  []string
+	iglobs        []string
+	ignoreFiles   []string
+	rotatedLogs   bool
+	compressed    bool
+	archives      bool
+	sections      bool
+	keyPaths      bool
+	patterns      []string
+	noLineContent bool
+	onlyMatching  bool
+	templateText  string
+	template      *OutputTemplate // Parsed from templateText by validate
+	sortOrder     SortOrder
+	patternFiles  []string
+	fileTypes     []string
+	fileTypesNot  []string
 	contextLines  int
+	beforeContext int    // -1 until set, to fall back to contextLines
+	afterContext  int    // -1 until set, to fall back to contextLines
+	contextSep    string // Printed between groups of context lines
 	timeout       time.Duration
+	followEvery   time.Duration
+	idlePolicy    *IdlePolicy
+	multiline     bool
+	fixedStrings  bool
+	wordRegexp    bool
+	lineRegexp    bool
+	pcre2         bool
+	rejectSlow    bool
+	auditLog      *slog.Logger
+	logger        *slog.Logger
+	normalizer    *UnicodeNormalizer
+	caseFolder    *AdvancedCaseFolding
+	serverLimits  ServerLimits
+	allowedRoots  []string
+	proxies       []string
+	invertMatch   bool
+	metadata      bool
+	fileNamesOnly bool
+	lineStart     int
+	lineEnd       int
+	headBytes     int64
+	tailBytes     int64
+
+	envErr error // An invalid environment variable, reported by validate
+
+	// Config key options
+	configValues bool // Report config values instead of masking them
+
+	// Replace options
+	dryRun       bool   // Report the rewrite as a diff without touching files
+	backupSuffix string // Keep a copy of each rewritten file with this suffix
 
 	// Streaming search options for large files
 	streamingSearch    bool                 // Enable streaming search for large files
@@ -45,9 +119,10 @@ type searchOptions struct {
 	memoryMappedFiles         bool // Use memory-mapped files for large files
 }
 
-// defaultOptions returns the default search options
+// defaultOptions returns the default search options, as changed by the
+// GORIPGREP_* environment variables
 func defaultOptions() *searchOptions {
-	return &searchOptions{
+	options := &searchOptions{
 		ctx:           context.Background(),
 		workers:       4,
 		bufferSize:    64 * 1024, // 64KB
@@ -58,9 +133,15 @@ func defaultOptions() *searchOptions {
 		caseSensitive: true,
 		hidden:        false,
 		symlinks:      false,
+		dedupeLinks:   true,
 		recursive:     false,
+		maxPathDepth:  512,
 		contextLines:  0,
+		beforeContext: -1,
+		afterContext:  -1,
+		contextSep:    "--",
 		timeout:       30 * time.Second,
+		followEvery:   250 * time.Millisecond,
 
 		// Streaming search defaults
 		streamingSearch:    true,                          // Enable streaming search by default
@@ -78,19 +159,75 @@ func defaultOptions() *searchOptions {
 		regexCaching:              false, // Disabled by default, enable via WithPerformanceMode()
 		memoryMappedFiles:         false, // Disabled by default, enable via WithPerformanceMode()
 	}
+	options.applyEnv()
+	return options
 }
 
-// Find performs a search with functional options
+// Find performs a search with functional options. It builds a fresh
+// SearchEngine for every call, so it is safe to call from multiple goroutines
 func Find(pattern, path string, opts ...Option) (*SearchResults, error) {
+	return FindMulti(pattern, []string{path}, opts...)
+}
+
+// FindMulti searches several paths as one search: a single pool of workers
+// searches the files of every path, walked in the order given, and the
+// results and stats cover them all. Ignore files and path globs apply to
+// each path as if it were searched alone.
+func FindMulti(pattern string, paths []string, opts ...Option) (*SearchResults, error) {
 	// Validate inputs
-	if pattern == "" {
-		return nil, fmt.Errorf("pattern cannot be empty")
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no paths to search")
+	}
+	for _, path := range paths {
+		if path == "" {
+			return nil, fmt.Errorf("path cannot be empty")
+		}
+
+		// Check if path exists
+		if _, err := os.Stat(path); err != nil {
+			return nil, fmt.Errorf("path error: %w", err)
+		}
 	}
+
+	options := defaultOptions()
+	for _, opt := range opts {
+		opt(options)
+	}
+	pattern, err := options.resolvePatterns(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	// Apply timeout to context if specified
+	ctx := options.ctx
+	if options.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.timeout)
+		defer cancel()
+	}
+
+	if err := options.validate(pattern); err != nil {
+		return nil, err
+	}
+
+	// Create and use SearchEngine
+	config := options.searchConfig(paths[0])
+	config.SearchPaths = paths[1:]
+	engine := NewSearchEngine(config)
+	results, err := engine.Search(ctx, pattern)
+	options.finishResults(results)
+	return results, err
+}
+
+// ListFiles returns the files Find would search under path, in walk
+// order, without searching them. The same filters decide: globs, file
+// types, ignore files, hidden and binary file rules and the depth limits,
+// so it shows how they combine. Directories that cannot be read are left
+// out, unless WithStrictErrors makes the first one an error.
+func ListFiles(path string, opts ...Option) ([]string, error) {
 	if path == "" {
 		return nil, fmt.Errorf("path cannot be empty")
 	}
-
-	// Check if path exists
 	if _, err := os.Stat(path); err != nil {
 		return nil, fmt.Errorf("path error: %w", err)
 	}
@@ -100,7 +237,68 @@ func Find(pattern, path string, opts ...Option) (*SearchResults, error) {
 		opt(options)
 	}
 
-	// Apply timeout to context if specified
+	if err := options.validate(""); err != nil {
+		return nil, err
+	}
+
+	ctx := options.ctx
+	if options.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.timeout)
+		defer cancel()
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	engine := &SearchEngine{config: options.searchConfig(path), abort: cancel}
+	if err := engine.initializeEngines(); err != nil {
+		return nil, err
+	}
+	filesChan := make(chan walkedFile)
+	go engine.walkFiles(ctx, filesChan)
+	var files []string
+	for file := range filesChan {
+		files = append(files, file.path)
+	}
+
+	if errs := engine.fileErrs.sorted(); options.strictErrors && len(errs) > 0 {
+		return nil, fmt.Errorf("listing aborted: %w", &errs[0])
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// FindInFile searches a single file the caller already knows about, skipping
+// directory walking and the file filters (globs, file types, ignore files and
+// hidden file rules) that Find applies. Compression, context, line range and
+// other content options still apply.
+func FindInFile(pattern, filePath string, opts ...Option) (*SearchResults, error) {
+	if filePath == "" {
+		return nil, fmt.Errorf("file path cannot be empty")
+	}
+
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("path error: %w", err)
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("path error: %s is a directory", filePath)
+	}
+
+	options := defaultOptions()
+	for _, opt := range opts {
+		opt(options)
+	}
+	pattern, err = options.resolvePatterns(pattern)
+	if err != nil {
+		return nil, err
+	}
+	if err := options.validate(pattern); err != nil {
+		return nil, err
+	}
+
 	ctx := options.ctx
 	if options.timeout > 0 {
 		var cancel context.CancelFunc
@@ -108,28 +306,294 @@ func Find(pattern, path string, opts ...Option) (*SearchResults, error) {
 		defer cancel()
 	}
 
+	engine := NewSearchEngine(options.searchConfig(filePath))
+	results, err := engine.SearchFile(ctx, pattern, filePath)
+	options.finishResults(results)
+	return results, err
+}
+
+// FindReader searches a stream of unknown and possibly unbounded length, such
+// as standard input, calling fn for each match as soon as it is found. Matches
+// are reported with File set to StdinName. Memory stays bounded however long
+// the stream runs, so unlike Find no timeout applies unless WithTimeout or
+// WithContext sets one. Returning an error from fn stops the search.
+func FindReader(pattern string, r io.Reader, fn func(Match) error, opts ...Option) (*SearchStats, error) {
+	options := defaultOptions()
+	options.timeout = 0
+	for _, opt := range opts {
+		opt(options)
+	}
+	pattern, err := options.resolvePatterns(pattern)
+	if err != nil {
+		return nil, err
+	}
+	if err := options.validate(pattern); err != nil {
+		return nil, err
+	}
+
+	ctx := options.ctx
+	if options.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.timeout)
+		defer cancel()
+	}
+
+	engine := NewSearchEngine(options.searchConfig(StdinName))
+	stats, err := engine.SearchReader(ctx, pattern, StdinName, r, fn)
+	if err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// Follow searches the files under each path that pass the usual filters,
+// reporting their matches to fn, then keeps the files open and reports new
+// matches as they grow, like tail -f. Rotated or truncated files are reopened
+// and searched from the start. It runs until the context set with WithContext
+// or WithTimeout is done, QuitAfter matches have been reported, or fn returns
+// an error; no timeout applies by default. Files created later are not followed.
+func Follow(pattern string, paths []string, fn func(Match) error, opts ...Option) error {
+	options := defaultOptions()
+	options.timeout = 0
+	for _, opt := range opts {
+		opt(options)
+	}
+	pattern, err := options.resolvePatterns(pattern)
+	if err != nil {
+		return err
+	}
+	if err := options.validate(pattern); err != nil {
+		return err
+	}
+
+	ctx := options.ctx
+	if options.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.timeout)
+		defer cancel()
+	}
+
+	var files []string
+	for _, path := range paths {
+		if _, err := os.Stat(path); err != nil {
+			return fmt.Errorf("path error: %w", err)
+		}
+		found, err := NewSearchEngine(options.searchConfig(path)).searchableFiles(ctx)
+		if err != nil {
+			return err
+		}
+		files = append(files, found...)
+	}
+
+	return followFiles(ctx, pattern, options.searchConfig(""), files, fn)
+}
+
+// StdinName is the file name reported for matches found by FindReader
+const StdinName = "<stdin>"
+
+// resolvePatterns gathers pattern, the WithPatterns patterns and those read
+// from pattern files, in that order, and returns the pattern to search for.
+// Several patterns are combined into one regex alternation, quoting fixed
+// strings, and kept in options.patterns to tell which one each match came from.
+// Smart case is decided for each of them on its own.
+func (options *searchOptions) resolvePatterns(pattern string) (string, error) {
+	var patterns []string
+	if pattern != "" {
+		patterns = append(patterns, pattern)
+	}
+	patterns = append(patterns, options.patterns...)
+	for _, path := range options.patternFiles {
+		filePatterns, err := ReadPatternFile(path)
+		if err != nil {
+			return "", err
+		}
+		patterns = append(patterns, filePatterns...)
+	}
+
+	switch len(patterns) {
+	case 0:
+		return "", fmt.Errorf("pattern cannot be empty")
+	case 1:
+		options.patterns = nil
+		return patterns[0], nil
+	}
+
+	for i, p := range patterns {
+		insensitive := options.smartCase && SmartCaseInsensitive(p, options.fixedStrings)
+		if options.fixedStrings {
+			patterns[i] = regexp.QuoteMeta(p)
+		} else if _, _, err := compileRegex(p, options.pcre2); err != nil {
+			return "", fmt.Errorf("invalid regex pattern %q: %w", p, err)
+		}
+		if insensitive {
+			patterns[i] = "(?i:" + patterns[i] + ")"
+		}
+	}
+	options.patterns = patterns
+	options.fixedStrings = false
+	options.smartCase = false
+	return combinePatterns(patterns), nil
+}
+
+// ReadPatternFile reads the patterns in a file, one per line, as
+// WithPatternFile does. Blank lines are skipped.
+func ReadPatternFile(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("pattern file: %w", err)
+	}
+
+	var patterns []string
+	for _, line := range strings.Split(string(stripBOM(data)), "\n") {
+		if line = strings.TrimSuffix(line, "\r"); line != "" {
+			patterns = append(patterns, line)
+		}
+	}
+	return patterns, nil
+}
+
+// finishResults passes the output settings on to results, if any
+func (options *searchOptions) finishResults(results *SearchResults) {
+	if results == nil {
+		return
+	}
+	results.template = options.template
+	results.Matches = Sort(options.sortOrder)(results.Matches)
+	if options.contextBefore() > 0 || options.contextAfter() > 0 {
+		results.contextSeparator = options.contextSep
+	}
+}
+
+// validate checks the pattern and options before a search starts
+func (options *searchOptions) validate(pattern string) error {
+	if options.envErr != nil {
+		return options.envErr
+	}
+
 	// Validate regex pattern early
-	if !isLiteralPattern(pattern) {
-		if _, err := regexp.Compile(pattern); err != nil {
-			return nil, fmt.Errorf("invalid regex pattern: %w", err)
+	if !options.fixedStrings && !isLiteralPattern(pattern) {
+		if _, _, err := compileRegex(pattern, options.pcre2); err != nil {
+			return fmt.Errorf("invalid regex pattern: %w", err)
+		}
+		if options.multiline && options.pcre2 && usesPCRE2Syntax(pattern) {
+			return fmt.Errorf("PCRE2 syntax is not supported in multiline mode")
+		}
+		if options.rejectSlow {
+			if err := checkSlowPattern(pattern, options.searchConfig("")); err != nil {
+				return err
+			}
 		}
 	}
 
-	// Create SearchConfig from options
-	config := SearchConfig{
+	if options.templateText != "" {
+		template, err := ParseOutputTemplate(options.templateText)
+		if err != nil {
+			return err
+		}
+		options.template = template
+	}
+
+	if options.headBytes > 0 && options.tailBytes > 0 {
+		return fmt.Errorf("head and tail byte limits cannot be combined")
+	}
+	if options.normalizer != nil && options.multiline {
+		return fmt.Errorf("unicode normalization is not supported in multiline mode")
+	}
+	if options.lineEnd > 0 && options.lineEnd < options.lineStart {
+		return fmt.Errorf("invalid line range: end %d is before start %d", options.lineEnd, options.lineStart)
+	}
+	return nil
+}
+
+// contextBefore returns the lines of context before a match, where
+// WithBeforeContext overrides WithContextLines even when set to 0
+func (options *searchOptions) contextBefore() int {
+	if options.beforeContext >= 0 {
+		return options.beforeContext
+	}
+	return options.contextLines
+}
+
+// contextAfter returns the lines of context after a match, where
+// WithAfterContext overrides WithContextLines even when set to 0
+func (options *searchOptions) contextAfter() int {
+	if options.afterContext >= 0 {
+		return options.afterContext
+	}
+	return options.contextLines
+}
+
+// searchConfig converts the options into a SearchConfig for path
+func (options *searchOptions) searchConfig(path string) SearchConfig {
+	// SearchConfig only takes positive overrides, so a side turned off
+	// explicitly needs ContextLines out of the way
+	contextLines := options.contextLines
+	if options.beforeContext >= 0 || options.afterContext >= 0 {
+		contextLines = 0
+	}
+	return SearchConfig{
 		SearchPath:      path,
 		MaxWorkers:      options.workers,
+		WorkerGroups:    options.workerGroups,
+		PinCPUs:         options.pinCPUs,
 		BufferSize:      options.bufferSize,
 		MaxResults:      options.maxResults,
+		QuitAfter:       options.quitAfter,
+		StrictErrors:    options.strictErrors,
+		MaxMatchFiles:   options.maxFiles,
+		MaxCountPerFile: options.maxPerFile,
+		SlowestFiles:    options.fileTimings,
+		CountOnly:       options.countOnly,
+		Deterministic:   options.deterministic,
 		UseOptimization: options.optimization,
 		UseGitignore:    options.gitignore,
 		IgnoreCase:      options.ignoreCase,
+		SmartCase:       options.smartCase,
 		IncludeHidden:   options.hidden,
+		SearchAllDirs:   options.allDirs,
 		FollowSymlinks:  options.symlinks,
+		DedupeLinks:     options.dedupeLinks,
+		BinaryMode:      options.binaryMode,
+		ColumnMode:      options.columnMode,
 		Recursive:       options.recursive,
+		MaxDepth:        options.maxDepth,
+		MaxPathDepth:    options.maxPathDepth,
 		FilePattern:     options.filePattern,
-		ContextLines:    options.contextLines,
+		IncludeGlobs:    options.includeGlobs,
+		ExcludeGlobs:    options.excludeGlobs,
+		FileTypes:       options.fileTypes,
+		FileTypesNot:    options.fileTypesNot,
+		ContextLines:    contextLines,
+		BeforeContext:   options.contextBefore(),
+		AfterContext:    options.contextAfter(),
 		Timeout:         options.timeout,
+		Multiline:       options.multiline,
+		FixedStrings:    options.fixedStrings,
+		WordRegexp:      options.wordRegexp,
+		LineRegexp:      options.lineRegexp,
+		PCRE2:           options.pcre2,
+		InvertMatch:     options.invertMatch,
+		SearchMetadata:  options.metadata,
+		FileNamesOnly:   options.fileNamesOnly,
+		LineStart:       options.lineStart,
+		LineEnd:         options.lineEnd,
+		HeadBytes:       options.headBytes,
+		TailBytes:       options.tailBytes,
+
+		CaseInsensitiveGlobs: options.iglobs,
+		IgnoreFiles:          options.ignoreFiles,
+		RotatedLogs:          options.rotatedLogs,
+		SearchCompressed:     options.compressed,
+		ArchiveSearch:        options.archives,
+		Sections:             options.sections,
+		KeyPaths:             options.keyPaths,
+		Patterns:             options.patterns,
+		OmitLineContent:      options.noLineContent,
+		OnlyMatching:         options.onlyMatching,
+		FollowInterval:       options.followEvery,
+		Logger:               options.logger,
+		Normalizer:           options.normalizer,
+		CaseFolder:           options.caseFolder,
 
 		// Streaming search configuration
 		StreamingSearch:    options.streamingSearch,
@@ -147,10 +611,6 @@ func Find(pattern, path string, opts ...Option) (*SearchResults, error) {
 		RegexCaching:              options.regexCaching,
 		MemoryMappedFiles:         options.memoryMappedFiles,
 	}
-
-	// Create and use SearchEngine
-	engine := NewSearchEngine(config)
-	return engine.Search(ctx, pattern)
 }
 
 // Context and Cancellation Options
@@ -173,6 +633,29 @@ func WithWorkers(count int) Option {
 	}
 }
 
+// WithWorkerGroups splits the workers into groups, each searching through
+// an engine of its own with its own matcher, buffers and statistics, so that
+// on machines with many cores workers stop contending for shared counters.
+// The walked files are dealt out to the groups in turn. SuggestedWorkerGroups
+// gives a count for the running machine.
+func WithWorkerGroups(groups int) Option {
+	return func(opts *searchOptions) {
+		if groups > 0 {
+			opts.workerGroups = groups
+		}
+	}
+}
+
+// WithCPUPinning pins the workers of each group to their own contiguous
+// share of the CPUs the process may use, keeping a group's caches warm and
+// on one NUMA node. It is a hint: it only applies on Linux, and only with
+// at least as many CPUs as groups.
+func WithCPUPinning() Option {
+	return func(opts *searchOptions) {
+		opts.pinCPUs = true
+	}
+}
+
 // WithBufferSize sets the I/O buffer size in bytes
 func WithBufferSize(size int) Option {
 	return func(opts *searchOptions) {
@@ -182,7 +665,9 @@ func WithBufferSize(size int) Option {
 	}
 }
 
-// WithMaxResults sets the maximum number of results to return
+// WithMaxResults sets the maximum number of results to return (default 1000).
+// Workers stop taking new files as soon as the files searched hold that many
+// matches, and StoppedEarly is set when the limit cut the search short.
 func WithMaxResults(max int) Option {
 	return func(opts *searchOptions) {
 		if max > 0 {
@@ -191,6 +676,100 @@ func WithMaxResults(max int) Option {
 	}
 }
 
+// WithMaxCountPerFile stops searching each file after matches on n lines, as
+// grep -m does, keeping every match on those lines. Unlike WithMaxResults it
+// applies to each file separately.
+func WithMaxCountPerFile(n int) Option {
+	return func(opts *searchOptions) {
+		if n > 0 {
+			opts.maxPerFile = n
+		}
+	}
+}
+
+// WithQuitAfter stops the entire search as soon as n matches have been found
+// and returns exactly those n. Files still being searched are abandoned, so
+// the stats describe the partial search and StoppedEarly is set.
+func WithQuitAfter(n int) Option {
+	return func(opts *searchOptions) {
+		if n > 0 {
+			opts.quitAfter = n
+		}
+	}
+}
+
+// WithMaxFiles stops the entire search once matches have been found in n
+// distinct files, however many matches each has, and returns all the matches
+// of those files. It suits looking for a handful of example files. Like
+// WithQuitAfter it sets StoppedEarly.
+func WithMaxFiles(n int) Option {
+	return func(opts *searchOptions) {
+		if n > 0 {
+			opts.maxFiles = n
+		}
+	}
+}
+
+// WithFileTimings records how long each file took to search and reports the
+// n slowest, with their sizes, in SearchStats.SlowestFiles. It helps find
+// the files that dominate a slow search.
+func WithFileTimings(n int) Option {
+	return func(opts *searchOptions) {
+		if n > 0 {
+			opts.fileTimings = n
+		}
+	}
+}
+
+// WithCountOnly reports the number of matches per file in SearchResults.Counts
+// instead of collecting Match values. WithMaxResults does not apply since no
+// matches are kept, but WithQuitAfter still stops the search.
+func WithCountOnly() Option {
+	return func(opts *searchOptions) {
+		opts.countOnly = true
+	}
+}
+
+// WithStrictErrors makes the first file or directory that cannot be opened
+// or read abort the search with an error wrapping its *FileError. Without
+// it the search carries on and reports them in SearchResults.Errors.
+func WithStrictErrors() Option {
+	return func(opts *searchOptions) {
+		opts.strictErrors = true
+	}
+}
+
+// WithQuiet only answers whether anything matches, like grep -q: the search
+// stops at the first match anywhere and no Match values are built, so
+// HasMatches is the result.
+func WithQuiet() Option {
+	return func(opts *searchOptions) {
+		opts.countOnly = true
+		opts.quitAfter = 1
+		opts.maxPerFile = 1
+	}
+}
+
+// WithDeterministicOutput reports matches grouped by file in walk order, as a
+// single worker would, however many workers search in parallel. A file's
+// matches wait until every file walked before it is done, trading a little
+// latency for output that is the same on every run, and WithQuitAfter keeps
+// the first matches in walk order.
+func WithDeterministicOutput(enabled bool) Option {
+	return func(opts *searchOptions) {
+		opts.deterministic = enabled
+	}
+}
+
+// WithSort orders the matches by file path, then by line and column, once
+// the search is done. SortPathNatural puts file2 before file10 and
+// SortPathLocale collates non-ASCII paths for the user's locale.
+func WithSort(order SortOrder) Option {
+	return func(opts *searchOptions) {
+		opts.sortOrder = order
+	}
+}
+
 // WithOptimization enables or disables performance optimizations
 func WithOptimization(enabled bool) Option {
 	return func(opts *searchOptions) {
@@ -204,6 +783,7 @@ func WithOptimization(enabled bool) Option {
 func WithIgnoreCase() Option {
 	return func(opts *searchOptions) {
 		opts.ignoreCase = true
+		opts.smartCase = false
 		opts.caseSensitive = false
 	}
 }
@@ -212,10 +792,50 @@ func WithIgnoreCase() Option {
 func WithCaseSensitive() Option {
 	return func(opts *searchOptions) {
 		opts.ignoreCase = false
+		opts.smartCase = false
 		opts.caseSensitive = true
 	}
 }
 
+// WithSmartCase searches case-insensitively when the pattern is all
+// lowercase and case-sensitively when it has an uppercase letter. Each of
+// several patterns is judged on its own. Like ripgrep, the last of
+// WithIgnoreCase, WithCaseSensitive and WithSmartCase wins.
+func WithSmartCase() Option {
+	return func(opts *searchOptions) {
+		opts.ignoreCase = false
+		opts.smartCase = true
+	}
+}
+
+// WithUnicodeNormalization matches the pattern and each line in the given
+// normal form, so text that is composed differently still matches: with
+// norm.NFC or norm.NFD "café" finds both its precomposed and decomposed
+// spellings, and norm.NFKC also folds compatibility characters such as the
+// "ﬁ" ligature. Matches are still reported at their offsets in the original
+// line. It cannot be combined with WithMultiline.
+func WithUnicodeNormalization(form norm.Form) Option {
+	return func(opts *searchOptions) {
+		opts.normalizer = NewUnicodeNormalizer(form)
+	}
+}
+
+// WithUnicodeEngine matches the way UnicodeSearchEngine does across a whole
+// search: lines and pattern are normalized to NFC unless
+// WithUnicodeNormalization picks another form, and when case is ignored they
+// are fully case folded for lang, so "STRASSE" finds "straße" and, with
+// language.Turkish, "ışık" finds "IŞIK". Script classes such as \p{Greek}
+// need no engine, as every regex supports them. It cannot be combined with
+// WithMultiline.
+func WithUnicodeEngine(lang language.Tag) Option {
+	return func(opts *searchOptions) {
+		opts.caseFolder = NewAdvancedCaseFolding(lang)
+		if opts.normalizer == nil {
+			opts.normalizer = NewUnicodeNormalizer(norm.NFC)
+		}
+	}
+}
+
 // WithContextLines sets the number of context lines around matches
 func WithContextLines(lines int) Option {
 	return func(opts *searchOptions) {
@@ -225,6 +845,231 @@ func WithContextLines(lines int) Option {
 	}
 }
 
+// WithBeforeContext sets the number of lines reported before each match,
+// overriding WithContextLines for that side; 0 turns that side off
+func WithBeforeContext(lines int) Option {
+	return func(opts *searchOptions) {
+		if lines >= 0 {
+			opts.beforeContext = lines
+		}
+	}
+}
+
+// WithAfterContext sets the number of lines reported after each match,
+// overriding WithContextLines for that side; 0 turns that side off
+func WithAfterContext(lines int) Option {
+	return func(opts *searchOptions) {
+		if lines >= 0 {
+			opts.afterContext = lines
+		}
+	}
+}
+
+// WithContextSeparator sets the line SearchResults.ContextLines puts
+// between groups of contiguous lines when context is requested, "--" by
+// default like grep. An empty separator only merges overlapping context.
+func WithContextSeparator(separator string) Option {
+	return func(opts *searchOptions) {
+		opts.contextSep = separator
+	}
+}
+
+// WithMultiline lets patterns match across line boundaries, e.g. `func main\(\) \{\n\s+return`.
+// Files are searched as whole buffers and each match reports its start and end line.
+func WithMultiline() Option {
+	return func(opts *searchOptions) {
+		opts.multiline = true
+	}
+}
+
+// WithFixedStrings treats the pattern as a literal string even if it contains
+// regex metacharacters, so searching for "a.b(c)" matches exactly that text
+func WithFixedStrings() Option {
+	return func(opts *searchOptions) {
+		opts.fixedStrings = true
+	}
+}
+
+// WithWordRegexp only reports matches surrounded by word boundaries, as if
+// the pattern were wrapped in \b...\b
+func WithWordRegexp() Option {
+	return func(opts *searchOptions) {
+		opts.wordRegexp = true
+	}
+}
+
+// WithLineRegexp only reports matches that span a whole line, as if the
+// pattern were wrapped in ^...$. It takes precedence over WithWordRegexp.
+func WithLineRegexp() Option {
+	return func(opts *searchOptions) {
+		opts.lineRegexp = true
+	}
+}
+
+// WithPCRE2Syntax enables lookahead (?= and (?!, lookbehind (?<= and (?<!,
+// and backreferences \1 and \k<name>. Patterns using them are matched by a
+// backtracking engine; everything else still goes through Go's regexp.
+// Multiline search and Replace do not support them.
+func WithPCRE2Syntax() Option {
+	return func(opts *searchOptions) {
+		opts.pcre2 = true
+	}
+}
+
+// WithRejectSlowPatterns refuses patterns likely to be pathologically slow in
+// the engine that would match them, such as huge counted repetition or
+// nested repetition under WithPCRE2Syntax, returning ErrSlowPattern instead
+// of searching. Servers running untrusted queries should enable it;
+// ExplainPattern lists the same hazards in PatternReport.Hazards.
+func WithRejectSlowPatterns() Option {
+	return func(opts *searchOptions) {
+		opts.rejectSlow = true
+	}
+}
+
+// WithAuditLog makes a Server record each search request in logger, with
+// the client and user who sent it, the pattern and path, its outcome,
+// duration and how many files and matches it found. Other searches ignore it.
+func WithAuditLog(logger *slog.Logger) Option {
+	return func(opts *searchOptions) {
+		opts.auditLog = logger
+	}
+}
+
+// WithLogger makes searches explain, at debug level, why files and
+// directories were not searched: skipped as hidden, binary or by a glob,
+// ignored by gitignore rules, or cut off by the depth limits. It also notes
+// when a memory mapped search falls back to reading the file.
+func WithLogger(logger *slog.Logger) Option {
+	return func(opts *searchOptions) {
+		opts.logger = logger
+	}
+}
+
+// WithServerLimits bounds the work each Server request may do, so one
+// expensive query cannot starve the others. Other searches ignore it.
+func WithServerLimits(limits ServerLimits) Option {
+	return func(opts *searchOptions) {
+		opts.serverLimits = limits
+	}
+}
+
+// WithAllowedRoots confines a Server to the given directories: requests for
+// paths outside them are refused, and symlinks leading out of them are not
+// searched. Without it a Server is confined to its root. Other searches
+// ignore it.
+func WithAllowedRoots(roots ...string) Option {
+	return func(opts *searchOptions) {
+		opts.allowedRoots = append(opts.allowedRoots, roots...)
+	}
+}
+
+// WithTrustedProxies names the addresses, as IPs or CIDR ranges, whose
+// requests a Server believes about who sent them: the user named by basic
+// auth or an X-Forwarded-User header counts against ServerLimits and is
+// recorded by WithAuditLog as the user. From other addresses the name is only
+// logged as claimed, and clients are told apart by address. Other searches
+// ignore it.
+func WithTrustedProxies(proxies ...string) Option {
+	return func(opts *searchOptions) {
+		opts.proxies = append(opts.proxies, proxies...)
+	}
+}
+
+// WithoutLineContent leaves Match.Content empty, keeping only the matched
+// text in Match.MatchText and the match positions, so memory stays bounded
+// when matching huge lines or when only locations are needed. Context lines
+// are still collected when asked for. Stages that read the line, such as
+// DuplicateLines and Annotate, have nothing to work with.
+func WithoutLineContent() Option {
+	return func(opts *searchOptions) {
+		opts.noLineContent = true
+	}
+}
+
+// WithOnlyMatching cuts Match.Content down to the matched text, like rg -o,
+// with MatchStart and MatchEnd spanning all of it. The text of each capture
+// group is kept in Match.Submatches. Lines with several matches already give
+// one Match each.
+func WithOnlyMatching() Option {
+	return func(opts *searchOptions) {
+		opts.onlyMatching = true
+	}
+}
+
+// WithOutputTemplate sets a text/template that SearchResults.Render writes
+// each match through; see OutputTemplate. An invalid template fails the
+// search before it starts.
+func WithOutputTemplate(text string) Option {
+	return func(opts *searchOptions) {
+		opts.templateText = text
+	}
+}
+
+// WithInvertMatch reports the lines that do not match the pattern instead of the
+// matches. Each such line is returned as a Match at column 1.
+func WithInvertMatch() Option {
+	return func(opts *searchOptions) {
+		opts.invertMatch = true
+	}
+}
+
+// WithMetadata also matches the pattern against each file's base name and, on
+// platforms that support them, its extended attribute values. These matches
+// have Line 0 and a Kind of MatchFileName or MatchXattr. Metadata is not
+// searched in invert mode.
+func WithMetadata() Option {
+	return func(opts *searchOptions) {
+		opts.metadata = true
+	}
+}
+
+// WithFileNamesOnly matches the pattern against each file's base name instead
+// of its contents, like find. File contents are never read, so binary files
+// are included. Matches have Line 0 and a Kind of MatchFileName.
+func WithFileNamesOnly() Option {
+	return func(opts *searchOptions) {
+		opts.fileNamesOnly = true
+	}
+}
+
+// WithLineRange limits the search to lines start through end (1-indexed, inclusive)
+// of each file. An end of 0 searches to the end of the file. Reading stops once
+// the end of the range is reached.
+func WithLineRange(start, end int) Option {
+	return func(opts *searchOptions) {
+		if start < 1 {
+			start = 1
+		}
+		if end < 0 {
+			end = 0
+		}
+		opts.lineStart = start
+		opts.lineEnd = end
+	}
+}
+
+// WithHeadBytes only searches the first n bytes of each file, e.g. for header checks.
+// A line cut off at the end of the range is not searched.
+func WithHeadBytes(n int64) Option {
+	return func(opts *searchOptions) {
+		if n > 0 {
+			opts.headBytes = n
+		}
+	}
+}
+
+// WithTailBytes only searches the last n bytes of each file, seeking past the rest.
+// The partial first line is skipped and line numbers count from the first complete
+// line of the tail, since the lines before it are never read.
+func WithTailBytes(n int64) Option {
+	return func(opts *searchOptions) {
+		if n > 0 {
+			opts.tailBytes = n
+		}
+	}
+}
+
 // WithTimeout sets the search timeout
 func WithTimeout(duration time.Duration) Option {
 	return func(opts *searchOptions) {
@@ -243,6 +1088,62 @@ func WithFilePattern(pattern string) Option {
 	}
 }
 
+// WithIncludeGlobs adds globs selecting which files are searched. Globs
+// support braces such as *.{go,md} and ** across directories, and a leading !
+// excludes matching files instead. When globs conflict the last one wins.
+func WithIncludeGlobs(globs []string) Option {
+	return func(opts *searchOptions) {
+		opts.includeGlobs = append(opts.includeGlobs, globs...)
+	}
+}
+
+// WithCaseInsensitiveGlobs adds include globs matched without regard to case
+func WithCaseInsensitiveGlobs(globs []string) Option {
+	return func(opts *searchOptions) {
+		opts.iglobs = append(opts.iglobs, globs...)
+	}
+}
+
+// WithExcludeGlobs adds globs excluding matching files and directories. They
+// take precedence over include globs.
+func WithExcludeGlobs(globs []string) Option {
+	return func(opts *searchOptions) {
+		opts.excludeGlobs = append(opts.excludeGlobs, globs...)
+	}
+}
+
+// WithFileTypes only searches files of the named types, such as "go" or
+// "web". FileTypes lists the known types. Include globs override types.
+func WithFileTypes(types []string) Option {
+	return func(opts *searchOptions) {
+		opts.fileTypes = append(opts.fileTypes, types...)
+	}
+}
+
+// WithFileTypesNot skips files of the named types
+func WithFileTypesNot(types []string) Option {
+	return func(opts *searchOptions) {
+		opts.fileTypesNot = append(opts.fileTypesNot, types...)
+	}
+}
+
+// WithFollowInterval sets how often Follow polls files for new data
+func WithFollowInterval(interval time.Duration) Option {
+	return func(opts *searchOptions) {
+		if interval > 0 {
+			opts.followEvery = interval
+		}
+	}
+}
+
+// WithIdleMaintenance makes a Watcher re-search changed files only when the
+// machine is idle by policy, such as IdleBalanced
+func WithIdleMaintenance(policy IdlePolicy) Option {
+	return func(opts *searchOptions) {
+		opts.idlePolicy = &policy
+	}
+}
+
 // WithGitignore enables or disables gitignore filtering
 func WithGitignore(enabled bool) Option {
 	return func(opts *searchOptions) {
@@ -250,17 +1151,165 @@ func WithGitignore(enabled bool) Option {
 	}
 }
 
+// WithIgnoreFiles adds ignore file names, such as ".dockerignore", read in
+// each directory like .gitignore. They take precedence over .gitignore,
+// .ignore and .rgignore, which are always read when gitignore filtering is on.
+func WithIgnoreFiles(names ...string) Option {
+	return func(opts *searchOptions) {
+		opts.ignoreFiles = append(opts.ignoreFiles, names...)
+	}
+}
+
+// WithRotatedLogs makes a search of a single log file, such as app.log, also
+// search its rotated siblings (app.log.1, app.log.2.gz, app.log-20240131)
+// oldest first, decompressing them as needed, so that matches come out in
+// chronological order
+func WithRotatedLogs() Option {
+	return func(opts *searchOptions) {
+		opts.rotatedLogs = true
+	}
+}
+
+// WithSearchCompressed searches the contents of gzip, bzip2, zstd, xz, lzma
+// and lz4 files, such as rotated .gz logs, instead of skipping them as
+// binary. They are decompressed as they are read and their decompressed size
+// is counted in BytesScanned. Line ranges and tail byte limits cannot apply to them, so
+// compressed files are skipped when either is set.
+func WithSearchCompressed() Option {
+	return func(opts *searchOptions) {
+		opts.compressed = true
+	}
+}
+
+// WithArchiveSearch searches the members of tar and zip archives (.tar,
+// .tar.gz, .tgz, .zip, .jar and the like) instead of skipping them as
+// binary. Matches in a member are reported under a virtual path such as
+// foo.zip!inner/path.txt, and archives inside archives are entered one level
+// deep. Each member is searched up to DefaultArchiveMaxMemberSize bytes and
+// an archive stops being read after DefaultArchiveMaxTotalSize decompressed
+// bytes, so zip bombs cannot run away.
+func WithArchiveSearch() Option {
+	return func(opts *searchOptions) {
+		opts.archives = true
+	}
+}
+
+// WithSections sets Match.Section on matches in Markdown files to the
+// nearest heading above them, so documentation searches tell which section
+// each hit is in
+func WithSections() Option {
+	return func(opts *searchOptions) {
+		opts.sections = true
+	}
+}
+
+// WithKeyPaths sets Match.KeyPath on matches in JSON and YAML files to the
+// path of the value they fall in, such as spec.containers[0].image, which
+// makes searches useful for auditing configuration
+func WithKeyPaths() Option {
+	return func(opts *searchOptions) {
+		opts.keyPaths = true
+	}
+}
+
+// WithPatterns adds patterns to search for alongside the main one, which may
+// then be empty. A line matches if any pattern does, and Match.PatternIndex
+// tells which one matched.
+func WithPatterns(patterns []string) Option {
+	return func(opts *searchOptions) {
+		opts.patterns = append(opts.patterns, patterns...)
+	}
+}
+
+// WithPatternFile adds the patterns in a file, one per line, after those
+// given with WithPatterns. Blank lines are skipped.
+func WithPatternFile(path string) Option {
+	return func(opts *searchOptions) {
+		opts.patternFiles = append(opts.patternFiles, path)
+	}
+}
+
 // WithHidden includes hidden files in the search
 func WithHidden() Option {
+	return WithHiddenFiles(true)
+}
+
+// WithHiddenFiles sets whether hidden files are searched, so a caller can
+// also turn off what GORIPGREP_HIDDEN turned on
+func WithHiddenFiles(enabled bool) Option {
 	return func(opts *searchOptions) {
-		opts.hidden = true
+		opts.hidden = enabled
 	}
 }
 
+// WithUnrestricted progressively turns off the filters that leave files out
+// of a search, like repeating rg -u. Level 1 stops honoring ignore files,
+// searches node_modules, vendor, build and the other directories the
+// optimized walk skips, and judges binary files by their content alone
+// instead of by extension. Level 2 also searches hidden files, and level 3
+// also searches binary files as text.
+func WithUnrestricted(level int) Option {
+	return func(opts *searchOptions) {
+		if level >= 1 {
+			opts.gitignore = false
+			opts.allDirs = true
+			opts.skipKnownBinary = false
+			opts.fastFileFiltering = false
+		}
+		if level >= 2 {
+			opts.hidden = true
+		}
+		if level >= 3 {
+			opts.binaryMode = BinaryText
+		}
+	}
+}
+
+// WithSearchAllFiles searches every file a name-based filter would skip,
+// including ignored and hidden ones, the same as WithUnrestricted(2). Binary
+// files are still recognized by their content.
+func WithSearchAllFiles() Option {
+	return WithUnrestricted(2)
+}
+
 // WithSymlinks enables following symbolic links
 func WithSymlinks() Option {
+	return WithFollowSymlinks(true)
+}
+
+// WithFollowSymlinks sets whether symbolic links are followed, so a caller
+// can also turn off what GORIPGREP_FOLLOW turned on
+func WithFollowSymlinks(enabled bool) Option {
 	return func(opts *searchOptions) {
-		opts.symlinks = true
+		opts.symlinks = enabled
+	}
+}
+
+// WithDedupeLinks sets whether a file reached by several paths, through hard
+// links or followed symlinks, is searched only once, under the first path
+// walked. It is on by default so results and counts are not inflated.
+func WithDedupeLinks(enabled bool) Option {
+	return func(opts *searchOptions) {
+		opts.dedupeLinks = enabled
+	}
+}
+
+// WithBinaryMode sets what happens to binary files found while walking:
+// BinarySkip leaves them out, as by default, BinaryText searches them like
+// any other file and BinaryReport searches them but reports a single
+// MatchBinary match per matching file instead of its lines
+func WithBinaryMode(mode BinaryMode) Option {
+	return func(opts *searchOptions) {
+		opts.binaryMode = mode
+	}
+}
+
+// WithColumnMode sets what Match.Column counts: bytes from the start of
+// the line with ColumnBytes, the default, or characters with ColumnRunes.
+// Match.RuneColumn holds the character column either way.
+func WithColumnMode(mode ColumnMode) Option {
+	return func(opts *searchOptions) {
+		opts.columnMode = mode
 	}
 }
 
@@ -272,6 +1321,29 @@ func WithRecursive(recursive bool) Option {
 	}
 }
 
+// WithMaxDepth bounds how deep a recursive search descends below the search
+// path: 1 searches only the files directly inside it, 2 those one directory
+// further down and so on, which keeps searches of monorepo roots shallow
+func WithMaxDepth(n int) Option {
+	return func(opts *searchOptions) {
+		if n > 0 {
+			opts.maxDepth = n
+		}
+	}
+}
+
+// WithMaxPathDepth sets how deep a recursive walk may go before it gives up on
+// a branch and counts it in SearchStats.DirsTruncated (default 512, 0 for no
+// limit). It guards against pathological trees such as deeply nested
+// node_modules rather than bounding the search like WithMaxDepth.
+func WithMaxPathDepth(n int) Option {
+	return func(opts *searchOptions) {
+		if n >= 0 {
+			opts.maxPathDepth = n
+		}
+	}
+}
+
 // Streaming Search Configuration Options
 
 // WithStreamingSearch enables or disables streaming search for large files
@@ -342,14 +1414,18 @@ func WithAdaptiveResize(enabled bool) Option {
 	}
 }
 
-// WithMemoryMapping enables or disables memory mapping for large files when available
+// WithMemoryMapping enables or disables memory mapping for large files when
+// available: mmap on Linux and macOS and a file mapping view on Windows.
+// Elsewhere files are always read.
 func WithMemoryMapping(enabled bool) Option {
 	return func(opts *searchOptions) {
 		opts.streamingOptions.UseMemoryMap = enabled
 	}
 }
 
-// WithMaxPatternLength sets the maximum expected pattern length for overlap calculation
+// WithMaxPatternLength sets the overlap reserved for matches spanning chunks in
+// streaming search. Bounded patterns are measured automatically, so this only
+// needs tuning for patterns with unbounded repetition such as .* or \w+.
 func WithMaxPatternLength(maxLength int) Option {
 	return func(opts *searchOptions) {
 		if maxLength > 0 {
@@ -358,6 +1434,19 @@ func WithMaxPatternLength(maxLength int) Option {
 	}
 }
 
+// WithChunkWorkers reads and searches up to workers chunks of a large file at
+// once in line-mode streaming search, with at most memoryBudget bytes of
+// chunks in memory (0 for no limit beyond the workers). Matches keep their
+// order. Multiline and stream searches still read one chunk at a time.
+func WithChunkWorkers(workers int, memoryBudget int64) Option {
+	return func(opts *searchOptions) {
+		if workers > 0 {
+			opts.streamingOptions.Workers = workers
+			opts.streamingOptions.MemoryBudget = memoryBudget
+		}
+	}
+}
+
 // WithProgressCallback sets a callback function for progress reporting during streaming search
 func WithProgressCallback(callback func(bytesProcessed, totalBytes int64, percentage float64)) Option {
 	return func(opts *searchOptions) {
@@ -457,3 +1546,25 @@ func WithPerformanceMode() Option {
 		opts.optimizedWalking = true
 	}
 }
+
+// WithConfigValues makes FindConfigKeys report the values of the keys it
+// finds instead of masking them
+func WithConfigValues() Option {
+	return func(opts *searchOptions) {
+		opts.configValues = true
+	}
+}
+
+// WithDryRun makes Replace report its changes as a diff without modifying any files
+func WithDryRun() Option {
+	return func(opts *searchOptions) {
+		opts.dryRun = true
+	}
+}
+
+// WithBackup makes Replace keep a copy of each original file at path+suffix
+func WithBackup(suffix string) Option {
+	return func(opts *searchOptions) {
+		opts.backupSuffix = suffix
+	}
+}
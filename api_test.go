@@ -1,10 +1,16 @@
 package goripgrep
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
 	"sync"
 	"testing"
@@ -89,7 +95,7 @@ func TestFind(t *testing.T) {
 
 		// Check that context lines are included
 		for _, match := range results.Matches {
-			if len(match.Context) == 0 {
+			if len(match.BeforeContext)+len(match.AfterContext) == 0 {
 				t.Error("Expected context lines to be included")
 			}
 		}
@@ -302,446 +308,2169 @@ func TestFindOptions(t *testing.T) {
 	})
 }
 
-func TestFindErrors(t *testing.T) {
-	t.Run("NonExistentPath", func(t *testing.T) {
-		_, err := Find("test", "/non/existent/path")
-		if err == nil {
-			t.Error("Expected error for non-existent path")
+func TestFindBeforeAfterContext(t *testing.T) {
+	tempDir := t.TempDir()
+	content := "one\ntwo\nthree\nMATCH\nfive\nsix\n"
+	if err := os.WriteFile(filepath.Join(tempDir, "test.txt"), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		opts     []Option
+		expected [2]string
+	}{
+		{"Symmetric", []Option{WithContextLines(1)}, [2]string{"three", "five"}},
+		{"BeforeOnly", []Option{WithBeforeContext(2)}, [2]string{"two,three", ""}},
+		{"AfterOnly", []Option{WithAfterContext(3)}, [2]string{"", "five,six"}},
+		{"Override", []Option{WithContextLines(1), WithBeforeContext(3)}, [2]string{"one,two,three", "five"}},
+		{"OverrideOff", []Option{WithContextLines(1), WithAfterContext(0)}, [2]string{"three", ""}},
+		{"OverrideOffFirst", []Option{WithBeforeContext(0), WithContextLines(2)}, [2]string{"", "five,six"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			results, err := Find("MATCH", tempDir, test.opts...)
+			if err != nil {
+				t.Fatalf("Find failed: %v", err)
+			}
+			if results.Count() != 1 {
+				t.Fatalf("Expected 1 match, got %d", results.Count())
+			}
+
+			match := results.Matches[0]
+			before := strings.Join(match.BeforeContext, ",")
+			after := strings.Join(match.AfterContext, ",")
+			if before != test.expected[0] || after != test.expected[1] {
+				t.Errorf("Expected context %q/%q, got %q/%q", test.expected[0], test.expected[1], before, after)
+			}
+		})
+	}
+}
+
+func TestFindFixedStrings(t *testing.T) {
+	tempDir := t.TempDir()
+	content := "call a.b(c) here\naXb(c)\nA.B(C)\n[unclosed\n"
+	if err := os.WriteFile(filepath.Join(tempDir, "test.txt"), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	// Without fixed strings the dot matches any character
+	results, err := Find("a.b\\(c\\)", tempDir)
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	if results.Count() != 2 {
+		t.Errorf("Expected 2 regex matches, got %d", results.Count())
+	}
+
+	results, err = Find("a.b(c)", tempDir, WithFixedStrings())
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	if results.Count() != 1 || results.Matches[0].Line != 1 || results.Matches[0].Column != 6 {
+		t.Errorf("Expected a single literal match at 1:6, got %+v", results.Matches)
+	}
+
+	results, err = Find("a.b(c)", tempDir, WithFixedStrings(), WithIgnoreCase())
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	if results.Count() != 2 {
+		t.Errorf("Expected 2 case-insensitive literal matches, got %d", results.Count())
+	}
+
+	// Patterns that are invalid regexes are fine as fixed strings
+	results, err = Find("[unclosed", tempDir, WithFixedStrings())
+	if err != nil {
+		t.Fatalf("Find failed for invalid regex in fixed-strings mode: %v", err)
+	}
+	if results.Count() != 1 {
+		t.Errorf("Expected 1 match for [unclosed, got %d", results.Count())
+	}
+}
+
+func TestFindFixedStringsIgnoreCaseLengthChange(t *testing.T) {
+	tempDir := t.TempDir()
+	// Ⱥ lowers to the longer ⱥ and İ to the shorter i
+	content := "ȺȺȺfoo\nİİFOO x\n"
+	if err := os.WriteFile(filepath.Join(tempDir, "test.txt"), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	results, err := Find("foo", tempDir, WithFixedStrings(), WithIgnoreCase())
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	expected := []struct {
+		column int
+		text   string
+	}{
+		{7, "foo"},
+		{5, "FOO"},
+	}
+	if results.Count() != len(expected) {
+		t.Fatalf("Expected %d matches, got %+v", len(expected), results.Matches)
+	}
+	for i, match := range results.Matches {
+		if match.Column != expected[i].column || match.MatchText != expected[i].text {
+			t.Errorf("Line %d: expected %q at column %d, got %q at column %d",
+				match.Line, expected[i].text, expected[i].column, match.MatchText, match.Column)
 		}
-	})
+	}
 
-	t.Run("InvalidRegex", func(t *testing.T) {
-		tempDir, err := os.MkdirTemp("", "goripgrep_test_*")
+	// Matches covering a changed rune still span the whole original rune
+	results, err = Find("ⱥfoo", tempDir, WithFixedStrings(), WithIgnoreCase())
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	if results.Count() != 1 || results.Matches[0].MatchText != "Ⱥfoo" || results.Matches[0].Column != 5 {
+		t.Errorf("Expected Ⱥfoo at column 5, got %+v", results.Matches)
+	}
+}
+
+func TestFindColumnMode(t *testing.T) {
+	tempDir := t.TempDir()
+	content := "naïve café x\nplain x\n"
+	if err := os.WriteFile(filepath.Join(tempDir, "test.txt"), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	// Rune columns are reported whatever the mode
+	runeColumns := []int{12, 7}
+	tests := []struct {
+		mode    ColumnMode
+		columns []int
+	}{
+		{ColumnBytes, []int{14, 7}},
+		{ColumnRunes, runeColumns},
+	}
+	for _, tt := range tests {
+		results, err := Find("x", tempDir, WithColumnMode(tt.mode))
 		if err != nil {
-			t.Fatalf("Failed to create temp directory: %v", err)
+			t.Fatalf("Find failed: %v", err)
 		}
-		defer os.RemoveAll(tempDir)
+		if results.Count() != len(tt.columns) {
+			t.Fatalf("Expected %d matches, got %d", len(tt.columns), results.Count())
+		}
+		for i, match := range results.Matches {
+			if match.Column != tt.columns[i] || match.RuneColumn != runeColumns[i] {
+				t.Errorf("Mode %d: expected column %d and rune column %d on line %d, got %d and %d",
+					tt.mode, tt.columns[i], runeColumns[i], match.Line, match.Column, match.RuneColumn)
+			}
+		}
+	}
 
-		_, err = Find("[invalid", tempDir)
-		if err == nil {
-			t.Error("Expected error for invalid regex pattern")
+	if mode, err := ParseColumnMode("runes"); err != nil || mode != ColumnRunes {
+		t.Errorf("ParseColumnMode(runes) = %v, %v", mode, err)
+	}
+	if _, err := ParseColumnMode("chars"); err == nil {
+		t.Error("Expected an error for an unknown column mode")
+	}
+}
+
+func TestFindHeadTailBytes(t *testing.T) {
+	tempDir := t.TempDir()
+	// Each line is exactly 10 bytes including the newline
+	content := "line0 one\nline1 two\nline2 one\nline3 two\nline4 one\n"
+	if err := os.WriteFile(filepath.Join(tempDir, "test.log"), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		opts     []Option
+		expected []string
+	}{
+		// 25 bytes cover two full lines and part of the third
+		{"Head", []Option{WithHeadBytes(25)}, []string{"1:line0 one"}},
+		{"HeadWholeFile", []Option{WithHeadBytes(1000)}, []string{"1:line0 one", "3:line2 one", "5:line4 one"}},
+		// 25 bytes start inside line2, which is skipped
+		{"Tail", []Option{WithTailBytes(25)}, []string{"2:line4 one"}},
+		{"TailMultiline", []Option{WithTailBytes(25), WithMultiline()}, []string{"2:line4 one"}},
+		// 20 bytes start right after a newline, so line3 is complete
+		{"TailOnLineBoundary", []Option{WithTailBytes(20)}, []string{"2:line4 one"}},
+		{"TailOnLineBoundaryMultiline", []Option{WithTailBytes(20), WithMultiline()}, []string{"2:line4 one"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			results, err := Find("one", tempDir, test.opts...)
+			if err != nil {
+				t.Fatalf("Find failed: %v", err)
+			}
+
+			var got []string
+			for _, match := range results.Matches {
+				got = append(got, fmt.Sprintf("%d:%s", match.Line, match.Content))
+			}
+			sort.Strings(got)
+
+			if fmt.Sprint(got) != fmt.Sprint(test.expected) {
+				t.Errorf("Expected %v, got %v", test.expected, got)
+			}
+		})
+	}
+
+	if _, err := Find("one", tempDir, WithHeadBytes(10), WithTailBytes(10)); err == nil {
+		t.Error("Expected error when combining head and tail byte limits")
+	}
+}
+
+func TestFindWordAndLineRegexp(t *testing.T) {
+	tempDir := t.TempDir()
+	content := "err\nerror here\nsome err, yes\nxerr_\n}\n  }\n"
+	if err := os.WriteFile(filepath.Join(tempDir, "test.txt"), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		pattern  string
+		opts     []Option
+		expected []int
+	}{
+		{"WordLiteral", "err", []Option{WithWordRegexp()}, []int{1, 3}},
+		{"WordFixed", "err", []Option{WithWordRegexp(), WithFixedStrings()}, []int{1, 3}},
+		{"WordRegex", "e.r", []Option{WithWordRegexp()}, []int{1, 3}},
+		{"WordIgnoreCase", "ERR", []Option{WithWordRegexp(), WithIgnoreCase()}, []int{1, 3}},
+		{"LineLiteral", "}", []Option{WithLineRegexp()}, []int{5}},
+		{"LineRegex", `\s*}`, []Option{WithLineRegexp()}, []int{5, 6}},
+		{"LineOverridesWord", "err", []Option{WithLineRegexp(), WithWordRegexp()}, []int{1}},
+		{"LineMultiline", `err\nerror.*`, []Option{WithLineRegexp(), WithMultiline()}, []int{1}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			results, err := Find(test.pattern, tempDir, test.opts...)
+			if err != nil {
+				t.Fatalf("Find failed: %v", err)
+			}
+
+			var lines []int
+			for _, match := range results.Matches {
+				lines = append(lines, match.Line)
+			}
+			sort.Ints(lines)
+
+			if fmt.Sprint(lines) != fmt.Sprint(test.expected) {
+				t.Errorf("Expected lines %v, got %v", test.expected, lines)
+			}
+		})
+	}
+}
+
+func TestFindInvertMatch(t *testing.T) {
+	tempDir := t.TempDir()
+	content := "# comment\nkey=1\n# begin\n# end\nother=2\n"
+	if err := os.WriteFile(filepath.Join(tempDir, "test.ini"), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		pattern  string
+		opts     []Option
+		expected []int
+	}{
+		{"Lines", "^#", []Option{WithInvertMatch()}, []int{2, 5}},
+		{"Multiline", `# begin\n# end`, []Option{WithInvertMatch(), WithMultiline()}, []int{1, 2, 5}},
+		{"HeadBytes", "^#", []Option{WithInvertMatch(), WithHeadBytes(20)}, []int{2}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			results, err := Find(test.pattern, tempDir, test.opts...)
+			if err != nil {
+				t.Fatalf("Find failed: %v", err)
+			}
+
+			var lines []int
+			for _, match := range results.Matches {
+				lines = append(lines, match.Line)
+				if match.Column != 1 {
+					t.Errorf("Expected inverted line %d at column 1, got %d", match.Line, match.Column)
+				}
+			}
+			sort.Ints(lines)
+
+			if fmt.Sprint(lines) != fmt.Sprint(test.expected) {
+				t.Errorf("Expected lines %v, got %v", test.expected, lines)
+			}
+			if results.Stats.NonMatchingLines != int64(len(test.expected)) {
+				t.Errorf("Expected %d non-matching lines in stats, got %d", len(test.expected), results.Stats.NonMatchingLines)
+			}
+		})
+	}
+}
+
+func TestFindByteAccounting(t *testing.T) {
+	tempDir := t.TempDir()
+	content := strings.Repeat("some line of text\n", 100)
+	if err := os.WriteFile(filepath.Join(tempDir, "test.txt"), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	size := int64(len(content))
+
+	tests := []struct {
+		name     string
+		opts     []Option
+		expected int64 // Bytes read
+	}{
+		{"WholeFile", nil, size},
+		{"Multiline", []Option{WithMultiline()}, size},
+		{"HeadBytes", []Option{WithHeadBytes(100)}, 100},
+		{"TailBytes", []Option{WithTailBytes(50)}, 50},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			results, err := Find("text", tempDir, test.opts...)
+			if err != nil {
+				t.Fatalf("Find failed: %v", err)
+			}
+
+			// Each file is counted once, however it is read
+			if results.Stats.FilesScanned != 1 || results.Stats.BytesScanned != size {
+				t.Errorf("Expected 1 file of %d bytes scanned, got %d files and %d bytes",
+					size, results.Stats.FilesScanned, results.Stats.BytesScanned)
+			}
+			if results.Stats.BytesRead != test.expected {
+				t.Errorf("Expected %d bytes read, got %d", test.expected, results.Stats.BytesRead)
+			}
+		})
+	}
+}
+
+func TestFindCountOnly(t *testing.T) {
+	tempDir := t.TempDir()
+	testFiles := map[string]string{
+		"a.txt": "foo bar foo\nbar\nfoo\n",
+		"b.txt": "nothing\nfoo\n",
+		"c.txt": "bar\n",
+	}
+	for filename, content := range testFiles {
+		if err := os.WriteFile(filepath.Join(tempDir, filename), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", filename, err)
+		}
+	}
+
+	// Counts must agree with the matches a regular search collects
+	tests := []struct {
+		name string
+		opts []Option
+	}{
+		{"Plain", nil},
+		{"Invert", []Option{WithInvertMatch()}},
+		{"Multiline", []Option{WithMultiline()}},
+		{"LineRange", []Option{WithLineRange(2, 3)}},
+		{"HeadBytes", []Option{WithHeadBytes(12)}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			expected, err := Find("foo", tempDir, test.opts...)
+			if err != nil {
+				t.Fatalf("Find failed: %v", err)
+			}
+
+			results, err := Find("foo", tempDir, append(test.opts, WithCountOnly())...)
+			if err != nil {
+				t.Fatalf("Find failed: %v", err)
+			}
+
+			if len(results.Matches) != 0 {
+				t.Errorf("Expected no materialized matches, got %d", len(results.Matches))
+			}
+
+			for _, group := range expected.GroupByFile() {
+				if results.Counts[group.File] != len(group.Matches) {
+					t.Errorf("Expected %d matches in %s, got %d", len(group.Matches), group.File, results.Counts[group.File])
+				}
+			}
+			if results.Count() != expected.Count() || results.Stats.MatchesFound != int64(expected.Count()) {
+				t.Errorf("Expected %d matches in total, got %d", expected.Count(), results.Count())
+			}
+		})
+	}
+
+	// Quit-after trims the counts to exactly the limit
+	results, err := Find("foo", tempDir, WithCountOnly(), WithQuitAfter(2))
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	if results.Count() != 2 || !results.Stats.StoppedEarly {
+		t.Errorf("Expected 2 counted matches after stopping early, got %d", results.Count())
+	}
+}
+
+func TestFindPhaseTimings(t *testing.T) {
+	tempDir := t.TempDir()
+	for i := 0; i < 5; i++ {
+		filename := filepath.Join(tempDir, fmt.Sprintf("file%d.txt", i))
+		if err := os.WriteFile(filename, []byte(strings.Repeat("some text here\n", 1000)), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+
+	results, err := Find("text", tempDir, WithMaxResults(100000))
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+
+	phases := results.Stats.Phases
+	if phases.Walk <= 0 || phases.Filter <= 0 || phases.Read <= 0 || phases.Match <= 0 {
+		t.Errorf("Expected walk, filter, read and match time to be recorded, got %+v", phases)
+	}
+	if phases.Decompress != 0 {
+		t.Errorf("Expected no decompression time, got %v", phases.Decompress)
+	}
+}
+
+func TestFindQuitAfter(t *testing.T) {
+	tempDir := t.TempDir()
+	for i := 0; i < 20; i++ {
+		filename := filepath.Join(tempDir, fmt.Sprintf("file%02d.txt", i))
+		if err := os.WriteFile(filename, []byte(strings.Repeat("needle\n", 5)), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+
+	results, err := Find("needle", tempDir, WithQuitAfter(7), WithWorkers(4))
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+
+	if results.Count() != 7 || results.Stats.MatchesFound != 7 {
+		t.Errorf("Expected exactly 7 matches, got %d (stats %d)", results.Count(), results.Stats.MatchesFound)
+	}
+	if !results.Stats.StoppedEarly {
+		t.Error("Expected the search to report stopping early")
+	}
+
+	// A limit that is never reached searches everything
+	results, err = Find("needle", tempDir, WithQuitAfter(500))
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	if results.Count() != 100 || results.Stats.StoppedEarly {
+		t.Errorf("Expected all 100 matches without stopping early, got %d", results.Count())
+	}
+}
+
+func TestFindMaxDepth(t *testing.T) {
+	tempDir := t.TempDir()
+	writeTestFiles(t, tempDir, map[string]string{
+		"a.txt":       "needle\n",
+		"b/b.txt":     "needle\n",
+		"b/c/c.txt":   "needle\n",
+		"b/c/d/d.txt": "needle\n",
+	})
+
+	for _, optimized := range []bool{false, true} {
+		for depth, want := range map[int]int{0: 4, 1: 1, 2: 2, 3: 3, 10: 4} {
+			results, err := Find("needle", tempDir, WithRecursive(true), WithMaxDepth(depth), WithOptimizedWalking(optimized))
+			if err != nil {
+				t.Fatalf("Find failed: %v", err)
+			}
+			if results.Count() != want {
+				t.Errorf("Expected %d matches at depth %d with optimized walking %v, got %d", want, depth, optimized, results.Count())
+			}
 		}
+	}
+
+	// Depth counts from the search path, not the root of the tree
+	results, err := Find("needle", filepath.Join(tempDir, "b"), WithRecursive(true), WithMaxDepth(2))
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	if results.Count() != 2 {
+		t.Errorf("Expected 2 matches two levels below b, got %d", results.Count())
+	}
+}
+
+func TestFindMaxPathDepth(t *testing.T) {
+	tempDir := t.TempDir()
+	deep := strings.Repeat("d/", 40)
+	writeTestFiles(t, tempDir, map[string]string{
+		"a.txt":           "needle\n",
+		deep + "deep.txt": "needle\n",
 	})
 
-	t.Run("ContextCancellation", func(t *testing.T) {
-		tempDir, err := os.MkdirTemp("", "goripgrep_test_*")
+	for _, optimized := range []bool{false, true} {
+		results, err := Find("needle", tempDir, WithRecursive(true), WithOptimizedWalking(optimized))
 		if err != nil {
-			t.Fatalf("Failed to create temp directory: %v", err)
+			t.Fatalf("Find failed: %v", err)
+		}
+		if results.Count() != 2 || results.Stats.DirsTruncated != 0 {
+			t.Errorf("Expected both matches under the default limit with optimized walking %v, got %d matches and %d truncated", optimized, results.Count(), results.Stats.DirsTruncated)
 		}
-		defer os.RemoveAll(tempDir)
 
-		// Create multiple large files to ensure search takes some time
-		largeContent := strings.Repeat("test line with content\n", 50000)
-		for i := 0; i < 10; i++ {
-			testFile := filepath.Join(tempDir, fmt.Sprintf("large_%d.txt", i))
-			if err := os.WriteFile(testFile, []byte(largeContent), 0644); err != nil {
-				t.Fatalf("Failed to create large test file: %v", err)
-			}
+		results, err = Find("needle", tempDir, WithRecursive(true), WithMaxPathDepth(10), WithOptimizedWalking(optimized))
+		if err != nil {
+			t.Fatalf("Find failed: %v", err)
+		}
+		if results.Count() != 1 || results.Stats.DirsTruncated != 1 {
+			t.Errorf("Expected the deep branch to be truncated with optimized walking %v, got %d matches and %d truncated", optimized, results.Count(), results.Stats.DirsTruncated)
 		}
+	}
+}
 
-		// Create context with very short timeout
-		ctx, cancel := context.WithTimeout(context.Background(), 1*time.Millisecond)
-		defer cancel()
+func TestFindSymlinkLoop(t *testing.T) {
+	tempDir := t.TempDir()
+	writeTestFiles(t, tempDir, map[string]string{
+		"a.txt":     "needle\n",
+		"sub/b.txt": "needle\n",
+	})
+	for link, target := range map[string]string{"sub/up": "..", "sub/self": ".", "loop": tempDir} {
+		if err := os.Symlink(target, filepath.Join(tempDir, link)); err != nil {
+			t.Skipf("Symlinks not supported: %v", err)
+		}
+	}
 
-		// Start search immediately and expect timeout
-		_, err = Find("test", tempDir, WithContext(ctx))
+	results, err := Find("needle", tempDir, WithRecursive(true), WithSymlinks(), WithMaxPathDepth(0))
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	if results.Count() != 2 {
+		t.Errorf("Expected each file once, got %+v", results.Matches)
+	}
+	if results.Stats.DirsTruncated != 3 {
+		t.Errorf("Expected the 3 looping links to be counted as truncated, got %d", results.Stats.DirsTruncated)
+	}
+}
+
+func TestFindBinaryMode(t *testing.T) {
+	tempDir := t.TempDir()
+	writeTestFiles(t, tempDir, map[string]string{
+		"a.bin": "head\x00\x01\x02 needle one\nneedle two\n",
+		"b.txt": "needle text\n",
+	})
+	binary := filepath.Join(tempDir, "a.bin")
+
+	for _, performance := range []bool{false, true} {
+		opts := []Option{}
+		if performance {
+			opts = append(opts, WithPerformanceMode())
+		}
+
+		// Binary files are skipped by default
+		results, err := Find("needle", tempDir, opts...)
+		if err != nil {
+			t.Fatalf("Find failed: %v", err)
+		}
+		if results.Count() != 1 || results.Matches[0].File == binary {
+			t.Errorf("Expected the binary file to be skipped, got %+v", results.Matches)
+		}
+
+		// searched as text,
+		results, err = Find("needle", tempDir, append(opts, WithBinaryMode(BinaryText))...)
+		if err != nil {
+			t.Fatalf("Find failed: %v", err)
+		}
+		if results.Count() != 3 {
+			t.Errorf("Expected 3 matches searching binaries as text, got %+v", results.Matches)
+		}
+
+		// or reported once without their content
+		results, err = Find("needle", tempDir, append(opts, WithBinaryMode(BinaryReport))...)
+		if err != nil {
+			t.Fatalf("Find failed: %v", err)
+		}
+		var reported []Match
+		for _, match := range results.Matches {
+			if match.File == binary {
+				reported = append(reported, match)
+			}
+		}
+		if results.Count() != 2 || len(reported) != 1 || reported[0].Kind != MatchBinary || reported[0].Content != "" || reported[0].Line != 1 {
+			t.Errorf("Expected one binary match without content, got %+v", results.Matches)
+		}
+
+		// Binary files that do not match are not reported
+		results, err = Find("absent", tempDir, append(opts, WithBinaryMode(BinaryReport))...)
+		if err != nil || results.Count() != 0 {
+			t.Errorf("Expected no matches, got %+v, %v", results, err)
+		}
+	}
+}
+
+func TestFindUTF8Text(t *testing.T) {
+	tempDir := t.TempDir()
+	writeTestFiles(t, tempDir, map[string]string{
+		// The 512 byte sample ends halfway through an é
+		"utf8.txt": "x" + strings.Repeat("\u00e9", 300) + " needle\n",
+		// Latin-1 is not valid UTF-8
+		"latin1.txt": strings.Repeat("\xe9", 300) + " needle\n",
+	})
+
+	for _, performance := range []bool{false, true} {
+		opts := []Option{}
+		if performance {
+			opts = append(opts, WithPerformanceMode())
+		}
+		results, err := Find("needle", tempDir, opts...)
+		if err != nil {
+			t.Fatalf("Find failed: %v", err)
+		}
+		if results.Count() != 1 || filepath.Base(results.Matches[0].File) != "utf8.txt" {
+			t.Errorf("Expected only the UTF-8 file to be searched, got %+v", results.Matches)
+		}
+	}
+}
+
+func TestFindUnrestricted(t *testing.T) {
+	tempDir := t.TempDir()
+	writeTestFiles(t, tempDir, map[string]string{
+		".gitignore":        "ignored.txt\n",
+		"plain.txt":         "needle\n",
+		"ignored.txt":       "needle\n",
+		"node_modules/a.js": "needle\n",
+		"doc.pdf":           "needle in plain text\n",
+		".hidden.txt":       "needle\n",
+		"data.bin":          "head\x00\x01 needle\n",
+	})
+
+	files := func(t *testing.T, opts ...Option) []string {
+		t.Helper()
+		results, err := Find("needle", tempDir, append(opts, WithRecursive(true))...)
+		if err != nil {
+			t.Fatalf("Find failed: %v", err)
+		}
+		var names []string
+		for _, match := range results.Matches {
+			rel, _ := filepath.Rel(tempDir, match.File)
+			names = append(names, filepath.ToSlash(rel))
+		}
+		slices.Sort(names)
+		return names
+	}
+
+	tests := []struct {
+		name string
+		opts []Option
+		want []string
+	}{
+		{name: "default", want: []string{"plain.txt"}},
+		{name: "level 1", opts: []Option{WithUnrestricted(1)}, want: []string{"doc.pdf", "ignored.txt", "node_modules/a.js", "plain.txt"}},
+		{name: "level 2", opts: []Option{WithUnrestricted(2)}, want: []string{".hidden.txt", "doc.pdf", "ignored.txt", "node_modules/a.js", "plain.txt"}},
+		{name: "search all files", opts: []Option{WithSearchAllFiles()}, want: []string{".hidden.txt", "doc.pdf", "ignored.txt", "node_modules/a.js", "plain.txt"}},
+		{name: "level 3", opts: []Option{WithUnrestricted(3)}, want: []string{".hidden.txt", "data.bin", "doc.pdf", "ignored.txt", "node_modules/a.js", "plain.txt"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := files(t, tt.opts...); !slices.Equal(got, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestFindOnlyMatching(t *testing.T) {
+	tempDir := t.TempDir()
+	writeTestFiles(t, tempDir, map[string]string{
+		"a.txt": "mail ann@example.com or bob@test.org today\nnothing here\n",
+	})
+	file := filepath.Join(tempDir, "a.txt")
+
+	tests := []struct {
+		name       string
+		pattern    string
+		opts       []Option
+		want       []string
+		submatches [][]string
+	}{
+		{name: "literal", pattern: "example", want: []string{"example"}, submatches: [][]string{nil}},
+		{name: "regex", pattern: `(\w+)@(\w+)\.\w+`, want: []string{"ann@example.com", "bob@test.org"}, submatches: [][]string{{"ann", "example"}, {"bob", "test"}}},
+		{name: "optional group", pattern: `(ann|bob)@(x)?\w+`, want: []string{"ann@example", "bob@test"}, submatches: [][]string{{"ann", ""}, {"bob", ""}}},
+		{name: "backtracking", pattern: `(\w+)(?=@test)`, opts: []Option{WithPCRE2Syntax()}, want: []string{"bob"}, submatches: [][]string{{"bob"}}},
+		{name: "multiline", pattern: `(today)\nnothing`, opts: []Option{WithMultiline()}, want: []string{"today\nnothing"}, submatches: [][]string{{"today"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := FindInFile(tt.pattern, file, append(tt.opts, WithOnlyMatching())...)
+			if err != nil {
+				t.Fatalf("FindInFile failed: %v", err)
+			}
+			if results.Count() != len(tt.want) {
+				t.Fatalf("Expected %d matches, got %+v", len(tt.want), results.Matches)
+			}
+			for i, match := range results.Matches {
+				if match.Content != tt.want[i] || match.MatchText != tt.want[i] || match.MatchStart != 0 || match.MatchEnd != len(tt.want[i]) {
+					t.Errorf("Expected only %q, got %+v", tt.want[i], match)
+				}
+				if !slices.Equal(match.Submatches, tt.submatches[i]) {
+					t.Errorf("Expected submatches %q, got %q", tt.submatches[i], match.Submatches)
+				}
+			}
+		})
+	}
+
+	// Columns still point into the line
+	results, err := FindInFile("bob", file, WithOnlyMatching())
+	if err != nil || results.Count() != 1 || results.Matches[0].Column != 25 {
+		t.Errorf("Expected bob at column 25, got %+v, %v", results, err)
+	}
+}
+
+func TestFindMetadata(t *testing.T) {
+	tempDir := t.TempDir()
+	testFiles := map[string]string{
+		"invoice-2024.txt": "nothing to see\n",
+		"notes.txt":        "unpaid invoice\n",
+	}
+	for filename, content := range testFiles {
+		if err := os.WriteFile(filepath.Join(tempDir, filename), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", filename, err)
+		}
+	}
+
+	// File names are only searched when requested
+	results, err := Find("invoice", tempDir)
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	if results.Count() != 1 || results.Matches[0].Kind != MatchContent {
+		t.Fatalf("Expected a single content match, got %+v", results.Matches)
+	}
+
+	results, err = Find("invoice", tempDir, WithMetadata())
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+
+	groups := results.GroupByFile()
+	if len(groups) != 2 || len(groups[0].Matches) != 1 || len(groups[1].Matches) != 1 {
+		t.Fatalf("Expected one match in each file, got %+v", groups)
+	}
+
+	nameMatch := groups[0].Matches[0]
+	if nameMatch.Kind != MatchFileName || nameMatch.Line != 0 || nameMatch.Content != "invoice-2024.txt" {
+		t.Errorf("Expected a file name match, got %+v", nameMatch)
+	}
+	if groups[1].Matches[0].Kind != MatchContent {
+		t.Errorf("Expected a content match in notes.txt, got %+v", groups[1].Matches[0])
+	}
+}
+
+func TestFindFileNamesOnly(t *testing.T) {
+	tempDir := t.TempDir()
+	testFiles := map[string][]byte{
+		"main.go":      []byte("package main\n"),
+		"main_test.go": []byte("package main\n"),
+		"data_test.go": []byte{0x00, 0x01, 0x02}, // Binary content is not read
+		"README.md":    []byte("main_test.go\n"),
+	}
+	for filename, content := range testFiles {
+		if err := os.WriteFile(filepath.Join(tempDir, filename), content, 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", filename, err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		opts     []Option
+		expected []string
+	}{
+		{"Match", []Option{WithFileNamesOnly()}, []string{"data_test.go", "main_test.go"}},
+		{"Invert", []Option{WithFileNamesOnly(), WithInvertMatch()}, []string{"README.md", "main.go"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			results, err := Find(`_test\.go$`, tempDir, test.opts...)
+			if err != nil {
+				t.Fatalf("Find failed: %v", err)
+			}
+
+			var names []string
+			for _, group := range results.GroupByFile() {
+				if group.Matches[0].Kind != MatchFileName {
+					t.Errorf("Expected a file name match, got %+v", group.Matches[0])
+				}
+				names = append(names, filepath.Base(group.File))
+			}
+
+			if fmt.Sprint(names) != fmt.Sprint(test.expected) {
+				t.Errorf("Expected %v, got %v", test.expected, names)
+			}
+		})
+	}
+}
+
+func TestFindLineRange(t *testing.T) {
+	tempDir := t.TempDir()
+	var builder strings.Builder
+	for i := 1; i <= 20; i++ {
+		fmt.Fprintf(&builder, "line %d marker\n", i)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "test.txt"), []byte(builder.String()), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		start     int
+		end       int
+		multiline bool
+		expected  []int
+	}{
+		{"Head", 1, 3, false, []int{1, 2, 3}},
+		{"Middle", 9, 11, false, []int{9, 10, 11}},
+		{"OpenEnded", 19, 0, false, []int{19, 20}},
+		{"Multiline", 4, 6, true, []int{4, 5}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pattern := "marker"
+			opts := []Option{WithLineRange(test.start, test.end)}
+			if test.multiline {
+				// Matches spanning past the end of the range are not reported
+				pattern = `marker\nline`
+				opts = append(opts, WithMultiline())
+			}
+
+			results, err := Find(pattern, tempDir, opts...)
+			if err != nil {
+				t.Fatalf("Find failed: %v", err)
+			}
+
+			var lines []int
+			for _, match := range results.Matches {
+				lines = append(lines, match.Line)
+			}
+			sort.Ints(lines)
+
+			if fmt.Sprint(lines) != fmt.Sprint(test.expected) {
+				t.Errorf("Expected lines %v, got %v", test.expected, lines)
+			}
+		})
+	}
+
+	if _, err := Find("marker", tempDir, WithLineRange(10, 5)); err == nil {
+		t.Error("Expected error for a line range that ends before it starts")
+	}
+}
+
+func TestFindErrors(t *testing.T) {
+	t.Run("NonExistentPath", func(t *testing.T) {
+		_, err := Find("test", "/non/existent/path")
+		if err == nil {
+			t.Error("Expected error for non-existent path")
+		}
+	})
+
+	t.Run("InvalidRegex", func(t *testing.T) {
+		tempDir, err := os.MkdirTemp("", "goripgrep_test_*")
+		if err != nil {
+			t.Fatalf("Failed to create temp directory: %v", err)
+		}
+		defer os.RemoveAll(tempDir)
+
+		_, err = Find("[invalid", tempDir)
+		if err == nil {
+			t.Error("Expected error for invalid regex pattern")
+		}
+	})
+
+	t.Run("ContextCancellation", func(t *testing.T) {
+		tempDir, err := os.MkdirTemp("", "goripgrep_test_*")
+		if err != nil {
+			t.Fatalf("Failed to create temp directory: %v", err)
+		}
+		defer os.RemoveAll(tempDir)
+
+		// Create multiple large files to ensure search takes some time
+		largeContent := strings.Repeat("test line with content\n", 50000)
+		for i := 0; i < 10; i++ {
+			testFile := filepath.Join(tempDir, fmt.Sprintf("large_%d.txt", i))
+			if err := os.WriteFile(testFile, []byte(largeContent), 0644); err != nil {
+				t.Fatalf("Failed to create large test file: %v", err)
+			}
+		}
+
+		// Create context with very short timeout
+		ctx, cancel := context.WithTimeout(context.Background(), 1*time.Millisecond)
+		defer cancel()
+
+		// Start search immediately and expect timeout
+		_, err = Find("test", tempDir, WithContext(ctx))
+
+		// The search should either timeout or be canceled
+		if err == nil {
+			// If no error, the search completed too quickly - try with even shorter timeout
+			ctx2, cancel2 := context.WithCancel(context.Background())
+			cancel2() // Cancel immediately
+
+			_, err = Find("test", tempDir, WithContext(ctx2))
+			if err == nil {
+				t.Skip("Search completes too quickly to test cancellation reliably")
+				return
+			}
+		}
+
+		// Check if it's a context error (could be Canceled or DeadlineExceeded)
+		if err != context.Canceled && err != context.DeadlineExceeded {
+			// Check if the error contains context-related messages
+			errStr := err.Error()
+			if !strings.Contains(errStr, "context") && !strings.Contains(errStr, "canceled") && !strings.Contains(errStr, "deadline") {
+				t.Errorf("Expected context error, got %v", err)
+			}
+		}
+	})
+}
+
+func TestFindMatchSpans(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "spans.txt")
+	content := "say hallo hello\nstart\nend here\n"
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		pattern  string
+		opts     []Option
+		expected []string // Content[MatchStart:MatchEnd] of each match
+	}{
+		{"Literal", "hello", nil, []string{"hello"}},
+		{"Regex", `h.llo`, nil, []string{"hallo", "hello"}},
+		{"Optimized", `h.llo`, []Option{WithPerformanceMode()}, []string{"hallo", "hello"}},
+		{"Multiline", `start\nend`, []Option{WithMultiline()}, []string{"start\nend"}},
+		{"Invert", "l", []Option{WithInvertMatch()}, []string{"", ""}},
+		{"FileName", `spans\.txt`, []Option{WithFileNamesOnly()}, []string{"spans.txt"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			results, err := Find(test.pattern, file, test.opts...)
+			if err != nil {
+				t.Fatalf("Find failed: %v", err)
+			}
+
+			var got []string
+			for _, match := range results.Matches {
+				if match.MatchStart > match.MatchEnd || match.MatchEnd > len(match.Content) {
+					t.Fatalf("Span %d:%d out of range for %q", match.MatchStart, match.MatchEnd, match.Content)
+				}
+				if match.MatchStart != match.Column-1 {
+					t.Errorf("MatchStart %d does not agree with column %d", match.MatchStart, match.Column)
+				}
+				got = append(got, match.Content[match.MatchStart:match.MatchEnd])
+			}
+			if fmt.Sprint(got) != fmt.Sprint(test.expected) {
+				t.Errorf("Expected spans %q, got %q", test.expected, got)
+			}
+		})
+	}
+
+	t.Run("Reader", func(t *testing.T) {
+		var got []string
+		// Spans are byte offsets, so multi-byte text before a match counts
+		_, err := FindReader(`h.llo`, strings.NewReader("say héllo hello\n"), func(match Match) error {
+			got = append(got, match.Content[match.MatchStart:match.MatchEnd])
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("FindReader failed: %v", err)
+		}
+		if fmt.Sprint(got) != "[héllo hello]" {
+			t.Errorf("Expected spans [héllo hello], got %q", got)
+		}
+	})
+
+	t.Run("AllSpans", func(t *testing.T) {
+		// Every match on a line lists the spans of all of them
+		expected := []Span{{Start: 4, End: 9}, {Start: 10, End: 15}}
+		for _, opts := range [][]Option{nil, {WithPerformanceMode()}} {
+			results, err := Find(`h.llo`, file, opts...)
+			if err != nil {
+				t.Fatalf("Find failed: %v", err)
+			}
+			for _, match := range results.Matches {
+				if !slices.Equal(match.Spans, expected) {
+					t.Errorf("Expected spans %v, got %v", expected, match.Spans)
+				}
+			}
+		}
+
+		results, err := Find("l", file, WithInvertMatch())
+		if err != nil {
+			t.Fatalf("Find failed: %v", err)
+		}
+		for _, match := range results.Matches {
+			if match.Spans != nil {
+				t.Errorf("Expected no spans for an inverted match, got %v", match.Spans)
+			}
+		}
+	})
+}
+
+func TestSearchResults(t *testing.T) {
+	// Create a temporary directory with test files
+	tempDir, err := os.MkdirTemp("", "goripgrep_test_*")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	// Create test files
+	testFiles := map[string]string{
+		"file1.txt": "test line 1\ntest line 2",
+		"file2.txt": "another test\nno match here",
+		"file3.txt": "final test line",
+	}
+
+	for filename, content := range testFiles {
+		filePath := filepath.Join(tempDir, filename)
+		if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", filename, err)
+		}
+	}
+
+	results, err := Find("test", tempDir)
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+
+	t.Run("HasMatches", func(t *testing.T) {
+		if !results.HasMatches() {
+			t.Error("Expected HasMatches() to return true")
+		}
+	})
+
+	t.Run("Count", func(t *testing.T) {
+		count := results.Count()
+		if count == 0 {
+			t.Error("Expected Count() to return non-zero")
+		}
+		if count != len(results.Matches) {
+			t.Errorf("Expected Count() to equal len(Matches), got %d vs %d", count, len(results.Matches))
+		}
+	})
+
+	t.Run("Files", func(t *testing.T) {
+		files := results.Files()
+		if len(files) == 0 {
+			t.Error("Expected Files() to return non-empty slice")
+		}
+
+		// Check that all files are unique
+		fileSet := make(map[string]bool)
+		for _, file := range files {
+			if fileSet[file] {
+				t.Errorf("Duplicate file in Files(): %s", file)
+			}
+			fileSet[file] = true
+		}
+	})
+
+	t.Run("Stats", func(t *testing.T) {
+		stats := results.Stats
+		if stats.FilesScanned == 0 {
+			t.Error("Expected FilesScanned > 0")
+		}
+		if stats.BytesScanned == 0 {
+			t.Error("Expected BytesScanned > 0")
+		}
+		if stats.Duration == 0 {
+			t.Error("Expected Duration > 0")
+		}
+		if stats.MatchesFound == 0 {
+			t.Error("Expected MatchesFound > 0")
+		}
+	})
+
+	t.Run("Query", func(t *testing.T) {
+		if results.Query != "test" {
+			t.Errorf("Expected Query='test', got '%s'", results.Query)
+		}
+	})
+}
+
+func BenchmarkFind(b *testing.B) {
+	// Create a temporary directory with test files
+	tempDir, err := os.MkdirTemp("", "goripgrep_bench_*")
+	if err != nil {
+		b.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	// Create a larger test file
+	content := strings.Repeat("This is a test line with some content to search through.\n", 1000)
+	testFile := filepath.Join(tempDir, "large_test.txt")
+	if err := os.WriteFile(testFile, []byte(content), 0644); err != nil {
+		b.Fatalf("Failed to create test file: %v", err)
+	}
+
+	b.Run("BasicSearch", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_, err := Find("test", tempDir)
+			if err != nil {
+				b.Fatalf("Find failed: %v", err)
+			}
+		}
+	})
+
+	b.Run("OptimizedSearch", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_, err := Find("test", tempDir, WithOptimization(true))
+			if err != nil {
+				b.Fatalf("Find failed: %v", err)
+			}
+		}
+	})
+
+	b.Run("MultiWorkerSearch", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_, err := Find("test", tempDir, WithWorkers(8))
+			if err != nil {
+				b.Fatalf("Find failed: %v", err)
+			}
+		}
+	})
+}
+
+func TestStreamingSearchConfiguration(t *testing.T) {
+	// Create a large test file to trigger streaming search
+	content := strings.Repeat("streaming search test pattern\n", 10000) // ~290KB
+	tmpFile, err := os.CreateTemp("", "large_test*.txt")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, err := tmpFile.WriteString(content); err != nil {
+		t.Fatalf("Failed to write to temp file: %v", err)
+	}
+	tmpFile.Close()
+
+	tests := []struct {
+		name    string
+		options []Option
+		pattern string
+		wantErr bool
+	}{
+		{
+			name: "Enable streaming search with custom chunk size",
+			options: []Option{
+				WithStreamingSearch(true),
+				WithLargeSizeThreshold(100 * 1024), // 100KB threshold
+				WithChunkSize(64 * 1024),           // 64KB chunks
+			},
+			pattern: "streaming",
+			wantErr: false,
+		},
+		{
+			name: "Custom overlap size",
+			options: []Option{
+				WithStreamingSearch(true),
+				WithLargeSizeThreshold(100 * 1024),
+				WithOverlapSize(32 * 1024), // 32KB overlap
+			},
+			pattern: "search",
+			wantErr: false,
+		},
+		{
+			name: "Disable adaptive resize",
+			options: []Option{
+				WithStreamingSearch(true),
+				WithLargeSizeThreshold(100 * 1024),
+				WithAdaptiveResize(false),
+			},
+			pattern: "test",
+			wantErr: false,
+		},
+		{
+			name: "Disable memory mapping",
+			options: []Option{
+				WithStreamingSearch(true),
+				WithLargeSizeThreshold(100 * 1024),
+				WithMemoryMapping(false),
+			},
+			pattern: "pattern",
+			wantErr: false,
+		},
+		{
+			name: "Custom memory threshold",
+			options: []Option{
+				WithStreamingSearch(true),
+				WithLargeSizeThreshold(100 * 1024),
+				WithMemoryThreshold(256 * 1024 * 1024), // 256MB
+			},
+			pattern: "streaming",
+			wantErr: false,
+		},
+		{
+			name: "Custom min/max chunk sizes",
+			options: []Option{
+				WithStreamingSearch(true),
+				WithLargeSizeThreshold(100 * 1024),
+				WithMinChunkSize(16 * 1024),         // 16KB min
+				WithMaxChunkSize(128 * 1024 * 1024), // 128MB max
+			},
+			pattern: "search",
+			wantErr: false,
+		},
+		{
+			name: "Disable streaming search",
+			options: []Option{
+				WithStreamingSearch(false),
+				WithLargeSizeThreshold(100 * 1024),
+			},
+			pattern: "streaming",
+			wantErr: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := Find(tt.pattern, tmpFile.Name(), tt.options...)
+
+			if tt.wantErr && err == nil {
+				t.Error("Expected error but got none")
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+
+			if err == nil {
+				if !results.HasMatches() {
+					t.Error("Expected matches but got none")
+				}
+				if results.Count() == 0 {
+					t.Error("Expected match count > 0")
+				}
+			}
+		})
+	}
+}
+
+func TestProgressCallbackIntegration(t *testing.T) {
+	// Create a large test file
+	content := strings.Repeat("progress callback test line\n", 5000) // ~135KB
+	tmpFile, err := os.CreateTemp("", "progress_test*.txt")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, err := tmpFile.WriteString(content); err != nil {
+		t.Fatalf("Failed to write to temp file: %v", err)
+	}
+	tmpFile.Close()
+
+	var progressUpdates []float64
+	var mu sync.Mutex
+
+	progressCallback := func(bytesProcessed, totalBytes int64, percentage float64) {
+		mu.Lock()
+		defer mu.Unlock()
+		progressUpdates = append(progressUpdates, percentage)
+	}
+
+	results, err := Find("progress", tmpFile.Name(),
+		WithStreamingSearch(true),
+		WithLargeSizeThreshold(50*1024), // 50KB threshold
+		WithChunkSize(16*1024),          // 16KB chunks for more progress updates
+		WithProgressCallback(progressCallback),
+	)
+
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+
+	if !results.HasMatches() {
+		t.Error("Expected matches but got none")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(progressUpdates) == 0 {
+		t.Error("Expected progress updates but got none")
+	}
+
+	// Verify progress updates make sense
+	if len(progressUpdates) > 1 {
+		for i := 1; i < len(progressUpdates); i++ {
+			if progressUpdates[i] < progressUpdates[i-1] {
+				t.Errorf("Progress went backwards: %f to %f", progressUpdates[i-1], progressUpdates[i])
+			}
+		}
+	}
+
+	// Should end at 100%
+	finalProgress := progressUpdates[len(progressUpdates)-1]
+	if finalProgress != 100.0 {
+		t.Errorf("Expected final progress to be 100%%, got %f%%", finalProgress)
+	}
+}
+
+func TestProgressCallbackDetailedIntegration(t *testing.T) {
+	// Create a test file large enough to trigger streaming search
+	content := strings.Repeat("line with search pattern\n", 5000) // ~125KB file
+	tmpFile, err := os.CreateTemp("", "detailed_progress_test_*.txt")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, err := tmpFile.WriteString(content); err != nil {
+		t.Fatalf("Failed to write test content: %v", err)
+	}
+	tmpFile.Close()
+
+	// Track detailed progress updates
+	var progressUpdates []ProgressInfo
+	var progressMutex sync.Mutex
+
+	// Search with detailed progress reporting
+	results, err := Find("search", tmpFile.Name(),
+		WithStreamingSearch(true),
+		WithLargeSizeThreshold(50*1024), // 50KB threshold to ensure streaming is used
+		WithChunkSize(16*1024),          // 16KB chunks for multiple updates
+		WithMaxResults(5000),
+		WithProgressCallbackDetailed(func(info ProgressInfo) {
+			progressMutex.Lock()
+			progressUpdates = append(progressUpdates, info)
+			progressMutex.Unlock()
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+
+	// Verify we found matches
+	if len(results.Matches) != 5000 {
+		t.Errorf("Expected 5000 matches, got %d", len(results.Matches))
+	}
+
+	// Verify detailed progress updates were received
+	progressMutex.Lock()
+	defer progressMutex.Unlock()
+
+	if len(progressUpdates) == 0 {
+		t.Error("No detailed progress updates received")
+	}
+
+	// Verify the final progress update
+	finalUpdate := progressUpdates[len(progressUpdates)-1]
+
+	if finalUpdate.Percentage != 100.0 {
+		t.Errorf("Final percentage should be 100.0, got %f", finalUpdate.Percentage)
+	}
+
+	if finalUpdate.ProcessingRate <= 0 {
+		t.Error("Processing rate should be positive")
+	}
 
-		// The search should either timeout or be canceled
-		if err == nil {
-			// If no error, the search completed too quickly - try with even shorter timeout
-			ctx2, cancel2 := context.WithCancel(context.Background())
-			cancel2() // Cancel immediately
+	if finalUpdate.MatchesFound != 5000 {
+		t.Errorf("Expected 5000 matches found in progress, got %d", finalUpdate.MatchesFound)
+	}
 
-			_, err = Find("test", tempDir, WithContext(ctx2))
-			if err == nil {
-				t.Skip("Search completes too quickly to test cancellation reliably")
-				return
-			}
+	// Verify progress increased monotonically
+	for i := 1; i < len(progressUpdates); i++ {
+		if progressUpdates[i].BytesProcessed < progressUpdates[i-1].BytesProcessed {
+			t.Errorf("Progress bytes not monotonic at update %d: %d < %d",
+				i, progressUpdates[i].BytesProcessed, progressUpdates[i-1].BytesProcessed)
 		}
+	}
 
-		// Check if it's a context error (could be Canceled or DeadlineExceeded)
-		if err != context.Canceled && err != context.DeadlineExceeded {
-			// Check if the error contains context-related messages
-			errStr := err.Error()
-			if !strings.Contains(errStr, "context") && !strings.Contains(errStr, "canceled") && !strings.Contains(errStr, "deadline") {
-				t.Errorf("Expected context error, got %v", err)
-			}
-		}
-	})
+	t.Logf("Detailed progress integration test completed:")
+	t.Logf("- Progress updates received: %d", len(progressUpdates))
+	t.Logf("- Final processing rate: %.2f bytes/sec", finalUpdate.ProcessingRate)
+	t.Logf("- Total elapsed time: %v", finalUpdate.ElapsedTime)
 }
 
-func TestSearchResults(t *testing.T) {
-	// Create a temporary directory with test files
-	tempDir, err := os.MkdirTemp("", "goripgrep_test_*")
-	if err != nil {
-		t.Fatalf("Failed to create temp directory: %v", err)
+func TestFindGlobs(t *testing.T) {
+	tempDir := t.TempDir()
+	testFiles := []string{
+		"main.go",
+		"main_test.go",
+		"README.md",
+		"notes.txt",
+		"src/util.go",
+		"testdata/fixture.go",
+	}
+	for _, filename := range testFiles {
+		path := filepath.Join(tempDir, filename)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory for %s: %v", filename, err)
+		}
+		if err := os.WriteFile(path, []byte("needle\n"), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", filename, err)
+		}
 	}
-	defer os.RemoveAll(tempDir)
 
-	// Create test files
-	testFiles := map[string]string{
-		"file1.txt": "test line 1\ntest line 2",
-		"file2.txt": "another test\nno match here",
-		"file3.txt": "final test line",
+	tests := []struct {
+		name     string
+		opts     []Option
+		expected []string
+	}{
+		{"Braces", []Option{WithIncludeGlobs([]string{"*.{go,md}"})}, []string{"README.md", "main.go", "main_test.go", "src/util.go", "testdata/fixture.go"}},
+		{"Negation", []Option{WithIncludeGlobs([]string{"*.go", "!*_test.go"})}, []string{"main.go", "src/util.go", "testdata/fixture.go"}},
+		{"ExcludeDirectory", []Option{WithIncludeGlobs([]string{"*.go"}), WithExcludeGlobs([]string{"testdata"})}, []string{"main.go", "main_test.go", "src/util.go"}},
+		{"PathGlob", []Option{WithIncludeGlobs([]string{"src/**"})}, []string{"src/util.go"}},
+		{"IgnoreCase", []Option{WithCaseInsensitiveGlobs([]string{"*.MD", "*.TXT"})}, []string{"README.md", "notes.txt"}},
+		{"FileTypes", []Option{WithFileTypes([]string{"md", "txt"})}, []string{"README.md", "notes.txt"}},
+		{"FileTypesNot", []Option{WithFileTypesNot([]string{"go"})}, []string{"README.md", "notes.txt"}},
 	}
 
-	for filename, content := range testFiles {
-		filePath := filepath.Join(tempDir, filename)
-		if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
-			t.Fatalf("Failed to create test file %s: %v", filename, err)
-		}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for _, walking := range []bool{false, true} {
+				opts := append([]Option{WithRecursive(true), WithOptimizedWalking(walking)}, test.opts...)
+				results, err := Find("needle", tempDir, opts...)
+				if err != nil {
+					t.Fatalf("Find failed: %v", err)
+				}
+
+				var files []string
+				for _, file := range results.Files() {
+					rel, _ := filepath.Rel(tempDir, file)
+					files = append(files, filepath.ToSlash(rel))
+				}
+				sort.Strings(files)
+
+				if fmt.Sprint(files) != fmt.Sprint(test.expected) {
+					t.Errorf("Optimized walking %v: expected %v, got %v", walking, test.expected, files)
+				}
+			}
+		})
 	}
 
-	results, err := Find("test", tempDir)
-	if err != nil {
-		t.Fatalf("Find failed: %v", err)
+	if _, err := Find("needle", tempDir, WithIncludeGlobs([]string{"*.{go"})); err == nil {
+		t.Error("Expected an error for an unterminated brace")
 	}
+}
 
-	t.Run("HasMatches", func(t *testing.T) {
-		if !results.HasMatches() {
-			t.Error("Expected HasMatches() to return true")
-		}
-	})
+func TestFindReader(t *testing.T) {
+	input := "INFO start\nERROR disk full\nINFO retry\nerror: timeout\nINFO done\n"
 
-	t.Run("Count", func(t *testing.T) {
-		count := results.Count()
-		if count == 0 {
-			t.Error("Expected Count() to return non-zero")
-		}
-		if count != len(results.Matches) {
-			t.Errorf("Expected Count() to equal len(Matches), got %d vs %d", count, len(results.Matches))
-		}
-	})
+	tests := []struct {
+		name     string
+		opts     []Option
+		expected []string
+	}{
+		{"Literal", nil, []string{"2:ERROR disk full"}},
+		{"IgnoreCase", []Option{WithIgnoreCase()}, []string{"2:ERROR disk full", "4:error: timeout"}},
+		{"Invert", []Option{WithInvertMatch(), WithIgnoreCase()}, []string{"1:INFO start", "3:INFO retry", "5:INFO done"}},
+		{"WordRegexp", []Option{WithWordRegexp(), WithIgnoreCase()}, []string{"2:ERROR disk full", "4:error: timeout"}},
+		{"Multiline", []Option{WithMultiline()}, []string{"2:ERROR disk full\nINFO retry"}},
+		{"HeadBytes", []Option{WithHeadBytes(45), WithIgnoreCase()}, []string{"2:ERROR disk full"}},
+	}
 
-	t.Run("Files", func(t *testing.T) {
-		files := results.Files()
-		if len(files) == 0 {
-			t.Error("Expected Files() to return non-empty slice")
-		}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pattern := "ERROR"
+			if test.name == "Multiline" {
+				pattern = `ERROR.*\nINFO`
+			}
 
-		// Check that all files are unique
-		fileSet := make(map[string]bool)
-		for _, file := range files {
-			if fileSet[file] {
-				t.Errorf("Duplicate file in Files(): %s", file)
+			var got []string
+			stats, err := FindReader(pattern, strings.NewReader(input), func(match Match) error {
+				if match.File != StdinName {
+					t.Errorf("Expected file %q, got %q", StdinName, match.File)
+				}
+				got = append(got, fmt.Sprintf("%d:%s", match.Line, match.Content))
+				return nil
+			}, test.opts...)
+			if err != nil {
+				t.Fatalf("FindReader failed: %v", err)
 			}
-			fileSet[file] = true
+
+			if fmt.Sprint(got) != fmt.Sprint(test.expected) {
+				t.Errorf("Expected %q, got %q", test.expected, got)
+			}
+			if stats.MatchesFound != int64(len(test.expected)) {
+				t.Errorf("Expected %d matches in stats, got %d", len(test.expected), stats.MatchesFound)
+			}
+		})
+	}
+
+	t.Run("Context", func(t *testing.T) {
+		var match Match
+		_, err := FindReader("retry", strings.NewReader(input), func(m Match) error {
+			match = m
+			return nil
+		}, WithContextLines(1))
+		if err != nil {
+			t.Fatalf("FindReader failed: %v", err)
+		}
+		if fmt.Sprint(match.BeforeContext) != "[ERROR disk full]" || fmt.Sprint(match.AfterContext) != "[error: timeout]" {
+			t.Errorf("Unexpected context %q / %q", match.BeforeContext, match.AfterContext)
 		}
 	})
 
-	t.Run("Stats", func(t *testing.T) {
-		stats := results.Stats
-		if stats.FilesScanned == 0 {
-			t.Error("Expected FilesScanned > 0")
+	t.Run("QuitAfterAndCount", func(t *testing.T) {
+		calls := 0
+		stats, err := FindReader("INFO", strings.NewReader(input), func(Match) error {
+			calls++
+			return nil
+		}, WithQuitAfter(2))
+		if err != nil {
+			t.Fatalf("FindReader failed: %v", err)
 		}
-		if stats.BytesScanned == 0 {
-			t.Error("Expected BytesScanned > 0")
+		if calls != 2 || !stats.StoppedEarly {
+			t.Errorf("Expected to stop after 2 matches, got %d calls (stopped early: %v)", calls, stats.StoppedEarly)
 		}
-		if stats.Duration == 0 {
-			t.Error("Expected Duration > 0")
+
+		stats, err = FindReader("INFO", strings.NewReader(input), func(Match) error {
+			t.Error("Count-only searches should not report matches")
+			return nil
+		}, WithCountOnly())
+		if err != nil {
+			t.Fatalf("FindReader failed: %v", err)
 		}
-		if stats.MatchesFound == 0 {
-			t.Error("Expected MatchesFound > 0")
+		if stats.MatchesFound != 3 || stats.BytesRead != int64(len(input)) {
+			t.Errorf("Expected 3 matches in %d bytes, got %d in %d", len(input), stats.MatchesFound, stats.BytesRead)
 		}
 	})
 
-	t.Run("Query", func(t *testing.T) {
-		if results.Query != "test" {
-			t.Errorf("Expected Query='test', got '%s'", results.Query)
+	t.Run("UnsupportedOptions", func(t *testing.T) {
+		if _, err := FindReader("INFO", strings.NewReader(input), func(Match) error { return nil }, WithTailBytes(10)); err == nil {
+			t.Error("Expected an error for tail bytes on a stream")
 		}
 	})
 }
 
-func BenchmarkFind(b *testing.B) {
-	// Create a temporary directory with test files
-	tempDir, err := os.MkdirTemp("", "goripgrep_bench_*")
-	if err != nil {
-		b.Fatalf("Failed to create temp directory: %v", err)
+func TestFindMultiplePatterns(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "log.txt")
+	if err := os.WriteFile(file, []byte("ERROR disk\nWARN cpu\nINFO ok\nWARN then ERROR\na.b\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	patternFile := filepath.Join(dir, "patterns")
+	if err := os.WriteFile(patternFile, []byte("INFO\r\n\na.b\n"), 0644); err != nil {
+		t.Fatal(err)
 	}
-	defer os.RemoveAll(tempDir)
 
-	// Create a larger test file
-	content := strings.Repeat("This is a test line with some content to search through.\n", 1000)
-	testFile := filepath.Join(tempDir, "large_test.txt")
-	if err := os.WriteFile(testFile, []byte(content), 0644); err != nil {
-		b.Fatalf("Failed to create test file: %v", err)
+	results, err := Find("ERROR", file, WithPatterns([]string{"WARN"}), WithPatternFile(patternFile))
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	var got []string
+	for _, match := range results.Matches {
+		got = append(got, fmt.Sprintf("%d:%d:%d", match.Line, match.Column, match.PatternIndex))
+	}
+	// Patterns are numbered from the main one, then WithPatterns, then the file
+	expected := []string{"1:1:0", "2:1:1", "3:1:2", "4:1:1", "4:11:0", "5:1:3"}
+	if fmt.Sprint(got) != fmt.Sprint(expected) {
+		t.Errorf("Expected line:column:pattern %v, got %v", expected, got)
 	}
 
-	b.Run("BasicSearch", func(b *testing.B) {
-		for i := 0; i < b.N; i++ {
-			_, err := Find("test", tempDir)
-			if err != nil {
-				b.Fatalf("Find failed: %v", err)
-			}
+	t.Run("FixedStrings", func(t *testing.T) {
+		results, err := Find("", file, WithPatterns([]string{"a.b", "c.u"}), WithFixedStrings())
+		if err != nil {
+			t.Fatalf("Find failed: %v", err)
+		}
+		if results.Count() != 1 || results.Matches[0].Content != "a.b" {
+			t.Errorf("Expected only the literal a.b to match, got %v", results.Matches)
 		}
 	})
 
-	b.Run("OptimizedSearch", func(b *testing.B) {
-		for i := 0; i < b.N; i++ {
-			_, err := Find("test", tempDir, WithOptimization(true))
-			if err != nil {
-				b.Fatalf("Find failed: %v", err)
-			}
+	t.Run("Reader", func(t *testing.T) {
+		var indexes []int
+		_, err := FindReader("", strings.NewReader("WARN then ERROR\n"), func(match Match) error {
+			indexes = append(indexes, match.PatternIndex)
+			return nil
+		}, WithPatterns([]string{"ERROR", "WARN"}))
+		if err != nil {
+			t.Fatalf("FindReader failed: %v", err)
+		}
+		if fmt.Sprint(indexes) != "[1 0]" {
+			t.Errorf("Expected pattern indexes [1 0], got %v", indexes)
 		}
 	})
 
-	b.Run("MultiWorkerSearch", func(b *testing.B) {
-		for i := 0; i < b.N; i++ {
-			_, err := Find("test", tempDir, WithWorkers(8))
-			if err != nil {
-				b.Fatalf("Find failed: %v", err)
-			}
+	t.Run("Errors", func(t *testing.T) {
+		if _, err := Find("", file); err == nil {
+			t.Error("Expected an error without any pattern")
+		}
+		if _, err := Find("ERROR", file, WithPatterns([]string{"("})); err == nil {
+			t.Error("Expected an error for an invalid extra pattern")
+		}
+		if _, err := Find("ERROR", file, WithPatternFile(filepath.Join(dir, "missing"))); err == nil {
+			t.Error("Expected an error for a missing pattern file")
 		}
 	})
 }
 
-func TestStreamingSearchConfiguration(t *testing.T) {
-	// Create a large test file to trigger streaming search
-	content := strings.Repeat("streaming search test pattern\n", 10000) // ~290KB
-	tmpFile, err := os.CreateTemp("", "large_test*.txt")
+func TestFindMulti(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"first/.gitignore": "*.log\n",
+		"first/a.txt":      "needle\n",
+		"first/skip.log":   "needle\n",
+		"second/b.log":     "needle\n",
+		"second/c.txt":     "needle\nneedle\n",
+	})
+	first, second := filepath.Join(dir, "first"), filepath.Join(dir, "second")
+
+	results, err := FindMulti("needle", []string{first, second}, WithGitignore(true), WithDeterministicOutput(true), WithWorkers(2))
 	if err != nil {
-		t.Fatalf("Failed to create temp file: %v", err)
+		t.Fatalf("FindMulti failed: %v", err)
 	}
-	defer os.Remove(tmpFile.Name())
 
-	if _, err := tmpFile.WriteString(content); err != nil {
-		t.Fatalf("Failed to write to temp file: %v", err)
+	// Each path keeps its own ignore files, and files come in path order
+	var got []string
+	for _, match := range results.Matches {
+		got = append(got, match.File)
+	}
+	want := []string{filepath.Join(first, "a.txt"), filepath.Join(second, "b.log"), filepath.Join(second, "c.txt"), filepath.Join(second, "c.txt")}
+	if !slices.Equal(got, want) {
+		t.Errorf("Expected matches in %q, got %q", want, got)
+	}
+	if results.Stats.MatchesFound != 4 || results.Stats.FilesScanned != 3 || results.Stats.FilesIgnored != 1 {
+		t.Errorf("Expected stats for both paths, got %d matches, %d files scanned and %d ignored",
+			results.Stats.MatchesFound, results.Stats.FilesScanned, results.Stats.FilesIgnored)
 	}
-	tmpFile.Close()
 
-	tests := []struct {
-		name    string
-		options []Option
-		pattern string
-		wantErr bool
-	}{
-		{
-			name: "Enable streaming search with custom chunk size",
-			options: []Option{
-				WithStreamingSearch(true),
-				WithLargeSizeThreshold(100 * 1024), // 100KB threshold
-				WithChunkSize(64 * 1024),           // 64KB chunks
-			},
-			pattern: "streaming",
-			wantErr: false,
-		},
-		{
-			name: "Custom overlap size",
-			options: []Option{
-				WithStreamingSearch(true),
-				WithLargeSizeThreshold(100 * 1024),
-				WithOverlapSize(32 * 1024), // 32KB overlap
-			},
-			pattern: "search",
-			wantErr: false,
-		},
-		{
-			name: "Disable adaptive resize",
-			options: []Option{
-				WithStreamingSearch(true),
-				WithLargeSizeThreshold(100 * 1024),
-				WithAdaptiveResize(false),
-			},
-			pattern: "test",
-			wantErr: false,
-		},
-		{
-			name: "Disable memory mapping",
-			options: []Option{
-				WithStreamingSearch(true),
-				WithLargeSizeThreshold(100 * 1024),
-				WithMemoryMapping(false),
-			},
-			pattern: "pattern",
-			wantErr: false,
-		},
-		{
-			name: "Custom memory threshold",
-			options: []Option{
-				WithStreamingSearch(true),
-				WithLargeSizeThreshold(100 * 1024),
-				WithMemoryThreshold(256 * 1024 * 1024), // 256MB
-			},
-			pattern: "streaming",
-			wantErr: false,
-		},
-		{
-			name: "Custom min/max chunk sizes",
-			options: []Option{
-				WithStreamingSearch(true),
-				WithLargeSizeThreshold(100 * 1024),
-				WithMinChunkSize(16 * 1024),         // 16KB min
-				WithMaxChunkSize(128 * 1024 * 1024), // 128MB max
-			},
-			pattern: "search",
-			wantErr: false,
-		},
-		{
-			name: "Disable streaming search",
-			options: []Option{
-				WithStreamingSearch(false),
-				WithLargeSizeThreshold(100 * 1024),
-			},
-			pattern: "streaming",
-			wantErr: false,
-		},
+	// Limits apply to the search as a whole
+	results, err = FindMulti("needle", []string{first, second}, WithQuitAfter(2), WithDeterministicOutput(true))
+	if err != nil {
+		t.Fatalf("FindMulti failed: %v", err)
+	}
+	if len(results.Matches) != 2 || results.Matches[1].File != filepath.Join(second, "b.log") {
+		t.Errorf("Expected the first 2 matches across both paths, got %+v", results.Matches)
+	}
+
+	if _, err := FindMulti("needle", []string{first, filepath.Join(dir, "missing")}); err == nil {
+		t.Error("Expected an error for a missing path")
+	}
+	if _, err := FindMulti("needle", nil); err == nil {
+		t.Error("Expected an error without paths")
+	}
+}
+
+func TestFindInFile(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		".hidden.log": "start\nERROR one\nend\n",
+		".gitignore":  "*.log\n",
+	})
+	hidden := filepath.Join(dir, ".hidden.log")
+	compressed := filepath.Join(dir, "old.log.gz")
+	writeGzipFile(t, compressed, "ok\nERROR two\n")
+
+	t.Run("SkipsFileFilters", func(t *testing.T) {
+		results, err := FindInFile("ERROR", hidden, WithGitignore(true), WithExcludeGlobs([]string{"*.log"}), WithContextLines(1))
+		if err != nil {
+			t.Fatalf("FindInFile failed: %v", err)
+		}
+		if len(results.Matches) != 1 {
+			t.Fatalf("Expected 1 match in the hidden, ignored file, got %d", len(results.Matches))
+		}
+		match := results.Matches[0]
+		if match.File != hidden || match.Line != 2 {
+			t.Errorf("Expected %s:2, got %s:%d", hidden, match.File, match.Line)
+		}
+		if !slices.Equal(match.BeforeContext, []string{"start"}) || !slices.Equal(match.AfterContext, []string{"end"}) {
+			t.Errorf("Expected context lines, got %q and %q", match.BeforeContext, match.AfterContext)
+		}
+		if results.Stats.FilesScanned != 1 {
+			t.Errorf("Expected 1 file scanned, got %d", results.Stats.FilesScanned)
+		}
+	})
+
+	t.Run("Compressed", func(t *testing.T) {
+		results, err := FindInFile("ERROR", compressed)
+		if err != nil {
+			t.Fatalf("FindInFile failed: %v", err)
+		}
+		if len(results.Matches) != 1 || results.Matches[0].Content != "ERROR two" || results.Matches[0].Line != 2 {
+			t.Errorf("Expected the decompressed match on line 2, got %+v", results.Matches)
+		}
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		if _, err := FindInFile("ERROR", dir); err == nil {
+			t.Error("Expected an error for a directory")
+		}
+		if _, err := FindInFile("ERROR", filepath.Join(dir, "missing.log")); err == nil {
+			t.Error("Expected an error for a missing file")
+		}
+		if _, err := FindInFile("ERROR", ""); err == nil {
+			t.Error("Expected an error for an empty path")
+		}
+	})
+}
+
+func TestWithoutLineContent(t *testing.T) {
+	dir := t.TempDir()
+	long := strings.Repeat("x", 10000) + " token=abc123 " + strings.Repeat("y", 10000)
+	writeTestFiles(t, dir, map[string]string{
+		"big.txt": "before\n" + long + "\nafter\n",
+	})
+
+	results, err := Find("", dir, WithPatterns([]string{"before", `token=\w+`}))
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	if len(results.Matches) != 2 {
+		t.Fatalf("Expected 2 matches, got %d", len(results.Matches))
+	}
+	for _, match := range results.Matches {
+		if match.MatchText != match.Content[match.MatchStart:match.MatchEnd] {
+			t.Errorf("Expected MatchText to be the matched part of Content, got %q", match.MatchText)
+		}
+	}
+
+	results, err = Find("", dir, WithoutLineContent(), WithContextLines(1), WithPatterns([]string{"before", `token=\w+`}))
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	var match Match
+	for _, m := range results.Matches {
+		if m.Line == 2 {
+			match = m
+		}
+	}
+	if match.Content != "" || match.MatchText != "token=abc123" {
+		t.Errorf("Expected only the matched text, got Content of %d bytes and MatchText %q", len(match.Content), match.MatchText)
+	}
+	if match.Column != 10002 || match.MatchStart != 10001 || match.PatternIndex != 1 {
+		t.Errorf("Expected the match location and pattern to be kept, got %+v", match)
+	}
+	if !slices.Equal(match.AfterContext, []string{"after"}) {
+		t.Errorf("Expected context lines to be kept, got %q", match.AfterContext)
+	}
+
+	var streamed []Match
+	if _, err := FindReader("abc", strings.NewReader("x abc y\n"), func(m Match) error {
+		streamed = append(streamed, m)
+		return nil
+	}, WithoutLineContent()); err != nil {
+		t.Fatalf("FindReader failed: %v", err)
+	}
+	if len(streamed) != 1 || streamed[0].Content != "" || streamed[0].MatchText != "abc" {
+		t.Errorf("Expected a streamed match without its line, got %+v", streamed)
+	}
+}
+
+func TestFindDedupeLinks(t *testing.T) {
+	dir := t.TempDir()
+	original := filepath.Join(dir, "a.log")
+	if err := os.WriteFile(original, []byte("needle\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Link(original, filepath.Join(dir, "b.log")); err != nil {
+		t.Skipf("hard links unavailable: %v", err)
+	}
+	if err := os.Symlink(original, filepath.Join(dir, "c.log")); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
 	}
 
+	tests := []struct {
+		name  string
+		opts  []Option
+		files []string
+	}{
+		{name: "hard links", opts: nil, files: []string{"a.log"}},
+		{name: "symlinks", opts: []Option{WithSymlinks()}, files: []string{"a.log"}},
+		{name: "disabled", opts: []Option{WithDedupeLinks(false)}, files: []string{"a.log", "b.log", "c.log"}},
+		{name: "disabled with symlinks", opts: []Option{WithDedupeLinks(false), WithSymlinks()}, files: []string{"a.log", "a.log", "b.log"}},
+	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			results, err := Find(tt.pattern, tmpFile.Name(), tt.options...)
-
-			if tt.wantErr && err == nil {
-				t.Error("Expected error but got none")
+			opts := append([]Option{WithRecursive(true), WithDeterministicOutput(true)}, tt.opts...)
+			results, err := Find("needle", dir, opts...)
+			if err != nil {
+				t.Fatalf("Find failed: %v", err)
 			}
-			if !tt.wantErr && err != nil {
-				t.Errorf("Unexpected error: %v", err)
+			var files []string
+			for _, match := range results.Matches {
+				files = append(files, filepath.Base(match.File))
 			}
-
-			if err == nil {
-				if !results.HasMatches() {
-					t.Error("Expected matches but got none")
-				}
-				if results.Count() == 0 {
-					t.Error("Expected match count > 0")
-				}
+			slices.Sort(files)
+			if !slices.Equal(files, tt.files) {
+				t.Errorf("Expected matches in %q, got %q", tt.files, files)
 			}
 		})
 	}
 }
 
-func TestProgressCallbackIntegration(t *testing.T) {
-	// Create a large test file
-	content := strings.Repeat("progress callback test line\n", 5000) // ~135KB
-	tmpFile, err := os.CreateTemp("", "progress_test*.txt")
+func TestFindMaxFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.go", "b.go", "c.go", "d.go", "e.go"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("use\nuse\nuse\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	results, err := Find("use", dir, WithMaxFiles(2), WithDeterministicOutput(true))
 	if err != nil {
-		t.Fatalf("Failed to create temp file: %v", err)
+		t.Fatalf("Find failed: %v", err)
+	}
+	files := results.Files()
+	slices.Sort(files)
+	if !slices.Equal(files, []string{filepath.Join(dir, "a.go"), filepath.Join(dir, "b.go")}) {
+		t.Errorf("Expected matches in the first 2 files, got %q", files)
+	}
+	if len(results.Matches) != 6 {
+		t.Errorf("Expected every match of both files, got %d", len(results.Matches))
+	}
+	if !results.Stats.StoppedEarly {
+		t.Error("Expected StoppedEarly to be set")
 	}
-	defer os.Remove(tmpFile.Name())
 
-	if _, err := tmpFile.WriteString(content); err != nil {
-		t.Fatalf("Failed to write to temp file: %v", err)
+	results, err = Find("use", dir, WithMaxFiles(3), WithCountOnly())
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	if len(results.Counts) != 3 {
+		t.Errorf("Expected counts for 3 files, got %v", results.Counts)
 	}
-	tmpFile.Close()
 
-	var progressUpdates []float64
-	var mu sync.Mutex
+	results, err = Find("use", dir, WithMaxFiles(10))
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	if len(results.Files()) != 5 || results.Stats.StoppedEarly {
+		t.Errorf("Expected all 5 files without stopping early, got %d files", len(results.Files()))
+	}
+}
 
-	progressCallback := func(bytesProcessed, totalBytes int64, percentage float64) {
-		mu.Lock()
-		defer mu.Unlock()
-		progressUpdates = append(progressUpdates, percentage)
+func TestFindMaxResultsStopsWorkers(t *testing.T) {
+	dir := t.TempDir()
+	var names []string
+	for i := range 40 {
+		name := fmt.Sprintf("file%02d.txt", i)
+		names = append(names, name)
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(strings.Repeat("needle\n", 20)), 0644); err != nil {
+			t.Fatal(err)
+		}
 	}
 
-	results, err := Find("progress", tmpFile.Name(),
-		WithStreamingSearch(true),
-		WithLargeSizeThreshold(50*1024), // 50KB threshold
-		WithChunkSize(16*1024),          // 16KB chunks for more progress updates
-		WithProgressCallback(progressCallback),
-	)
+	for _, deterministic := range []bool{false, true} {
+		for _, groups := range []int{0, 2} {
+			results, err := Find("needle", dir, WithMaxResults(30), WithWorkers(2), WithWorkerGroups(groups), WithDeterministicOutput(deterministic))
+			if err != nil {
+				t.Fatalf("Find failed: %v", err)
+			}
+			if len(results.Matches) != 30 || !results.Stats.StoppedEarly {
+				t.Errorf("deterministic %v, %d groups: expected 30 matches and StoppedEarly, got %d and %v", deterministic, groups, len(results.Matches), results.Stats.StoppedEarly)
+			}
+			if results.Stats.FilesScanned >= 20 {
+				t.Errorf("deterministic %v, %d groups: expected the workers to stop early, but %d files were scanned", deterministic, groups, results.Stats.FilesScanned)
+			}
+			if deterministic {
+				for i, match := range results.Matches {
+					if want := filepath.Join(dir, names[i/20]); match.File != want {
+						t.Errorf("%d groups: expected match %d in %s, the first 2 files in walk order, got %s", groups, i, want, match.File)
+						break
+					}
+				}
+			}
+		}
+	}
+}
 
-	if err != nil {
-		t.Fatalf("Search failed: %v", err)
+func TestFindMaxCountPerFile(t *testing.T) {
+	dir := t.TempDir()
+	// Large enough for the memory-mapped and streaming strategies
+	filler := strings.Repeat("nothing to see here\n", 60000)
+	content := "one needle needle\n" + filler + "two needle\n" + filler + "three needle\n"
+	for _, name := range []string{"a.txt", "b.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
 	}
 
-	if !results.HasMatches() {
-		t.Error("Expected matches but got none")
+	strategies := map[string][]Option{
+		"lines":     nil,
+		"mmap":      {WithMemoryMappedFiles()},
+		"streaming": {WithStreamingSearch(true), WithLargeSizeThreshold(1024), WithChunkSize(64 * 1024)},
+		"parallel":  {WithStreamingSearch(true), WithLargeSizeThreshold(1024), WithChunkSize(64 * 1024), WithChunkWorkers(4, 0)},
+		"context":   {WithContextLines(1)},
+		"multiline": {WithMultiline()},
 	}
+	for name, opts := range strategies {
+		results, err := Find("needle", dir, append(opts, WithMaxCountPerFile(2))...)
+		if err != nil {
+			t.Fatalf("%s: Find failed: %v", name, err)
+		}
+		// Both matches on the first line and the one on the second, in each file
+		if results.Count() != 6 {
+			t.Errorf("%s: expected 6 matches on the first 2 matching lines of each file, got %d", name, results.Count())
+		}
+		for _, match := range results.Matches {
+			if match.Content != "one needle needle" && match.Content != "two needle" {
+				t.Errorf("%s: unexpected match past the limit: %+v", name, match)
+			}
+		}
 
-	mu.Lock()
-	defer mu.Unlock()
+		results, err = Find("needle", dir, append(opts, WithMaxCountPerFile(1), WithCountOnly())...)
+		if err != nil {
+			t.Fatalf("%s: Find failed: %v", name, err)
+		}
+		if results.Counts[filepath.Join(dir, "a.txt")] != 2 || results.Counts[filepath.Join(dir, "b.txt")] != 2 {
+			t.Errorf("%s: expected counts of 2 per file, got %v", name, results.Counts)
+		}
+	}
 
-	if len(progressUpdates) == 0 {
-		t.Error("Expected progress updates but got none")
+	// The global limit still applies on top
+	results, err := Find("needle", dir, WithMaxCountPerFile(2), WithMaxResults(4))
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
 	}
+	if results.Count() != 4 {
+		t.Errorf("Expected 4 matches with both limits, got %d", results.Count())
+	}
+}
 
-	// Verify progress updates make sense
-	if len(progressUpdates) > 1 {
-		for i := 1; i < len(progressUpdates); i++ {
-			if progressUpdates[i] < progressUpdates[i-1] {
-				t.Errorf("Progress went backwards: %f to %f", progressUpdates[i-1], progressUpdates[i])
-			}
+func TestFindQuiet(t *testing.T) {
+	dir := t.TempDir()
+	for i := range 20 {
+		content := strings.Repeat("needle needle\n", 100)
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("file%02d.txt", i)), []byte(content), 0644); err != nil {
+			t.Fatal(err)
 		}
 	}
 
-	// Should end at 100%
-	finalProgress := progressUpdates[len(progressUpdates)-1]
-	if finalProgress != 100.0 {
-		t.Errorf("Expected final progress to be 100%%, got %f%%", finalProgress)
+	results, err := Find("needle", dir, WithQuiet(), WithWorkers(4))
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	if !results.HasMatches() {
+		t.Fatal("Expected a match")
+	}
+	if len(results.Matches) != 0 {
+		t.Errorf("Expected no Match values, got %d", len(results.Matches))
+	}
+	if results.Stats.MatchesFound != 1 || !results.Stats.StoppedEarly {
+		t.Errorf("Expected the search to stop at the first match, got %d matches (stopped early: %v)", results.Stats.MatchesFound, results.Stats.StoppedEarly)
+	}
+
+	results, err = Find("haystack", dir, WithQuiet())
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	if results.HasMatches() {
+		t.Errorf("Expected no matches, got %v", results.Counts)
 	}
 }
 
-func TestProgressCallbackDetailedIntegration(t *testing.T) {
-	// Create a test file large enough to trigger streaming search
-	content := strings.Repeat("line with search pattern\n", 5000) // ~125KB file
-	tmpFile, err := os.CreateTemp("", "detailed_progress_test_*.txt")
+func TestFindFileErrors(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"a.txt":     "needle\n",
+		"bad.gz":    "not gzip, but a needle\n",
+		"sub/c.txt": "needle\n",
+	})
+	var wantErrors []string
+	wantErrors = append(wantErrors, filepath.Join(dir, "bad.gz"))
+	if os.Geteuid() != 0 {
+		// Permissions do not stop root
+		locked := filepath.Join(dir, "sub")
+		if err := os.Chmod(locked, 0); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { os.Chmod(locked, 0755) })
+		wantErrors = append(wantErrors, locked)
+	}
+
+	results, err := Find("needle", dir, WithRecursive(true), WithSearchCompressed())
 	if err != nil {
-		t.Fatalf("Failed to create temp file: %v", err)
+		t.Fatalf("Find failed: %v", err)
+	}
+	if !results.HasMatches() {
+		t.Error("Expected the readable files to be searched")
+	}
+	var got []string
+	for _, fileErr := range results.Errors {
+		got = append(got, fileErr.Path)
+	}
+	if !slices.Equal(got, wantErrors) {
+		t.Fatalf("Expected errors for %q, got %+v", wantErrors, results.Errors)
 	}
-	defer os.Remove(tmpFile.Name())
 
-	if _, err := tmpFile.WriteString(content); err != nil {
-		t.Fatalf("Failed to write test content: %v", err)
+	// Errors survive export, as text
+	var buf bytes.Buffer
+	if err := results.Export(&buf); err != nil {
+		t.Fatal(err)
+	}
+	imported, err := ImportResults(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(imported.Errors) != len(results.Errors) || imported.Errors[0].Error() != results.Errors[0].Error() {
+		t.Errorf("Expected %v after export, got %v", results.Errors, imported.Errors)
 	}
-	tmpFile.Close()
 
-	// Track detailed progress updates
-	var progressUpdates []ProgressInfo
-	var progressMutex sync.Mutex
+	_, err = Find("needle", dir, WithRecursive(true), WithSearchCompressed(), WithStrictErrors())
+	var fileErr *FileError
+	if !errors.As(err, &fileErr) {
+		t.Fatalf("Expected a FileError with strict errors, got %v", err)
+	}
+}
 
-	// Search with detailed progress reporting
-	results, err := Find("search", tmpFile.Name(),
-		WithStreamingSearch(true),
-		WithLargeSizeThreshold(50*1024), // 50KB threshold to ensure streaming is used
-		WithChunkSize(16*1024),          // 16KB chunks for multiple updates
-		WithProgressCallbackDetailed(func(info ProgressInfo) {
-			progressMutex.Lock()
-			progressUpdates = append(progressUpdates, info)
-			progressMutex.Unlock()
-		}),
-	)
+func TestListFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		".gitignore":    "*.log\n",
+		"a.go":          "package a\n",
+		"b.txt":         "text\n",
+		"c.bin":         "binary\x00\n",
+		"app.log":       "ignored\n",
+		"sub/d.go":      "package sub\n",
+		"sub/deep/e.go": "package deep\n",
+	})
+	rel := func(files []string) []string {
+		for i, file := range files {
+			files[i], _ = filepath.Rel(dir, file)
+		}
+		return files
+	}
+
+	files, err := ListFiles(dir, WithRecursive(true), WithGitignore(true))
 	if err != nil {
-		t.Fatalf("Search failed: %v", err)
+		t.Fatalf("ListFiles failed: %v", err)
+	}
+	want := []string{"a.go", "b.txt", "sub/d.go", "sub/deep/e.go"}
+	if got := rel(files); !slices.Equal(got, want) {
+		t.Errorf("Expected %q, got %q", want, got)
 	}
 
-	// Verify we found matches
-	if len(results.Matches) != 5000 {
-		t.Errorf("Expected 5000 matches, got %d", len(results.Matches))
+	files, err = ListFiles(dir, WithRecursive(true), WithIncludeGlobs([]string{"*.go"}), WithMaxDepth(2))
+	if err != nil {
+		t.Fatalf("ListFiles failed: %v", err)
+	}
+	want = []string{"a.go", "sub/d.go"}
+	if got := rel(files); !slices.Equal(got, want) {
+		t.Errorf("Expected %q with a glob and depth limit, got %q", want, got)
 	}
 
-	// Verify detailed progress updates were received
-	progressMutex.Lock()
-	defer progressMutex.Unlock()
+	// Without recursion only the files in the directory are listed
+	files, err = ListFiles(dir, WithGitignore(false))
+	if err != nil {
+		t.Fatalf("ListFiles failed: %v", err)
+	}
+	want = []string{"a.go", "app.log", "b.txt"}
+	if got := rel(files); !slices.Equal(got, want) {
+		t.Errorf("Expected %q without recursion, got %q", want, got)
+	}
 
-	if len(progressUpdates) == 0 {
-		t.Error("No detailed progress updates received")
+	if _, err := ListFiles(filepath.Join(dir, "missing")); err == nil {
+		t.Error("Expected an error for a missing path")
 	}
+}
 
-	// Verify the final progress update
-	finalUpdate := progressUpdates[len(progressUpdates)-1]
+func TestFindLogger(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		".gitignore":      "ignored/\n*.log\n",
+		"a.txt":           "needle\n",
+		"b.txt":           "needle\x00\n",
+		"c.md":            "needle\n",
+		"app.log":         "needle\n",
+		".hidden.txt":     "needle\n",
+		"ignored/d.txt":   "needle\n",
+		"vendor/e.txt":    "needle\n",
+		"deep/more/f.txt": "needle\n",
+	})
 
-	if finalUpdate.Percentage != 100.0 {
-		t.Errorf("Final percentage should be 100.0, got %f", finalUpdate.Percentage)
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	results, err := Find("needle", dir, WithRecursive(true), WithGitignore(true), WithMaxDepth(2),
+		WithExcludeGlobs([]string{"*.md"}), WithLogger(logger))
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	if results.Count() != 1 {
+		t.Errorf("Expected only a.txt to match, got %+v", results.Matches)
 	}
 
-	if finalUpdate.ProcessingRate <= 0 {
-		t.Error("Processing rate should be positive")
+	reasons := make(map[string]string)
+	decoder := json.NewDecoder(&buf)
+	for decoder.More() {
+		var record struct {
+			Level  string
+			Msg    string
+			Path   string
+			Reason string
+		}
+		if err := decoder.Decode(&record); err != nil {
+			t.Fatal(err)
+		}
+		if record.Level != "DEBUG" {
+			t.Errorf("Expected debug records, got %+v", record)
+		}
+		rel, _ := filepath.Rel(dir, record.Path)
+		reasons[rel] = record.Msg + ": " + record.Reason
 	}
+	want := map[string]string{
+		".gitignore":  "skipped file: hidden",
+		".hidden.txt": "skipped file: hidden",
+		"app.log":     "skipped file: gitignore",
+		"b.txt":       "skipped file: binary content",
+		"c.md":        "skipped file: glob",
+		"deep/more":   "skipped directory: max depth",
+		"ignored":     "skipped directory: gitignore",
+		"vendor":      "skipped directory: build directory",
+	}
+	for path, reason := range want {
+		if reasons[path] != reason {
+			t.Errorf("Expected %s to be logged as %q, got %q", path, reason, reasons[path])
+		}
+	}
+	if _, ok := reasons["a.txt"]; ok {
+		t.Errorf("Expected nothing logged for a searched file, got %q", reasons["a.txt"])
+	}
+}
 
-	if finalUpdate.MatchesFound != 5000 {
-		t.Errorf("Expected 5000 matches found in progress, got %d", finalUpdate.MatchesFound)
+func TestFindFileTimings(t *testing.T) {
+	dir := t.TempDir()
+	for i, name := range []string{"a.go", "b.go", "c.go", "d.go"} {
+		content := strings.Repeat("no match here\n", 1+i*1000)
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
 	}
 
-	// Verify progress increased monotonically
-	for i := 1; i < len(progressUpdates); i++ {
-		if progressUpdates[i].BytesProcessed < progressUpdates[i-1].BytesProcessed {
-			t.Errorf("Progress bytes not monotonic at update %d: %d < %d",
-				i, progressUpdates[i].BytesProcessed, progressUpdates[i-1].BytesProcessed)
+	for _, groups := range []int{0, 2} {
+		results, err := Find("use", dir, WithFileTimings(2), WithWorkerGroups(groups))
+		if err != nil {
+			t.Fatalf("Find failed: %v", err)
+		}
+		slowest := results.Stats.SlowestFiles
+		if len(slowest) != 2 {
+			t.Fatalf("Expected the 2 slowest files, got %+v", slowest)
+		}
+		if slowest[0].Duration < slowest[1].Duration {
+			t.Errorf("Expected the slowest file first, got %+v", slowest)
+		}
+		for _, timing := range slowest {
+			info, err := os.Stat(timing.Path)
+			if err != nil || info.Size() != timing.Size || timing.Duration <= 0 {
+				t.Errorf("Expected the size and a duration of a searched file, got %+v", timing)
+			}
 		}
 	}
 
-	t.Logf("Detailed progress integration test completed:")
-	t.Logf("- Progress updates received: %d", len(progressUpdates))
-	t.Logf("- Final processing rate: %.2f bytes/sec", finalUpdate.ProcessingRate)
-	t.Logf("- Total elapsed time: %v", finalUpdate.ElapsedTime)
+	results, err := Find("use", dir)
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	if results.Stats.SlowestFiles != nil {
+		t.Errorf("Expected no timings without WithFileTimings, got %+v", results.Stats.SlowestFiles)
+	}
+}
+
+func TestSlowestFilesRecord(t *testing.T) {
+	var slowest slowestFiles
+	slowest.reset(3)
+	for i, ms := range []int{5, 1, 9, 3, 7, 2} {
+		slowest.record(FileTiming{Path: fmt.Sprint(i), Duration: time.Duration(ms) * time.Millisecond})
+	}
+	var got []string
+	for _, timing := range slowest.timings() {
+		got = append(got, timing.Path)
+	}
+	if !slices.Equal(got, []string{"2", "4", "0"}) {
+		t.Errorf("Expected the 3 slowest files slowest first, got %q", got)
+	}
 }
@@ -0,0 +1,252 @@
+package goripgrep
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Default limits applied by NewArchiveSearcher
+const (
+	DefaultArchiveMaxDepth      = 2
+	DefaultArchiveMaxMemberSize = 64 << 20
+	DefaultArchiveMaxTotalSize  = 1 << 30
+)
+
+// ErrArchiveTooLarge is returned when an archive decompresses to more than
+// its ArchiveSearcher's MaxTotalSize, as a zip bomb would
+var ErrArchiveTooLarge = errors.New("archive exceeds the decompressed size limit")
+
+// ArchiveMember is a regular file inside an archive
+type ArchiveMember struct {
+	Path string // Virtual path: the archive's path, then ! and the member's name, such as foo.zip!inner/path.txt
+	Size int64  // Size recorded in the archive, which may not be trusted
+}
+
+// ArchiveSearcher walks the members of tar and zip archives (.tar, .tar.gz,
+// .tgz and other compressed tarballs, .zip, .jar, .war and .ear) so they can
+// be searched like files. Archives inside archives are entered down to
+// MaxDepth, and the decompressed bytes read are bounded so a zip bomb
+// cannot exhaust memory or CPU.
+type ArchiveSearcher struct {
+	MaxDepth      int   // Archives nested deeper than this are treated as plain members; the outermost archive is depth 1
+	MaxMemberSize int64 // Bytes of each member searched; longer members are truncated
+	MaxTotalSize  int64 // Decompressed bytes read from one archive on disk, nested archives included
+
+	detector *CompressionDetector
+}
+
+// NewArchiveSearcher creates an archive searcher with the default limits
+func NewArchiveSearcher() *ArchiveSearcher {
+	return &ArchiveSearcher{
+		MaxDepth:      DefaultArchiveMaxDepth,
+		MaxMemberSize: DefaultArchiveMaxMemberSize,
+		MaxTotalSize:  DefaultArchiveMaxTotalSize,
+		detector:      NewCompressionDetector(),
+	}
+}
+
+// archiveFormat is how an archive's members are stored
+type archiveFormat int
+
+const (
+	archiveNone archiveFormat = iota
+	archiveTar
+	archiveZip
+)
+
+// format returns the archive format of name, judged by its extension, and
+// the compression wrapped around tarballs
+func (a *ArchiveSearcher) format(name string) (archiveFormat, CompressionType) {
+	lower := strings.ToLower(name)
+	switch filepath.Ext(lower) {
+	case ".zip", ".jar", ".war", ".ear":
+		return archiveZip, CompressionNone
+	case ".tar":
+		return archiveTar, CompressionNone
+	case ".tgz":
+		return archiveTar, CompressionGzip
+	case ".tbz", ".tbz2":
+		return archiveTar, CompressionBzip2
+	case ".txz":
+		return archiveTar, CompressionXz
+	}
+
+	// Compressed tarballs such as .tar.gz and .tar.zst
+	if compression := a.detector.DetectCompressionByExtension(lower); compression != CompressionNone &&
+		strings.HasSuffix(strings.TrimSuffix(lower, filepath.Ext(lower)), ".tar") {
+		return archiveTar, compression
+	}
+	return archiveNone, CompressionNone
+}
+
+// IsArchive reports whether name has the extension of an archive format the
+// searcher can walk
+func (a *ArchiveSearcher) IsArchive(name string) bool {
+	format, _ := a.format(name)
+	return format != archiveNone
+}
+
+// Walk calls fn with every regular file in the archive at path, in archive
+// order, with a reader for its contents limited to MaxMemberSize bytes.
+// Walking stops at the first error fn returns, which Walk returns, and
+// reading past MaxTotalSize fails with ErrArchiveTooLarge.
+func (a *ArchiveSearcher) Walk(ctx context.Context, path string, fn func(member ArchiveMember, r io.Reader) error) error {
+	format, compression := a.format(path)
+	if format == archiveNone {
+		return fmt.Errorf("%s is not a supported archive", path)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	walk := &archiveWalk{ctx: ctx, searcher: a, fn: fn, remaining: a.MaxTotalSize}
+	return walk.archive(path, format, compression, file, info.Size(), 1)
+}
+
+// archiveWalk is the state of one Walk, shared by the archives nested in it
+type archiveWalk struct {
+	ctx       context.Context
+	searcher  *ArchiveSearcher
+	fn        func(member ArchiveMember, r io.Reader) error
+	remaining int64 // Decompressed bytes left before ErrArchiveTooLarge
+}
+
+// archive walks the members of an archive read from r
+func (w *archiveWalk) archive(path string, format archiveFormat, compression CompressionType, r io.ReaderAt, size int64, depth int) error {
+	if format == archiveZip {
+		return w.zip(path, r, size, depth)
+	}
+
+	var reader io.Reader = io.NewSectionReader(r, 0, size)
+	if compression != CompressionNone {
+		decompressed, err := w.searcher.detector.DecompressReader(reader, compression)
+		if err != nil {
+			return fmt.Errorf("failed to decompress %s: %w", path, err)
+		}
+		if closer, ok := decompressed.(io.Closer); ok {
+			defer closer.Close()
+		}
+		reader = decompressed
+	}
+
+	// Skipping over a member still decompresses it, so the whole stream
+	// counts against the limit
+	return w.tar(path, &archiveBudgetReader{reader: reader, remaining: &w.remaining}, depth)
+}
+
+// tar walks the members of a tarball
+func (w *archiveWalk) tar(path string, r io.Reader, depth int) error {
+	tr := tar.NewReader(r)
+	for {
+		if err := w.ctx.Err(); err != nil {
+			return err
+		}
+
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		if !header.FileInfo().Mode().IsRegular() {
+			continue
+		}
+
+		if err := w.member(path, header.Name, header.Size, tr, depth); err != nil {
+			return err
+		}
+	}
+}
+
+// zip walks the members of a zip file
+func (w *archiveWalk) zip(path string, r io.ReaderAt, size int64, depth int) error {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	for _, file := range zr.File {
+		if err := w.ctx.Err(); err != nil {
+			return err
+		}
+		if !file.Mode().IsRegular() {
+			continue
+		}
+
+		rc, err := file.Open()
+		if err != nil {
+			return fmt.Errorf("failed to open %s!%s: %w", path, file.Name, err)
+		}
+		err = w.member(path, file.Name, int64(file.UncompressedSize64), &archiveBudgetReader{reader: rc, remaining: &w.remaining}, depth)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// member passes a member of the archive at path to fn, or walks it when it
+// is itself an archive within the depth limit
+func (w *archiveWalk) member(path, name string, size int64, r io.Reader, depth int) error {
+	memberPath := path + "!" + name
+	limit := w.searcher.MaxMemberSize
+
+	if depth < w.searcher.MaxDepth {
+		if format, compression := w.searcher.format(name); format != archiveNone {
+			// Nested archives are read into memory, so ones too large to
+			// search whole are skipped
+			data, err := io.ReadAll(io.LimitReader(r, limit+1))
+			if err != nil {
+				return err
+			}
+			if int64(len(data)) > limit {
+				return nil
+			}
+			return w.archive(memberPath, format, compression, bytes.NewReader(data), int64(len(data)), depth+1)
+		}
+	}
+
+	return w.fn(ArchiveMember{Path: memberPath, Size: size}, io.LimitReader(r, limit))
+}
+
+// archiveBudgetReader fails with ErrArchiveTooLarge once the bytes read from
+// it use up remaining, which is shared by every reader of one walk
+type archiveBudgetReader struct {
+	reader    io.Reader
+	remaining *int64
+}
+
+func (r *archiveBudgetReader) Read(p []byte) (int, error) {
+	if *r.remaining <= 0 {
+		// An archive of exactly the limit still ends cleanly
+		var probe [1]byte
+		if n, err := r.reader.Read(probe[:]); n == 0 && err != nil {
+			return 0, err
+		}
+		return 0, ErrArchiveTooLarge
+	}
+	if int64(len(p)) > *r.remaining {
+		p = p[:*r.remaining]
+	}
+	n, err := r.reader.Read(p)
+	*r.remaining -= int64(n)
+	return n, err
+}
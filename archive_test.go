@@ -0,0 +1,228 @@
+package goripgrep
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// zipBytes builds a zip file holding files, in name order
+func zipBytes(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range names {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("Failed to add %s: %v", name, err)
+		}
+		if _, err := w.Write([]byte(files[name])); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("Failed to close zip: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// tarGzBytes builds a gzipped tarball holding files, in name order
+func tarGzBytes(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, name := range names {
+		header := &tar.Header{Name: name, Mode: 0644, Size: int64(len(files[name])), Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatalf("Failed to add %s: %v", name, err)
+		}
+		if _, err := tw.Write([]byte(files[name])); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("Failed to close tar: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("Failed to close gzip: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestArchiveSearcherWalk(t *testing.T) {
+	root := t.TempDir()
+	inner := zipBytes(t, map[string]string{"META-INF/MANIFEST.MF": "Main-Class: app.Main\n"})
+	archive := filepath.Join(root, "bundle.tar.gz")
+	if err := os.WriteFile(archive, tarGzBytes(t, map[string]string{
+		"readme.txt":  "hello\n",
+		"lib/app.jar": string(inner),
+	}), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	searcher := NewArchiveSearcher()
+	for name, want := range map[string]bool{
+		"a.zip": true, "a.JAR": true, "a.tar": true, "a.tgz": true, "a.tar.gz": true, "a.tar.zst": true,
+		"a.gz": false, "a.txt": false, "tar": false,
+	} {
+		if got := searcher.IsArchive(name); got != want {
+			t.Errorf("IsArchive(%q) = %v, want %v", name, got, want)
+		}
+	}
+
+	var members []string
+	err := searcher.Walk(context.Background(), archive, func(member ArchiveMember, r io.Reader) error {
+		data, err := io.ReadAll(r)
+		members = append(members, member.Path+"="+strings.TrimSpace(string(data)))
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Walk failed: %v", err)
+	}
+	want := []string{
+		archive + "!lib/app.jar!META-INF/MANIFEST.MF=Main-Class: app.Main",
+		archive + "!readme.txt=hello",
+	}
+	if strings.Join(members, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Expected members %q, got %q", want, members)
+	}
+
+	// Past the depth limit, nested archives are plain members
+	searcher.MaxDepth = 1
+	members = nil
+	if err := searcher.Walk(context.Background(), archive, func(member ArchiveMember, r io.Reader) error {
+		members = append(members, member.Path)
+		return nil
+	}); err != nil {
+		t.Fatalf("Walk failed: %v", err)
+	}
+	if len(members) != 2 || members[0] != archive+"!lib/app.jar" {
+		t.Errorf("Expected the jar as a member at depth 1, got %q", members)
+	}
+}
+
+func TestArchiveSearcherLimits(t *testing.T) {
+	root := t.TempDir()
+	bomb := filepath.Join(root, "bomb.zip")
+	if err := os.WriteFile(bomb, zipBytes(t, map[string]string{
+		"a.txt": strings.Repeat("x", 4096),
+		"b.txt": strings.Repeat("y", 4096),
+	}), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	searcher := NewArchiveSearcher()
+	searcher.MaxMemberSize = 100
+	searcher.MaxTotalSize = 1000
+
+	var read []int
+	err := searcher.Walk(context.Background(), bomb, func(member ArchiveMember, r io.Reader) error {
+		data, err := io.ReadAll(r)
+		read = append(read, len(data))
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Walk failed: %v", err)
+	}
+	if len(read) != 2 || read[0] != 100 || read[1] != 100 {
+		t.Errorf("Expected each member truncated to 100 bytes, got %v", read)
+	}
+
+	searcher.MaxMemberSize = 8192
+	err = searcher.Walk(context.Background(), bomb, func(member ArchiveMember, r io.Reader) error {
+		_, err := io.ReadAll(r)
+		return err
+	})
+	if !errors.Is(err, ErrArchiveTooLarge) {
+		t.Errorf("Expected ErrArchiveTooLarge, got %v", err)
+	}
+
+	if err := searcher.Walk(context.Background(), filepath.Join(root, "plain.txt"), nil); err == nil {
+		t.Error("Expected an error for a file that is not an archive")
+	}
+}
+
+func TestFindArchiveSearch(t *testing.T) {
+	root := t.TempDir()
+	writeTestFiles(t, root, map[string]string{"plain.txt": "TODO on disk\n"})
+	if err := os.WriteFile(filepath.Join(root, "src.zip"), zipBytes(t, map[string]string{
+		"pkg/main.go": "package main\n// TODO in zip\n",
+		"logo.png":    "\x89PNG\x00\x00TODO",
+	}), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "docs.tgz"), tarGzBytes(t, map[string]string{
+		"guide.md": "# Guide\nTODO in tarball\n",
+	}), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Archives are skipped as binary by default
+	results, err := Find("TODO", root)
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	if len(results.Matches) != 1 {
+		t.Errorf("Expected only the plain file to match, got %+v", results.Matches)
+	}
+
+	results, err = Find("TODO", root, WithArchiveSearch())
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	found := make(map[string]int)
+	for _, match := range results.Matches {
+		found[match.File] = match.Line
+	}
+	want := map[string]int{
+		filepath.Join(root, "plain.txt"):                1,
+		filepath.Join(root, "src.zip") + "!pkg/main.go": 2,
+		filepath.Join(root, "docs.tgz") + "!guide.md":   2,
+	}
+	if len(found) != len(want) {
+		t.Errorf("Expected matches in %v, got %v", want, found)
+	}
+	for file, line := range want {
+		if found[file] != line {
+			t.Errorf("Expected a match on line %d of %s, got %v", line, file, found)
+		}
+	}
+
+	results, err = Find("TODO", root, WithArchiveSearch(), WithCountOnly())
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	if results.Counts[filepath.Join(root, "src.zip")+"!pkg/main.go"] != 1 {
+		t.Errorf("Expected counts per member, got %v", results.Counts)
+	}
+
+	results, err = FindInFile("TODO", filepath.Join(root, "docs.tgz"), WithArchiveSearch())
+	if err != nil {
+		t.Fatalf("FindInFile failed: %v", err)
+	}
+	if len(results.Matches) != 1 || results.Matches[0].Content != "TODO in tarball" {
+		t.Errorf("Expected FindInFile to search the tarball's members, got %+v", results.Matches)
+	}
+}
@@ -1,10 +1,12 @@
 package goripgrep
 
 import (
+	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"runtime"
 	"strings"
 	"testing"
 )
@@ -195,3 +197,39 @@ func BenchmarkPatternCompilation(b *testing.B) {
 		}
 	}
 }
+
+// BenchmarkWorkerGroups compares one shared engine with worker groups as the
+// worker count grows. Run it with -cpu 16,32,64 on a large machine to see
+// how far each setup scales.
+func BenchmarkWorkerGroups(b *testing.B) {
+	root := b.TempDir()
+	line := strings.Repeat("lorem ipsum dolor sit amet ", 4) + "\n"
+	content := []byte(strings.Repeat(line, 100) + "func needleSushi() {}\n")
+	for i := 0; i < 2000; i++ {
+		dir := filepath.Join(root, fmt.Sprintf("d%02d", i%50))
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			b.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("f%04d.txt", i)), content, 0644); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	for _, groups := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("groups=%d", groups), func(b *testing.B) {
+			workers := runtime.GOMAXPROCS(0)
+			for i := 0; i < b.N; i++ {
+				_, err := Find("needleSushi", root,
+					WithRecursive(true),
+					WithWorkers(workers),
+					WithWorkerGroups(groups),
+					WithCPUPinning(),
+					WithMaxResults(10000),
+				)
+				if err != nil {
+					b.Fatalf("Search failed: %v", err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package goripgrep
+
+import (
+	"bytes"
+	"io"
+	"strings"
+
+	unicodeenc "golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// utf8BOM is the byte order mark some editors write at the start of UTF-8
+// files. Left in place it would be part of the first line, shifting its
+// columns and hiding its start from patterns anchored with ^.
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// utf16Decoder returns a decoder to UTF-8 for text starting with head when
+// it starts with a UTF-16 byte order mark, or nil. The decoder drops the mark.
+func utf16Decoder(head []byte) transform.Transformer {
+	switch {
+	case bytes.HasPrefix(head, []byte{0xFF, 0xFE}):
+		return unicodeenc.UTF16(unicodeenc.LittleEndian, unicodeenc.ExpectBOM).NewDecoder()
+	case bytes.HasPrefix(head, []byte{0xFE, 0xFF}):
+		return unicodeenc.UTF16(unicodeenc.BigEndian, unicodeenc.ExpectBOM).NewDecoder()
+	}
+	return nil
+}
+
+// hasBOM reports whether text starting with head starts with a UTF-8 or
+// UTF-16 byte order mark
+func hasBOM(head []byte) bool {
+	return bytes.HasPrefix(head, utf8BOM) || utf16Decoder(head) != nil
+}
+
+// stripBOM returns data without a leading UTF-8 byte order mark, decoding
+// text marked as UTF-16 to UTF-8
+func stripBOM(data []byte) []byte {
+	if bytes.HasPrefix(data, utf8BOM) {
+		return data[len(utf8BOM):]
+	}
+	if decoder := utf16Decoder(data); decoder != nil {
+		if decoded, _, err := transform.Bytes(decoder, data); err == nil {
+			return decoded
+		}
+	}
+	return data
+}
+
+// stripBOMString is stripBOM for text already held as a string. Text marked
+// as UTF-16 is returned as it is, with false.
+func stripBOMString(text string) (string, bool) {
+	if utf16Decoder([]byte(text[:min(len(text), 2)])) != nil {
+		return text, false
+	}
+	return strings.TrimPrefix(text, string(utf8BOM)), true
+}
+
+// bomReader is stripBOM for a stream. It only waits for more bytes while
+// those read so far could still start a mark, so a stream that is followed
+// as it grows is not held up.
+type bomReader struct {
+	reader  io.Reader
+	checked bool
+	head    []byte // Bytes read while looking for a mark, yet to be returned
+	err     error  // Error met while looking
+}
+
+// Read implements io.Reader
+func (r *bomReader) Read(p []byte) (int, error) {
+	if !r.checked {
+		r.check()
+	}
+	if len(r.head) > 0 {
+		n := copy(p, r.head)
+		r.head = r.head[n:]
+		return n, nil
+	}
+	if r.err != nil {
+		return 0, r.err
+	}
+	return r.reader.Read(p)
+}
+
+// check reads the start of the stream, dropping a UTF-8 mark or switching
+// to decoding UTF-16
+func (r *bomReader) check() {
+	r.checked = true
+	head := make([]byte, len(utf8BOM))
+	n := 0
+	for n < len(head) && r.err == nil && mayStartBOM(head[:n]) && utf16Decoder(head[:n]) == nil {
+		var read int
+		read, r.err = r.reader.Read(head[n:])
+		n += read
+	}
+	head = head[:n]
+
+	switch {
+	case bytes.HasPrefix(head, utf8BOM):
+	case utf16Decoder(head) != nil && (r.err == nil || r.err == io.EOF):
+		r.reader = transform.NewReader(io.MultiReader(bytes.NewReader(head), r.reader), utf16Decoder(head))
+		r.err = nil
+	default:
+		r.head = head
+	}
+}
+
+// mayStartBOM reports whether head could be the start of a byte order mark
+func mayStartBOM(head []byte) bool {
+	return bytes.HasPrefix(utf8BOM, head) || bytes.HasPrefix([]byte{0xFF, 0xFE}, head) || bytes.HasPrefix([]byte{0xFE, 0xFF}, head)
+}
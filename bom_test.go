@@ -0,0 +1,164 @@
+package goripgrep
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"unicode/utf16"
+)
+
+// encodeUTF16 encodes text as UTF-16 with a byte order mark
+func encodeUTF16(text string, bigEndian bool) []byte {
+	var buf bytes.Buffer
+	for _, unit := range utf16.Encode([]rune("\uFEFF" + text)) {
+		if bigEndian {
+			buf.Write([]byte{byte(unit >> 8), byte(unit)})
+		} else {
+			buf.Write([]byte{byte(unit), byte(unit >> 8)})
+		}
+	}
+	return buf.Bytes()
+}
+
+func TestStripBOM(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"no mark", []byte("foo\n"), "foo\n"},
+		{"UTF-8", []byte("\xEF\xBB\xBFfoo\n"), "foo\n"},
+		{"UTF-16LE", encodeUTF16("héllo\n", false), "héllo\n"},
+		{"UTF-16BE", encodeUTF16("héllo\n", true), "héllo\n"},
+		{"partial mark", []byte("\xEF\xBBx"), "\xEF\xBBx"},
+		{"empty", nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(stripBOM(tt.data)); got != tt.want {
+				t.Errorf("stripBOM = %q, want %q", got, tt.want)
+			}
+
+			// Byte at a time, so the mark arrives in pieces
+			got, err := io.ReadAll(&bomReader{reader: iotest.OneByteReader(bytes.NewReader(tt.data))})
+			if err != nil {
+				t.Fatalf("Reading failed: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("bomReader read %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindBOM(t *testing.T) {
+	tempDir := t.TempDir()
+	files := map[string][]byte{
+		"utf8.txt":    []byte("\xEF\xBB\xBFfoo héllo\nfoo\n"),
+		"utf16le.txt": encodeUTF16("foo héllo\nfoo\n", false),
+		"utf16be.txt": encodeUTF16("foo héllo\nfoo\n", true),
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(tempDir, name), data, 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		pattern string
+		opts    []Option
+	}{
+		{"lines", "^foo", nil},
+		{"count", "^foo", []Option{WithCountOnly()}},
+		{"multiline", "^foo", []Option{WithMultiline()}},
+		{"head bytes", "^foo", []Option{WithHeadBytes(1 << 10)}},
+		{"sliding window", "foo", []Option{WithStreamingSearch(true), WithLargeSizeThreshold(1)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for name := range files {
+				results, err := Find(tt.pattern, filepath.Join(tempDir, name), tt.opts...)
+				if err != nil {
+					t.Fatalf("Find failed for %s: %v", name, err)
+				}
+				if results.Count() != 2 {
+					t.Fatalf("Expected 2 matches in %s, got %d: %+v", name, results.Count(), results.Matches)
+				}
+				if len(results.Matches) > 0 {
+					first := results.Matches[0]
+					if first.Line != 1 || first.Column != 1 || strings.HasPrefix(first.Content, "\uFEFF") {
+						t.Errorf("Expected %s to match at 1:1 without the mark, got %d:%d %q", name, first.Line, first.Column, first.Content)
+					}
+				}
+			}
+		})
+	}
+
+	var matches []Match
+	_, err := FindReader("^foo", bytes.NewReader(files["utf16le.txt"]), func(match Match) error {
+		matches = append(matches, match)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("FindReader failed: %v", err)
+	}
+	if len(matches) != 2 || matches[0].Content != "foo héllo" {
+		t.Errorf("Expected 2 decoded matches from the stream, got %+v", matches)
+	}
+}
+
+func TestMatchRuneColumn(t *testing.T) {
+	tempDir := t.TempDir()
+	path := filepath.Join(tempDir, "test.txt")
+	if err := os.WriteFile(path, []byte("\xEF\xBB\xBFnaïve café x\n"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	for _, opts := range [][]Option{nil, {WithOnlyMatching()}, {WithoutLineContent()}} {
+		results, err := Find("x", path, opts...)
+		if err != nil {
+			t.Fatalf("Find failed: %v", err)
+		}
+		if results.Count() != 1 {
+			t.Fatalf("Expected 1 match, got %d", results.Count())
+		}
+		if match := results.Matches[0]; match.RuneColumn != 12 {
+			t.Errorf("Expected rune column 12, got %d (byte column %d)", match.RuneColumn, match.Column)
+		}
+	}
+	results, err := Find("x", path)
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	if column := results.Matches[0].Column; column != 14 {
+		t.Errorf("Expected byte column 14, got %d", column)
+	}
+}
+
+func TestReplaceKeepsBOM(t *testing.T) {
+	tempDir := t.TempDir()
+	path := filepath.Join(tempDir, "test.txt")
+	if err := os.WriteFile(path, []byte("\xEF\xBB\xBFfoo\nfoo\n"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	results, err := Replace("^foo", "bar", path)
+	if err != nil {
+		t.Fatalf("Replace failed: %v", err)
+	}
+	if results.Replacements != 2 {
+		t.Errorf("Expected 2 replacements, got %d", results.Replacements)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read file: %v", err)
+	}
+	if string(data) != "\xEF\xBB\xBFbar\nbar\n" {
+		t.Errorf("Expected the mark kept before the rewritten lines, got %q", data)
+	}
+}
@@ -0,0 +1,40 @@
+package goripgrep
+
+// Features lists the optional features compiled into this copy of goripgrep,
+// so embedders can offer only the options the build supports and bug reports
+// can tell exactly what a binary was built with
+type Features struct {
+	MemoryMapping bool            `json:"memory_mapping"` // Large files are searched through mmap
+	SIMD          string          `json:"simd"`           // Widest vector instruction set detected for the byte scanner, or "none"
+	ScanPath      string          `json:"scan_path"`      // Byte scanner in use: avx2, sse2 or neon assembly, or word for word-level Go
+	ScanPaths     map[string]bool `json:"scan_paths"`     // Byte scanning optimizations and the CPU features detected for them
+	Xattrs        bool            `json:"xattrs"`         // WithMetadata can read extended attributes
+	Compression   []string        `json:"compression"`    // Formats WithSearchCompressed decompresses
+	Archives      []string        `json:"archives"`       // Formats WithArchiveSearch walks
+	RegexEngines  []string        `json:"regex_engines"`  // Ways a pattern can be matched, as named by ExplainPattern
+}
+
+// Capabilities reports the features of this build on the running machine
+func Capabilities() Features {
+	var compression []string
+	for format := CompressionGzip; format <= CompressionLz4; format++ {
+		compression = append(compression, format.String())
+	}
+
+	engine := NewOptimizedEngine()
+	return Features{
+		MemoryMapping: mmapSupported,
+		SIMD:          engine.simdLevel(),
+		ScanPath:      engine.scanPath(),
+		ScanPaths:     engine.GetCapabilities(),
+		Xattrs:        xattrSupported,
+		Compression:   compression,
+		Archives:      []string{"tar", "zip"},
+		RegexEngines: []string{
+			PatternLiteral.String(),
+			PatternRegex.String(),
+			PatternMultiline.String(),
+			PatternBacktracking.String(),
+		},
+	}
+}
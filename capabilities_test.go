@@ -0,0 +1,31 @@
+package goripgrep
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCapabilities(t *testing.T) {
+	caps := Capabilities()
+
+	if !caps.MemoryMapping || caps.SIMD == "" {
+		t.Errorf("Expected memory mapping and a SIMD level, got %+v", caps)
+	}
+	if !caps.ScanPaths["PURE_GO"] {
+		t.Errorf("Expected the pure Go scan path, got %v", caps.ScanPaths)
+	}
+	if (caps.ScanPath == "word") == vectorScan {
+		t.Errorf("Expected scan path %q to match vector scanning %v", caps.ScanPath, vectorScan)
+	}
+	if caps.Xattrs != xattrSupported {
+		t.Errorf("Expected xattr support %v, got %v", xattrSupported, caps.Xattrs)
+	}
+	for _, format := range []string{"gzip", "bzip2", "zstd", "xz", "lzma", "lz4"} {
+		if !slices.Contains(caps.Compression, format) {
+			t.Errorf("Expected %s among %v", format, caps.Compression)
+		}
+	}
+	if !slices.Contains(caps.Archives, "zip") || !slices.Contains(caps.RegexEngines, "backtracking regex") {
+		t.Errorf("Expected zip archives and the backtracking engine, got %+v", caps)
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// ANSI escape sequences used to highlight text output, matching ripgrep's
+// default colors
+const (
+	colorPath  = "\x1b[35m"   // Magenta file names
+	colorLine  = "\x1b[32m"   // Green line numbers
+	colorMatch = "\x1b[1;31m" // Bold red matched text
+	colorReset = "\x1b[0m"
+)
+
+// useColor is set from --color once flags are parsed
+var useColor bool
+
+// resolveColor decides whether to color output for a --color mode. In auto
+// mode output is colored only when stdout is a terminal and neither NO_COLOR
+// nor TERM=dumb asks otherwise.
+func resolveColor(mode string) (bool, error) {
+	switch mode {
+	case "always":
+		return true, nil
+	case "never":
+		return false, nil
+	case "auto":
+		if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+			return false, nil
+		}
+		return stdoutIsTerminal(), nil
+	}
+	return false, fmt.Errorf("invalid --color value %q (want auto, always or never)", mode)
+}
+
+// stdoutIsTerminal reports whether standard output is a terminal rather
+// than a pipe or file
+func stdoutIsTerminal() bool {
+	info, err := os.Stdout.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// colorize wraps s in the given color when color output is enabled
+func colorize(color, s string) string {
+	if !useColor {
+		return s
+	}
+	return color + s + colorReset
+}
+
+// colorPathName colors a file path
+func colorPathName(path string) string {
+	return colorize(colorPath, path)
+}
+
+// colorLineNumber colors a line number, with an optional suffix such as the
+// - and + that mark context lines
+func colorLineNumber(line int, suffix string) string {
+	return colorize(colorLine, strconv.Itoa(line)) + suffix
+}
+
+// highlightSpan highlights the bytes of line from start to end, clamped to
+// the line. An empty span leaves the line unchanged.
+func highlightSpan(line string, start, end int) string {
+	start = min(max(start, 0), len(line))
+	end = min(max(end, start), len(line))
+	if !useColor || start == end {
+		return line
+	}
+	return line[:start] + colorMatch + line[start:end] + colorReset + line[end:]
+}
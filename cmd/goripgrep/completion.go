@@ -0,0 +1,171 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/localrivet/goripgrep"
+	"github.com/spf13/cobra"
+)
+
+// registerCompletions wires the dynamic completions of arguments and flag
+// values into the commands, for the scripts 'goripgrep completion' prints.
+// It runs once every command has registered its flags.
+func registerCompletions() {
+	rootCmd.ValidArgsFunction = completeSearchArgs
+	explainFileCmd.ValidArgsFunction = func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return completePaths(toComplete)
+	}
+
+	for _, cmd := range []*cobra.Command{rootCmd, explainFileCmd} {
+		for _, flag := range []string{"type", "type-not"} {
+			cmd.RegisterFlagCompletionFunc(flag, completeTypes)
+		}
+		for _, flag := range []string{"glob", "iglob", "exclude"} {
+			cmd.RegisterFlagCompletionFunc(flag, completeGlobs)
+		}
+	}
+	rootCmd.RegisterFlagCompletionFunc("sort", cobra.FixedCompletions([]string{
+		"none\tkeep the order of the search",
+		"path\tcompare paths byte by byte",
+		"path:natural\tfile2 before file10",
+		"path:locale\tcollate for the locale",
+	}, cobra.ShellCompDirectiveNoFileComp))
+	rootCmd.RegisterFlagCompletionFunc("normalize", cobra.FixedCompletions([]string{
+		"nfc\tcomposed characters",
+		"nfd\tdecomposed characters",
+		"nfkc\tcomposed, folding compatibility characters",
+		"nfkd\tdecomposed, folding compatibility characters",
+	}, cobra.ShellCompDirectiveNoFileComp))
+	rootCmd.RegisterFlagCompletionFunc("column-mode", cobra.FixedCompletions([]string{
+		"bytes\tbytes from the start of the line",
+		"runes\tcharacters, as editors count them",
+	}, cobra.ShellCompDirectiveNoFileComp))
+	rootCmd.RegisterFlagCompletionFunc("color", cobra.FixedCompletions([]string{
+		"auto\twhen printing to a terminal",
+		"always",
+		"never",
+	}, cobra.ShellCompDirectiveNoFileComp))
+	rootCmd.RegisterFlagCompletionFunc("output", cobra.FixedCompletions([]string{
+		"csv\tcomma separated rows",
+		"tsv\ttab separated rows",
+		"sarif\tlog for code scanning",
+	}, cobra.ShellCompDirectiveNoFileComp))
+}
+
+// completeSearchArgs completes the paths after the pattern, which is left
+// to the user unless flags give it
+func completeSearchArgs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	patternFromFlags := namePattern != "" || listFiles || len(regexps) > 0 || len(patternFiles) > 0
+	if len(args) == 0 && !patternFromFlags {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return completePaths(toComplete)
+}
+
+// completePaths completes the files and directories next to toComplete
+// that a search would read, leaving out what ignore files exclude and
+// hidden names unless asked for. Directories end in a slash so completion
+// can go on inside them.
+func completePaths(toComplete string) ([]string, cobra.ShellCompDirective) {
+	dir, prefix := filepath.Split(toComplete)
+	readDir := dir
+	if readDir == "" {
+		readDir = "."
+	}
+	entries, err := os.ReadDir(readDir)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var ignore *goripgrep.GitignoreEngine
+	if useGitignore && !noIgnore && unrestricted == 0 {
+		ignore = goripgrep.NewGitignoreEngine(readDir)
+	}
+	hidden := includeHidden || unrestricted >= 2 || strings.HasPrefix(prefix, ".")
+
+	var completions []string
+	directive := cobra.ShellCompDirectiveNoFileComp
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, prefix) || (!hidden && strings.HasPrefix(name, ".")) {
+			continue
+		}
+		path := filepath.Join(readDir, name)
+		isDir := entry.IsDir()
+		if entry.Type()&os.ModeSymlink != 0 {
+			if info, err := os.Stat(path); err == nil {
+				isDir = info.IsDir()
+			}
+		}
+
+		switch {
+		case isDir && ignore != nil && ignore.ShouldIgnoreDir(path):
+		case !isDir && ignore != nil && ignore.ShouldIgnore(path):
+		case isDir:
+			completions = append(completions, dir+name+"/")
+			directive |= cobra.ShellCompDirectiveNoSpace
+		default:
+			completions = append(completions, dir+name)
+		}
+	}
+	return completions, directive
+}
+
+// completeTypes completes --type and --type-not with the known file types
+// and their globs
+func completeTypes(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	var completions []string
+	for _, fileType := range goripgrep.FileTypes() {
+		if strings.HasPrefix(fileType.Name, toComplete) {
+			completions = append(completions, fileType.Name+"\t"+strings.Join(fileType.Globs, ", "))
+		}
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeGlobs suggests a glob for each file extension found in the files
+// a search of the current directory would read, a few levels deep, with a
+// leading ! kept for negated globs
+func completeGlobs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	negate := ""
+	if strings.HasPrefix(toComplete, "!") {
+		negate = "!"
+	}
+
+	// Binary files are not told apart, so no file is opened
+	files, err := goripgrep.ListFiles(".",
+		goripgrep.WithRecursive(true),
+		goripgrep.WithMaxDepth(4),
+		goripgrep.WithBinaryMode(goripgrep.BinaryText),
+		goripgrep.WithTimeout(time.Second),
+	)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	counts := make(map[string]int)
+	for _, file := range files {
+		name := filepath.Base(file)
+		if ext := filepath.Ext(name); ext != "" && ext != name {
+			counts[negate+"*"+ext]++
+		}
+	}
+	var completions []string
+	for glob, count := range counts {
+		if !strings.HasPrefix(glob, toComplete) {
+			continue
+		}
+		if count == 1 {
+			completions = append(completions, glob+"\t1 file")
+		} else {
+			completions = append(completions, fmt.Sprintf("%s\t%d files", glob, count))
+		}
+	}
+	slices.Sort(completions)
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
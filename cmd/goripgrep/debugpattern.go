@@ -0,0 +1,125 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/localrivet/goripgrep"
+	"github.com/spf13/cobra"
+)
+
+var (
+	debugAllLines bool
+	debugMaxLines int
+)
+
+var debugPatternCmd = &cobra.Command{
+	Use:   "debug-pattern [flags] PATTERN FILE",
+	Short: "Show how a pattern is matched against a file, line by line",
+	Long: `Match PATTERN against FILE the way a search would and show what happened:
+how the pattern was classified, the literals lines are prefiltered on, which
+lines the prefilter rejected and which the regex engine verified, and the
+time spent compiling, reading, prefiltering and verifying.
+
+Use it when a query is unexpectedly slow or misses matches. Lines the regex
+verified without matching are listed with the matches; --all also lists the
+lines the prefilter rejected.`,
+	Example: `  goripgrep debug-pattern '\w+Sushi' big.txt
+  goripgrep debug-pattern -i --all 'conn(ection)? reset' app.log`,
+	Args: cobra.ExactArgs(2),
+	RunE: runDebugPattern,
+}
+
+func init() {
+	debugPatternCmd.Flags().BoolVarP(&ignoreCase, "ignore-case", "i", false, "Case-insensitive search")
+	debugPatternCmd.Flags().BoolVarP(&fixedStrings, "fixed-strings", "F", false, "Treat the pattern as a literal string instead of a regex")
+	debugPatternCmd.Flags().BoolVarP(&wordRegexp, "word-regexp", "w", false, "Only match whole words")
+	debugPatternCmd.Flags().BoolVarP(&lineRegexp, "line-regexp", "x", false, "Only match whole lines")
+	debugPatternCmd.Flags().BoolVarP(&pcre2, "pcre2", "P", false, "Allow lookaround and backreferences, matched by a backtracking engine")
+	debugPatternCmd.Flags().BoolVarP(&multiline, "multiline", "U", false, "Allow matches to span multiple lines")
+	debugPatternCmd.Flags().BoolVar(&jsonOutput, "json", false, "Output the trace in JSON format")
+	debugPatternCmd.Flags().BoolVar(&debugAllLines, "all", false, "Also list the lines the prefilter rejected")
+	debugPatternCmd.Flags().IntVarP(&debugMaxLines, "max-lines", "m", 100, "List at most NUM lines (0 for all)")
+
+	rootCmd.AddCommand(debugPatternCmd)
+}
+
+func runDebugPattern(cmd *cobra.Command, args []string) error {
+	var opts []goripgrep.Option
+	if ignoreCase {
+		opts = append(opts, goripgrep.WithIgnoreCase())
+	}
+	if fixedStrings {
+		opts = append(opts, goripgrep.WithFixedStrings())
+	}
+	if wordRegexp {
+		opts = append(opts, goripgrep.WithWordRegexp())
+	}
+	if lineRegexp {
+		opts = append(opts, goripgrep.WithLineRegexp())
+	}
+	if pcre2 {
+		opts = append(opts, goripgrep.WithPCRE2Syntax())
+	}
+	if multiline {
+		opts = append(opts, goripgrep.WithMultiline())
+	}
+
+	debug, err := goripgrep.DebugPattern(args[0], args[1], opts...)
+	if err != nil {
+		return fmt.Errorf("debug failed: %w", err)
+	}
+
+	if jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(debug)
+	}
+
+	printPatternReport(debug.Report)
+	fmt.Println()
+	switch {
+	case len(debug.Prefilter) > 0:
+		quoted := make([]string, len(debug.Prefilter))
+		for i, literal := range debug.Prefilter {
+			quoted[i] = fmt.Sprintf("%q", literal)
+		}
+		fmt.Printf("Prefilter: lines must contain %s before the regex runs\n", strings.Join(quoted, " or "))
+	case debug.Report.Kind == goripgrep.PatternLiteral:
+		fmt.Println("Prefilter: none, lines are searched for the literal directly")
+	default:
+		fmt.Println("Prefilter: none, every line runs through the regex engine")
+	}
+	fmt.Printf("File:      %s\n", debug.File)
+	fmt.Printf("Lines:     %d read, %d prefiltered, %d verified, %d matched\n",
+		debug.LinesRead, debug.Prefiltered, debug.Verified, debug.Matched)
+	fmt.Printf("Timings:   compile %v, read %v, prefilter %v, verify %v\n",
+		debug.Timings.Compile, debug.Timings.Read, debug.Timings.Prefilter, debug.Timings.Verify)
+
+	// Format: line stage (matches): content
+	listed, hidden := 0, 0
+	for _, line := range debug.Lines {
+		if line.Stage == goripgrep.StagePrefiltered && !debugAllLines {
+			continue
+		}
+		if debugMaxLines > 0 && listed == debugMaxLines {
+			hidden++
+			continue
+		}
+		if listed == 0 {
+			fmt.Println()
+		}
+		listed++
+		stage := line.Stage.String()
+		if line.Matches > 1 {
+			stage = fmt.Sprintf("%s (%d)", stage, line.Matches)
+		}
+		fmt.Printf("%6d %-15s %s\n", line.Line, stage, line.Content)
+	}
+	if hidden > 0 {
+		fmt.Printf("... %d more lines; raise --max-lines to list them\n", hidden)
+	}
+	return nil
+}
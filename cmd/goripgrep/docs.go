@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/localrivet/goripgrep"
+	"github.com/spf13/cobra"
+	"github.com/spf13/cobra/doc"
+)
+
+var docsDir string
+
+var manCmd = &cobra.Command{
+	Use:   "man [flags]",
+	Short: "Print the man page, or write one per command with --dir",
+	Long: `Generate man pages from the commands and their flags, with the GORIPGREP_*
+environment variables and the exit status. Without --dir the goripgrep(1)
+page is printed; with it a page is written for every command. Set
+SOURCE_DATE_EPOCH for reproducible dates.`,
+	Example: `  goripgrep man | man -l -
+  goripgrep man --dir /usr/share/man/man1`,
+	Args: cobra.NoArgs,
+	RunE: runMan,
+}
+
+var docsCmd = &cobra.Command{
+	Use:   "docs [flags]",
+	Short: "Print a Markdown reference of every command, or write one file per command with --dir",
+	Long: `Generate a Markdown reference from the commands and their flags, with the
+GORIPGREP_* environment variables and the exit status. Without --dir every
+command is printed as one document; with it a file is written per command.`,
+	Example: `  goripgrep docs > REFERENCE.md
+  goripgrep docs --dir docs/cli`,
+	Args: cobra.NoArgs,
+	RunE: runDocs,
+}
+
+func init() {
+	manCmd.Flags().StringVar(&docsDir, "dir", "", "Write a page for every command to this directory")
+	docsCmd.Flags().StringVar(&docsDir, "dir", "", "Write a file for every command to this directory")
+	rootCmd.AddCommand(manCmd, docsCmd)
+}
+
+func runMan(cmd *cobra.Command, args []string) error {
+	defer referenceRoot("# ")()
+
+	header := &doc.GenManHeader{
+		Section: "1",
+		Source:  "goripgrep " + currentBuildInfo().Version,
+		Manual:  "User Commands",
+	}
+	if docsDir == "" {
+		return doc.GenMan(rootCmd, header, os.Stdout)
+	}
+	if err := os.MkdirAll(docsDir, 0755); err != nil {
+		return err
+	}
+	return doc.GenManTree(rootCmd, header, docsDir)
+}
+
+func runDocs(cmd *cobra.Command, args []string) error {
+	defer referenceRoot("### ")()
+
+	if docsDir != "" {
+		if err := os.MkdirAll(docsDir, 0755); err != nil {
+			return err
+		}
+		return doc.GenMarkdownTree(rootCmd, docsDir)
+	}
+
+	// One document, its links between commands turned into anchors
+	anchor := func(file string) string {
+		return "#" + strings.ReplaceAll(strings.TrimSuffix(file, ".md"), "_", "-")
+	}
+	return generateMarkdown(rootCmd, os.Stdout, anchor)
+}
+
+// generateMarkdown writes the reference of cmd and of every command under it
+func generateMarkdown(cmd *cobra.Command, w io.Writer, linkHandler func(string) string) error {
+	if err := doc.GenMarkdownCustom(cmd, w, linkHandler); err != nil {
+		return err
+	}
+	for _, sub := range cmd.Commands() {
+		if !sub.IsAvailableCommand() || sub.IsAdditionalHelpTopicCommand() {
+			continue
+		}
+		if err := generateMarkdown(sub, w, linkHandler); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// referenceRoot prepares the root command for generated documentation,
+// returning a function that restores it. Its examples, part of the help
+// text, move to the example section, which keeps their layout, and the
+// environment variables and exit status follow the description under
+// headings starting with heading.
+func referenceRoot(heading string) func() {
+	long, example, autoGen := rootCmd.Long, rootCmd.Example, rootCmd.DisableAutoGenTag
+	description, examples, _ := strings.Cut(long, "\n\nBASIC USAGE:\n")
+
+	var builder strings.Builder
+	builder.WriteString(description)
+	fmt.Fprintf(&builder, "\n\n%sEnvironment\n\n", heading)
+	builder.WriteString("Flags take precedence over these variables, which take precedence over the defaults.\n\n")
+	for _, env := range goripgrep.EnvVars() {
+		fmt.Fprintf(&builder, "- `%s`: %s\n", env.Name, env.Usage)
+	}
+	fmt.Fprintf(&builder, "\n%sExit Status\n\n", heading)
+	builder.WriteString("0 when something matched, 1 when nothing did (0 with --exit-zero) and 2 on errors, including files that could not be read.\n")
+
+	rootCmd.Long = builder.String()
+	if examples != "" {
+		rootCmd.Example = "BASIC USAGE:\n" + examples
+	}
+	rootCmd.DisableAutoGenTag = true
+	return func() {
+		rootCmd.Long, rootCmd.Example, rootCmd.DisableAutoGenTag = long, example, autoGen
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/localrivet/goripgrep"
+)
+
+// runExplain prints how pattern would be matched instead of searching for it
+func runExplain(pattern string, opts []goripgrep.Option) error {
+	report, err := goripgrep.ExplainPattern(pattern, opts...)
+	if err != nil {
+		return fmt.Errorf("explain failed: %w", err)
+	}
+
+	if jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	}
+
+	printPatternReport(report)
+	return nil
+}
+
+// printPatternReport prints how a pattern is matched, one field per line
+// followed by its hazards and notes
+func printPatternReport(report *goripgrep.PatternReport) {
+	fmt.Printf("Pattern:   %s\n", report.Pattern)
+	fmt.Printf("Kind:      %s\n", report.Kind)
+	fmt.Printf("Cost:      %s\n", report.Cost)
+	if report.Literal != "" {
+		fmt.Printf("Literal:   %q\n", report.Literal)
+	}
+	if report.Prefix != "" {
+		fmt.Printf("Prefix:    %q\n", report.Prefix)
+	}
+	if len(report.Literals) > 0 {
+		quoted := make([]string, len(report.Literals))
+		for i, literal := range report.Literals {
+			quoted[i] = fmt.Sprintf("%q", literal)
+		}
+		fmt.Printf("Literals:  %s\n", strings.Join(quoted, ", "))
+	}
+	if report.RareByte != "" {
+		fmt.Printf("Rare byte: %q\n", report.RareByte)
+	}
+	for _, hazard := range report.Hazards {
+		fmt.Printf("! %s\n", hazard)
+	}
+	for _, note := range report.Notes {
+		fmt.Printf("- %s\n", note)
+	}
+}
+
+// warnSlowPattern prints the hazards of a pattern likely to be pathologically
+// slow to stderr; an invalid pattern is left for the search to report
+func warnSlowPattern(pattern string, opts []goripgrep.Option) {
+	report, err := goripgrep.ExplainPattern(pattern, opts...)
+	if err != nil {
+		return
+	}
+	for _, hazard := range report.Hazards {
+		fmt.Fprintf(os.Stderr, "Warning: slow pattern: %s\n", hazard)
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/localrivet/goripgrep"
+	"github.com/spf13/cobra"
+)
+
+var explainFileCmd = &cobra.Command{
+	Use:   "explain [flags] FILE [PATH]",
+	Short: "Show why a recursive search would or would not read a file",
+	Long: `Report whether a recursive search of PATH, the current directory by
+default, would read FILE, and which rules decided: the ignore file line that
+ignored it or a directory above it, the hidden file policy, the glob or file
+type it matched or missed, binary detection or the depth limit.
+
+Give it the same filter flags as the search to see how they combine.`,
+	Example: `  goripgrep explain build/gen.go
+  goripgrep explain -g "*.go" -g "!*_test.go" internal/x_test.go .`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runExplainFile,
+}
+
+func init() {
+	explainFileCmd.Flags().StringArrayVarP(&globs, "glob", "g", nil, "Only search files matching this glob, or skip them if it starts with ! (repeatable; later globs win)")
+	explainFileCmd.Flags().StringArrayVar(&iglobs, "iglob", nil, "Like --glob but case-insensitive (repeatable)")
+	explainFileCmd.Flags().StringArrayVar(&excludeGlobs, "exclude", nil, "Skip files and directories matching this glob (repeatable)")
+	explainFileCmd.Flags().StringArrayVarP(&fileTypes, "type", "t", nil, "Only search files of this type (repeatable)")
+	explainFileCmd.Flags().StringArrayVarP(&fileTypesNot, "type-not", "T", nil, "Skip files of this type (repeatable)")
+	explainFileCmd.Flags().BoolVar(&noIgnore, "no-ignore", false, "Don't respect ignore files")
+	explainFileCmd.Flags().StringArrayVar(&ignoreFiles, "ignore-file-name", nil, "Also read ignore files with this name in each directory, like .gitignore (repeatable)")
+	explainFileCmd.Flags().CountVarP(&unrestricted, "unrestricted", "u", "Broaden the search, repeatable, as for a search")
+	explainFileCmd.Flags().BoolVarP(&includeHidden, "hidden", ".", false, "Include hidden files and directories")
+	explainFileCmd.Flags().BoolVarP(&searchText, "text", "a", false, "Search binary files as if they were text")
+	explainFileCmd.Flags().BoolVar(&binaryMatches, "binary", false, "Search binary files but only report \"binary file matches\"")
+	explainFileCmd.Flags().BoolVarP(&searchZip, "search-zip", "z", false, "Search the contents of compressed files instead of skipping them")
+	explainFileCmd.Flags().IntVarP(&maxDepth, "max-depth", "d", 0, "Descend at most NUM directory levels")
+	explainFileCmd.Flags().BoolVar(&jsonOutput, "json", false, "Output the report in JSON format")
+
+	rootCmd.AddCommand(explainFileCmd)
+}
+
+func runExplainFile(cmd *cobra.Command, args []string) error {
+	opts := []goripgrep.Option{goripgrep.WithRecursive(true)}
+	if len(globs) > 0 {
+		opts = append(opts, goripgrep.WithIncludeGlobs(globs))
+	}
+	if len(iglobs) > 0 {
+		opts = append(opts, goripgrep.WithCaseInsensitiveGlobs(iglobs))
+	}
+	if len(excludeGlobs) > 0 {
+		opts = append(opts, goripgrep.WithExcludeGlobs(excludeGlobs))
+	}
+	if len(fileTypes) > 0 {
+		opts = append(opts, goripgrep.WithFileTypes(fileTypes))
+	}
+	if len(fileTypesNot) > 0 {
+		opts = append(opts, goripgrep.WithFileTypesNot(fileTypesNot))
+	}
+	if cmd.Flags().Changed("no-ignore") {
+		opts = append(opts, goripgrep.WithGitignore(!noIgnore))
+	}
+	if unrestricted > 0 {
+		opts = append(opts, goripgrep.WithUnrestricted(unrestricted))
+	}
+	if len(ignoreFiles) > 0 {
+		opts = append(opts, goripgrep.WithIgnoreFiles(ignoreFiles...))
+	}
+	if cmd.Flags().Changed("hidden") {
+		opts = append(opts, goripgrep.WithHiddenFiles(includeHidden))
+	}
+	switch {
+	case searchText:
+		opts = append(opts, goripgrep.WithBinaryMode(goripgrep.BinaryText))
+	case binaryMatches:
+		opts = append(opts, goripgrep.WithBinaryMode(goripgrep.BinaryReport))
+	}
+	if searchZip {
+		opts = append(opts, goripgrep.WithSearchCompressed())
+	}
+	if maxDepth > 0 {
+		opts = append(opts, goripgrep.WithMaxDepth(maxDepth))
+	}
+	// Searches run in performance mode, which changes how binary files are found
+	opts = append(opts, goripgrep.WithPerformanceMode())
+
+	path := "."
+	if len(args) == 2 {
+		path = args[1]
+	}
+	report, err := goripgrep.ExplainFile(path, args[0], opts...)
+	if err != nil {
+		return fmt.Errorf("explain failed: %w", err)
+	}
+
+	if jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	}
+
+	fmt.Printf("File:     %s\n", report.Path)
+	switch {
+	case report.Binary:
+		fmt.Println("Searched: only to report whether the binary file matches")
+	case report.Searched:
+		fmt.Println("Searched: yes")
+	default:
+		fmt.Println("Searched: no")
+	}
+
+	// Format: decision path by filter: rule
+	for _, step := range report.Steps {
+		decision := "kept"
+		if step.Excluded {
+			decision = "excluded"
+		}
+		fmt.Printf("  %-8s %s by %s", decision, step.Path, step.Filter)
+		if step.Rule != "" {
+			fmt.Printf(": %s", step.Rule)
+		}
+		fmt.Println()
+	}
+	if len(report.Steps) == 0 && report.Searched {
+		fmt.Println("  no filter applies to it")
+	}
+	return nil
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/localrivet/goripgrep"
+	"github.com/spf13/cobra"
+)
+
+// runListFiles prints the files a search of paths would read for --files,
+// one per line, exiting 1 like a search without matches when there are none
+func runListFiles(cmd *cobra.Command, paths []string, opts []goripgrep.Option) error {
+	listed := 0
+	for _, path := range paths {
+		files, err := goripgrep.ListFiles(path, opts...)
+		if err != nil {
+			return fmt.Errorf("listing failed for path %s: %w", path, err)
+		}
+		for _, file := range files {
+			fmt.Println(file)
+		}
+		listed += len(files)
+	}
+	return exitStatus(cmd, int64(listed), 0)
+}
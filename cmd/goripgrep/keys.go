@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/localrivet/goripgrep"
+	"github.com/spf13/cobra"
+)
+
+var (
+	keysShowValues bool
+	keysJSON       bool
+	keysMaxResults int
+)
+
+var keysCmd = &cobra.Command{
+	Use:   "keys [flags] PATTERN [PATH...]",
+	Short: "Audit config files for keys matching a pattern",
+	Long: `Audit dotenv, INI, TOML and YAML files for keys whose name matches PATTERN.
+
+Keys are reported with their file and line, qualified by their INI or TOML
+section or YAML parents as in database.password. Values are masked unless
+--show-values is given, so the report can be shared. Hidden files such as
+.env are always searched, and directories are searched recursively.`,
+	Example: `  goripgrep keys -i "password|secret|token" .
+  goripgrep keys --json "^AWS_" deploy/`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runKeys,
+}
+
+func init() {
+	keysCmd.Flags().BoolVar(&keysShowValues, "show-values", false, "Print the values of the keys instead of masking them")
+	keysCmd.Flags().BoolVar(&keysJSON, "json", false, "Output the keys in JSON format")
+	keysCmd.Flags().BoolVarP(&ignoreCase, "ignore-case", "i", false, "Match key names case-insensitively")
+	keysCmd.Flags().BoolVar(&useGitignore, "gitignore", true, "Respect .gitignore files")
+	keysCmd.Flags().IntVarP(&keysMaxResults, "max-count", "m", 10000, "Maximum number of candidate lines to examine")
+
+	rootCmd.AddCommand(keysCmd)
+}
+
+func runKeys(cmd *cobra.Command, args []string) error {
+	pattern := args[0]
+	paths := []string{"."}
+	if len(args) > 1 {
+		paths = args[1:]
+	}
+
+	opts := []goripgrep.Option{
+		goripgrep.WithRecursive(true),
+		goripgrep.WithGitignore(useGitignore),
+		goripgrep.WithMaxResults(keysMaxResults),
+	}
+	if ignoreCase {
+		opts = append(opts, goripgrep.WithIgnoreCase())
+	}
+	if keysShowValues {
+		opts = append(opts, goripgrep.WithConfigValues())
+	}
+
+	keys := []goripgrep.ConfigKey{}
+	for _, path := range paths {
+		found, err := goripgrep.FindConfigKeys(pattern, path, opts...)
+		if err != nil {
+			return fmt.Errorf("config key search failed for path %s: %w", path, err)
+		}
+		keys = append(keys, found...)
+	}
+
+	if keysJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(keys)
+	}
+
+	// Format: file:line:key=value
+	for _, key := range keys {
+		fmt.Printf("%s:%d:%s=%s\n", key.File, key.Line, key.Key, key.Value)
+	}
+	return nil
+}
@@ -1,44 +1,151 @@
 package main
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
 	"os"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
+	"unicode"
 
 	"github.com/localrivet/goripgrep"
 	"github.com/spf13/cobra"
+	"golang.org/x/text/language"
+	"golang.org/x/text/unicode/norm"
 )
 
 var (
 	// Global flags
 	ignoreCase     bool
+	smartCase      bool
 	contextLines   int
+	beforeContext  int
+	afterContext   int
 	maxResults     int
+	quitAfter      int
+	quiet          bool
+	strictErrors   bool
+	debugWalk      bool
+	exitZero       bool
+	noMessages     bool
+	maxFiles       int
+	maxPerFile     int
+	fileTimings    int
+	countOnly      bool
 	workers        int
+	workerGroups   int
+	pinCPUs        bool
+	deterministic  bool
+	sortText       string
+	sortOrder      goripgrep.SortOrder
+	columnText     string
+	columnMode     goripgrep.ColumnMode
 	timeout        time.Duration
 	includeHidden  bool
+	searchText     bool
+	binaryMatches  bool
 	followSymlinks bool
+	noDedupeLinks  bool
 	useGitignore   bool
+	noIgnore       bool
+	unrestricted   int
 	recursive      bool
+	maxDepth       int
+	maxPathDepth   int
 	filePattern    string
+	globs          []string
+	iglobs         []string
+	excludeGlobs   []string
+	fileTypes      []string
+	fileTypesNot   []string
+	ignoreFiles    []string
+	rotatedLogs    bool
+	searchZip      bool
+	searchArchives bool
 	jsonOutput     bool
+	formatText     string
+	outputTmpl     *goripgrep.OutputTemplate
+	outputFormat   string
+	rowWriter      *goripgrep.CSVWriter
+	exportPath     string
+	sarifRule      string
+	sarifDesc      string
 	statsOnly      bool
+	redact         bool
+	annotate       bool
+	sections       bool
+	keyPaths       bool
+	duplicates     int
+	colorMode      string
+	heading        bool
+	noHeading      bool
+	contextSep     string
+	noContextSep   bool
+	multiline      bool
+	fixedStrings   bool
+	wordRegexp     bool
+	lineRegexp     bool
+	pcre2          bool
+	invertMatch    bool
+	onlyMatching   bool
+	metadata       bool
+	namePattern    string
+	listFiles      bool
+	regexps        []string
+	patternFiles   []string
+	lineRange      string
+	normalize      string
+	unicodeLang    string
+	headBytes      int64
+	tailBytes      int64
+	replacement    string
+	dryRun         bool
+	backupSuffix   string
+	followFiles    bool
+	explainPattern bool
+	rejectSlow     bool
+	allowSlow      bool
 	version        = "dev" // Will be set during build
 )
 
+// errNoMatch ends a search that found nothing, for exit status 1
+var errNoMatch = errors.New("no match")
+
+// errUnreadable ends a search that could not read some files, already
+// reported, for exit status 2
+var errUnreadable = errors.New("some files could not be searched")
+
+// main exits like grep: 0 if anything matched, 1 if nothing did and 2 on
+// errors, so scripts can tell them apart
 func main() {
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	registerCompletions()
+	err := rootCmd.Execute()
+	switch {
+	case err == nil:
+	case errors.Is(err, errNoMatch):
 		os.Exit(1)
+	case errors.Is(err, errUnreadable):
+		os.Exit(2)
+	default:
+		if !noMessages {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
+		os.Exit(2)
 	}
 }
 
 var rootCmd = &cobra.Command{
 	Use:   "goripgrep [flags] PATTERN [PATH...]",
 	Short: "A fast text search tool written in Go",
+	// main prints errors, unless --no-messages
+	SilenceErrors: true,
 	Long: `GoRipGrep is a high-performance text search tool that provides ripgrep-like 
 functionality with native Go performance optimizations. It supports literal string 
 search, regular expressions, Unicode handling, and various output formats.
@@ -52,6 +159,7 @@ BASIC USAGE:
   goripgrep "func.*main" src/                             # Search src/ directory only
   goripgrep -r "func.*main" src/                          # Search src/ and subdirectories
   goripgrep "TODO" /path/to/project                       # Search specific directory
+  journalctl -f | goripgrep "error" -                     # Filter standard input as it arrives
 
 RECURSIVE SEARCH:
   goripgrep -r "pattern" .                                # Search all subdirectories
@@ -61,32 +169,101 @@ RECURSIVE SEARCH:
 CASE SENSITIVITY:
   goripgrep -i "Hello" .                                  # Case-insensitive search
   goripgrep -r -i "ERROR" logs/                           # Recursive case-insensitive
+  goripgrep -S "error" logs/                              # Ignore case unless the pattern has capitals
+  goripgrep --normalize nfc "café" .                      # Match composed and decomposed accents alike
+  goripgrep -i --unicode "STRASSE" .                      # Full Unicode case folding, finding "straße"
+  goripgrep -F "a.b(c)" .                                 # Literal search, no regex
+  goripgrep -w "err" .                                    # Whole word only, not "error"
+  goripgrep -x "}" main.go                                # Lines that are exactly "}"
+  goripgrep -v "^#" config.ini                            # Lines that are not comments
+  goripgrep -P "\w+(?=\()" .                              # Lookahead: names followed by (
+  goripgrep -P "\b(\w+) \1\b" .                           # Backreference: doubled words
+  goripgrep -e TODO -e FIXME src/                         # Match any of several patterns
+  goripgrep -F -f secrets.txt -r .                        # Search for every line of a file
+  goripgrep -r --metadata "invoice" ~/Documents           # Also match file names and xattrs
+
+MULTILINE:
+  goripgrep -U "func main\(\) \{\n\s+return" .            # Match across line boundaries
 
 CONTEXT LINES:
   goripgrep -C 2 "error" .                                # Show 2 lines before/after match
   goripgrep -r -C 5 "func main" src/                      # Recursive with 5 lines context
   goripgrep -C 1 "import" *.go                            # Context for imports
+  goripgrep -B 3 -A 1 "panic" .                           # 3 lines before, 1 line after
+  goripgrep -C 2 --context-separator '~~~' "error" .      # Separate groups of context with ~~~
 
 FILE FILTERING:
   goripgrep -g "*.go" "func" .                            # Search only Go files
   goripgrep -r -g "*.{js,ts}" "export" .                  # Recursive search JS/TS files
   goripgrep -g "*.log" "ERROR" /var/log/                  # Search log files only
+  goripgrep -r -g "*.go" -g "!*_test.go" "func" .         # Go files except tests
+  goripgrep -r --binary "libssl" /usr/lib                 # Name binaries that match without printing them
+  goripgrep -a "ELF" ./bin/tool                           # Search a binary file as text
+  goripgrep -r --iglob "*.md" "install" .                 # Markdown files, any case (.MD too)
+  goripgrep -r --exclude "testdata/**" "TODO" .           # Skip everything under testdata
+  goripgrep -r -t go -t md "TODO" .                       # Only Go and Markdown files
+  goripgrep -r -T web "password" .                        # Skip HTML, CSS and JS/TS files
   goripgrep -r --hidden "config" .                        # Recursive including hidden files
   goripgrep -r --follow "test" .                          # Recursive following symlinks
+  goripgrep -r -L --no-dedupe-links "test" .              # Search hard links and symlinks to the same file
+  goripgrep --follow-file "ERROR" /var/log/app.log        # Keep printing new matches, like tail -f
+  goripgrep --rotated "ERROR" /var/log/app.log            # Also search app.log.1, app.log.2.gz, oldest first
+  goripgrep -r -z "ERROR" /var/log                        # Also search inside .gz, .zst, .xz ... files
+  goripgrep -r --search-archives "log4j" ./libs           # Also search inside .jar, .zip and .tar.gz files
+  goripgrep -r --sections "deprecated" docs/              # Name the Markdown section of each match
+  goripgrep -r --key-path -t yaml "image:" k8s/           # Show where each match sits in the config
+  goripgrep -r --line-range :10 "Copyright" .             # Only check the first 10 lines
+  goripgrep --head-bytes 4096 "#!/bin/" scripts/          # Only read the start of each file
+  goripgrep --tail-bytes 1048576 "FATAL" /var/log/        # Scan the last 1MB of each log
+  goripgrep -r --files-matching-name "_test\.go$" .       # List files by name, like find
+  goripgrep -r --files -g "*.go" .                        # List the files a search would read
 
 OUTPUT FORMATS:
   goripgrep --json "error" .                              # JSON output format
+  goripgrep --format '{{.Line}},{{csv .Content}}' x a.txt # CSV rows through a Go template
+  goripgrep --output csv -C 1 TODO src/                   # Spreadsheet rows, context included
+  goripgrep -r --output sarif 'AKIA[0-9A-Z]{16}' .        # SARIF for GitHub Code Scanning
+  goripgrep -r --export run.grg -C 2 error logs/          # Save a large run for later
+  goripgrep view --output csv run.grg                     # Review or share it without searching again
+  goripgrep patterns run aws-key -r src/                  # Search with a saved, shared pattern
   goripgrep --stats "pattern" .                           # Show only statistics
+  goripgrep -r --file-timings 10 "pattern" .              # Find the files that slow a search down
+  goripgrep -r -c "TODO" .                                # Match counts per file
+  goripgrep -r -o "[a-z.]+@[a-z.]+" .                     # Print each match alone, not its line
   goripgrep -r -m 10 "TODO" .                             # Recursive with 10 result limit
+  goripgrep -r --quit-after 3 "os.Exit" .                 # Stop everything after 3 matches
+  goripgrep -r --max-count-per-file 1 "TODO" .            # First matching line of each file
+  goripgrep -q -r "DO NOT MERGE" . && exit 1              # Exit status only: 0 match, 1 none, 2 error
+  goripgrep -r --exit-zero "TODO" .                       # Exit 0 even when nothing matches
+  goripgrep -r --max-files 5 "http.NewRequest" .          # Example uses from 5 files
+  goripgrep -r --deterministic --workers 8 "TODO" .       # Same order on every run
+  goripgrep -r --sort path:natural "TODO" .               # file2 before file10
+  goripgrep -r --color=always "TODO" . | less -R          # Keep highlighting when piping (NO_COLOR disables auto)
+  goripgrep -r --heading "TODO" . | less -R               # Group by file when piping, as on a terminal
+  goripgrep -r --no-heading "TODO" .                      # file:line:col on every line, as when piped
+
+SEARCH AND REPLACE:
+  goripgrep -r --replace 'log.$1(' 'fmt.(Print\w*)\(' .   # Rewrite matches in place
+  goripgrep -r --replace NewName OldName --dry-run .      # Preview the rewrite as a diff
+  goripgrep -r --replace v2 v1 --backup .orig .           # Keep originals as *.orig
 
 PERFORMANCE TUNING:
   goripgrep -r --workers 8 "pattern" .                    # Recursive with 8 workers
   goripgrep --timeout 30s "pattern" .                     # Set 30 second timeout
   goripgrep --workers 1 "complex.*regex" .                # Single worker for complex regex
+  goripgrep -r --workers 64 --worker-groups 4 "TODO" /src # Scale past 16 cores
+  goripgrep --explain-pattern ".*Error\(" .               # Why a pattern is slow and how to speed it up
+  goripgrep debug-pattern '\w+Sushi' big.txt              # Trace every line through prefilter and regex
+  goripgrep --reject-slow-patterns "$QUERY" /srv/data     # Refuse queries that could stall a server
+  goripgrep -r --debug "TODO" . 2>&1 | grep skipped       # Find out why a file was not searched
 
 GITIGNORE HANDLING:
-  goripgrep -r --gitignore=false "test" .                 # Ignore .gitignore files
-  goripgrep -r "secret" .                                 # Respects .gitignore by default
+  goripgrep -r --no-ignore "test" .                       # Search files any ignore file excludes
+  goripgrep -r "secret" .                                 # Respects .gitignore, .ignore and .rgignore by default
+  goripgrep -r --ignore-file-name .dockerignore "TODO" .  # Also honor .dockerignore files
+  goripgrep -r -u "text" docs/                            # Also search ignored files, build dirs and .pdf files
+  goripgrep -r -uu "secret" .                             # Also search hidden files
+  goripgrep -r -uuu "magic" .                             # Also search binary files as text
 
 REAL-WORLD EXAMPLES:
   goripgrep -r -i -g "*.{go,js,py}" "TODO|FIXME" .        # Find TODO comments recursively
@@ -94,16 +271,29 @@ REAL-WORLD EXAMPLES:
   goripgrep -r -i "password|secret|key" --hidden .        # Recursive security audit
   goripgrep -r "^func [A-Z]" -g "*.go" .                  # Find exported functions
   goripgrep -r --json -m 100 "import.*react" src/         # Find React imports recursively
+  goripgrep -r --redact "AKIA[0-9A-Z]{16}" .              # Report secrets without leaking them
+  goripgrep -r --duplicates 3 -g "*.go" "^" .             # Lines repeated in 3 or more files
   goripgrep -r -C 2 "panic\|fatal" -g "*.go" .            # Find Go panics/fatals
+  goripgrep -r --json --annotate -g "*.go" "TODO" .       # Report the function around each match
 
 COMBINING FLAGS:
   goripgrep -r -i -C 2 -g "*.txt" -m 5 "hello" .          # Recursive with multiple options
   goripgrep -r --json --workers 4 --timeout 10s "error" . # Recursive performance + format
   goripgrep -r -i --hidden --follow "config" /etc/        # Comprehensive recursive search
+  goripgrep -r --max-depth 2 "module" ~/src               # Only two levels below a monorepo root
 
 UTILITY COMMANDS:
   goripgrep version                                       # Show version information
   goripgrep bench "pattern" .                             # Run performance benchmark
+  goripgrep todos .                                       # Extract TODO/FIXME/HACK comments as JSON
+  goripgrep usage github.com/spf13/cobra .                # Report Go packages importing a path
+  goripgrep keys -i "password|secret|token" .             # Audit config keys, values masked
+  goripgrep serve --listen :7700 .                        # Answer searches over HTTP with warm caches
+  goripgrep watch "TODO|FIXME" src/                       # Print matches as edits add and remove them
+  goripgrep types                                         # List the file types known to -t/-T
+  goripgrep explain vendor/lib/x.go                       # Show which rule keeps a file out of searches
+  source <(goripgrep completion bash)                     # Complete flags, types, globs and paths
+  goripgrep man | man -l -                                # Read the manual, generated from the flags
   goripgrep --help                                        # Show this help message`,
 	Args: func(cmd *cobra.Command, args []string) error {
 		// If no arguments, that's fine - we'll show help
@@ -111,7 +301,7 @@ UTILITY COMMANDS:
 			return nil
 		}
 		// If first argument is a known subcommand, let cobra handle it
-		if args[0] == "version" || args[0] == "bench" || args[0] == "help" || args[0] == "completion" {
+		if args[0] == "version" || args[0] == "bench" || args[0] == "todos" || args[0] == "usage" || args[0] == "keys" || args[0] == "types" || args[0] == "help" || args[0] == "completion" || args[0] == "man" || args[0] == "docs" {
 			return nil
 		}
 		// Otherwise, we need at least one argument (the pattern)
@@ -119,31 +309,110 @@ UTILITY COMMANDS:
 	},
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// If no arguments provided, show help
-		if len(args) == 0 {
+		if len(args) == 0 && namePattern == "" && !listFiles && len(regexps) == 0 && len(patternFiles) == 0 {
 			return cmd.Help()
 		}
-		return runSearch(cmd, args)
+		err := runSearch(cmd, args)
+		if errors.Is(err, goripgrep.ErrPCRE2Syntax) {
+			err = fmt.Errorf("%w (use -P to enable them)", err)
+		}
+		return err
 	},
 }
 
 func init() {
 	// Search behavior flags
 	rootCmd.Flags().BoolVarP(&ignoreCase, "ignore-case", "i", false, "Case-insensitive search")
+	rootCmd.Flags().BoolVarP(&smartCase, "smart-case", "S", false, "Ignore case unless the pattern has an uppercase letter; -i takes precedence")
+	rootCmd.Flags().BoolVarP(&multiline, "multiline", "U", false, "Allow matches to span multiple lines")
+	rootCmd.Flags().StringVar(&normalize, "normalize", "", "Match the pattern and lines in a Unicode normal form: nfc, nfd, nfkc or nfkd")
+	rootCmd.Flags().StringVar(&unicodeLang, "unicode", "", "Match in NFC with full Unicode case folding for a language such as tr; the language may be omitted")
+	rootCmd.Flags().Lookup("unicode").NoOptDefVal = "und"
+	rootCmd.Flags().BoolVarP(&fixedStrings, "fixed-strings", "F", false, "Treat the pattern as a literal string instead of a regex")
+	rootCmd.Flags().BoolVarP(&wordRegexp, "word-regexp", "w", false, "Only match whole words")
+	rootCmd.Flags().BoolVarP(&lineRegexp, "line-regexp", "x", false, "Only match whole lines")
+	rootCmd.Flags().BoolVarP(&pcre2, "pcre2", "P", false, "Allow lookaround and backreferences, matched by a backtracking engine")
+	rootCmd.Flags().BoolVarP(&invertMatch, "invert-match", "v", false, "Show lines that do not match the pattern")
+	rootCmd.Flags().BoolVarP(&onlyMatching, "only-matching", "o", false, "Print only the matched parts of each line, one match per line")
+	rootCmd.Flags().BoolVar(&metadata, "metadata", false, "Also match file names and extended attribute values")
+	rootCmd.Flags().BoolVar(&explainPattern, "explain-pattern", false, "Explain how the pattern is matched and what makes it slow instead of searching")
+	rootCmd.Flags().BoolVar(&rejectSlow, "reject-slow-patterns", false, "Refuse patterns likely to be pathologically slow instead of warning about them")
+	rootCmd.Flags().BoolVar(&allowSlow, "allow-slow-patterns", false, "Search with patterns likely to be pathologically slow without warning, overriding --reject-slow-patterns")
+	rootCmd.Flags().StringArrayVarP(&regexps, "regexp", "e", nil, "Search for this pattern; all arguments are then paths (repeatable)")
+	rootCmd.Flags().StringArrayVarP(&patternFiles, "file", "f", nil, "Search for the patterns in FILE, one per line; all arguments are then paths (repeatable)")
+	rootCmd.Flags().StringVar(&namePattern, "files-matching-name", "", "Match PATTERN against file names instead of contents; all arguments are paths")
+	rootCmd.Flags().BoolVar(&listFiles, "files", false, "Print the files that would be searched, without searching them; all arguments are paths")
 	rootCmd.Flags().IntVarP(&contextLines, "context", "C", 0, "Show NUM lines before and after each match")
+	rootCmd.Flags().IntVarP(&beforeContext, "before-context", "B", 0, "Show NUM lines before each match, overriding -C (0 for none)")
+	rootCmd.Flags().IntVarP(&afterContext, "after-context", "A", 0, "Show NUM lines after each match, overriding -C (0 for none)")
 	rootCmd.Flags().IntVarP(&maxResults, "max-count", "m", 1000, "Maximum number of results to return")
+	rootCmd.Flags().IntVar(&quitAfter, "quit-after", 0, "Stop the whole search after NUM matches in total")
+	rootCmd.Flags().BoolVarP(&quiet, "quiet", "q", false, "Print nothing and stop at the first match; exit 0 if anything matched, 1 if not and 2 on errors")
+	rootCmd.Flags().BoolVar(&exitZero, "exit-zero", false, "Exit 0 when nothing matched instead of 1, as before grep-style exit codes")
+	rootCmd.Flags().BoolVarP(&noMessages, "no-messages", "s", false, "Don't print error messages; the exit status is still 2 on errors")
+	rootCmd.Flags().BoolVar(&strictErrors, "strict-errors", false, "Stop at the first file or directory that cannot be read instead of reporting it and carrying on")
+	rootCmd.Flags().BoolVar(&debugWalk, "debug", false, "Print to stderr why files and directories were not searched")
+	rootCmd.Flags().IntVar(&maxFiles, "max-files", 0, "Stop the whole search once matches are found in NUM files")
+	rootCmd.Flags().IntVar(&maxPerFile, "max-count-per-file", 0, "Stop searching each file after NUM matching lines, like grep -m")
 	rootCmd.Flags().IntVar(&workers, "workers", 4, "Number of concurrent workers")
+	rootCmd.Flags().IntVar(&workerGroups, "worker-groups", 0, "Split the workers into NUM groups with their own engines, for machines with many cores (-1 picks one per NUMA node or 16 CPUs)")
+	rootCmd.Flags().BoolVar(&pinCPUs, "pin-cpus", false, "Pin each worker group to its own share of the CPUs (Linux only)")
+	rootCmd.Flags().BoolVar(&deterministic, "deterministic", false, "Print files in walk order on every run, whatever order the workers finish them in")
+	rootCmd.Flags().StringVar(&columnText, "column-mode", "", "Count columns in bytes, the default, or in runes (characters) as editors do")
+	rootCmd.Flags().StringVar(&sortText, "sort", "", "Sort the results by path: path (byte order), path:natural (file2 before file10) or path:locale")
 	rootCmd.Flags().DurationVar(&timeout, "timeout", 30*time.Second, "Search timeout")
 
 	// File filtering flags
 	rootCmd.Flags().BoolVarP(&includeHidden, "hidden", ".", false, "Include hidden files and directories")
+	rootCmd.Flags().BoolVarP(&searchText, "text", "a", false, "Search binary files as if they were text")
+	rootCmd.Flags().BoolVar(&binaryMatches, "binary", false, "Search binary files but only report \"binary file matches\"")
 	rootCmd.Flags().BoolVarP(&followSymlinks, "follow", "L", false, "Follow symbolic links")
+	rootCmd.Flags().BoolVar(&noDedupeLinks, "no-dedupe-links", false, "Search every path to a file, including hard links and symlinks to a file already searched")
+	rootCmd.Flags().BoolVar(&followFiles, "follow-file", false, "Keep searched files open and print new matches as they grow, like tail -f")
+	rootCmd.Flags().BoolVar(&rotatedLogs, "rotated", false, "When searching a log file, also search its rotated siblings (app.log.1, app.log.2.gz), oldest first")
+	rootCmd.Flags().BoolVarP(&searchZip, "search-zip", "z", false, "Search the contents of compressed files (gzip, bzip2, zstd, xz, lzma, lz4) instead of skipping them")
+	rootCmd.Flags().BoolVar(&searchArchives, "search-archives", false, "Search the files inside tar and zip archives (.tar.gz, .zip, .jar ...), shown as archive!member")
 	rootCmd.Flags().BoolVar(&useGitignore, "gitignore", true, "Respect .gitignore files")
+	rootCmd.Flags().BoolVar(&noIgnore, "no-ignore", false, "Don't respect ignore files, the same as --gitignore=false")
+	rootCmd.Flags().CountVarP(&unrestricted, "unrestricted", "u", "Broaden the search, repeatable: -u is --no-ignore that also searches skipped directories and binary-looking extensions, -uu adds --hidden, -uuu adds --text")
+	rootCmd.Flags().StringArrayVar(&ignoreFiles, "ignore-file-name", nil, "Also read ignore files with this name in each directory, like .gitignore (repeatable)")
 	rootCmd.Flags().BoolVarP(&recursive, "recursive", "r", false, "Search directories recursively")
-	rootCmd.Flags().StringVarP(&filePattern, "glob", "g", "", "Only search files matching this glob pattern")
+	rootCmd.Flags().IntVarP(&maxDepth, "max-depth", "d", 0, "Descend at most NUM directory levels when searching recursively (1 searches only the files in each path)")
+	rootCmd.Flags().IntVar(&maxPathDepth, "max-path-depth", 512, "Give up on directories more than NUM levels deep and report them as truncated, as a guard against pathological trees (0 for no limit)")
+	rootCmd.Flags().StringArrayVarP(&globs, "glob", "g", nil, "Only search files matching this glob, or skip them if it starts with ! (repeatable; later globs win)")
+	rootCmd.Flags().StringArrayVar(&iglobs, "iglob", nil, "Like --glob but case-insensitive (repeatable)")
+	rootCmd.Flags().StringArrayVar(&excludeGlobs, "exclude", nil, "Skip files and directories matching this glob (repeatable)")
+	rootCmd.Flags().StringArrayVarP(&fileTypes, "type", "t", nil, "Only search files of this type, see 'goripgrep types' (repeatable)")
+	rootCmd.Flags().StringArrayVarP(&fileTypesNot, "type-not", "T", nil, "Skip files of this type (repeatable)")
+	rootCmd.Flags().StringVar(&lineRange, "line-range", "", "Only search lines START:END of each file (either bound may be omitted)")
+	rootCmd.Flags().Int64Var(&headBytes, "head-bytes", 0, "Only search the first NUM bytes of each file")
+	rootCmd.Flags().Int64Var(&tailBytes, "tail-bytes", 0, "Only search the last NUM bytes of each file (line numbers are relative to the tail)")
 
 	// Output format flags
 	rootCmd.Flags().BoolVar(&jsonOutput, "json", false, "Output results in JSON format")
+	rootCmd.Flags().StringVar(&formatText, "format", "", "Print each match through this Go template, with .File, .Line, .Column, .Content, .Submatches and .Stats")
+	rootCmd.Flags().StringVar(&outputFormat, "output", "", "Print matches as csv or tsv rows with a header, with context lines as rows of their own, or as a sarif log for code scanning")
+	rootCmd.Flags().StringVar(&sarifRule, "sarif-rule", "goripgrep.match", "With --output sarif, the rule ID reported for every match")
+	rootCmd.Flags().StringVar(&sarifDesc, "sarif-description", "", "With --output sarif, the description of the rule (default \"Matches PATTERN\")")
+	rootCmd.Flags().StringVar(&exportPath, "export", "", "Save the results to this compressed file instead of printing them, for 'goripgrep view'")
 	rootCmd.Flags().BoolVar(&statsOnly, "stats", false, "Show only search statistics")
+	rootCmd.Flags().IntVar(&fileTimings, "file-timings", 0, "After the search, print the NUM slowest files with their scan times and sizes to stderr")
+	rootCmd.Flags().BoolVarP(&countOnly, "count", "c", false, "Show the number of matches per file instead of the matches")
+	rootCmd.Flags().BoolVar(&redact, "redact", false, "Mask the matched text in output")
+	rootCmd.Flags().IntVar(&duplicates, "duplicates", 0, "Instead of the matches, report matched lines found in at least NUM files")
+	rootCmd.Flags().BoolVar(&sections, "sections", false, "Show the Markdown heading each match falls under")
+	rootCmd.Flags().BoolVar(&keyPaths, "key-path", false, "Show the key path of each match in JSON and YAML files, like spec.containers[0].image")
+	rootCmd.Flags().BoolVar(&annotate, "annotate", false, "With --json, add the enclosing Go function or Markdown heading to each match")
+	rootCmd.Flags().StringVar(&colorMode, "color", "auto", "Highlight file names, line numbers and matches: auto, always or never")
+	rootCmd.Flags().BoolVar(&heading, "heading", false, "Print each file name once above its matches, the default on a terminal")
+	rootCmd.Flags().BoolVar(&noHeading, "no-heading", false, "Print the file name on every line, the default when output is piped")
+	rootCmd.Flags().StringVar(&contextSep, "context-separator", "--", "With context lines, print this between groups of contiguous lines")
+	rootCmd.Flags().BoolVar(&noContextSep, "no-context-separator", false, "With context lines, only merge overlapping context without separating groups")
+
+	// Replace flags
+	rootCmd.Flags().StringVar(&replacement, "replace", "", "Rewrite matches in place with this text ($1, ${name} expand capture groups)")
+	rootCmd.Flags().BoolVar(&dryRun, "dry-run", false, "With --replace, print a diff instead of modifying files")
+	rootCmd.Flags().StringVar(&backupSuffix, "backup", "", "With --replace, keep the original of each file with this suffix")
 
 	// Add subcommands
 	rootCmd.AddCommand(versionCmd)
@@ -151,118 +420,682 @@ func init() {
 }
 
 func runSearch(cmd *cobra.Command, args []string) error {
-	pattern := args[0]
+	// -q and -s print nothing for errors, not even usage
+	if quiet || noMessages {
+		cmd.SilenceUsage = true
+	}
+	// -q only needs the first match
+	if quiet {
+		quitAfter, countOnly = 1, true
+	}
+
+	var err error
+	if useColor, err = resolveColor(colorMode); err != nil {
+		return err
+	}
+
+	// Name matching and -e/-f take their patterns from flags, and listing
+	// files needs none, so every argument is a path
+	pattern, pathArgs := namePattern, args
+	var patterns []string
+	switch {
+	case namePattern != "" || listFiles:
+	case len(regexps) > 0 || len(patternFiles) > 0:
+		pattern = ""
+		patterns = append(patterns, regexps...)
+		for _, file := range patternFiles {
+			filePatterns, err := goripgrep.ReadPatternFile(file)
+			if err != nil {
+				return err
+			}
+			patterns = append(patterns, filePatterns...)
+		}
+	default:
+		pattern, pathArgs = args[0], args[1:]
+	}
 
 	// Default to current directory if no paths specified
 	paths := []string{"."}
-	if len(args) > 1 {
-		paths = args[1:]
+	if len(pathArgs) > 0 {
+		paths = pathArgs
 	}
 
 	// Build search options
 	var opts []goripgrep.Option
 
-	if workers > 0 {
+	// Flags left unset keep the library defaults, which GORIPGREP_*
+	// environment variables may have changed
+	if cmd.Flags().Changed("workers") {
 		opts = append(opts, goripgrep.WithWorkers(workers))
 	}
-	if maxResults > 0 {
+	if workerGroups < 0 {
+		workerGroups = goripgrep.SuggestedWorkerGroups()
+	}
+	if workerGroups > 1 {
+		opts = append(opts, goripgrep.WithWorkerGroups(workerGroups))
+	}
+	if pinCPUs {
+		opts = append(opts, goripgrep.WithCPUPinning())
+	}
+	if deterministic {
+		opts = append(opts, goripgrep.WithDeterministicOutput(true))
+	}
+	if cmd.Flags().Changed("max-count") {
 		opts = append(opts, goripgrep.WithMaxResults(maxResults))
 	}
-	if ignoreCase {
+	if quitAfter > 0 {
+		opts = append(opts, goripgrep.WithQuitAfter(quitAfter))
+	}
+	if quiet {
+		opts = append(opts, goripgrep.WithQuiet())
+	}
+	if strictErrors {
+		opts = append(opts, goripgrep.WithStrictErrors())
+	}
+	if debugWalk {
+		handler := slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug})
+		opts = append(opts, goripgrep.WithLogger(slog.New(handler)))
+	}
+	if maxFiles > 0 {
+		opts = append(opts, goripgrep.WithMaxFiles(maxFiles))
+	}
+	if maxPerFile > 0 {
+		opts = append(opts, goripgrep.WithMaxCountPerFile(maxPerFile))
+	}
+	if fileTimings > 0 {
+		opts = append(opts, goripgrep.WithFileTimings(fileTimings))
+	}
+	if countOnly {
+		opts = append(opts, goripgrep.WithCountOnly())
+	}
+	// Flags given explicitly apply either way, so =false can undo what a
+	// GORIPGREP_* variable turned on
+	switch {
+	case ignoreCase:
 		opts = append(opts, goripgrep.WithIgnoreCase())
+	case smartCase:
+		opts = append(opts, goripgrep.WithSmartCase())
+	case cmd.Flags().Changed("ignore-case") || cmd.Flags().Changed("smart-case"):
+		opts = append(opts, goripgrep.WithCaseSensitive())
+	}
+	if multiline {
+		opts = append(opts, goripgrep.WithMultiline())
+	}
+	if normalize != "" {
+		form, err := parseNormalForm(normalize)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, goripgrep.WithUnicodeNormalization(form))
 	}
-	if contextLines > 0 {
-		opts = append(opts, goripgrep.WithContextLines(contextLines))
+	if unicodeLang != "" {
+		lang, err := language.Parse(unicodeLang)
+		if err != nil {
+			return fmt.Errorf("invalid --unicode language %q: %w", unicodeLang, err)
+		}
+		opts = append(opts, goripgrep.WithUnicodeEngine(lang))
+	}
+	if len(patterns) > 0 {
+		opts = append(opts, goripgrep.WithPatterns(patterns))
+	}
+	if fixedStrings {
+		opts = append(opts, goripgrep.WithFixedStrings())
+	}
+	if wordRegexp {
+		opts = append(opts, goripgrep.WithWordRegexp())
+	}
+	if lineRegexp {
+		opts = append(opts, goripgrep.WithLineRegexp())
+	}
+	if pcre2 {
+		opts = append(opts, goripgrep.WithPCRE2Syntax())
+	}
+	if invertMatch {
+		opts = append(opts, goripgrep.WithInvertMatch())
+	}
+	if onlyMatching {
+		opts = append(opts, goripgrep.WithOnlyMatching())
+	}
+	if metadata {
+		opts = append(opts, goripgrep.WithMetadata())
+	}
+	if namePattern != "" {
+		opts = append(opts, goripgrep.WithFileNamesOnly())
+	}
+	// -A and -B override -C for their side, even to turn it off with 0
+	if !cmd.Flags().Changed("before-context") {
+		beforeContext = contextLines
+	}
+	if !cmd.Flags().Changed("after-context") {
+		afterContext = contextLines
+	}
+	if beforeContext > 0 || afterContext > 0 {
+		opts = append(opts, goripgrep.WithBeforeContext(beforeContext), goripgrep.WithAfterContext(afterContext))
+	}
+	if lineRange != "" {
+		start, end, err := parseLineRange(lineRange)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, goripgrep.WithLineRange(start, end))
+	}
+	if headBytes > 0 {
+		opts = append(opts, goripgrep.WithHeadBytes(headBytes))
 	}
-	if filePattern != "" {
-		opts = append(opts, goripgrep.WithFilePattern(filePattern))
+	if tailBytes > 0 {
+		opts = append(opts, goripgrep.WithTailBytes(tailBytes))
 	}
-	if !useGitignore {
-		opts = append(opts, goripgrep.WithGitignore(false))
+	if len(globs) > 0 {
+		opts = append(opts, goripgrep.WithIncludeGlobs(globs))
 	}
-	if includeHidden {
-		opts = append(opts, goripgrep.WithHidden())
+	if len(iglobs) > 0 {
+		opts = append(opts, goripgrep.WithCaseInsensitiveGlobs(iglobs))
 	}
-	if followSymlinks {
-		opts = append(opts, goripgrep.WithSymlinks())
+	if len(excludeGlobs) > 0 {
+		opts = append(opts, goripgrep.WithExcludeGlobs(excludeGlobs))
+	}
+	if len(fileTypes) > 0 {
+		opts = append(opts, goripgrep.WithFileTypes(fileTypes))
+	}
+	if len(fileTypesNot) > 0 {
+		opts = append(opts, goripgrep.WithFileTypesNot(fileTypesNot))
+	}
+	if cmd.Flags().Changed("gitignore") || cmd.Flags().Changed("no-ignore") {
+		opts = append(opts, goripgrep.WithGitignore(useGitignore && !noIgnore))
+	}
+	if unrestricted > 0 {
+		opts = append(opts, goripgrep.WithUnrestricted(unrestricted))
+	}
+	if len(ignoreFiles) > 0 {
+		opts = append(opts, goripgrep.WithIgnoreFiles(ignoreFiles...))
+	}
+	if sections {
+		opts = append(opts, goripgrep.WithSections())
+	}
+	if keyPaths {
+		opts = append(opts, goripgrep.WithKeyPaths())
+	}
+	if rotatedLogs {
+		opts = append(opts, goripgrep.WithRotatedLogs())
+	}
+	if searchZip {
+		opts = append(opts, goripgrep.WithSearchCompressed())
+	}
+	if searchArchives {
+		opts = append(opts, goripgrep.WithArchiveSearch())
+	}
+	if cmd.Flags().Changed("hidden") {
+		opts = append(opts, goripgrep.WithHiddenFiles(includeHidden))
+	}
+	if cmd.Flags().Changed("follow") {
+		opts = append(opts, goripgrep.WithFollowSymlinks(followSymlinks))
+	}
+	if noDedupeLinks {
+		opts = append(opts, goripgrep.WithDedupeLinks(false))
+	}
+	switch {
+	case searchText:
+		opts = append(opts, goripgrep.WithBinaryMode(goripgrep.BinaryText))
+	case binaryMatches:
+		opts = append(opts, goripgrep.WithBinaryMode(goripgrep.BinaryReport))
 	}
 	if recursive {
 		opts = append(opts, goripgrep.WithRecursive(true))
 	}
+	if cmd.Flags().Changed("max-path-depth") {
+		opts = append(opts, goripgrep.WithMaxPathDepth(maxPathDepth))
+	}
+	if maxDepth > 0 {
+		opts = append(opts, goripgrep.WithMaxDepth(maxDepth))
+	}
 
-	// Add context for timeout
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
-	defer cancel()
+	// Add context for an explicit --timeout. Otherwise searches of files get
+	// the default timeout, or GORIPGREP_TIMEOUT, while standard input, pipes
+	// and followed files, which may never end, get none.
+	ctx := context.Background()
+	if cmd.Flags().Changed("timeout") {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
 	opts = append(opts, goripgrep.WithContext(ctx))
 
 	// Enable performance mode by default for better speed
 	opts = append(opts, goripgrep.WithPerformanceMode())
 
+	if listFiles {
+		return runListFiles(cmd, paths, opts)
+	}
+	if explainPattern {
+		return runExplain(pattern, opts)
+	}
+	if !allowSlow {
+		if rejectSlow {
+			opts = append(opts, goripgrep.WithRejectSlowPatterns())
+		} else {
+			warnSlowPattern(pattern, opts)
+		}
+	}
+
+	// An empty replacement is valid and deletes the matches
+	if cmd.Flags().Changed("replace") {
+		return runReplace(pattern, paths, opts)
+	}
+
+	if annotate && !jsonOutput {
+		return fmt.Errorf("--annotate only applies to --json output")
+	}
+	if err := setupOutput(); err != nil {
+		return err
+	}
+	if sortOrder != goripgrep.SortNone {
+		opts = append(opts, goripgrep.WithSort(sortOrder))
+	}
+	if columnMode != goripgrep.ColumnBytes {
+		opts = append(opts, goripgrep.WithColumnMode(columnMode))
+	}
+	if exportPath != "" && (jsonOutput || countOnly || statsOnly || duplicates > 0 || formatText != "" || outputFormat != "") {
+		return fmt.Errorf("--export saves the results instead of printing them; format them later with 'goripgrep view'")
+	}
+
+	// Compile the pattern used to mask matches when redacting output
+	var redactPattern *regexp.Regexp
+	if redact {
+		alternatives := append([]string{pattern}, patterns...)
+		if pattern == "" {
+			alternatives = patterns
+		}
+		for i, alternative := range alternatives {
+			insensitive := smartCase && !ignoreCase && goripgrep.SmartCaseInsensitive(alternative, fixedStrings)
+			if fixedStrings {
+				alternative = regexp.QuoteMeta(alternative)
+			}
+			alternatives[i] = "(?:" + alternative + ")"
+			if insensitive {
+				alternatives[i] = "(?i:" + alternative + ")"
+			}
+		}
+		expr := strings.Join(alternatives, "|")
+		if lineRegexp {
+			expr = "^(?:" + expr + ")$"
+		} else if wordRegexp {
+			expr = `\b(?:` + expr + `)\b`
+		}
+		if multiline {
+			expr = "(?m)" + expr
+		}
+		if ignoreCase {
+			expr = "(?i)" + expr
+		}
+		var err error
+		redactPattern, err = regexp.Compile(expr)
+		if err != nil && pcre2 {
+			return fmt.Errorf("--redact does not support PCRE2 syntax: %w", err)
+		}
+		if err != nil {
+			return fmt.Errorf("invalid pattern for redaction: %w", err)
+		}
+	}
+
+	if followFiles {
+		return runFollow(pattern, paths, opts, redactPattern)
+	}
+
 	var allResults []*goripgrep.SearchResults
 	var totalStats goripgrep.SearchStats
+	matchedFiles := 0
 
-	// Search each path
+	// Paths on disk next to each other are searched together by one pool of
+	// workers, and each - searches standard input in its place between them
+	var groups [][]string
 	for _, path := range paths {
-		results, err := goripgrep.Find(pattern, path, opts...)
+		if path == "-" || len(groups) == 0 || groups[len(groups)-1][0] == "-" {
+			groups = append(groups, []string{path})
+		} else {
+			groups[len(groups)-1] = append(groups[len(groups)-1], path)
+		}
+	}
+
+	// Search each group of paths
+	for _, group := range groups {
+		// The quit-after and max-files limits are shared by all paths
+		pathOpts := opts[:len(opts):len(opts)]
+		if quitAfter > 0 {
+			remaining := quitAfter - int(totalStats.MatchesFound)
+			pathOpts = append(pathOpts, goripgrep.WithQuitAfter(remaining))
+		}
+		if maxFiles > 0 {
+			pathOpts = append(pathOpts, goripgrep.WithMaxFiles(maxFiles-matchedFiles))
+		}
+
+		// A path of - searches standard input
+		if group[0] == "-" {
+			results, err := searchStdin(pattern, pathOpts, redactPattern)
+			if err != nil {
+				return fmt.Errorf("search failed for standard input: %w", err)
+			}
+			allResults = append(allResults, results)
+			matchedFiles += len(results.Files())
+			totalStats.BytesRead += results.Stats.BytesRead
+			totalStats.BytesScanned += results.Stats.BytesScanned
+			totalStats.FilesScanned += results.Stats.FilesScanned
+			totalStats.MatchesFound += results.Stats.MatchesFound
+			totalStats.NonMatchingLines += results.Stats.NonMatchingLines
+			totalStats.Phases.Read += results.Stats.Phases.Read
+			totalStats.Phases.Match += results.Stats.Phases.Match
+			if totalStats.Duration < results.Stats.Duration {
+				totalStats.Duration = results.Stats.Duration
+			}
+			if results.Stats.StoppedEarly {
+				totalStats.StoppedEarly = true
+				break
+			}
+			continue
+		}
+
+		results, err := goripgrep.FindMulti(pattern, group, pathOpts...)
 		if err != nil {
-			return fmt.Errorf("search failed for path %s: %w", path, err)
+			return fmt.Errorf("search failed for path %s: %w", strings.Join(group, ", "), err)
+		}
+
+		if redact {
+			results = results.Transform(goripgrep.Redact(redactPattern))
+		}
+		if annotate {
+			results = results.Transform(goripgrep.Annotate())
 		}
 
 		allResults = append(allResults, results)
+		matchedFiles += len(results.Files())
 
 		// Accumulate stats
 		totalStats.FilesScanned += results.Stats.FilesScanned
 		totalStats.FilesSkipped += results.Stats.FilesSkipped
 		totalStats.FilesIgnored += results.Stats.FilesIgnored
 		totalStats.BytesScanned += results.Stats.BytesScanned
+		totalStats.BytesRead += results.Stats.BytesRead
+		totalStats.FilesChanged += results.Stats.FilesChanged
+		totalStats.DirsTruncated += results.Stats.DirsTruncated
 		totalStats.MatchesFound += results.Stats.MatchesFound
+		totalStats.NonMatchingLines += results.Stats.NonMatchingLines
+		totalStats.Phases.Walk += results.Stats.Phases.Walk
+		totalStats.Phases.Filter += results.Stats.Phases.Filter
+		totalStats.Phases.Read += results.Stats.Phases.Read
+		totalStats.Phases.Match += results.Stats.Phases.Match
+		totalStats.Phases.Decompress += results.Stats.Phases.Decompress
+		totalStats.SlowestFiles = append(totalStats.SlowestFiles, results.Stats.SlowestFiles...)
 		if totalStats.Duration < results.Stats.Duration {
 			totalStats.Duration = results.Stats.Duration
 		}
+
+		if results.Stats.StoppedEarly {
+			totalStats.StoppedEarly = true
+			if quitAfter > 0 && totalStats.MatchesFound >= int64(quitAfter) {
+				break
+			}
+			if maxFiles > 0 && matchedFiles >= maxFiles {
+				break
+			}
+		}
+	}
+
+	// Files that could not be read are reported like grep does, after the
+	// search rather than between its results
+	var fileErrors []goripgrep.FileError
+	for _, results := range allResults {
+		fileErrors = append(fileErrors, results.Errors...)
+	}
+	if !noMessages {
+		defer outputFileErrors(fileErrors)
+	}
+
+	if quiet {
+		return exitStatus(cmd, totalStats.MatchesFound, len(fileErrors))
+	}
+
+	// Each path has its own slowest files; keep the slowest of them all
+	slices.SortStableFunc(totalStats.SlowestFiles, func(a, b goripgrep.FileTiming) int {
+		return cmp.Compare(b.Duration, a.Duration)
+	})
+	if len(totalStats.SlowestFiles) > fileTimings {
+		totalStats.SlowestFiles = totalStats.SlowestFiles[:fileTimings]
+	}
+	defer outputFileTimings(totalStats.SlowestFiles)
+
+	if exportPath != "" {
+		results := combineResults(allResults, totalStats)
+		if err := results.ExportFile(exportPath); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Exported %d matches to %s\n", results.Count(), exportPath)
+		return exitStatus(cmd, totalStats.MatchesFound, len(fileErrors))
+	}
+
+	if err := outputResults(allResults, totalStats); err != nil {
+		return err
+	}
+	return exitStatus(cmd, totalStats.MatchesFound, len(fileErrors))
+}
+
+// exitStatus returns the error that sets grep's exit status for a search:
+// errUnreadable for 2 when files could not be read, unless -q found a
+// match, and errNoMatch for 1 when nothing matched, unless --exit-zero
+// keeps the old exit status of 0
+func exitStatus(cmd *cobra.Command, matches int64, unreadable int) error {
+	switch {
+	case unreadable > 0 && !(quiet && matches > 0):
+		cmd.SilenceUsage = true
+		return errUnreadable
+	case matches > 0 || exitZero:
+		return nil
+	}
+	cmd.SilenceUsage = true
+	return errNoMatch
+}
+
+// outputFileErrors prints the files and directories that could not be
+// searched to stderr
+func outputFileErrors(fileErrors []goripgrep.FileError) {
+	for _, fileErr := range fileErrors {
+		fmt.Fprintf(os.Stderr, "goripgrep: %v\n", &fileErr)
+	}
+}
+
+// outputFileTimings prints the slowest files for --file-timings to stderr,
+// where it stays out of the way of the results
+func outputFileTimings(timings []goripgrep.FileTiming) {
+	if len(timings) == 0 {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "\nSlowest %d files:\n", len(timings))
+	for _, timing := range timings {
+		fmt.Fprintf(os.Stderr, "%12v %12d bytes  %s\n", timing.Duration.Round(time.Microsecond), timing.Size, timing.Path)
+	}
+}
+
+// setupOutput checks the output flags and prepares --format and --output
+func setupOutput() error {
+	var err error
+	if sortOrder, err = goripgrep.ParseSortOrder(sortText); err != nil {
+		return err
+	}
+	if columnMode, err = goripgrep.ParseColumnMode(columnText); err != nil {
+		return err
+	}
+
+	// Groups of context lines are separated like grep's --
+	if (beforeContext > 0 || afterContext > 0) && !noContextSep {
+		contextMerger = goripgrep.NewContextMerger(contextSep)
+	}
+
+	// Matches are grouped under file headings on a terminal, like ripgrep
+	if noHeading {
+		heading = false
+	} else if !heading {
+		heading = stdoutIsTerminal()
+	}
+
+	if formatText != "" {
+		if jsonOutput || countOnly || statsOnly || duplicates > 0 {
+			return fmt.Errorf("--format cannot be combined with --json, --count, --stats or --duplicates")
+		}
+		if outputTmpl, err = goripgrep.ParseOutputTemplate(formatText); err != nil {
+			return err
+		}
 	}
+	if outputFormat != "" {
+		if jsonOutput || countOnly || statsOnly || duplicates > 0 || formatText != "" {
+			return fmt.Errorf("--output cannot be combined with --json, --count, --stats, --duplicates or --format")
+		}
+		switch outputFormat {
+		case "csv":
+			rowWriter = goripgrep.NewCSVWriter(os.Stdout)
+		case "tsv":
+			rowWriter = goripgrep.NewTSVWriter(os.Stdout)
+		case "sarif":
+			return nil
+		default:
+			return fmt.Errorf("invalid --output %q: want csv, tsv or sarif", outputFormat)
+		}
+		rowWriter.Context = beforeContext > 0 || afterContext > 0
+	}
+	if duplicates > 0 && countOnly {
+		return fmt.Errorf("--duplicates needs the matches and cannot be combined with --count")
+	}
+	return nil
+}
 
-	// Output results
+// outputResults prints the results in the format chosen by the flags
+func outputResults(allResults []*goripgrep.SearchResults, totalStats goripgrep.SearchStats) error {
 	if statsOnly {
 		return outputStats(totalStats)
 	}
 
+	if countOnly {
+		return outputCounts(allResults)
+	}
+
+	if duplicates > 0 {
+		return outputDuplicates(allResults)
+	}
+
 	if jsonOutput {
 		return outputJSON(allResults, totalStats)
 	}
 
+	if outputTmpl != nil {
+		return outputTmpl.Execute(os.Stdout, &goripgrep.SearchResults{Matches: getAllMatches(allResults), Stats: totalStats})
+	}
+
+	if rowWriter != nil {
+		return rowWriter.Write(&goripgrep.SearchResults{Matches: getAllMatches(allResults)})
+	}
+
+	if outputFormat == "sarif" {
+		data, err := goripgrep.ToSARIF(combineResults(allResults, totalStats), sarifRule, sarifDesc)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Println(string(data))
+		return err
+	}
+
 	return outputText(allResults, totalStats)
 }
 
+// combineResults merges the results of every searched path into one set
+func combineResults(allResults []*goripgrep.SearchResults, totalStats goripgrep.SearchStats) *goripgrep.SearchResults {
+	combined := &goripgrep.SearchResults{Matches: getAllMatches(allResults), Stats: totalStats}
+	for _, results := range allResults {
+		if combined.Query == "" {
+			combined.Query = results.Query
+		}
+		combined.Errors = append(combined.Errors, results.Errors...)
+		for file, count := range results.Counts {
+			if combined.Counts == nil {
+				combined.Counts = make(map[string]int)
+			}
+			combined.Counts[file] += count
+		}
+	}
+	return combined
+}
+
+// outputDuplicates prints the matched lines found in at least --duplicates
+// files, most widespread first
+func outputDuplicates(results []*goripgrep.SearchResults) error {
+	combined := &goripgrep.SearchResults{Matches: getAllMatches(results)}
+	lines := combined.DuplicateLines(duplicates)
+
+	if jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(map[string]interface{}{
+			"query":      results[0].Query,
+			"duplicates": lines,
+		})
+	}
+
+	// Format: files:count:content, then file:line for each occurrence
+	for i, line := range lines {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("%d files, %d times: %s\n", line.Files, line.Count, line.Content)
+		for _, match := range line.Matches {
+			fmt.Printf("  %s:%s\n", colorPathName(match.File), colorLineNumber(match.Line, ""))
+		}
+	}
+	return nil
+}
+
+// outputCounts prints the per-file match counts, ordered by file
+func outputCounts(results []*goripgrep.SearchResults) error {
+	counts := make(map[string]int)
+	for _, result := range results {
+		for file, count := range result.Counts {
+			counts[file] += count
+		}
+	}
+
+	if jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(map[string]interface{}{
+			"query":  results[0].Query,
+			"counts": counts,
+		})
+	}
+
+	files := make([]string, 0, len(counts))
+	for file := range counts {
+		files = append(files, file)
+	}
+	// Counts come from a map, so they are always sorted, by path unless
+	// another order is asked for
+	order := sortOrder
+	if order == goripgrep.SortNone {
+		order = goripgrep.SortPath
+	}
+	goripgrep.SortPaths(files, order)
+
+	// Format: file:count
+	for _, file := range files {
+		fmt.Printf("%s:%d\n", colorPathName(file), counts[file])
+	}
+	return nil
+}
+
 func outputText(results []*goripgrep.SearchResults, stats goripgrep.SearchStats) error {
 	totalMatches := 0
 
 	for _, result := range results {
 		for _, match := range result.Matches {
 			totalMatches++
-
-			// Format: file:line:column:content
-			fmt.Printf("%s:%d:%d:%s\n",
-				match.File,
-				match.Line,
-				match.Column,
-				strings.TrimSpace(match.Content))
-
-			// Show context lines if requested
-			for i, contextLine := range match.Context {
-				if i < contextLines { // Before context
-					fmt.Printf("%s:%d-:%s\n",
-						match.File,
-						match.Line-contextLines+i,
-						strings.TrimSpace(contextLine))
-				} else if i >= contextLines+1 { // After context
-					fmt.Printf("%s:%d+:%s\n",
-						match.File,
-						match.Line+i-contextLines,
-						strings.TrimSpace(contextLine))
-				}
-			}
+			printMatch(match)
 		}
 	}
+	flushContext()
 
 	// Show summary if multiple files or verbose
 	if len(results) > 1 || totalMatches > 10 {
@@ -276,6 +1109,238 @@ func outputText(results []*goripgrep.SearchResults, stats goripgrep.SearchStats)
 	return nil
 }
 
+// isStreamPath reports whether path is standard input, a named pipe or a
+// process substitution path like /dev/fd/63, which are read as streams
+func isStreamPath(path string) bool {
+	if path == "-" {
+		return true
+	}
+	info, err := os.Stat(path)
+	return err == nil && info.Mode()&(os.ModeNamedPipe|os.ModeSocket|os.ModeCharDevice) != 0
+}
+
+// printMatch prints a single match in the text output format
+func printMatch(match goripgrep.Match) {
+	// Streamed matches go through --format or --output as they arrive
+	if outputTmpl != nil {
+		if err := outputTmpl.ExecuteMatch(os.Stdout, match, goripgrep.SearchStats{}); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		return
+	}
+	if rowWriter != nil {
+		if err := rowWriter.WriteMatch(match); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		return
+	}
+
+	// Name matching lists paths only, like find
+	if namePattern != "" {
+		fmt.Println(colorPathName(match.File))
+		return
+	}
+
+	// Context shared with the previous match is printed once; the match's
+	// own after context waits for the next match or flushContext
+	for _, line := range contextMerger.Add(match) {
+		printLine(line)
+	}
+}
+
+// flushContext prints the after context held back from the last match
+func flushContext() {
+	for _, line := range contextMerger.Flush() {
+		printLine(line)
+	}
+}
+
+// printLine prints a match or context line in the text output format
+func printLine(line goripgrep.ContextLine) {
+	file := colorPathName(line.File)
+
+	// Lines start with the file name, or are grouped under it with
+	// --heading, where the blank line before a heading separates files
+	prefix := file + ":"
+	if heading {
+		if line.Separator != "" && headingFile != nil && *headingFile == line.File {
+			fmt.Println(line.Separator)
+		}
+		printHeading(line.File)
+		prefix = ""
+	} else if line.Separator != "" {
+		fmt.Println(line.Separator)
+	}
+
+	// Context lines are marked - before a match and + after one
+	if line.Match == nil {
+		marker := "-"
+		if line.After {
+			marker = "+"
+		}
+		fmt.Printf("%s%s:%s\n", prefix, colorLineNumber(line.Line, marker), strings.TrimSpace(line.Content))
+		return
+	}
+	match := *line.Match
+
+	// Metadata matches have no line; format: file:kind:content
+	content := highlightSpan(match.Content, match.MatchStart, match.MatchEnd)
+	switch match.Kind {
+	case goripgrep.MatchFileName:
+		fmt.Printf("%s%s:%s\n", prefix, match.Kind, content)
+		return
+	case goripgrep.MatchXattr:
+		fmt.Printf("%s%s[%s]:%s\n", prefix, match.Kind, match.Attribute, content)
+		return
+	case goripgrep.MatchBinary:
+		if heading {
+			fmt.Println("binary file matches")
+		} else {
+			fmt.Printf("%s: binary file matches\n", file)
+		}
+		return
+	}
+
+	// Multiline matches print every spanned line with its own line number
+	if match.EndLine > match.Line {
+		offset := 0
+		for i, line := range strings.Split(match.Content, "\n") {
+			fmt.Printf("%s%s:%s\n", prefix, colorLineNumber(match.Line+i, ""),
+				highlightSpan(line, match.MatchStart-offset, match.MatchEnd-offset))
+			offset += len(line) + 1
+		}
+		return
+	}
+
+	// Format: file:line:column:content, with the span shifted past the
+	// trimmed indentation and any Markdown section or key path before the
+	// content
+	trimmed := strings.TrimLeftFunc(match.Content, unicode.IsSpace)
+	indent := len(match.Content) - len(trimmed)
+	section := ""
+	if match.Section != "" {
+		section = "[" + match.Section + "] "
+	} else if match.KeyPath != "" {
+		section = "[" + match.KeyPath + "] "
+	}
+	fmt.Printf("%s%s:%d:%s%s\n",
+		prefix,
+		colorLineNumber(match.Line, ""),
+		match.Column,
+		section,
+		highlightSpan(strings.TrimRightFunc(trimmed, unicode.IsSpace), match.MatchStart-indent, match.MatchEnd-indent))
+}
+
+// contextMerger merges the context of neighbouring matches in text output,
+// separating groups once setupOutput sees context was requested
+var contextMerger = goripgrep.NewContextMerger("")
+
+// headingFile is the file whose heading was printed last with --heading
+var headingFile *string
+
+// printHeading prints the name of file above its matches when they start,
+// separated from the previous file's matches by a blank line
+func printHeading(file string) {
+	if headingFile != nil && *headingFile == file {
+		return
+	}
+	if headingFile != nil {
+		fmt.Println()
+	}
+	headingFile = &file
+	fmt.Println(colorPathName(file))
+}
+
+// searchStdin searches standard input as a stream. Text output is printed as
+// matches arrive, so endless streams can be filtered; the other output modes
+// collect up to --max-count matches and print them with the other results.
+func searchStdin(pattern string, opts []goripgrep.Option, redactPattern *regexp.Regexp) (*goripgrep.SearchResults, error) {
+	results := &goripgrep.SearchResults{Query: pattern}
+	streaming := !jsonOutput && !statsOnly && !countOnly && duplicates == 0 && exportPath == "" && outputFormat != "sarif"
+
+	stats, err := goripgrep.FindReader(pattern, os.Stdin, func(match goripgrep.Match) error {
+		if redactPattern != nil {
+			match = goripgrep.Redact(redactPattern)([]goripgrep.Match{match})[0]
+		}
+		if streaming {
+			printMatch(match)
+		} else if maxResults <= 0 || len(results.Matches) < maxResults {
+			results.Matches = append(results.Matches, match)
+		}
+		return nil
+	}, opts...)
+	if streaming {
+		flushContext()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	results.Stats = *stats
+	if countOnly && stats.MatchesFound > 0 {
+		results.Counts = map[string]int{goripgrep.StdinName: int(stats.MatchesFound)}
+	}
+	return results, nil
+}
+
+// runFollow prints the matches in paths and then new matches as the files
+// grow, until interrupted
+func runFollow(pattern string, paths []string, opts []goripgrep.Option, redactPattern *regexp.Regexp) error {
+	if jsonOutput || countOnly || statsOnly || namePattern != "" || exportPath != "" || outputFormat == "sarif" {
+		return fmt.Errorf("--follow-file only supports plain text output")
+	}
+	if slices.Contains(paths, "-") {
+		return fmt.Errorf("--follow-file cannot follow standard input")
+	}
+
+	return goripgrep.Follow(pattern, paths, func(match goripgrep.Match) error {
+		if redactPattern != nil {
+			match = goripgrep.Redact(redactPattern)([]goripgrep.Match{match})[0]
+		}
+		// New lines have no after context yet, so nothing is held back
+		printMatch(match)
+		flushContext()
+		return nil
+	}, opts...)
+}
+
+// parseNormalForm parses the name of a Unicode normal form
+func parseNormalForm(value string) (norm.Form, error) {
+	switch strings.ToLower(value) {
+	case "nfc":
+		return norm.NFC, nil
+	case "nfd":
+		return norm.NFD, nil
+	case "nfkc":
+		return norm.NFKC, nil
+	case "nfkd":
+		return norm.NFKD, nil
+	}
+	return 0, fmt.Errorf("invalid normal form %q: expected nfc, nfd, nfkc or nfkd", value)
+}
+
+// parseLineRange parses START:END, where either bound may be omitted
+func parseLineRange(value string) (int, int, error) {
+	startText, endText, found := strings.Cut(value, ":")
+	if !found {
+		return 0, 0, fmt.Errorf("invalid line range %q: expected START:END", value)
+	}
+
+	start, end := 1, 0
+	var err error
+	if startText != "" {
+		if start, err = strconv.Atoi(startText); err != nil || start < 1 {
+			return 0, 0, fmt.Errorf("invalid line range start %q", startText)
+		}
+	}
+	if endText != "" {
+		if end, err = strconv.Atoi(endText); err != nil || end < 1 {
+			return 0, 0, fmt.Errorf("invalid line range end %q", endText)
+		}
+	}
+	return start, end, nil
+}
+
 func outputJSON(results []*goripgrep.SearchResults, stats goripgrep.SearchStats) error {
 	output := map[string]interface{}{
 		"query":   results[0].Query, // Assuming same query for all
@@ -293,8 +1358,30 @@ func outputStats(stats goripgrep.SearchStats) error {
 	fmt.Printf("Files skipped: %d\n", stats.FilesSkipped)
 	fmt.Printf("Files ignored: %d\n", stats.FilesIgnored)
 	fmt.Printf("Bytes scanned: %d\n", stats.BytesScanned)
+	fmt.Printf("Bytes read: %d\n", stats.BytesRead)
 	fmt.Printf("Matches found: %d\n", stats.MatchesFound)
+	if stats.NonMatchingLines > 0 {
+		fmt.Printf("Non-matching lines: %d\n", stats.NonMatchingLines)
+	}
+	if stats.FilesChanged > 0 {
+		fmt.Printf("Files changed during search: %d\n", stats.FilesChanged)
+	}
+	if stats.DirsTruncated > 0 {
+		fmt.Printf("Directories truncated: %d (too deep or looping)\n", stats.DirsTruncated)
+	}
+	if stats.StoppedEarly {
+		fmt.Println("Stopped early: result limit reached")
+	}
 	fmt.Printf("Duration: %v\n", stats.Duration)
+
+	// Worker phases are summed across workers and can exceed the duration
+	fmt.Printf("  Walk: %v\n", stats.Phases.Walk)
+	fmt.Printf("  Filter: %v\n", stats.Phases.Filter)
+	fmt.Printf("  Read: %v\n", stats.Phases.Read)
+	fmt.Printf("  Match: %v\n", stats.Phases.Match)
+	if stats.Phases.Decompress > 0 {
+		fmt.Printf("  Decompress: %v\n", stats.Phases.Decompress)
+	}
 	return nil
 }
 
@@ -321,16 +1408,6 @@ func getUniqueFiles(results []*goripgrep.SearchResults) []string {
 	return files
 }
 
-var versionCmd = &cobra.Command{
-	Use:   "version",
-	Short: "Print version information",
-	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Printf("goripgrep %s\n", version)
-		fmt.Println("A fast text search tool written in Go")
-		fmt.Println("https://github.com/localrivet/goripgrep")
-	},
-}
-
 var benchCmd = &cobra.Command{
 	Use:   "bench [flags] PATTERN [PATH...]",
 	Short: "Run performance benchmarks",
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/localrivet/goripgrep"
+	"github.com/spf13/cobra"
+)
+
+var (
+	patternsFile        string
+	patternsDescription string
+	patternsForce       bool
+	patternsJSON        bool
+)
+
+var patternsCmd = &cobra.Command{
+	Use:   "patterns",
+	Short: "Save, list and run named patterns from a shared library",
+	Long: `Keep frequently used regexes under a name, with a description, so a team
+can share vetted patterns instead of pasting fragile regex strings around.
+
+The library is a JSON file, patterns.json in the goripgrep config directory
+by default. Point --library or GORIPGREP_PATTERNS at a file checked into a
+repository to share it.`,
+	Example: `  goripgrep patterns add -d "AWS access key IDs" aws-key "AKIA[0-9A-Z]{16}"
+  goripgrep patterns list
+  goripgrep patterns run aws-key -r src/`,
+}
+
+var patternsAddCmd = &cobra.Command{
+	Use:   "add [flags] NAME PATTERN",
+	Short: "Save a pattern under a name",
+	Args:  cobra.ExactArgs(2),
+	RunE:  runPatternsAdd,
+}
+
+var patternsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the saved patterns",
+	Args:  cobra.NoArgs,
+	RunE:  runPatternsList,
+}
+
+var patternsRemoveCmd = &cobra.Command{
+	Use:   "remove NAME",
+	Short: "Delete a saved pattern",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runPatternsRemove,
+}
+
+var patternsRunCmd = &cobra.Command{
+	Use:   "run [flags] NAME [PATH...]",
+	Short: "Search with a saved pattern",
+	Long: `Search with a saved pattern, taking the same flags as a plain search.
+The pattern's saved -i and -P settings are applied on top of them.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runPatternsRun,
+}
+
+func init() {
+	patternsCmd.PersistentFlags().StringVar(&patternsFile, "library", "", "Pattern library file (default $GORIPGREP_PATTERNS or patterns.json in the config directory)")
+
+	patternsAddCmd.Flags().StringVarP(&patternsDescription, "description", "d", "", "What the pattern finds")
+	patternsAddCmd.Flags().BoolVarP(&ignoreCase, "ignore-case", "i", false, "Search with the pattern case-insensitively")
+	patternsAddCmd.Flags().BoolVarP(&pcre2, "pcre2", "P", false, "Allow lookaround and backreferences in the pattern")
+	patternsAddCmd.Flags().BoolVar(&patternsForce, "force", false, "Replace a saved pattern with the same name")
+
+	patternsListCmd.Flags().BoolVar(&patternsJSON, "json", false, "Output the patterns in JSON format")
+
+	// Searches take every flag of a plain search
+	patternsRunCmd.Flags().AddFlagSet(rootCmd.Flags())
+
+	patternsCmd.AddCommand(patternsAddCmd, patternsListCmd, patternsRemoveCmd, patternsRunCmd)
+	rootCmd.AddCommand(patternsCmd)
+}
+
+// loadPatterns opens the library chosen by --library or the default one
+func loadPatterns() (*goripgrep.PatternLibrary, error) {
+	path := patternsFile
+	if path == "" {
+		var err error
+		if path, err = goripgrep.DefaultPatternLibraryPath(); err != nil {
+			return nil, err
+		}
+	}
+	return goripgrep.LoadPatternLibrary(path)
+}
+
+func runPatternsAdd(cmd *cobra.Command, args []string) error {
+	library, err := loadPatterns()
+	if err != nil {
+		return err
+	}
+	if _, exists := library.Get(args[0]); exists && !patternsForce {
+		return fmt.Errorf("pattern %s already exists; use --force to replace it", args[0])
+	}
+
+	pattern := goripgrep.SavedPattern{
+		Name:        args[0],
+		Pattern:     args[1],
+		Description: patternsDescription,
+		IgnoreCase:  ignoreCase,
+		PCRE2:       pcre2,
+	}
+	if err := library.Add(pattern); err != nil {
+		return err
+	}
+	return library.Save()
+}
+
+func runPatternsList(cmd *cobra.Command, args []string) error {
+	library, err := loadPatterns()
+	if err != nil {
+		return err
+	}
+	patterns := library.List()
+
+	if patternsJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(patterns)
+	}
+
+	// Format: name: pattern, then the description indented below
+	for _, pattern := range patterns {
+		fmt.Printf("%s: %s\n", pattern.Name, pattern.Pattern)
+		if pattern.Description != "" {
+			fmt.Printf("    %s\n", pattern.Description)
+		}
+	}
+	return nil
+}
+
+func runPatternsRemove(cmd *cobra.Command, args []string) error {
+	library, err := loadPatterns()
+	if err != nil {
+		return err
+	}
+	if !library.Remove(args[0]) {
+		return fmt.Errorf("no saved pattern named %s", args[0])
+	}
+	return library.Save()
+}
+
+func runPatternsRun(cmd *cobra.Command, args []string) error {
+	library, err := loadPatterns()
+	if err != nil {
+		return err
+	}
+	pattern, ok := library.Get(args[0])
+	if !ok {
+		return fmt.Errorf("no saved pattern named %s", args[0])
+	}
+
+	// SARIF output reports the saved pattern as the rule
+	if !cmd.Flags().Changed("sarif-rule") {
+		sarifRule = pattern.Name
+	}
+	if !cmd.Flags().Changed("sarif-description") {
+		sarifDesc = pattern.Description
+	}
+	ignoreCase = ignoreCase || pattern.IgnoreCase
+	pcre2 = pcre2 || pattern.PCRE2
+	return runSearch(cmd, append([]string{pattern.Pattern}, args[1:]...))
+}
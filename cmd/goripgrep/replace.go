@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/localrivet/goripgrep"
+)
+
+// runReplace rewrites matches of pattern under each path with the --replace text
+func runReplace(pattern string, paths []string, opts []goripgrep.Option) error {
+	if dryRun {
+		opts = append(opts, goripgrep.WithDryRun())
+	}
+	if backupSuffix != "" {
+		opts = append(opts, goripgrep.WithBackup(backupSuffix))
+	}
+
+	var allResults []*goripgrep.ReplaceResults
+	for _, path := range paths {
+		results, err := goripgrep.Replace(pattern, replacement, path, opts...)
+		if err != nil {
+			return fmt.Errorf("replace failed for path %s: %w", path, err)
+		}
+		allResults = append(allResults, results)
+	}
+
+	if jsonOutput {
+		var files []goripgrep.FileReplacement
+		total := 0
+		for _, results := range allResults {
+			files = append(files, results.Files...)
+			total += results.Replacements
+		}
+
+		output := map[string]interface{}{
+			"files":        files,
+			"replacements": total,
+			"dry_run":      dryRun,
+		}
+
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(output)
+	}
+
+	total, fileCount := 0, 0
+	for _, results := range allResults {
+		if dryRun {
+			fmt.Print(results.Diff())
+		} else {
+			for _, file := range results.Files {
+				fmt.Printf("%s: %d replacements\n", file.File, file.Replacements)
+			}
+		}
+		total += results.Replacements
+		fileCount += len(results.Files)
+	}
+
+	if dryRun {
+		fmt.Fprintf(os.Stderr, "\n%d replacements in %d files (dry run)\n", total, fileCount)
+	} else {
+		fmt.Fprintf(os.Stderr, "\n%d replacements in %d files\n", total, fileCount)
+	}
+	return nil
+}
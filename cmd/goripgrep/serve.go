@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/localrivet/goripgrep"
+	"github.com/spf13/cobra"
+)
+
+var (
+	serveListen   string
+	serveAuditLog string
+	serveLimits   goripgrep.ServerLimits
+	serveRoots    []string
+	serveProxies  []string
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve [flags] [PATH]",
+	Short: "Answer searches over HTTP, keeping caches warm between queries",
+	Long: `Serve searches of PATH (the current directory by default) over HTTP, so
+editors and CI bots can run many queries without starting a process for each.
+
+POST /search takes a JSON object such as {"pattern": "TODO", "path": "src",
+"ignore_case": true, "globs": ["*.go"]} and streams the matches back as
+newline-delimited JSON: a {"type": "match"} line per match, then a
+{"type": "done"} line with the statistics or a {"type": "error"} line.
+Paths are relative to PATH unless absolute. Requests are confined to PATH, or
+to the directories given with --allowed-root, and refused with 403 Forbidden
+outside them; symlinks are resolved before checking, and symlinks leading out
+are not searched. Compiled patterns and ignore
+rules are kept between requests. Patterns likely to be pathologically slow
+are refused unless --allow-slow-patterns is given.
+
+Each request runs for at most --timeout. --max-files, --max-bytes and
+--max-results stop a request early once it has searched that many files or
+bytes or found that many matches, and --max-concurrent refuses a client's
+requests with 429 Too Many Requests while it already runs that many, so one
+expensive query cannot starve the others. Clients are told apart by address.
+
+With --audit-log every request is recorded as a JSON line naming the client,
+the pattern and path, the outcome, the duration and what the search found.
+The user named by basic auth or an X-Forwarded-User header is only believed
+from the proxies given with --trusted-proxy: their requests are logged with
+that user and limited per user. From anywhere else the name is unverified and
+logged as claimed_user, since any client can send one.`,
+	Example: `  goripgrep serve --listen :7700 ~/src/project
+  goripgrep serve --audit-log /var/log/goripgrep-audit.jsonl /srv/repos
+  goripgrep serve --allowed-root /srv/repos/team-a --allowed-root /srv/repos/team-b /srv/repos
+  goripgrep serve --max-files 50000 --max-results 5000 --max-concurrent 2 /srv/repos
+  goripgrep serve --trusted-proxy 10.0.0.0/8 --audit-log - /srv/repos
+  curl -N localhost:7700/search -d '{"pattern": "func main", "globs": ["*.go"]}'`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runServe,
+}
+
+func init() {
+	serveCmd.Flags().StringVar(&serveListen, "listen", ":7700", "Address to listen on")
+	serveCmd.Flags().StringVar(&serveAuditLog, "audit-log", "", "Append a JSON record of each search (client, user, pattern, path, duration, matches) to FILE, or - for stderr")
+	serveCmd.Flags().BoolVarP(&includeHidden, "hidden", ".", false, "Include hidden files and directories")
+	serveCmd.Flags().BoolVar(&useGitignore, "gitignore", true, "Respect .gitignore files")
+	serveCmd.Flags().StringArrayVar(&ignoreFiles, "ignore-file-name", nil, "Also read ignore files with this name in each directory, like .gitignore (repeatable)")
+	serveCmd.Flags().BoolVarP(&searchZip, "search-zip", "z", false, "Search the contents of compressed files")
+	serveCmd.Flags().IntVar(&workers, "workers", 4, "Number of concurrent workers per search")
+	serveCmd.Flags().DurationVar(&timeout, "timeout", 30*time.Second, "Longest a search may run")
+	serveCmd.Flags().StringArrayVar(&serveRoots, "allowed-root", nil, "Confine requests to this directory instead of PATH (repeatable)")
+	serveCmd.Flags().StringArrayVar(&serveProxies, "trusted-proxy", nil, "Believe the user named by requests from this IP or CIDR range (repeatable)")
+	serveCmd.Flags().IntVar(&serveLimits.MaxFiles, "max-files", 0, "Stop a request after searching this many files (0 for no limit)")
+	serveCmd.Flags().Int64Var(&serveLimits.MaxBytes, "max-bytes", 0, "Stop a request after searching this many bytes (0 for no limit)")
+	serveCmd.Flags().IntVar(&serveLimits.MaxResults, "max-results", 0, "Stop a request after finding this many matches (0 for no limit)")
+	serveCmd.Flags().IntVar(&serveLimits.MaxConcurrent, "max-concurrent", 0, "Refuse requests from a client already running this many (0 for no limit)")
+	serveCmd.Flags().BoolVar(&allowSlow, "allow-slow-patterns", false, "Search with patterns likely to be pathologically slow instead of refusing them")
+
+	rootCmd.AddCommand(serveCmd)
+}
+
+func runServe(cmd *cobra.Command, args []string) error {
+	root := "."
+	if len(args) > 0 {
+		root = args[0]
+	}
+
+	// Flags left unset keep the library defaults, which GORIPGREP_*
+	// environment variables may have changed
+	opts := []goripgrep.Option{
+		goripgrep.WithPerformanceMode(),
+		goripgrep.WithServerLimits(serveLimits),
+	}
+	if cmd.Flags().Changed("gitignore") {
+		opts = append(opts, goripgrep.WithGitignore(useGitignore))
+	}
+	if cmd.Flags().Changed("workers") {
+		opts = append(opts, goripgrep.WithWorkers(workers))
+	}
+	if cmd.Flags().Changed("timeout") {
+		opts = append(opts, goripgrep.WithTimeout(timeout))
+	}
+	if cmd.Flags().Changed("hidden") {
+		opts = append(opts, goripgrep.WithHiddenFiles(includeHidden))
+	}
+	if len(ignoreFiles) > 0 {
+		opts = append(opts, goripgrep.WithIgnoreFiles(ignoreFiles...))
+	}
+	if searchZip {
+		opts = append(opts, goripgrep.WithSearchCompressed())
+	}
+	if !allowSlow {
+		opts = append(opts, goripgrep.WithRejectSlowPatterns())
+	}
+	if len(serveRoots) > 0 {
+		opts = append(opts, goripgrep.WithAllowedRoots(serveRoots...))
+	}
+	if len(serveProxies) > 0 {
+		opts = append(opts, goripgrep.WithTrustedProxies(serveProxies...))
+	}
+	if serveAuditLog != "" {
+		auditLog := os.Stderr
+		if serveAuditLog != "-" {
+			file, err := os.OpenFile(serveAuditLog, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+			if err != nil {
+				return fmt.Errorf("failed to open audit log: %w", err)
+			}
+			defer file.Close()
+			auditLog = file
+		}
+		opts = append(opts, goripgrep.WithAuditLog(slog.New(slog.NewJSONHandler(auditLog, nil))))
+	}
+
+	handler, err := goripgrep.NewServer(root, opts...)
+	if err != nil {
+		return fmt.Errorf("serve failed for path %s: %w", root, err)
+	}
+
+	server := &http.Server{Addr: serveListen, Handler: handler}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdown)
+	}()
+
+	fmt.Fprintf(os.Stderr, "Serving searches of %s on %s\n", root, serveListen)
+	if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("serve failed: %w", err)
+	}
+	return nil
+}
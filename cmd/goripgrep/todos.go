@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/localrivet/goripgrep"
+	"github.com/spf13/cobra"
+)
+
+var (
+	todosRecursive  bool
+	todosMaxResults int
+)
+
+var todosCmd = &cobra.Command{
+	Use:   "todos [flags] [PATH...]",
+	Short: "Extract TODO/FIXME/HACK annotations as JSON",
+	Long: `Extract TODO, FIXME and HACK annotations from source code comments.
+
+Comment syntax is detected from the file extension for the major languages,
+and optional metadata such as TODO(alice, 2024-01-31) is parsed into author
+and date fields. Results are written as a JSON array for dashboards.`,
+	RunE: runTodos,
+}
+
+func init() {
+	todosCmd.Flags().BoolVarP(&todosRecursive, "recursive", "r", true, "Search directories recursively")
+	todosCmd.Flags().BoolVarP(&includeHidden, "hidden", ".", false, "Include hidden files and directories")
+	todosCmd.Flags().BoolVar(&useGitignore, "gitignore", true, "Respect .gitignore files")
+	todosCmd.Flags().StringVarP(&filePattern, "glob", "g", "", "Only search files matching this glob pattern")
+	todosCmd.Flags().IntVarP(&todosMaxResults, "max-count", "m", 10000, "Maximum number of annotations to return")
+
+	rootCmd.AddCommand(todosCmd)
+}
+
+func runTodos(cmd *cobra.Command, args []string) error {
+	paths := []string{"."}
+	if len(args) > 0 {
+		paths = args
+	}
+
+	opts := []goripgrep.Option{
+		goripgrep.WithRecursive(todosRecursive),
+		goripgrep.WithGitignore(useGitignore),
+		goripgrep.WithMaxResults(todosMaxResults),
+	}
+	if cmd.Flags().Changed("hidden") {
+		opts = append(opts, goripgrep.WithHiddenFiles(includeHidden))
+	}
+	if filePattern != "" {
+		opts = append(opts, goripgrep.WithFilePattern(filePattern))
+	}
+
+	todos := []goripgrep.Todo{}
+	for _, path := range paths {
+		found, err := goripgrep.FindTodos(path, opts...)
+		if err != nil {
+			return fmt.Errorf("todo extraction failed for path %s: %w", path, err)
+		}
+		todos = append(todos, found...)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(todos)
+}
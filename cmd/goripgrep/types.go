@@ -0,0 +1,30 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/localrivet/goripgrep"
+	"github.com/spf13/cobra"
+)
+
+var typesCmd = &cobra.Command{
+	Use:   "types",
+	Short: "List the file types known to --type and --type-not",
+	Long: `List the file types that can be selected with -t/--type or skipped with
+-T/--type-not, together with the globs matching their file names.`,
+	Args: cobra.NoArgs,
+	RunE: runTypes,
+}
+
+func init() {
+	rootCmd.AddCommand(typesCmd)
+}
+
+func runTypes(cmd *cobra.Command, args []string) error {
+	// Format: name: glob, glob
+	for _, fileType := range goripgrep.FileTypes() {
+		fmt.Printf("%s: %s\n", fileType.Name, strings.Join(fileType.Globs, ", "))
+	}
+	return nil
+}
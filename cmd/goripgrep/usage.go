@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/localrivet/goripgrep"
+	"github.com/spf13/cobra"
+)
+
+var (
+	usageSymbol     bool
+	usageJSON       bool
+	usageMaxResults int
+)
+
+var usageCmd = &cobra.Command{
+	Use:   "usage [flags] TARGET [PATH...]",
+	Short: "Report which Go packages and files use an import or symbol",
+	Long: `Report which Go packages and files use an import path or symbol.
+
+By default TARGET is an import path and only real import specs are counted.
+With --symbol TARGET is an identifier such as errors.Is and every reference
+outside of comments is counted. Packages are listed by usage, heaviest first.`,
+	Example: `  goripgrep usage github.com/spf13/cobra .
+  goripgrep usage --symbol errors.Is --json ./...`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runUsage,
+}
+
+func init() {
+	usageCmd.Flags().BoolVar(&usageSymbol, "symbol", false, "Treat TARGET as a symbol instead of an import path")
+	usageCmd.Flags().BoolVar(&usageJSON, "json", false, "Output the report in JSON format")
+	usageCmd.Flags().BoolVarP(&includeHidden, "hidden", ".", false, "Include hidden files and directories")
+	usageCmd.Flags().BoolVar(&useGitignore, "gitignore", true, "Respect .gitignore files")
+	usageCmd.Flags().IntVarP(&usageMaxResults, "max-count", "m", 100000, "Maximum number of occurrences to collect")
+
+	rootCmd.AddCommand(usageCmd)
+}
+
+func runUsage(cmd *cobra.Command, args []string) error {
+	target := args[0]
+	paths := []string{"."}
+	if len(args) > 1 {
+		paths = args[1:]
+	}
+
+	opts := []goripgrep.Option{
+		goripgrep.WithGitignore(useGitignore),
+		goripgrep.WithMaxResults(usageMaxResults),
+	}
+	if cmd.Flags().Changed("hidden") {
+		opts = append(opts, goripgrep.WithHiddenFiles(includeHidden))
+	}
+
+	find := goripgrep.ImportUsage
+	if usageSymbol {
+		find = goripgrep.SymbolUsage
+	}
+
+	var reports []*goripgrep.UsageReport
+	for _, path := range paths {
+		// Accept the familiar ./... form for the current module
+		path = strings.TrimSuffix(path, "/...")
+		if path == "" {
+			path = "."
+		}
+
+		report, err := find(target, path, opts...)
+		if err != nil {
+			return fmt.Errorf("usage report failed for path %s: %w", path, err)
+		}
+		reports = append(reports, report)
+	}
+
+	if usageJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if len(reports) == 1 {
+			return encoder.Encode(reports[0])
+		}
+		return encoder.Encode(reports)
+	}
+
+	for _, report := range reports {
+		outputUsage(report)
+	}
+	return nil
+}
+
+func outputUsage(report *goripgrep.UsageReport) {
+	fmt.Printf("%s %s: %d uses in %d files across %d packages\n",
+		report.Kind, report.Target, report.Total, len(report.Files), len(report.Packages))
+
+	for _, pkg := range report.Packages {
+		fmt.Printf("  %s (%d files, %d uses)\n", pkg.Package, pkg.Files, pkg.Count)
+
+		for _, file := range report.Files {
+			if file.Package != pkg.Package {
+				continue
+			}
+
+			lines := make([]string, len(file.Lines))
+			for i, line := range file.Lines {
+				lines[i] = strconv.Itoa(line)
+			}
+			fmt.Printf("    %s: %d (lines %s)\n", file.File, file.Count, strings.Join(lines, ", "))
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/debug"
+
+	"github.com/localrivet/goripgrep"
+	"github.com/spf13/cobra"
+)
+
+// Set during release builds with -ldflags "-X main.commit=... -X
+// main.buildDate=..."; the commit otherwise comes from the VCS stamp the go
+// command embeds
+var (
+	commit    string
+	buildDate string
+)
+
+var versionJSON bool
+
+// buildInfo is what version reports about the binary
+type buildInfo struct {
+	Version      string             `json:"version"`
+	Commit       string             `json:"commit,omitempty"`
+	CommitDate   string             `json:"commit_date,omitempty"`
+	BuildDate    string             `json:"build_date,omitempty"`
+	Modified     bool               `json:"modified,omitempty"` // Built from a tree with uncommitted changes
+	GoVersion    string             `json:"go_version"`
+	Platform     string             `json:"platform"`
+	Capabilities goripgrep.Features `json:"capabilities"`
+}
+
+var versionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Print version information",
+	Long: `Print the version, commit and build date of this binary and, with --json,
+the Go version, platform and capabilities it was built with: memory mapping,
+the SIMD level, byte scanner in use, scanning paths and CPU features, extended
+attribute support, compression and archive formats and regex engines. Attach the JSON output to bug reports.`,
+	Example: `  goripgrep version
+  goripgrep version --json`,
+	Args: cobra.NoArgs,
+	RunE: runVersion,
+}
+
+func init() {
+	versionCmd.Flags().BoolVar(&versionJSON, "json", false, "Output build metadata and capabilities as JSON")
+}
+
+func runVersion(cmd *cobra.Command, args []string) error {
+	info := currentBuildInfo()
+	if versionJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(info)
+	}
+
+	fmt.Printf("goripgrep %s\n", info.Version)
+	if info.Commit != "" {
+		line := "commit " + info.Commit
+		if info.CommitDate != "" {
+			line += " from " + info.CommitDate
+		}
+		if info.Modified {
+			line += ", modified"
+		}
+		fmt.Println(line)
+	}
+	if info.BuildDate != "" {
+		fmt.Printf("built %s\n", info.BuildDate)
+	}
+	fmt.Printf("%s %s\n", info.GoVersion, info.Platform)
+	fmt.Println("A fast text search tool written in Go")
+	fmt.Println("https://github.com/localrivet/goripgrep")
+	return nil
+}
+
+// currentBuildInfo gathers the metadata of the running binary, preferring
+// values set at link time over the go command's VCS stamp
+func currentBuildInfo() buildInfo {
+	info := buildInfo{
+		Version:      version,
+		Commit:       commit,
+		BuildDate:    buildDate,
+		GoVersion:    runtime.Version(),
+		Platform:     runtime.GOOS + "/" + runtime.GOARCH,
+		Capabilities: goripgrep.Capabilities(),
+	}
+
+	if build, ok := debug.ReadBuildInfo(); ok {
+		// go install pkg@version records the module version
+		if info.Version == "dev" && build.Main.Version != "" && build.Main.Version != "(devel)" {
+			info.Version = build.Main.Version
+		}
+		settings := make(map[string]string)
+		for _, setting := range build.Settings {
+			settings[setting.Key] = setting.Value
+		}
+		if info.Commit == "" {
+			info.Commit = settings["vcs.revision"]
+		}
+		// The stamp only describes the commit it was taken from
+		if revision := settings["vcs.revision"]; revision != "" && info.Commit == revision {
+			info.CommitDate = settings["vcs.time"]
+			info.Modified = settings["vcs.modified"] == "true"
+		}
+	}
+	return info
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/localrivet/goripgrep"
+	"github.com/spf13/cobra"
+)
+
+var viewCmd = &cobra.Command{
+	Use:   "view [flags] FILE",
+	Short: "Print results saved with --export",
+	Long: `Print the results of an earlier search saved with --export.
+
+The saved matches keep their context lines, sections and key paths, and can
+be printed with any of the output formats of a search: plain text, --json,
+--format, --output csv|tsv|sarif, --count, --stats or --duplicates.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runView,
+}
+
+func init() {
+	viewCmd.Flags().BoolVar(&jsonOutput, "json", false, "Output results in JSON format")
+	viewCmd.Flags().StringVar(&formatText, "format", "", "Print each match through this Go template, with .File, .Line, .Column, .Content, .Submatches and .Stats")
+	viewCmd.Flags().StringVar(&outputFormat, "output", "", "Print matches as csv or tsv rows with a header, with context lines as rows of their own, or as a sarif log for code scanning")
+	viewCmd.Flags().StringVar(&sarifRule, "sarif-rule", "goripgrep.match", "With --output sarif, the rule ID reported for every match")
+	viewCmd.Flags().StringVar(&sarifDesc, "sarif-description", "", "With --output sarif, the description of the rule (default \"Matches PATTERN\")")
+	viewCmd.Flags().BoolVar(&statsOnly, "stats", false, "Show only search statistics")
+	viewCmd.Flags().BoolVarP(&countOnly, "count", "c", false, "Show the number of matches per file instead of the matches")
+	viewCmd.Flags().IntVar(&duplicates, "duplicates", 0, "Instead of the matches, report matched lines found in at least NUM files")
+	viewCmd.Flags().StringVar(&colorMode, "color", "auto", "Highlight file names, line numbers and matches: auto, always or never")
+	viewCmd.Flags().BoolVar(&heading, "heading", false, "Print each file name once above its matches, the default on a terminal")
+	viewCmd.Flags().BoolVar(&noHeading, "no-heading", false, "Print the file name on every line, the default when output is piped")
+	viewCmd.Flags().StringVar(&contextSep, "context-separator", "--", "With context lines, print this between groups of contiguous lines")
+	viewCmd.Flags().BoolVar(&noContextSep, "no-context-separator", false, "With context lines, only merge overlapping context without separating groups")
+	viewCmd.Flags().StringVar(&sortText, "sort", "", "Sort the results by path: path (byte order), path:natural (file2 before file10) or path:locale")
+
+	rootCmd.AddCommand(viewCmd)
+}
+
+func runView(cmd *cobra.Command, args []string) error {
+	var err error
+	if useColor, err = resolveColor(colorMode); err != nil {
+		return err
+	}
+	if err := setupOutput(); err != nil {
+		return err
+	}
+
+	results, err := goripgrep.ImportResultsFile(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to load results: %w", err)
+	}
+	results.Matches = goripgrep.Sort(sortOrder)(results.Matches)
+
+	// Saved context lines are printed as rows of their own, or separated
+	// into groups in text output
+	if rowWriter != nil {
+		rowWriter.Context = true
+	}
+	hasContext := slices.ContainsFunc(results.Matches, func(match goripgrep.Match) bool {
+		return len(match.BeforeContext) > 0 || len(match.AfterContext) > 0
+	})
+	if hasContext && !noContextSep {
+		contextMerger = goripgrep.NewContextMerger(contextSep)
+	}
+	// Results saved from a full search are counted from their matches
+	if countOnly && results.Counts == nil {
+		results.Counts = make(map[string]int)
+		for _, match := range results.Matches {
+			results.Counts[match.File]++
+		}
+	}
+
+	return outputResults([]*goripgrep.SearchResults{results}, results.Stats)
+}
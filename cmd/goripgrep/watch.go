@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/localrivet/goripgrep"
+	"github.com/spf13/cobra"
+)
+
+var (
+	watchRecursive bool
+	watchJSON      bool
+	watchIdle      string
+)
+
+var watchCmd = &cobra.Command{
+	Use:   "watch [flags] PATTERN [PATH]",
+	Short: "Keep searching as files change, printing matches as they appear and disappear",
+	Long: `Search PATH (the current directory by default) for PATTERN, then keep watching
+it and print the matches each change adds or removes.
+
+Added matches are prefixed with + and removed ones with -, and only the files
+that change are searched again. A match moved to another line by an edit above
+it is reported as removed and added again. New directories are watched as
+they appear, and editing a .gitignore applies its rules at once. Runs until
+interrupted.
+
+With --idle, changes are searched only once the machine is idle, so a watch
+left running never slows down interactive work: strict waits for a quiet
+machine as long as it takes, balanced waits at most 5 minutes and eager at
+most 30 seconds.`,
+	Example: `  goripgrep watch "TODO|FIXME" src/
+  goripgrep watch --json -g "*_test.go" "t.Skip" .
+  goripgrep watch --idle balanced "deprecated" ~/src`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runWatch,
+}
+
+func init() {
+	watchCmd.Flags().BoolVarP(&watchRecursive, "recursive", "r", true, "Watch directories recursively")
+	watchCmd.Flags().BoolVarP(&ignoreCase, "ignore-case", "i", false, "Case-insensitive search")
+	watchCmd.Flags().BoolVarP(&fixedStrings, "fixed-strings", "F", false, "Treat the pattern as a literal string instead of a regex")
+	watchCmd.Flags().BoolVarP(&wordRegexp, "word-regexp", "w", false, "Only match whole words")
+	watchCmd.Flags().BoolVarP(&includeHidden, "hidden", ".", false, "Include hidden files and directories")
+	watchCmd.Flags().BoolVar(&useGitignore, "gitignore", true, "Respect .gitignore files")
+	watchCmd.Flags().StringArrayVarP(&globs, "glob", "g", nil, "Only search files matching this glob, or skip them if it starts with ! (repeatable)")
+	watchCmd.Flags().StringArrayVarP(&fileTypes, "type", "t", nil, "Only search files of this type (repeatable)")
+	watchCmd.Flags().BoolVar(&watchJSON, "json", false, "Print each event as a JSON object on its own line")
+	watchCmd.Flags().StringVar(&watchIdle, "idle", "", "Search changes only when the machine is idle: strict, balanced or eager")
+
+	rootCmd.AddCommand(watchCmd)
+}
+
+// watchEvent is the JSON form of a watch event
+type watchEvent struct {
+	Event string          `json:"event"`
+	Match goripgrep.Match `json:"match"`
+}
+
+func runWatch(cmd *cobra.Command, args []string) error {
+	pattern := args[0]
+	path := "."
+	if len(args) > 1 {
+		path = args[1]
+	}
+
+	opts := []goripgrep.Option{
+		goripgrep.WithRecursive(watchRecursive),
+		goripgrep.WithGitignore(useGitignore),
+	}
+	if ignoreCase {
+		opts = append(opts, goripgrep.WithIgnoreCase())
+	}
+	if fixedStrings {
+		opts = append(opts, goripgrep.WithFixedStrings())
+	}
+	if wordRegexp {
+		opts = append(opts, goripgrep.WithWordRegexp())
+	}
+	if cmd.Flags().Changed("hidden") {
+		opts = append(opts, goripgrep.WithHiddenFiles(includeHidden))
+	}
+	if len(globs) > 0 {
+		opts = append(opts, goripgrep.WithIncludeGlobs(globs))
+	}
+	if len(fileTypes) > 0 {
+		opts = append(opts, goripgrep.WithFileTypes(fileTypes))
+	}
+
+	if watchIdle != "" {
+		policies := map[string]goripgrep.IdlePolicy{
+			"strict":   goripgrep.IdleStrict,
+			"balanced": goripgrep.IdleBalanced,
+			"eager":    goripgrep.IdleEager,
+		}
+		policy, ok := policies[watchIdle]
+		if !ok {
+			return fmt.Errorf("invalid --idle %q: use strict, balanced or eager", watchIdle)
+		}
+		opts = append(opts, goripgrep.WithIdleMaintenance(policy))
+	}
+
+	watcher, err := goripgrep.NewWatcher(pattern, path, opts...)
+	if err != nil {
+		return fmt.Errorf("watch failed for path %s: %w", path, err)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	return watcher.Run(func(event goripgrep.WatchEvent) error {
+		if watchJSON {
+			return encoder.Encode(watchEvent{Event: event.Kind.String(), Match: event.Match})
+		}
+
+		// Format: +file:line:column:content
+		sign := "+"
+		if event.Kind == goripgrep.WatchRemoved {
+			sign = "-"
+		}
+		match := event.Match
+		fmt.Printf("%s%s:%d:%d:%s\n", sign, match.File, match.Line, match.Column, strings.TrimSpace(match.Content))
+		return nil
+	})
+}
@@ -9,6 +9,11 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+	"github.com/ulikunitz/xz"
+	"github.com/ulikunitz/xz/lzma"
 )
 
 // CompressionType represents the type of compression detected
@@ -18,6 +23,10 @@ const (
 	CompressionNone CompressionType = iota
 	CompressionGzip
 	CompressionBzip2
+	CompressionZstd
+	CompressionXz
+	CompressionLzma
+	CompressionLz4
 )
 
 // String returns the string representation of the compression type
@@ -29,6 +38,14 @@ func (ct CompressionType) String() string {
 		return "gzip"
 	case CompressionBzip2:
 		return "bzip2"
+	case CompressionZstd:
+		return "zstd"
+	case CompressionXz:
+		return "xz"
+	case CompressionLzma:
+		return "lzma"
+	case CompressionLz4:
+		return "lz4"
 	default:
 		return "unknown"
 	}
@@ -52,12 +69,24 @@ func NewCompressionDetector() *CompressionDetector {
 	// Initialize magic bytes for different formats
 	detector.magicBytes[CompressionGzip] = []byte{0x1f, 0x8b}
 	detector.magicBytes[CompressionBzip2] = []byte{0x42, 0x5a, 0x68}
+	detector.magicBytes[CompressionZstd] = []byte{0x28, 0xb5, 0x2f, 0xfd}
+	detector.magicBytes[CompressionXz] = []byte{0xfd, 0x37, 0x7a, 0x58, 0x5a, 0x00}
+	detector.magicBytes[CompressionLz4] = []byte{0x04, 0x22, 0x4d, 0x18}
+	// The legacy .lzma format has no magic number, but files made with the
+	// default settings start with these properties and a dictionary size
+	// whose low bytes are zero
+	detector.magicBytes[CompressionLzma] = []byte{0x5d, 0x00, 0x00}
 
 	// Initialize file extensions
 	detector.extensions[".gz"] = CompressionGzip
 	detector.extensions[".gzip"] = CompressionGzip
 	detector.extensions[".bz2"] = CompressionBzip2
 	detector.extensions[".bzip2"] = CompressionBzip2
+	detector.extensions[".zst"] = CompressionZstd
+	detector.extensions[".zstd"] = CompressionZstd
+	detector.extensions[".xz"] = CompressionXz
+	detector.extensions[".lzma"] = CompressionLzma
+	detector.extensions[".lz4"] = CompressionLz4
 
 	return detector
 }
@@ -144,6 +173,32 @@ func (cd *CompressionDetector) DecompressReader(reader io.Reader, compressionTyp
 		// bzip2.NewReader doesn't return an error
 		return bzip2.NewReader(reader), nil
 
+	case CompressionZstd:
+		// A single decoder goroutine keeps memory bounded; the reader
+		// must be closed to release it
+		decoder, err := zstd.NewReader(reader, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create zstd reader: %w", err)
+		}
+		return decoder.IOReadCloser(), nil
+
+	case CompressionXz:
+		xzReader, err := xz.NewReader(reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create xz reader: %w", err)
+		}
+		return xzReader, nil
+
+	case CompressionLzma:
+		lzmaReader, err := lzma.NewReader(reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create lzma reader: %w", err)
+		}
+		return lzmaReader, nil
+
+	case CompressionLz4:
+		return lz4.NewReader(reader), nil
+
 	case CompressionNone:
 		return reader, nil
 
@@ -177,9 +232,9 @@ func (cd *CompressionDetector) DecompressFile(filename string) ([]byte, Compress
 		return nil, compressionType, fmt.Errorf("failed to create decompressed reader: %w", err)
 	}
 
-	// Handle gzip reader closing
-	if gzipReader, ok := decompressedReader.(*gzip.Reader); ok {
-		defer gzipReader.Close()
+	// Close readers that hold resources, such as gzip and zstd
+	if closer, ok := decompressedReader.(io.Closer); ok {
+		defer closer.Close()
 	}
 
 	content, err := io.ReadAll(decompressedReader)
@@ -195,6 +250,10 @@ func (cd *CompressionDetector) GetSupportedFormats() []CompressionType {
 	return []CompressionType{
 		CompressionGzip,
 		CompressionBzip2,
+		CompressionZstd,
+		CompressionXz,
+		CompressionLzma,
+		CompressionLz4,
 	}
 }
 
@@ -285,9 +344,9 @@ func (crc *compositeReadCloser) Close() error {
 
 	var lastErr error
 
-	// Close gzip reader if present
-	if gzipReader, ok := crc.reader.(*gzip.Reader); ok {
-		if err := gzipReader.Close(); err != nil {
+	// Close the decompressor if it holds resources, such as gzip and zstd
+	if closer, ok := crc.reader.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
 			lastErr = err
 		}
 	}
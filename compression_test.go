@@ -3,13 +3,20 @@ package goripgrep
 import (
 	"bytes"
 	"compress/gzip"
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strings"
 	"sync"
 	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+	"github.com/ulikunitz/xz"
+	"github.com/ulikunitz/xz/lzma"
 )
 
 func TestCompressionDetector(t *testing.T) {
@@ -27,11 +34,14 @@ func TestCompressionDetector(t *testing.T) {
 			{"test.bzip2", CompressionBzip2},
 			{"test.GZ", CompressionGzip}, // Test case insensitive
 			{"test.BZ2", CompressionBzip2},
+			{"test.xz", CompressionXz},
+			{"test.lzma", CompressionLzma},
+			{"test.lz4", CompressionLz4},
+			{"test.zst", CompressionZstd},
+			{"test.zstd", CompressionZstd},
 			// Unsupported formats should return CompressionNone
-			{"test.xz", CompressionNone},
-			{"test.lzma", CompressionNone},
-			{"test.lz4", CompressionNone},
-			{"test.zst", CompressionNone},
+			{"test.zip", CompressionNone},
+			{"test.7z", CompressionNone},
 		}
 
 		for _, tc := range testCases {
@@ -53,11 +63,12 @@ func TestCompressionDetector(t *testing.T) {
 			{"Plain text", []byte("Hello, World!"), CompressionNone},
 			{"Gzip magic", []byte{0x1f, 0x8b, 0x08, 0x00}, CompressionGzip},
 			{"Bzip2 magic", []byte{0x42, 0x5a, 0x68, 0x39}, CompressionBzip2},
+			{"XZ magic", []byte{0xfd, 0x37, 0x7a, 0x58, 0x5a, 0x00}, CompressionXz},
+			{"LZMA magic", []byte{0x5d, 0x00, 0x00, 0x80, 0x00}, CompressionLzma},
+			{"LZ4 magic", []byte{0x04, 0x22, 0x4d, 0x18}, CompressionLz4},
+			{"Zstd magic", []byte{0x28, 0xb5, 0x2f, 0xfd}, CompressionZstd},
 			// Unsupported formats should return CompressionNone
-			{"XZ magic", []byte{0xfd, 0x37, 0x7a, 0x58, 0x5a, 0x00}, CompressionNone},
-			{"LZMA magic", []byte{0x5d, 0x00, 0x00, 0x80, 0x00}, CompressionNone},
-			{"LZ4 magic", []byte{0x04, 0x22, 0x4d, 0x18}, CompressionNone},
-			{"Zstd magic", []byte{0x28, 0xb5, 0x2f, 0xfd}, CompressionNone},
+			{"Zip magic", []byte{0x50, 0x4b, 0x03, 0x04}, CompressionNone},
 		}
 
 		for _, tc := range testCases {
@@ -71,7 +82,7 @@ func TestCompressionDetector(t *testing.T) {
 
 	t.Run("GetSupportedFormats", func(t *testing.T) {
 		formats := detector.GetSupportedFormats()
-		expectedFormats := []CompressionType{CompressionGzip, CompressionBzip2}
+		expectedFormats := []CompressionType{CompressionGzip, CompressionBzip2, CompressionZstd, CompressionXz, CompressionLzma, CompressionLz4}
 
 		if len(formats) != len(expectedFormats) {
 			t.Errorf("GetSupportedFormats() returned %d formats, expected %d",
@@ -99,6 +110,11 @@ func TestCompressionDetector(t *testing.T) {
 			".gzip":  CompressionGzip,
 			".bz2":   CompressionBzip2,
 			".bzip2": CompressionBzip2,
+			".zst":   CompressionZstd,
+			".zstd":  CompressionZstd,
+			".xz":    CompressionXz,
+			".lzma":  CompressionLzma,
+			".lz4":   CompressionLz4,
 		}
 
 		for ext, expectedType := range expectedExtensions {
@@ -120,6 +136,10 @@ func TestCompressionTypeString(t *testing.T) {
 		{CompressionNone, "none"},
 		{CompressionGzip, "gzip"},
 		{CompressionBzip2, "bzip2"},
+		{CompressionZstd, "zstd"},
+		{CompressionXz, "xz"},
+		{CompressionLzma, "lzma"},
+		{CompressionLz4, "lz4"},
 		{CompressionType(999), "unknown"},
 	}
 
@@ -739,3 +759,113 @@ func TestErrorHandlingAndResourceManagement(t *testing.T) {
 		}
 	})
 }
+
+func TestFindSearchCompressed(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{"app.log": "ok\nERROR now\n"})
+	archived := "x\nERROR then\nERROR before\n"
+	writeGzipFile(t, filepath.Join(dir, "app.log.1.gz"), archived)
+
+	results, err := Find("ERROR", dir)
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	if len(results.Matches) != 1 {
+		t.Errorf("Expected compressed files to be skipped by default, got %d matches", len(results.Matches))
+	}
+
+	results, err = Find("ERROR", dir, WithSearchCompressed())
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	sort.SliceStable(results.Matches, func(i, j int) bool { return results.Matches[i].File < results.Matches[j].File })
+	var found []string
+	for _, match := range results.Matches {
+		found = append(found, fmt.Sprintf("%s:%d", filepath.Base(match.File), match.Line))
+	}
+	if got := strings.Join(found, " "); got != "app.log:2 app.log.1.gz:2 app.log.1.gz:3" {
+		t.Errorf("Expected matches in both files, got %s", got)
+	}
+	if want := int64(len("ok\nERROR now\n") + len(archived)); results.Stats.BytesScanned != want {
+		t.Errorf("Expected %d bytes scanned counting the decompressed size, got %d", want, results.Stats.BytesScanned)
+	}
+
+	results, err = Find("ERROR", dir, WithSearchCompressed(), WithCountOnly())
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	if count := results.Counts[filepath.Join(dir, "app.log.1.gz")]; count != 2 {
+		t.Errorf("Expected 2 matches counted in the compressed file, got %d", count)
+	}
+}
+
+func TestDecompressZstdXzLzmaLz4(t *testing.T) {
+	content := strings.Repeat("line with ERROR in it\n", 200)
+	writers := map[string]func(io.Writer) (io.WriteCloser, error){
+		"test.zst":  func(w io.Writer) (io.WriteCloser, error) { return zstd.NewWriter(w) },
+		"test.xz":   func(w io.Writer) (io.WriteCloser, error) { return xz.NewWriter(w) },
+		"test.lzma": func(w io.Writer) (io.WriteCloser, error) { return lzma.NewWriter(w) },
+		"test.lz4":  func(w io.Writer) (io.WriteCloser, error) { return lz4.NewWriter(w), nil },
+	}
+	expected := map[string]CompressionType{
+		"test.zst":  CompressionZstd,
+		"test.xz":   CompressionXz,
+		"test.lzma": CompressionLzma,
+		"test.lz4":  CompressionLz4,
+	}
+
+	dir := t.TempDir()
+	detector := NewCompressionDetector()
+	for name, newWriter := range writers {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			writer, err := newWriter(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := writer.Write([]byte(content)); err != nil {
+				t.Fatal(err)
+			}
+			if err := writer.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			// Magic bytes alone identify the format, whatever the file is called
+			if got := detector.DetectCompressionByBytes(buf.Bytes()); got != expected[name] {
+				t.Errorf("Expected magic bytes to detect %v, got %v", expected[name], got)
+			}
+			path := filepath.Join(dir, name)
+			if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			decompressed, compressionType, err := detector.DecompressFile(path)
+			if err != nil {
+				t.Fatalf("DecompressFile failed: %v", err)
+			}
+			if compressionType != expected[name] || string(decompressed) != content {
+				t.Errorf("Expected %v content to round-trip, got %v and %d bytes", expected[name], compressionType, len(decompressed))
+			}
+
+			stream, _, err := NewStreamingDecompressor(0).DecompressStream(path)
+			if err != nil {
+				t.Fatalf("DecompressStream failed: %v", err)
+			}
+			streamed, err := io.ReadAll(stream)
+			if err != nil || string(streamed) != content {
+				t.Errorf("Expected the stream to decompress, got %d bytes and %v", len(streamed), err)
+			}
+			if err := stream.Close(); err != nil {
+				t.Errorf("Close failed: %v", err)
+			}
+
+			results, err := Find("ERROR", dir, WithSearchCompressed(), WithFilePattern(name), WithMaxResults(1000))
+			if err != nil {
+				t.Fatalf("Find failed: %v", err)
+			}
+			if len(results.Matches) != 200 {
+				t.Errorf("Expected 200 matches through the decompressor, got %d", len(results.Matches))
+			}
+		})
+	}
+}
@@ -0,0 +1,286 @@
+package goripgrep
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// concurrencyFixture writes a small tree with ignored files, compressed files
+// and a few matches per file, returning its root
+func concurrencyFixture(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	files := map[string]string{
+		".gitignore":       "*.tmp\nbuild/\n",
+		"build/out.go":     "ERROR ignored\n",
+		"notes.tmp":        "ERROR ignored\n",
+		"docs/guide.md":    "# Guide\n\nERROR in docs\n",
+		"config/app.yaml":  "server:\n  level: ERROR\n",
+		"config/app.json":  `{"log": {"level": "ERROR"}}` + "\n",
+		"src/nested/x.txt": "error lower\nERROR upper\n",
+	}
+	for i := range 20 {
+		files[fmt.Sprintf("src/file%02d.go", i)] = strings.Repeat(fmt.Sprintf("func f%d() { return ERROR }\n", i), 5)
+	}
+	writeTestFiles(t, root, files)
+	writeGzipFile(t, filepath.Join(root, "logs.gz"), "ERROR compressed\n")
+	return root
+}
+
+func TestConcurrentFind(t *testing.T) {
+	root := concurrencyFixture(t)
+
+	// Each configuration runs once on its own for the expected count, then
+	// many times at once
+	configs := []struct {
+		name string
+		opts []Option
+	}{
+		{"Plain", []Option{WithRecursive(true), WithGitignore(true)}},
+		{"IgnoreCase", []Option{WithRecursive(true), WithGitignore(true), WithIgnoreCase()}},
+		{"Context", []Option{WithRecursive(true), WithContextLines(1), WithWorkers(8)}},
+		{"CachedRegex", []Option{WithRecursive(true), WithPerformanceMode()}},
+		{"Count", []Option{WithRecursive(true), WithCountOnly()}},
+		{"Compressed", []Option{WithRecursive(true), WithSearchCompressed()}},
+		{"Stages", []Option{WithRecursive(true), WithSections(), WithKeyPaths()}},
+		{"Patterns", []Option{WithRecursive(true), WithPatterns([]string{"func", "level"})}},
+		{"PCRE2", []Option{WithRecursive(true), WithPCRE2Syntax()}},
+	}
+	patterns := map[string]string{"PCRE2": `(?<=return )ERROR`}
+
+	expected := make(map[string]int64)
+	for _, config := range configs {
+		pattern := patterns[config.name]
+		if pattern == "" {
+			pattern = "ERROR"
+		}
+		results, err := Find(pattern, root, config.opts...)
+		if err != nil {
+			t.Fatalf("%s: Find failed: %v", config.name, err)
+		}
+		expected[config.name] = results.Stats.MatchesFound
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, len(configs)*8)
+	for round := range 8 {
+		for _, config := range configs {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				pattern := patterns[config.name]
+				if pattern == "" {
+					pattern = "ERROR"
+				}
+				results, err := Find(pattern, root, config.opts...)
+				if err != nil {
+					errs <- fmt.Errorf("%s round %d: %w", config.name, round, err)
+					return
+				}
+				if results.Stats.MatchesFound != expected[config.name] {
+					errs <- fmt.Errorf("%s round %d: expected %d matches, got %d", config.name, round, expected[config.name], results.Stats.MatchesFound)
+				}
+			}()
+		}
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func TestConcurrentSharedEngines(t *testing.T) {
+	root := concurrencyFixture(t)
+	file := filepath.Join(root, "src", "file00.go")
+
+	engine, err := NewEngine(SearchArgs{Pattern: `f\d+\(\)`})
+	if err != nil {
+		t.Fatalf("NewEngine failed: %v", err)
+	}
+	gitignore := NewGitignoreEngine(root)
+	cache := NewDFACache(4, 0)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 64)
+	for i := range 16 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			// One Engine searching from many goroutines
+			matches, err := engine.Search(context.Background(), file)
+			if err != nil || len(matches) != 5 {
+				errs <- fmt.Errorf("engine: expected 5 matches, got %d (%v)", len(matches), err)
+			}
+			_ = engine.GetStats()
+
+			// One gitignore engine answering from many goroutines while it
+			// loads nested ignore files
+			if !gitignore.ShouldIgnore(filepath.Join(root, "notes.tmp")) || gitignore.ShouldIgnore(file) {
+				errs <- fmt.Errorf("gitignore: wrong decision")
+			}
+			if !gitignore.ShouldIgnoreDir(filepath.Join(root, "build")) {
+				errs <- fmt.Errorf("gitignore: build/ should be ignored")
+			}
+
+			// Caches shared between goroutines, including evictions
+			if _, err := cache.GetOrCompile(fmt.Sprintf("p%d", i%6), ""); err != nil {
+				errs <- err
+			}
+			_ = cache.Stats()
+			_ = cache.GetCachedPatterns()
+			if _, err := CompileWithCache(`ERROR\s+\w+`, i%2 == 0); err != nil {
+				errs <- err
+			}
+
+			// Streams and search engines are independent per call
+			var buf bytes.Buffer
+			if _, err := FindReader("ERROR", strings.NewReader("a\nERROR b\n"), func(m Match) error {
+				buf.WriteString(m.Content)
+				return nil
+			}); err != nil || buf.String() != "ERROR b" {
+				errs <- fmt.Errorf("FindReader: got %q (%v)", buf.String(), err)
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	if scanned := engine.GetStats()["files_scanned"]; scanned != int64(16) {
+		t.Errorf("Expected 16 files scanned by the shared engine, got %v", scanned)
+	}
+	if stats := cache.Stats(); stats.Hits+stats.Misses != 16 {
+		t.Errorf("Expected every cache lookup to be counted once, got %d hits and %d misses", stats.Hits, stats.Misses)
+	}
+}
+
+func TestDeterministicOutput(t *testing.T) {
+	root := t.TempDir()
+	files := make(map[string]string)
+	for i := 0; i < 60; i++ {
+		// Larger files early in the walk tend to finish last
+		lines := strings.Repeat("filler line\n", (60-i)*40)
+		files[fmt.Sprintf("d%d/f%02d.txt", i%4, i)] = "MATCH first\n" + lines + "MATCH last\n"
+	}
+	writeTestFiles(t, root, files)
+
+	order := func(results *SearchResults) []string {
+		var order []string
+		for _, match := range results.Matches {
+			order = append(order, fmt.Sprintf("%s:%d", match.File, match.Line))
+		}
+		return order
+	}
+
+	single, err := Find("MATCH", root, WithRecursive(true), WithWorkers(1), WithMaxResults(1000))
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	want := order(single)
+	if len(want) != 120 {
+		t.Fatalf("Expected 120 matches, got %d", len(want))
+	}
+
+	for run := 0; run < 5; run++ {
+		for _, optimized := range []bool{false, true} {
+			results, err := Find("MATCH", root, WithRecursive(true), WithWorkers(8), WithMaxResults(1000),
+				WithOptimizedWalking(optimized), WithDeterministicOutput(true))
+			if err != nil {
+				t.Fatalf("Find failed: %v", err)
+			}
+			if got := order(results); strings.Join(got, "\n") != strings.Join(want, "\n") {
+				t.Fatalf("Expected walk order with optimized walking %v, got %v", optimized, got)
+			}
+		}
+	}
+
+	// The first matches in walk order are kept
+	results, err := Find("MATCH", root, WithRecursive(true), WithWorkers(8), WithQuitAfter(5), WithDeterministicOutput(true))
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	if got := order(results); strings.Join(got, "\n") != strings.Join(want[:5], "\n") {
+		t.Errorf("Expected the first 5 matches in walk order, got %v", got)
+	}
+}
+
+func TestSequenceResults(t *testing.T) {
+	in := make(chan fileResult, 10)
+	for _, result := range []fileResult{
+		{file: "c", seq: 2},
+		{seq: 2, last: true},
+		{file: "b1", seq: 1},
+		{file: "e", seq: 4}, // File 3 never reports, as when a search stops
+		{seq: 0, last: true},
+		{file: "b2", seq: 1},
+		{seq: 1, last: true},
+	} {
+		in <- result
+	}
+	close(in)
+
+	var got []string
+	for result := range sequenceResults(in, 1) {
+		got = append(got, result.file)
+	}
+	if strings.Join(got, ",") != "b1,b2,c,e" {
+		t.Errorf("Expected results in walk order, got %v", got)
+	}
+}
+
+func TestWorkerGroups(t *testing.T) {
+	root := t.TempDir()
+	files := make(map[string]string)
+	for i := 0; i < 40; i++ {
+		files[fmt.Sprintf("d%d/f%02d.txt", i%4, i)] = "MATCH first\n" + strings.Repeat("filler line\n", i*10) + "MATCH last\n"
+	}
+	writeTestFiles(t, root, files)
+
+	single, err := Find("MATCH", root, WithRecursive(true), WithWorkers(1), WithDeterministicOutput(true))
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+
+	for _, opts := range [][]Option{
+		{WithWorkerGroups(3)},
+		{WithWorkerGroups(4), WithCPUPinning()},
+		{WithWorkerGroups(SuggestedWorkerGroups())},
+	} {
+		results, err := Find("M[A-Z]+H", root, append(opts, WithRecursive(true), WithWorkers(8), WithDeterministicOutput(true))...)
+		if err != nil {
+			t.Fatalf("Find failed: %v", err)
+		}
+		if results.Count() != single.Count() {
+			t.Fatalf("Expected %d matches, got %d", single.Count(), results.Count())
+		}
+		for i, match := range results.Matches {
+			if match.File != single.Matches[i].File || match.Line != single.Matches[i].Line {
+				t.Fatalf("Expected %s:%d at %d, got %s:%d", single.Matches[i].File, single.Matches[i].Line, i, match.File, match.Line)
+			}
+		}
+
+		// Statistics gathered by the groups are merged
+		if results.Stats.FilesScanned != 40 || results.Stats.BytesScanned != single.Stats.BytesScanned || results.Stats.Phases.Read == 0 {
+			t.Errorf("Expected the stats of all groups, got %+v", results.Stats)
+		}
+	}
+
+	// Groups share the limits of the search
+	engine := NewSearchEngine(SearchConfig{SearchPath: root, Recursive: true, MaxWorkers: 8, WorkerGroups: 4, MaxResults: 1000, MaxFiles: 5})
+	results, err := engine.Search(context.Background(), "MATCH")
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	if results.Stats.FilesScanned != 5 || !results.Stats.StoppedEarly {
+		t.Errorf("Expected 5 files across all groups, got %+v", results.Stats)
+	}
+}
@@ -0,0 +1,279 @@
+package goripgrep
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ConfigKey is a key found in a dotenv, INI, TOML or YAML file
+type ConfigKey struct {
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Key    string `json:"key"`   // Full key name: section.key in INI and TOML, the key path in YAML
+	Value  string `json:"value"` // Masked unless WithConfigValues is set; empty values stay empty
+	Format string `json:"format"`
+}
+
+// redactedValue replaces non-empty config values unless WithConfigValues is
+// set. It has a fixed length so secrets do not leak their size.
+const redactedValue = "********"
+
+// configFormat returns the config format of a file from its name, or ""
+func configFormat(file string) string {
+	name := strings.ToLower(filepath.Base(file))
+	switch {
+	case name == ".env" || strings.HasPrefix(name, ".env.") || strings.HasSuffix(name, ".env"):
+		return "env"
+	}
+
+	switch filepath.Ext(name) {
+	case ".ini", ".cfg":
+		return "ini"
+	case ".toml":
+		return "toml"
+	case ".yaml", ".yml":
+		return "yaml"
+	}
+	return ""
+}
+
+var (
+	// envAssignment parses KEY=value with an optional export prefix
+	envAssignment = regexp.MustCompile(`^\s*(?:export\s+)?([A-Za-z_][A-Za-z0-9_.]*)\s*=\s*(.*)$`)
+	// iniAssignment parses key = value or key: value
+	iniAssignment = regexp.MustCompile(`^\s*([^=:;#\[\s][^=:]*?)\s*[=:]\s*(.*)$`)
+	// tomlAssignment parses key = value, where the key may be dotted or quoted
+	tomlAssignment = regexp.MustCompile(`^\s*((?:[A-Za-z0-9_-]+|"[^"]*"|'[^']*')(?:\s*\.\s*(?:[A-Za-z0-9_-]+|"[^"]*"|'[^']*'))*)\s*=\s*(.*)$`)
+	// configSection parses [section], and [[table]] in TOML
+	configSection = regexp.MustCompile(`^\s*\[\[?\s*([^\]]+?)\s*\]\]?\s*(?:[#;].*)?$`)
+)
+
+// configFile holds the parsed lines of one config file
+type configFile struct {
+	format   string
+	lines    []string
+	sections []string                      // Section in effect on each line (INI and TOML)
+	keyPath  func(line, offset int) string // Key path finder (YAML)
+}
+
+// loadConfigFile reads a config file and indexes its sections
+func loadConfigFile(file, format string) (*configFile, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &configFile{format: format, lines: strings.Split(string(stripBOM(data)), "\n")}
+	switch format {
+	case "yaml":
+		config.keyPath = yamlKeyPaths(data)
+	case "ini", "toml":
+		section := ""
+		for _, line := range config.lines {
+			if groups := configSection.FindStringSubmatch(line); groups != nil {
+				section = normalizeTOMLKey(groups[1])
+			}
+			config.sections = append(config.sections, section)
+		}
+	}
+	return config, nil
+}
+
+// key parses line n (1-indexed) as an assignment, returning its own key name,
+// its full key with any section or parent keys, and its raw value
+func (c *configFile) key(n int) (name, full, value string, ok bool) {
+	if n > len(c.lines) {
+		return "", "", "", false
+	}
+	line := strings.TrimSuffix(c.lines[n-1], "\r")
+
+	switch c.format {
+	case "env":
+		groups := envAssignment.FindStringSubmatch(line)
+		if groups == nil {
+			return "", "", "", false
+		}
+		return groups[1], groups[1], configValue(groups[2], "#"), true
+
+	case "ini", "toml":
+		var groups []string
+		if c.format == "toml" {
+			groups = tomlAssignment.FindStringSubmatch(line)
+		} else {
+			groups = iniAssignment.FindStringSubmatch(line)
+		}
+		if groups == nil {
+			return "", "", "", false
+		}
+		name = normalizeTOMLKey(groups[1])
+		full = name
+		if section := c.sections[n-1]; section != "" {
+			full = section + "." + name
+		}
+		return name, full, configValue(groups[2], "#;"), true
+
+	case "yaml":
+		content := strings.TrimLeft(line, " ")
+		for content == "-" || strings.HasPrefix(content, "- ") {
+			content = strings.TrimLeft(content[1:], " ")
+		}
+		groups := yamlKey.FindStringSubmatch(content)
+		if groups == nil {
+			return "", "", "", false
+		}
+		name = groups[3]
+		if groups[1] != "" {
+			name, _ = strconv.Unquote(`"` + groups[1] + `"`)
+		} else if groups[2] != "" {
+			name = strings.ReplaceAll(groups[2], "''", "'")
+		}
+		return name, c.keyPath(n, 0), configValue(content[len(groups[0]):], "#"), true
+	}
+	return "", "", "", false
+}
+
+// normalizeTOMLKey removes the quotes and spaces around the parts of a dotted
+// key, leaving plain INI keys unchanged. Parts that contain a dot themselves
+// stay quoted so the key remains unambiguous.
+func normalizeTOMLKey(key string) string {
+	if !strings.ContainsAny(key, `."'`) {
+		return key
+	}
+
+	var parts []string
+	var part strings.Builder
+	quote := byte(0)
+	flush := func() {
+		text := strings.TrimSpace(part.String())
+		if unquoted, err := strconv.Unquote(text); err == nil && text[0] == '"' {
+			text = unquoted
+		} else if len(text) >= 2 && text[0] == '\'' && text[len(text)-1] == '\'' {
+			text = text[1 : len(text)-1]
+		}
+		if strings.Contains(text, ".") {
+			text = strconv.Quote(text)
+		}
+		parts = append(parts, text)
+		part.Reset()
+	}
+
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else if c == '\\' && quote == '"' && i+1 < len(key) {
+				part.WriteByte(c)
+				i++
+				c = key[i]
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '.':
+			flush()
+			continue
+		}
+		part.WriteByte(c)
+	}
+	flush()
+	return strings.Join(parts, ".")
+}
+
+// configValue trims a raw value, removing surrounding quotes or, for unquoted
+// values, a trailing comment started by one of the comment characters
+func configValue(raw, comments string) string {
+	value := strings.TrimSpace(raw)
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') {
+		if end := strings.IndexByte(value[1:], value[0]); end >= 0 {
+			return value[1 : end+1]
+		}
+	}
+	for i := 0; i < len(value); i++ {
+		if strings.IndexByte(comments, value[i]) >= 0 && (i == 0 || value[i-1] == ' ' || value[i-1] == '\t') {
+			return strings.TrimSpace(value[:i])
+		}
+	}
+	return value
+}
+
+// FindConfigKeys searches path for keys whose name matches pattern in
+// dotenv (.env, .env.*, *.env), INI (.ini, .cfg), TOML and YAML files, for
+// security and operations audits. Hidden files such as .env are always
+// searched. Values are masked unless WithConfigValues is set.
+func FindConfigKeys(pattern, path string, opts ...Option) ([]ConfigKey, error) {
+	opts = append([]Option{WithHidden()}, opts...)
+	options := defaultOptions()
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	results, err := Find(pattern, path, opts...)
+	if err != nil {
+		return nil, err
+	}
+	// Keys are matched on their own, with the same patterns and settings
+	expr, err := options.resolvePatterns(pattern)
+	if err != nil {
+		return nil, err
+	}
+	matcher, err := newLineMatcher(expr, options.searchConfig(path))
+	if err != nil {
+		return nil, err
+	}
+
+	type fileLine struct {
+		file string
+		line int
+	}
+	seen := make(map[fileLine]bool)
+	files := make(map[string]*configFile)
+
+	keys := []ConfigKey{}
+	for _, match := range results.Matches {
+		format := configFormat(match.File)
+		if format == "" || match.Kind != MatchContent || seen[fileLine{match.File, match.Line}] {
+			continue
+		}
+		seen[fileLine{match.File, match.Line}] = true
+
+		config, ok := files[match.File]
+		if !ok {
+			// Unreadable files are skipped, as in the search itself
+			config, _ = loadConfigFile(match.File, format)
+			files[match.File] = config
+		}
+		if config == nil {
+			continue
+		}
+
+		name, full, value, ok := config.key(match.Line)
+		if !ok || !matcher.matches(name) {
+			continue
+		}
+		if value != "" && !options.configValues {
+			value = redactedValue
+		}
+
+		keys = append(keys, ConfigKey{
+			File:   match.File,
+			Line:   match.Line,
+			Key:    full,
+			Value:  value,
+			Format: format,
+		})
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].File != keys[j].File {
+			return keys[i].File < keys[j].File
+		}
+		return keys[i].Line < keys[j].Line
+	})
+
+	return keys, nil
+}
@@ -0,0 +1,70 @@
+package goripgrep
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+func TestFindConfigKeys(t *testing.T) {
+	tempDir := t.TempDir()
+	writeTestFiles(t, tempDir, map[string]string{
+		".env":          "export DB_PASSWORD=\"hunter2\" # prod\nAPI_TOKEN=\nDEBUG=true\n# PASSWORD=commented\n",
+		"app.ini":       "[database]\npassword = s3cret ; note\nuser = admin\nhint = the password is elsewhere\n",
+		"conf/app.toml": "[server]\nsecret_key = \"abc\"\n[[users]]\n\"auth.token\" = 1\n",
+		"conf/app.yaml": "db:\n  password: x # inline\nitems:\n- token: 'y'\n",
+		"notes.txt":     "password = not a config file\n",
+	})
+
+	keys, err := FindConfigKeys("(?i)password|secret|token", tempDir, WithRecursive(true))
+	if err != nil {
+		t.Fatalf("FindConfigKeys failed: %v", err)
+	}
+
+	var got []string
+	for _, key := range keys {
+		rel, _ := filepath.Rel(tempDir, key.File)
+		got = append(got, fmt.Sprintf("%s:%d:%s:%s=%s", key.Format, key.Line, rel, key.Key, key.Value))
+	}
+	expected := []string{
+		"env:1:.env:DB_PASSWORD=********",
+		"env:2:.env:API_TOKEN=",
+		"ini:2:app.ini:database.password=********",
+		"toml:2:conf/app.toml:server.secret_key=********",
+		`toml:4:conf/app.toml:users."auth.token"=********`,
+		"yaml:2:conf/app.yaml:db.password=********",
+		"yaml:4:conf/app.yaml:items[0].token=********",
+	}
+	if fmt.Sprint(got) != fmt.Sprint(expected) {
+		t.Errorf("Expected keys:\n%v\ngot:\n%v", expected, got)
+	}
+
+	t.Run("WithConfigValues", func(t *testing.T) {
+		keys, err := FindConfigKeys("password", tempDir, WithRecursive(true), WithIgnoreCase(), WithConfigValues())
+		if err != nil {
+			t.Fatalf("FindConfigKeys failed: %v", err)
+		}
+		var values []string
+		for _, key := range keys {
+			values = append(values, key.Value)
+		}
+		if fmt.Sprint(values) != "[hunter2 s3cret x]" {
+			t.Errorf("Expected values without quotes or comments, got %q", values)
+		}
+	})
+}
+
+func TestNormalizeTOMLKey(t *testing.T) {
+	tests := map[string]string{
+		"plain":             "plain",
+		`a . "b" . 'c'`:     "a.b.c",
+		`"auth.token"`:      `"auth.token"`,
+		`site."google.com"`: `site."google.com"`,
+		`"a \"q\""`:         `a "q"`,
+	}
+	for key, expected := range tests {
+		if got := normalizeTOMLKey(key); got != expected {
+			t.Errorf("normalizeTOMLKey(%q) = %q, expected %q", key, got, expected)
+		}
+	}
+}
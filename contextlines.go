@@ -0,0 +1,113 @@
+package goripgrep
+
+// ContextLine is one line of output around matches: a match, or a context
+// line of one. A line with several matches appears once per match, like
+// Matches.
+type ContextLine struct {
+	File    string
+	Line    int    // Line number (1-indexed); the first line of a multiline match
+	Content string // The context line; for matches, see Match
+	Match   *Match // The match on this line, nil for context lines
+	After   bool   // A context line following a match rather than preceding one
+
+	// Separator is printed on a line of its own before this line when it
+	// starts a new group of contiguous lines, like grep's --; empty
+	// otherwise
+	Separator string
+}
+
+// ContextMerger merges the context lines of consecutive matches, so lines
+// shared by neighbouring matches are written once, and marks where the
+// output jumps ahead with a separator, like grep and ripgrep. Matches are
+// added in the order they are written; each file's matches must be in
+// line order.
+type ContextMerger struct {
+	separator string
+	started   bool   // Any line has been returned
+	file      string // File of the last match
+	last      int    // Last line returned in file
+
+	pending []ContextLine // After context of the last match, held back in case the next match overlaps it
+}
+
+// NewContextMerger returns a merger that separates groups with separator,
+// or only merges them when it is empty
+func NewContextMerger(separator string) *ContextMerger {
+	return &ContextMerger{separator: separator}
+}
+
+// Add returns the lines to write for match: the after context still held
+// from earlier matches that comes before it, its own before context not
+// yet written and the match itself. Its after context is held until the
+// next match or Flush.
+func (m *ContextMerger) Add(match Match) []ContextLine {
+	// Metadata matches have no lines to merge
+	if match.Kind != MatchContent || match.Line == 0 {
+		lines := m.Flush()
+		m.file, m.last = "", 0
+		return append(lines, ContextLine{File: match.File, Match: &match})
+	}
+
+	var lines []ContextLine
+	if match.File != m.file {
+		lines = m.Flush()
+		m.file, m.last = match.File, 0
+	}
+
+	// Held context before this match is written; the rest is covered by
+	// the match and its own context
+	for _, line := range m.pending {
+		if line.Line < match.Line {
+			lines = m.appendLine(lines, line)
+		}
+	}
+	m.pending = m.pending[:0]
+
+	first := match.Line - len(match.BeforeContext)
+	for i, content := range match.BeforeContext {
+		if line := first + i; line > m.last {
+			lines = m.appendLine(lines, ContextLine{File: match.File, Line: line, Content: content})
+		}
+	}
+	lines = m.appendLine(lines, ContextLine{File: match.File, Line: match.Line, Match: &match})
+
+	last := max(match.Line, match.EndLine)
+	m.last = max(m.last, last)
+	for i, content := range match.AfterContext {
+		m.pending = append(m.pending, ContextLine{File: match.File, Line: last + 1 + i, Content: content, After: true})
+	}
+	return lines
+}
+
+// Flush returns the after context still held from the last match
+func (m *ContextMerger) Flush() []ContextLine {
+	var lines []ContextLine
+	for _, line := range m.pending {
+		lines = m.appendLine(lines, line)
+	}
+	m.pending = m.pending[:0]
+	return lines
+}
+
+// appendLine adds line to lines, marking it with the separator when it does
+// not follow on from the last line returned, as in another file
+func (m *ContextMerger) appendLine(lines []ContextLine, line ContextLine) []ContextLine {
+	if m.started && (m.last == 0 || line.Line > m.last+1) {
+		line.Separator = m.separator
+	}
+	m.started = true
+	m.last = max(m.last, line.Line)
+	return append(lines, line)
+}
+
+// ContextLines returns the matches and their context lines in output
+// order, with context shared by neighbouring matches merged and separators
+// between groups of contiguous lines as set by WithContextSeparator
+func (r *SearchResults) ContextLines() []ContextLine {
+	merger := NewContextMerger(r.contextSeparator)
+	var lines []ContextLine
+	for _, match := range r.Matches {
+		lines = append(lines, merger.Add(match)...)
+	}
+	return append(lines, merger.Flush()...)
+}
@@ -0,0 +1,86 @@
+package goripgrep
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestContextLines(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"a.txt": "l1\nTODO a\nl3\nTODO b\nl5\nl6\nl7\nl8\nTODO c\nl10\n",
+		"b.txt": "TODO d\nl2\n",
+	})
+
+	// Lines are written as line:kind, with -- for separators
+	format := func(lines []ContextLine) string {
+		var out []string
+		for _, line := range lines {
+			if line.Separator != "" {
+				out = append(out, line.Separator)
+			}
+			kind := "match"
+			switch {
+			case line.Match == nil && line.After:
+				kind = "after"
+			case line.Match == nil:
+				kind = "before"
+			}
+			out = append(out, fmt.Sprintf("%s:%d:%s", filepath.Base(line.File), line.Line, kind))
+		}
+		return strings.Join(out, " ")
+	}
+
+	tests := []struct {
+		name string
+		opts []Option
+		want string
+	}{
+		{
+			name: "merged with separators",
+			opts: []Option{WithContextLines(1)},
+			want: "a.txt:1:before a.txt:2:match a.txt:3:after a.txt:4:match a.txt:5:after " +
+				"-- a.txt:8:before a.txt:9:match a.txt:10:after " +
+				"-- b.txt:1:match b.txt:2:after",
+		},
+		{
+			name: "custom separator",
+			opts: []Option{WithAfterContext(1), WithContextSeparator("...")},
+			want: "a.txt:2:match a.txt:3:after a.txt:4:match a.txt:5:after " +
+				"... a.txt:9:match a.txt:10:after " +
+				"... b.txt:1:match b.txt:2:after",
+		},
+		{
+			name: "separators turned off",
+			opts: []Option{WithBeforeContext(1), WithContextSeparator("")},
+			want: "a.txt:1:before a.txt:2:match a.txt:3:before a.txt:4:match " +
+				"a.txt:8:before a.txt:9:match b.txt:1:match",
+		},
+		{
+			name: "no context requested",
+			want: "a.txt:2:match a.txt:4:match a.txt:9:match b.txt:1:match",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := append(tt.opts, WithRecursive(true), WithDeterministicOutput(true))
+			results, err := Find("TODO", dir, opts...)
+			if err != nil {
+				t.Fatalf("Find failed: %v", err)
+			}
+			if got := format(results.ContextLines()); got != tt.want {
+				t.Errorf("Expected\n%s\ngot\n%s", tt.want, got)
+			}
+		})
+	}
+
+	// Streamed matches hold back their after context until the next match
+	merger := NewContextMerger("--")
+	first := merger.Add(Match{File: "x", Line: 2, AfterContext: []string{"l3", "l4"}})
+	second := merger.Add(Match{File: "x", Line: 4, BeforeContext: []string{"l3"}})
+	if got := format(first) + " | " + format(second) + " | " + format(merger.Flush()); got != "x:2:match | x:3:after x:4:match | " {
+		t.Errorf("Unexpected streamed lines: %s", got)
+	}
+}
@@ -0,0 +1,187 @@
+package goripgrep
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// LineStage is how far a line got through matching when debugging a pattern
+type LineStage int
+
+const (
+	StagePrefiltered LineStage = iota // Rejected by the literal prefilter without running the regex
+	StageVerified                     // Run through the regex or literal search without matching
+	StageMatched                      // Matched
+)
+
+// String returns the name of the stage
+func (s LineStage) String() string {
+	switch s {
+	case StagePrefiltered:
+		return "prefiltered"
+	case StageVerified:
+		return "verified"
+	case StageMatched:
+		return "matched"
+	default:
+		return "unknown"
+	}
+}
+
+// MarshalText encodes the stage by name so JSON output stays readable
+func (s LineStage) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// LineTrace is what happened to one line of a debugged file
+type LineTrace struct {
+	Line    int       // Line number (1-indexed)
+	Stage   LineStage // How far the line got
+	Matches int       // Number of matches on the line
+	Content string    // The line
+}
+
+// DebugTimings breaks the time spent on a debugged file down by stage
+type DebugTimings struct {
+	Compile   time.Duration // Compiling the pattern
+	Read      time.Duration // Reading the file
+	Prefilter time.Duration // Checking lines for the prefilter literals
+	Verify    time.Duration // Running the regex engine or literal search on the lines left
+}
+
+// PatternDebug shows how a pattern was matched against one file, line by
+// line, for finding out why a search is slow or misses matches
+type PatternDebug struct {
+	Report    *PatternReport // How the pattern is classified, as ExplainPattern reports it
+	File      string
+	Prefilter []string // Literals a line must contain before the regex runs; empty when every line reaches it
+	Lines     []LineTrace
+	Timings   DebugTimings
+
+	LinesRead   int // Lines in the file
+	Prefiltered int // Lines rejected by the prefilter
+	Verified    int // Lines run through the regex or literal search
+	Matched     int // Lines with at least one match
+}
+
+// DebugPattern matches pattern against the file at path the way Find
+// would, with the same options, and reports what happened to every line:
+// whether the prefilter rejected it, the regex or literal search ran on it
+// and whether it matched, with the time spent in each stage. In multiline
+// mode the regex runs over the whole file instead, and only matched lines
+// are reported.
+func DebugPattern(pattern, path string, opts ...Option) (*PatternDebug, error) {
+	report, err := ExplainPattern(pattern, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	options := defaultOptions()
+	for _, opt := range opts {
+		opt(options)
+	}
+	config := options.searchConfig(path)
+	config.RegexCaching = false
+
+	debug := &PatternDebug{Report: report, File: path}
+	start := time.Now()
+	matcher, err := newLineMatcher(report.Pattern, config)
+	if err != nil {
+		return nil, err
+	}
+	debug.Timings.Compile = time.Since(start)
+	debug.Prefilter = matcher.required
+
+	start = time.Now()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	debug.Timings.Read = time.Since(start)
+
+	data = stripBOM(data)
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if len(data) == 0 {
+		lines = nil
+	}
+	debug.LinesRead = len(lines)
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+
+	if config.Multiline {
+		debug.traceMultiline(matcher, data)
+		return debug, nil
+	}
+
+	// Prefilter every line first, then verify the survivors, so each stage
+	// is timed as a whole
+	start = time.Now()
+	candidates := make([]bool, len(lines))
+	for i, line := range lines {
+		candidates[i] = matcher.mayMatch(line)
+	}
+	debug.Timings.Prefilter = time.Since(start)
+
+	counts := make([]int, len(lines))
+	start = time.Now()
+	for i, line := range lines {
+		if candidates[i] {
+			counts[i] = len(matcher.findAll(line))
+		}
+	}
+	debug.Timings.Verify = time.Since(start)
+
+	debug.Lines = make([]LineTrace, len(lines))
+	for i, line := range lines {
+		trace := LineTrace{Line: i + 1, Matches: counts[i], Content: line}
+		switch {
+		case !candidates[i]:
+			trace.Stage = StagePrefiltered
+			debug.Prefiltered++
+		case counts[i] > 0:
+			trace.Stage = StageMatched
+			debug.Verified++
+			debug.Matched++
+		default:
+			trace.Stage = StageVerified
+			debug.Verified++
+		}
+		debug.Lines[i] = trace
+	}
+	return debug, nil
+}
+
+// traceMultiline runs a multiline regex over the whole file and reports the
+// lines its matches start on
+func (d *PatternDebug) traceMultiline(matcher *lineMatcher, data []byte) {
+	start := time.Now()
+	spans := matcher.regex.FindAllIndex(data, -1)
+	d.Timings.Verify = time.Since(start)
+	d.Verified = d.LinesRead
+
+	for _, span := range spans {
+		line := bytes.Count(data[:span[0]], []byte("\n")) + 1
+		if n := len(d.Lines); n > 0 && d.Lines[n-1].Line == line {
+			d.Lines[n-1].Matches++
+			continue
+		}
+		lineStart := bytes.LastIndexByte(data[:span[0]], '\n') + 1
+		lineEnd := bytes.IndexByte(data[span[0]:], '\n')
+		if lineEnd < 0 {
+			lineEnd = len(data)
+		} else {
+			lineEnd += span[0]
+		}
+		d.Lines = append(d.Lines, LineTrace{
+			Line:    line,
+			Stage:   StageMatched,
+			Matches: 1,
+			Content: strings.TrimSuffix(string(data[lineStart:lineEnd]), "\r"),
+		})
+		d.Matched++
+	}
+}
@@ -0,0 +1,105 @@
+package goripgrep
+
+import (
+	"path/filepath"
+	"regexp"
+	"slices"
+	"testing"
+)
+
+func TestDebugPattern(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"menu.txt": "alpha\nbetaSushi here\nSushi alone\nno fish\r\nwasabiSushi and tunaSushi\n",
+	})
+	file := filepath.Join(dir, "menu.txt")
+
+	stages := func(debug *PatternDebug) []string {
+		var got []string
+		for _, line := range debug.Lines {
+			got = append(got, line.Stage.String())
+		}
+		return got
+	}
+
+	debug, err := DebugPattern(`\w+Sushi`, file)
+	if err != nil {
+		t.Fatalf("DebugPattern failed: %v", err)
+	}
+	if debug.Report.Kind != PatternRegex || !slices.Equal(debug.Prefilter, []string{"Sushi"}) {
+		t.Errorf("Expected a regex prefiltered on Sushi, got %s with %q", debug.Report.Kind, debug.Prefilter)
+	}
+	want := []string{"prefiltered", "matched", "verified", "prefiltered", "matched"}
+	if !slices.Equal(stages(debug), want) {
+		t.Errorf("Expected stages %v, got %v", want, stages(debug))
+	}
+	if debug.LinesRead != 5 || debug.Prefiltered != 2 || debug.Verified != 3 || debug.Matched != 2 {
+		t.Errorf("Unexpected counts: %+v", debug)
+	}
+	if debug.Lines[4].Matches != 2 || debug.Lines[3].Content != "no fish" {
+		t.Errorf("Unexpected line traces: %+v", debug.Lines)
+	}
+
+	// Literals and case folded regexes reach the search on every line
+	for _, opts := range [][]Option{nil, {WithIgnoreCase()}} {
+		debug, err = DebugPattern("sushi", file, opts...)
+		if err != nil {
+			t.Fatalf("DebugPattern failed: %v", err)
+		}
+		if len(debug.Prefilter) != 0 || debug.Prefiltered != 0 || debug.Verified != 5 {
+			t.Errorf("Expected no prefilter, got %q with %d lines prefiltered", debug.Prefilter, debug.Prefiltered)
+		}
+	}
+	if debug.Matched != 3 {
+		t.Errorf("Expected 3 lines matched ignoring case, got %d", debug.Matched)
+	}
+
+	debug, err = DebugPattern(`here\nSushi`, file, WithMultiline())
+	if err != nil {
+		t.Fatalf("DebugPattern failed: %v", err)
+	}
+	if debug.Report.Kind != PatternMultiline || len(debug.Lines) != 1 || debug.Lines[0].Line != 2 {
+		t.Errorf("Expected a multiline match on line 2, got %+v", debug.Lines)
+	}
+
+	if _, err := DebugPattern("x", filepath.Join(dir, "missing.txt")); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+}
+
+func TestLineMatcherPrefilter(t *testing.T) {
+	lines := []string{"", "foo", "xfooy", "bar baz", "foobar", "FOO", "a1b2", "say hello world", "hello"}
+	tests := []struct {
+		pattern  string
+		required []string
+	}{
+		{`\w+foo`, []string{"foo"}},
+		{`\d(foo|bar)`, []string{"foo", "bar"}},
+		{`\bhello\s+\w+`, []string{"hello"}},
+		{`(?i)\w+foo`, nil},
+		{`foo\w*`, []string{"foo"}},
+		{`\w+(foo)?`, nil},
+		{`[a-z]\d`, nil},
+	}
+	for _, tt := range tests {
+		matcher, err := newLineMatcher(tt.pattern, SearchConfig{})
+		if err != nil {
+			t.Fatalf("newLineMatcher(%q) failed: %v", tt.pattern, err)
+		}
+		if !slices.Equal(matcher.required, tt.required) {
+			t.Errorf("Expected %q to require %q, got %q", tt.pattern, tt.required, matcher.required)
+		}
+
+		// Prefiltering never changes the matches
+		re := regexp.MustCompile(tt.pattern)
+		for _, line := range lines {
+			want := re.FindAllStringIndex(line, -1)
+			if got := matcher.findAll(line); !slices.EqualFunc(got, want, slices.Equal) {
+				t.Errorf("%q on %q: expected %v, got %v", tt.pattern, line, want, got)
+			}
+			if matcher.matches(line) != (want != nil) {
+				t.Errorf("%q on %q: expected matches %v", tt.pattern, line, want != nil)
+			}
+		}
+	}
+}
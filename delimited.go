@@ -0,0 +1,171 @@
+package goripgrep
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// delimitedHeader names the columns written by CSVWriter
+var delimitedHeader = []string{"file", "line", "column", "type", "match", "content"}
+
+// escapeTSV escapes the characters that would break a TSV row
+var escapeTSV = strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`).Replace
+
+// CSVWriter writes matches as CSV or TSV rows for spreadsheets and data
+// pipelines. The first row is a header naming the columns: file, line,
+// column, type, match and content. CSV fields are quoted as RFC 4180
+// requires; TSV fields escape tabs, newlines and backslashes with a
+// backslash instead.
+type CSVWriter struct {
+	// Context also writes the context lines around each match as rows of
+	// their own, with type "context" and no column or match. Lines shared
+	// by neighbouring matches are written once, in line order.
+	Context bool
+
+	w       io.Writer
+	csv     *csv.Writer // nil for TSV
+	started bool
+
+	file    string     // File of the last match written
+	line    int        // Last line of the last match written
+	pending [][]string // After context of the last match, held back in case the next match overlaps it
+}
+
+// NewCSVWriter returns a writer of comma separated rows
+func NewCSVWriter(w io.Writer) *CSVWriter {
+	return &CSVWriter{w: w, csv: csv.NewWriter(w)}
+}
+
+// NewTSVWriter returns a writer of tab separated rows
+func NewTSVWriter(w io.Writer) *CSVWriter {
+	return &CSVWriter{w: w}
+}
+
+// Write writes a row for every match in results, after the header if it
+// has not been written yet
+func (cw *CSVWriter) Write(results *SearchResults) error {
+	for _, match := range results.Matches {
+		if err := cw.writeMatch(match); err != nil {
+			return err
+		}
+	}
+	return cw.Flush()
+}
+
+// WriteMatch writes the rows of a single match, for output written as
+// matches arrive. Its after context is held until the next match or Flush.
+func (cw *CSVWriter) WriteMatch(match Match) error {
+	if err := cw.writeMatch(match); err != nil {
+		return err
+	}
+	return cw.flushWriter()
+}
+
+// Flush writes any held back context rows, and the header if nothing has
+// been written yet
+func (cw *CSVWriter) Flush() error {
+	if err := cw.writeHeader(); err != nil {
+		return err
+	}
+	if err := cw.writePending(0); err != nil {
+		return err
+	}
+	return cw.flushWriter()
+}
+
+// writeMatch writes the rows of match, leaving its after context pending
+func (cw *CSVWriter) writeMatch(match Match) error {
+	if err := cw.writeHeader(); err != nil {
+		return err
+	}
+
+	// Context from the previous match in the same file stops where this
+	// match's lines begin
+	first := match.Line - len(match.BeforeContext)
+	before := first
+	if match.File != cw.file {
+		cw.line, before = 0, 0
+	}
+	if err := cw.writePending(before); err != nil {
+		return err
+	}
+
+	if cw.Context {
+		for i, content := range match.BeforeContext {
+			if line := first + i; line > cw.line {
+				if err := cw.writeRow(contextRow(match.File, line, content)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	err := cw.writeRow([]string{
+		match.File, strconv.Itoa(match.Line), strconv.Itoa(match.Column),
+		"match", match.MatchText, match.Content,
+	})
+	if err != nil {
+		return err
+	}
+
+	cw.file, cw.line = match.File, max(match.Line, match.EndLine)
+	if cw.Context {
+		for i, content := range match.AfterContext {
+			cw.pending = append(cw.pending, contextRow(match.File, cw.line+1+i, content))
+		}
+	}
+	return nil
+}
+
+// writePending writes the held back context rows before line, or all of
+// them when line is 0 or the next match is in another file, and drops the
+// rest, which the next match's own rows cover
+func (cw *CSVWriter) writePending(before int) error {
+	for _, row := range cw.pending {
+		if line, _ := strconv.Atoi(row[1]); before > 0 && line >= before {
+			break
+		}
+		if err := cw.writeRow(row); err != nil {
+			return err
+		}
+	}
+	cw.pending = cw.pending[:0]
+	return nil
+}
+
+// writeHeader writes the header row once
+func (cw *CSVWriter) writeHeader() error {
+	if cw.started {
+		return nil
+	}
+	cw.started = true
+	return cw.writeRow(delimitedHeader)
+}
+
+// writeRow writes one row in the writer's format
+func (cw *CSVWriter) writeRow(fields []string) error {
+	if cw.csv != nil {
+		return cw.csv.Write(fields)
+	}
+	escaped := make([]string, len(fields))
+	for i, field := range fields {
+		escaped[i] = escapeTSV(field)
+	}
+	_, err := io.WriteString(cw.w, strings.Join(escaped, "\t")+"\n")
+	return err
+}
+
+// flushWriter pushes buffered CSV rows to the underlying writer
+func (cw *CSVWriter) flushWriter() error {
+	if cw.csv == nil {
+		return nil
+	}
+	cw.csv.Flush()
+	return cw.csv.Error()
+}
+
+// contextRow is the row of a context line
+func contextRow(file string, line int, content string) []string {
+	return []string{file, strconv.Itoa(line), "", "context", "", content}
+}
@@ -0,0 +1,91 @@
+package goripgrep
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCSVWriter(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{"a.txt": "a\nTODO one, \"x\"\nTODO\ttwo\nb\nc\nd\nTODO three\n"})
+	file := filepath.Join(dir, "a.txt")
+
+	search := func(t *testing.T, opts ...Option) *SearchResults {
+		results, err := FindInFile("TODO", file, opts...)
+		if err != nil {
+			t.Fatalf("FindInFile failed: %v", err)
+		}
+		return results
+	}
+
+	t.Run("csv", func(t *testing.T) {
+		var out strings.Builder
+		if err := NewCSVWriter(&out).Write(search(t)); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+		want := "file,line,column,type,match,content\n" +
+			file + ",2,1,match,TODO,\"TODO one, \"\"x\"\"\"\n" +
+			file + ",3,1,match,TODO,TODO\ttwo\n" +
+			file + ",7,1,match,TODO,TODO three\n"
+		if out.String() != want {
+			t.Errorf("Expected %q, got %q", want, out.String())
+		}
+	})
+
+	t.Run("tsv", func(t *testing.T) {
+		var out strings.Builder
+		if err := NewTSVWriter(&out).Write(search(t)); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+		lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+		if len(lines) != 4 || lines[0] != "file\tline\tcolumn\ttype\tmatch\tcontent" {
+			t.Fatalf("Unexpected rows: %q", lines)
+		}
+		if want := file + "\t3\t1\tmatch\tTODO\tTODO\\ttwo"; lines[2] != want {
+			t.Errorf("Expected %q, got %q", want, lines[2])
+		}
+	})
+
+	t.Run("context rows", func(t *testing.T) {
+		var out strings.Builder
+		writer := NewCSVWriter(&out)
+		writer.Context = true
+		if err := writer.Write(search(t, WithContextLines(1))); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+		// Lines 2 and 3 are each other's context but appear once, as matches
+		var got []string
+		for _, line := range strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")[1:] {
+			fields := strings.Split(strings.TrimPrefix(line, file+","), ",")
+			got = append(got, fields[0]+":"+fields[2])
+		}
+		want := []string{"1:context", "2:match", "3:match", "4:context", "6:context", "7:match"}
+		if strings.Join(got, " ") != strings.Join(want, " ") {
+			t.Errorf("Expected rows %v, got %v", want, got)
+		}
+	})
+
+	t.Run("streamed matches", func(t *testing.T) {
+		var out strings.Builder
+		writer := NewCSVWriter(&out)
+		for _, match := range search(t).Matches {
+			if err := writer.WriteMatch(match); err != nil {
+				t.Fatalf("WriteMatch failed: %v", err)
+			}
+		}
+		if n := strings.Count(out.String(), "file,line"); n != 1 {
+			t.Errorf("Expected one header, got %d", n)
+		}
+	})
+
+	t.Run("no matches", func(t *testing.T) {
+		var out strings.Builder
+		if err := NewCSVWriter(&out).Write(&SearchResults{}); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+		if out.String() != "file,line,column,type,match,content\n" {
+			t.Errorf("Expected just the header, got %q", out.String())
+		}
+	})
+}
@@ -8,7 +8,9 @@ import (
 	"time"
 )
 
-// DFACache provides thread-safe caching of compiled regular expressions
+// DFACache provides thread-safe caching of compiled regular expressions.
+// All methods may be called from multiple goroutines; the compiled
+// *regexp.Regexp values it returns are themselves safe for concurrent use
 type DFACache struct {
 	cache   map[string]*CachedRegex
 	mutex   sync.RWMutex
@@ -54,20 +56,21 @@ func NewDFACache(maxSize int, ttl time.Duration) *DFACache {
 func (c *DFACache) GetOrCompile(pattern string, flags string) (*regexp.Regexp, error) {
 	key := c.generateKey(pattern, flags)
 
-	// Try to get from cache first
-	c.mutex.RLock()
+	// Try to get from cache first; a hit updates the entry's usage, so it
+	// needs the write lock
+	c.mutex.Lock()
 	cached, exists := c.cache[key]
 	if exists && !c.isExpired(cached) {
 		cached.lastUsed = time.Now()
 		cached.useCount++
 		c.hits++
-		c.mutex.RUnlock()
+		c.mutex.Unlock()
 		return cached.regex, nil
 	}
-	c.mutex.RUnlock()
-
-	// Cache miss - need to compile
 	c.misses++
+	c.mutex.Unlock()
+
+	// Cache miss - compile outside the lock
 
 	// Compile the regex
 	fullPattern := flags + pattern
@@ -236,7 +239,8 @@ type PatternInfo struct {
 var globalDFACache *DFACache
 var cacheOnce sync.Once
 
-// GetGlobalDFACache returns the global DFA cache instance
+// GetGlobalDFACache returns the global DFA cache instance shared by every
+// Engine in the process
 func GetGlobalDFACache() *DFACache {
 	cacheOnce.Do(func() {
 		globalDFACache = NewDFACache(1000, 30*time.Minute)
@@ -0,0 +1,86 @@
+package goripgrep
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strings"
+)
+
+// DuplicateLine is a matched line that occurs, apart from its indentation,
+// in several files
+type DuplicateLine struct {
+	Hash    string  // SHA-256 of the trimmed line, hex encoded
+	Content string  // The line with surrounding whitespace removed
+	Files   int     // Number of distinct files containing the line
+	Count   int     // Number of occurrences across all files
+	Matches []Match // One match per occurrence, ordered by file and line
+}
+
+// DuplicateLines groups the matched lines by their content, ignoring
+// surrounding whitespace, and returns those found in at least minFiles files
+// (two if minFiles is lower). Groups in the most files come first, which
+// points at copy-pasted code and repeated config blocks. Blank lines and
+// non-content matches are ignored, and a line matched several times counts
+// once.
+func (r *SearchResults) DuplicateLines(minFiles int) []DuplicateLine {
+	minFiles = max(minFiles, 2)
+
+	type fileLine struct {
+		file string
+		line int
+	}
+	seen := make(map[fileLine]bool)
+	index := make(map[string]int)
+	var groups []DuplicateLine
+	var files []map[string]bool // Distinct files of each group
+
+	for _, match := range r.Matches {
+		content := strings.TrimSpace(match.Content)
+		key := fileLine{match.File, match.Line}
+		if match.Kind != MatchContent || content == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		sum := sha256.Sum256([]byte(content))
+		hash := hex.EncodeToString(sum[:])
+		i, ok := index[hash]
+		if !ok {
+			i = len(groups)
+			index[hash] = i
+			groups = append(groups, DuplicateLine{Hash: hash, Content: content})
+			files = append(files, make(map[string]bool))
+		}
+		groups[i].Matches = append(groups[i].Matches, match)
+		groups[i].Count++
+		files[i][match.File] = true
+	}
+
+	var duplicates []DuplicateLine
+	for i, group := range groups {
+		group.Files = len(files[i])
+		if group.Files < minFiles {
+			continue
+		}
+		sort.SliceStable(group.Matches, func(a, b int) bool {
+			if group.Matches[a].File != group.Matches[b].File {
+				return group.Matches[a].File < group.Matches[b].File
+			}
+			return group.Matches[a].Line < group.Matches[b].Line
+		})
+		duplicates = append(duplicates, group)
+	}
+
+	sort.Slice(duplicates, func(i, j int) bool {
+		if duplicates[i].Files != duplicates[j].Files {
+			return duplicates[i].Files > duplicates[j].Files
+		}
+		if duplicates[i].Count != duplicates[j].Count {
+			return duplicates[i].Count > duplicates[j].Count
+		}
+		return duplicates[i].Content < duplicates[j].Content
+	})
+
+	return duplicates
+}
@@ -0,0 +1,50 @@
+package goripgrep
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestDuplicateLines(t *testing.T) {
+	results := &SearchResults{Matches: []Match{
+		{File: "b.go", Line: 3, Content: "\tx = shared()"},
+		{File: "a.go", Line: 2, Content: "x = shared()"},
+		{File: "a.go", Line: 2, Column: 5, Content: "x = shared()"},
+		{File: "b.go", Line: 1, Content: "x = shared()  "},
+		{File: "c.go", Line: 1, Content: "x = shared()"},
+		{File: "a.go", Line: 1, Content: "a := 1"},
+		{File: "c.go", Line: 2, Content: "a := 1"},
+		{File: "a.go", Line: 5, Content: "   "},
+		{File: "c.go", Line: 5, Content: ""},
+		{File: "d.go", Line: 1, Content: "only once"},
+		{File: "e.go", Content: "a := 1", Kind: MatchFileName},
+	}}
+
+	duplicates := results.DuplicateLines(0)
+	if len(duplicates) != 2 {
+		t.Fatalf("Expected 2 duplicated lines, got %+v", duplicates)
+	}
+
+	first := duplicates[0]
+	if first.Content != "x = shared()" || first.Files != 3 || first.Count != 4 || len(first.Matches) != 4 {
+		t.Errorf("Unexpected first group: %q in %d files, %d times", first.Content, first.Files, first.Count)
+	}
+	var locations []string
+	for _, match := range first.Matches {
+		locations = append(locations, fmt.Sprintf("%s:%d", match.File, match.Line))
+	}
+	if fmt.Sprint(locations) != "[a.go:2 b.go:1 b.go:3 c.go:1]" {
+		t.Errorf("Expected occurrences ordered by file and line, got %v", locations)
+	}
+	if len(first.Hash) != 64 || first.Hash == duplicates[1].Hash {
+		t.Errorf("Expected distinct SHA-256 hashes, got %q and %q", first.Hash, duplicates[1].Hash)
+	}
+
+	if second := duplicates[1]; second.Content != "a := 1" || second.Files != 2 {
+		t.Errorf("Unexpected second group: %q in %d files", second.Content, second.Files)
+	}
+
+	if got := results.DuplicateLines(3); len(got) != 1 || got[0].Content != "x = shared()" {
+		t.Errorf("Expected only the line in 3 files with minFiles 3, got %+v", got)
+	}
+}
@@ -53,16 +53,25 @@ var ByteFrequency = [256]int{
 	248: 3, 249: 3, 250: 3, 251: 3, 252: 2, 253: 2, 254: 1, 255: 1,
 }
 
-// Engine provides high-performance text search with advanced optimizations
+// Engine provides high-performance text search with advanced optimizations.
+// An Engine is safe for concurrent use: Search may be called from multiple
+// goroutines and its statistics are updated atomically
 type Engine struct {
-	pattern      string
-	regex        *regexp.Regexp
-	isLiteral    bool
-	ignoreCase   bool
-	searchBytes  []byte
-	rareByte     byte
-	rareByteIdx  int
-	contextLines int
+	pattern       string
+	regex         *regexp.Regexp
+	pcre          *pcreRegexp // Set instead of regex for lookaround and backreferences
+	isLiteral     bool
+	ignoreCase    bool
+	multiline     bool
+	invertMatch   bool
+	wordRegexp    bool // Literal matches must sit on word boundaries
+	lineRegexp    bool // Literal matches must span the whole line
+	searchBytes   []byte
+	required      [][]byte // Literals one of which a regex match contains; nil to run the regex on everything
+	rareByte      byte
+	rareByteIdx   int
+	beforeContext int
+	afterContext  int
 
 	// Performance settings
 	bufferSize   int
@@ -78,9 +87,13 @@ type Engine struct {
 	streamDecompressor  *StreamingDecompressor
 
 	// Statistics
-	bytesScanned int64
-	filesScanned int64
-	matchesFound int64
+	bytesScanned     int64
+	filesScanned     int64
+	matchesFound     int64
+	nonMatchingLines int64
+	prefilterSkipped int64 // Lines, or multiline buffers, the prefilter kept from the regex
+	prefilterPassed  int64
+	phases           phaseCounters
 }
 
 // NewEngine creates a high-performance search engine
@@ -94,20 +107,34 @@ func NewEngine(args SearchArgs) (*Engine, error) {
 
 		// Initialize optimizations
 		optimizedEngine: NewOptimizedEngine(),
-		dfaCache:        NewDFACache(1000, 30*time.Minute),
+		dfaCache:        GetGlobalDFACache(),
 
 		// Initialize compression support
 		compressionDetector: NewCompressionDetector(),
 		streamDecompressor:  NewStreamingDecompressor(64 * 1024),
 	}
 
-	// Set context lines if provided
+	// Set context lines if provided, with explicit before/after counts taking precedence
 	if args.ContextLines != nil {
-		engine.contextLines = *args.ContextLines
+		engine.beforeContext = *args.ContextLines
+		engine.afterContext = *args.ContextLines
+	}
+	if args.BeforeContext != nil {
+		engine.beforeContext = *args.BeforeContext
+	}
+	if args.AfterContext != nil {
+		engine.afterContext = *args.AfterContext
 	}
 
-	// Determine if pattern is literal
-	engine.isLiteral = isLiteralPattern(args.Pattern)
+	// Multiline mode always searches whole buffers with the regex engine
+	engine.multiline = args.Multiline != nil && *args.Multiline
+	engine.invertMatch = args.InvertMatch != nil && *args.InvertMatch
+	engine.wordRegexp = args.WordRegexp != nil && *args.WordRegexp
+	engine.lineRegexp = args.LineRegexp != nil && *args.LineRegexp
+
+	// Determine if pattern is literal; fixed strings always take the literal path
+	fixedStrings := args.FixedStrings != nil && *args.FixedStrings
+	engine.isLiteral = (fixedStrings || isLiteralPattern(args.Pattern)) && !engine.multiline
 
 	if engine.isLiteral {
 		// Optimize literal search
@@ -119,8 +146,29 @@ func NewEngine(args SearchArgs) (*Engine, error) {
 		engine.findRareByte()
 	} else {
 		// Compile regex with DFA caching
+		pattern := args.Pattern
+		if fixedStrings {
+			pattern = regexp.QuoteMeta(pattern)
+		}
+		pattern = boundaryPattern(pattern, engine.wordRegexp, engine.lineRegexp)
+		if !fixedStrings && usesPCRE2Syntax(pattern) {
+			// Lookaround and backreferences need the backtracking engine, and
+			// literal extraction cannot see through them
+			if engine.multiline && args.PCRE2 != nil && *args.PCRE2 {
+				return nil, fmt.Errorf("PCRE2 syntax is not supported in multiline mode")
+			}
+			if engine.ignoreCase {
+				pattern = "(?i)" + pattern
+			}
+			var err error
+			if _, engine.pcre, err = compileRegex(pattern, args.PCRE2 != nil && *args.PCRE2); err != nil {
+				return nil, fmt.Errorf("invalid regex pattern: %w", err)
+			}
+			return engine, nil
+		}
+
 		var err error
-		engine.regex, err = engine.dfaCache.GetOrCompile(args.Pattern, engine.getRegexFlags())
+		engine.regex, err = engine.dfaCache.GetOrCompile(pattern, engine.getRegexFlags())
 		if err != nil {
 			return nil, fmt.Errorf("invalid regex pattern: %w", err)
 		}
@@ -134,8 +182,13 @@ func NewEngine(args SearchArgs) (*Engine, error) {
 
 // getRegexFlags returns the appropriate regex flags for compilation
 func (e *Engine) getRegexFlags() string {
-	if e.ignoreCase {
+	switch {
+	case e.ignoreCase && e.multiline:
+		return "(?im)"
+	case e.ignoreCase:
 		return "(?i)"
+	case e.multiline:
+		return "(?m)"
 	}
 	return ""
 }
@@ -145,75 +198,44 @@ func (e *Engine) findRareByte() {
 	if len(e.searchBytes) == 0 {
 		return
 	}
+	e.rareByte, e.rareByteIdx = rarestByte(e.searchBytes)
+}
 
-	minFreq := ByteFrequency[e.searchBytes[0]]
-	e.rareByte = e.searchBytes[0]
-	e.rareByteIdx = 0
-
-	for i, b := range e.searchBytes {
-		if ByteFrequency[b] < minFreq {
-			minFreq = ByteFrequency[b]
-			e.rareByte = b
-			e.rareByteIdx = i
+// rarestByte returns the byte of a non-empty literal that is least common in
+// typical text, by ByteFrequency, and its index; the first wins ties
+func rarestByte(literal []byte) (byte, int) {
+	rare, index := literal[0], 0
+	for i, b := range literal {
+		if ByteFrequency[b] < ByteFrequency[rare] {
+			rare, index = b, i
 		}
 	}
+	return rare, index
 }
 
-// extractLiterals attempts to extract literal substrings from regex patterns
+// extractLiterals finds the literals one of which every regex match
+// contains, by analysing the parsed regex, so lines and multiline buffers
+// without any are skipped before the regex runs
 func (e *Engine) extractLiterals() {
-	// Simple literal extraction for common patterns
-	pattern := e.pattern
-
-	// Skip patterns with character classes - they don't have useful literals
-	if strings.Contains(pattern, "[") && strings.Contains(pattern, "]") {
-		return
+	for _, literal := range prefilterLiterals(e.regex) {
+		e.required = append(e.required, []byte(literal))
 	}
+}
 
-	// Skip patterns that are just quantifiers
-	if len(pattern) <= 2 && strings.ContainsAny(pattern, "+*?") {
-		return
+// mayMatch reports whether data contains one of the required literals, so
+// that the regex can match it at all, counting the outcome for GetStats
+func (e *Engine) mayMatch(data []byte) bool {
+	if e.required == nil {
+		return true
 	}
-
-	// Remove common regex anchors and modifiers
-	pattern = strings.TrimPrefix(pattern, "^")
-	pattern = strings.TrimSuffix(pattern, "$")
-
-	// Look for literal sequences
-	if strings.Contains(pattern, "|") {
-		// Handle alternations - find common prefix/suffix or use first alternative
-		parts := strings.Split(pattern, "|")
-		if len(parts) > 1 {
-			commonPrefix := findCommonPrefix(parts)
-			if len(commonPrefix) >= 2 {
-				e.searchBytes = []byte(commonPrefix)
-				e.findRareByte()
-				return
-			}
-			// If no common prefix, use the first alternative if it's literal
-			firstPart := parts[0]
-			if len(firstPart) >= 2 && !strings.ContainsAny(firstPart, ".*+?^$()[]{}\\") {
-				e.searchBytes = []byte(firstPart)
-				e.findRareByte()
-				return
-			}
-		}
-	} else {
-		// Look for literal sequences in the pattern
-		literals := extractSimpleLiterals(pattern)
-		if len(literals) > 0 {
-			// Choose the longest literal
-			longest := literals[0]
-			for _, lit := range literals[1:] {
-				if len(lit) > len(longest) {
-					longest = lit
-				}
-			}
-			if len(longest) >= 2 {
-				e.searchBytes = []byte(longest)
-				e.findRareByte()
-			}
+	for _, literal := range e.required {
+		if bytes.Contains(data, literal) {
+			atomic.AddInt64(&e.prefilterPassed, 1)
+			return true
 		}
 	}
+	atomic.AddInt64(&e.prefilterSkipped, 1)
+	return false
 }
 
 // fastByteScan performs optimized byte scanning using SIMD when available
@@ -277,10 +299,13 @@ func (e *Engine) optimizedLiteralSearch(data []byte) []int {
 
 // Search performs optimized search on a file
 func (e *Engine) Search(ctx context.Context, filePath string) ([]Match, error) {
+	defer e.phases.since(&e.phases.process, time.Now())
 	atomic.AddInt64(&e.filesScanned, 1)
 
-	// Check if file is compressed
+	// Check if file is compressed; sniffing the header counts as reading
+	readStart := time.Now()
 	isCompressed, compressionType, err := e.compressionDetector.IsCompressed(filePath)
+	e.phases.since(&e.phases.read, readStart)
 	if err != nil {
 		return nil, fmt.Errorf("failed to check compression: %w", err)
 	}
@@ -300,7 +325,7 @@ func (e *Engine) searchPlainFile(ctx context.Context, filePath string) ([]Match,
 	}
 	defer file.Close()
 
-	return e.searchFromReader(ctx, filePath, file)
+	return e.searchFromReader(ctx, filePath, &countingReader{reader: file, elapsed: &e.phases.read})
 }
 
 // searchCompressedFile performs search on compressed files
@@ -308,6 +333,8 @@ func (e *Engine) searchCompressedFile(ctx context.Context, filePath string, comp
 	var results []Match
 
 	err := e.streamDecompressor.ProcessCompressedFile(filePath, func(reader io.Reader, ct CompressionType) error {
+		// Reads through the decompressor include reading the compressed file
+		reader = &countingReader{reader: reader, elapsed: &e.phases.decompress}
 		matches, err := e.searchFromReader(ctx, filePath, reader)
 		if err != nil {
 			return err
@@ -325,50 +352,17 @@ func (e *Engine) searchCompressedFile(ctx context.Context, filePath string, comp
 
 // searchFromReader performs the actual search logic on any io.Reader
 func (e *Engine) searchFromReader(ctx context.Context, filePath string, reader io.Reader) ([]Match, error) {
-	var results []Match
-	var allLines []string
-
-	// Read all lines first if we need context
-	if e.contextLines > 0 {
-		scanner := bufio.NewScanner(reader)
-		scanner.Buffer(make([]byte, e.bufferSize), e.bufferSize)
-		for scanner.Scan() {
-			allLines = append(allLines, scanner.Text())
-		}
-		if err := scanner.Err(); err != nil {
-			return nil, err
-		}
-
-		// For compressed files, we can't seek back, so we'll process from allLines
-		for lineNum, line := range allLines {
-			select {
-			case <-ctx.Done():
-				return results, ctx.Err()
-			default:
-			}
+	if e.multiline {
+		return e.searchMultiline(ctx, filePath, reader)
+	}
 
-			lineBytes := []byte(line)
-			atomic.AddInt64(&e.bytesScanned, int64(len(lineBytes)))
-
-			matches := e.findMatches(lineBytes)
-			for _, pos := range matches {
-				atomic.AddInt64(&e.matchesFound, 1)
-				result := Match{
-					File:    filePath,
-					Line:    lineNum + 1, // 1-indexed
-					Content: line,
-					Column:  pos + 1, // 1-indexed
-				}
-
-				// Add context lines
-				result.Context = e.extractContextLines(allLines, lineNum, e.contextLines)
-				results = append(results, result)
-			}
-		}
-		return results, nil
+	// Context is kept by a sliding window, so memory does not grow with the
+	// size of the file
+	if e.hasContext() {
+		return e.searchWithContext(ctx, filePath, reader)
 	}
 
-	// No context needed, process line by line
+	var results []Match
 	scanner := bufio.NewScanner(reader)
 	scanner.Buffer(make([]byte, e.bufferSize), e.bufferSize)
 
@@ -383,14 +377,19 @@ func (e *Engine) searchFromReader(ctx context.Context, filePath string, reader i
 		line := scanner.Bytes()
 		atomic.AddInt64(&e.bytesScanned, int64(len(line)))
 
-		matches := e.findMatches(line)
-		for _, pos := range matches {
+		spans := e.lineSpans(line)
+		allSpans := newSpans(spans)
+		for _, span := range spans {
 			atomic.AddInt64(&e.matchesFound, 1)
 			result := Match{
-				File:    filePath,
-				Line:    lineNum,
-				Content: string(line),
-				Column:  pos + 1, // 1-indexed
+				File:       filePath,
+				Line:       lineNum,
+				Content:    string(line),
+				MatchText:  string(line[span[0]:span[1]]),
+				Column:     span[0] + 1, // 1-indexed
+				MatchStart: span[0],
+				MatchEnd:   span[1],
+				Spans:      allSpans,
 			}
 			results = append(results, result)
 		}
@@ -401,14 +400,114 @@ func (e *Engine) searchFromReader(ctx context.Context, filePath string, reader i
 	return results, scanner.Err()
 }
 
+// searchWithContext streams reader through a sliding window searcher, which
+// holds back only the lines needed for before and after context
+func (e *Engine) searchWithContext(ctx context.Context, filePath string, reader io.Reader) ([]Match, error) {
+	options := DefaultSlidingWindowOptions()
+	options.ChunkSize = int64(e.bufferSize)
+	options.AdaptiveResize = false
+	options.BeforeContext = e.beforeContext
+	options.AfterContext = e.afterContext
+
+	searcher, err := NewSlidingWindowReaderSearcher(reader, filePath, e.pattern, options)
+	if err != nil {
+		return nil, err
+	}
+	searcher.lineSpans = func(line string) [][]int {
+		atomic.AddInt64(&e.bytesScanned, int64(len(line)))
+		return e.lineSpans([]byte(line))
+	}
+
+	var results []Match
+	err = searcher.SearchStream(ctx, func(match Match) error {
+		atomic.AddInt64(&e.matchesFound, 1)
+		match.MatchText = match.Content[match.MatchStart:match.MatchEnd]
+		results = append(results, match)
+		return nil
+	})
+	return results, err
+}
+
+// searchMultiline reads the whole stream and matches the regex across line boundaries
+func (e *Engine) searchMultiline(ctx context.Context, filePath string, reader io.Reader) ([]Match, error) {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	atomic.AddInt64(&e.bytesScanned, int64(len(data)))
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	var results []Match
+	if e.mayMatch(data) {
+		results = multilineMatches(filePath, data, e.regex, 1)
+	}
+	if e.invertMatch {
+		results = invertMultilineMatches(filePath, data, results, 1)
+		atomic.AddInt64(&e.nonMatchingLines, int64(len(results)))
+	}
+	atomic.AddInt64(&e.matchesFound, int64(len(results)))
+
+	if e.hasContext() && len(results) > 0 {
+		allLines := strings.Split(string(data), "\n")
+		for i := range results {
+			results[i].BeforeContext, results[i].AfterContext = surroundingLines(
+				allLines, results[i].Line-1, results[i].EndLine-1, e.beforeContext, e.afterContext)
+		}
+	}
+
+	return results, nil
+}
+
+// lineSpans returns the start and end offsets of the matches to report for
+// a line. In invert mode a line without matches is reported once, as an empty
+// span at position 0.
+func (e *Engine) lineSpans(line []byte) [][]int {
+	spans := e.findSpans(line)
+	if !e.invertMatch {
+		return spans
+	}
+	if len(spans) > 0 {
+		return nil
+	}
+	atomic.AddInt64(&e.nonMatchingLines, 1)
+	return [][]int{{0, 0}}
+}
+
+// findSpans returns the start and end offsets of each match in line
+func (e *Engine) findSpans(line []byte) [][]int {
+	if e.pcre != nil {
+		return e.pcre.FindAllIndex(line, -1)
+	}
+	if !e.isLiteral {
+		if !e.mayMatch(line) {
+			return nil
+		}
+		return e.regex.FindAllIndex(line, -1)
+	}
+
+	var spans [][]int
+	for _, start := range e.findMatches(line) {
+		spans = append(spans, []int{start, start + len(e.searchBytes)})
+	}
+	return spans
+}
+
 // findMatches extracts the match finding logic
 func (e *Engine) findMatches(line []byte) []int {
 	var matches []int
 	if e.isLiteral {
 		matches = e.optimizedLiteralSearch(line)
+		if e.wordRegexp || e.lineRegexp {
+			matches = e.boundedLiteralMatches(line, matches)
+		}
 	} else {
 		// Use regex search
-		regexMatches := e.regex.FindAllIndex(line, -1)
+		regexMatches := e.findSpans(line)
 		for _, match := range regexMatches {
 			matches = append(matches, match[0])
 		}
@@ -416,41 +515,41 @@ func (e *Engine) findMatches(line []byte) []int {
 	return matches
 }
 
-// extractContextLines extracts context lines around a match
-func (e *Engine) extractContextLines(allLines []string, matchLineIndex int, contextLines int) []string {
-	var context []string
-
-	// Add lines before the match
-	start := matchLineIndex - contextLines
-	if start < 0 {
-		start = 0
-	}
-
-	// Add lines after the match
-	end := matchLineIndex + contextLines + 1
-	if end > len(allLines) {
-		end = len(allLines)
-	}
-
-	for i := start; i < end; i++ {
-		if i != matchLineIndex {
-			context = append(context, allLines[i])
+// boundedLiteralMatches keeps the literal matches that satisfy the word or
+// line mode, mirroring the \b and ^$ wrapping applied on the regex path
+func (e *Engine) boundedLiteralMatches(line []byte, starts []int) []int {
+	bounded := starts[:0]
+	for _, start := range starts {
+		if isBoundedMatch(line, start, start+len(e.searchBytes), e.wordRegexp, e.lineRegexp) {
+			bounded = append(bounded, start)
 		}
 	}
+	return bounded
+}
 
-	return context
+// hasContext reports whether matches should carry surrounding lines
+func (e *Engine) hasContext() bool {
+	return e.beforeContext > 0 || e.afterContext > 0
 }
 
-// GetStats returns performance statistics including SIMD and cache info
+// GetStats returns performance statistics including SIMD and cache info.
+// Engines share the process-wide regex cache, so cache_size, cache_hits,
+// cache_misses, cache_hit_rate and cache_evicted cover every Engine in the
+// process rather than this one.
 func (e *Engine) GetStats() map[string]interface{} {
 	stats := map[string]interface{}{
-		"bytes_scanned": atomic.LoadInt64(&e.bytesScanned),
-		"files_scanned": atomic.LoadInt64(&e.filesScanned),
-		"matches_found": atomic.LoadInt64(&e.matchesFound),
-		"is_literal":    e.isLiteral,
-		"rare_byte":     fmt.Sprintf("0x%02x", e.rareByte),
-		"worker_count":  e.workerCount,
-		"buffer_size":   e.bufferSize,
+		"bytes_scanned":      atomic.LoadInt64(&e.bytesScanned),
+		"files_scanned":      atomic.LoadInt64(&e.filesScanned),
+		"matches_found":      atomic.LoadInt64(&e.matchesFound),
+		"non_matching_lines": atomic.LoadInt64(&e.nonMatchingLines),
+		"phase_timings":      e.phases.timings(),
+		"is_literal":         e.isLiteral,
+		"rare_byte":          fmt.Sprintf("0x%02x", e.rareByte),
+		"worker_count":       e.workerCount,
+		"buffer_size":        e.bufferSize,
+		"prefilter_literals": e.requiredStrings(),
+		"prefilter_skipped":  atomic.LoadInt64(&e.prefilterSkipped),
+		"prefilter_passed":   atomic.LoadInt64(&e.prefilterPassed),
 	}
 
 	// Add SIMD capabilities
@@ -459,7 +558,7 @@ func (e *Engine) GetStats() map[string]interface{} {
 		stats["simd_"+strings.ToLower(key)] = value
 	}
 
-	// Add DFA cache statistics
+	// Add DFA cache statistics, shared with every other Engine
 	cacheStats := e.dfaCache.Stats()
 	stats["cache_size"] = cacheStats.Size
 	stats["cache_hits"] = cacheStats.Hits
@@ -475,7 +574,19 @@ func (e *Engine) GetStats() map[string]interface{} {
 	return stats
 }
 
-// GetAdvancedStats returns detailed performance statistics
+// requiredStrings returns the required literals as strings, empty when
+// every line reaches the regex
+func (e *Engine) requiredStrings() []string {
+	literals := make([]string, len(e.required))
+	for i, literal := range e.required {
+		literals[i] = string(literal)
+	}
+	return literals
+}
+
+// GetAdvancedStats returns detailed performance statistics. Like the cache
+// entries of GetStats, CacheStats and CachedPatterns describe the regex cache
+// every Engine in the process shares.
 func (e *Engine) GetAdvancedStats() AdvancedStats {
 	return AdvancedStats{
 		BasicStats:       e.GetStats(),
@@ -492,50 +603,3 @@ type AdvancedStats struct {
 	CacheStats       CacheStats             `json:"cache_stats"`
 	CachedPatterns   []PatternInfo          `json:"cached_patterns"`
 }
-
-// Helper functions
-
-func findCommonPrefix(strs []string) string {
-	if len(strs) == 0 {
-		return ""
-	}
-
-	prefix := strs[0]
-	for _, s := range strs[1:] {
-		for len(prefix) > 0 && !strings.HasPrefix(s, prefix) {
-			prefix = prefix[:len(prefix)-1]
-		}
-		if len(prefix) == 0 {
-			break
-		}
-	}
-	return prefix
-}
-
-func extractSimpleLiterals(pattern string) []string {
-	var literals []string
-	current := ""
-
-	for i := 0; i < len(pattern); i++ {
-		r := rune(pattern[i])
-		switch r {
-		case '.', '*', '+', '?', '^', '$', '|', '(', ')', '[', ']', '{', '}', '\\':
-			if len(current) >= 2 {
-				literals = append(literals, current)
-			}
-			current = ""
-			// Skip escaped characters
-			if r == '\\' && i+1 < len(pattern) {
-				i++ // Skip next character
-			}
-		default:
-			current += string(r)
-		}
-	}
-
-	if len(current) >= 2 {
-		literals = append(literals, current)
-	}
-
-	return literals
-}
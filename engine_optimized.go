@@ -91,10 +91,13 @@ func (e *FastEngine) FastSearch(ctx context.Context, filePath string, ignoreCase
 
 			if idx := strings.Index(searchLine, searchTerm); idx != -1 {
 				results = append(results, Match{
-					File:    filePath,
-					Line:    lineNum,
-					Column:  idx + 1,
-					Content: line,
+					File:       filePath,
+					Line:       lineNum,
+					Column:     idx + 1,
+					Content:    line,
+					MatchStart: idx,
+					MatchEnd:   idx + len(searchTerm),
+					Spans:      []Span{{Start: idx, End: idx + len(searchTerm)}},
 				})
 			}
 
@@ -115,12 +118,16 @@ func (e *FastEngine) FastSearch(ctx context.Context, filePath string, ignoreCase
 			line := scanner.Text()
 
 			if matches := e.regex.FindAllStringIndex(line, -1); matches != nil {
+				allSpans := newSpans(matches)
 				for _, match := range matches {
 					results = append(results, Match{
-						File:    filePath,
-						Line:    lineNum,
-						Column:  match[0] + 1,
-						Content: line,
+						File:       filePath,
+						Line:       lineNum,
+						Column:     match[0] + 1,
+						Content:    line,
+						MatchStart: match[0],
+						MatchEnd:   match[1],
+						Spans:      allSpans,
 					})
 				}
 			}
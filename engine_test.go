@@ -3,8 +3,10 @@ package goripgrep
 import (
 	"compress/gzip"
 	"context"
+	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 )
@@ -213,6 +215,104 @@ func TestEngineSearch(t *testing.T) {
 	})
 }
 
+func TestEngineFixedStrings(t *testing.T) {
+	testFile := filepath.Join(t.TempDir(), "test.txt")
+	if err := os.WriteFile(testFile, []byte("x+y=z\nxxy=z\n"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	fixedStrings := true
+	engine, err := NewEngine(SearchArgs{
+		Pattern:      "x+y",
+		FixedStrings: &fixedStrings,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+
+	if !engine.isLiteral || engine.regex != nil {
+		t.Error("Expected fixed strings to use the literal search path without a regex")
+	}
+
+	results, err := engine.Search(context.Background(), testFile)
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(results) != 1 || results[0].Line != 1 {
+		t.Errorf("Expected a single match on line 1, got %+v", results)
+	}
+}
+
+func TestEngineWordAndLineRegexp(t *testing.T) {
+	testFile := filepath.Join(t.TempDir(), "test.txt")
+	if err := os.WriteFile(testFile, []byte("err\nerror here\nsome err, yes\n"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	enabled := true
+	tests := []struct {
+		name     string
+		args     SearchArgs
+		expected []int
+	}{
+		{"WordLiteral", SearchArgs{Pattern: "err", WordRegexp: &enabled}, []int{1, 3}},
+		{"WordRegex", SearchArgs{Pattern: "e.r", WordRegexp: &enabled}, []int{1, 3}},
+		{"LineLiteral", SearchArgs{Pattern: "err", LineRegexp: &enabled}, []int{1}},
+		{"LineRegex", SearchArgs{Pattern: "e.r", LineRegexp: &enabled}, []int{1}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			engine, err := NewEngine(test.args)
+			if err != nil {
+				t.Fatalf("Failed to create engine: %v", err)
+			}
+
+			results, err := engine.Search(context.Background(), testFile)
+			if err != nil {
+				t.Fatalf("Search failed: %v", err)
+			}
+
+			var lines []int
+			for _, result := range results {
+				lines = append(lines, result.Line)
+			}
+			if fmt.Sprint(lines) != fmt.Sprint(test.expected) {
+				t.Errorf("Expected lines %v, got %v", test.expected, lines)
+			}
+		})
+	}
+}
+
+func TestEngineInvertMatch(t *testing.T) {
+	testFile := filepath.Join(t.TempDir(), "test.txt")
+	if err := os.WriteFile(testFile, []byte("keep\ndrop this\nkeep too\n"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	invertMatch := true
+	engine, err := NewEngine(SearchArgs{
+		Pattern:     "drop",
+		InvertMatch: &invertMatch,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+
+	results, err := engine.Search(context.Background(), testFile)
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+
+	if len(results) != 2 || results[0].Line != 1 || results[1].Line != 3 {
+		t.Errorf("Expected lines 1 and 3, got %+v", results)
+	}
+
+	if got := engine.GetStats()["non_matching_lines"]; got != int64(2) {
+		t.Errorf("Expected 2 non-matching lines in stats, got %v", got)
+	}
+}
+
 func TestEngineOptimizedLiteralSearch(t *testing.T) {
 	args := SearchArgs{
 		Pattern: "test",
@@ -393,14 +493,17 @@ func BenchmarkEngineSearch(b *testing.B) {
 func TestEngineExtractLiterals(t *testing.T) {
 	tests := []struct {
 		pattern  string
-		hasBytes bool
+		required []string
 	}{
-		{"hello", true},
-		{"hello|world", true}, // Should extract common prefix or one of the alternatives
-		{"^hello$", true},     // Should extract "hello"
-		{".*", false},         // No useful literals
-		{"[abc]", false},      // Character class, no literals
-		{"a+", false},         // Quantifier, no useful literals
+		{"hello", nil}, // Searched as a literal, without the regex
+		{"hello|world", []string{"hello", "world"}},
+		{"^hello$", []string{"hello"}},
+		{`ERROR\s+\d+`, []string{"ERROR"}},
+		{`\w+(foo|bar)\d`, []string{"foo", "bar"}},
+		{".*", nil},    // No useful literals
+		{"[abc]", nil}, // Character class, no literals
+		{"a+", []string{"a"}},
+		{`\w+(foo)?`, nil}, // The literal is optional
 	}
 
 	for _, test := range tests {
@@ -414,12 +517,75 @@ func TestEngineExtractLiterals(t *testing.T) {
 				t.Fatalf("Failed to create engine: %v", err)
 			}
 
-			hasBytes := len(engine.searchBytes) > 0
-			if hasBytes != test.hasBytes {
-				t.Errorf("Pattern %q: expected hasBytes=%v, got %v", test.pattern, test.hasBytes, hasBytes)
+			if got := engine.requiredStrings(); !slices.Equal(got, test.required) {
+				t.Errorf("Pattern %q: expected required literals %q, got %q", test.pattern, test.required, got)
 			}
 		})
 	}
+
+	ignoreCase := true
+	engine, err := NewEngine(SearchArgs{Pattern: `ERROR\s+\d+`, IgnoreCase: &ignoreCase})
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+	if len(engine.required) != 0 {
+		t.Errorf("Expected no prefilter ignoring case, got %q", engine.requiredStrings())
+	}
+}
+
+func TestEnginePrefilter(t *testing.T) {
+	tempDir := t.TempDir()
+	testFile := filepath.Join(tempDir, "app.log")
+	content := "INFO started\nERROR 42 failed\nINFO ERROR without code\nWARN slow\nERROR  7 again\n"
+	if err := os.WriteFile(testFile, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	for _, invert := range []bool{false, true} {
+		engine, err := NewEngine(SearchArgs{Pattern: `ERROR\s+\d+`, InvertMatch: &invert})
+		if err != nil {
+			t.Fatalf("Failed to create engine: %v", err)
+		}
+		matches, err := engine.Search(context.Background(), testFile)
+		if err != nil {
+			t.Fatalf("Search failed: %v", err)
+		}
+
+		// Prefiltering never changes the matches, inverted or not
+		var lines []int
+		for _, match := range matches {
+			lines = append(lines, match.Line)
+		}
+		want := []int{2, 5}
+		if invert {
+			want = []int{1, 3, 4}
+		}
+		if !slices.Equal(lines, want) {
+			t.Errorf("Invert %v: expected matches on lines %v, got %v", invert, want, lines)
+		}
+
+		stats := engine.GetStats()
+		if stats["prefilter_skipped"] != int64(2) || stats["prefilter_passed"] != int64(3) {
+			t.Errorf("Expected 2 lines skipped and 3 passed, got %v and %v", stats["prefilter_skipped"], stats["prefilter_passed"])
+		}
+		if !slices.Equal(stats["prefilter_literals"].([]string), []string{"ERROR"}) {
+			t.Errorf("Expected prefilter literals [ERROR], got %v", stats["prefilter_literals"])
+		}
+	}
+
+	// A multiline buffer without the literal skips the regex entirely
+	multiline := true
+	engine, err := NewEngine(SearchArgs{Pattern: `FATAL\s+\d+`, Multiline: &multiline})
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+	matches, err := engine.Search(context.Background(), testFile)
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(matches) != 0 || engine.GetStats()["prefilter_skipped"] != int64(1) {
+		t.Errorf("Expected the buffer to be skipped, got %d matches and stats %v", len(matches), engine.GetStats()["prefilter_skipped"])
+	}
 }
 
 func TestEngineCompressedFileSearch(t *testing.T) {
@@ -475,6 +641,11 @@ func TestEngineCompressedFileSearch(t *testing.T) {
 				t.Errorf("Expected content to contain '%s', got '%s'", pattern, result.Content)
 			}
 		}
+
+		phases := engine.GetStats()["phase_timings"].(PhaseTimings)
+		if phases.Decompress <= 0 {
+			t.Errorf("Expected decompression time to be recorded, got %+v", phases)
+		}
 	})
 
 	t.Run("PlainFileSearch", func(t *testing.T) {
@@ -555,7 +726,7 @@ func TestEngineCompressedFileSearch(t *testing.T) {
 			if result.Line != 3 {
 				t.Errorf("Expected match on line 3, got line %d", result.Line)
 			}
-			if len(result.Context) == 0 {
+			if len(result.BeforeContext) == 0 || len(result.AfterContext) == 0 {
 				t.Error("Expected context lines, got none")
 			}
 		}
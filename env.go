@@ -0,0 +1,142 @@
+package goripgrep
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// envVars are the environment variables that change the default options of
+// every search, for CI pipelines where editing commands is awkward. Options
+// passed to a search, and so the CLI's flags, are applied after them and
+// take precedence.
+var envVars = []struct {
+	name  string
+	usage string
+	apply func(opts *searchOptions, value string) error
+}{
+	{"GORIPGREP_WORKERS", "Number of concurrent workers, as --workers", func(opts *searchOptions, value string) (err error) {
+		opts.workers, err = envCount(value)
+		return err
+	}},
+	{"GORIPGREP_TIMEOUT", "Search timeout, such as 2m, as --timeout", func(opts *searchOptions, value string) error {
+		timeout, err := time.ParseDuration(value)
+		if err == nil && timeout <= 0 {
+			err = fmt.Errorf("%s is not a positive duration", value)
+		}
+		opts.timeout = timeout
+		return err
+	}},
+	{"GORIPGREP_MAX_RESULTS", "Maximum number of results, as --max-count", func(opts *searchOptions, value string) (err error) {
+		opts.maxResults, err = envCount(value)
+		return err
+	}},
+	{"GORIPGREP_MAX_DEPTH", "Directory levels to descend, as --max-depth", func(opts *searchOptions, value string) (err error) {
+		opts.maxDepth, err = envCount(value)
+		return err
+	}},
+	{"GORIPGREP_GLOBS", "Comma separated globs of files to search, as --glob", func(opts *searchOptions, value string) error {
+		opts.includeGlobs = splitEnvList(value)
+		return nil
+	}},
+	{"GORIPGREP_EXCLUDE", "Comma separated globs of files and directories to skip, as --exclude", func(opts *searchOptions, value string) error {
+		opts.excludeGlobs = splitEnvList(value)
+		return nil
+	}},
+	{"GORIPGREP_TYPES", "Comma separated file types to search, as --type", func(opts *searchOptions, value string) error {
+		opts.fileTypes = splitEnvList(value)
+		return nil
+	}},
+	{"GORIPGREP_TYPES_NOT", "Comma separated file types to skip, as --type-not", func(opts *searchOptions, value string) error {
+		opts.fileTypesNot = splitEnvList(value)
+		return nil
+	}},
+	{"GORIPGREP_IGNORE_CASE", "Case-insensitive search when true, as --ignore-case", func(opts *searchOptions, value string) (err error) {
+		opts.ignoreCase, err = strconv.ParseBool(value)
+		opts.caseSensitive = !opts.ignoreCase
+		return err
+	}},
+	{"GORIPGREP_SMART_CASE", "Ignore case unless the pattern has an uppercase letter when true, as --smart-case", func(opts *searchOptions, value string) (err error) {
+		opts.smartCase, err = strconv.ParseBool(value)
+		return err
+	}},
+	{"GORIPGREP_HIDDEN", "Include hidden files when true, as --hidden", func(opts *searchOptions, value string) (err error) {
+		opts.hidden, err = strconv.ParseBool(value)
+		return err
+	}},
+	{"GORIPGREP_FOLLOW", "Follow symbolic links when true, as --follow", func(opts *searchOptions, value string) (err error) {
+		opts.symlinks, err = strconv.ParseBool(value)
+		return err
+	}},
+	{"GORIPGREP_NO_IGNORE", "Don't respect ignore files when true, as --no-ignore", func(opts *searchOptions, value string) error {
+		noIgnore, err := strconv.ParseBool(value)
+		opts.gitignore = !noIgnore
+		return err
+	}},
+}
+
+// EnvVar is an environment variable that changes the default options
+type EnvVar struct {
+	Name  string
+	Usage string // What it sets, for help and generated documentation
+}
+
+// EnvVars lists the GORIPGREP_* environment variables searches read
+func EnvVars() []EnvVar {
+	vars := make([]EnvVar, len(envVars))
+	for i, env := range envVars {
+		vars[i] = EnvVar{Name: env.name, Usage: env.usage}
+	}
+	return vars
+}
+
+// applyEnv overrides the defaults with the environment variables that are
+// set. Defaults cannot fail, so the first invalid value is kept for
+// validate to report, and the variable is otherwise ignored.
+func (options *searchOptions) applyEnv() {
+	for _, env := range envVars {
+		value := strings.TrimSpace(os.Getenv(env.name))
+		if value == "" {
+			continue
+		}
+		defaults := *options
+		if err := env.apply(options, value); err != nil {
+			*options = defaults
+			if options.envErr == nil {
+				options.envErr = fmt.Errorf("invalid %s: %w", env.name, err)
+			}
+		}
+	}
+}
+
+// envCount parses a positive number
+func envCount(value string) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err == nil && n <= 0 {
+		err = fmt.Errorf("%s is not a positive number", value)
+	}
+	return n, err
+}
+
+// splitEnvList splits a comma separated list, leaving the commas of brace
+// alternatives such as *.{go,md} alone
+func splitEnvList(value string) []string {
+	var items []string
+	depth, start := 0, 0
+	for i := 0; i <= len(value); i++ {
+		switch {
+		case i < len(value) && value[i] == '{':
+			depth++
+		case i < len(value) && value[i] == '}' && depth > 0:
+			depth--
+		case i == len(value) || (value[i] == ',' && depth == 0):
+			if item := strings.TrimSpace(value[start:i]); item != "" {
+				items = append(items, item)
+			}
+			start = i + 1
+		}
+	}
+	return items
+}
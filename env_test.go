@@ -0,0 +1,109 @@
+package goripgrep
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEnvOptions(t *testing.T) {
+	t.Setenv("GORIPGREP_WORKERS", "8")
+	t.Setenv("GORIPGREP_TIMEOUT", "2m")
+	t.Setenv("GORIPGREP_GLOBS", "*.{go,md}, docs/**")
+	t.Setenv("GORIPGREP_HIDDEN", "true")
+	t.Setenv("GORIPGREP_NO_IGNORE", "1")
+
+	options := defaultOptions()
+	if options.envErr != nil {
+		t.Fatalf("Unexpected error: %v", options.envErr)
+	}
+	if options.workers != 8 || options.timeout != 2*time.Minute || !options.hidden || options.gitignore {
+		t.Errorf("Expected the environment to change the defaults, got %+v", options)
+	}
+	if want := []string{"*.{go,md}", "docs/**"}; !slices.Equal(options.includeGlobs, want) {
+		t.Errorf("Expected globs %q, got %q", want, options.includeGlobs)
+	}
+
+	// Options passed to a search come after the environment
+	WithWorkers(2)(options)
+	WithGitignore(true)(options)
+	if options.workers != 2 || !options.gitignore {
+		t.Errorf("Expected options to override the environment, got %+v", options)
+	}
+
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"a.go":  "needle\n",
+		"b.txt": "needle\n",
+		".c.md": "needle\n",
+	})
+	results, err := Find("needle", dir)
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	if results.Count() != 2 {
+		t.Errorf("Expected the environment's globs and hidden files to apply, got %+v", results.Matches)
+	}
+}
+
+func TestEnvBooleansOverridden(t *testing.T) {
+	t.Setenv("GORIPGREP_IGNORE_CASE", "true")
+	t.Setenv("GORIPGREP_HIDDEN", "true")
+	t.Setenv("GORIPGREP_FOLLOW", "true")
+	t.Setenv("GORIPGREP_NO_IGNORE", "true")
+
+	// Flags set to false, as the CLI passes them, undo the environment
+	options := defaultOptions()
+	for _, opt := range []Option{WithCaseSensitive(), WithHiddenFiles(false), WithFollowSymlinks(false), WithGitignore(true)} {
+		opt(options)
+	}
+	if options.ignoreCase || options.hidden || options.symlinks || !options.gitignore {
+		t.Errorf("Expected options to turn off what the environment turned on, got %+v", options)
+	}
+
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"a.txt":  "NEEDLE\nneedle\n",
+		".b.txt": "needle\n",
+	})
+	results, err := Find("needle", dir, WithCaseSensitive(), WithHiddenFiles(false))
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	if results.Count() != 1 || results.Matches[0].Content != "needle" {
+		t.Errorf("Expected only the lowercase needle in a.txt, got %+v", results.Matches)
+	}
+}
+
+func TestEnvOptionsInvalid(t *testing.T) {
+	t.Setenv("GORIPGREP_WORKERS", "0")
+	t.Setenv("GORIPGREP_MAX_RESULTS", "lots")
+
+	options := defaultOptions()
+	if options.workers != 4 {
+		t.Errorf("Expected an invalid value to leave the default, got %d", options.workers)
+	}
+	_, err := Find("needle", t.TempDir())
+	if err == nil || !strings.Contains(err.Error(), "GORIPGREP_WORKERS") {
+		t.Errorf("Expected the first invalid variable to be reported, got %v", err)
+	}
+	if _, err := ListFiles(t.TempDir()); err == nil {
+		t.Error("Expected ListFiles to report the invalid variable")
+	}
+}
+
+func TestSplitEnvList(t *testing.T) {
+	tests := map[string][]string{
+		"":                 nil,
+		"go":               {"go"},
+		" go , md ,":       {"go", "md"},
+		"*.{go,md},!*.txt": {"*.{go,md}", "!*.txt"},
+		"{a,{b,c}},d":      {"{a,{b,c}}", "d"},
+	}
+	for value, want := range tests {
+		if got := splitEnvList(value); !slices.Equal(got, want) {
+			t.Errorf("splitEnvList(%q) = %q, want %q", value, got, want)
+		}
+	}
+}
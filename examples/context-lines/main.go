@@ -33,23 +33,20 @@ func main() {
 		fmt.Printf("\n%s:%d: %s\n", match.File, match.Line, match.Content)
 
 		// Display context lines
-		contextLines := len(match.Context)
-		if contextLines > 0 {
-			beforeLines := contextLines / 2
-
+		if len(match.BeforeContext)+len(match.AfterContext) > 0 {
 			// Before context
-			for i := 0; i < beforeLines; i++ {
-				lineNum := match.Line - beforeLines + i
-				fmt.Printf("%s:%d-: %s\n", match.File, lineNum, match.Context[i])
+			for i, contextLine := range match.BeforeContext {
+				lineNum := match.Line - len(match.BeforeContext) + i
+				fmt.Printf("%s:%d-: %s\n", match.File, lineNum, contextLine)
 			}
 
 			// The match line (highlighted)
 			fmt.Printf("%s:%d:> %s\n", match.File, match.Line, match.Content)
 
 			// After context
-			for i := beforeLines + 1; i < contextLines; i++ {
-				lineNum := match.Line + i - beforeLines
-				fmt.Printf("%s:%d+: %s\n", match.File, lineNum, match.Context[i])
+			for i, contextLine := range match.AfterContext {
+				lineNum := match.Line + 1 + i
+				fmt.Printf("%s:%d+: %s\n", match.File, lineNum, contextLine)
 			}
 		}
 	}
@@ -69,7 +66,7 @@ func main() {
 		if len(results.Matches) > 0 {
 			match := results.Matches[0] // Show first match
 			fmt.Printf("     %s:%d: %s\n", match.File, match.Line, match.Content)
-			fmt.Printf("     Context lines: %d\n", len(match.Context))
+			fmt.Printf("     Context lines: %d before, %d after\n", len(match.BeforeContext), len(match.AfterContext))
 		}
 	}
 	fmt.Println()
@@ -94,26 +91,12 @@ func main() {
 		fmt.Printf("\n%s:%d:\n", match.File, match.Line)
 
 		// Show context with line numbers
-		if len(match.Context) > 0 {
-			contextBefore := len(match.Context) / 2
-
-			for j, contextLine := range match.Context {
-				var lineNum int
-				var marker string
-
-				if j < contextBefore {
-					lineNum = match.Line - contextBefore + j
-					marker = "-"
-				} else if j == contextBefore {
-					lineNum = match.Line
-					marker = ":"
-				} else {
-					lineNum = match.Line + j - contextBefore
-					marker = "+"
-				}
-
-				fmt.Printf("  %d%s %s\n", lineNum, marker, contextLine)
-			}
+		for j, contextLine := range match.BeforeContext {
+			fmt.Printf("  %d- %s\n", match.Line-len(match.BeforeContext)+j, contextLine)
+		}
+		fmt.Printf("  %d: %s\n", match.Line, match.Content)
+		for j, contextLine := range match.AfterContext {
+			fmt.Printf("  %d+ %s\n", match.Line+1+j, contextLine)
 		}
 	}
 	fmt.Println()
@@ -130,12 +113,11 @@ func main() {
 		fmt.Printf("\n%s:%d: %s\n", match.File, match.Line, match.Content)
 
 		// Show simplified context
-		for i, contextLine := range match.Context {
-			if i < len(match.Context)/2 {
-				fmt.Printf("  | %s\n", contextLine)
-			} else if i > len(match.Context)/2 {
-				fmt.Printf("  | %s\n", contextLine)
-			}
+		for _, contextLine := range match.BeforeContext {
+			fmt.Printf("  | %s\n", contextLine)
+		}
+		for _, contextLine := range match.AfterContext {
+			fmt.Printf("  | %s\n", contextLine)
 		}
 	}
 	fmt.Println()
@@ -181,18 +163,19 @@ func main() {
 		match := largeContextResults.Matches[0]
 		fmt.Printf("Match with 10 lines of context:\n")
 		fmt.Printf("%s:%d: %s\n", match.File, match.Line, match.Content)
-		fmt.Printf("Context lines provided: %d\n", len(match.Context))
+		fmt.Printf("Context lines provided: %d before, %d after\n", len(match.BeforeContext), len(match.AfterContext))
 
-		// Show first few and last few context lines
-		if len(match.Context) > 6 {
-			fmt.Println("First 3 context lines:")
-			for i := 0; i < 3; i++ {
-				fmt.Printf("  %s\n", match.Context[i])
+		// Show the nearest few context lines on each side
+		if len(match.BeforeContext) > 3 {
+			fmt.Println("Last 3 lines before the match:")
+			for _, contextLine := range match.BeforeContext[len(match.BeforeContext)-3:] {
+				fmt.Printf("  %s\n", contextLine)
 			}
-			fmt.Println("  ...")
-			fmt.Println("Last 3 context lines:")
-			for i := len(match.Context) - 3; i < len(match.Context); i++ {
-				fmt.Printf("  %s\n", match.Context[i])
+		}
+		if len(match.AfterContext) > 3 {
+			fmt.Println("First 3 lines after the match:")
+			for _, contextLine := range match.AfterContext[:3] {
+				fmt.Printf("  %s\n", contextLine)
 			}
 		}
 	}
@@ -212,8 +195,8 @@ func main() {
 	fmt.Printf("Found %d matches with advanced options:\n", advancedResults.Count())
 	for _, match := range advancedResults.Matches {
 		fmt.Printf("\n%s:%d: %s\n", match.File, match.Line, match.Content)
-		if len(match.Context) > 0 {
-			fmt.Printf("  Context: %d lines\n", len(match.Context))
+		if len(match.BeforeContext)+len(match.AfterContext) > 0 {
+			fmt.Printf("  Context: %d lines before, %d after\n", len(match.BeforeContext), len(match.AfterContext))
 		}
 	}
 }
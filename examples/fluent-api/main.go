@@ -36,7 +36,10 @@ func main() {
 			break
 		}
 		fmt.Printf("  %s:%d:%d: %s\n", match.File, match.Line, match.Column, match.Content)
-		for _, contextLine := range match.Context {
+		for _, contextLine := range match.BeforeContext {
+			fmt.Printf("    | %s\n", contextLine)
+		}
+		for _, contextLine := range match.AfterContext {
 			fmt.Printf("    | %s\n", contextLine)
 		}
 	}
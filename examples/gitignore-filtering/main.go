@@ -152,8 +152,8 @@ func main() {
 	fmt.Printf("Found %d matches with combined options\n", combinedResults.Count())
 	for _, match := range combinedResults.Matches {
 		fmt.Printf("  %s:%d: %s\n", match.File, match.Line, match.Content)
-		if len(match.Context) > 0 {
-			fmt.Printf("    Context: %v\n", match.Context)
+		if len(match.BeforeContext)+len(match.AfterContext) > 0 {
+			fmt.Printf("    Context: %v / %v\n", match.BeforeContext, match.AfterContext)
 		}
 	}
 }
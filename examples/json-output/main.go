@@ -229,7 +229,7 @@ func main() {
 			"results": map[string]interface{}{
 				"total_matches": advancedResults.Count(),
 				"files_found":   len(advancedResults.Files()),
-				"has_context":   len(advancedResults.Matches) > 0 && len(advancedResults.Matches[0].Context) > 0,
+				"has_context":   len(advancedResults.Matches) > 0 && len(advancedResults.Matches[0].BeforeContext)+len(advancedResults.Matches[0].AfterContext) > 0,
 				"matches":       advancedResults.Matches,
 			},
 			"performance": advancedResults.Stats,
@@ -57,8 +57,11 @@ func main() {
 	fmt.Printf("Found %d matches with context:\n", results.Count())
 	for _, match := range results.Matches {
 		fmt.Printf("  %s:%d: %s\n", match.File, match.Line, match.Content)
-		for _, context := range match.Context {
-			fmt.Printf("    Context: %s\n", context)
+		for _, context := range match.BeforeContext {
+			fmt.Printf("    Before: %s\n", context)
+		}
+		for _, context := range match.AfterContext {
+			fmt.Printf("    After:  %s\n", context)
 		}
 	}
 	fmt.Println()
@@ -105,12 +105,11 @@ func main() {
 	fmt.Printf("Found %d matches for '世界' with context\n", results.Count())
 	for _, match := range results.Matches {
 		fmt.Printf("  %s:%d: %s\n", match.File, match.Line, match.Content)
-		for i, contextLine := range match.Context {
-			if i == 0 && len(match.Context) > 1 {
-				fmt.Printf("    Before: %s\n", contextLine)
-			} else if i == len(match.Context)-1 && len(match.Context) > 1 {
-				fmt.Printf("    After:  %s\n", contextLine)
-			}
+		for _, contextLine := range match.BeforeContext {
+			fmt.Printf("    Before: %s\n", contextLine)
+		}
+		for _, contextLine := range match.AfterContext {
+			fmt.Printf("    After:  %s\n", contextLine)
 		}
 	}
 	fmt.Println()
@@ -0,0 +1,295 @@
+package goripgrep
+
+import (
+	"fmt"
+	"regexp/syntax"
+	"slices"
+	"strings"
+)
+
+// PatternKind is the way a search matches a pattern against lines
+type PatternKind int
+
+const (
+	PatternLiteral      PatternKind = iota // Substring search, no regex engine
+	PatternRegex                           // Go's regexp, in time linear in the line length
+	PatternMultiline                       // Go's regexp over whole files
+	PatternBacktracking                    // The backtracking engine for lookaround and backreferences
+)
+
+// String returns the name of the kind
+func (k PatternKind) String() string {
+	switch k {
+	case PatternLiteral:
+		return "literal"
+	case PatternRegex:
+		return "regex"
+	case PatternMultiline:
+		return "multiline regex"
+	case PatternBacktracking:
+		return "backtracking regex"
+	default:
+		return "unknown"
+	}
+}
+
+// MarshalText encodes the kind by name so JSON output stays readable
+func (k PatternKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// PatternCost is a rough estimate of how expensive a pattern is to search for
+type PatternCost int
+
+const (
+	CostLow    PatternCost = iota // Scans for a literal, close to the speed of reading the files
+	CostMedium                    // Runs the regex engine over every line
+	CostHigh                      // Runs the regex engine over every line with nothing to skip ahead to, or backtracks
+)
+
+// String returns the name of the cost
+func (c PatternCost) String() string {
+	switch c {
+	case CostLow:
+		return "low"
+	case CostMedium:
+		return "medium"
+	case CostHigh:
+		return "high"
+	default:
+		return "unknown"
+	}
+}
+
+// MarshalText encodes the cost by name so JSON output stays readable
+func (c PatternCost) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+// PatternReport explains how a search matches a pattern and why it may be
+// slow, from the same analysis the search itself does
+type PatternReport struct {
+	Pattern  string      // The pattern searched for, after combining several patterns
+	Kind     PatternKind // How lines are matched
+	Literal  string      // The substring searched for on the literal path, lowercase when case is folded
+	Prefix   string      // Literal text every regex match starts with, which the regex engine skips ahead to
+	Literals []string    // Strings every regex match contains one of, lowercase under case folding; empty when none are required
+	RareByte string      // The least common byte of the literal or of the only required literal, which scanning looks for first
+	Cost     PatternCost // Rough estimate of the search cost
+	Notes    []string    // Why the pattern costs what it does and how it could be rewritten
+	Hazards  []string    // Why the pattern is likely to be pathologically slow; WithRejectSlowPatterns refuses it when set
+}
+
+// ExplainPattern reports how Find would match pattern with the given
+// options: whether it takes the literal path or a regex engine, the literals
+// it requires, the rare byte scanned for and an estimated cost, with notes
+// on rewriting slow patterns
+func ExplainPattern(pattern string, opts ...Option) (*PatternReport, error) {
+	options := defaultOptions()
+	for _, opt := range opts {
+		opt(options)
+	}
+	// Slow patterns are reported as hazards rather than refused
+	options.rejectSlow = false
+	pattern, err := options.resolvePatterns(pattern)
+	if err != nil {
+		return nil, err
+	}
+	if err := options.validate(pattern); err != nil {
+		return nil, err
+	}
+
+	config := options.searchConfig("")
+	matcher, err := newLineMatcher(pattern, config)
+	if err != nil {
+		return nil, err
+	}
+
+	hazards, err := patternHazards(pattern, config)
+	if err != nil {
+		return nil, err
+	}
+	report := &PatternReport{Pattern: pattern, Hazards: hazards}
+	switch {
+	case matcher.pcre != nil:
+		report.Kind = PatternBacktracking
+		report.Cost = CostHigh
+		report.note("Lookaround and backreferences need the backtracking engine, which tries alternatives one at a time and can take exponential time on nested repetition such as (a+)+")
+		report.note("Match the surrounding text instead of looking around it and use Match.MatchStart and MatchEnd to find the part you need")
+		return report, nil
+
+	case matcher.regex == nil:
+		report.Kind = PatternLiteral
+		report.Literal = matcher.literal
+		report.Cost = CostLow
+		if matcher.foldCase {
+			report.note("Each line is lowercased before searching to ignore case")
+		}
+		if report.Literal != "" {
+			report.RareByte = string(rune(report.Literal[rarestLiteralByte(report.Literal)]))
+		}
+		return report, nil
+	}
+
+	report.Kind = PatternRegex
+	if config.Multiline {
+		report.Kind = PatternMultiline
+		report.note("Multiline mode reads whole files and matches across lines; leave it off unless matches span lines")
+	}
+
+	prefix, complete := matcher.regex.LiteralPrefix()
+	report.Prefix = prefix
+	expr := matcher.regex.String()
+	if parsed, err := syntax.Parse(expr, syntax.Perl); err == nil {
+		report.Literals = requiredLiterals(parsed.Simplify())
+		report.explainSyntax(parsed)
+	}
+	if len(report.Literals) == 1 && report.Literals[0] != "" {
+		literal := report.Literals[0]
+		report.RareByte = string(rune(literal[rarestLiteralByte(literal)]))
+	}
+
+	switch {
+	case report.Prefix != "":
+		report.Cost = CostLow
+	case len(matcher.required) > 0:
+		report.Cost = CostMedium
+		report.note(fmt.Sprintf("Lines without %s are rejected before the regex runs, but the engine cannot skip ahead to it, so every line containing it runs through the engine", quoteLiterals(matcher.required)))
+	case len(report.Literals) > 0:
+		report.Cost = CostMedium
+		report.note(fmt.Sprintf("Every match contains %s, but the regex engine cannot skip ahead to it and lines are not prefiltered on it when ignoring case, so every line runs through the engine", quoteLiterals(report.Literals)))
+	default:
+		report.Cost = CostHigh
+		report.note("No literal text is required in every match, so every line runs through the regex engine with nothing to skip ahead to; anchoring the pattern on a fixed word helps most")
+	}
+	if config.Multiline && report.Cost < CostMedium {
+		report.Cost = CostMedium
+	}
+	if len(report.Hazards) > 0 {
+		report.Cost = CostHigh
+	}
+
+	if complete && !config.Multiline {
+		report.note(fmt.Sprintf("The pattern only matches the text %q; search for it as a fixed string (-F or WithFixedStrings) to skip the regex engine", report.Prefix))
+	}
+	if config.ignoresCase(pattern) && !config.FixedStrings && isLiteralPattern(pattern) && !config.Multiline {
+		report.note("Ignoring case sends a literal pattern through the regex engine; add -F (WithFixedStrings) to search for it as a case-folded literal instead")
+	}
+	return report, nil
+}
+
+// note adds an explanation to the report
+func (r *PatternReport) note(text string) {
+	r.Notes = append(r.Notes, text)
+}
+
+// explainSyntax notes constructs in a parsed regex that slow it down
+func (r *PatternReport) explainSyntax(re *syntax.Regexp) {
+	// Line searches match the same lines with or without a leading .*
+	first := re
+	for first.Op == syntax.OpConcat && len(first.Sub) > 0 {
+		first = first.Sub[0]
+	}
+	if (first.Op == syntax.OpStar || first.Op == syntax.OpPlus) && isAnyChar(first.Sub[0]) {
+		r.note("A leading .* does not change which lines match but makes every match start at the beginning of the line and hides the literal that follows from the engine; drop it unless you need that text")
+	}
+
+	var walk func(re *syntax.Regexp)
+	walk = func(re *syntax.Regexp) {
+		if re.Op == syntax.OpRepeat && max(re.Min, re.Max) > 100 {
+			r.note(fmt.Sprintf("The counted repetition {%d,%d} is expanded into a copy of its subexpression per count, which makes a large, slow program; use a smaller count or +", re.Min, re.Max))
+		}
+		for _, sub := range re.Sub {
+			walk(sub)
+		}
+	}
+	walk(re)
+}
+
+// isAnyChar reports whether re matches any character, as . does
+func isAnyChar(re *syntax.Regexp) bool {
+	return re.Op == syntax.OpAnyChar || re.Op == syntax.OpAnyCharNotNL
+}
+
+// requiredLiterals returns strings one of which every match of re contains,
+// preferring long ones, or nil when a match may contain no literal text.
+// Literals matched without regard to case are lowercased.
+func requiredLiterals(re *syntax.Regexp) []string {
+	switch re.Op {
+	case syntax.OpLiteral:
+		literal := string(re.Rune)
+		if re.Flags&syntax.FoldCase != 0 {
+			literal = strings.ToLower(literal)
+		}
+		return []string{literal}
+	case syntax.OpCapture, syntax.OpPlus:
+		return requiredLiterals(re.Sub[0])
+	case syntax.OpRepeat:
+		if re.Min > 0 {
+			return requiredLiterals(re.Sub[0])
+		}
+	case syntax.OpConcat:
+		var best []string
+		for _, sub := range re.Sub {
+			if literals := requiredLiterals(sub); betterLiterals(literals, best) {
+				best = literals
+			}
+		}
+		return best
+	case syntax.OpAlternate:
+		var all []string
+		for _, sub := range re.Sub {
+			literals := requiredLiterals(sub)
+			if literals == nil {
+				return nil
+			}
+			for _, literal := range literals {
+				if !slices.Contains(all, literal) {
+					all = append(all, literal)
+				}
+			}
+		}
+		return all
+	}
+	return nil
+}
+
+// betterLiterals reports whether a is a more selective set of required
+// literals than b: its shortest literal is longer, or it has fewer of them
+func betterLiterals(a, b []string) bool {
+	if len(a) == 0 {
+		return false
+	}
+	if len(b) == 0 {
+		return true
+	}
+	shortest := func(literals []string) int {
+		n := len(literals[0])
+		for _, literal := range literals[1:] {
+			n = min(n, len(literal))
+		}
+		return n
+	}
+	if shortest(a) != shortest(b) {
+		return shortest(a) > shortest(b)
+	}
+	return len(a) < len(b)
+}
+
+// rarestLiteralByte returns the index of the rarest byte of a non-empty literal
+func rarestLiteralByte(literal string) int {
+	_, index := rarestByte([]byte(literal))
+	return index
+}
+
+// quoteLiterals formats required literals for a note
+func quoteLiterals(literals []string) string {
+	quoted := make([]string, len(literals))
+	for i, literal := range literals {
+		quoted[i] = fmt.Sprintf("%q", literal)
+	}
+	if len(quoted) == 1 {
+		return quoted[0]
+	}
+	return "one of " + strings.Join(quoted, ", ")
+}
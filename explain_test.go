@@ -0,0 +1,77 @@
+package goripgrep
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestExplainPattern(t *testing.T) {
+	tests := []struct {
+		name     string
+		pattern  string
+		opts     []Option
+		kind     PatternKind
+		cost     PatternCost
+		literals []string
+		rareByte string
+		note     string
+	}{
+		{name: "literal", pattern: "func", kind: PatternLiteral, cost: CostLow, rareByte: "f"},
+		{name: "folded literal", pattern: "Func", opts: []Option{WithIgnoreCase(), WithFixedStrings()}, kind: PatternLiteral, cost: CostLow, rareByte: "f", note: "lowercased"},
+		{name: "literal prefix", pattern: `func \w+`, kind: PatternRegex, cost: CostLow, literals: []string{"func "}},
+		{name: "inner literal", pattern: `\w+Error`, kind: PatternRegex, cost: CostMedium, literals: []string{"Error"}, rareByte: "E", note: `"Error"`},
+		{name: "alternation", pattern: `\s(?:foo|bar)\s`, kind: PatternRegex, cost: CostMedium, literals: []string{"foo", "bar"}, note: "one of"},
+		{name: "no literals", pattern: `\d+\s\w+`, kind: PatternRegex, cost: CostHigh, note: "No literal text"},
+		{name: "leading dot star", pattern: `.*needle`, kind: PatternRegex, cost: CostMedium, literals: []string{"needle"}, note: "leading .*"},
+		{name: "large repeat", pattern: `x{200}`, kind: PatternRegex, cost: CostLow, literals: []string{"x"}, note: "{200,200}"},
+		{name: "ignore case", pattern: "Error", opts: []Option{WithIgnoreCase()}, kind: PatternRegex, cost: CostMedium, literals: []string{"error"}, note: "WithFixedStrings"},
+		{name: "backtracking", pattern: `foo(?=bar)`, opts: []Option{WithPCRE2Syntax()}, kind: PatternBacktracking, cost: CostHigh, note: "exponential"},
+		{name: "multiline", pattern: `start\nend`, opts: []Option{WithMultiline()}, kind: PatternMultiline, cost: CostMedium, note: "Multiline"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := ExplainPattern(tt.pattern, tt.opts...)
+			if err != nil {
+				t.Fatalf("ExplainPattern failed: %v", err)
+			}
+			if report.Kind != tt.kind || report.Cost != tt.cost {
+				t.Errorf("Expected %s with %s cost, got %s with %s cost", tt.kind, tt.cost, report.Kind, report.Cost)
+			}
+			if tt.literals != nil && !slices.Equal(report.Literals, tt.literals) {
+				t.Errorf("Expected literals %q, got %q", tt.literals, report.Literals)
+			}
+			if tt.rareByte != "" && report.RareByte != tt.rareByte {
+				t.Errorf("Expected rare byte %q, got %q", tt.rareByte, report.RareByte)
+			}
+			if tt.note != "" && !slices.ContainsFunc(report.Notes, func(note string) bool { return strings.Contains(note, tt.note) }) {
+				t.Errorf("Expected a note mentioning %q, got %q", tt.note, report.Notes)
+			}
+		})
+	}
+}
+
+func TestExplainPatternOptions(t *testing.T) {
+	// Several patterns are explained as the alternation Find searches for
+	report, err := ExplainPattern("", WithPatterns([]string{"alpha", "beta"}))
+	if err != nil {
+		t.Fatalf("ExplainPattern failed: %v", err)
+	}
+	if report.Kind != PatternRegex || !slices.Equal(report.Literals, []string{"alpha", "beta"}) {
+		t.Errorf("Expected a regex requiring alpha or beta, got %+v", report)
+	}
+
+	// A regex matching only fixed text suggests a fixed string search
+	report, err = ExplainPattern(`a\.b`)
+	if err != nil {
+		t.Fatalf("ExplainPattern failed: %v", err)
+	}
+	if report.Prefix != "a.b" || !slices.ContainsFunc(report.Notes, func(note string) bool { return strings.Contains(note, "fixed string") }) {
+		t.Errorf("Expected a fixed string suggestion for a.b, got %+v", report)
+	}
+
+	if _, err := ExplainPattern("("); err == nil {
+		t.Error("Expected an error for an invalid pattern")
+	}
+}
@@ -0,0 +1,136 @@
+package goripgrep
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FileReport explains whether a search would read a file, listing the
+// filters that decided it, as ExplainFile reports it
+type FileReport struct {
+	Path     string       // Absolute path of the file
+	Searched bool         // A search would read the file
+	Binary   bool         // Searched only to report whether it matches, under BinaryReport
+	Steps    []FilterStep // The filters with a say, in the order they apply; the last one excludes the file unless it is searched
+}
+
+// FilterStep is a filter's decision on a file or a directory above it
+type FilterStep struct {
+	Path     string // The file, or the directory above it the filter applied to
+	Filter   string // The filter, named as WithLogger names the reasons for skipping, such as "gitignore" or "glob"
+	Excluded bool   // The filter left the path out; otherwise one of its rules matched and kept it
+	Rule     string // The rule that decided, such as an ignore file line or a glob, when there is one
+}
+
+// ExplainFile reports whether a search of path would read file, and which
+// rules decided: an ignore file line, the hidden file policy, a glob or file
+// type, binary detection or a depth limit, applied to the file and to each
+// directory the walk passes on the way to it. The options are those of the
+// search. Files a search finds more than once, through links, are not
+// detected as duplicates.
+func ExplainFile(path, file string, opts ...Option) (*FileReport, error) {
+	root, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("path error: %w", err)
+	}
+	if _, err := os.Stat(root); err != nil {
+		return nil, fmt.Errorf("path error: %w", err)
+	}
+	target, err := filepath.Abs(file)
+	if err != nil {
+		return nil, fmt.Errorf("path error: %w", err)
+	}
+	info, err := os.Lstat(target)
+	if err != nil {
+		return nil, fmt.Errorf("path error: %w", err)
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("path error: %s is a directory", file)
+	}
+	rel, err := filepath.Rel(root, target)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return nil, fmt.Errorf("%s is not under %s", file, path)
+	}
+
+	options := defaultOptions()
+	for _, opt := range opts {
+		opt(options)
+	}
+	if err := options.validate(""); err != nil {
+		return nil, err
+	}
+
+	report := &FileReport{Path: target}
+	engine := &SearchEngine{config: options.searchConfig(path), root: root, trace: report}
+	if err := engine.initializeEngines(); err != nil {
+		return nil, err
+	}
+
+	// The walk reaches the file through each directory above it
+	if rel != "." {
+		parts := strings.Split(rel, string(filepath.Separator))
+		dir := root
+		for depth, part := range parts[:len(parts)-1] {
+			dir = filepath.Join(dir, part)
+			if !engine.config.Recursive {
+				engine.skipDir(dir, "not recursive")
+				return report, nil
+			}
+			if engine.prunesDir(dir) {
+				return report, nil
+			}
+			if engine.config.MaxDepth > 0 && depth+1 >= engine.config.MaxDepth {
+				engine.skipDir(dir, "max depth")
+				return report, nil
+			}
+		}
+	}
+
+	class := engine.classifyFile(target, info)
+	report.Searched = class != fileSkipped
+	report.Binary = class == fileBinary
+	return report, nil
+}
+
+// traceStep records, while explaining a file, that filter decided about the
+// file or directory at path. Filters that kept it are only recorded when
+// one of their rules matched.
+func (e *SearchEngine) traceStep(path, filter string, dir, excluded bool) {
+	if e.trace == nil {
+		return
+	}
+	rule := e.filterRule(path, filter, dir)
+	if !excluded && rule == "" {
+		return
+	}
+	e.trace.Steps = append(e.trace.Steps, FilterStep{Path: path, Filter: filter, Excluded: excluded, Rule: rule})
+}
+
+// filterRule describes the rule of filter that applies to path
+func (e *SearchEngine) filterRule(path, filter string, dir bool) string {
+	switch filter {
+	case "gitignore":
+		if e.gitignoreEngine != nil {
+			if pattern := e.gitignoreEngine.DecidingPattern(path, dir); pattern != nil {
+				return pattern.String()
+			}
+		}
+	case "glob":
+		if e.globs == nil {
+			return ""
+		}
+		if rule, ok := e.globs.lastMatch(e.globs.relative(path), dir); ok {
+			return rule.glob
+		}
+		if e.globs.hasIncludes {
+			return "matches no include glob"
+		}
+	case "binary extension":
+		return filepath.Ext(path)
+	case "max depth":
+		return fmt.Sprintf("deeper than %d levels", e.config.MaxDepth)
+	}
+	return ""
+}
@@ -0,0 +1,101 @@
+package goripgrep
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestExplainFile(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		".gitignore":         "# build output\ngenerated/\n*.log\n!keep.log\n",
+		"main.go":            "package main\n",
+		"main_test.go":       "package main\n",
+		"app.log":            "log\n",
+		"keep.log":           "log\n",
+		"data.txt":           "data\x00\n",
+		".env":               "SECRET=1\n",
+		"generated/gen.go":   "package out\n",
+		"node_modules/x.js":  "x\n",
+		"a/b/c/deep.go":      "package c\n",
+		"docs/guide.md":      "# Guide\n",
+		"docs/sub/readme.md": "# Readme\n",
+	})
+	ignore := filepath.Join(dir, ".gitignore")
+
+	tests := []struct {
+		name     string
+		file     string
+		opts     []Option
+		searched bool
+		steps    []FilterStep
+	}{
+		{name: "plain", file: "main.go", searched: true},
+		{name: "ignored", file: "app.log", steps: []FilterStep{
+			{Path: "app.log", Filter: "gitignore", Excluded: true, Rule: ignore + ":3: *.log"},
+		}},
+		{name: "re-included", file: "keep.log", searched: true, steps: []FilterStep{
+			{Path: "keep.log", Filter: "gitignore", Rule: ignore + ":4: !keep.log"},
+		}},
+		{name: "ignored directory", file: "generated/gen.go", steps: []FilterStep{
+			{Path: "generated", Filter: "gitignore", Excluded: true, Rule: ignore + ":2: generated/"},
+		}},
+		{name: "hidden", file: ".env", steps: []FilterStep{
+			{Path: ".env", Filter: "hidden", Excluded: true},
+		}},
+		{name: "build directory", file: "node_modules/x.js", steps: []FilterStep{
+			{Path: "node_modules", Filter: "build directory", Excluded: true},
+		}},
+		{name: "binary", file: "data.txt", steps: []FilterStep{
+			{Path: "data.txt", Filter: "binary content", Excluded: true},
+		}},
+		{name: "binary report", file: "data.txt", opts: []Option{WithBinaryMode(BinaryReport)}, searched: true},
+		{name: "depth", file: "a/b/c/deep.go", opts: []Option{WithMaxDepth(2)}, steps: []FilterStep{
+			{Path: "a/b", Filter: "max depth", Excluded: true, Rule: "deeper than 2 levels"},
+		}},
+		{name: "negated glob", file: "main_test.go", opts: []Option{WithIncludeGlobs([]string{"*.go", "!*_test.go"})}, steps: []FilterStep{
+			{Path: "main_test.go", Filter: "glob", Excluded: true, Rule: "!*_test.go"},
+		}},
+		{name: "glob", file: "main.go", opts: []Option{WithIncludeGlobs([]string{"*.go", "!*_test.go"})}, searched: true, steps: []FilterStep{
+			{Path: "main.go", Filter: "glob", Rule: "*.go"},
+		}},
+		{name: "no include glob", file: "keep.log", opts: []Option{WithFileTypes([]string{"go"})}, steps: []FilterStep{
+			{Path: "keep.log", Filter: "gitignore", Rule: ignore + ":4: !keep.log"},
+			{Path: "keep.log", Filter: "glob", Excluded: true, Rule: "matches no include glob"},
+		}},
+		{name: "excluded directory", file: "docs/sub/readme.md", opts: []Option{WithExcludeGlobs([]string{"docs/sub"})}, steps: []FilterStep{
+			{Path: "docs/sub", Filter: "glob", Excluded: true, Rule: "docs/sub"},
+		}},
+		{name: "not recursive", file: "docs/guide.md", steps: []FilterStep{
+			{Path: "docs", Filter: "not recursive", Excluded: true},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := append([]Option{WithRecursive(tt.name != "not recursive")}, tt.opts...)
+			report, err := ExplainFile(dir, filepath.Join(dir, tt.file), opts...)
+			if err != nil {
+				t.Fatalf("ExplainFile failed: %v", err)
+			}
+			if report.Searched != tt.searched {
+				t.Errorf("Expected searched to be %v, got %+v", tt.searched, report)
+			}
+			if len(report.Steps) != len(tt.steps) {
+				t.Fatalf("Expected steps %+v, got %+v", tt.steps, report.Steps)
+			}
+			for i, want := range tt.steps {
+				want.Path = filepath.Join(dir, want.Path)
+				if report.Steps[i] != want {
+					t.Errorf("Expected step %+v, got %+v", want, report.Steps[i])
+				}
+			}
+		})
+	}
+
+	if _, err := ExplainFile(filepath.Join(dir, "docs"), filepath.Join(dir, "main.go")); err == nil {
+		t.Error("Expected an error for a file outside the path")
+	}
+	if _, err := ExplainFile(dir, filepath.Join(dir, "docs")); err == nil {
+		t.Error("Expected an error for a directory")
+	}
+}
@@ -0,0 +1,94 @@
+package goripgrep
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// resultsMagic starts every exported results file, followed by the format
+// version
+const resultsMagic = "GRGR\x01"
+
+// ErrNotResultsFile is returned when importing data that was not written by
+// SearchResults.Export
+var ErrNotResultsFile = errors.New("not a goripgrep results file")
+
+// Export writes the results to w in a compact form that ImportResults reads
+// back: the matches, counts, statistics and query as zstd compressed JSON.
+// Saved results can be reviewed and shared without searching again.
+func (r *SearchResults) Export(w io.Writer) error {
+	if _, err := io.WriteString(w, resultsMagic); err != nil {
+		return fmt.Errorf("failed to export results: %w", err)
+	}
+	encoder, err := zstd.NewWriter(w, zstd.WithEncoderLevel(zstd.SpeedBetterCompression), zstd.WithEncoderConcurrency(1))
+	if err != nil {
+		return fmt.Errorf("failed to create zstd writer: %w", err)
+	}
+	if err := json.NewEncoder(encoder).Encode(r); err != nil {
+		encoder.Close()
+		return fmt.Errorf("failed to export results: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return fmt.Errorf("failed to export results: %w", err)
+	}
+	return nil
+}
+
+// ExportFile writes the results to a file at path, replacing it
+func (r *SearchResults) ExportFile(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	writer := bufio.NewWriter(file)
+	if err := r.Export(writer); err != nil {
+		file.Close()
+		return err
+	}
+	if err := writer.Flush(); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return file.Close()
+}
+
+// ImportResults reads results written by Export
+func ImportResults(r io.Reader) (*SearchResults, error) {
+	magic := make([]byte, len(resultsMagic))
+	if _, err := io.ReadFull(r, magic); err != nil || !bytes.Equal(magic, []byte(resultsMagic)) {
+		return nil, ErrNotResultsFile
+	}
+	decoder, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create zstd reader: %w", err)
+	}
+	defer decoder.Close()
+
+	var results SearchResults
+	if err := json.NewDecoder(decoder).Decode(&results); err != nil {
+		return nil, fmt.Errorf("failed to import results: %w", err)
+	}
+	return &results, nil
+}
+
+// ImportResultsFile reads results exported to the file at path
+func ImportResultsFile(path string) (*SearchResults, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	results, err := ImportResults(bufio.NewReader(file))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return results, nil
+}
@@ -0,0 +1,60 @@
+package goripgrep
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestExportResults(t *testing.T) {
+	dir := t.TempDir()
+	lines := strings.Repeat("filler line\n", 200)
+	writeTestFiles(t, dir, map[string]string{
+		"a.txt":      lines + "needle one\n" + lines + "needle two\n",
+		"docs/b.md":  "# Setup\nneedle three\n",
+		"config.yml": "spec:\n  image: needle\n",
+	})
+
+	results, err := Find("needle", dir, WithRecursive(true), WithContextLines(2), WithSections(), WithKeyPaths())
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	if results.Count() != 4 {
+		t.Fatalf("Expected 4 matches, got %d", results.Count())
+	}
+
+	path := filepath.Join(t.TempDir(), "results.grg")
+	if err := results.ExportFile(path); err != nil {
+		t.Fatalf("ExportFile failed: %v", err)
+	}
+	loaded, err := ImportResultsFile(path)
+	if err != nil {
+		t.Fatalf("ImportResultsFile failed: %v", err)
+	}
+	if loaded.Query != results.Query || !reflect.DeepEqual(loaded.Matches, results.Matches) {
+		t.Errorf("Imported matches differ:\nwant %+v\ngot  %+v", results.Matches, loaded.Matches)
+	}
+	if loaded.Stats.MatchesFound != results.Stats.MatchesFound || loaded.Stats.Duration != results.Stats.Duration {
+		t.Errorf("Imported stats differ: want %+v, got %+v", results.Stats, loaded.Stats)
+	}
+
+	// Repeated lines and field names compress well
+	var buf bytes.Buffer
+	if err := (&SearchResults{Matches: slices.Repeat(results.Matches, 100)}).Export(&buf); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if buf.Len() > 4096 {
+		t.Errorf("Expected a compact export, got %d bytes for 400 matches", buf.Len())
+	}
+
+	if _, err := ImportResults(strings.NewReader("needle one\n")); !errors.Is(err, ErrNotResultsFile) {
+		t.Errorf("Expected ErrNotResultsFile, got %v", err)
+	}
+	if _, err := ImportResults(strings.NewReader(resultsMagic + "garbage")); err == nil {
+		t.Error("Expected an error importing corrupt data")
+	}
+}
@@ -0,0 +1,73 @@
+//go:build linux || darwin
+
+package goripgrep
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// writeFIFO creates a named pipe at path and writes content to it once a
+// reader opens it
+func writeFIFO(t *testing.T, path, content string) {
+	t.Helper()
+	if err := unix.Mkfifo(path, 0644); err != nil {
+		t.Skipf("Named pipes not supported here: %v", err)
+	}
+
+	go func() {
+		file, err := os.OpenFile(path, os.O_WRONLY, 0)
+		if err != nil {
+			return
+		}
+		defer file.Close()
+		file.WriteString(content)
+	}()
+}
+
+func TestFindFIFO(t *testing.T) {
+	tempDir := t.TempDir()
+	pipe := filepath.Join(tempDir, "pipe")
+	writeFIFO(t, pipe, "one\nfind me\nthree\nfind me too\n")
+
+	// Content must not be lost to the binary sniffing done for regular files
+	results, err := Find("find", pipe, WithContextLines(1))
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	if results.Count() != 2 {
+		t.Fatalf("Expected 2 matches from the pipe, got %d", results.Count())
+	}
+	first := results.Matches[0]
+	if first.File != pipe || first.Line != 2 || first.Content != "find me" || len(first.BeforeContext) != 1 {
+		t.Errorf("Unexpected first match %+v", first)
+	}
+	if results.Stats.FilesScanned != 1 || results.Stats.BytesScanned != 30 {
+		t.Errorf("Expected 1 file and 30 bytes scanned, got %d and %d", results.Stats.FilesScanned, results.Stats.BytesScanned)
+	}
+
+	t.Run("WalkSkipsPipes", func(t *testing.T) {
+		dir := t.TempDir()
+		if err := unix.Mkfifo(filepath.Join(dir, "idle"), 0644); err != nil {
+			t.Skipf("Named pipes not supported here: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "file.txt"), []byte("find me\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		// Nothing ever writes to the pipe, so opening it would block
+		for _, recursive := range []bool{false, true} {
+			results, err := Find("find", dir, WithRecursive(recursive), WithTimeout(5*time.Second))
+			if err != nil {
+				t.Fatalf("Find failed: %v", err)
+			}
+			if results.Count() != 1 || results.Stats.StoppedEarly {
+				t.Errorf("recursive=%v: expected only the regular file to match, got %d matches", recursive, results.Count())
+			}
+		}
+	})
+}
@@ -0,0 +1,111 @@
+package goripgrep
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"slices"
+	"sync"
+)
+
+// FileError is a file or directory that could not be searched, such as one
+// that could not be opened or read. Searches carry on past them and report
+// them in SearchResults.Errors, unless WithStrictErrors makes the first one
+// abort the search.
+type FileError struct {
+	Path string
+	Op   string // What failed, such as "open", "read" or "readdirent"
+	Err  error
+}
+
+// Error formats the error as fs.PathError does
+func (e *FileError) Error() string {
+	return e.Op + " " + e.Path + ": " + e.Err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e *FileError) Unwrap() error {
+	return e.Err
+}
+
+// fileErrorJSON is how a FileError is encoded, with its error as text
+type fileErrorJSON struct {
+	Path  string `json:"path"`
+	Op    string `json:"op"`
+	Error string `json:"error"`
+}
+
+// MarshalJSON encodes the error as text, so exported results keep it
+func (e FileError) MarshalJSON() ([]byte, error) {
+	return json.Marshal(fileErrorJSON{Path: e.Path, Op: e.Op, Error: e.Err.Error()})
+}
+
+// UnmarshalJSON decodes an error encoded by MarshalJSON
+func (e *FileError) UnmarshalJSON(data []byte) error {
+	var decoded fileErrorJSON
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	*e = FileError{Path: decoded.Path, Op: decoded.Op, Err: errors.New(decoded.Error)}
+	return nil
+}
+
+// newFileError describes err, met searching path. The operation and cause
+// of an fs.PathError are kept, while other errors happened searching.
+func newFileError(path string, err error) FileError {
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		return FileError{Path: path, Op: pathErr.Op, Err: pathErr.Err}
+	}
+	return FileError{Path: path, Op: "search", Err: err}
+}
+
+// fileErrors collects the FileErrors of a search from the walker and workers
+type fileErrors struct {
+	mu   sync.Mutex
+	errs []FileError
+}
+
+// reset forgets the errors of an earlier search
+func (f *fileErrors) reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.errs = nil
+}
+
+// add records an error
+func (f *fileErrors) add(err FileError) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.errs = append(f.errs, err)
+}
+
+// sorted returns the errors ordered by path, whatever order they were met in
+func (f *fileErrors) sorted() []FileError {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	errs := slices.Clone(f.errs)
+	slices.SortStableFunc(errs, func(a, b FileError) int {
+		return cmp.Compare(a.Path, b.Path)
+	})
+	return errs
+}
+
+// fileError records that the file or directory at path could not be
+// searched, aborting the search with StrictErrors. A search being stopped
+// is not an error of the file.
+func (e *SearchEngine) fileError(path string, err error) {
+	if e.owner != nil {
+		e.owner.fileError(path, err)
+		return
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return
+	}
+	e.fileErrs.add(newFileError(path, err))
+	if e.config.StrictErrors && e.abort != nil {
+		e.abort()
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !linux && !darwin
+
+package goripgrep
+
+import "os"
+
+// fileIdentity reports no identity on platforms without inode numbers, so
+// every path is searched
+func fileIdentity(info os.FileInfo) (fileID, bool) {
+	return fileID{}, false
+}
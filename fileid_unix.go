@@ -0,0 +1,18 @@
+//go:build linux || darwin
+
+package goripgrep
+
+import (
+	"os"
+	"syscall"
+)
+
+// fileIdentity returns the device and inode of the file described by info,
+// which are the same whichever hard link reached it
+func fileIdentity(info os.FileInfo) (fileID, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return fileID{}, false
+	}
+	return fileID{dev: uint64(stat.Dev), ino: uint64(stat.Ino)}, true
+}
@@ -0,0 +1,69 @@
+package goripgrep
+
+import (
+	"fmt"
+	"sort"
+)
+
+// fileTypes maps file type names to the globs matching their file names
+var fileTypes = map[string][]string{
+	"c":        {"*.c", "*.h"},
+	"cpp":      {"*.cc", "*.cpp", "*.cxx", "*.c++", "*.hh", "*.hpp", "*.hxx", "*.h++", "*.inl"},
+	"csharp":   {"*.cs", "*.csx"},
+	"css":      {"*.css", "*.scss", "*.sass", "*.less"},
+	"csv":      {"*.csv", "*.tsv"},
+	"docker":   {"Dockerfile", "Dockerfile.*", "*.dockerfile", "Containerfile"},
+	"go":       {"*.go"},
+	"html":     {"*.htm", "*.html", "*.xhtml"},
+	"java":     {"*.java", "*.jsp"},
+	"js":       {"*.js", "*.jsx", "*.mjs", "*.cjs", "*.vue"},
+	"json":     {"*.json", "*.jsonl", "*.geojson"},
+	"kotlin":   {"*.kt", "*.kts"},
+	"log":      {"*.log"},
+	"lua":      {"*.lua"},
+	"make":     {"Makefile", "makefile", "GNUmakefile", "*.mk", "*.mak"},
+	"markdown": {"*.md", "*.markdown", "*.mdx"},
+	"md":       {"*.md", "*.markdown", "*.mdx"},
+	"php":      {"*.php", "*.phtml"},
+	"proto":    {"*.proto"},
+	"py":       {"*.py", "*.pyi", "*.pyw"},
+	"ruby":     {"*.rb", "*.rake", "*.gemspec", "Gemfile", "Rakefile"},
+	"rust":     {"*.rs"},
+	"sh":       {"*.sh", "*.bash", "*.zsh", "*.ksh", ".bashrc", ".zshrc", ".profile"},
+	"sql":      {"*.sql", "*.psql"},
+	"swift":    {"*.swift"},
+	"toml":     {"*.toml", "Cargo.lock"},
+	"ts":       {"*.ts", "*.tsx", "*.mts", "*.cts"},
+	"txt":      {"*.txt"},
+	"web":      {"*.htm", "*.html", "*.xhtml", "*.css", "*.scss", "*.sass", "*.less", "*.js", "*.jsx", "*.mjs", "*.cjs", "*.ts", "*.tsx", "*.vue", "*.svelte"},
+	"xml":      {"*.xml", "*.xsd", "*.xsl", "*.xslt", "*.svg"},
+	"yaml":     {"*.yaml", "*.yml"},
+}
+
+// FileType is a named set of globs selecting files of one kind
+type FileType struct {
+	Name  string
+	Globs []string
+}
+
+// FileTypes returns the known file types, sorted by name
+func FileTypes() []FileType {
+	types := make([]FileType, 0, len(fileTypes))
+	for name, globs := range fileTypes {
+		types = append(types, FileType{Name: name, Globs: append([]string(nil), globs...)})
+	}
+
+	sort.Slice(types, func(i, j int) bool {
+		return types[i].Name < types[j].Name
+	})
+	return types
+}
+
+// fileTypeGlobs returns the globs of the named file type
+func fileTypeGlobs(name string) ([]string, error) {
+	globs, ok := fileTypes[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown file type %q", name)
+	}
+	return globs, nil
+}
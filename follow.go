@@ -0,0 +1,175 @@
+package goripgrep
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// errFollowQuit stops every followed file once QuitAfter matches are reported
+var errFollowQuit = errors.New("follow quit-after limit reached")
+
+// searchableFiles returns the files under the search path that pass the
+// configured filters, the files a search would read
+func (e *SearchEngine) searchableFiles(ctx context.Context) ([]string, error) {
+	if err := e.initializeEngines(); err != nil {
+		return nil, err
+	}
+
+	filesChan := make(chan walkedFile, e.config.MaxWorkers*2)
+	go e.walkFiles(ctx, filesChan)
+
+	var files []string
+	for file := range filesChan {
+		files = append(files, file.path)
+	}
+	return files, ctx.Err()
+}
+
+// followFiles searches each file from the start and keeps following it as it
+// grows, reporting matches from all files to fn one at a time. It returns nil
+// once ctx is done or QuitAfter matches have been reported.
+func followFiles(ctx context.Context, pattern string, config SearchConfig, files []string, fn func(Match) error) error {
+	if len(files) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	quitAfter := config.QuitAfter
+	config.QuitAfter = 0
+	config.CountOnly = false
+
+	var mu sync.Mutex
+	total := 0
+	report := func(match Match) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		// Files still being read when the search stops report nothing more
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if quitAfter > 0 && total >= quitAfter {
+			return errFollowQuit
+		}
+		if err := fn(match); err != nil {
+			return err
+		}
+		total++
+		if quitAfter > 0 && total >= quitAfter {
+			return errFollowQuit
+		}
+		return nil
+	}
+
+	errs := make(chan error, len(files))
+	for _, file := range files {
+		go func(file string) {
+			errs <- followFile(ctx, pattern, config, file, report)
+		}(file)
+	}
+
+	// The first file to stop, for whatever reason, stops all of them
+	var result error
+	for range files {
+		err := <-errs
+		stopped := err == nil || errors.Is(err, errFollowQuit) || (ctx.Err() != nil && errors.Is(err, ctx.Err()))
+		if !stopped && result == nil {
+			result = err
+		}
+		cancel()
+	}
+
+	return result
+}
+
+// followFile searches path from the start and keeps following it, starting
+// over whenever the file is rotated or truncated
+func followFile(ctx context.Context, pattern string, config SearchConfig, path string, report func(Match) error) error {
+	for {
+		file, err := os.Open(path)
+		if err != nil {
+			// A rotated file may not have been recreated yet
+			if !os.IsNotExist(err) {
+				return err
+			}
+			if err := waitFollowInterval(ctx, config.FollowInterval); err != nil {
+				return err
+			}
+			continue
+		}
+
+		reader := &followReader{ctx: ctx, path: path, file: file, interval: config.FollowInterval}
+		_, err = NewSearchEngine(config).SearchReader(ctx, pattern, path, reader, report)
+		file.Close()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// followReader reads a growing file, waiting at the end of the file for more
+// data. It reports io.EOF once the file has been replaced at its path or
+// truncated, after everything written to the old file has been read.
+type followReader struct {
+	ctx      context.Context
+	path     string
+	file     *os.File
+	offset   int64
+	interval time.Duration
+}
+
+// Read implements io.Reader
+func (f *followReader) Read(p []byte) (int, error) {
+	for {
+		n, err := f.file.Read(p)
+		f.offset += int64(n)
+		if n > 0 {
+			return n, nil
+		}
+		if err != nil && err != io.EOF {
+			return 0, err
+		}
+
+		if err := waitFollowInterval(f.ctx, f.interval); err != nil {
+			return 0, err
+		}
+		if f.replaced() {
+			return 0, io.EOF
+		}
+	}
+}
+
+// replaced reports whether the path now names another file, or the file has
+// been truncated below what was already read
+func (f *followReader) replaced() bool {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		// Keep reading the old file until a new one appears
+		return false
+	}
+
+	current, err := f.file.Stat()
+	if err != nil {
+		return true
+	}
+	return !os.SameFile(info, current) || current.Size() < f.offset
+}
+
+// waitFollowInterval sleeps between polls of a followed file
+func waitFollowInterval(ctx context.Context, interval time.Duration) error {
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,103 @@
+package goripgrep
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFollow(t *testing.T) {
+	tempDir := t.TempDir()
+	logFile := filepath.Join(tempDir, "app.log")
+	if err := os.WriteFile(logFile, []byte("ERROR one\nINFO ok\n"), 0644); err != nil {
+		t.Fatalf("Failed to create log file: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	matches := make(chan Match, 10)
+	done := make(chan error, 1)
+	go func() {
+		done <- Follow("ERROR", []string{tempDir}, func(match Match) error {
+			matches <- match
+			return nil
+		}, WithContext(ctx), WithFollowInterval(10*time.Millisecond))
+	}()
+
+	expect := func(expected string) {
+		t.Helper()
+		select {
+		case match := <-matches:
+			if got := fmt.Sprintf("%d:%s", match.Line, match.Content); got != expected {
+				t.Errorf("Expected %q, got %q", expected, got)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Timed out waiting for %q", expected)
+		}
+	}
+	appendLog := func(path, content string) {
+		t.Helper()
+		file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			t.Fatalf("Failed to open log file: %v", err)
+		}
+		defer file.Close()
+		if _, err := file.WriteString(content); err != nil {
+			t.Fatalf("Failed to append to log file: %v", err)
+		}
+	}
+
+	// Existing matches come first, then lines appended later
+	expect("1:ERROR one")
+	appendLog(logFile, "ERROR two\n")
+	expect("3:ERROR two")
+
+	// A partial line is only matched once it is complete
+	appendLog(logFile, "partial ERR")
+	time.Sleep(50 * time.Millisecond)
+	appendLog(logFile, "OR three\n")
+	expect("4:partial ERROR three")
+
+	// A rotated file is searched from the start
+	if err := os.Rename(logFile, logFile+".1"); err != nil {
+		t.Fatalf("Failed to rotate log file: %v", err)
+	}
+	appendLog(logFile, "ERROR rotated\n")
+	expect("1:ERROR rotated")
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Expected Follow to stop cleanly, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Follow did not stop after cancellation")
+	}
+}
+
+func TestFollowQuitAfter(t *testing.T) {
+	tempDir := t.TempDir()
+	for i := 0; i < 3; i++ {
+		path := filepath.Join(tempDir, fmt.Sprintf("%d.log", i))
+		if err := os.WriteFile(path, []byte("ERROR a\nERROR b\n"), 0644); err != nil {
+			t.Fatalf("Failed to create log file: %v", err)
+		}
+	}
+
+	count := 0
+	err := Follow("ERROR", []string{tempDir}, func(Match) error {
+		count++
+		return nil
+	}, WithQuitAfter(4), WithFollowInterval(10*time.Millisecond), WithTimeout(5*time.Second))
+	if err != nil {
+		t.Fatalf("Follow failed: %v", err)
+	}
+	if count != 4 {
+		t.Errorf("Expected Follow to stop after 4 matches, got %d", count)
+	}
+}
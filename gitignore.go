@@ -6,13 +6,31 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
+	"sync"
+	"time"
 )
 
-// GitignoreEngine provides gitignore pattern matching functionality
+// defaultIgnoreFiles are the per-directory ignore files always read, in
+// increasing order of precedence
+var defaultIgnoreFiles = []string{".gitignore", ".ignore", ".rgignore"}
+
+// GitignoreEngine provides gitignore pattern matching functionality. Rules
+// come from core.excludesFile, .git/info/exclude and the ignore files of
+// every directory from the repository root down, each anchored at its own
+// directory. Nested ignore files are loaded as the walk reaches them. A
+// GitignoreEngine is safe for concurrent use.
 type GitignoreEngine struct {
-	patterns []GitignorePattern
-	basePath string
+	patterns    []GitignorePattern
+	basePath    string
+	root        string   // Absolute directory pattern bases are relative to
+	ignoreFiles []string // Ignore file names read in each directory
+
+	mu          sync.Mutex
+	loadedDirs  map[string]bool      // Directories, relative to root, whose .gitignore was read
+	ignoredDirs map[string]bool      // Cached decisions for directories
+	sources     map[string]time.Time // Ignore files read or looked for, with their modification times; zero when missing
 }
 
 // GitignorePattern represents a single gitignore rule
@@ -21,54 +39,215 @@ type GitignorePattern struct {
 	Regex       *regexp.Regexp
 	Negation    bool
 	Directory   bool
-	Absolute    bool
+	Absolute    bool // Anchored at Base instead of matching at any depth
 	MatchPrefix bool
+	Base        string // Directory of the rule's file relative to the root, "" for the root
+	Source      string // The ignore file the rule was read from, empty when added with AddPattern
+	Line        int    // Line number of the rule in Source
 }
 
-// NewGitignoreEngine creates a new gitignore engine
-func NewGitignoreEngine(basePath string) *GitignoreEngine {
+// String describes the rule as its ignore file, line number and pattern
+func (p GitignorePattern) String() string {
+	if p.Source == "" {
+		return p.Pattern
+	}
+	return fmt.Sprintf("%s:%d: %s", p.Source, p.Line, p.Pattern)
+}
+
+// NewGitignoreEngine creates a new gitignore engine. Besides .gitignore, the
+// .ignore and .rgignore files of each directory are read, followed by any
+// extra ignore file names; later files take precedence. Outside of a git
+// repository, ignore files still apply from basePath down.
+func NewGitignoreEngine(basePath string, ignoreFiles ...string) *GitignoreEngine {
+	absPath, err := filepath.Abs(basePath)
+	if err != nil {
+		absPath = basePath
+	}
+
+	// Rules are collected from directories, so start from a file's parent
+	startDir := absPath
+	if info, err := os.Stat(absPath); err == nil && !info.IsDir() {
+		startDir = filepath.Dir(absPath)
+	}
+
 	engine := &GitignoreEngine{
-		basePath: basePath,
+		basePath:    basePath,
+		root:        startDir,
+		ignoreFiles: append([]string(nil), defaultIgnoreFiles...),
+		loadedDirs:  make(map[string]bool),
+		ignoredDirs: make(map[string]bool),
+		sources:     make(map[string]time.Time),
+	}
+	for _, name := range ignoreFiles {
+		if name != "" && !slices.Contains(engine.ignoreFiles, name) {
+			engine.ignoreFiles = append(engine.ignoreFiles, name)
+		}
+	}
+
+	// Repository-wide excludes have the lowest precedence
+	if repoRoot, gitDir := findGitRepository(startDir); repoRoot != "" {
+		engine.root = repoRoot
+		if excludesFile := globalExcludesFile(gitDir); excludesFile != "" {
+			engine.loadGitignoreFile(excludesFile, "")
+		}
+		engine.loadGitignoreFile(filepath.Join(gitDir, "info", "exclude"), "")
 	}
 
-	// Load .gitignore files
-	engine.loadGitignoreFiles()
+	// Load the ignore files above and at the search path
+	engine.mu.Lock()
+	engine.loadDirChain(engine.relative(startDir))
+	engine.mu.Unlock()
 
 	return engine
 }
 
-// loadGitignoreFiles loads all .gitignore files in the directory tree
-func (g *GitignoreEngine) loadGitignoreFiles() {
-	err := filepath.Walk(g.basePath, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return nil // Continue on errors
+// findGitRepository returns the root of the repository containing dir and its
+// git directory, or empty strings outside of a repository
+func findGitRepository(dir string) (string, string) {
+	for {
+		gitPath := filepath.Join(dir, ".git")
+		if info, err := os.Stat(gitPath); err == nil {
+			if info.IsDir() {
+				return dir, gitPath
+			}
+
+			// Worktrees and submodules point at their git directory
+			if data, err := os.ReadFile(gitPath); err == nil {
+				if gitDir, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir:"); ok {
+					gitDir = strings.TrimSpace(gitDir)
+					if !filepath.IsAbs(gitDir) {
+						gitDir = filepath.Join(dir, gitDir)
+					}
+					return dir, gitDir
+				}
+			}
 		}
 
-		if info.IsDir() {
-			return nil
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", ""
 		}
+		dir = parent
+	}
+}
+
+// globalExcludesFile returns git's core.excludesFile, read from the global
+// config and then the repository config, or git's default location
+func globalExcludesFile(gitDir string) string {
+	home, _ := os.UserHomeDir()
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" && home != "" {
+		configHome = filepath.Join(home, ".config")
+	}
 
-		if info.Name() == ".gitignore" {
-			g.loadGitignoreFile(path)
+	var excludesFile string
+	var configFiles []string
+	if configHome != "" {
+		configFiles = append(configFiles, filepath.Join(configHome, "git", "config"))
+	}
+	if home != "" {
+		configFiles = append(configFiles, filepath.Join(home, ".gitconfig"))
+	}
+	configFiles = append(configFiles, filepath.Join(gitDir, "config"))
+
+	for _, configFile := range configFiles {
+		if value := gitConfigValue(configFile, "core", "excludesfile"); value != "" {
+			excludesFile = value
 		}
+	}
 
-		return nil
-	})
+	if excludesFile == "" {
+		if configHome == "" {
+			return ""
+		}
+		return filepath.Join(configHome, "git", "ignore")
+	}
+	if rest, ok := strings.CutPrefix(excludesFile, "~/"); ok && home != "" {
+		excludesFile = filepath.Join(home, rest)
+	}
+	return excludesFile
+}
 
-	// Silently continue on errors - no action needed
-	_ = err
+// gitConfigValue returns the last value of key in section of a git config
+// file. Section and key names are case-insensitive; subsections are skipped.
+func gitConfigValue(configFile, section, key string) string {
+	file, err := os.Open(configFile)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	var value string
+	inSection := false
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			name := strings.TrimSpace(strings.Trim(line, "[]"))
+			inSection = strings.EqualFold(name, section)
+			continue
+		}
+
+		name, val, found := strings.Cut(line, "=")
+		if inSection && found && strings.EqualFold(strings.TrimSpace(name), key) {
+			value = strings.Trim(strings.TrimSpace(val), `"`)
+		}
+	}
+
+	return value
+}
+
+// loadDirChain loads the ignore files of every directory from the root down
+// to dir, which is relative to the root. Callers hold g.mu.
+func (g *GitignoreEngine) loadDirChain(dir string) {
+	if dir == ".." || strings.HasPrefix(dir, "../") {
+		return
+	}
+
+	// Parents load first so that deeper rules take precedence
+	dirs := []string{""}
+	if dir != "" && dir != "." {
+		parts := strings.Split(dir, "/")
+		for i := range parts {
+			dirs = append(dirs, strings.Join(parts[:i+1], "/"))
+		}
+	}
+
+	for _, current := range dirs {
+		if g.loadedDirs[current] {
+			continue
+		}
+		g.loadedDirs[current] = true
+		for _, name := range g.ignoreFiles {
+			g.loadGitignoreFile(filepath.Join(g.root, filepath.FromSlash(current), name), current)
+		}
+	}
 }
 
-// loadGitignoreFile loads patterns from a specific .gitignore file
-func (g *GitignoreEngine) loadGitignoreFile(filePath string) {
+// loadGitignoreFile loads patterns from a specific ignore file whose
+// patterns apply under base
+func (g *GitignoreEngine) loadGitignoreFile(filePath, base string) {
 	file, err := os.Open(filePath)
 	if err != nil {
+		// Unreadable files keep their time so they do not look changed
+		var modTime time.Time
+		if info, err := os.Stat(filePath); err == nil {
+			modTime = info.ModTime()
+		}
+		g.sources[filePath] = modTime
 		return
 	}
 	defer file.Close()
+	if info, err := file.Stat(); err == nil {
+		g.sources[filePath] = info.ModTime()
+	}
 
 	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
 		line := strings.TrimSpace(scanner.Text())
 
 		// Skip empty lines and comments
@@ -78,6 +257,9 @@ func (g *GitignoreEngine) loadGitignoreFile(filePath string) {
 
 		pattern := g.parseGitignorePattern(line, filePath)
 		if pattern != nil {
+			pattern.Base = base
+			pattern.Source = filePath
+			pattern.Line = lineNumber
 			g.patterns = append(g.patterns, *pattern)
 		}
 	}
@@ -101,16 +283,19 @@ func (g *GitignoreEngine) parseGitignorePattern(line, gitignoreFile string) *Git
 		line = line[:len(line)-1]
 	}
 
-	// Handle absolute patterns (leading /)
-	if strings.HasPrefix(line, "/") {
+	// A slash at the start or in the middle anchors the pattern at its
+	// .gitignore; otherwise it matches a name at any depth
+	if strings.Contains(line, "/") {
 		pattern.Absolute = true
-		line = line[1:]
+		line = strings.TrimPrefix(line, "/")
 	}
 
 	// Convert gitignore pattern to regex
-	regexPattern := g.gitignoreToRegex(line)
+	regexPattern, err := g.gitignoreToRegex(line)
+	if err != nil {
+		return nil
+	}
 
-	var err error
 	pattern.Regex, err = regexp.Compile(regexPattern)
 	if err != nil {
 		return nil
@@ -119,98 +304,173 @@ func (g *GitignoreEngine) parseGitignorePattern(line, gitignoreFile string) *Git
 	return pattern
 }
 
-// gitignoreToRegex converts a gitignore pattern to a regular expression
-func (g *GitignoreEngine) gitignoreToRegex(pattern string) string {
-	// Escape regex special characters except * and ?
-	escaped := regexp.QuoteMeta(pattern)
-
-	// Replace escaped wildcards with regex equivalents
-	escaped = strings.ReplaceAll(escaped, "\\*\\*", "__DOUBLESTAR__")
-	escaped = strings.ReplaceAll(escaped, "\\*", "[^/]*")
-	escaped = strings.ReplaceAll(escaped, "__DOUBLESTAR__", ".*")
-	escaped = strings.ReplaceAll(escaped, "\\?", "[^/]")
+// gitignoreToRegex converts a gitignore pattern to an anchored regular
+// expression. Gitignore has no brace alternatives, so braces are literal.
+func (g *GitignoreEngine) gitignoreToRegex(pattern string) (string, error) {
+	pattern = strings.NewReplacer("{", `\{`, "}", `\}`).Replace(pattern)
+	return globToRegexp(pattern)
+}
 
-	// Handle character classes [abc]
-	escaped = strings.ReplaceAll(escaped, "\\[", "[")
-	escaped = strings.ReplaceAll(escaped, "\\]", "]")
+// relative returns path relative to the root with forward slashes
+func (g *GitignoreEngine) relative(path string) string {
+	if absPath, err := filepath.Abs(path); err == nil {
+		path = absPath
+	}
 
-	// Add anchors
-	if strings.Contains(pattern, "/") {
-		// Pattern contains slash, match from beginning
-		escaped = "^" + escaped + "$"
-	} else {
-		// Pattern doesn't contain slash, can match anywhere in path
-		escaped = "(^|/)" + escaped + "($|/)"
+	relPath, err := filepath.Rel(g.root, path)
+	if err != nil {
+		relPath = path
 	}
+	return filepath.ToSlash(relPath)
+}
+
+// changed reports whether any ignore file the engine read or looked for has
+// since been created, modified or removed, making its rules stale
+func (g *GitignoreEngine) changed() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
 
-	return escaped
+	for path, modTime := range g.sources {
+		info, err := os.Stat(path)
+		if err != nil {
+			if !modTime.IsZero() {
+				return true
+			}
+			continue
+		}
+		if !info.ModTime().Equal(modTime) {
+			return true
+		}
+	}
+	return false
 }
 
 // ShouldIgnore checks if a file should be ignored based on gitignore patterns
 func (g *GitignoreEngine) ShouldIgnore(filePath string) bool {
-	// Convert to relative path from base
-	relPath, err := filepath.Rel(g.basePath, filePath)
-	if err != nil {
-		relPath = filePath
+	return g.shouldIgnore(filePath, false)
+}
+
+// ShouldIgnoreDir checks if a directory should be ignored, in which case
+// nothing below it is searched
+func (g *GitignoreEngine) ShouldIgnoreDir(dirPath string) bool {
+	return g.shouldIgnore(dirPath, true)
+}
+
+// shouldIgnore checks path and, as git does, every directory above it; a file
+// in an ignored directory cannot be re-included
+func (g *GitignoreEngine) shouldIgnore(path string, isDir bool) bool {
+	relPath := g.relative(path)
+	if relPath == "." || relPath == ".." || strings.HasPrefix(relPath, "../") {
+		return false
 	}
 
-	// Normalize path separators
-	relPath = filepath.ToSlash(relPath)
+	g.mu.Lock()
+	defer g.mu.Unlock()
 
-	ignored := false
+	parent := ""
+	if idx := strings.LastIndex(relPath, "/"); idx != -1 {
+		parent = relPath[:idx]
+	}
+	g.loadDirChain(parent)
 
-	// Apply patterns in order
-	for _, pattern := range g.patterns {
-		if g.matchesPattern(relPath, pattern) {
-			if pattern.Negation {
-				ignored = false
-			} else {
-				ignored = true
-			}
+	// Check the ancestors from the top down, caching their decisions
+	dir := ""
+	for _, part := range strings.Split(parent, "/") {
+		if part == "" {
+			break
+		}
+		dir = strings.TrimPrefix(dir+"/"+part, "/")
+		ignored, ok := g.ignoredDirs[dir]
+		if !ok {
+			ignored = g.isIgnored(dir, true)
+			g.ignoredDirs[dir] = ignored
+		}
+		if ignored {
+			return true
 		}
 	}
 
-	return ignored
+	return g.isIgnored(relPath, isDir)
 }
 
-// matchesPattern checks if a path matches a gitignore pattern
-func (g *GitignoreEngine) matchesPattern(path string, pattern GitignorePattern) bool {
-	// Handle directory-only patterns
-	if pattern.Directory {
-		// Check if path is a directory or if any parent directory matches
-		if !strings.HasSuffix(path, "/") {
-			// For files, check if any parent directory matches
-			parts := strings.Split(path, "/")
-			for i := 0; i < len(parts)-1; i++ {
-				dirPath := strings.Join(parts[:i+1], "/") + "/"
-				if pattern.Regex.MatchString(dirPath) {
-					return true
-				}
-			}
-			return false
+// DecidingPattern returns the rule that decides whether the file or
+// directory at path is ignored: the one ignoring a directory above it or
+// else the last one matching it, which may be a negation re-including it.
+// It returns nil when no rule applies.
+func (g *GitignoreEngine) DecidingPattern(path string, isDir bool) *GitignorePattern {
+	relPath := g.relative(path)
+	if relPath == "." || relPath == ".." || strings.HasPrefix(relPath, "../") {
+		return nil
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	parent := ""
+	if idx := strings.LastIndex(relPath, "/"); idx != -1 {
+		parent = relPath[:idx]
+	}
+	g.loadDirChain(parent)
+
+	dir := ""
+	for _, part := range strings.Split(parent, "/") {
+		if part == "" {
+			break
+		}
+		dir = strings.TrimPrefix(dir+"/"+part, "/")
+		if pattern := g.lastMatch(dir, true); pattern != nil && !pattern.Negation {
+			return pattern
 		}
 	}
+	return g.lastMatch(relPath, isDir)
+}
 
-	// Handle absolute patterns
-	if pattern.Absolute {
-		return pattern.Regex.MatchString(path)
+// isIgnored applies the patterns in order, the last match deciding. Callers
+// hold g.mu.
+func (g *GitignoreEngine) isIgnored(relPath string, isDir bool) bool {
+	pattern := g.lastMatch(relPath, isDir)
+	return pattern != nil && !pattern.Negation
+}
+
+// lastMatch returns a copy of the last pattern matching relPath, or nil.
+// Callers hold g.mu.
+func (g *GitignoreEngine) lastMatch(relPath string, isDir bool) *GitignorePattern {
+	for i := len(g.patterns) - 1; i >= 0; i-- {
+		if g.matches(relPath, isDir, g.patterns[i]) {
+			pattern := g.patterns[i]
+			return &pattern
+		}
 	}
+	return nil
+}
 
-	// Check if pattern matches the full path or any suffix
-	if pattern.Regex.MatchString(path) {
-		return true
+// matches checks if a path relative to the root matches a pattern
+func (g *GitignoreEngine) matches(relPath string, isDir bool, pattern GitignorePattern) bool {
+	if pattern.Directory && !isDir {
+		return false
 	}
 
-	// For non-absolute patterns, check all path components
-	parts := strings.Split(path, "/")
-	for i := 0; i < len(parts); i++ {
-		subPath := strings.Join(parts[i:], "/")
-		if pattern.Regex.MatchString(subPath) {
-			return true
+	// Patterns only apply below the directory of their file
+	if pattern.Base != "" {
+		rest, ok := strings.CutPrefix(relPath, pattern.Base+"/")
+		if !ok {
+			return false
 		}
+		relPath = rest
 	}
 
-	return false
+	return g.matchesPattern(relPath, pattern)
+}
+
+// matchesPattern checks if a path relative to the pattern's base matches it
+func (g *GitignoreEngine) matchesPattern(path string, pattern GitignorePattern) bool {
+	// Anchored patterns match the whole path
+	if pattern.Absolute {
+		return pattern.Regex.MatchString(path)
+	}
+
+	// Other patterns match the name at any depth
+	return pattern.Regex.MatchString(path[strings.LastIndex(path, "/")+1:])
 }
 
 // GetIgnoredFiles returns a list of files that would be ignored
@@ -222,7 +482,7 @@ func (g *GitignoreEngine) GetIgnoredFiles(rootPath string) ([]string, error) {
 			return nil
 		}
 
-		if g.ShouldIgnore(path) {
+		if g.shouldIgnore(path, info.IsDir()) {
 			ignoredFiles = append(ignoredFiles, path)
 
 			// If it's a directory, skip walking into it
@@ -237,19 +497,25 @@ func (g *GitignoreEngine) GetIgnoredFiles(rootPath string) ([]string, error) {
 	return ignoredFiles, err
 }
 
-// AddPattern adds a custom gitignore pattern
+// AddPattern adds a custom gitignore pattern, anchored at the root
 func (g *GitignoreEngine) AddPattern(patternStr string) error {
 	pattern := g.parseGitignorePattern(patternStr, "custom")
 	if pattern == nil {
 		return fmt.Errorf("invalid pattern: %s", patternStr)
 	}
 
+	g.mu.Lock()
+	defer g.mu.Unlock()
 	g.patterns = append(g.patterns, *pattern)
+	g.ignoredDirs = make(map[string]bool)
 	return nil
 }
 
 // RemovePattern removes patterns matching the given string
 func (g *GitignoreEngine) RemovePattern(patternStr string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
 	var newPatterns []GitignorePattern
 
 	for _, pattern := range g.patterns {
@@ -259,18 +525,19 @@ func (g *GitignoreEngine) RemovePattern(patternStr string) {
 	}
 
 	g.patterns = newPatterns
+	g.ignoredDirs = make(map[string]bool)
 }
 
-// ListPatterns returns all loaded gitignore patterns
+// ListPatterns returns the gitignore patterns loaded so far; nested
+// .gitignore files are only loaded once their directory is reached
 func (g *GitignoreEngine) ListPatterns() []string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
 	var patterns []string
 
 	for _, pattern := range g.patterns {
-		patternStr := pattern.Pattern
-		if pattern.Negation {
-			patternStr = "!" + patternStr
-		}
-		patterns = append(patterns, patternStr)
+		patterns = append(patterns, pattern.Pattern)
 	}
 
 	return patterns
@@ -285,7 +552,7 @@ func (g *GitignoreEngine) IsGitRepository() bool {
 	return false
 }
 
-// GetGitignoreFiles returns paths to all .gitignore files found
+// GetGitignoreFiles returns paths to all ignore files found
 func (g *GitignoreEngine) GetGitignoreFiles() []string {
 	var gitignoreFiles []string
 
@@ -294,7 +561,7 @@ func (g *GitignoreEngine) GetGitignoreFiles() []string {
 			return nil
 		}
 
-		if info.Name() == ".gitignore" {
+		if !info.IsDir() && slices.Contains(g.ignoreFiles, info.Name()) {
 			gitignoreFiles = append(gitignoreFiles, path)
 		}
 
@@ -318,15 +585,13 @@ func (g *GitignoreEngine) ValidatePattern(pattern string) error {
 
 // MatchesAnyPattern checks if a path matches any of the loaded patterns
 func (g *GitignoreEngine) MatchesAnyPattern(path string) (bool, string) {
-	relPath, err := filepath.Rel(g.basePath, path)
-	if err != nil {
-		relPath = path
-	}
+	relPath := g.relative(path)
 
-	relPath = filepath.ToSlash(relPath)
+	g.mu.Lock()
+	defer g.mu.Unlock()
 
 	for _, pattern := range g.patterns {
-		if g.matchesPattern(relPath, pattern) {
+		if g.matches(relPath, false, pattern) {
 			return true, pattern.Pattern
 		}
 	}
@@ -0,0 +1,186 @@
+package goripgrep
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeTestFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestGitignoreHierarchy(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+	writeTestFiles(t, home, map[string]string{
+		".gitconfig":    "[user]\n\tname = test\n[core]\n\texcludesFile = ~/global-ignore\n",
+		"global-ignore": "*.swp\n*.orig\n",
+	})
+
+	repo := t.TempDir()
+	writeTestFiles(t, repo, map[string]string{
+		".git/HEAD":          "ref: refs/heads/main\n",
+		".git/info/exclude":  "secret.txt\n",
+		".gitignore":         "*.log\n/build/\n!keep.orig\n",
+		"src/.gitignore":     "!important.log\n/generated.go\ncache/\n",
+		"src/sub/.gitignore": "*.tmp\n",
+	})
+
+	tests := []struct {
+		path    string
+		dir     bool
+		ignored bool
+	}{
+		{"app.log", false, true},
+		{"src/app.log", false, true},
+		{"src/important.log", false, false},    // Re-included by a deeper rule
+		{"other/important.log", false, true},   // The nested rule only applies under src
+		{"build", true, true},                  // Anchored at the root
+		{"src/build", true, false},             // Not the root build directory
+		{"src/generated.go", false, true},      // Anchored at src
+		{"src/sub/generated.go", false, false}, // Anchored patterns do not float
+		{"generated.go", false, false},         // Above the nested .gitignore
+		{"src/cache", true, true},              // Directory-only pattern
+		{"src/cache", false, false},            // Files are not matched by dir patterns
+		{"src/cache/data.json", false, true},   // Inside an ignored directory
+		{"src/sub/a.tmp", false, true},         // Deepest .gitignore
+		{"src/a.tmp", false, false},            // Above it
+		{"secret.txt", false, true},            // .git/info/exclude
+		{"src/secret.txt", false, true},        // Unanchored info/exclude pattern
+		{"notes.swp", false, true},             // Global excludes file
+		{"keep.orig", false, false},            // .gitignore overrides global excludes
+		{"other.orig", false, true},
+		{"src/main.go", false, false},
+	}
+
+	// Search below the repository root, so rules above must still apply
+	engine := NewGitignoreEngine(filepath.Join(repo, "src"))
+	for _, tt := range tests {
+		path := filepath.Join(repo, filepath.FromSlash(tt.path))
+		var got bool
+		if tt.dir {
+			got = engine.ShouldIgnoreDir(path)
+		} else {
+			got = engine.ShouldIgnore(path)
+		}
+		if got != tt.ignored {
+			t.Errorf("ignored(%q, dir=%v) = %v, want %v", tt.path, tt.dir, got, tt.ignored)
+		}
+	}
+}
+
+func TestFindGitignoreNested(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	repo := t.TempDir()
+	writeTestFiles(t, repo, map[string]string{
+		".git/HEAD":         "ref: refs/heads/main\n",
+		".gitignore":        "deps/\n",
+		"main.go":           "needle\n",
+		"deps/lib.go":       "needle\n",
+		"lib/.gitignore":    "*.gen.go\n",
+		"lib/a.go":          "needle\n",
+		"lib/a.gen.go":      "needle\n",
+		"lib/deps/inner.go": "needle\n",
+	})
+
+	for _, optimize := range []bool{false, true} {
+		results, err := Find("needle", repo, WithRecursive(true), WithGitignore(true), WithOptimization(optimize))
+		if err != nil {
+			t.Fatalf("Find() error: %v", err)
+		}
+
+		found := make(map[string]bool)
+		for _, match := range results.Matches {
+			rel, _ := filepath.Rel(repo, match.File)
+			found[filepath.ToSlash(rel)] = true
+		}
+
+		want := []string{"main.go", "lib/a.go"}
+		if len(found) != len(want) {
+			t.Errorf("optimize=%v: found %v, want %v", optimize, found, want)
+		}
+		for _, file := range want {
+			if !found[file] {
+				t.Errorf("optimize=%v: %s not searched, found %v", optimize, file, found)
+			}
+		}
+	}
+}
+
+func TestGitignoreIgnoreFiles(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	root := t.TempDir()
+	writeTestFiles(t, root, map[string]string{
+		".gitignore":        "*.log\n*.bak\n",
+		".ignore":           "!keep.log\n*.tmp\n",
+		".rgignore":         "!keep.tmp\n",
+		".dockerignore":     "*.secret\n!keep.bak\n",
+		"sub/.gitignore":    "!sub.tmp\n",
+		"sub/.dockerignore": "*.txt\n",
+	})
+
+	tests := []struct {
+		path    string
+		ignored bool
+	}{
+		{"app.log", true},
+		{"keep.log", false},    // .ignore overrides .gitignore
+		{"a.tmp", true},        // .ignore
+		{"keep.tmp", false},    // .rgignore overrides .ignore
+		{"sub/sub.tmp", false}, // A deeper .gitignore overrides the parent .ignore
+		{"a.secret", false},    // Custom ignore files are opt-in
+		{"notes.txt", false},
+	}
+
+	engine := NewGitignoreEngine(root)
+	for _, tt := range tests {
+		if got := engine.ShouldIgnore(filepath.Join(root, tt.path)); got != tt.ignored {
+			t.Errorf("ShouldIgnore(%q) = %v, want %v", tt.path, got, tt.ignored)
+		}
+	}
+
+	custom := []struct {
+		path    string
+		ignored bool
+	}{
+		{"a.secret", true},
+		{"keep.bak", false}, // Custom ignore files override .gitignore
+		{"other.bak", true},
+		{"notes.txt", false},
+		{"sub/notes.txt", true},
+	}
+
+	engine = NewGitignoreEngine(root, ".dockerignore")
+	for _, tt := range custom {
+		if got := engine.ShouldIgnore(filepath.Join(root, tt.path)); got != tt.ignored {
+			t.Errorf("with .dockerignore: ShouldIgnore(%q) = %v, want %v", tt.path, got, tt.ignored)
+		}
+	}
+
+	writeTestFiles(t, root, map[string]string{
+		"a.secret": "needle\n",
+		"b.go":     "needle\n",
+	})
+	results, err := Find("needle", root, WithIgnoreFiles(".dockerignore"))
+	if err != nil {
+		t.Fatalf("Find() error: %v", err)
+	}
+	if len(results.Matches) != 1 || filepath.Base(results.Matches[0].File) != "b.go" {
+		t.Errorf("Find() with WithIgnoreFiles matched %v, want only b.go", results.Matches)
+	}
+}
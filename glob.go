@@ -0,0 +1,242 @@
+package goripgrep
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// globRule is a single compiled include or exclude glob
+type globRule struct {
+	glob     string // The glob as given, or the file type it comes from
+	regex    *regexp.Regexp
+	exclude  bool
+	fullPath bool // Match the path relative to the search root instead of the base name
+	fileType bool // Comes from a file type, which only applies to files
+}
+
+// globSet selects files with ordered include and exclude globs. As in
+// ripgrep, the last glob matching a path decides, and when any include glob
+// is present files matching none of the globs are skipped. File types come
+// first so that explicit globs override them.
+type globSet struct {
+	root        string // Absolute search root that path globs are anchored at
+	rules       []globRule
+	hasIncludes bool
+}
+
+// newGlobSet compiles the configured globs. Include globs may be negated
+// with a leading !; exclude globs always exclude and take precedence.
+func newGlobSet(config SearchConfig) (*globSet, error) {
+	root, err := filepath.Abs(config.SearchPath)
+	if err != nil {
+		root = config.SearchPath
+	}
+	set := &globSet{root: root}
+
+	for _, name := range config.FileTypes {
+		if err := set.addFileType(name, false); err != nil {
+			return nil, err
+		}
+	}
+	for _, name := range config.FileTypesNot {
+		if err := set.addFileType(name, true); err != nil {
+			return nil, err
+		}
+	}
+
+	var includes []string
+	if config.FilePattern != "" {
+		includes = append(includes, config.FilePattern)
+	}
+	includes = append(includes, config.IncludeGlobs...)
+
+	for _, glob := range includes {
+		if err := set.add(glob, false, false); err != nil {
+			return nil, err
+		}
+	}
+	for _, glob := range config.CaseInsensitiveGlobs {
+		if err := set.add(glob, false, true); err != nil {
+			return nil, err
+		}
+	}
+	for _, glob := range config.ExcludeGlobs {
+		if err := set.add(glob, true, false); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(set.rules) == 0 {
+		return nil, nil
+	}
+	return set, nil
+}
+
+// addFileType appends rules for the globs of the named file type
+func (s *globSet) addFileType(name string, exclude bool) error {
+	globs, err := fileTypeGlobs(name)
+	if err != nil {
+		return err
+	}
+	for _, glob := range globs {
+		if err := s.add(glob, exclude, false); err != nil {
+			return err
+		}
+		s.rules[len(s.rules)-1].glob = fmt.Sprintf("%s (type %s)", glob, name)
+		s.rules[len(s.rules)-1].fileType = true
+	}
+	return nil
+}
+
+// add compiles glob and appends it to the rules
+func (s *globSet) add(glob string, exclude, foldCase bool) error {
+	pattern := glob
+	if !exclude && strings.HasPrefix(pattern, "!") {
+		exclude = true
+		pattern = pattern[1:]
+	}
+
+	// Globs with a slash are anchored at the search root
+	fullPath := strings.Contains(pattern, "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+
+	expr, err := globToRegexp(pattern)
+	if err != nil {
+		return fmt.Errorf("invalid glob %q: %w", glob, err)
+	}
+	if foldCase {
+		expr = "(?i)" + expr
+	}
+
+	regex, err := regexp.Compile(expr)
+	if err != nil {
+		return fmt.Errorf("invalid glob %q: %w", glob, err)
+	}
+
+	s.rules = append(s.rules, globRule{glob: glob, regex: regex, exclude: exclude, fullPath: fullPath})
+	if !exclude {
+		s.hasIncludes = true
+	}
+	return nil
+}
+
+// includesFile reports whether the file at path should be searched
+func (s *globSet) includesFile(path string) bool {
+	if rule, ok := s.lastMatch(s.relative(path), false); ok {
+		return !rule.exclude
+	}
+	return !s.hasIncludes
+}
+
+// excludesDir reports whether the directory at path should be skipped.
+// Include globs select files only, so they never prune directories.
+func (s *globSet) excludesDir(path string) bool {
+	if path == s.root {
+		return false
+	}
+	rule, ok := s.lastMatch(s.relative(path), true)
+	return ok && rule.exclude
+}
+
+// lastMatch returns the last rule matching relPath, ignoring file type rules
+// for directories
+func (s *globSet) lastMatch(relPath string, dir bool) (globRule, bool) {
+	name := relPath[strings.LastIndex(relPath, "/")+1:]
+	for i := len(s.rules) - 1; i >= 0; i-- {
+		rule := s.rules[i]
+		if dir && rule.fileType {
+			continue
+		}
+		target := name
+		if rule.fullPath {
+			target = relPath
+		}
+		if rule.regex.MatchString(target) {
+			return rule, true
+		}
+	}
+	return globRule{}, false
+}
+
+// relative returns path relative to the search root with forward slashes,
+// the form globs are matched against
+func (s *globSet) relative(path string) string {
+	relPath, err := filepath.Rel(s.root, path)
+	if err != nil || relPath == "." {
+		relPath = filepath.Base(path)
+	}
+	return filepath.ToSlash(relPath)
+}
+
+// globToRegexp translates a glob into an anchored regular expression. It
+// supports *, ?, ** across directories, [classes] with ! or ^ negation,
+// {a,b} alternatives (which may nest) and backslash escapes.
+func globToRegexp(glob string) (string, error) {
+	var builder strings.Builder
+	builder.WriteString("^")
+
+	depth := 0 // Open brace groups
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch c {
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				// **/ matches zero or more directories, a trailing ** everything
+				if i+2 < len(glob) && glob[i+2] == '/' {
+					builder.WriteString("(?:.*/)?")
+					i += 2
+				} else {
+					builder.WriteString(".*")
+					i++
+				}
+			} else {
+				builder.WriteString("[^/]*")
+			}
+		case '?':
+			builder.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end == -1 {
+				return "", fmt.Errorf("unterminated character class")
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			builder.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		case '{':
+			depth++
+			builder.WriteString("(?:")
+		case '}':
+			if depth == 0 {
+				return "", fmt.Errorf("unmatched }")
+			}
+			depth--
+			builder.WriteString(")")
+		case ',':
+			if depth > 0 {
+				builder.WriteString("|")
+			} else {
+				builder.WriteString(",")
+			}
+		case '\\':
+			if i+1 == len(glob) {
+				return "", fmt.Errorf("trailing backslash")
+			}
+			i++
+			builder.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		default:
+			builder.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		}
+	}
+
+	if depth > 0 {
+		return "", fmt.Errorf("unterminated {")
+	}
+
+	builder.WriteString("$")
+	return builder.String(), nil
+}
@@ -0,0 +1,85 @@
+package goripgrep
+
+import "testing"
+
+func TestGlobSet(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   SearchConfig
+		path     string
+		expected bool
+	}{
+		{"NoGlobs", SearchConfig{ExcludeGlobs: []string{"*.md"}}, "main.go", true},
+		{"Star", SearchConfig{IncludeGlobs: []string{"*.go"}}, "src/main.go", true},
+		{"StarMiss", SearchConfig{IncludeGlobs: []string{"*.go"}}, "src/main.gox", false},
+		{"Braces", SearchConfig{IncludeGlobs: []string{"*.{go,md}"}}, "README.md", true},
+		{"BracesMiss", SearchConfig{IncludeGlobs: []string{"*.{go,md}"}}, "notes.txt", false},
+		{"NestedBraces", SearchConfig{IncludeGlobs: []string{"*.{t{s,sx},js}"}}, "app.tsx", true},
+		{"Question", SearchConfig{IncludeGlobs: []string{"?.go"}}, "a.go", true},
+		{"Class", SearchConfig{IncludeGlobs: []string{"[!a]*.go"}}, "a.go", false},
+		{"Negation", SearchConfig{IncludeGlobs: []string{"*.go", "!*_test.go"}}, "main_test.go", false},
+		{"LastWins", SearchConfig{IncludeGlobs: []string{"!*_test.go", "*.go"}}, "main_test.go", true},
+		{"OnlyNegation", SearchConfig{IncludeGlobs: []string{"!*.md"}}, "main.go", true},
+		{"ExcludeWins", SearchConfig{IncludeGlobs: []string{"*.go"}, ExcludeGlobs: []string{"gen_*"}}, "gen_types.go", false},
+		{"PathGlob", SearchConfig{IncludeGlobs: []string{"src/*.go"}}, "src/main.go", true},
+		{"PathGlobDepth", SearchConfig{IncludeGlobs: []string{"src/*.go"}}, "src/sub/main.go", false},
+		{"DoubleStar", SearchConfig{IncludeGlobs: []string{"src/**/*.go"}}, "src/sub/main.go", true},
+		{"DoubleStarZeroDirs", SearchConfig{IncludeGlobs: []string{"**/main.go"}}, "main.go", true},
+		{"Anchored", SearchConfig{IncludeGlobs: []string{"/main.go"}}, "src/main.go", false},
+		{"Escape", SearchConfig{IncludeGlobs: []string{`\*.go`}}, "*.go", true},
+		{"FilePattern", SearchConfig{FilePattern: "*.{go,md}"}, "README.md", true},
+		{"IgnoreCase", SearchConfig{CaseInsensitiveGlobs: []string{"*.md"}}, "README.MD", true},
+		{"CaseSensitive", SearchConfig{IncludeGlobs: []string{"*.md"}}, "README.MD", false},
+		{"Type", SearchConfig{FileTypes: []string{"go"}}, "src/main.go", true},
+		{"TypeMiss", SearchConfig{FileTypes: []string{"go"}}, "README.md", false},
+		{"TypeExactName", SearchConfig{FileTypes: []string{"make"}}, "Makefile", true},
+		{"TypeComposite", SearchConfig{FileTypes: []string{"web"}}, "app.tsx", true},
+		{"TypeNot", SearchConfig{FileTypesNot: []string{"md"}}, "README.md", false},
+		{"TypeNotOthers", SearchConfig{FileTypesNot: []string{"md"}}, "main.go", true},
+		{"GlobOverridesType", SearchConfig{FileTypes: []string{"go"}, IncludeGlobs: []string{"!*_test.go"}}, "main_test.go", false},
+		{"GlobAddsToType", SearchConfig{FileTypes: []string{"go"}, IncludeGlobs: []string{"*.md"}}, "README.md", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.config.SearchPath = "/root"
+			set, err := newGlobSet(test.config)
+			if err != nil {
+				t.Fatalf("newGlobSet failed: %v", err)
+			}
+			if got := set.includesFile("/root/" + test.path); got != test.expected {
+				t.Errorf("includesFile(%q) = %v, want %v", test.path, got, test.expected)
+			}
+		})
+	}
+}
+
+func TestGlobSetInvalid(t *testing.T) {
+	for _, glob := range []string{"*.{go,md", "*.go}", "[abc", `foo\`} {
+		if _, err := newGlobSet(SearchConfig{IncludeGlobs: []string{glob}}); err == nil {
+			t.Errorf("Expected an error for glob %q", glob)
+		}
+	}
+}
+
+func TestFileTypes(t *testing.T) {
+	types := FileTypes()
+	for i := 1; i < len(types); i++ {
+		if types[i-1].Name >= types[i].Name {
+			t.Errorf("File types are not sorted: %q before %q", types[i-1].Name, types[i].Name)
+		}
+	}
+
+	// Type rules never prune directories
+	set, err := newGlobSet(SearchConfig{SearchPath: "/root", FileTypesNot: []string{"go"}})
+	if err != nil {
+		t.Fatalf("newGlobSet failed: %v", err)
+	}
+	if set.excludesDir("/root/cmd.go") {
+		t.Error("Expected a directory matching a type glob to be walked")
+	}
+
+	if _, err := newGlobSet(SearchConfig{FileTypes: []string{"nope"}}); err == nil {
+		t.Error("Expected an error for an unknown file type")
+	}
+}
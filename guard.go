@@ -0,0 +1,139 @@
+package goripgrep
+
+import (
+	"errors"
+	"fmt"
+	"regexp/syntax"
+	"strings"
+)
+
+// ErrSlowPattern is returned with WithRejectSlowPatterns for patterns that
+// are likely to be pathologically slow in the engine that would match them
+var ErrSlowPattern = errors.New("pattern is likely to be pathologically slow")
+
+// maxPatternInstructions is the largest compiled regex program accepted
+// without a warning. Go's regexp steps through the program for every byte it
+// reads, so counted repetition like {1,1000} slows each line down a
+// thousandfold.
+const maxPatternInstructions = 1000
+
+// checkSlowPattern returns an ErrSlowPattern error naming the hazards of
+// pattern, or nil when it has none
+func checkSlowPattern(pattern string, config SearchConfig) error {
+	hazards, err := patternHazards(pattern, config)
+	if err != nil || len(hazards) == 0 {
+		return err
+	}
+	return fmt.Errorf("%w: %s", ErrSlowPattern, strings.Join(hazards, "; "))
+}
+
+// patternHazards lists the reasons pattern is likely to be pathologically
+// slow with config, judged against the engine the search would use
+func patternHazards(pattern string, config SearchConfig) ([]string, error) {
+	matcher, err := newLineMatcher(pattern, config)
+	if err != nil {
+		return nil, err
+	}
+
+	var hazards []string
+	switch {
+	case matcher.pcre != nil:
+		if pcreNestedRepeat(matcher.pcre.root, false) {
+			hazards = append(hazards, "nested repetition such as (a+)+ backtracks exponentially on lines that almost match, until the step limit abandons the line and its matches")
+		}
+	case matcher.regex != nil:
+		re, err := syntax.Parse(matcher.regex.String(), syntax.Perl)
+		if err != nil {
+			return nil, err
+		}
+		if prog, err := syntax.Compile(re.Simplify()); err == nil && len(prog.Inst) > maxPatternInstructions {
+			hazards = append(hazards, fmt.Sprintf("counted repetition compiles to %d instructions, which every byte searched steps through; use smaller counts such as {1,100} or an unbounded + or *", len(prog.Inst)))
+		}
+		if config.Multiline && spanningRepeat(re) {
+			hazards = append(hazards, "in multiline mode a repeated alternation or a repeat of any character can run across the whole file, so one match may cover and hide the rest; bound it, as in [^\\n]* or {0,20}")
+		}
+	}
+	return hazards, nil
+}
+
+// pcreNestedRepeat reports whether a repeat that can match more than once
+// sits inside another, the shape that backtracks exponentially
+func pcreNestedRepeat(node pcreNode, inRepeat bool) bool {
+	switch n := node.(type) {
+	case pcreConcat:
+		for _, sub := range n {
+			if pcreNestedRepeat(sub, inRepeat) {
+				return true
+			}
+		}
+	case pcreAlternate:
+		for _, sub := range n {
+			if pcreNestedRepeat(sub, inRepeat) {
+				return true
+			}
+		}
+	case *pcreRepeat:
+		repeats := n.max < 0 || n.max > 1
+		if repeats && inRepeat {
+			return true
+		}
+		return pcreNestedRepeat(n.sub, inRepeat || repeats)
+	case *pcreCapture:
+		return pcreNestedRepeat(n.sub, inRepeat)
+	case *pcreLookaround:
+		return pcreNestedRepeat(n.sub, inRepeat)
+	}
+	return false
+}
+
+// spanningRepeat reports whether re has an unbounded repeat of an
+// alternation or of a character that crosses lines
+func spanningRepeat(re *syntax.Regexp) bool {
+	unbounded := re.Op == syntax.OpStar || re.Op == syntax.OpPlus || (re.Op == syntax.OpRepeat && re.Max < 0)
+	if unbounded && (hasAlternation(re.Sub[0]) || spansLines(re.Sub[0])) {
+		return true
+	}
+	for _, sub := range re.Sub {
+		if spanningRepeat(sub) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasAlternation reports whether re contains an alternation
+func hasAlternation(re *syntax.Regexp) bool {
+	if re.Op == syntax.OpAlternate {
+		return true
+	}
+	for _, sub := range re.Sub {
+		if hasAlternation(sub) {
+			return true
+		}
+	}
+	return false
+}
+
+// spansLines reports whether re is a single character matching both \n and
+// ordinary text, like . in (?s) mode or [\s\S], so repeating it crosses lines
+func spansLines(re *syntax.Regexp) bool {
+	switch re.Op {
+	case syntax.OpAnyChar:
+		return true
+	case syntax.OpCharClass:
+		return classContains(re.Rune, '\n') && classContains(re.Rune, 'a')
+	case syntax.OpCapture:
+		return spansLines(re.Sub[0])
+	}
+	return false
+}
+
+// classContains reports whether the ranges of a character class include r
+func classContains(ranges []rune, r rune) bool {
+	for i := 0; i+1 < len(ranges); i += 2 {
+		if ranges[i] <= r && r <= ranges[i+1] {
+			return true
+		}
+	}
+	return false
+}
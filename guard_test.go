@@ -0,0 +1,70 @@
+package goripgrep
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestPatternHazards(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		opts    []Option
+		hazard  bool
+	}{
+		{name: "literal", pattern: "needle"},
+		{name: "plain regex", pattern: `func \w+\(`},
+		{name: "small counted repeat", pattern: `\w{1,100}`},
+		{name: "huge counted repeat", pattern: `[a-z]{1,1000}x`, hazard: true},
+		{name: "nested counted repeat", pattern: `(?:\w{1,20}\s){1,40}`, hazard: true},
+		{name: "nested repeat in regexp", pattern: `(\w+\s?)+$`},
+		{name: "nested repeat backtracking", pattern: `(\w+\s?)+(?=;)`, opts: []Option{WithPCRE2Syntax()}, hazard: true},
+		{name: "single repeat backtracking", pattern: `\w+(?=\()`, opts: []Option{WithPCRE2Syntax()}},
+		{name: "repeated alternation multiline", pattern: `BEGIN(?:foo|bar|\n)*END`, opts: []Option{WithMultiline()}, hazard: true},
+		{name: "repeated newline class multiline", pattern: `BEGIN[\s\S]*END`, opts: []Option{WithMultiline()}, hazard: true},
+		{name: "bounded multiline", pattern: `func main\(\) \{\n\s+return`, opts: []Option{WithMultiline()}},
+		{name: "repeated alternation single line", pattern: `(?:foo|bar)*baz`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := ExplainPattern(tt.pattern, tt.opts...)
+			if err != nil {
+				t.Fatalf("ExplainPattern failed: %v", err)
+			}
+			if (len(report.Hazards) > 0) != tt.hazard {
+				t.Errorf("Expected hazard %v, got %q", tt.hazard, report.Hazards)
+			}
+			if tt.hazard && report.Cost != CostHigh {
+				t.Errorf("Expected high cost for a hazardous pattern, got %s", report.Cost)
+			}
+		})
+	}
+}
+
+func TestWithRejectSlowPatterns(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{"a.txt": "word word word\n"})
+
+	// Hazards only refuse the search when asked to
+	results, err := Find(`(?:\w{1,20}\s?){1,40}`, dir)
+	if err != nil || !results.HasMatches() {
+		t.Fatalf("Expected the slow pattern to search by default, got %v, %v", results, err)
+	}
+	if _, err := Find(`(?:\w{1,20}\s?){1,40}`, dir, WithRejectSlowPatterns()); !errors.Is(err, ErrSlowPattern) {
+		t.Errorf("Expected ErrSlowPattern from Find, got %v", err)
+	}
+	if _, err := FindInFile(`(\w+\s?)+(?=x)`, filepath.Join(dir, "a.txt"), WithPCRE2Syntax(), WithRejectSlowPatterns()); !errors.Is(err, ErrSlowPattern) {
+		t.Errorf("Expected ErrSlowPattern from FindInFile, got %v", err)
+	}
+	if _, err := Replace(`[a-z]{1,1000}`, "x", dir, WithRejectSlowPatterns(), WithDryRun()); !errors.Is(err, ErrSlowPattern) {
+		t.Errorf("Expected ErrSlowPattern from Replace, got %v", err)
+	}
+
+	// Safe patterns are unaffected
+	results, err = Find(`w\w+`, dir, WithRejectSlowPatterns())
+	if err != nil || results.Count() != 3 {
+		t.Errorf("Expected 3 matches for a safe pattern, got %v, %v", results, err)
+	}
+}
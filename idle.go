@@ -0,0 +1,96 @@
+package goripgrep
+
+import "time"
+
+// IdlePolicy makes a Watcher put off re-searching changed files until the
+// machine is idle, so keeping matches current never competes with
+// interactive work. Lower limits wait for a quieter machine; MaxDelay bounds
+// how stale the matches may get. Load is sampled from /proc/stat on Linux;
+// elsewhere the machine always counts as idle.
+type IdlePolicy struct {
+	MaxCPU    float64       // Largest share of CPU time spent busy, 0 to 1, at which work runs (0 means 0.25)
+	MaxIOWait float64       // Largest share of CPU time spent waiting for disk IO at which work runs (0 means 0.05)
+	MaxDelay  time.Duration // Run anyway once changes have waited this long (0 for no limit)
+	Interval  time.Duration // How often load is sampled while work waits (0 means 1s)
+}
+
+// Idle policies from most to least patient
+var (
+	IdleStrict   = IdlePolicy{MaxCPU: 0.10, MaxIOWait: 0.02}
+	IdleBalanced = IdlePolicy{MaxCPU: 0.25, MaxIOWait: 0.05, MaxDelay: 5 * time.Minute}
+	IdleEager    = IdlePolicy{MaxCPU: 0.50, MaxIOWait: 0.10, MaxDelay: 30 * time.Second}
+)
+
+// cpuTimes are cumulative CPU times in clock ticks, summed over all CPUs
+type cpuTimes struct {
+	busy   uint64
+	iowait uint64
+	total  uint64
+}
+
+// idleScheduler decides when deferred work may run under an IdlePolicy
+type idleScheduler struct {
+	policy IdlePolicy
+	sample func() (cpuTimes, error) // Reads the current CPU times
+	now    func() time.Time
+
+	prev    cpuTimes
+	sampled bool
+	since   time.Time // When the waiting work was first deferred
+}
+
+// newIdleScheduler creates a scheduler for policy, filling in its defaults
+func newIdleScheduler(policy IdlePolicy) *idleScheduler {
+	if policy.MaxCPU <= 0 {
+		policy.MaxCPU = 0.25
+	}
+	if policy.MaxIOWait <= 0 {
+		policy.MaxIOWait = 0.05
+	}
+	if policy.Interval <= 0 {
+		policy.Interval = time.Second
+	}
+	return &idleScheduler{policy: policy, sample: readCPUTimes, now: time.Now}
+}
+
+// wait records that work is waiting, taking the first load sample it will
+// be judged by, and returns how long to wait before asking ready
+func (s *idleScheduler) wait() time.Duration {
+	if s.since.IsZero() {
+		s.since = s.now()
+		s.prev, s.sampled = s.read()
+	}
+	return s.policy.Interval
+}
+
+// ready reports whether the waiting work may run: the machine was idle since
+// the last sample, load cannot be sampled, or the work has waited MaxDelay
+func (s *idleScheduler) ready() bool {
+	current, ok := s.read()
+	idle := !ok || !s.sampled || s.idleSince(current)
+	s.prev, s.sampled = current, ok
+
+	if idle || (s.policy.MaxDelay > 0 && s.now().Sub(s.since) >= s.policy.MaxDelay) {
+		s.since = time.Time{}
+		return true
+	}
+	return false
+}
+
+// idleSince reports whether CPU and IO wait stayed within the policy
+// between the previous sample and current
+func (s *idleScheduler) idleSince(current cpuTimes) bool {
+	if current.total <= s.prev.total {
+		return true
+	}
+	total := current.total - s.prev.total
+	busy := float64(current.busy-s.prev.busy) / float64(total)
+	iowait := float64(current.iowait-s.prev.iowait) / float64(total)
+	return busy <= s.policy.MaxCPU && iowait <= s.policy.MaxIOWait
+}
+
+// read samples the CPU times, reporting whether that worked
+func (s *idleScheduler) read() (cpuTimes, bool) {
+	times, err := s.sample()
+	return times, err == nil
+}
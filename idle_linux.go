@@ -0,0 +1,53 @@
+//go:build linux
+
+package goripgrep
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// readCPUTimes reads the CPU times of all CPUs from the cpu line of /proc/stat
+func readCPUTimes() (cpuTimes, error) {
+	file, err := os.Open("/proc/stat")
+	if err != nil {
+		return cpuTimes{}, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	if !scanner.Scan() {
+		return cpuTimes{}, fmt.Errorf("empty /proc/stat")
+	}
+	return parseCPUTimes(scanner.Text())
+}
+
+// parseCPUTimes parses a /proc/stat cpu line: user, nice, system, idle,
+// iowait, irq, softirq and steal ticks. Guest time is already counted in
+// user and nice.
+func parseCPUTimes(line string) (cpuTimes, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 5 || fields[0] != "cpu" {
+		return cpuTimes{}, fmt.Errorf("unexpected /proc/stat line %q", line)
+	}
+
+	var times cpuTimes
+	for i, field := range fields[1:min(len(fields), 9)] {
+		ticks, err := strconv.ParseUint(field, 10, 64)
+		if err != nil {
+			return cpuTimes{}, fmt.Errorf("unexpected /proc/stat line %q: %w", line, err)
+		}
+		times.total += ticks
+		switch i {
+		case 3: // idle
+		case 4:
+			times.iowait = ticks
+		default:
+			times.busy += ticks
+		}
+	}
+	return times, nil
+}
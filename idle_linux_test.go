@@ -0,0 +1,22 @@
+//go:build linux
+
+package goripgrep
+
+import "testing"
+
+func TestParseCPUTimes(t *testing.T) {
+	times, err := parseCPUTimes("cpu  100 5 50 800 20 3 2 1 0 0")
+	if err != nil {
+		t.Fatalf("parseCPUTimes failed: %v", err)
+	}
+	if times != (cpuTimes{busy: 161, iowait: 20, total: 981}) {
+		t.Errorf("Unexpected times %+v", times)
+	}
+
+	if _, err := parseCPUTimes("intr 1 2 3"); err == nil {
+		t.Error("Expected an error for a line other than cpu")
+	}
+	if _, err := readCPUTimes(); err != nil {
+		t.Errorf("readCPUTimes failed: %v", err)
+	}
+}
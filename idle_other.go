@@ -0,0 +1,11 @@
+//go:build !linux
+
+package goripgrep
+
+import "errors"
+
+// readCPUTimes reports that load cannot be sampled, so the machine always
+// counts as idle
+func readCPUTimes() (cpuTimes, error) {
+	return cpuTimes{}, errors.ErrUnsupported
+}
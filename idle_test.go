@@ -0,0 +1,70 @@
+package goripgrep
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeLoad returns a sampler that advances 100 ticks per call, busy and
+// waiting for IO for the given shares of them
+func fakeLoad(busy, iowait *atomic.Uint64) func() (cpuTimes, error) {
+	var times cpuTimes
+	return func() (cpuTimes, error) {
+		times.total += 100
+		times.busy += busy.Load()
+		times.iowait += iowait.Load()
+		return times, nil
+	}
+}
+
+func TestIdleScheduler(t *testing.T) {
+	var busy, iowait atomic.Uint64
+	now := time.Now()
+	s := newIdleScheduler(IdlePolicy{MaxDelay: time.Minute})
+	s.sample = fakeLoad(&busy, &iowait)
+	s.now = func() time.Time { return now }
+
+	if s.wait() != time.Second {
+		t.Errorf("Expected the default 1s interval")
+	}
+
+	// A busy CPU or disk holds work back
+	busy.Store(50)
+	if s.ready() {
+		t.Error("Expected work to wait while CPUs are 50% busy")
+	}
+	busy.Store(0)
+	iowait.Store(10)
+	if s.ready() {
+		t.Error("Expected work to wait while CPUs wait 10% of the time for IO")
+	}
+
+	// until the machine goes idle,
+	iowait.Store(20)
+	s.wait()
+	iowait.Store(0)
+	if !s.ready() {
+		t.Error("Expected work to run once idle")
+	}
+
+	// or the work has waited MaxDelay
+	busy.Store(90)
+	s.wait()
+	now = now.Add(30 * time.Second)
+	if s.ready() {
+		t.Error("Expected work to wait before MaxDelay")
+	}
+	now = now.Add(30 * time.Second)
+	if !s.ready() {
+		t.Error("Expected work to run after MaxDelay")
+	}
+
+	// Load that cannot be sampled never holds work back
+	s.sample = func() (cpuTimes, error) { return cpuTimes{}, errors.ErrUnsupported }
+	s.wait()
+	if !s.ready() {
+		t.Error("Expected work to run without load samples")
+	}
+}
@@ -0,0 +1,285 @@
+package goripgrep
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// keyPathFinder returns the key path of the value at a byte offset within a
+// line of a structured file
+type keyPathFinder func(line, offset int) string
+
+// KeyPaths sets KeyPath on each content match in a JSON or YAML file to the
+// path of the value it falls in, such as spec.containers[0].image. Each file
+// is read once; matches in other or unreadable files are left unchanged.
+func KeyPaths() Stage {
+	return func(matches []Match) []Match {
+		finders := make(map[string]keyPathFinder)
+
+		for i, match := range matches {
+			if match.Kind != MatchContent || match.Line < 1 {
+				continue
+			}
+
+			find, ok := finders[match.File]
+			if !ok {
+				find = newKeyPathFinder(match.File)
+				finders[match.File] = find
+			}
+			if find != nil {
+				matches[i].KeyPath = find(match.Line, match.MatchStart)
+			}
+		}
+
+		return matches
+	}
+}
+
+// newKeyPathFinder reads and indexes a JSON or YAML file, returning nil for
+// other file types and unreadable files
+func newKeyPathFinder(file string) keyPathFinder {
+	var build func(src []byte) keyPathFinder
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".json", ".jsonl", ".ndjson":
+		build = jsonKeyPaths
+	case ".yaml", ".yml":
+		build = yamlKeyPaths
+	default:
+		return nil
+	}
+
+	src, err := os.ReadFile(file)
+	if err != nil {
+		return nil
+	}
+	return build(stripBOM(src))
+}
+
+// keyPathSegment is one step of a key path: an object key or an array index
+type keyPathSegment struct {
+	key   string
+	index int
+	array bool
+}
+
+// plainKey matches keys that can be written after a dot in a key path
+var plainKey = regexp.MustCompile(`^[A-Za-z0-9_$-]+$`)
+
+// formatKeyPath renders segments as key.sub[0].name, quoting keys that would
+// be ambiguous unquoted as ["a.b"]
+func formatKeyPath(segments []keyPathSegment) string {
+	var builder strings.Builder
+	for _, segment := range segments {
+		switch {
+		case segment.array:
+			builder.WriteString("[" + strconv.Itoa(segment.index) + "]")
+		case plainKey.MatchString(segment.key):
+			if builder.Len() > 0 {
+				builder.WriteByte('.')
+			}
+			builder.WriteString(segment.key)
+		default:
+			builder.WriteString("[" + strconv.Quote(segment.key) + "]")
+		}
+	}
+	return builder.String()
+}
+
+// jsonPathMark records the key path in effect from a byte offset onwards
+type jsonPathMark struct {
+	offset int
+	path   string
+}
+
+// jsonKeyPaths walks the tokens of a JSON document, or a stream of them as in
+// JSON Lines, noting the path of each key and value from the offset where it
+// begins. Invalid input is indexed up to the first error.
+func jsonKeyPaths(src []byte) keyPathFinder {
+	decoder := json.NewDecoder(bytes.NewReader(src))
+	decoder.UseNumber()
+
+	var marks []jsonPathMark
+	var stack []keyPathSegment
+	// expectKey is set inside objects when the next string is a key
+	expectKey := false
+
+	mark := func(offset int) {
+		marks = append(marks, jsonPathMark{offset: offset, path: formatKeyPath(stack)})
+	}
+	// nextValue moves to the next element when inside an array
+	nextValue := func() {
+		if n := len(stack); n > 0 && stack[n-1].array {
+			stack[n-1].index++
+		}
+	}
+
+	for {
+		offset := int(decoder.InputOffset())
+		token, err := decoder.Token()
+		if err != nil {
+			// io.EOF at the end, or the first syntax error
+			break
+		}
+
+		switch token {
+		case json.Delim('}'), json.Delim(']'):
+			stack = stack[:len(stack)-1]
+			mark(offset)
+		default:
+			if key, ok := token.(string); ok && expectKey {
+				stack[len(stack)-1].key = key
+				mark(offset)
+				expectKey = false
+				continue
+			}
+
+			nextValue()
+			mark(offset)
+			switch token {
+			case json.Delim('{'):
+				stack = append(stack, keyPathSegment{})
+				expectKey = true
+				continue
+			case json.Delim('['):
+				stack = append(stack, keyPathSegment{array: true, index: -1})
+				continue
+			}
+		}
+
+		// A value has ended; objects expect their next key
+		if n := len(stack); n > 0 && !stack[n-1].array {
+			expectKey = true
+		}
+	}
+
+	lineStarts := []int{0}
+	for i, b := range src {
+		if b == '\n' {
+			lineStarts = append(lineStarts, i+1)
+		}
+	}
+
+	return func(line, offset int) string {
+		if line > len(lineStarts) {
+			return ""
+		}
+		position := lineStarts[line-1] + offset
+		i := sort.Search(len(marks), func(i int) bool { return marks[i].offset > position })
+		if i == 0 {
+			return ""
+		}
+		return marks[i-1].path
+	}
+}
+
+// yamlKey matches a block mapping key at the start of a line: a quoted key,
+// or a plain one running up to the first ": " or trailing colon
+var yamlKey = regexp.MustCompile(`^(?:"((?:[^"\\]|\\.)*)"|'((?:[^']|'')*)'|([^\s"'#{}\[\],][^#]*?))[ \t]*:(?:[ \t]+|$)`)
+
+// yamlFrame is a key or sequence item open at some indentation
+type yamlFrame struct {
+	indent  int
+	segment keyPathSegment
+}
+
+// yamlKeyPaths tracks block mappings and sequences by indentation to find the
+// key path in effect on each line of a YAML file. Flow collections and
+// multi-line scalars take the path of the key that holds them; each document
+// after --- starts from the root.
+func yamlKeyPaths(src []byte) keyPathFinder {
+	var paths []string
+	var stack []yamlFrame
+	// Lines indented past blockIndent belong to a block scalar (| or >)
+	blockIndent := -1
+
+	path := func() string {
+		segments := make([]keyPathSegment, len(stack))
+		for i, frame := range stack {
+			segments[i] = frame.segment
+		}
+		return formatKeyPath(segments)
+	}
+	// popTo closes every frame indented at least as far as indent, or beyond
+	// it when inclusive is false
+	popTo := func(indent int, inclusive bool) {
+		for len(stack) > 0 {
+			top := stack[len(stack)-1].indent
+			if top < indent || (top == indent && !inclusive) {
+				break
+			}
+			stack = stack[:len(stack)-1]
+		}
+	}
+
+	for _, line := range strings.Split(string(src), "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		content := strings.TrimLeft(line, " ")
+		indent := len(line) - len(content)
+
+		if blockIndent >= 0 {
+			if strings.TrimSpace(content) == "" || indent > blockIndent {
+				paths = append(paths, path())
+				continue
+			}
+			blockIndent = -1
+		}
+
+		switch {
+		case strings.TrimSpace(content) == "" || strings.HasPrefix(content, "#"):
+			paths = append(paths, path())
+			continue
+		case content == "---" || strings.HasPrefix(content, "--- ") || content == "...":
+			stack = stack[:0]
+			paths = append(paths, "")
+			continue
+		}
+
+		// Sequence entries, possibly nested as in "- - x", come before any key
+		column := indent
+		for content == "-" || strings.HasPrefix(content, "- ") {
+			popTo(column, false)
+			if n := len(stack); n > 0 && stack[n-1].indent == column && stack[n-1].segment.array {
+				stack[n-1].segment.index++
+			} else {
+				stack = append(stack, yamlFrame{indent: column, segment: keyPathSegment{array: true}})
+			}
+
+			rest := strings.TrimLeft(content[1:], " ")
+			column += len(content) - len(rest)
+			content = rest
+		}
+
+		if groups := yamlKey.FindStringSubmatch(content); groups != nil {
+			key := groups[3]
+			switch {
+			case groups[1] != "" || strings.HasPrefix(content, `""`):
+				key, _ = strconv.Unquote(`"` + groups[1] + `"`)
+			case groups[2] != "" || strings.HasPrefix(content, "''"):
+				key = strings.ReplaceAll(groups[2], "''", "'")
+			}
+
+			popTo(column, true)
+			stack = append(stack, yamlFrame{indent: column, segment: keyPathSegment{key: key}})
+
+			value := strings.TrimSpace(content[len(groups[0]):])
+			if strings.HasPrefix(value, "|") || strings.HasPrefix(value, ">") {
+				blockIndent = column
+			}
+		}
+
+		paths = append(paths, path())
+	}
+
+	return func(line, _ int) string {
+		if line > len(paths) {
+			return ""
+		}
+		return paths[line-1]
+	}
+}
@@ -0,0 +1,128 @@
+package goripgrep
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+func TestJSONKeyPaths(t *testing.T) {
+	src := `{
+  "spec": {
+    "containers": [
+      {"image": "nginx", "a.b": 1},
+      {"image": "redis"}
+    ]
+  },
+  "list": [1, 2,
+ 3]
+}
+{"next": "document"}
+`
+	find := jsonKeyPaths([]byte(src))
+
+	tests := []struct {
+		line, offset int
+		expected     string
+	}{
+		{1, 0, ""},
+		{2, 4, "spec"},
+		{3, 5, "spec.containers"},
+		{4, 8, "spec.containers[0].image"},
+		{4, 18, "spec.containers[0].image"},
+		{4, 25, `spec.containers[0]["a.b"]`},
+		{5, 18, "spec.containers[1].image"},
+		{8, 12, "list[1]"},
+		{9, 1, "list[2]"},
+		{11, 10, "next"},
+		{99, 0, ""},
+	}
+	for _, test := range tests {
+		if got := find(test.line, test.offset); got != test.expected {
+			t.Errorf("Line %d offset %d: expected %q, got %q", test.line, test.offset, test.expected, got)
+		}
+	}
+}
+
+func TestYAMLKeyPaths(t *testing.T) {
+	src := `apiVersion: v1
+spec:
+  containers:
+  - name: web
+    image: nginx:1.25
+    args:
+      - --port
+      - "80"
+  - name: db
+    image: postgres
+  script: |
+    echo image: x
+
+  volumes: []
+"quoted.key": 1
+# comment
+---
+other: 1
+---
+- - nested
+`
+	find := yamlKeyPaths([]byte(src))
+
+	expected := []string{
+		"apiVersion",
+		"spec",
+		"spec.containers",
+		"spec.containers[0].name",
+		"spec.containers[0].image",
+		"spec.containers[0].args",
+		"spec.containers[0].args[0]",
+		"spec.containers[0].args[1]",
+		"spec.containers[1].name",
+		"spec.containers[1].image",
+		"spec.script",
+		"spec.script",
+		"spec.script",
+		"spec.volumes",
+		`["quoted.key"]`,
+		`["quoted.key"]`,
+		"",
+		"other",
+		"",
+		"[0][0]",
+	}
+	for i, want := range expected {
+		if got := find(i+1, 0); got != want {
+			t.Errorf("Line %d: expected %q, got %q", i+1, want, got)
+		}
+	}
+}
+
+func TestFindKeyPaths(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"deploy.yaml": "spec:\n  containers:\n  - name: web\n    image: nginx\n",
+		"deploy.json": `{"spec":{"containers":[{"image":"nginx"},{"image":"redis"}]}}` + "\n",
+		"notes.txt":   "image: nginx\n",
+	})
+
+	results, err := Find("image", dir, WithKeyPaths())
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+
+	got := make(map[string][]string)
+	for _, match := range results.Matches {
+		name := filepath.Base(match.File)
+		got[name] = append(got[name], match.KeyPath)
+	}
+	expected := map[string][]string{
+		"deploy.yaml": {"spec.containers[0].image"},
+		"deploy.json": {"spec.containers[0].image", "spec.containers[1].image"},
+		"notes.txt":   {""},
+	}
+	for name, want := range expected {
+		if fmt.Sprint(got[name]) != fmt.Sprint(want) {
+			t.Errorf("%s: expected key paths %q, got %q", name, want, got[name])
+		}
+	}
+}
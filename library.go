@@ -0,0 +1,163 @@
+package goripgrep
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// SavedPattern is a named, described pattern kept in a PatternLibrary, so
+// a team can share vetted regexes instead of pasting them around
+type SavedPattern struct {
+	Name        string `json:"name"`
+	Pattern     string `json:"pattern"`
+	Description string `json:"description,omitempty"`
+	IgnoreCase  bool   `json:"ignore_case,omitempty"`
+	PCRE2       bool   `json:"pcre2,omitempty"` // Lookaround and backreferences are allowed
+}
+
+// Options returns the search options the pattern was saved with
+func (p SavedPattern) Options() []Option {
+	var opts []Option
+	if p.IgnoreCase {
+		opts = append(opts, WithIgnoreCase())
+	}
+	if p.PCRE2 {
+		opts = append(opts, WithPCRE2Syntax())
+	}
+	return opts
+}
+
+// validate checks that the pattern has a usable name and compiles
+func (p SavedPattern) validate() error {
+	if p.Name == "" || strings.ContainsFunc(p.Name, func(r rune) bool { return r <= ' ' || r == '/' }) {
+		return fmt.Errorf("invalid pattern name %q: use letters, digits and punctuation other than /", p.Name)
+	}
+	if _, _, err := compileRegex(p.Pattern, p.PCRE2); err != nil {
+		return fmt.Errorf("invalid regex pattern for %s: %w", p.Name, err)
+	}
+	return nil
+}
+
+// PatternLibrary is a set of saved patterns stored as JSON in a file
+type PatternLibrary struct {
+	path     string
+	patterns map[string]SavedPattern
+}
+
+// DefaultPatternLibraryPath returns where the pattern library is kept:
+// $GORIPGREP_PATTERNS if set, which can point at a file shared through a
+// repository, or patterns.json in the goripgrep user config directory
+func DefaultPatternLibraryPath() (string, error) {
+	if path := os.Getenv("GORIPGREP_PATTERNS"); path != "" {
+		return path, nil
+	}
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("pattern library: %w", err)
+	}
+	return filepath.Join(configDir, "goripgrep", "patterns.json"), nil
+}
+
+// LoadPatternLibrary reads the library stored at path. A missing file is an
+// empty library, created by the first Save.
+func LoadPatternLibrary(path string) (*PatternLibrary, error) {
+	library := &PatternLibrary{path: path, patterns: make(map[string]SavedPattern)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return library, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("pattern library: %w", err)
+	}
+
+	var patterns []SavedPattern
+	if err := json.Unmarshal(data, &patterns); err != nil {
+		return nil, fmt.Errorf("pattern library %s: %w", path, err)
+	}
+	for _, pattern := range patterns {
+		library.patterns[pattern.Name] = pattern
+	}
+	return library, nil
+}
+
+// Path returns the file the library is stored in
+func (l *PatternLibrary) Path() string {
+	return l.path
+}
+
+// Add stores pattern, replacing any pattern with the same name. Invalid
+// names and patterns that do not compile are refused.
+func (l *PatternLibrary) Add(pattern SavedPattern) error {
+	if err := pattern.validate(); err != nil {
+		return err
+	}
+	l.patterns[pattern.Name] = pattern
+	return nil
+}
+
+// Get returns the pattern called name
+func (l *PatternLibrary) Get(name string) (SavedPattern, bool) {
+	pattern, ok := l.patterns[name]
+	return pattern, ok
+}
+
+// Remove deletes the pattern called name, reporting whether it existed
+func (l *PatternLibrary) Remove(name string) bool {
+	_, ok := l.patterns[name]
+	delete(l.patterns, name)
+	return ok
+}
+
+// List returns the saved patterns sorted by name
+func (l *PatternLibrary) List() []SavedPattern {
+	patterns := make([]SavedPattern, 0, len(l.patterns))
+	for _, pattern := range l.patterns {
+		patterns = append(patterns, pattern)
+	}
+	sort.Slice(patterns, func(i, j int) bool {
+		return patterns[i].Name < patterns[j].Name
+	})
+	return patterns
+}
+
+// Save writes the library back to its file, sorted by name so that shared
+// libraries diff cleanly. The file is replaced atomically.
+func (l *PatternLibrary) Save() error {
+	data, err := json.MarshalIndent(l.List(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("pattern library: %w", err)
+	}
+	data = append(data, '\n')
+
+	dir := filepath.Dir(l.path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("pattern library: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(l.path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("pattern library: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := tmp.Chmod(0o644); err != nil {
+		tmp.Close()
+		return fmt.Errorf("pattern library: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("pattern library: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("pattern library: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), l.path); err != nil {
+		return fmt.Errorf("pattern library: %w", err)
+	}
+	return nil
+}
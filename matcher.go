@@ -0,0 +1,421 @@
+package goripgrep
+
+import (
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// lineMatcher locates pattern occurrences within a single line of text.
+// Literal patterns use plain substring search, everything else goes through regexp.
+type lineMatcher struct {
+	pattern  string
+	literal  string
+	foldCase bool // Literal is lowercase and lines are lowered before comparing
+	regex    *regexp.Regexp
+	pcre     *pcreRegexp // Set instead of regex for lookaround and backreferences
+	required []string    // Literals one of which a line needs before the regex runs; nil to run it on every line
+
+	// Set to match lines in normal form, and case folded when folder is set;
+	// spans still index the original line
+	normalizer *UnicodeNormalizer
+	folder     *AdvancedCaseFolding
+
+	// Boundary modes the literal path enforces itself; regexes are wrapped instead
+	wordRegexp bool
+	lineRegexp bool
+}
+
+// newLineMatcher compiles a pattern according to the search configuration.
+// With RegexCaching the compiled regex is shared through the global DFA cache.
+func newLineMatcher(pattern string, config SearchConfig) (*lineMatcher, error) {
+	config.IgnoreCase = config.ignoresCase(pattern)
+	matcher := &lineMatcher{
+		pattern:    pattern,
+		wordRegexp: config.WordRegexp,
+		lineRegexp: config.LineRegexp,
+	}
+	if config.CaseFolder != nil && config.IgnoreCase && config.Normalizer == nil {
+		config.Normalizer = NewUnicodeNormalizer(norm.NFC)
+	}
+	if config.Normalizer != nil {
+		pattern = config.Normalizer.Normalize(pattern)
+		matcher.normalizer = config.Normalizer
+	}
+
+	// Lookaround and backreferences need the backtracking engine
+	if !config.FixedStrings && usesPCRE2Syntax(pattern) {
+		if config.Multiline && config.PCRE2 {
+			return nil, fmt.Errorf("PCRE2 syntax is not supported in multiline mode")
+		}
+		expr := boundaryPattern(pattern, config.WordRegexp, config.LineRegexp)
+		if config.IgnoreCase {
+			expr = "(?i)" + expr
+		}
+		var err error
+		if _, matcher.pcre, err = compileRegex(expr, config.PCRE2); err != nil {
+			return nil, err
+		}
+		return matcher, nil
+	}
+
+	// Multiline patterns are always matched by regex against whole buffers
+	if config.Multiline {
+		expr := pattern
+		if config.FixedStrings {
+			expr = regexp.QuoteMeta(pattern)
+		}
+		var err error
+		matcher.regex, err = compileMultilineRegex(boundaryPattern(expr, config.WordRegexp, config.LineRegexp), config.IgnoreCase)
+		if err != nil {
+			return nil, err
+		}
+		return matcher, nil
+	}
+
+	// Lines are folded in full, so the pattern's literal text is folded the same
+	// way; regexes still ignore case for what the folding leaves alone
+	if config.CaseFolder != nil && config.IgnoreCase {
+		matcher.folder = config.CaseFolder
+		if config.FixedStrings || isLiteralPattern(pattern) {
+			matcher.literal = config.CaseFolder.Fold(pattern)
+			return matcher, nil
+		}
+		pattern = foldLiterals(pattern, config.CaseFolder)
+	}
+
+	// Fixed strings never touch the regex engine, folding case like Engine does
+	if config.FixedStrings {
+		matcher.literal = pattern
+		if config.IgnoreCase {
+			matcher.literal = strings.ToLower(pattern)
+			matcher.foldCase = true
+		}
+		return matcher, nil
+	}
+
+	// Case-sensitive literals never need the regex engine
+	if isLiteralPattern(pattern) && !config.IgnoreCase {
+		matcher.literal = pattern
+		return matcher, nil
+	}
+
+	expr := boundaryPattern(pattern, config.WordRegexp, config.LineRegexp)
+	var err error
+	if config.RegexCaching {
+		matcher.regex, err = CompileWithCache(expr, config.IgnoreCase)
+	} else {
+		if config.IgnoreCase {
+			expr = "(?i)" + expr
+		}
+		matcher.regex, err = regexp.Compile(expr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !config.IgnoreCase {
+		matcher.required = prefilterLiterals(matcher.regex)
+	}
+
+	return matcher, nil
+}
+
+// foldLiterals case folds the literal text of a regex, leaving its operators
+// alone. An invalid regex is returned unchanged for compiling to report.
+func foldLiterals(expr string, folder *AdvancedCaseFolding) string {
+	parsed, err := syntax.Parse(expr, syntax.Perl)
+	if err != nil {
+		return expr
+	}
+	var fold func(re *syntax.Regexp)
+	fold = func(re *syntax.Regexp) {
+		if re.Op == syntax.OpLiteral {
+			re.Rune = []rune(folder.Fold(string(re.Rune)))
+		}
+		for _, sub := range re.Sub {
+			fold(sub)
+		}
+	}
+	fold(parsed)
+	return parsed.String()
+}
+
+// prefilterLiterals returns the literals one of which every match of re
+// contains, so lines without any can be rejected before running the regex
+// engine. This pays off even when the engine skips ahead to a literal prefix,
+// as a substring search costs a fraction of setting up a match. It returns
+// nil when the literals could only be compared ignoring case.
+func prefilterLiterals(re *regexp.Regexp) []string {
+	parsed, err := syntax.Parse(re.String(), syntax.Perl)
+	if err != nil || foldsCase(parsed) {
+		return nil
+	}
+	literals := requiredLiterals(parsed.Simplify())
+	if slices.Contains(literals, "") {
+		return nil
+	}
+	return literals
+}
+
+// foldsCase reports whether any part of re matches without regard to case
+func foldsCase(re *syntax.Regexp) bool {
+	if re.Flags&syntax.FoldCase != 0 {
+		return true
+	}
+	return slices.ContainsFunc(re.Sub, foldsCase)
+}
+
+// mayMatch reports whether line contains one of the required literals, so
+// that the regex can match it at all
+func (m *lineMatcher) mayMatch(line string) bool {
+	if m.required == nil {
+		return true
+	}
+	for _, literal := range m.required {
+		if strings.Contains(line, literal) {
+			return true
+		}
+	}
+	return false
+}
+
+// findAll returns the [start, end) byte offsets of every match in line
+func (m *lineMatcher) findAll(line string) [][]int {
+	if m.transformsLine(line) {
+		normalized := m.normalizer.normalizeLine(line, m.folder)
+		return normalized.originalSpans(m.findSpans(normalized.text))
+	}
+	return m.findSpans(line)
+}
+
+// transformsLine reports whether line differs from the text patterns are
+// matched against, so it has to be normalized or folded first
+func (m *lineMatcher) transformsLine(line string) bool {
+	return m.folder != nil || m.normalizer != nil && !m.normalizer.form.IsNormalString(line)
+}
+
+// findSpans is findAll for a line already in normal form
+func (m *lineMatcher) findSpans(line string) [][]int {
+	if m.pcre != nil {
+		return m.pcre.FindAllStringIndex(line, -1)
+	}
+	if m.regex != nil {
+		if !m.mayMatch(line) {
+			return nil
+		}
+		return m.regex.FindAllStringIndex(line, -1)
+	}
+
+	if m.literal == "" {
+		return nil
+	}
+	if m.foldCase && isASCII(line) {
+		return m.literalSpans(strings.ToLower(line))
+	}
+	if m.foldCase {
+		lowered := lowerLine(line)
+		return lowered.originalSpans(m.literalSpans(lowered.text))
+	}
+	return m.literalSpans(line)
+}
+
+// literalSpans returns the spans of the literal in line that satisfy the
+// word or line mode
+func (m *lineMatcher) literalSpans(line string) [][]int {
+	var spans [][]int
+	offset := 0
+	for {
+		idx := strings.Index(line[offset:], m.literal)
+		if idx == -1 {
+			break
+		}
+		start := offset + idx
+		if !isBoundedMatch(line, start, start+len(m.literal), m.wordRegexp, m.lineRegexp) {
+			offset = start + 1
+			continue
+		}
+		spans = append(spans, []int{start, start + len(m.literal)})
+		offset = start + len(m.literal)
+	}
+	return spans
+}
+
+// submatches returns the text of each capture group of the match starting at
+// start in line, "" for groups that did not take part. Literals have none.
+func (m *lineMatcher) submatches(line string, start int) []string {
+	if m.transformsLine(line) {
+		normalized := m.normalizer.normalizeLine(line, m.folder)
+		line, start = normalized.text, normalized.normalizedOffset(start)
+	}
+
+	var locs [][]int
+	switch {
+	case m.pcre != nil:
+		locs = m.pcre.FindAllStringSubmatchIndex(line, -1)
+	case m.regex != nil && m.mayMatch(line):
+		locs = m.regex.FindAllStringSubmatchIndex(line, -1)
+	}
+
+	for _, loc := range locs {
+		if loc[0] != start || len(loc) == 2 {
+			continue
+		}
+		groups := make([]string, len(loc)/2-1)
+		for i := range groups {
+			if loc[2*i+2] >= 0 {
+				groups[i] = line[loc[2*i+2]:loc[2*i+3]]
+			}
+		}
+		return groups
+	}
+	return nil
+}
+
+// matches reports whether line contains at least one match
+func (m *lineMatcher) matches(line string) bool {
+	if m.folder != nil {
+		return len(m.findAll(line)) > 0
+	}
+	if m.normalizer != nil {
+		line = m.normalizer.Normalize(line)
+	}
+	if m.pcre != nil {
+		return m.pcre.MatchString(line)
+	}
+	if m.regex != nil {
+		return m.mayMatch(line) && m.regex.MatchString(line)
+	}
+	if m.wordRegexp || m.lineRegexp {
+		return len(m.findAll(line)) > 0
+	}
+	if m.foldCase && isASCII(line) {
+		line = strings.ToLower(line)
+	} else if m.foldCase {
+		line = lowerLine(line).text
+	}
+	return m.literal != "" && strings.Contains(line, m.literal)
+}
+
+// lowerLine lowercases line a rune at a time like strings.ToLower, except
+// that invalid bytes are kept as they are. Lowering can change how many bytes
+// a rune takes, so the result remembers where each of its bytes came from.
+func lowerLine(line string) normalizedLine {
+	lowered := normalizedLine{length: len(line)}
+	var text strings.Builder
+	text.Grow(len(line))
+	for start := 0; start < len(line); {
+		r, size := utf8.DecodeRuneInString(line[start:])
+		end := start + size
+		if r == utf8.RuneError && size == 1 {
+			text.WriteByte(line[start])
+		} else {
+			text.WriteRune(unicode.ToLower(r))
+		}
+		for len(lowered.starts) < text.Len() {
+			lowered.starts = append(lowered.starts, start)
+			lowered.ends = append(lowered.ends, end)
+		}
+		start = end
+	}
+	lowered.text = text.String()
+	return lowered
+}
+
+// isASCII reports whether line is plain ASCII, which lowers byte for byte
+func isASCII(line string) bool {
+	for i := 0; i < len(line); i++ {
+		if line[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// boundaryPattern wraps a regex so it only matches whole words or whole lines.
+// Line mode takes precedence when both are set.
+func boundaryPattern(expr string, word, line bool) string {
+	switch {
+	case line:
+		return "^(?:" + expr + ")$"
+	case word:
+		return `\b(?:` + expr + `)\b`
+	}
+	return expr
+}
+
+// isBoundedMatch reports whether the literal match text[start:end] satisfies
+// the word or line mode, with the same semantics as boundaryPattern
+func isBoundedMatch[T string | []byte](text T, start, end int, word, line bool) bool {
+	switch {
+	case line:
+		return start == 0 && end == len(text)
+	case word:
+		return isWordBoundary(text, start) && isWordBoundary(text, end)
+	}
+	return true
+}
+
+// isWordBoundary reports whether \b holds at offset i, using ASCII word
+// characters like the regexp package
+func isWordBoundary[T string | []byte](text T, i int) bool {
+	before := i > 0 && isWordByte(text[i-1])
+	after := i < len(text) && isWordByte(text[i])
+	return before != after
+}
+
+// isWordByte reports whether b is an ASCII word character [0-9A-Za-z_]
+func isWordByte(b byte) bool {
+	return b == '_' || '0' <= b && b <= '9' || 'a' <= b && b <= 'z' || 'A' <= b && b <= 'Z'
+}
+
+// combinePatterns joins several regex patterns into one alternation. A single
+// pattern is returned unchanged.
+func combinePatterns(patterns []string) string {
+	if len(patterns) == 1 {
+		return patterns[0]
+	}
+
+	alternatives := make([]string, len(patterns))
+	for i, pattern := range patterns {
+		alternatives[i] = "(?:" + pattern + ")"
+	}
+	return strings.Join(alternatives, "|")
+}
+
+// newPatternMatchers compiles each of the combined patterns on its own, so
+// matches can be traced back to the pattern that produced them
+func newPatternMatchers(config SearchConfig) ([]*lineMatcher, error) {
+	if len(config.Patterns) < 2 {
+		return nil, nil
+	}
+
+	matchers := make([]*lineMatcher, len(config.Patterns))
+	for i, pattern := range config.Patterns {
+		matcher, err := newLineMatcher(pattern, config)
+		if err != nil {
+			return nil, err
+		}
+		matchers[i] = matcher
+	}
+	return matchers, nil
+}
+
+// patternIndex returns the index of the first pattern with a match starting
+// where match does. Like the alternation it came from, earlier patterns win
+// when several match at the same place.
+func patternIndex(matchers []*lineMatcher, match Match) int {
+	for i, matcher := range matchers {
+		for _, span := range matcher.findAll(match.Content) {
+			if span[0] == match.MatchStart {
+				return i
+			}
+		}
+	}
+	return 0
+}
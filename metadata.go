@@ -0,0 +1,65 @@
+package goripgrep
+
+import "path/filepath"
+
+// metadataMatches matches the file's base name and extended attribute values.
+// Column is the 1-indexed byte offset of the first match within the name or value.
+func metadataMatches(matcher *lineMatcher, filePath string) []Match {
+	var matches []Match
+
+	if match, ok := fileNameMatch(matcher, filePath, false); ok {
+		matches = append(matches, match)
+	}
+
+	attrs, err := readXattrs(filePath)
+	if err != nil {
+		// Unreadable or unsupported attributes only hide metadata matches
+		return matches
+	}
+	for _, attr := range attrs {
+		if spans := matcher.findAll(attr.value); len(spans) > 0 {
+			matches = append(matches, Match{
+				File:       filePath,
+				Column:     spans[0][0] + 1,
+				Content:    attr.value,
+				MatchStart: spans[0][0],
+				MatchEnd:   spans[0][1],
+				Spans:      newSpans(spans),
+				Kind:       MatchXattr,
+				Attribute:  attr.name,
+			})
+		}
+	}
+
+	return matches
+}
+
+// fileNameMatch matches the base name of filePath. When invert is set a name
+// without any match is reported instead, at column 1.
+func fileNameMatch(matcher *lineMatcher, filePath string, invert bool) (Match, bool) {
+	name := filepath.Base(filePath)
+	spans := matcher.findAll(name)
+	if (len(spans) > 0) == invert {
+		return Match{}, false
+	}
+
+	span := []int{0, 0}
+	if len(spans) > 0 {
+		span = spans[0]
+	}
+	return Match{
+		File:       filePath,
+		Column:     span[0] + 1,
+		Content:    name,
+		MatchStart: span[0],
+		MatchEnd:   span[1],
+		Spans:      newSpans(spans),
+		Kind:       MatchFileName,
+	}, true
+}
+
+// xattr is a single extended attribute of a file
+type xattr struct {
+	name  string
+	value string
+}
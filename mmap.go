@@ -0,0 +1,89 @@
+package goripgrep
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"runtime/debug"
+)
+
+// errMapUnsupported is returned by mapFile where files cannot be mapped, so
+// callers read them instead
+var errMapUnsupported = errors.New("memory mapping is not supported on this platform")
+
+// errMapTruncated is returned when the mapped file was truncated after it
+// was mapped and the pages past its new end were touched
+var errMapTruncated = errors.New("mapped file was truncated")
+
+// mappedFile is a read-only memory mapping of a whole file, made with mmap
+// on Unix and a file mapping view on Windows
+type mappedFile struct {
+	data []byte
+}
+
+// mapFile maps the first size bytes of file read-only
+func mapFile(file *os.File, size int64) (*mappedFile, error) {
+	if size <= 0 || int64(int(size)) != size {
+		return nil, fmt.Errorf("cannot map %d bytes", size)
+	}
+	data, err := mmap(file, int(size))
+	if err != nil {
+		return nil, err
+	}
+	return &mappedFile{data: data}, nil
+}
+
+// Len returns the number of bytes mapped
+func (m *mappedFile) Len() int {
+	return len(m.data)
+}
+
+// ReadAt copies from the mapping at off, like os.File.ReadAt
+func (m *mappedFile) ReadAt(p []byte, off int64) (n int, err error) {
+	if off >= int64(len(m.data)) {
+		return 0, io.EOF
+	}
+	err = recoverFault(func() {
+		n = copy(p, m.data[off:])
+	})
+	if err != nil {
+		return 0, err
+	}
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// contents copies the whole mapping into a string
+func (m *mappedFile) contents() (content string, err error) {
+	err = recoverFault(func() {
+		content = string(m.data)
+	})
+	return content, err
+}
+
+// Close unmaps the file
+func (m *mappedFile) Close() error {
+	if m.data == nil {
+		return nil
+	}
+	err := munmap(m.data)
+	m.data = nil
+	return err
+}
+
+// recoverFault runs fn, which touches mapped memory. Pages past the end of a
+// file truncated after it was mapped fault when touched; the fault is
+// recovered and reported as errMapTruncated instead of crashing the process.
+func recoverFault(fn func()) (err error) {
+	defer debug.SetPanicOnFault(debug.SetPanicOnFault(true))
+	defer func() {
+		if recover() != nil {
+			err = errMapTruncated
+		}
+	}()
+	fn()
+	return nil
+}
@@ -0,0 +1,18 @@
+//go:build !linux && !darwin && !windows
+
+package goripgrep
+
+import "os"
+
+// mmapSupported reports whether large files can be memory mapped here
+const mmapSupported = false
+
+// mmap reports that files cannot be mapped here, so they are read instead
+func mmap(file *os.File, size int) ([]byte, error) {
+	return nil, errMapUnsupported
+}
+
+// munmap has nothing to unmap
+func munmap(data []byte) error {
+	return nil
+}
@@ -0,0 +1,78 @@
+package goripgrep
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMappedFileReadAt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mapped.txt")
+	if err := os.WriteFile(path, []byte("0123456789"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	mapping, err := mapFile(file, 10)
+	if err == errMapUnsupported {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatalf("mapFile failed: %v", err)
+	}
+	defer mapping.Close()
+
+	p := make([]byte, 4)
+	if n, err := mapping.ReadAt(p, 3); n != 4 || err != nil || string(p) != "3456" {
+		t.Errorf("Expected 4 bytes 3456, got %d bytes %q, %v", n, p[:n], err)
+	}
+	if n, err := mapping.ReadAt(p, 8); n != 2 || err != io.EOF || string(p[:n]) != "89" {
+		t.Errorf("Expected 2 bytes 89 and EOF, got %d bytes %q, %v", n, p[:n], err)
+	}
+	if n, err := mapping.ReadAt(p, 10); n != 0 || err != io.EOF {
+		t.Errorf("Expected EOF at the end, got %d bytes, %v", n, err)
+	}
+	if content, err := mapping.contents(); content != "0123456789" || err != nil {
+		t.Errorf("Expected the whole file, got %q, %v", content, err)
+	}
+
+	if _, err := mapFile(file, 0); err == nil {
+		t.Error("Expected an error mapping an empty range")
+	}
+}
+
+func TestMappedFileTruncated(t *testing.T) {
+	size := 3 * os.Getpagesize()
+	path := filepath.Join(t.TempDir(), "mapped.log")
+	if err := os.WriteFile(path, []byte(strings.Repeat("x", size)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	mapping, err := mapFile(file, int64(size))
+	if err != nil {
+		t.Skipf("mmap unavailable: %v", err)
+	}
+	defer mapping.Close()
+
+	// Windows refuses to truncate a mapped file
+	if err := os.Truncate(path, 0); err != nil {
+		t.Skipf("Cannot truncate a mapped file: %v", err)
+	}
+	if _, err := mapping.contents(); err != errMapTruncated {
+		t.Errorf("Expected copying a mapping of a truncated file to fail, got %v", err)
+	}
+	if _, err := mapping.ReadAt(make([]byte, 16), int64(size/2)); err != errMapTruncated {
+		t.Errorf("Expected reading past the new end to fail, got %v", err)
+	}
+}
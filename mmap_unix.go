@@ -0,0 +1,22 @@
+//go:build linux || darwin
+
+package goripgrep
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// mmapSupported reports whether large files can be memory mapped here
+const mmapSupported = true
+
+// mmap maps size bytes of file privately and read-only
+func mmap(file *os.File, size int) ([]byte, error) {
+	return unix.Mmap(int(file.Fd()), 0, size, unix.PROT_READ, unix.MAP_PRIVATE)
+}
+
+// munmap unmaps data returned by mmap
+func munmap(data []byte) error {
+	return unix.Munmap(data)
+}
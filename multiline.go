@@ -0,0 +1,97 @@
+package goripgrep
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+)
+
+// compileMultilineRegex compiles pattern for searching whole buffers. The (?m)
+// flag makes ^ and $ match at line boundaries.
+func compileMultilineRegex(pattern string, ignoreCase bool) (*regexp.Regexp, error) {
+	flags := "(?m)"
+	if ignoreCase {
+		flags = "(?im)"
+	}
+
+	return regexp.Compile(flags + pattern)
+}
+
+// multilineMatches runs re over a whole buffer and converts every match into a
+// Match covering the lines it spans. firstLine is the line number of data[0].
+func multilineMatches(filePath string, data []byte, re *regexp.Regexp, firstLine int) []Match {
+	return multilineMatchesFromSpans(filePath, data, re.FindAllIndex(data, -1), firstLine)
+}
+
+// multilineMatchesFromSpans converts [start, end) spans found in data into matches
+func multilineMatchesFromSpans(filePath string, data []byte, spans [][]int, firstLine int) []Match {
+	var matches []Match
+
+	line := firstLine
+	counted := 0 // Newlines in data[:counted] are already reflected in line
+
+	for _, span := range spans {
+		start, end := span[0], span[1]
+
+		line += bytes.Count(data[counted:start], []byte{'\n'})
+		counted = start
+
+		// A match that ends with a newline does not spill onto the following line
+		last := end
+		if last > start && data[last-1] == '\n' {
+			last--
+		}
+
+		lineStart := bytes.LastIndexByte(data[:start], '\n') + 1
+		lineEnd := len(data)
+		if idx := bytes.IndexByte(data[last:], '\n'); idx != -1 {
+			lineEnd = last + idx
+		}
+
+		matches = append(matches, Match{
+			File:       filePath,
+			Line:       line,
+			EndLine:    line + bytes.Count(data[start:last], []byte{'\n'}),
+			Column:     start - lineStart + 1,
+			Content:    string(data[lineStart:lineEnd]),
+			MatchStart: start - lineStart,
+			MatchEnd:   last - lineStart,
+			Spans:      newSpans([][]int{{start - lineStart, last - lineStart}}),
+		})
+	}
+
+	return matches
+}
+
+// invertMultilineMatches returns a single-line result for every line of data
+// that is not covered by any of the given matches
+func invertMultilineMatches(filePath string, data []byte, matches []Match, firstLine int) []Match {
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if len(data) == 0 {
+		return nil
+	}
+
+	covered := make([]bool, len(lines))
+	for _, match := range matches {
+		for line := match.Line; line <= match.EndLine; line++ {
+			if idx := line - firstLine; idx >= 0 && idx < len(covered) {
+				covered[idx] = true
+			}
+		}
+	}
+
+	var inverted []Match
+	for i, line := range lines {
+		if covered[i] {
+			continue
+		}
+		inverted = append(inverted, Match{
+			File:    filePath,
+			Line:    firstLine + i,
+			EndLine: firstLine + i,
+			Column:  1,
+			Content: line,
+		})
+	}
+	return inverted
+}
@@ -0,0 +1,179 @@
+package goripgrep
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMultilineMatches(t *testing.T) {
+	data := []byte("package main\n\nfunc main() {\n\treturn\n}\n")
+	re, err := compileMultilineRegex(`func main\(\) \{\n\s+return`, false)
+	if err != nil {
+		t.Fatalf("Failed to compile pattern: %v", err)
+	}
+
+	matches := multilineMatches("main.go", data, re, 1)
+	if len(matches) != 1 {
+		t.Fatalf("Expected 1 match, got %d", len(matches))
+	}
+
+	match := matches[0]
+	if match.Line != 3 || match.EndLine != 4 {
+		t.Errorf("Expected lines 3-4, got %d-%d", match.Line, match.EndLine)
+	}
+	if match.Column != 1 {
+		t.Errorf("Expected column 1, got %d", match.Column)
+	}
+	if match.Content != "func main() {\n\treturn" {
+		t.Errorf("Unexpected content %q", match.Content)
+	}
+
+	// A trailing newline does not extend the match onto the next line
+	re, err = compileMultilineRegex(`^\treturn\n`, false)
+	if err != nil {
+		t.Fatalf("Failed to compile pattern: %v", err)
+	}
+	matches = multilineMatches("main.go", data, re, 1)
+	if len(matches) != 1 || matches[0].Line != 4 || matches[0].EndLine != 4 {
+		t.Errorf("Expected single-line match on line 4, got %+v", matches)
+	}
+}
+
+func TestSurroundingLines(t *testing.T) {
+	lines := []string{"a", "b", "c", "d", "e", "f"}
+
+	before, after := surroundingLines(lines, 2, 3, 1, 1)
+	if strings.Join(before, ",") != "b" || strings.Join(after, ",") != "e" {
+		t.Errorf("Expected [b] and [e], got %v and %v", before, after)
+	}
+
+	before, after = surroundingLines(lines, 2, 3, 10, 0)
+	if strings.Join(before, ",") != "a,b" || after != nil {
+		t.Errorf("Expected [a b] and no after context, got %v and %v", before, after)
+	}
+
+	before, after = surroundingLines(lines, 0, 0, 2, 10)
+	if before != nil || strings.Join(after, ",") != "b,c,d,e,f" {
+		t.Errorf("Expected no before context and [b c d e f], got %v and %v", before, after)
+	}
+}
+
+func TestFindMultiline(t *testing.T) {
+	tempDir := t.TempDir()
+	content := "first\nsecond\nthird\nFIRST\nSECOND\n"
+	if err := os.WriteFile(filepath.Join(tempDir, "test.txt"), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	// Without multiline mode the pattern never matches a single line
+	results, err := Find(`first\nsecond`, tempDir)
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	if results.Count() != 0 {
+		t.Errorf("Expected no matches without multiline mode, got %d", results.Count())
+	}
+
+	results, err = Find(`first\nsecond`, tempDir, WithMultiline(), WithIgnoreCase(), WithContextLines(1))
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	if results.Count() != 2 {
+		t.Fatalf("Expected 2 matches, got %d", results.Count())
+	}
+
+	expected := [][2]int{{1, 2}, {4, 5}}
+	for i, match := range results.Matches {
+		if match.Line != expected[i][0] || match.EndLine != expected[i][1] {
+			t.Errorf("Match %d: expected lines %v, got %d-%d", i, expected[i], match.Line, match.EndLine)
+		}
+	}
+
+	if len(results.Matches[0].BeforeContext) != 0 {
+		t.Errorf("Expected no before context, got %v", results.Matches[0].BeforeContext)
+	}
+	if got := strings.Join(results.Matches[0].AfterContext, ","); got != "third" {
+		t.Errorf("Expected after context [third], got %v", results.Matches[0].AfterContext)
+	}
+}
+
+func TestEngineMultilineSearch(t *testing.T) {
+	testFile := filepath.Join(t.TempDir(), "test.txt")
+	content := "begin\nmiddle\nend\nbegin\nend\n"
+	if err := os.WriteFile(testFile, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	multiline := true
+	engine, err := NewEngine(SearchArgs{
+		Pattern:   `begin\n(middle\n)?end`,
+		Multiline: &multiline,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+
+	matches, err := engine.Search(context.Background(), testFile)
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+
+	if len(matches) != 2 {
+		t.Fatalf("Expected 2 matches, got %d", len(matches))
+	}
+	if matches[0].Line != 1 || matches[0].EndLine != 3 {
+		t.Errorf("Expected first match on lines 1-3, got %d-%d", matches[0].Line, matches[0].EndLine)
+	}
+	if matches[1].Line != 4 || matches[1].EndLine != 5 {
+		t.Errorf("Expected second match on lines 4-5, got %d-%d", matches[1].Line, matches[1].EndLine)
+	}
+}
+
+func TestSlidingWindowSearcherMultiline(t *testing.T) {
+	// Many small records so that matches straddle chunk boundaries
+	var builder strings.Builder
+	for i := 0; i < 200; i++ {
+		fmt.Fprintf(&builder, "record %d\nstatus: ok\n", i)
+	}
+
+	tmpFile, err := createTempFile(builder.String())
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpFile)
+
+	options := DefaultSlidingWindowOptions()
+	options.ChunkSize = 64
+	options.MinChunkSize = 64
+	options.OverlapSize = 32
+	options.UseMemoryMap = false
+	options.Multiline = true
+
+	searcher, err := NewSlidingWindowSearcher(tmpFile, `record \d+\nstatus`, options)
+	if err != nil {
+		t.Fatalf("Failed to create searcher: %v", err)
+	}
+	defer searcher.Close()
+
+	matches, err := searcher.Search(context.Background())
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+
+	if len(matches) != 200 {
+		t.Fatalf("Expected 200 matches, got %d", len(matches))
+	}
+
+	for i, match := range matches {
+		if match.Line != 2*i+1 || match.EndLine != 2*i+2 {
+			t.Errorf("Match %d: expected lines %d-%d, got %d-%d", i, 2*i+1, 2*i+2, match.Line, match.EndLine)
+		}
+		if want := fmt.Sprintf("record %d\nstatus: ok", i); match.Content != want {
+			t.Errorf("Match %d: expected content %q, got %q", i, want, match.Content)
+		}
+	}
+}
@@ -98,9 +98,58 @@ func TestOptimizedEngine(t *testing.T) {
 		if results["word_optimized"] != 12 {
 			t.Errorf("Expected to find 'o' at position 12, got %d", results["word_optimized"])
 		}
+		if results["vectorized"] != 12 {
+			t.Errorf("Expected the vectorized scan to find 'o' at position 12, got %d", results["vectorized"])
+		}
 	})
 }
 
+func TestOptimizedEngineScanPaths(t *testing.T) {
+	vector := NewOptimizedEngine()
+	vector.vectorized = true
+	word := NewOptimizedEngine()
+	word.vectorized = false
+
+	if vectorScan && vector.scanPath() == "word" {
+		t.Errorf("Expected a vector scan path, got %q", vector.scanPath())
+	}
+	if word.scanPath() != "word" || word.GetCapabilities()["VECTOR_SCAN"] {
+		t.Errorf("Expected the word scan path, got %q", word.scanPath())
+	}
+
+	// Every offset from the start and end of the data, across the unaligned
+	// prefix, whole words and the tail, and past the 32 bytes of a vector
+	data := bytes.Repeat([]byte("abcdefghijklmnopqrstuvwxyz012345"), 4)
+	data = append(data, "needle\nhaystack\n"...)
+	for start := 0; start < 40; start++ {
+		for end := len(data) - 20; end <= len(data); end++ {
+			chunk := data[start:end]
+			for _, target := range []byte{'a', '5', 'n', '\n', 'Z'} {
+				want := bytes.IndexByte(chunk, target)
+				if got := word.FastIndexByte(chunk, target); got != want {
+					t.Fatalf("Word FastIndexByte(%q, %q) = %d, want %d", chunk, target, got, want)
+				}
+				if got := vector.FastIndexByte(chunk, target); got != want {
+					t.Fatalf("Vector FastIndexByte(%q, %q) = %d, want %d", chunk, target, got, want)
+				}
+			}
+			for _, pattern := range []string{"needle", "z01", "5abc", "stack\n", "x", "missing", ""} {
+				want := bytes.Index(chunk, []byte(pattern))
+				if got := word.FastIndex(chunk, []byte(pattern)); got != want {
+					t.Fatalf("Word FastIndex(%q, %q) = %d, want %d", chunk, pattern, got, want)
+				}
+				if got := vector.FastIndex(chunk, []byte(pattern)); got != want {
+					t.Fatalf("Vector FastIndex(%q, %q) = %d, want %d", chunk, pattern, got, want)
+				}
+			}
+			want := bytes.Count(chunk, []byte{'\n'})
+			if word.FastCountLines(chunk) != want || vector.FastCountLines(chunk) != want {
+				t.Fatalf("FastCountLines(%q) = %d and %d, want %d", chunk, word.FastCountLines(chunk), vector.FastCountLines(chunk), want)
+			}
+		}
+	}
+}
+
 func TestDFACache(t *testing.T) {
 	cache := NewDFACache(10, 5*time.Minute)
 
@@ -284,7 +333,7 @@ Testing optimization performance with various patterns.
 
 		// Check that context lines are included
 		for _, match := range matches {
-			if len(match.Context) == 0 {
+			if len(match.BeforeContext)+len(match.AfterContext) == 0 {
 				t.Error("Expected context lines to be included")
 			}
 		}
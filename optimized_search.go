@@ -1,29 +1,34 @@
 package goripgrep
 
 import (
+	"bytes"
 	"runtime"
 	"unsafe"
 
 	"golang.org/x/sys/cpu"
 )
 
-// OptimizedEngine provides high-performance search operations using pure Go optimizations
+// OptimizedEngine provides high-performance byte scanning. On amd64 and arm64
+// scans run on the standard library's vector assembly; elsewhere, or when
+// built with the purego tag, they use word-level Go.
 type OptimizedEngine struct {
-	hasAVX2   bool // CPU feature detection for potential future use
-	hasSSE42  bool
-	hasNEON   bool
-	wordSize  int
-	chunkSize int
+	hasAVX2    bool
+	hasSSE42   bool
+	hasNEON    bool
+	vectorized bool // Scans use the standard library's vector assembly
+	wordSize   int
+	chunkSize  int
 }
 
 // NewOptimizedEngine creates a new optimized search engine with CPU feature detection
 func NewOptimizedEngine() *OptimizedEngine {
 	engine := &OptimizedEngine{
-		wordSize:  8,  // 64-bit words
-		chunkSize: 64, // Process 64 bytes at a time for optimal performance
+		vectorized: vectorScan,
+		wordSize:   8,  // 64-bit words
+		chunkSize:  64, // Process 64 bytes at a time for optimal performance
 	}
 
-	// Detect CPU features for potential future optimizations
+	// Detect CPU features to report the vector path in use
 	switch runtime.GOARCH {
 	case "amd64":
 		engine.hasAVX2 = cpu.X86.HasAVX2
@@ -35,8 +40,13 @@ func NewOptimizedEngine() *OptimizedEngine {
 	return engine
 }
 
-// FastIndexByte performs optimized byte search using word-level operations and bit manipulation
+// FastIndexByte returns the index of the first target byte in data, or -1,
+// scanning with vector instructions where available and word-level
+// operations otherwise
 func (e *OptimizedEngine) FastIndexByte(data []byte, target byte) int {
+	if e.vectorized {
+		return bytes.IndexByte(data, target)
+	}
 	if len(data) == 0 {
 		return -1
 	}
@@ -55,6 +65,33 @@ func (e *OptimizedEngine) FastIndexByte(data []byte, target byte) int {
 	return -1
 }
 
+// FastIndex returns the index of the first instance of pattern in data, or
+// -1. Without vector instructions candidates are found by scanning for the
+// pattern's rarest byte.
+func (e *OptimizedEngine) FastIndex(data, pattern []byte) int {
+	if e.vectorized || len(pattern) <= 1 {
+		return bytes.Index(data, pattern)
+	}
+
+	rare, rareIdx := rarestByte(pattern)
+	pos := rareIdx
+	for pos < len(data) {
+		idx := e.FastIndexByte(data[pos:], rare)
+		if idx == -1 {
+			return -1
+		}
+		start := pos + idx - rareIdx
+		if start+len(pattern) > len(data) {
+			return -1
+		}
+		if bytes.Equal(data[start:start+len(pattern)], pattern) {
+			return start
+		}
+		pos += idx + 1
+	}
+	return -1
+}
+
 // indexByteWordOptimized uses word-level operations and bit manipulation for fast byte searching
 func (e *OptimizedEngine) indexByteWordOptimized(data []byte, target byte) int {
 	if len(data) == 0 {
@@ -135,8 +172,12 @@ func (e *OptimizedEngine) hasZeroByte(word uint64) bool {
 	return (word-0x0101010101010101)&^word&0x8080808080808080 != 0
 }
 
-// FastCountLines performs optimized newline counting using word-level operations
+// FastCountLines counts the newlines in data, with vector instructions where
+// available and word-level operations otherwise
 func (e *OptimizedEngine) FastCountLines(data []byte) int {
+	if e.vectorized {
+		return bytes.Count(data, []byte{'\n'})
+	}
 	if len(data) == 0 {
 		return 0
 	}
@@ -221,11 +262,39 @@ func (e *OptimizedEngine) GetCapabilities() map[string]bool {
 		"BIT_MANIPULATION": true,                                                   // Bit manipulation optimizations
 		"MEMORY_ALIGNMENT": true,                                                   // Memory alignment optimizations
 		"ARCH_OPTIMIZED":   runtime.GOARCH == "amd64" || runtime.GOARCH == "arm64", // Architecture-specific optimizations
-		"AVX2_DETECTED":    e.hasAVX2,                                              // CPU feature detection (for future use)
+		"AVX2_DETECTED":    e.hasAVX2,                                              // CPU features detected
 		"SSE42_DETECTED":   e.hasSSE42,
 		"NEON_DETECTED":    e.hasNEON,
-		"PURE_GO":          true, // This is pure Go implementation
+		"VECTOR_SCAN":      e.vectorized, // Scans run on the standard library's vector assembly
+		"PURE_GO":          true,         // goripgrep itself has no assembly
+	}
+}
+
+// scanPath names the byte scanner in use: the vector instruction set the
+// standard library's assembly picks on this CPU, or "word" for word-level Go
+func (e *OptimizedEngine) scanPath() string {
+	switch {
+	case !e.vectorized:
+		return "word"
+	case e.hasNEON:
+		return "neon"
+	case e.hasAVX2:
+		return "avx2"
 	}
+	return "sse2"
+}
+
+// simdLevel names the widest vector instruction set detected, or "none"
+func (e *OptimizedEngine) simdLevel() string {
+	switch {
+	case e.hasAVX2:
+		return "avx2"
+	case e.hasSSE42:
+		return "sse4.2"
+	case e.hasNEON:
+		return "neon"
+	}
+	return "none"
 }
 
 // BenchmarkMethods provides performance comparison between different search methods
@@ -235,6 +304,9 @@ func (e *OptimizedEngine) BenchmarkMethods(data []byte, target byte) map[string]
 	// Optimized word-level implementation
 	results["word_optimized"] = e.indexByteWordOptimized(data, target)
 
+	// Standard library, vectorized on amd64 and arm64
+	results["vectorized"] = bytes.IndexByte(data, target)
+
 	// Simple byte-by-byte for comparison
 	results["byte_by_byte"] = e.simpleIndexByte(data, target)
 
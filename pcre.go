@@ -0,0 +1,868 @@
+package goripgrep
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ErrPCRE2Syntax is returned for patterns that use lookaround or
+// backreferences, which Go's regexp cannot match, unless WithPCRE2Syntax is set
+var ErrPCRE2Syntax = errors.New("lookaround and backreferences require PCRE2 syntax")
+
+// pcreStepLimit bounds the work of finding one match so pathological
+// patterns give up on a line instead of backtracking forever
+const pcreStepLimit = 1 << 20
+
+// usesPCRE2Syntax reports whether pattern contains lookahead, lookbehind or a
+// backreference outside of character classes
+func usesPCRE2Syntax(pattern string) bool {
+	inClass := false
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case c == '\\' && i+1 < len(pattern):
+			next := pattern[i+1]
+			if !inClass && ('1' <= next && next <= '9' || next == 'k' && i+2 < len(pattern) && (pattern[i+2] == '<' || pattern[i+2] == '{')) {
+				return true
+			}
+			i++
+		case inClass:
+			if c == ']' {
+				inClass = false
+			}
+		case c == '[':
+			inClass = true
+			// A ] straight after [ or [^ is a literal member of the class
+			if i+1 < len(pattern) && pattern[i+1] == '^' {
+				i++
+			}
+			if i+1 < len(pattern) && pattern[i+1] == ']' {
+				i++
+			}
+		case c == '(':
+			rest := pattern[i+1:]
+			for _, prefix := range []string{"?=", "?!", "?<=", "?<!", "?P="} {
+				if strings.HasPrefix(rest, prefix) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// compileRegex compiles expr with Go's regexp, or with the backtracking
+// engine when pcre2 is set and expr needs it. Patterns that need it while
+// pcre2 is unset fail with ErrPCRE2Syntax.
+func compileRegex(expr string, pcre2 bool) (*regexp.Regexp, *pcreRegexp, error) {
+	if usesPCRE2Syntax(expr) {
+		if !pcre2 {
+			return nil, nil, fmt.Errorf("%w: %s", ErrPCRE2Syntax, expr)
+		}
+		re, err := compilePCRE(expr)
+		return nil, re, err
+	}
+	re, err := regexp.Compile(expr)
+	return re, nil, err
+}
+
+// pcreRegexp is a backtracking regex supporting lookahead, lookbehind and
+// backreferences on top of the RE2 syntax Go's regexp accepts. Matching
+// follows the same leftmost-first rules; only its speed guarantees differ.
+type pcreRegexp struct {
+	expr   string
+	root   pcreNode
+	groups int // Number of capture groups
+}
+
+// compilePCRE parses expr into a backtracking regex
+func compilePCRE(expr string) (*pcreRegexp, error) {
+	p := &pcreParser{src: expr, names: make(map[string]int)}
+	root, err := p.parseAlternate()
+	if err == nil && p.pos < len(p.src) {
+		err = p.errorf("unexpected )")
+	}
+	if err == nil {
+		err = p.resolveBackrefs()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &pcreRegexp{expr: expr, root: root, groups: p.groups}, nil
+}
+
+// String returns the source expression
+func (re *pcreRegexp) String() string {
+	return re.expr
+}
+
+// MatchString reports whether s contains a match
+func (re *pcreRegexp) MatchString(s string) bool {
+	return re.find(s, 0) != nil
+}
+
+// FindAllStringIndex returns the [start, end) offsets of up to n successive
+// matches in s (all of them if n is negative), like regexp.Regexp does
+func (re *pcreRegexp) FindAllStringIndex(s string, n int) [][]int {
+	matches := re.FindAllStringSubmatchIndex(s, n)
+	for i, loc := range matches {
+		matches[i] = loc[:2]
+	}
+	return matches
+}
+
+// FindAllStringSubmatchIndex is FindAllStringIndex with the offsets of each
+// capture group following those of the match, -1 for groups that did not
+// take part, like regexp.Regexp does
+func (re *pcreRegexp) FindAllStringSubmatchIndex(s string, n int) [][]int {
+	var matches [][]int
+	prevEnd := -1
+	for pos := 0; pos <= len(s) && (n < 0 || len(matches) < n); {
+		loc := re.find(s, pos)
+		if loc == nil {
+			break
+		}
+		// Empty matches right after the previous match are skipped
+		if loc[1] > loc[0] || loc[0] != prevEnd {
+			matches = append(matches, loc)
+		}
+		prevEnd = loc[1]
+
+		pos = loc[1]
+		if loc[1] == loc[0] {
+			if pos == len(s) {
+				break
+			}
+			_, size := utf8.DecodeRuneInString(s[pos:])
+			pos += size
+		}
+	}
+	return matches
+}
+
+// FindAllIndex is FindAllStringIndex for byte slices
+func (re *pcreRegexp) FindAllIndex(b []byte, n int) [][]int {
+	return re.FindAllStringIndex(string(b), n)
+}
+
+// find returns the offsets of the leftmost match in s starting at or after
+// start followed by those of its capture groups, or nil if there is none or
+// the step limit is reached first
+func (re *pcreRegexp) find(s string, start int) []int {
+	m := &pcreMachine{input: s, caps: make([]int, 2*re.groups)}
+	for pos := start; pos <= len(s); {
+		for i := range m.caps {
+			m.caps[i] = -1
+		}
+		end := -1
+		if re.root.match(m, pos, func(p int) bool { end = p; return true }) {
+			return append([]int{pos, end}, m.caps...)
+		}
+		if pos == len(s) {
+			break
+		}
+		_, size := utf8.DecodeRuneInString(s[pos:])
+		pos += size
+	}
+	return nil
+}
+
+// pcreMachine holds the state of one match attempt
+type pcreMachine struct {
+	input string
+	caps  []int // Start and end of each capture group, -1 when unset
+	steps int
+}
+
+// step counts work done, reporting false once the step limit is reached
+func (m *pcreMachine) step() bool {
+	m.steps++
+	return m.steps <= pcreStepLimit
+}
+
+// pcreNode matches part of a pattern at pos, calling next with the position
+// after each way it can match until next reports success
+type pcreNode interface {
+	match(m *pcreMachine, pos int, next func(int) bool) bool
+	// maxWidth is the longest match in bytes, or -1 if unbounded
+	maxWidth() int
+}
+
+// pcreRune matches a single rune
+type pcreRune struct {
+	accepts func(rune) bool
+}
+
+func (n *pcreRune) match(m *pcreMachine, pos int, next func(int) bool) bool {
+	if pos >= len(m.input) || !m.step() {
+		return false
+	}
+	r, size := utf8.DecodeRuneInString(m.input[pos:])
+	return n.accepts(r) && next(pos+size)
+}
+
+func (n *pcreRune) maxWidth() int { return utf8.UTFMax }
+
+// pcreConcat matches its nodes one after another
+type pcreConcat []pcreNode
+
+func (n pcreConcat) match(m *pcreMachine, pos int, next func(int) bool) bool {
+	if len(n) == 0 {
+		return next(pos)
+	}
+	return n[0].match(m, pos, func(p int) bool {
+		return n[1:].match(m, p, next)
+	})
+}
+
+func (n pcreConcat) maxWidth() int {
+	total := 0
+	for _, sub := range n {
+		width := sub.maxWidth()
+		if width < 0 {
+			return -1
+		}
+		total += width
+	}
+	return total
+}
+
+// pcreAlternate tries each alternative in order
+type pcreAlternate []pcreNode
+
+func (n pcreAlternate) match(m *pcreMachine, pos int, next func(int) bool) bool {
+	for _, sub := range n {
+		if sub.match(m, pos, next) {
+			return true
+		}
+	}
+	return false
+}
+
+func (n pcreAlternate) maxWidth() int {
+	widest := 0
+	for _, sub := range n {
+		width := sub.maxWidth()
+		if width < 0 {
+			return -1
+		}
+		widest = max(widest, width)
+	}
+	return widest
+}
+
+// pcreRepeat matches sub between min and max times (max -1 for no limit)
+type pcreRepeat struct {
+	sub      pcreNode
+	min, max int
+	lazy     bool
+}
+
+func (n *pcreRepeat) match(m *pcreMachine, pos int, next func(int) bool) bool {
+	return n.matchFrom(m, pos, 0, next)
+}
+
+func (n *pcreRepeat) matchFrom(m *pcreMachine, pos, count int, next func(int) bool) bool {
+	if n.max >= 0 && count == n.max {
+		return next(pos)
+	}
+	more := func() bool {
+		return n.sub.match(m, pos, func(p int) bool {
+			// An empty iteration cannot lead anywhere new
+			if p == pos && count >= n.min {
+				return false
+			}
+			return n.matchFrom(m, p, count+1, next)
+		})
+	}
+	switch {
+	case count < n.min:
+		return more()
+	case n.lazy:
+		return next(pos) || more()
+	default:
+		return more() || next(pos)
+	}
+}
+
+func (n *pcreRepeat) maxWidth() int {
+	width := n.sub.maxWidth()
+	if n.max < 0 || width < 0 {
+		return -1
+	}
+	return width * n.max
+}
+
+// pcreCapture records where sub matched as a numbered group
+type pcreCapture struct {
+	sub   pcreNode
+	index int
+}
+
+func (n *pcreCapture) match(m *pcreMachine, pos int, next func(int) bool) bool {
+	start, end := &m.caps[2*n.index-2], &m.caps[2*n.index-1]
+	oldStart, oldEnd := *start, *end
+	if n.sub.match(m, pos, func(p int) bool {
+		prevStart, prevEnd := *start, *end
+		*start, *end = pos, p
+		if next(p) {
+			return true
+		}
+		*start, *end = prevStart, prevEnd
+		return false
+	}) {
+		return true
+	}
+	*start, *end = oldStart, oldEnd
+	return false
+}
+
+func (n *pcreCapture) maxWidth() int { return n.sub.maxWidth() }
+
+// pcreBackref matches the text last captured by a group. A group that has
+// not matched makes the backreference fail, as in PCRE.
+type pcreBackref struct {
+	index    int
+	name     string // Set for \k<name> until the name is resolved
+	foldCase bool
+}
+
+func (n *pcreBackref) match(m *pcreMachine, pos int, next func(int) bool) bool {
+	start, end := m.caps[2*n.index-2], m.caps[2*n.index-1]
+	if start < 0 || !m.step() {
+		return false
+	}
+	captured := m.input[start:end]
+	if !n.foldCase {
+		if !strings.HasPrefix(m.input[pos:], captured) {
+			return false
+		}
+		return next(pos + len(captured))
+	}
+
+	p := pos
+	for _, want := range captured {
+		if p >= len(m.input) {
+			return false
+		}
+		got, size := utf8.DecodeRuneInString(m.input[p:])
+		if !equalFoldRune(want, got) {
+			return false
+		}
+		p += size
+	}
+	return next(p)
+}
+
+func (n *pcreBackref) maxWidth() int { return -1 }
+
+// pcreAssertion is a zero-width test of the position
+type pcreAssertion struct {
+	test func(s string, pos int) bool
+}
+
+func (n *pcreAssertion) match(m *pcreMachine, pos int, next func(int) bool) bool {
+	return n.test(m.input, pos) && next(pos)
+}
+
+func (n *pcreAssertion) maxWidth() int { return 0 }
+
+// pcreLookaround checks whether sub matches ahead of pos, or ending at pos
+// for lookbehind, without consuming any input
+type pcreLookaround struct {
+	sub    pcreNode
+	behind bool
+	negate bool
+	width  int // maxWidth of sub, bounding how far back lookbehind starts
+}
+
+func (n *pcreLookaround) match(m *pcreMachine, pos int, next func(int) bool) bool {
+	saved := append([]int(nil), m.caps...)
+
+	found := false
+	if n.behind {
+		first := 0
+		if n.width >= 0 {
+			first = max(0, pos-n.width)
+		}
+		for start := pos; start >= first && !found; start-- {
+			if start < len(m.input) && !utf8.RuneStart(m.input[start]) {
+				continue
+			}
+			found = n.sub.match(m, start, func(p int) bool { return p == pos })
+		}
+	} else {
+		found = n.sub.match(m, pos, func(int) bool { return true })
+	}
+
+	if found == n.negate {
+		copy(m.caps, saved)
+		return false
+	}
+	if n.negate {
+		// Groups inside a negative lookaround never keep a value
+		copy(m.caps, saved)
+	}
+	if next(pos) {
+		return true
+	}
+	copy(m.caps, saved)
+	return false
+}
+
+func (n *pcreLookaround) maxWidth() int { return 0 }
+
+// equalFoldRune reports whether a and b are equal under simple case folding
+func equalFoldRune(a, b rune) bool {
+	if a == b {
+		return true
+	}
+	for f := unicode.SimpleFold(a); f != a; f = unicode.SimpleFold(f) {
+		if f == b {
+			return true
+		}
+	}
+	return false
+}
+
+// pcreFlags are the inline flags in effect while parsing
+type pcreFlags struct {
+	foldCase  bool // i
+	multiLine bool // m: ^ and $ match at line breaks
+	dotNL     bool // s: . matches \n
+	ungreedy  bool // U: swap the meaning of x* and x*?
+}
+
+// pcreParser builds the node tree of a pattern, parsing single characters,
+// escapes and classes with regexp/syntax so they mean exactly what they mean
+// to Go's regexp
+type pcreParser struct {
+	src      string
+	pos      int
+	flags    pcreFlags
+	groups   int
+	names    map[string]int
+	backrefs []*pcreBackref
+}
+
+// errorf reports a syntax error at the current position
+func (p *pcreParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("invalid PCRE2 pattern at offset %d: %s", p.pos, fmt.Sprintf(format, args...))
+}
+
+// parseAlternate parses alternatives up to a closing parenthesis or the end
+func (p *pcreParser) parseAlternate() (pcreNode, error) {
+	var alternatives pcreAlternate
+	for {
+		concat, err := p.parseConcat()
+		if err != nil {
+			return nil, err
+		}
+		alternatives = append(alternatives, concat)
+		if p.pos >= len(p.src) || p.src[p.pos] != '|' {
+			break
+		}
+		p.pos++
+	}
+	if len(alternatives) == 1 {
+		return alternatives[0], nil
+	}
+	return alternatives, nil
+}
+
+// parseConcat parses repeated atoms up to |, ) or the end
+func (p *pcreParser) parseConcat() (pcreNode, error) {
+	var concat pcreConcat
+	for p.pos < len(p.src) && p.src[p.pos] != '|' && p.src[p.pos] != ')' {
+		atom, err := p.parseAtom()
+		if err != nil {
+			return nil, err
+		}
+		if atom == nil {
+			continue // Flag groups such as (?i) match nothing themselves
+		}
+		if atom, err = p.parseRepeat(atom); err != nil {
+			return nil, err
+		}
+		concat = append(concat, atom)
+	}
+	if len(concat) == 1 {
+		return concat[0], nil
+	}
+	return concat, nil
+}
+
+// repeatCount matches the {n}, {n,} and {n,m} counted repetitions
+var repeatCount = regexp.MustCompile(`^\{(\d+)(,(\d*))?\}`)
+
+// parseRepeat applies any quantifiers following atom
+func (p *pcreParser) parseRepeat(atom pcreNode) (pcreNode, error) {
+	for p.pos < len(p.src) {
+		repeat := &pcreRepeat{sub: atom, max: -1}
+		switch p.src[p.pos] {
+		case '*':
+			p.pos++
+		case '+':
+			repeat.min = 1
+			p.pos++
+		case '?':
+			repeat.max = 1
+			p.pos++
+		case '{':
+			groups := repeatCount.FindStringSubmatch(p.src[p.pos:])
+			if groups == nil {
+				return atom, nil // A brace that is not a count is a literal
+			}
+			repeat.min, _ = strconv.Atoi(groups[1])
+			repeat.max = repeat.min
+			if groups[2] != "" {
+				repeat.max = -1
+				if groups[3] != "" {
+					repeat.max, _ = strconv.Atoi(groups[3])
+				}
+			}
+			if repeat.min > 1000 || repeat.max > 1000 || (repeat.max >= 0 && repeat.max < repeat.min) {
+				return nil, p.errorf("invalid repeat count %s", groups[0])
+			}
+			p.pos += len(groups[0])
+		default:
+			return atom, nil
+		}
+
+		if _, ok := atom.(*pcreRepeat); ok {
+			return nil, p.errorf("invalid nested repetition operator")
+		}
+		repeat.lazy = p.flags.ungreedy
+		if p.pos < len(p.src) && p.src[p.pos] == '?' {
+			repeat.lazy = !repeat.lazy
+			p.pos++
+		}
+		atom = repeat
+	}
+	return atom, nil
+}
+
+// parseAtom parses a single character, class, escape, anchor or group. It
+// returns nil for groups that only set flags and empty \Q\E quotes.
+func (p *pcreParser) parseAtom() (pcreNode, error) {
+	switch c := p.src[p.pos]; c {
+	case '(':
+		return p.parseGroup()
+	case '*', '+', '?':
+		return nil, p.errorf("missing argument to repetition operator %c", c)
+	case '^':
+		p.pos++
+		if p.flags.multiLine {
+			return &pcreAssertion{test: func(s string, pos int) bool { return pos == 0 || s[pos-1] == '\n' }}, nil
+		}
+		return &pcreAssertion{test: func(s string, pos int) bool { return pos == 0 }}, nil
+	case '$':
+		p.pos++
+		if p.flags.multiLine {
+			return &pcreAssertion{test: func(s string, pos int) bool { return pos == len(s) || s[pos] == '\n' }}, nil
+		}
+		return &pcreAssertion{test: func(s string, pos int) bool { return pos == len(s) }}, nil
+	case '[':
+		end, err := p.classEnd()
+		if err != nil {
+			return nil, err
+		}
+		return p.parseRune(end)
+	case '\\':
+		return p.parseEscape()
+	}
+
+	_, size := utf8.DecodeRuneInString(p.src[p.pos:])
+	return p.parseRune(p.pos + size)
+}
+
+// parseRune compiles p.src[p.pos:end], which matches exactly one rune, with
+// regexp/syntax under the current flags
+func (p *pcreParser) parseRune(end int) (pcreNode, error) {
+	text := p.src[p.pos:end]
+	flags := syntax.Perl
+	if p.flags.foldCase {
+		flags |= syntax.FoldCase
+	}
+	if p.flags.dotNL {
+		flags |= syntax.DotNL
+	}
+	re, err := syntax.Parse(text, flags)
+	if err != nil {
+		return nil, err
+	}
+	p.pos = end
+
+	switch re.Op {
+	case syntax.OpLiteral:
+		if len(re.Rune) == 1 {
+			r := re.Rune[0]
+			if re.Flags&syntax.FoldCase != 0 {
+				return &pcreRune{accepts: func(c rune) bool { return equalFoldRune(r, c) }}, nil
+			}
+			return &pcreRune{accepts: func(c rune) bool { return c == r }}, nil
+		}
+	case syntax.OpCharClass:
+		ranges := re.Rune
+		return &pcreRune{accepts: func(c rune) bool {
+			for i := 0; i < len(ranges); i += 2 {
+				if ranges[i] <= c && c <= ranges[i+1] {
+					return true
+				}
+			}
+			return false
+		}}, nil
+	case syntax.OpAnyChar:
+		return &pcreRune{accepts: func(rune) bool { return true }}, nil
+	case syntax.OpAnyCharNotNL:
+		return &pcreRune{accepts: func(c rune) bool { return c != '\n' }}, nil
+	case syntax.OpEmptyMatch, syntax.OpNoMatch:
+		// Classes that cannot match anything, like [^\x00-\x{10FFFF}]
+		return &pcreRune{accepts: func(rune) bool { return false }}, nil
+	}
+	return nil, fmt.Errorf("invalid PCRE2 pattern: unsupported element %q", text)
+}
+
+// classEnd returns the offset just past the character class starting at
+// p.pos, skipping escapes and named classes like [:alpha:]
+func (p *pcreParser) classEnd() (int, error) {
+	i := p.pos + 1
+	if i < len(p.src) && p.src[i] == '^' {
+		i++
+	}
+	if i < len(p.src) && p.src[i] == ']' {
+		i++
+	}
+	for i < len(p.src) {
+		switch {
+		case p.src[i] == '\\':
+			i += 2
+		case strings.HasPrefix(p.src[i:], "[:"):
+			if end := strings.Index(p.src[i+2:], ":]"); end >= 0 {
+				i += end + 4
+			} else {
+				i++
+			}
+		case p.src[i] == ']':
+			return i + 1, nil
+		default:
+			i++
+		}
+	}
+	return 0, p.errorf("missing closing ]")
+}
+
+// parseEscape parses a backslash escape
+func (p *pcreParser) parseEscape() (pcreNode, error) {
+	if p.pos+1 >= len(p.src) {
+		return nil, p.errorf("trailing backslash at end of expression")
+	}
+
+	switch c := p.src[p.pos+1]; {
+	case '1' <= c && c <= '9':
+		end := p.pos + 1
+		for end < len(p.src) && '0' <= p.src[end] && p.src[end] <= '9' {
+			end++
+		}
+		index, _ := strconv.Atoi(p.src[p.pos+1 : end])
+		p.pos = end
+		return p.backref(index, ""), nil
+	case c == 'k':
+		if p.pos+2 < len(p.src) {
+			closing := map[byte]byte{'<': '>', '{': '}', '\'': '\''}[p.src[p.pos+2]]
+			if end := strings.IndexByte(p.src[p.pos+3:], closing); closing != 0 && end > 0 {
+				name := p.src[p.pos+3 : p.pos+3+end]
+				p.pos += end + 4
+				return p.backref(0, name), nil
+			}
+		}
+		return nil, p.errorf("invalid named backreference")
+	case c == 'b' || c == 'B':
+		p.pos += 2
+		word := c == 'b'
+		return &pcreAssertion{test: func(s string, pos int) bool { return isWordBoundary(s, pos) == word }}, nil
+	case c == 'A':
+		p.pos += 2
+		return &pcreAssertion{test: func(s string, pos int) bool { return pos == 0 }}, nil
+	case c == 'z':
+		p.pos += 2
+		return &pcreAssertion{test: func(s string, pos int) bool { return pos == len(s) }}, nil
+	case c == 'Q':
+		// \Q...\E quotes everything up to \E or the end; it is expanded in
+		// place so a following quantifier applies to the last character only
+		start := p.pos + 2
+		end, next := len(p.src), len(p.src)
+		if i := strings.Index(p.src[start:], `\E`); i >= 0 {
+			end, next = start+i, start+i+2
+		}
+		p.src = p.src[:p.pos] + regexp.QuoteMeta(p.src[start:end]) + p.src[next:]
+		if p.pos >= len(p.src) || p.src[p.pos] == '|' || p.src[p.pos] == ')' {
+			return nil, nil
+		}
+		return p.parseAtom()
+	}
+
+	// Other escapes, such as \d, \pL, \p{Greek}, \x{263a} or \n, match one rune
+	end := p.pos + 2
+	switch p.src[p.pos+1] {
+	case 'p', 'P', 'x':
+		if end < len(p.src) && p.src[end] == '{' {
+			if brace := strings.IndexByte(p.src[end:], '}'); brace >= 0 {
+				end += brace + 1
+			}
+		} else if p.src[p.pos+1] == 'x' {
+			end = min(end+2, len(p.src))
+		} else if end < len(p.src) {
+			_, size := utf8.DecodeRuneInString(p.src[end:])
+			end += size
+		}
+	case '0':
+		for end < len(p.src) && end < p.pos+4 && '0' <= p.src[end] && p.src[end] <= '7' {
+			end++
+		}
+	default:
+		_, size := utf8.DecodeRuneInString(p.src[p.pos+1:])
+		end = p.pos + 1 + size
+	}
+	return p.parseRune(end)
+}
+
+// backref records a numbered or named backreference, checked once every
+// group is known
+func (p *pcreParser) backref(index int, name string) pcreNode {
+	ref := &pcreBackref{index: index, name: name, foldCase: p.flags.foldCase}
+	p.backrefs = append(p.backrefs, ref)
+	return ref
+}
+
+// resolveBackrefs checks every backreference names an existing group
+func (p *pcreParser) resolveBackrefs() error {
+	for _, ref := range p.backrefs {
+		if ref.name != "" {
+			index, ok := p.names[ref.name]
+			if !ok {
+				return fmt.Errorf("invalid PCRE2 pattern: reference to unknown group %q", ref.name)
+			}
+			ref.index = index
+		}
+		if ref.index > p.groups {
+			return fmt.Errorf("invalid PCRE2 pattern: reference to non-existent group %d", ref.index)
+		}
+	}
+	return nil
+}
+
+// parseGroup parses a parenthesized group: capturing, named, non-capturing,
+// a lookaround, a (?P=name) backreference, or inline flags
+func (p *pcreParser) parseGroup() (pcreNode, error) {
+	start := p.pos
+	p.pos++
+	rest := p.src[p.pos:]
+
+	var lookaround *pcreLookaround
+	index := 0
+	switch {
+	case strings.HasPrefix(rest, "?="), strings.HasPrefix(rest, "?!"):
+		lookaround = &pcreLookaround{negate: rest[1] == '!'}
+		p.pos += 2
+	case strings.HasPrefix(rest, "?<="), strings.HasPrefix(rest, "?<!"):
+		lookaround = &pcreLookaround{behind: true, negate: rest[2] == '!'}
+		p.pos += 3
+	case strings.HasPrefix(rest, "?P="):
+		end := strings.IndexByte(rest, ')')
+		if end < 0 {
+			return nil, p.errorf("missing closing )")
+		}
+		p.pos += end + 1
+		return p.backref(0, rest[3:end]), nil
+	case strings.HasPrefix(rest, "?P<"), strings.HasPrefix(rest, "?<"):
+		open := strings.IndexByte(rest, '<')
+		end := strings.IndexByte(rest, '>')
+		if end < 0 {
+			return nil, p.errorf("invalid named capture")
+		}
+		name := rest[open+1 : end]
+		if name == "" || !isAlphaNumeric(name) {
+			return nil, p.errorf("invalid named capture %q", name)
+		}
+		if _, ok := p.names[name]; ok {
+			return nil, p.errorf("duplicate capture group name %q", name)
+		}
+		p.groups++
+		index = p.groups
+		p.names[name] = index
+		p.pos += end + 1
+	case strings.HasPrefix(rest, "?"):
+		// Inline flags, either for the rest of the group as in (?i) or for a
+		// non-capturing group as in (?i:...) and (?:...)
+		flags := p.flags
+		enable := true
+		for i := 1; i < len(rest); i++ {
+			switch rest[i] {
+			case 'i':
+				flags.foldCase = enable
+			case 'm':
+				flags.multiLine = enable
+			case 's':
+				flags.dotNL = enable
+			case 'U':
+				flags.ungreedy = enable
+			case '-':
+				if !enable {
+					return nil, p.errorf("invalid flags")
+				}
+				enable = false
+			case ')':
+				p.flags = flags
+				p.pos += i + 1
+				return nil, nil
+			case ':':
+				outer := p.flags
+				p.flags = flags
+				p.pos += i + 1
+				sub, err := p.parseGroupBody(start)
+				p.flags = outer
+				return sub, err
+			default:
+				return nil, p.errorf("invalid or unsupported group syntax %q", p.src[start:p.pos+i+1])
+			}
+		}
+		return nil, p.errorf("missing closing )")
+	default:
+		p.groups++
+		index = p.groups
+	}
+
+	outer := p.flags
+	sub, err := p.parseGroupBody(start)
+	p.flags = outer
+	if err != nil {
+		return nil, err
+	}
+	if lookaround != nil {
+		lookaround.sub = sub
+		lookaround.width = sub.maxWidth()
+		return lookaround, nil
+	}
+	return &pcreCapture{sub: sub, index: index}, nil
+}
+
+// parseGroupBody parses the alternatives of a group and its closing
+// parenthesis; start is where the group opened
+func (p *pcreParser) parseGroupBody(start int) (pcreNode, error) {
+	sub, err := p.parseAlternate()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos >= len(p.src) || p.src[p.pos] != ')' {
+		p.pos = start
+		return nil, p.errorf("missing closing )")
+	}
+	p.pos++
+	return sub, nil
+}
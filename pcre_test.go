@@ -0,0 +1,151 @@
+package goripgrep
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+func TestPCRERegexpMatchesGoRegexp(t *testing.T) {
+	// Without lookaround or backreferences both engines must agree
+	patterns := []string{
+		`foo`, `a+`, `a*?b`, `(a|ab)(c|bcd)`, `\bfunc\s+(\w+)`, `[^a-c]+`, `[]a]`, `^\s*#`,
+		`x{2,3}`, `(?i)hello`, `(?i:é)T`, `\p{Greek}+`, `\d{3}-\d{4}$`, `a|`, `(?U)a+`, `.`,
+		`[[:alpha:]]+`, `\Qa.b\E+`, `\x41\x{42}`, `{`, `a{,2}`,
+	}
+	inputs := []string{
+		"foo bar food", "aaab aab b", "abcd abc", "func main() { func x() }", "xyzabc",
+		"a]b", "  # comment", "xxxxx", "Say HELLO", "ÉT éT", "αβγ abc δ", "555-1234",
+		"", "héllo wörld", "A.bb a.bbb AB", "a{,2}",
+	}
+
+	for _, pattern := range patterns {
+		want := regexp.MustCompile(pattern)
+		got, err := compilePCRE(pattern)
+		if err != nil {
+			t.Errorf("compilePCRE(%q) failed: %v", pattern, err)
+			continue
+		}
+		for _, input := range inputs {
+			expected := fmt.Sprint(want.FindAllStringIndex(input, -1))
+			if actual := fmt.Sprint(got.FindAllStringIndex(input, -1)); actual != expected {
+				t.Errorf("Pattern %q on %q: expected %s, got %s", pattern, input, expected, actual)
+			}
+		}
+	}
+}
+
+func TestPCRERegexpFeatures(t *testing.T) {
+	tests := []struct {
+		pattern string
+		input   string
+		want    string
+	}{
+		{`\w+(?=\()`, "x = call(y) + f (z)", "[[4 8]]"},
+		{`foo(?!bar)`, "foobar foobaz", "[[7 10]]"},
+		{`(?<=\$)\d+`, "cost $100, 200 or $3", "[[6 9] [19 20]]"},
+		{`(?<!\$)\b\d+`, "cost $100, 200", "[[11 14]]"},
+		{`(?<=ab|c)x`, "abx cx dx", "[[2 3] [5 6]]"},
+		{`\b(\w+) \1\b`, "the the cat sat sat", "[[0 7] [12 19]]"},
+		{`(?i)(\w+) \1`, "The the", "[[0 7]]"},
+		{`(?<q>['"]).*?\k<q>`, `say "it's" now`, "[[4 10]]"},
+		{`(?P<x>a)(?P=x)`, "aa ab", "[[0 2]]"},
+		{`(a)|\1b`, "b ab", "[[2 3]]"},
+		{`^(?=.*\d)(?=.*[a-z]).{6,}$`, "abc123", "[[0 6]]"},
+		{`^(?=.*\d)(?=.*[a-z]).{6,}$`, "abcdef", "[]"},
+		{`(?=a)`, "aa", "[[0 0] [1 1]]"},
+	}
+
+	for _, test := range tests {
+		re, err := compilePCRE(test.pattern)
+		if err != nil {
+			t.Errorf("compilePCRE(%q) failed: %v", test.pattern, err)
+			continue
+		}
+		if got := fmt.Sprint(re.FindAllStringIndex(test.input, -1)); got != test.want {
+			t.Errorf("Pattern %q on %q: expected %s, got %s", test.pattern, test.input, test.want, got)
+		}
+	}
+
+	for _, pattern := range []string{`(a`, `a)`, `\2(a)`, `\k<nope>(a)`, `(?<x>a)(?<x>b)`, `*a`, `a**`, `[a`, `(?z)`} {
+		if _, err := compilePCRE(pattern); err == nil {
+			t.Errorf("Expected compilePCRE(%q) to fail", pattern)
+		}
+	}
+}
+
+func TestUsesPCRE2Syntax(t *testing.T) {
+	tests := map[string]bool{
+		`a(?=b)`: true, `a(?!b)`: true, `(?<=a)b`: true, `(?<!a)b`: true, `(a)\1`: true,
+		`(?<n>a)\k<n>`: true, `(?P<n>a)(?P=n)`: true,
+		`(?<n>a)`: false, `(?:a)`: false, `\\1`: false, `[\1]`: false, `[(?=]`: false, `a\.b`: false,
+	}
+	for pattern, want := range tests {
+		if got := usesPCRE2Syntax(pattern); got != want {
+			t.Errorf("usesPCRE2Syntax(%q) = %v, want %v", pattern, got, want)
+		}
+	}
+}
+
+func TestFindPCRE2Syntax(t *testing.T) {
+	root := t.TempDir()
+	writeTestFiles(t, root, map[string]string{
+		"code.go": "x := call(y)\nthe the typo\nprice := \"$100\"\n",
+	})
+
+	if _, err := Find(`\w+(?=\()`, root); !errors.Is(err, ErrPCRE2Syntax) {
+		t.Fatalf("Expected ErrPCRE2Syntax without WithPCRE2Syntax, got %v", err)
+	}
+
+	results, err := Find(`\w+(?=\()`, root, WithPCRE2Syntax())
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	if len(results.Matches) != 1 || results.Matches[0].Line != 1 || results.Matches[0].Column != 6 {
+		t.Errorf("Expected the lookahead to match call on line 1, got %+v", results.Matches)
+	}
+	if spans := fmt.Sprint(results.Matches[0].Spans); spans != "[{5 9}]" {
+		t.Errorf("Expected the match to exclude the lookahead, got spans %s", spans)
+	}
+
+	results, err = Find(`(\w+) \1`, root, WithPCRE2Syntax(), WithIgnoreCase(), WithWordRegexp())
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	if len(results.Matches) != 1 || results.Matches[0].Line != 2 {
+		t.Errorf("Expected the backreference to match line 2, got %+v", results.Matches)
+	}
+
+	results, err = Find(`(?<!\$)\d+`, root, WithPCRE2Syntax(), WithInvertMatch())
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	if len(results.Matches) != 2 {
+		t.Errorf("Expected the lines without an unprefixed number inverted, got %+v", results.Matches)
+	}
+
+	// Plain patterns keep using Go's regexp
+	if results, err := Find(`call\(`, root, WithPCRE2Syntax()); err != nil || len(results.Matches) != 1 {
+		t.Errorf("Expected a plain pattern to match once, got %v, %v", results, err)
+	}
+
+	if _, err := Find(`a(?=b)`, root, WithPCRE2Syntax(), WithMultiline()); err == nil {
+		t.Error("Expected PCRE2 syntax to be rejected in multiline mode")
+	}
+	if _, err := Replace(`(?<=\$)\d+`, "0", filepath.Join(root, "code.go"), WithPCRE2Syntax(), WithDryRun()); err == nil {
+		t.Error("Expected Replace to reject PCRE2 syntax")
+	}
+
+	engine, err := NewEngine(SearchArgs{Pattern: `(?<=\$)\d+`, PCRE2: &[]bool{true}[0]})
+	if err != nil {
+		t.Fatalf("NewEngine failed: %v", err)
+	}
+	if spans := engine.findSpans([]byte(`price := "$100"`)); fmt.Sprint(spans) != "[[11 14]]" {
+		t.Errorf("Expected the engine to use the lookbehind, got %v", spans)
+	}
+	if _, err := NewEngine(SearchArgs{Pattern: `(?<=\$)\d+`}); !errors.Is(err, ErrPCRE2Syntax) {
+		t.Errorf("Expected NewEngine to return ErrPCRE2Syntax, got %v", err)
+	}
+}
@@ -0,0 +1,233 @@
+package goripgrep
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Stage is a single post-processing step applied to a set of matches
+type Stage func(matches []Match) []Match
+
+// Transform runs the matches through the given stages in order and returns
+// a new SearchResults. The receiver is left untouched.
+func (r *SearchResults) Transform(stages ...Stage) *SearchResults {
+	matches := make([]Match, len(r.Matches))
+	copy(matches, r.Matches)
+
+	for _, stage := range stages {
+		if stage != nil {
+			matches = stage(matches)
+		}
+	}
+
+	transformed := *r
+	transformed.Matches = matches
+	return &transformed
+}
+
+// Dedupe removes matches that share the same file, line and column,
+// keeping the first occurrence
+func Dedupe() Stage {
+	return func(matches []Match) []Match {
+		type matchKey struct {
+			file   string
+			line   int
+			column int
+		}
+
+		seen := make(map[matchKey]bool, len(matches))
+		deduped := make([]Match, 0, len(matches))
+
+		for _, match := range matches {
+			key := matchKey{match.File, match.Line, match.Column}
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			deduped = append(deduped, match)
+		}
+
+		return deduped
+	}
+}
+
+// Redact masks every occurrence of re in the match content, matched text, capture
+// groups and context lines. Each masked character is replaced with a single '*', so
+// line numbers, RuneColumn and columns counted in runes stay valid. MatchStart,
+// MatchEnd and Spans are remapped to the redacted content; a Column counted in bytes
+// still refers to the original line.
+func Redact(re *regexp.Regexp) Stage {
+	return func(matches []Match) []Match {
+		if re == nil {
+			return matches
+		}
+
+		for i := range matches {
+			redact := func(line string) string { return redactString(re, line) }
+			content := matches[i].Content
+			// Submatches are found in the matched text, located within the
+			// content when it is kept
+			text, base, matchText := content, matches[i].MatchStart, matches[i].MatchText
+			if content == "" {
+				text, base = matchText, 0
+			} else {
+				matchText = content[matches[i].MatchStart:matches[i].MatchEnd]
+			}
+			matches[i].MatchStart = redactOffset(re, content, matches[i].MatchStart)
+			matches[i].MatchEnd = redactOffset(re, content, matches[i].MatchEnd)
+			matches[i].Spans = mapSpans(matches[i].Spans, func(offset int) int { return redactOffset(re, content, offset) })
+			matches[i].Content = redact(content)
+			if content != "" {
+				matches[i].MatchText = matches[i].Content[matches[i].MatchStart:matches[i].MatchEnd]
+			} else {
+				matches[i].MatchText = redact(matches[i].MatchText)
+			}
+			matches[i].Submatches = redactSubmatches(re, text, base, matchText, matches[i].Submatches)
+			matches[i].BeforeContext = mapLines(matches[i].BeforeContext, redact)
+			matches[i].AfterContext = mapLines(matches[i].AfterContext, redact)
+		}
+
+		return matches
+	}
+}
+
+// Truncate shortens match content and context lines to at most maxRunes
+// characters, appending "..." to lines that were cut
+func Truncate(maxRunes int) Stage {
+	return func(matches []Match) []Match {
+		if maxRunes <= 0 {
+			return matches
+		}
+
+		for i := range matches {
+			truncate := func(line string) string { return truncateString(line, maxRunes) }
+			content := truncate(matches[i].Content)
+			if content != matches[i].Content {
+				// Spans end where the cut content does, before the "..."
+				cut := len(content) - len("...")
+				matches[i].MatchStart = min(matches[i].MatchStart, cut)
+				matches[i].MatchEnd = min(matches[i].MatchEnd, cut)
+				matches[i].Spans = mapSpans(matches[i].Spans, func(offset int) int { return min(offset, cut) })
+			}
+			matches[i].Content = content
+			matches[i].BeforeContext = mapLines(matches[i].BeforeContext, truncate)
+			matches[i].AfterContext = mapLines(matches[i].AfterContext, truncate)
+		}
+
+		return matches
+	}
+}
+
+// RelativePaths rewrites match file paths relative to base. Paths that
+// cannot be made relative are left unchanged.
+func RelativePaths(base string) Stage {
+	return func(matches []Match) []Match {
+		absBase, err := filepath.Abs(base)
+		if err != nil {
+			absBase = base
+		}
+
+		for i := range matches {
+			absFile, err := filepath.Abs(matches[i].File)
+			if err != nil {
+				continue
+			}
+			if rel, err := filepath.Rel(absBase, absFile); err == nil {
+				matches[i].File = rel
+			}
+		}
+
+		return matches
+	}
+}
+
+// redactString replaces each match of re in s with a run of '*' of equal rune length
+func redactString(re *regexp.Regexp, s string) string {
+	return re.ReplaceAllStringFunc(s, func(matched string) string {
+		return strings.Repeat("*", utf8.RuneCountInString(matched))
+	})
+}
+
+// redactSubmatches masks the capture groups of a match the way the text
+// they came from was masked. matchText starts at base in text; a group that
+// cannot be located in it is masked whole, so nothing redacted leaks.
+func redactSubmatches(re *regexp.Regexp, text string, base int, matchText string, submatches []string) []string {
+	if len(submatches) == 0 {
+		return submatches
+	}
+
+	redacted := redactString(re, text)
+	masked := make([]string, len(submatches))
+	for i, group := range submatches {
+		idx := strings.Index(matchText, group)
+		if idx == -1 {
+			masked[i] = strings.Repeat("*", utf8.RuneCountInString(group))
+			continue
+		}
+		start := redactOffset(re, text, base+idx)
+		end := redactOffset(re, text, base+idx+len(group))
+		masked[i] = redacted[start:end]
+	}
+	return masked
+}
+
+// redactOffset maps a byte offset in s to the same position in s once
+// redacted, as redactString would redact it
+func redactOffset(re *regexp.Regexp, s string, offset int) int {
+	shift := 0
+	for _, loc := range re.FindAllStringIndex(s, -1) {
+		if loc[0] >= offset {
+			break
+		}
+		if offset < loc[1] {
+			// Inside a redacted run, each rune became one '*'
+			return loc[0] + shift + utf8.RuneCountInString(s[loc[0]:offset])
+		}
+		shift += utf8.RuneCountInString(s[loc[0]:loc[1]]) - (loc[1] - loc[0])
+	}
+	return offset + shift
+}
+
+// truncateString cuts s to maxRunes characters without splitting a UTF-8 sequence
+func truncateString(s string, maxRunes int) string {
+	if utf8.RuneCountInString(s) <= maxRunes {
+		return s
+	}
+
+	count := 0
+	for i := range s {
+		if count == maxRunes {
+			return s[:i] + "..."
+		}
+		count++
+	}
+	return s
+}
+
+// mapSpans applies fn to both ends of every span, returning a new slice so
+// matches that share their line's spans are left alone. Spans left empty are
+// dropped.
+func mapSpans(spans []Span, fn func(int) int) []Span {
+	var mapped []Span
+	for _, span := range spans {
+		if span = (Span{Start: fn(span.Start), End: fn(span.End)}); span.End > span.Start {
+			mapped = append(mapped, span)
+		}
+	}
+	return mapped
+}
+
+// mapLines applies fn to a copy of lines so the original slice is never modified
+func mapLines(lines []string, fn func(string) string) []string {
+	if len(lines) == 0 {
+		return lines
+	}
+
+	mapped := make([]string, len(lines))
+	for i, line := range lines {
+		mapped[i] = fn(line)
+	}
+	return mapped
+}
@@ -35,26 +35,29 @@ func SimplifiedFind(pattern, filePath string, useRegex bool) ([]Match, error) {
 		line := scanner.Text()
 
 		var found bool
-		var position int
+		var position, end int
 
 		if useRegex && compiled != nil {
 			if match := compiled.FindStringIndex(line); match != nil {
 				found = true
-				position = match[0]
+				position, end = match[0], match[1]
 			}
 		} else {
 			if pos := strings.Index(line, pattern); pos != -1 {
 				found = true
-				position = pos
+				position, end = pos, pos+len(pattern)
 			}
 		}
 
 		if found {
 			matches = append(matches, Match{
-				File:    filePath,
-				Line:    lineNum,
-				Column:  position + 1,
-				Content: line,
+				File:       filePath,
+				Line:       lineNum,
+				Column:     position + 1,
+				Content:    line,
+				MatchStart: position,
+				MatchEnd:   end,
+				Spans:      newSpans([][]int{{position, end}}),
 			})
 		}
 
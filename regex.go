@@ -3,7 +3,10 @@ package goripgrep
 import (
 	"fmt"
 	"regexp"
+	"regexp/syntax"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
 // RegexEngine provides enhanced regex capabilities
@@ -216,6 +219,80 @@ func Complexity(pattern string) int {
 	return score
 }
 
+// maxMatchLength returns the length in bytes of the longest text expr can
+// match, or -1 if unbounded repetition allows arbitrarily long matches
+func maxMatchLength(expr string) int {
+	re, err := syntax.Parse(expr, syntax.Perl)
+	if err != nil {
+		return -1
+	}
+	return maxSyntaxLength(re.Simplify())
+}
+
+// maxSyntaxLength computes maxMatchLength over a parsed expression
+func maxSyntaxLength(re *syntax.Regexp) int {
+	switch re.Op {
+	case syntax.OpNoMatch, syntax.OpEmptyMatch, syntax.OpBeginLine, syntax.OpEndLine,
+		syntax.OpBeginText, syntax.OpEndText, syntax.OpWordBoundary, syntax.OpNoWordBoundary:
+		return 0
+	case syntax.OpLiteral:
+		length := 0
+		for _, r := range re.Rune {
+			length += maxRuneLength(r, re.Flags&syntax.FoldCase != 0)
+		}
+		return length
+	case syntax.OpCharClass:
+		// Rune holds inclusive ranges; the widest encoding is at the top of a range
+		length := 0
+		for i := 1; i < len(re.Rune); i += 2 {
+			length = max(length, maxRuneLength(re.Rune[i], false))
+		}
+		return length
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		return utf8.UTFMax
+	case syntax.OpCapture, syntax.OpQuest:
+		return maxSyntaxLength(re.Sub[0])
+	case syntax.OpRepeat:
+		length := maxSyntaxLength(re.Sub[0])
+		if re.Max < 0 || length < 0 {
+			return -1
+		}
+		return length * re.Max
+	case syntax.OpConcat, syntax.OpAlternate:
+		total := 0
+		for _, sub := range re.Sub {
+			length := maxSyntaxLength(sub)
+			if length < 0 {
+				return -1
+			}
+			if re.Op == syntax.OpConcat {
+				total += length
+			} else {
+				total = max(total, length)
+			}
+		}
+		return total
+	}
+
+	// OpStar, OpPlus and anything unrecognised are unbounded
+	return -1
+}
+
+// maxRuneLength returns the UTF-8 length of r, or of the widest rune it
+// matches when case folding (the Kelvin sign K is three bytes, for example)
+func maxRuneLength(r rune, foldCase bool) int {
+	length := utf8.RuneLen(r)
+	if length < 0 {
+		length = utf8.UTFMax
+	}
+	if foldCase {
+		for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+			length = max(length, utf8.RuneLen(f))
+		}
+	}
+	return length
+}
+
 // IsLiteral checks if a pattern is a literal string (no regex metacharacters)
 func IsLiteral(pattern string) bool {
 	// Check for common regex metacharacters
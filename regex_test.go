@@ -282,6 +282,34 @@ func TestRegexEngineGetComplexity(t *testing.T) {
 	}
 }
 
+func TestMaxMatchLength(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		expected int
+	}{
+		{"hello", 5},
+		{"^hello$", 5},
+		{`\d{3}-\d{2}-\d{4}`, 11},
+		{"colou?r", 6},
+		{"cat|horse", 5},
+		{"[a-z]{2,4}", 4},
+		{"é", 2},
+		{".", 4},
+		{"(?i)k", 3}, // Folds to the three-byte Kelvin sign
+		{"a+", -1},
+		{"a{2,}", -1},
+		{"x(y|z*)", -1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.pattern, func(t *testing.T) {
+			if length := maxMatchLength(test.pattern); length != test.expected {
+				t.Errorf("maxMatchLength(%q) = %d, expected %d", test.pattern, length, test.expected)
+			}
+		})
+	}
+}
+
 func TestRegexEngineIsLiteral(t *testing.T) {
 	tests := []struct {
 		pattern  string
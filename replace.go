@@ -0,0 +1,303 @@
+package goripgrep
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// FileReplacement describes the rewrite of a single file
+type FileReplacement struct {
+	File         string
+	Replacements int
+	Diff         string // Unified diff of the rewrite without context lines
+	BackupFile   string // Path of the backup copy, empty if none was written
+}
+
+// ReplaceResults summarizes a search-and-replace run
+type ReplaceResults struct {
+	Files        []FileReplacement
+	Replacements int
+	DryRun       bool
+}
+
+// diffHunk is a run of changed lines; start is the 1-based line in the original file
+type diffHunk struct {
+	start    int
+	oldLines []string
+	newLines []string
+}
+
+// Replace rewrites every match of pattern under path with replacement.
+// The replacement may reference capture groups as $1 or ${name}. Files are
+// replaced atomically; use WithDryRun to only compute diffs and WithBackup to
+// keep the original contents. Only files found by the search are rewritten.
+// The search has no result limit unless WithMaxResults sets one, which then
+// also bounds the set of files that are touched.
+func Replace(pattern, replacement, path string, opts ...Option) (*ReplaceResults, error) {
+	options := defaultOptions()
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	expr, err := options.resolvePatterns(pattern)
+	if err != nil {
+		return nil, err
+	}
+	re, err := compileReplacePattern(expr, options)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regex pattern: %w", err)
+	}
+
+	// Find's default limit would silently leave files past it unedited
+	unlimited := append([]Option{WithMaxResults(math.MaxInt)}, opts...)
+	results, err := Find(pattern, path, unlimited...)
+	if err != nil {
+		return nil, err
+	}
+
+	replaceResults := &ReplaceResults{DryRun: options.dryRun}
+	for _, group := range results.GroupByFile() {
+		fileReplacement, err := replaceInFile(group.File, re, replacement, options)
+		if err != nil {
+			return replaceResults, fmt.Errorf("failed to rewrite %s: %w", group.File, err)
+		}
+		if fileReplacement.Replacements == 0 {
+			continue
+		}
+
+		replaceResults.Files = append(replaceResults.Files, fileReplacement)
+		replaceResults.Replacements += fileReplacement.Replacements
+	}
+
+	return replaceResults, nil
+}
+
+// Diff returns the combined unified diff of all rewritten files
+func (r *ReplaceResults) Diff() string {
+	var builder strings.Builder
+	for _, file := range r.Files {
+		builder.WriteString(file.Diff)
+	}
+	return builder.String()
+}
+
+// compileReplacePattern compiles pattern with the same semantics Find uses
+func compileReplacePattern(pattern string, options *searchOptions) (*regexp.Regexp, error) {
+	ignoreCase := options.ignoreCase
+	if options.smartCase {
+		ignoreCase = SmartCaseInsensitive(pattern, options.fixedStrings)
+	}
+	if options.fixedStrings {
+		pattern = regexp.QuoteMeta(pattern)
+	} else if usesPCRE2Syntax(pattern) {
+		if options.pcre2 {
+			return nil, fmt.Errorf("PCRE2 syntax is not supported by Replace")
+		}
+		return nil, fmt.Errorf("%w: %s", ErrPCRE2Syntax, pattern)
+	}
+	pattern = boundaryPattern(pattern, options.wordRegexp, options.lineRegexp)
+	if options.multiline {
+		return compileMultilineRegex(pattern, ignoreCase)
+	}
+	if ignoreCase {
+		pattern = "(?i)" + pattern
+	}
+	return regexp.Compile(pattern)
+}
+
+// replaceInFile applies the replacement to a single file
+func replaceInFile(filePath string, re *regexp.Regexp, replacement string, options *searchOptions) (FileReplacement, error) {
+	result := FileReplacement{File: filePath}
+
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return result, err
+	}
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return result, err
+	}
+
+	// A UTF-8 byte order mark is kept but, as in searches, not part of the first line
+	original, bom := string(data), ""
+	if stripped, ok := stripBOMString(original); ok {
+		bom = original[:len(original)-len(stripped)]
+		original = stripped
+	}
+	var updated string
+	var hunks []diffHunk
+	if options.multiline {
+		updated, result.Replacements = replaceBuffer(original, re, replacement)
+		hunks = bufferHunks(original, updated)
+	} else {
+		updated, result.Replacements, hunks = replaceLines(original, re, replacement)
+	}
+
+	if result.Replacements == 0 || updated == original {
+		return result, nil
+	}
+	result.Diff = formatDiff(filePath, hunks)
+
+	if options.dryRun {
+		return result, nil
+	}
+
+	if options.backupSuffix != "" {
+		result.BackupFile = filePath + options.backupSuffix
+		if err := writeFileAtomic(result.BackupFile, data, info.Mode().Perm()); err != nil {
+			return result, fmt.Errorf("failed to write backup: %w", err)
+		}
+	}
+
+	if err := writeFileAtomic(filePath, []byte(bom+updated), info.Mode().Perm()); err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
+
+// replaceBuffer replaces every match in the whole buffer
+func replaceBuffer(content string, re *regexp.Regexp, replacement string) (string, int) {
+	count := len(re.FindAllStringIndex(content, -1))
+	if count == 0 {
+		return content, 0
+	}
+	return re.ReplaceAllString(content, replacement), count
+}
+
+// replaceLines replaces matches one line at a time so that anchors and
+// character classes behave exactly as they do during a search
+func replaceLines(content string, re *regexp.Regexp, replacement string) (string, int, []diffHunk) {
+	var builder strings.Builder
+	var hunks []diffHunk
+	count := 0
+
+	lines := strings.SplitAfter(content, "\n")
+	for i, line := range lines {
+		if line == "" {
+			continue
+		}
+
+		// Match against the line without its terminator, like the line scanner does
+		body := strings.TrimSuffix(line, "\n")
+		body = strings.TrimSuffix(body, "\r")
+		eol := line[len(body):]
+
+		matches := len(re.FindAllStringIndex(body, -1))
+		if matches == 0 {
+			builder.WriteString(line)
+			continue
+		}
+
+		replaced := re.ReplaceAllString(body, replacement)
+		count += matches
+		builder.WriteString(replaced)
+		builder.WriteString(eol)
+
+		if replaced != body {
+			hunks = append(hunks, diffHunk{
+				start:    i + 1,
+				oldLines: []string{body},
+				newLines: strings.Split(replaced, "\n"),
+			})
+		}
+	}
+
+	return builder.String(), count, hunks
+}
+
+// bufferHunks describes a whole-buffer rewrite as a single hunk spanning the
+// lines between the common prefix and suffix
+func bufferHunks(original, updated string) []diffHunk {
+	oldLines := strings.Split(original, "\n")
+	newLines := strings.Split(updated, "\n")
+
+	prefix := 0
+	for prefix < len(oldLines) && prefix < len(newLines) && oldLines[prefix] == newLines[prefix] {
+		prefix++
+	}
+
+	suffix := 0
+	for suffix < len(oldLines)-prefix && suffix < len(newLines)-prefix &&
+		oldLines[len(oldLines)-1-suffix] == newLines[len(newLines)-1-suffix] {
+		suffix++
+	}
+
+	return []diffHunk{{
+		start:    prefix + 1,
+		oldLines: oldLines[prefix : len(oldLines)-suffix],
+		newLines: newLines[prefix : len(newLines)-suffix],
+	}}
+}
+
+// formatDiff renders hunks as a unified diff with no context lines
+func formatDiff(filePath string, hunks []diffHunk) string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "--- %s\n+++ %s\n", filePath, filePath)
+
+	offset := 0 // Line shift in the new file caused by earlier hunks
+	for _, hunk := range hunks {
+		fmt.Fprintf(&builder, "@@ -%s +%s @@\n",
+			hunkRange(hunk.start, len(hunk.oldLines)),
+			hunkRange(hunk.start+offset, len(hunk.newLines)))
+		for _, line := range hunk.oldLines {
+			fmt.Fprintf(&builder, "-%s\n", line)
+		}
+		for _, line := range hunk.newLines {
+			fmt.Fprintf(&builder, "+%s\n", line)
+		}
+		offset += len(hunk.newLines) - len(hunk.oldLines)
+	}
+
+	return builder.String()
+}
+
+// hunkRange formats a unified diff line range; empty ranges refer to the preceding line
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start-1)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+// writeFileAtomic writes data to a temporary file next to filePath and renames
+// it into place, so readers never observe a partially written file
+func writeFileAtomic(filePath string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(filePath), "."+filepath.Base(filePath)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	if err := os.Rename(tmpPath, filePath); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package goripgrep
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReplace(t *testing.T) {
+	tempDir := t.TempDir()
+	testFile := filepath.Join(tempDir, "main.go")
+	content := "fmt.Println(\"a\")\nx := 1\nfmt.Printf(\"%d\", x)\n"
+	if err := os.WriteFile(testFile, []byte(content), 0640); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	t.Run("DryRun", func(t *testing.T) {
+		results, err := Replace(`fmt\.(?P<fn>Print\w*)\(`, "log.${fn}(", tempDir, WithDryRun())
+		if err != nil {
+			t.Fatalf("Replace failed: %v", err)
+		}
+
+		if results.Replacements != 2 || len(results.Files) != 1 {
+			t.Fatalf("Expected 2 replacements in 1 file, got %d in %d", results.Replacements, len(results.Files))
+		}
+
+		expectedDiff := "--- " + testFile + "\n+++ " + testFile + "\n" +
+			"@@ -1 +1 @@\n-fmt.Println(\"a\")\n+log.Println(\"a\")\n" +
+			"@@ -3 +3 @@\n-fmt.Printf(\"%d\", x)\n+log.Printf(\"%d\", x)\n"
+		if results.Diff() != expectedDiff {
+			t.Errorf("Unexpected diff:\n%s", results.Diff())
+		}
+
+		data, _ := os.ReadFile(testFile)
+		if string(data) != content {
+			t.Error("Dry run must not modify the file")
+		}
+	})
+
+	t.Run("InPlaceWithBackup", func(t *testing.T) {
+		results, err := Replace(`fmt\.(Print\w*)\(`, "log.$1(", tempDir, WithBackup(".orig"))
+		if err != nil {
+			t.Fatalf("Replace failed: %v", err)
+		}
+
+		data, _ := os.ReadFile(testFile)
+		expected := "log.Println(\"a\")\nx := 1\nlog.Printf(\"%d\", x)\n"
+		if string(data) != expected {
+			t.Errorf("Expected rewritten content %q, got %q", expected, string(data))
+		}
+
+		backup, err := os.ReadFile(results.Files[0].BackupFile)
+		if err != nil || string(backup) != content {
+			t.Errorf("Expected backup with original content, got %q (%v)", string(backup), err)
+		}
+
+		info, err := os.Stat(testFile)
+		if err != nil || info.Mode().Perm() != 0640 {
+			t.Errorf("Expected permissions to be preserved, got %v", info.Mode().Perm())
+		}
+
+		// No temporary files are left behind
+		entries, _ := os.ReadDir(tempDir)
+		if len(entries) != 2 {
+			t.Errorf("Expected only the file and its backup, got %d entries", len(entries))
+		}
+	})
+}
+
+func TestReplaceMultiline(t *testing.T) {
+	tempDir := t.TempDir()
+	testFile := filepath.Join(tempDir, "test.txt")
+	if err := os.WriteFile(testFile, []byte("keep\nbegin\nend\nkeep\n"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	results, err := Replace(`begin\nend`, "merged", tempDir, WithMultiline())
+	if err != nil {
+		t.Fatalf("Replace failed: %v", err)
+	}
+
+	data, _ := os.ReadFile(testFile)
+	if string(data) != "keep\nmerged\nkeep\n" {
+		t.Errorf("Unexpected content %q", string(data))
+	}
+
+	if !strings.Contains(results.Diff(), "@@ -2,2 +2 @@\n-begin\n-end\n+merged\n") {
+		t.Errorf("Unexpected diff:\n%s", results.Diff())
+	}
+}
+
+func TestReplacePastDefaultResultLimit(t *testing.T) {
+	tempDir := t.TempDir()
+	// More matches in a.txt than Find returns by default
+	if err := os.WriteFile(filepath.Join(tempDir, "a.txt"), []byte(strings.Repeat("foo\n", 1100)), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "b.txt"), []byte("foo\n"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	results, err := Replace("foo", "bar", tempDir, WithWorkers(1))
+	if err != nil {
+		t.Fatalf("Replace failed: %v", err)
+	}
+	if results.Replacements != 1101 || len(results.Files) != 2 {
+		t.Errorf("Expected 1101 replacements in 2 files, got %d in %d", results.Replacements, len(results.Files))
+	}
+
+	data, _ := os.ReadFile(filepath.Join(tempDir, "b.txt"))
+	if string(data) != "bar\n" {
+		t.Errorf("Expected b.txt to be rewritten, got %q", string(data))
+	}
+}
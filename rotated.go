@@ -0,0 +1,153 @@
+package goripgrep
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// rotationSuffix matches what log rotation appends to a log's name once any
+// compression extension is removed: a generation number (app.log.1) or a
+// date (app.log-20240131, app.log.2024-01-31)
+var rotationSuffix = regexp.MustCompile(`^(?:\.(\d{1,7})|[-.](\d{4}-?\d{2}-?\d{2}(?:[-_T]?\d{2,6})?))$`)
+
+// rotatedLog is a rotated sibling of a log file and its place in the rotation
+type rotatedLog struct {
+	path       string
+	generation int    // Higher generations are older
+	date       string // Rotation date with separators removed, if dated
+}
+
+// isRegularFile reports whether path names a regular file
+func isRegularFile(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Mode().IsRegular()
+}
+
+// rotatedLogs returns the log file at path and its rotated siblings, such as
+// app.log.2.gz, app.log.1 and app.log, in chronological order: dated
+// rotations by date, then numbered ones from the highest generation down,
+// then the live file itself
+func rotatedLogs(path string) ([]string, error) {
+	dir, base := filepath.Split(path)
+	listDir := dir
+	if listDir == "" {
+		listDir = "."
+	}
+
+	entries, err := os.ReadDir(listDir)
+	if err != nil {
+		return nil, err
+	}
+
+	detector := NewCompressionDetector()
+	var logs []rotatedLog
+	for _, entry := range entries {
+		rest, ok := strings.CutPrefix(entry.Name(), base)
+		if !ok || rest == "" || !entry.Type().IsRegular() {
+			continue
+		}
+		if detector.DetectCompressionByExtension(rest) != CompressionNone {
+			rest = strings.TrimSuffix(rest, filepath.Ext(rest))
+		}
+
+		groups := rotationSuffix.FindStringSubmatch(rest)
+		if groups == nil {
+			continue
+		}
+		log := rotatedLog{path: dir + entry.Name()}
+		if groups[1] != "" {
+			log.generation, _ = strconv.Atoi(groups[1])
+		} else {
+			log.date = strings.NewReplacer("-", "", "_", "", "T", "").Replace(groups[2])
+		}
+		logs = append(logs, log)
+	}
+
+	sort.Slice(logs, func(i, j int) bool {
+		a, b := logs[i], logs[j]
+		if (a.date != "") != (b.date != "") {
+			return a.date != ""
+		}
+		if a.date != b.date {
+			return a.date < b.date
+		}
+		if a.generation != b.generation {
+			return a.generation > b.generation
+		}
+		// A generation left mid-compression has both copies; keep them stable
+		return a.path < b.path
+	})
+
+	files := make([]string, 0, len(logs)+1)
+	for _, log := range logs {
+		files = append(files, log.path)
+	}
+	return append(files, path), nil
+}
+
+// searchRotated searches the log file at the search path and its rotated
+// siblings one after another, oldest first, so matches come out in
+// chronological order. Compressed rotations are decompressed as they are read.
+func (e *SearchEngine) searchRotated(ctx context.Context, matcher *lineMatcher, pattern string, results *SearchResults) error {
+	files, err := rotatedLogs(e.config.SearchPath)
+	if err != nil {
+		return err
+	}
+
+	detector := NewCompressionDetector()
+	total := 0
+	for _, file := range files {
+		if ctx.Err() != nil {
+			break
+		}
+
+		result, err := e.searchLogFile(ctx, detector, matcher, pattern, file, e.resultLimit(total))
+		if err != nil {
+			// Skip unreadable files, as the workers do
+			continue
+		}
+		if result.count > 0 && e.addResult(results, result, &total) {
+			break
+		}
+	}
+
+	return nil
+}
+
+// searchLogFile searches a single file, such as a log file, streaming
+// compressed files through a decompressor and stopping once limit matches are
+// found
+func (e *SearchEngine) searchLogFile(ctx context.Context, detector *CompressionDetector, matcher *lineMatcher, pattern, file string, limit int) (fileResult, error) {
+	if e.config.FileNamesOnly {
+		return e.processFile(ctx, pattern, file)
+	}
+
+	compression, err := detector.DetectCompression(file)
+	if err != nil {
+		return fileResult{}, err
+	}
+	if compression == CompressionNone {
+		return e.processFile(ctx, pattern, file)
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return fileResult{}, err
+	}
+	defer f.Close()
+
+	reader, err := detector.DecompressReader(f, compression)
+	if err != nil {
+		return fileResult{}, err
+	}
+	if closer, ok := reader.(io.Closer); ok {
+		defer closer.Close()
+	}
+	return e.collectStream(ctx, matcher, pattern, file, reader, &e.phases.decompress, limit)
+}
@@ -0,0 +1,117 @@
+package goripgrep
+
+import (
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func writeGzipFile(t *testing.T, path, content string) {
+	t.Helper()
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write([]byte(content)); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRotatedLogs(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{
+		"app.log", "app.log.1", "app.log.2.gz", "app.log.10.gz", "app.log.3.bz2",
+		"app.log-20240102.gz", "app.log-20231231",
+		"app.log.bak", "app.log.gz", "app.logger", "other.log.1",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "app.log.4"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := rotatedLogs(filepath.Join(dir, "app.log"))
+	if err != nil {
+		t.Fatalf("rotatedLogs() error: %v", err)
+	}
+
+	var names []string
+	for _, file := range files {
+		names = append(names, filepath.Base(file))
+	}
+	want := []string{
+		"app.log-20231231", "app.log-20240102.gz",
+		"app.log.10.gz", "app.log.3.bz2", "app.log.2.gz", "app.log.1",
+		"app.log",
+	}
+	if !slices.Equal(names, want) {
+		t.Errorf("rotatedLogs() = %v, want %v", names, want)
+	}
+}
+
+func TestFindRotatedLogs(t *testing.T) {
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "app.log")
+	writeGzipFile(t, logPath+".2.gz", "ERROR first\nINFO ok\n")
+	if err := os.WriteFile(logPath+".1", []byte("ERROR second\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(logPath, []byte("INFO ok\nERROR third\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := Find("ERROR", logPath, WithRotatedLogs())
+	if err != nil {
+		t.Fatalf("Find() error: %v", err)
+	}
+
+	var contents []string
+	for _, match := range results.Matches {
+		contents = append(contents, match.Content)
+	}
+	want := []string{"ERROR first", "ERROR second", "ERROR third"}
+	if !slices.Equal(contents, want) {
+		t.Errorf("Find() matched %v, want %v", contents, want)
+	}
+	if results.Matches[0].File != logPath+".2.gz" || results.Matches[0].Line != 1 {
+		t.Errorf("first match = %s:%d, want %s.2.gz:1", results.Matches[0].File, results.Matches[0].Line, logPath)
+	}
+	if results.Stats.FilesScanned != 3 {
+		t.Errorf("FilesScanned = %d, want 3", results.Stats.FilesScanned)
+	}
+
+	// Limits apply across the rotation in chronological order
+	results, err = Find("ERROR", logPath, WithRotatedLogs(), WithQuitAfter(2))
+	if err != nil {
+		t.Fatalf("Find() error: %v", err)
+	}
+	if len(results.Matches) != 2 || results.Matches[1].Content != "ERROR second" || !results.Stats.StoppedEarly {
+		t.Errorf("Find() with WithQuitAfter(2) = %v, want the two oldest matches", results.Matches)
+	}
+
+	results, err = Find("ERROR", logPath, WithRotatedLogs(), WithCountOnly())
+	if err != nil {
+		t.Fatalf("Find() error: %v", err)
+	}
+	if results.Counts[logPath+".2.gz"] != 1 || results.Count() != 3 {
+		t.Errorf("Find() with WithCountOnly() = %v, want one match per file", results.Counts)
+	}
+
+	// Without the option only the named file is searched
+	results, err = Find("ERROR", logPath)
+	if err != nil {
+		t.Fatalf("Find() error: %v", err)
+	}
+	if results.Count() != 1 {
+		t.Errorf("Find() without WithRotatedLogs() found %d matches, want 1", results.Count())
+	}
+}
@@ -0,0 +1,181 @@
+// Package rpc serves goripgrep searches over gRPC, so other services can
+// embed remote code search. The Search service is defined in search.proto:
+// NewServer implements it on top of a goripgrep.Server and the generated
+// SearchClient calls it, receiving matches as the server finds them.
+// Cancelling a call's context or reaching its deadline stops the search on
+// the server.
+package rpc
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative search.proto
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+
+	"github.com/localrivet/goripgrep"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// Server implements the Search service for a directory, keeping compiled
+// patterns and ignore rules warm between calls like goripgrep.Server does.
+// Register it with RegisterSearchServer.
+type Server struct {
+	UnimplementedSearchServer
+	search *goripgrep.Server
+}
+
+// NewServer creates a Search service for the directory root. The options
+// apply to every search as they do for goripgrep.NewServer; a call's
+// deadline shortens WithTimeout but cannot extend it. The audit log of
+// WithAuditLog names the user from the x-forwarded-user metadata, believed
+// only from the proxies given to WithTrustedProxies.
+func NewServer(root string, opts ...goripgrep.Option) (*Server, error) {
+	search, err := goripgrep.NewServer(root, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &Server{search: search}, nil
+}
+
+// Search streams a result per match as each file finishes, then the
+// statistics
+func (s *Server) Search(req *SearchRequest, stream grpc.ServerStreamingServer[SearchResult]) error {
+	ctx := stream.Context()
+	var addr, user string
+	if p, ok := peer.FromContext(ctx); ok {
+		addr = p.Addr.String()
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if users := md.Get("x-forwarded-user"); len(users) > 0 {
+			user = users[0]
+		}
+	}
+	ctx = goripgrep.ContextWithClient(ctx, addr, user)
+
+	results, err := s.search.Search(ctx, req.searchRequest(), func(matches []goripgrep.Match) error {
+		for _, match := range matches {
+			if err := stream.Send(&SearchResult{Result: &SearchResult_Match{Match: newMatch(match)}}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return statusError(err)
+	}
+	return stream.Send(&SearchResult{Result: &SearchResult_Stats{Stats: newStats(results)}})
+}
+
+// statusError converts a search error into a gRPC status
+func statusError(err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	code := codes.Internal
+	switch {
+	case errors.Is(err, goripgrep.ErrPathNotAllowed):
+		code = codes.PermissionDenied
+	case errors.Is(err, fs.ErrNotExist):
+		code = codes.NotFound
+	case errors.Is(err, goripgrep.ErrInvalidRequest):
+		code = codes.InvalidArgument
+	case errors.Is(err, goripgrep.ErrTooManySearches):
+		code = codes.ResourceExhausted
+	case errors.Is(err, context.DeadlineExceeded):
+		code = codes.DeadlineExceeded
+	case errors.Is(err, context.Canceled):
+		code = codes.Canceled
+	}
+	return status.Error(code, err.Error())
+}
+
+// searchRequest converts the request for goripgrep.Server
+func (r *SearchRequest) searchRequest() goripgrep.SearchRequest {
+	req := goripgrep.SearchRequest{
+		Pattern:       r.GetPattern(),
+		Patterns:      r.GetPatterns(),
+		Path:          r.GetPath(),
+		IgnoreCase:    r.GetIgnoreCase(),
+		FixedStrings:  r.GetFixedStrings(),
+		WordRegexp:    r.GetWordRegexp(),
+		LineRegexp:    r.GetLineRegexp(),
+		Multiline:     r.GetMultiline(),
+		PCRE2:         r.GetPcre2(),
+		InvertMatch:   r.GetInvertMatch(),
+		Recursive:     r.Recursive,
+		Hidden:        r.GetHidden(),
+		Globs:         r.GetGlobs(),
+		Types:         r.GetTypes(),
+		TypesNot:      r.GetTypesNot(),
+		Context:       int(r.GetContext()),
+		BeforeContext: int(r.GetBeforeContext()),
+		AfterContext:  int(r.GetAfterContext()),
+		MaxResults:    int(r.GetMaxResults()),
+		QuitAfter:     int(r.GetQuitAfter()),
+		Count:         r.GetCount(),
+	}
+	if r.Timeout != nil {
+		req.Timeout = r.Timeout.AsDuration().String()
+	}
+	return req
+}
+
+// newMatch converts a match for the wire
+func newMatch(match goripgrep.Match) *Match {
+	return &Match{
+		File:          match.File,
+		Line:          int32(match.Line),
+		EndLine:       int32(match.EndLine),
+		Column:        int32(match.Column),
+		Content:       match.Content,
+		MatchText:     match.MatchText,
+		MatchStart:    int32(match.MatchStart),
+		MatchEnd:      int32(match.MatchEnd),
+		PatternIndex:  int32(match.PatternIndex),
+		BeforeContext: match.BeforeContext,
+		AfterContext:  match.AfterContext,
+	}
+}
+
+// newStats converts the statistics of a search for the wire
+func newStats(results *goripgrep.SearchResults) *Stats {
+	stats := &Stats{
+		FilesScanned: results.Stats.FilesScanned,
+		FilesSkipped: results.Stats.FilesSkipped,
+		FilesIgnored: results.Stats.FilesIgnored,
+		BytesScanned: results.Stats.BytesScanned,
+		MatchesFound: results.Stats.MatchesFound,
+		StoppedEarly: results.Stats.StoppedEarly,
+		Duration:     durationpb.New(results.Stats.Duration),
+	}
+	if results.Counts != nil {
+		stats.Counts = make(map[string]int64, len(results.Counts))
+		for file, count := range results.Counts {
+			stats.Counts[file] = int64(count)
+		}
+	}
+	return stats
+}
+
+// AsMatch converts a received match into a goripgrep.Match
+func (m *Match) AsMatch() goripgrep.Match {
+	return goripgrep.Match{
+		File:          m.GetFile(),
+		Line:          int(m.GetLine()),
+		EndLine:       int(m.GetEndLine()),
+		Column:        int(m.GetColumn()),
+		Content:       m.GetContent(),
+		MatchText:     m.GetMatchText(),
+		MatchStart:    int(m.GetMatchStart()),
+		MatchEnd:      int(m.GetMatchEnd()),
+		PatternIndex:  int(m.GetPatternIndex()),
+		BeforeContext: m.GetBeforeContext(),
+		AfterContext:  m.GetAfterContext(),
+	}
+}
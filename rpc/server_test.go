@@ -0,0 +1,198 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/localrivet/goripgrep"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// startServer serves the Search service for root in memory and returns a
+// client connected to it
+func startServer(t *testing.T, root string, opts ...goripgrep.Option) SearchClient {
+	t.Helper()
+	s, err := NewServer(root, opts...)
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+
+	lis := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	RegisterSearchServer(server, s)
+	go func() { _ = server.Serve(lis) }()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return NewSearchClient(conn)
+}
+
+// receive reads the stream to its end
+func receive(stream grpc.ServerStreamingClient[SearchResult]) ([]goripgrep.Match, *Stats, error) {
+	var matches []goripgrep.Match
+	var stats *Stats
+	for {
+		result, err := stream.Recv()
+		if err == io.EOF {
+			return matches, stats, nil
+		}
+		if err != nil {
+			return matches, stats, err
+		}
+		if match := result.GetMatch(); match != nil {
+			matches = append(matches, match.AsMatch())
+		}
+		if result.GetStats() != nil {
+			stats = result.GetStats()
+		}
+	}
+}
+
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestSearch(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"a.go":       "package a\n// TODO one\n",
+		"sub/b.go":   "// TODO two\n// todo three\n",
+		"gen/c.go":   "// TODO generated\n",
+		".gitignore": "gen/\n",
+	})
+	client := startServer(t, root)
+
+	stream, err := client.Search(context.Background(), &SearchRequest{Pattern: "TODO"})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	matches, stats, err := receive(stream)
+	if err != nil {
+		t.Fatalf("Stream failed: %v", err)
+	}
+	if len(matches) != 2 || stats == nil || stats.GetMatchesFound() != 2 {
+		t.Fatalf("Expected 2 matches and stats, got %+v %v", matches, stats)
+	}
+
+	stream, err = client.Search(context.Background(), &SearchRequest{
+		Pattern:    "todo",
+		Path:       "sub",
+		IgnoreCase: true,
+		Context:    1,
+	})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	matches, _, err = receive(stream)
+	if err != nil {
+		t.Fatalf("Stream failed: %v", err)
+	}
+	if len(matches) != 2 || matches[0].File != filepath.Join(root, "sub", "b.go") || matches[0].Line != 1 ||
+		len(matches[0].AfterContext) != 1 {
+		t.Errorf("Expected 2 matches in sub/b.go with context, got %+v", matches)
+	}
+
+	stream, err = client.Search(context.Background(), &SearchRequest{Pattern: "TODO", Count: true})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	matches, stats, err = receive(stream)
+	if err != nil || len(matches) != 0 || stats.GetCounts()[filepath.Join(root, "sub", "b.go")] != 1 {
+		t.Errorf("Expected only counts, got %+v %v %v", matches, stats, err)
+	}
+}
+
+func TestSearchErrors(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{"a.txt": "text\n"})
+	client := startServer(t, root, goripgrep.WithRejectSlowPatterns())
+
+	tests := []struct {
+		name string
+		req  *SearchRequest
+		code codes.Code
+	}{
+		{name: "invalid pattern", req: &SearchRequest{Pattern: "("}, code: codes.InvalidArgument},
+		{name: "slow pattern", req: &SearchRequest{Pattern: "[a-z]{1,1000}"}, code: codes.InvalidArgument},
+		{name: "zero timeout", req: &SearchRequest{Pattern: "x", Timeout: durationpb.New(0)}, code: codes.InvalidArgument},
+		{name: "missing path", req: &SearchRequest{Pattern: "x", Path: "missing"}, code: codes.NotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stream, err := client.Search(context.Background(), tt.req)
+			if err == nil {
+				_, _, err = receive(stream)
+			}
+			if status.Code(err) != tt.code {
+				t.Errorf("Expected %s, got %v", tt.code, err)
+			}
+		})
+	}
+}
+
+func TestSearchCancel(t *testing.T) {
+	root := t.TempDir()
+	files := make(map[string]string)
+	// Enough long matches to fill the flow control window, so the server
+	// is still sending when the client cancels
+	line := "TODO " + strings.Repeat("x", 4096) + "\n"
+	for i := 0; i < 200; i++ {
+		files[fmt.Sprintf("dir%d/file%d.txt", i%10, i)] = line
+	}
+	writeFiles(t, root, files)
+	client := startServer(t, root)
+
+	// Cancelling the call after the first match ends the stream
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, err := client.Search(ctx, &SearchRequest{Pattern: "TODO"})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if _, err := stream.Recv(); err != nil {
+		t.Fatalf("Expected a first match, got %v", err)
+	}
+	cancel()
+	if _, _, err := receive(stream); status.Code(err) != codes.Canceled {
+		t.Errorf("Expected Canceled after cancelling, got %v", err)
+	}
+
+	// An expired deadline stops the search too
+	ctx, cancel = context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	stream, err = client.Search(ctx, &SearchRequest{Pattern: "TODO"})
+	if err == nil {
+		_, _, err = receive(stream)
+	}
+	if status.Code(err) != codes.DeadlineExceeded && !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected DeadlineExceeded, got %v", err)
+	}
+}
@@ -0,0 +1,180 @@
+package goripgrep
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// sarifSchema is the schema URI of the SARIF version ToSARIF writes
+const sarifSchema = "https://json.schemastore.org/sarif-2.1.0.json"
+
+// SARIF 2.1.0 log structure, limited to what code scanning reads
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool       sarifTool     `json:"tool"`
+	ColumnKind string        `json:"columnKind"`
+	Results    []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+	FullDescription  sarifMessage `json:"fullDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	RuleIndex int             `json:"ruleIndex"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int           `json:"startLine"`
+	StartColumn int           `json:"startColumn,omitempty"`
+	EndLine     int           `json:"endLine,omitempty"`
+	EndColumn   int           `json:"endColumn,omitempty"`
+	Snippet     *sarifMessage `json:"snippet,omitempty"`
+}
+
+// ToSARIF converts results to a SARIF 2.1.0 log with a single rule, for
+// uploading security audit searches to code scanning services such as
+// GitHub Code Scanning. Every match becomes a warning of rule ruleID,
+// described by ruleDesc, at its line and columns. Files under the working
+// directory are given by relative paths, as code scanning expects paths
+// relative to the repository root; others become file URIs.
+func ToSARIF(results *SearchResults, ruleID, ruleDesc string) ([]byte, error) {
+	if ruleID == "" {
+		return nil, fmt.Errorf("SARIF output needs a rule ID")
+	}
+	if ruleDesc == "" {
+		ruleDesc = "Matches " + results.Query
+	}
+	wd, _ := os.Getwd()
+
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "goripgrep",
+			InformationURI: "https://github.com/localrivet/goripgrep",
+			Rules: []sarifRule{{
+				ID:               ruleID,
+				ShortDescription: sarifMessage{Text: ruleDesc},
+				FullDescription:  sarifMessage{Text: ruleDesc},
+			}},
+		}},
+		ColumnKind: "unicodeCodePoints",
+		Results:    []sarifResult{},
+	}
+	for _, match := range results.Matches {
+		message := ruleDesc
+		if match.Kind == MatchXattr {
+			message = fmt.Sprintf("%s (extended attribute %s)", ruleDesc, match.Attribute)
+		}
+		location := sarifPhysicalLocation{
+			ArtifactLocation: sarifArtifactLocation{URI: sarifURI(match.File, wd)},
+		}
+		if match.Kind == MatchContent && match.Line > 0 {
+			location.Region = sarifMatchRegion(match)
+		}
+		run.Results = append(run.Results, sarifResult{
+			RuleID:    ruleID,
+			Level:     "warning",
+			Message:   sarifMessage{Text: message},
+			Locations: []sarifLocation{{PhysicalLocation: location}},
+		})
+	}
+
+	doc := sarifLog{Schema: sarifSchema, Version: "2.1.0", Runs: []sarifRun{run}}
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode SARIF: %w", err)
+	}
+	return data, nil
+}
+
+// sarifURI returns path relative to wd with forward slashes when it lies
+// under wd, and a file URI otherwise
+func sarifURI(path, wd string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	if wd != "" {
+		if rel, err := filepath.Rel(wd, abs); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return (&url.URL{Path: filepath.ToSlash(rel)}).String()
+		}
+	}
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(abs)}).String()
+}
+
+// sarifMatchRegion returns the lines and code point columns of a match. The
+// columns are counted from Content when it holds the matched line; without
+// it, RuneColumn (or Column) gives the start and the match text the end.
+func sarifMatchRegion(match Match) *sarifRegion {
+	region := &sarifRegion{StartLine: match.Line, StartColumn: match.Column}
+	if match.RuneColumn > 0 {
+		region.StartColumn = match.RuneColumn
+	}
+	if match.EndLine > match.Line {
+		region.EndLine = match.EndLine
+	}
+
+	// Content holds the matched line unless only the match was kept
+	content := match.Content
+	holdsLine := content != "" && match.MatchStart <= match.MatchEnd && match.MatchEnd <= len(content)
+	if holdsLine && match.RuneColumn > 0 {
+		holdsLine = match.RuneColumn == utf8.RuneCountInString(content[:match.MatchStart])+1
+	} else if holdsLine {
+		holdsLine = match.MatchStart == match.Column-1
+	}
+	if holdsLine {
+		region.StartColumn = utf8.RuneCountInString(content[:match.MatchStart]) + 1
+		lineStart := strings.LastIndexByte(content[:match.MatchEnd], '\n') + 1
+		region.EndColumn = utf8.RuneCountInString(content[lineStart:match.MatchEnd]) + 1
+		region.Snippet = &sarifMessage{Text: content}
+	} else if match.MatchText != "" && !strings.Contains(match.MatchText, "\n") {
+		region.EndColumn = region.StartColumn + utf8.RuneCountInString(match.MatchText)
+	}
+	if region.EndColumn <= region.StartColumn && region.EndLine == 0 {
+		region.EndColumn = 0
+	}
+	return region
+}
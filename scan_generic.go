@@ -0,0 +1,8 @@
+//go:build !(amd64 || arm64) || purego
+
+package goripgrep
+
+// vectorScan reports that byte scans use the word-level Go implementation,
+// as there is no vetted vector assembly for this architecture or the purego
+// build tag asked for none
+const vectorScan = false
@@ -0,0 +1,8 @@
+//go:build (amd64 || arm64) && !purego
+
+package goripgrep
+
+// vectorScan reports whether byte scans go through the standard library,
+// whose assembly uses SSE2 or AVX2 on amd64, picked at run time by CPU
+// feature detection, and NEON on arm64
+const vectorScan = true
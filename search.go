@@ -2,32 +2,95 @@ package goripgrep
 
 import (
 	"bufio"
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
+	"io"
 	"io/fs"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 	"sync"
-	"syscall"
+	"sync/atomic"
 	"time"
+	"unicode/utf8"
 )
 
 // SearchConfig holds configuration for the search engine
 type SearchConfig struct {
 	SearchPath      string
+	SearchPaths     []string // Further paths searched after SearchPath by the same workers
 	MaxWorkers      int
+	WorkerGroups    int  // Split the workers into this many groups with their own engines (0 or 1 for a single group)
+	PinCPUs         bool // Pin each worker group to its own share of the CPUs (Linux only)
 	BufferSize      int
 	MaxResults      int
+	MaxCountPerFile int        // Stop searching a file after matches on this many lines, like grep -m (0 for no limit)
+	QuitAfter       int        // Stop the whole search once this many matches are found (0 for no limit)
+	StrictErrors    bool       // Abort the search at the first file or directory that cannot be searched
+	MaxFiles        int        // Stop walking once this many files have been searched (0 for no limit)
+	MaxMatchFiles   int        // Stop the whole search once matches have been found in this many files (0 for no limit)
+	MaxBytes        int64      // Stop walking once this many bytes have been searched (0 for no limit)
+	SlowestFiles    int        // Record the scan time of this many of the slowest files in SearchStats.SlowestFiles
+	AllowedRoots    []string   // When set, skip symlinks resolving outside these absolute, resolved directories
+	BinaryMode      BinaryMode // What to do with binary files found while walking
+	ColumnMode      ColumnMode // Whether Match.Column counts bytes or characters
+	Deterministic   bool       // Report files in walk order whatever order the workers finish them in
+	CountOnly       bool       // Count matches per file instead of collecting them
 	UseOptimization bool
 	UseGitignore    bool
 	IgnoreCase      bool
+	SmartCase       bool // Ignore case unless the pattern has an uppercase letter, overriding IgnoreCase
 	IncludeHidden   bool
+	SearchAllDirs   bool // Walk node_modules, vendor, build and the other directories the optimized walk skips
 	FollowSymlinks  bool
+	DedupeLinks     bool // Search a file reached through several hard links or symlinks only once
 	Recursive       bool
+	MaxDepth        int // Deepest level a recursive walk descends to below the search path, 1 for its entries alone (0 for no limit)
+	MaxPathDepth    int // Like MaxDepth, but directories cut off are counted in DirsTruncated, as a guard against pathological trees
 	FilePattern     string
-	ContextLines    int
+	IncludeGlobs    []string // Globs selecting files to search; a leading ! excludes instead
+	ExcludeGlobs    []string // Globs excluding files and directories, applied after IncludeGlobs
+	FileTypes       []string // Only search files of these types; globs override types
+	FileTypesNot    []string // Skip files of these types
+	ContextLines    int      // Lines of context on both sides of a match
+	BeforeContext   int      // Overrides ContextLines for lines before a match when positive
+	AfterContext    int      // Overrides ContextLines for lines after a match when positive
 	Timeout         time.Duration
+	Multiline       bool  // Match patterns across line boundaries
+	FixedStrings    bool  // Treat the pattern as a literal string
+	WordRegexp      bool  // Only match whole words, as if wrapped in \b...\b
+	LineRegexp      bool  // Only match whole lines, as if wrapped in ^...$
+	PCRE2           bool  // Match lookaround and backreferences with a backtracking engine
+	HeadBytes       int64 // Only search the first HeadBytes of each file
+	TailBytes       int64 // Only search the last TailBytes of each file
+	InvertMatch     bool  // Report lines that do not match the pattern
+	SearchMetadata  bool  // Also match file names and extended attributes
+	FileNamesOnly   bool  // Match file names instead of contents
+	LineStart       int   // First line searched in each file (1-indexed, 0 for the first line)
+	LineEnd         int   // Last line searched in each file (0 for the end of the file)
+
+	CaseInsensitiveGlobs []string      // Like IncludeGlobs but matched without regard to case
+	IgnoreFiles          []string      // Extra per-directory ignore file names, after .gitignore, .ignore and .rgignore
+	RotatedLogs          bool          // When searching a file, also search its rotated siblings, oldest first
+	SearchCompressed     bool          // Decompress compressed files found while walking and search their contents
+	ArchiveSearch        bool          // Search the members of tar and zip archives found while walking as archive!member paths
+	Sections             bool          // Report the heading each Markdown match falls under
+	KeyPaths             bool          // Report the key path of each match in JSON and YAML files
+	Patterns             []string      // Patterns combined into the search pattern, to tell which one each match came from
+	OmitLineContent      bool          // Leave Match.Content empty, keeping MatchText and the match positions
+	OnlyMatching         bool          // Cut Match.Content down to the matched text and record capture groups in Submatches
+	FollowInterval       time.Duration // How often followed files are polled for new data
+	Logger               *slog.Logger  // Receives walk decisions at debug level, such as why a file was skipped
+
+	// Normal form the pattern and lines are matched in; nil compares them as they are
+	Normalizer *UnicodeNormalizer
+	// Full Unicode case folding used when case is ignored; nil folds rune by rune
+	CaseFolder *AdvancedCaseFolding
 
 	// Streaming search configuration for large files
 	StreamingSearch    bool                 // Enable streaming search for large files
@@ -46,39 +109,187 @@ type SearchConfig struct {
 	MemoryMappedFiles         bool // Use memory-mapped files for large files
 }
 
-// SearchEngine provides integrated search functionality
+// SearchEngine provides integrated search functionality. It keeps per-search
+// state such as statistics, so run one Search at a time on each SearchEngine
+// and create one per goroutine for parallel searches
 type SearchEngine struct {
 	config          SearchConfig
 	gitignoreEngine *GitignoreEngine
+	globs           *globSet
+	compression     *CompressionDetector // Set when compressed files are searched
+	archives        *ArchiveSearcher     // Set when archive members are searched
+	matcher         *lineMatcher
+	patterns        []*lineMatcher // Each of several combined patterns, to tag matches with
 	stats           SearchStats
+	phases          phaseCounters
+	slowest         slowestFiles
+
+	// Set by Server: ignore rules shared across searches instead of loaded
+	// for each, and a function that receives each file's matches instead of
+	// collecting them in the results
+	sharedIgnore bool
+	emit         func([]Match)
+	emitted      int
+
+	fileErrs fileErrors         // Files that could not be searched
+	abort    context.CancelFunc // Cancels the search, for StrictErrors
+
+	// Set by Searcher: the files of an earlier walk, searched again instead
+	// of walking, or else the list this walk is recorded in
+	walkedList *fileList
+	recordList *fileList
+
+	trace *FileReport // Set by ExplainFile, which records the filter decisions here
+
+	claimed   int64               // Files started against MaxFiles
+	found     int64               // Matches in files searched so far, against QuitAfter and MaxResults
+	foundSeq  int64               // Latest file in walk order counted in found
+	enough    atomic.Bool         // found reached QuitAfter or MaxResults
+	matched   map[string]struct{} // Files with matches, counted against MaxMatchFiles
+	walked    int                 // Files sent by the walker, numbering them
+	root      string              // Absolute path of the search path being walked
+	seenFiles map[fileID]struct{} // Files sent, so other links to them are skipped
+	exhausted atomic.Bool         // MaxFiles or MaxBytes stopped the walk
+
+	// With WorkerGroups, the engines the groups search through, and in each
+	// of those the engine whose limits they share
+	groups []*SearchEngine
+	owner  *SearchEngine
 }
 
 // SearchStats tracks search performance metrics
 type SearchStats struct {
-	FilesScanned int64
-	FilesSkipped int64
-	FilesIgnored int64
-	BytesScanned int64
-	MatchesFound int64
-	Duration     time.Duration
-	StartTime    time.Time
-	EndTime      time.Time
+	FilesScanned     int64
+	FilesSkipped     int64
+	FilesIgnored     int64
+	BytesScanned     int64 // Total size of the files searched, counted once per file
+	BytesRead        int64 // Bytes actually read; below BytesScanned when only part of a file is read
+	MatchesFound     int64
+	NonMatchingLines int64 // Non-matching lines reported in invert mode
+	FilesChanged     int64 // Files whose size or modification time changed while they were searched
+	DirsTruncated    int64 // Directories not walked because they were deeper than MaxPathDepth or looped back to a directory above them
+	StoppedEarly     bool  // A result limit ended the search, so later files may not have been searched
+	Duration         time.Duration
+	StartTime        time.Time
+	EndTime          time.Time
+	Phases           PhaseTimings // Where the time went, summed across workers
+	SlowestFiles     []FileTiming // With SlowestFiles set, the files that took longest, slowest first
+}
+
+// FileTiming is the time spent searching one file
+type FileTiming struct {
+	Path     string
+	Size     int64
+	Duration time.Duration
+}
+
+// PhaseTimings breaks search time down by phase. Worker phases are summed
+// across workers, so together they can exceed the wall-clock duration.
+type PhaseTimings struct {
+	Walk       time.Duration // Listing directories, excluding filters and waiting for workers
+	Filter     time.Duration // Gitignore, glob, hidden and binary checks
+	Read       time.Duration // Reading file contents
+	Match      time.Duration // Matching and building results
+	Decompress time.Duration // Reading through a decompressor (Engine, rotated logs and SearchCompressed only)
+}
+
+// phaseCounters accumulates phase durations in nanoseconds
+type phaseCounters struct {
+	walk       int64
+	filter     int64
+	read       int64
+	decompress int64
+	process    int64 // Total time workers spent on files, reads included
+	sendWait   int64 // Time the walker spent blocked on busy workers
+}
+
+// since adds the time elapsed since start to counter; safe for concurrent use
+func (p *phaseCounters) since(counter *int64, start time.Time) {
+	atomic.AddInt64(counter, int64(time.Since(start)))
+}
+
+// timings converts the counters, deriving match time from processing time
+func (p *phaseCounters) timings() PhaseTimings {
+	timings := PhaseTimings{
+		Walk:       time.Duration(atomic.LoadInt64(&p.walk)),
+		Filter:     time.Duration(atomic.LoadInt64(&p.filter)),
+		Read:       time.Duration(atomic.LoadInt64(&p.read)),
+		Decompress: time.Duration(atomic.LoadInt64(&p.decompress)),
+	}
+	if match := time.Duration(atomic.LoadInt64(&p.process)) - timings.Read - timings.Decompress; match > 0 {
+		timings.Match = match
+	}
+	return timings
+}
+
+// slowestFiles keeps the limit slowest files recorded, slowest first; safe
+// for concurrent use
+type slowestFiles struct {
+	mu    sync.Mutex
+	limit int
+	files []FileTiming
+}
+
+// reset forgets the files recorded and keeps up to limit from now on
+func (s *slowestFiles) reset(limit int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.limit = limit
+	s.files = nil
+}
+
+// record adds timing if it is among the slowest files so far
+func (s *slowestFiles) record(timing FileTiming) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.limit <= 0 || len(s.files) == s.limit && timing.Duration <= s.files[len(s.files)-1].Duration {
+		return
+	}
+	i := sort.Search(len(s.files), func(i int) bool { return s.files[i].Duration < timing.Duration })
+	s.files = append(s.files, FileTiming{})
+	copy(s.files[i+1:], s.files[i:])
+	s.files[i] = timing
+	if len(s.files) > s.limit {
+		s.files = s.files[:s.limit]
+	}
+}
+
+// timings returns a copy of the files recorded
+func (s *slowestFiles) timings() []FileTiming {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.files) == 0 {
+		return nil
+	}
+	return append([]FileTiming(nil), s.files...)
 }
 
 // SearchResults contains search results and metadata
 type SearchResults struct {
 	Matches []Match
+	Counts  map[string]int // Matches per file in count-only mode, where Matches stays empty
 	Stats   SearchStats
 	Query   string
+	Errors  []FileError // Files and directories that could not be searched, by path
+
+	template         *OutputTemplate // Set with WithOutputTemplate, for Render
+	contextSeparator string          // Set with WithContextSeparator when context is requested, for ContextLines
 }
 
 // HasMatches returns true if any matches were found
 func (r *SearchResults) HasMatches() bool {
-	return len(r.Matches) > 0
+	return r.Count() > 0
 }
 
 // Count returns the number of matches
 func (r *SearchResults) Count() int {
+	if r.Counts != nil {
+		total := 0
+		for _, count := range r.Counts {
+			total += count
+		}
+		return total
+	}
 	return len(r.Matches)
 }
 
@@ -88,6 +299,9 @@ func (r *SearchResults) Files() []string {
 	for _, match := range r.Matches {
 		fileSet[match.File] = true
 	}
+	for file := range r.Counts {
+		fileSet[file] = true
+	}
 
 	files := make([]string, 0, len(fileSet))
 	for file := range fileSet {
@@ -96,6 +310,43 @@ func (r *SearchResults) Files() []string {
 	return files
 }
 
+// FileMatches holds the matches found in a single file
+type FileMatches struct {
+	File    string
+	Matches []Match
+}
+
+// GroupByFile returns the matches grouped per file, ordered by file path and
+// then by position within each file
+func (r *SearchResults) GroupByFile() []FileMatches {
+	index := make(map[string]int)
+	var groups []FileMatches
+
+	for _, match := range r.Matches {
+		i, ok := index[match.File]
+		if !ok {
+			i = len(groups)
+			index[match.File] = i
+			groups = append(groups, FileMatches{File: match.File})
+		}
+		groups[i].Matches = append(groups[i].Matches, match)
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].File < groups[j].File
+	})
+	for _, group := range groups {
+		sort.SliceStable(group.Matches, func(i, j int) bool {
+			if group.Matches[i].Line != group.Matches[j].Line {
+				return group.Matches[i].Line < group.Matches[j].Line
+			}
+			return group.Matches[i].Column < group.Matches[j].Column
+		})
+	}
+
+	return groups
+}
+
 // NewSearchEngine creates a new integrated search engine
 func NewSearchEngine(config SearchConfig) *SearchEngine {
 	engine := &SearchEngine{
@@ -114,40 +365,145 @@ func (e *SearchEngine) initializeEngines() error {
 	// since it needs the pattern for optimization
 
 	// Initialize gitignore engine if enabled
-	if e.config.UseGitignore {
-		e.gitignoreEngine = NewGitignoreEngine(e.config.SearchPath)
+	if e.config.UseGitignore && !e.sharedIgnore {
+		e.gitignoreEngine = NewGitignoreEngine(e.config.SearchPath, e.config.IgnoreFiles...)
+	}
+
+	if e.config.SearchCompressed {
+		e.compression = NewCompressionDetector()
+	}
+	if e.config.ArchiveSearch {
+		e.archives = NewArchiveSearcher()
+	}
+
+	// Compile include and exclude globs
+	globs, err := newGlobSet(e.config)
+	if err != nil {
+		return err
 	}
+	e.globs = globs
 
 	return nil
 }
 
 // Search performs an integrated search with all enabled features
 func (e *SearchEngine) Search(ctx context.Context, pattern string) (*SearchResults, error) {
+	return e.search(ctx, pattern, func(matcher *lineMatcher, results *SearchResults) error {
+		// Pipes are read once as streams and a log file may bring its rotated
+		// siblings along
+		switch {
+		case len(e.config.SearchPaths) > 0:
+			return e.performSearch(ctx, pattern, results)
+		case isStreamPath(e.config.SearchPath):
+			return e.searchStreamPath(ctx, matcher, pattern, e.config.SearchPath, results)
+		case e.config.RotatedLogs && isRegularFile(e.config.SearchPath):
+			return e.searchRotated(ctx, matcher, pattern, results)
+		default:
+			return e.performSearch(ctx, pattern, results)
+		}
+	})
+}
+
+// SearchFile searches filePath alone, without walking directories or applying
+// the file filters: globs, file types, ignore files and hidden file rules.
+// Compressed files are decompressed as they are read, and content options
+// such as context lines and line ranges apply as usual. Unlike Search, an
+// unreadable file is an error.
+func (e *SearchEngine) SearchFile(ctx context.Context, pattern, filePath string) (*SearchResults, error) {
+	return e.search(ctx, pattern, func(matcher *lineMatcher, results *SearchResults) error {
+		if isStreamPath(filePath) {
+			return e.searchStreamPath(ctx, matcher, pattern, filePath, results)
+		}
+		if e.searchesArchive(filePath) {
+			members, err := e.searchArchive(ctx, matcher, pattern, filePath)
+			total := 0
+			for _, result := range members {
+				if e.addResult(results, result, &total) {
+					break
+				}
+			}
+			return err
+		}
+
+		result, err := e.searchLogFile(ctx, NewCompressionDetector(), matcher, pattern, filePath, e.resultLimit(0))
+		if err != nil {
+			return err
+		}
+		total := 0
+		if result.count > 0 {
+			e.addResult(results, result, &total)
+		}
+		return nil
+	})
+}
+
+// search runs a search whose files are searched by fn, which adds their
+// results, wrapping it with the setup, post-processing stages and statistics
+// common to every search
+func (e *SearchEngine) search(ctx context.Context, pattern string, fn func(matcher *lineMatcher, results *SearchResults) error) (*SearchResults, error) {
 	startTime := time.Now()
 
 	// Reset stats for this search
 	e.stats = SearchStats{StartTime: startTime}
+	e.phases = phaseCounters{}
+	e.slowest.reset(e.config.SlowestFiles)
+	e.fileErrs.reset()
+	e.matched = nil
 
 	// Initialize results
 	results := &SearchResults{
 		Query: pattern,
 		Stats: SearchStats{StartTime: startTime},
 	}
+	if e.config.CountOnly {
+		results.Counts = make(map[string]int)
+	}
 
 	// Initialize engines for this specific pattern
-	_ = e.initializeEngines()
+	if err := e.initializeEngines(); err != nil {
+		return nil, err
+	}
 
-	// Perform the search
-	if err := e.performSearch(ctx, pattern, results); err != nil {
+	// Compile the pattern once so every worker shares the same matcher
+	matcher, err := newLineMatcher(pattern, e.config)
+	if err != nil {
+		return nil, err
+	}
+	e.matcher = matcher
+	if e.patterns, err = newPatternMatchers(e.config); err != nil {
 		return nil, err
 	}
 
+	if err := fn(matcher, results); err != nil {
+		return nil, err
+	}
+	results.Errors = e.fileErrs.sorted()
+	if e.config.StrictErrors && len(results.Errors) > 0 {
+		return nil, fmt.Errorf("search aborted: %w", &results.Errors[0])
+	}
+	if e.config.Sections {
+		results.Matches = Sections()(results.Matches)
+	}
+	if e.config.KeyPaths {
+		results.Matches = KeyPaths()(results.Matches)
+	}
 	// Copy accumulated stats from engine to results
 	results.Stats.FilesScanned = e.stats.FilesScanned
 	results.Stats.FilesSkipped = e.stats.FilesSkipped
 	results.Stats.FilesIgnored = e.stats.FilesIgnored
 	results.Stats.BytesScanned = e.stats.BytesScanned
-	results.Stats.MatchesFound = int64(len(results.Matches))
+	results.Stats.BytesRead = e.stats.BytesRead
+	results.Stats.FilesChanged = e.stats.FilesChanged
+	results.Stats.DirsTruncated = e.stats.DirsTruncated
+	results.Stats.MatchesFound = int64(results.Count())
+	if e.emit != nil {
+		results.Stats.MatchesFound = int64(e.emitted)
+	}
+	results.Stats.Phases = e.phases.timings()
+	results.Stats.SlowestFiles = e.slowest.timings()
+	if e.config.InvertMatch {
+		results.Stats.NonMatchingLines = results.Stats.MatchesFound
+	}
 
 	// Update final stats
 	results.Stats.EndTime = time.Now()
@@ -156,21 +512,315 @@ func (e *SearchEngine) Search(ctx context.Context, pattern string) (*SearchResul
 	return results, nil
 }
 
+// errQuitAfter stops a stream search once the quit-after limit is reached
+var errQuitAfter = errors.New("quit-after limit reached")
+
+// finishMatch records which of several combined patterns produced match,
+// its matched text and character column, which becomes Column in
+// ColumnRunes mode, then cuts its line down to the match with only-matching
+// or drops it when line content is left out.
+// Matches are finished as they are added, so left out lines are never all
+// held at once.
+func (e *SearchEngine) finishMatch(match *Match) {
+	if e.patterns != nil {
+		match.PatternIndex = patternIndex(e.patterns, *match)
+	}
+	if match.MatchStart < match.MatchEnd && match.MatchEnd <= len(match.Content) {
+		match.MatchText = match.Content[match.MatchStart:match.MatchEnd]
+	}
+	if match.MatchStart <= len(match.Content) {
+		match.RuneColumn = utf8.RuneCountInString(match.Content[:match.MatchStart]) + 1
+		if e.config.ColumnMode == ColumnRunes {
+			match.Column = match.RuneColumn
+		}
+	}
+	if e.config.OnlyMatching && match.MatchText != "" {
+		if e.matcher != nil {
+			match.Submatches = e.matcher.submatches(match.Content, match.MatchStart)
+		}
+		// A copy, so the match does not keep the whole line alive
+		match.Content = strings.Clone(match.MatchText)
+		match.MatchText = match.Content
+		match.MatchStart, match.MatchEnd = 0, len(match.Content)
+		match.Spans = []Span{{Start: 0, End: len(match.Content)}}
+	}
+	if e.config.OmitLineContent {
+		// A copy, so the text does not keep the whole line alive
+		match.MatchText = strings.Clone(match.MatchText)
+		match.Content = ""
+	}
+}
+
+// SearchReader searches r, a stream of unknown and possibly unbounded length
+// such as standard input, through the sliding window. Each match is passed to
+// fn as soon as its lines have been read, with File set to name, so memory
+// stays bounded however long the stream runs. With CountOnly fn is never
+// called and the count is reported in the stats. Only QuitAfter ends the
+// search early; MaxResults bounds collected results and does not apply.
+func (e *SearchEngine) SearchReader(ctx context.Context, pattern, name string, r io.Reader, fn func(Match) error) (SearchStats, error) {
+	startTime := time.Now()
+	e.stats = SearchStats{StartTime: startTime}
+	e.phases = phaseCounters{}
+
+	matcher, err := newLineMatcher(pattern, e.config)
+	if err != nil {
+		return e.stats, err
+	}
+	e.matcher = matcher
+	if e.patterns, err = newPatternMatchers(e.config); err != nil {
+		return e.stats, err
+	}
+
+	err = e.streamSearch(ctx, matcher, pattern, name, r, &e.phases.read, func(match Match) error {
+		e.stats.MatchesFound++
+		if !e.config.CountOnly {
+			e.finishMatch(&match)
+			if err := fn(match); err != nil {
+				return err
+			}
+		}
+		if e.reachedQuitAfter(int(e.stats.MatchesFound)) {
+			return errQuitAfter
+		}
+		return nil
+	})
+
+	if err == errQuitAfter {
+		e.stats.StoppedEarly = true
+		err = nil
+	}
+
+	e.stats.FilesScanned = 1
+	e.stats.BytesScanned = e.stats.BytesRead
+	e.stats.Phases = e.phases.timings()
+	if e.config.InvertMatch {
+		e.stats.NonMatchingLines = e.stats.MatchesFound
+	}
+	e.stats.EndTime = time.Now()
+	e.stats.Duration = e.stats.EndTime.Sub(startTime)
+
+	return e.stats, err
+}
+
+// streamSearch searches r through the sliding window, passing each match to
+// fn. Bytes read are added to the stats and the time spent reading to
+// elapsed.
+func (e *SearchEngine) streamSearch(ctx context.Context, matcher *lineMatcher, pattern, name string, r io.Reader, elapsed *int64, fn func(Match) error) error {
+	if e.config.TailBytes > 0 {
+		return fmt.Errorf("tail byte limits are not supported for streams")
+	}
+	if e.hasLineRange() {
+		return fmt.Errorf("line ranges are not supported for streams")
+	}
+
+	options := e.config.StreamingOptions
+	options.Multiline = e.config.Multiline
+	options.IgnoreCase = e.config.ignoresCase(pattern)
+	options.InvertMatch = e.config.InvertMatch
+	options.BeforeContext = e.contextBefore()
+	options.AfterContext = e.contextAfter()
+	options.MaxCount = e.config.MaxCountPerFile
+
+	r = &bomReader{reader: r}
+	if e.config.HeadBytes > 0 {
+		r = &headReader{reader: r, remaining: e.config.HeadBytes}
+	}
+
+	// The matcher's regex already accounts for fixed strings and boundaries
+	var regex *regexp.Regexp
+	if e.config.Multiline {
+		regex = matcher.regex
+	}
+	searcher, err := newSlidingWindowSearcher(pattern, options, regex)
+	if err != nil {
+		return err
+	}
+	searcher.reader = &countingReader{reader: r, count: &e.stats.BytesRead, elapsed: elapsed}
+	searcher.name = name
+	searcher.fileSize = -1
+	searcher.lineSpans = func(line string) [][]int {
+		return e.lineSpans(matcher, line)
+	}
+
+	defer e.phases.since(&e.phases.process, time.Now())
+	return searcher.SearchStream(ctx, fn)
+}
+
+// collectStream searches a single stream as the file name, collecting its
+// matches until limit of them are found (0 for no limit). The bytes read
+// are the bytes scanned, so decompressed sizes are reported for compressed
+// streams.
+func (e *SearchEngine) collectStream(ctx context.Context, matcher *lineMatcher, pattern, name string, r io.Reader, elapsed *int64, limit int) (fileResult, error) {
+	atomic.AddInt64(&e.stats.FilesScanned, 1)
+	read := atomic.LoadInt64(&e.stats.BytesRead)
+	defer func() {
+		atomic.AddInt64(&e.stats.BytesScanned, atomic.LoadInt64(&e.stats.BytesRead)-read)
+	}()
+
+	result := fileResult{file: name}
+	err := e.streamSearch(ctx, matcher, pattern, name, r, elapsed, func(match Match) error {
+		result.count++
+		if !e.config.CountOnly {
+			result.matches = append(result.matches, match)
+		}
+		if limit > 0 && result.count >= limit {
+			return errQuitAfter
+		}
+		return nil
+	})
+	if err == errQuitAfter {
+		err = nil
+	}
+	return result, err
+}
+
+// resultLimit returns how many more matches are worth collecting once total
+// have been found, or 0 when no limit applies. Matches past a result limit
+// would only be dropped.
+func (e *SearchEngine) resultLimit(total int) int {
+	limit := 0
+	if e.config.QuitAfter > 0 {
+		limit = e.config.QuitAfter - total
+	}
+	if !e.config.CountOnly && (limit == 0 || e.config.MaxResults-total < limit) {
+		limit = e.config.MaxResults - total
+	}
+	return limit
+}
+
+// isStreamPath reports whether path names something that can only be read
+// once, from start to end, such as a named pipe, the /dev/fd path of a
+// process substitution or a terminal. Stat reports no size for these and
+// they cannot be seeked, so they are searched as streams.
+func isStreamPath(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil || info.Size() > 0 {
+		return false
+	}
+	return info.Mode()&(os.ModeNamedPipe|os.ModeSocket|os.ModeCharDevice) != 0
+}
+
+// searchStreamPath searches the stream at path, reading it once
+func (e *SearchEngine) searchStreamPath(ctx context.Context, matcher *lineMatcher, pattern, path string, results *SearchResults) error {
+
+	// Name matching never opens the file
+	var result fileResult
+	var err error
+	if e.config.FileNamesOnly {
+		result, err = e.processFile(ctx, pattern, path)
+	} else {
+		var file *os.File
+		file, err = os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		result, err = e.collectStream(ctx, matcher, pattern, path, file, &e.phases.read, e.resultLimit(0))
+	}
+	if err != nil && ctx.Err() == nil {
+		return err
+	}
+
+	total := 0
+	if result.count > 0 {
+		e.addResult(results, result, &total)
+	}
+	return nil
+}
+
+// headReader returns the first remaining bytes of a stream. Like
+// byteRangeSearch it drops the line cut by the limit, unless the stream ends
+// right at the limit; that line is held back until it is known to be complete.
+type headReader struct {
+	reader    io.Reader
+	remaining int64
+	ready     []byte // Complete lines that can be returned
+	partial   []byte // Bytes after the last newline read so far
+	done      bool
+}
+
+// Read implements io.Reader
+func (h *headReader) Read(p []byte) (int, error) {
+	for len(h.ready) == 0 {
+		if h.done {
+			return 0, io.EOF
+		}
+
+		if h.remaining == 0 {
+			// Keep the partial line only if nothing follows it
+			var probe [1]byte
+			if n, _ := io.ReadFull(h.reader, probe[:]); n == 0 {
+				h.ready = h.partial
+			}
+			h.partial = nil
+			h.done = true
+			continue
+		}
+
+		buf := make([]byte, min(int64(len(p)), h.remaining))
+		n, err := h.reader.Read(buf)
+		h.remaining -= int64(n)
+		h.partial = append(h.partial, buf[:n]...)
+		if idx := bytes.LastIndexByte(h.partial, '\n'); idx != -1 {
+			h.ready = h.partial[:idx+1]
+			h.partial = append([]byte(nil), h.partial[idx+1:]...)
+		}
+
+		if err == io.EOF {
+			h.ready = append(h.ready, h.partial...)
+			h.partial = nil
+			h.done = true
+		} else if err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(p, h.ready)
+	h.ready = h.ready[n:]
+	return n, nil
+}
+
 // performSearch executes the actual search using the configured engines
 func (e *SearchEngine) performSearch(ctx context.Context, pattern string, results *SearchResults) error {
-	// Create channels for communication
-	filesChan := make(chan string, e.config.MaxWorkers*2)
-	resultsChan := make(chan []Match, e.config.MaxWorkers)
+	// Cancelling stops the walker and workers once a result limit is hit
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	e.abort = cancel
 
-	// Start workers
+	// Create channels for communication
+	filesChan := make(chan walkedFile, e.config.MaxWorkers*2)
+	resultsChan := make(chan fileResult, e.config.MaxWorkers)
+
+	// Workers stop the walk alone at the MaxFiles and MaxBytes limits, so the
+	// files they are searching still finish
+	walkCtx, stopWalk := context.WithCancel(ctx)
+	defer stopWalk()
+
+	// Start workers, dealing files out to their groups when there are several
+	e.claimed = 0
+	e.found = 0
+	e.foundSeq = 0
+	e.enough.Store(false)
+	e.exhausted.Store(false)
+	engines, groupChans, err := e.startWorkerGroups(pattern, filesChan)
+	if err != nil {
+		return err
+	}
+	cpus := e.groupCPUs(len(engines))
 	var wg sync.WaitGroup
 	for i := 0; i < e.config.MaxWorkers; i++ {
+		group := i % len(engines)
 		wg.Add(1)
-		go e.searchWorker(ctx, pattern, filesChan, resultsChan, &wg)
+		go func() {
+			if cpus != nil {
+				pinThread(cpus[group])
+			}
+			engines[group].searchWorker(ctx, stopWalk, cancel, pattern, groupChans[group], resultsChan, &wg)
+		}()
 	}
 
 	// Start file walker
-	go e.walkFiles(ctx, filesChan)
+	go e.walkFiles(walkCtx, filesChan)
 
 	// Collect results
 	go func() {
@@ -178,47 +828,472 @@ func (e *SearchEngine) performSearch(ctx context.Context, pattern string, result
 		close(resultsChan)
 	}()
 
-	// Process results
-	for workerResults := range resultsChan {
-		results.Matches = append(results.Matches, workerResults...)
-		e.stats.MatchesFound += int64(len(workerResults))
-
-		// Check if we've hit the max results limit
-		if len(results.Matches) >= e.config.MaxResults {
+	// Process results, in walk order when asked to
+	var collected <-chan fileResult = resultsChan
+	if e.config.Deterministic {
+		collected = sequenceResults(resultsChan, e.config.MaxWorkers)
+	}
+	total := 0
+	for result := range collected {
+		if e.addResult(results, result, &total) {
 			break
 		}
 	}
 
+	// Drain in-flight results so every worker can exit before stats are read
+	cancel()
+	for range collected {
+	}
+	e.mergeWorkerGroups()
+
+	if e.exhausted.Load() {
+		results.Stats.StoppedEarly = true
+	}
 	return nil
 }
 
-// searchWorker processes files from the files channel
-func (e *SearchEngine) searchWorker(ctx context.Context, pattern string, filesChan <-chan string, resultsChan chan<- []Match, wg *sync.WaitGroup) {
+// addResult adds a file's result to results and total, reporting whether a
+// result limit has been reached and the search should stop
+func (e *SearchEngine) addResult(results *SearchResults, result fileResult, total *int) bool {
+	if e.config.CountOnly {
+		results.Counts[result.file] += result.count
+	} else {
+		for i := range result.matches {
+			e.finishMatch(&result.matches[i])
+		}
+		if e.emit != nil {
+			e.emitMatches(result.matches, *total)
+		} else {
+			results.Matches = append(results.Matches, result.matches...)
+		}
+	}
+	*total += result.count
+	e.stats.MatchesFound += int64(result.count)
+
+	// Check if we've hit the quit-after or matching files limit, or the max
+	// results limit which only bounds collected matches
+	if !e.reachedQuitAfter(*total) && !e.reachedMaxMatchFiles(result) && (e.config.CountOnly || *total < e.config.MaxResults) {
+		return false
+	}
+	results.Stats.StoppedEarly = true
+
+	// Report exactly QuitAfter matches, and at most MaxResults
+	if excess := *total - e.config.QuitAfter; e.config.QuitAfter > 0 && excess > 0 {
+		if e.config.CountOnly {
+			results.Counts[result.file] -= excess
+		} else if e.emit == nil {
+			results.Matches = results.Matches[:e.config.QuitAfter]
+		}
+	}
+	if !e.config.CountOnly && e.emit == nil && e.config.MaxResults > 0 && len(results.Matches) > e.config.MaxResults {
+		results.Matches = results.Matches[:e.config.MaxResults]
+	}
+	return true
+}
+
+// emitMatches passes the matches of one file to e.emit, after the stages
+// Search would otherwise apply to all results and stopping at QuitAfter.
+// total counts the matches already emitted.
+func (e *SearchEngine) emitMatches(matches []Match, total int) {
+	if e.config.QuitAfter > 0 {
+		matches = matches[:max(min(len(matches), e.config.QuitAfter-total), 0)]
+	}
+	if e.config.Sections {
+		matches = Sections()(matches)
+	}
+	if e.config.KeyPaths {
+		matches = KeyPaths()(matches)
+	}
+	if len(matches) > 0 {
+		e.emitted += len(matches)
+		e.emit(matches)
+	}
+}
+
+// fileResult is what a worker reports for a single file
+type fileResult struct {
+	file    string
+	matches []Match // Left empty in count-only mode
+	count   int
+	seq     int  // Walk order of the file searched, or of the archive holding it
+	last    bool // Marks the end of the results of file seq, for sequenceResults
+}
+
+// walkedFile is a file found by the walker, numbered in walk order
+type walkedFile struct {
+	path   string
+	seq    int
+	binary bool // Only report whether it matches (BinaryReport)
+}
+
+// sequenceResults passes on results in the walk order of their files. Each
+// file's results are held back until every file walked before it has
+// reported in with its last result; those left when in closes, after files
+// were skipped by a stopped search, are passed on in order.
+func sequenceResults(in <-chan fileResult, size int) <-chan fileResult {
+	out := make(chan fileResult, size)
+	go func() {
+		defer close(out)
+
+		pending := make(map[int][]fileResult)
+		finished := make(map[int]bool)
+		next := 0
+		for result := range in {
+			if result.last {
+				finished[result.seq] = true
+			} else {
+				pending[result.seq] = append(pending[result.seq], result)
+			}
+			for finished[next] {
+				for _, held := range pending[next] {
+					out <- held
+				}
+				delete(pending, next)
+				delete(finished, next)
+				next++
+			}
+		}
+
+		seqs := make([]int, 0, len(pending))
+		for seq := range pending {
+			seqs = append(seqs, seq)
+		}
+		sort.Ints(seqs)
+		for _, seq := range seqs {
+			for _, held := range pending[seq] {
+				out <- held
+			}
+		}
+	}()
+	return out
+}
+
+// reachedQuitAfter reports whether count matches satisfy the QuitAfter limit
+func (e *SearchEngine) reachedQuitAfter(count int) bool {
+	return e.config.QuitAfter > 0 && count >= e.config.QuitAfter
+}
+
+// reachedMaxMatchFiles counts the file of result if it has matches and
+// reports whether the MaxMatchFiles limit is now satisfied. The last file
+// keeps all its matches.
+func (e *SearchEngine) reachedMaxMatchFiles(result fileResult) bool {
+	if e.config.MaxMatchFiles <= 0 {
+		return false
+	}
+	if result.count > 0 {
+		if e.matched == nil {
+			e.matched = make(map[string]struct{})
+		}
+		e.matched[result.file] = struct{}{}
+	}
+	return len(e.matched) >= e.config.MaxMatchFiles
+}
+
+// claimFile reports whether the MaxFiles and MaxBytes limits leave room to
+// search another file, counting it against MaxFiles. Files already being
+// searched finish, so MaxBytes can be overshot by their sizes.
+func (e *SearchEngine) claimFile() bool {
+	if e.owner != nil {
+		return e.owner.claimFile()
+	}
+	if e.config.MaxBytes > 0 && e.bytesScanned() >= e.config.MaxBytes {
+		e.exhausted.Store(true)
+		return false
+	}
+	if e.config.MaxFiles > 0 && atomic.AddInt64(&e.claimed, 1) > int64(e.config.MaxFiles) {
+		e.exhausted.Store(true)
+		return false
+	}
+	return true
+}
+
+// addFound counts the matches a worker found in file seq and reports whether
+// the QuitAfter or MaxResults limit is now reached
+func (e *SearchEngine) addFound(count, seq int) bool {
+	if e.owner != nil {
+		return e.owner.addFound(count, seq)
+	}
+	for {
+		latest := atomic.LoadInt64(&e.foundSeq)
+		if int64(seq) <= latest || atomic.CompareAndSwapInt64(&e.foundSeq, latest, int64(seq)) {
+			break
+		}
+	}
+	found := atomic.AddInt64(&e.found, int64(count))
+	if limit := e.resultLimit(0); limit > 0 && found >= int64(limit) {
+		e.enough.Store(true)
+	}
+	return e.enough.Load()
+}
+
+// needlessFile reports whether file seq cannot contribute to the results
+// because files walked before it already hold enough matches for the limits
+func (e *SearchEngine) needlessFile(seq int) bool {
+	if e.owner != nil {
+		return e.owner.needlessFile(seq)
+	}
+	return e.enough.Load() && int64(seq) > atomic.LoadInt64(&e.foundSeq)
+}
+
+// searchWorker processes files from the files channel, calling stopWalk
+// once the MaxFiles or MaxBytes limit is reached. Once the files searched
+// hold enough matches for QuitAfter or MaxResults it stops the walk too and
+// skips the files walked after them. Unless results must keep walk order,
+// where earlier files still being searched are needed, it also calls
+// stopSearch to abandon the files in flight.
+func (e *SearchEngine) searchWorker(ctx context.Context, stopWalk, stopSearch context.CancelFunc, pattern string, filesChan <-chan walkedFile, resultsChan chan<- fileResult, wg *sync.WaitGroup) {
 	defer wg.Done()
 
-	for filePath := range filesChan {
+	for file := range filesChan {
 		select {
 		case <-ctx.Done():
-			return
+			// Keep consuming so the walker is never blocked on a send
+			continue
 		default:
-			// Track file size for bytes scanned
-			if info, err := os.Stat(filePath); err == nil {
-				e.stats.BytesScanned += info.Size()
+			if e.needlessFile(file.seq) {
+				if e.config.Deterministic {
+					resultsChan <- fileResult{seq: file.seq, last: true}
+				}
+				continue
 			}
-
-			fileResults, err := e.searchFile(ctx, pattern, filePath)
-			if err != nil {
-				// Log error but continue processing
+			if !e.claimFile() {
+				stopWalk()
 				continue
 			}
+			results := e.searchWalked(ctx, pattern, file)
+			found := 0
+			for _, result := range results {
+				found += result.count
+			}
+			if found > 0 && e.addFound(found, file.seq) {
+				stopWalk()
+				if !e.config.Deterministic {
+					stopSearch()
+				}
+			}
+			for _, result := range results {
+				result.seq = file.seq
+				resultsChan <- result
+			}
+			if e.config.Deterministic {
+				// Every file reports in, so the files after it can be released
+				resultsChan <- fileResult{seq: file.seq, last: true}
+			}
+		}
+	}
+}
+
+// searchWalked searches a file found by the walker, returning the result of
+// the file, or of each member of an archive, that matched. Errors skip the
+// file, though archive members searched before one are still reported.
+func (e *SearchEngine) searchWalked(ctx context.Context, pattern string, file walkedFile) []fileResult {
+	if e.searchesArchive(file.path) {
+		matcher, err := e.getMatcher(pattern)
+		if err != nil {
+			return nil
+		}
+		members, err := e.searchArchive(ctx, matcher, pattern, file.path)
+		if err != nil {
+			e.fileError(file.path, err)
+		}
+		return members
+	}
 
-			if len(fileResults) > 0 {
-				resultsChan <- fileResults
+	result, err := e.searchWalkedFile(ctx, pattern, file.path)
+	if err != nil {
+		e.fileError(file.path, err)
+		return nil
+	}
+	if result.count == 0 {
+		return nil
+	}
+	if file.binary {
+		result = reportBinary(result)
+	}
+	return []fileResult{result}
+}
+
+// reportBinary reduces the content matches of a binary file to a single
+// MatchBinary match, so none of its content is reported. Counts are kept.
+func reportBinary(result fileResult) fileResult {
+	if result.matches == nil {
+		return result
+	}
+
+	var matches []Match
+	reported := false
+	for _, match := range result.matches {
+		if match.Kind != MatchContent {
+			matches = append(matches, match)
+		} else if !reported {
+			matches = append(matches, Match{File: match.File, Line: match.Line, Kind: MatchBinary, PatternIndex: match.PatternIndex})
+			reported = true
+		}
+	}
+	result.matches = matches
+	result.count = len(matches)
+	return result
+}
+
+// searchWalkedFile searches a file found by the walker, streaming it through
+// a decompressor when compressed files are searched. The decompressed bytes
+// are counted as scanned.
+func (e *SearchEngine) searchWalkedFile(ctx context.Context, pattern, filePath string) (fileResult, error) {
+	if e.compression == nil {
+		return e.processFile(ctx, pattern, filePath)
+	}
+	matcher, err := e.getMatcher(pattern)
+	if err != nil {
+		return fileResult{file: filePath}, err
+	}
+	return e.searchLogFile(ctx, e.compression, matcher, pattern, filePath, 0)
+}
+
+// searchesArchive reports whether the file at path is searched as an archive
+// of members rather than as a file
+func (e *SearchEngine) searchesArchive(path string) bool {
+	return e.archives != nil && !e.config.FileNamesOnly && e.archives.IsArchive(path)
+}
+
+// searchArchive searches each member of the archive at path as a file named
+// by its virtual archive!member path, returning a result for each member
+// that matched. Binary members are skipped, compressed ones are decompressed
+// when compressed files are searched, and results found before an error are
+// returned along with it.
+func (e *SearchEngine) searchArchive(ctx context.Context, matcher *lineMatcher, pattern, path string) ([]fileResult, error) {
+	var results []fileResult
+	err := e.archives.Walk(ctx, path, func(member ArchiveMember, r io.Reader) error {
+		if e.compression != nil {
+			if compression := e.compression.DetectCompressionByExtension(member.Path); compression != CompressionNone {
+				decompressed, err := e.compression.DecompressReader(r, compression)
+				if err != nil {
+					return nil
+				}
+				if closer, ok := decompressed.(io.Closer); ok {
+					defer closer.Close()
+				}
+				r = decompressed
 			}
+		}
+
+		// Like git, treat a NUL byte near the start as binary
+		buffered := bufio.NewReader(r)
+		head, _ := buffered.Peek(512)
+		binary := e.config.BinaryMode != BinaryText && !hasBOM(head) && bytes.IndexByte(head, 0) >= 0
+		if binary && e.config.BinaryMode == BinarySkip {
+			return nil
+		}
 
-			e.stats.FilesScanned++
+		result, err := e.collectStream(ctx, matcher, pattern, member.Path, buffered, &e.phases.decompress, e.resultLimit(0))
+		if binary {
+			result = reportBinary(result)
+		}
+		if result.count > 0 {
+			results = append(results, result)
+		}
+		return err
+	})
+	return results, err
+}
+
+// processFile matches a single file's name, metadata and contents as configured
+func (e *SearchEngine) processFile(ctx context.Context, pattern string, filePath string) (fileResult, error) {
+	defer e.phases.since(&e.phases.process, time.Now())
+	result := fileResult{file: filePath}
+
+	matcher, err := e.getMatcher(pattern)
+	if err != nil {
+		return result, err
+	}
+
+	// Name matching never opens the file
+	if e.config.FileNamesOnly {
+		atomic.AddInt64(&e.stats.FilesScanned, 1)
+		if match, ok := fileNameMatch(matcher, filePath, e.config.InvertMatch); ok {
+			result.count = 1
+			if !e.config.CountOnly {
+				result.matches = []Match{match}
+			}
+		}
+		return result, nil
+	}
+
+	if e.config.CountOnly {
+		result.count, err = e.countFile(ctx, matcher, pattern, filePath)
+	} else {
+		result.matches, err = e.searchFile(ctx, pattern, filePath)
+		result.count = len(result.matches)
+	}
+	if err != nil {
+		return result, err
+	}
+
+	if e.config.SearchMetadata && !e.config.InvertMatch {
+		metadata := metadataMatches(matcher, filePath)
+		result.count += len(metadata)
+		if !e.config.CountOnly {
+			result.matches = append(metadata, result.matches...)
+		}
+	}
+
+	return result, nil
+}
+
+// countFile counts the matches in a file without building Match values. Byte
+// ranges and multiline modes that need extra bookkeeping count the results of
+// a regular search instead.
+func (e *SearchEngine) countFile(ctx context.Context, matcher *lineMatcher, pattern string, filePath string) (int, error) {
+	if e.config.HeadBytes > 0 || e.config.TailBytes > 0 ||
+		(e.config.Multiline && (e.hasLineRange() || e.config.InvertMatch || e.config.MaxCountPerFile > 0)) {
+		matches, err := e.searchFile(ctx, pattern, filePath)
+		return len(matches), err
+	}
+
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return 0, err
+	}
+	atomic.AddInt64(&e.stats.FilesScanned, 1)
+	atomic.AddInt64(&e.stats.BytesScanned, info.Size())
+
+	if e.config.Multiline {
+		data, err := e.readFile(filePath)
+		if err != nil {
+			return 0, err
+		}
+		return len(matcher.regex.FindAllIndex(data, -1)), nil
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	count, matchedLines := 0, 0
+	scanner := bufio.NewScanner(e.fileReader(file))
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		if lineNum%1000 == 0 && ctx.Err() != nil {
+			return count, ctx.Err()
+		}
+
+		// Stop reading once the end of the line range has been passed
+		if e.config.LineEnd > 0 && lineNum > e.config.LineEnd {
+			break
+		}
+		if lineNum < e.config.LineStart {
+			continue
+		}
+		if spans := e.lineSpans(matcher, scanner.Text()); len(spans) > 0 {
+			count += len(spans)
+			matchedLines++
+			if e.reachedMaxCount(matchedLines) {
+				break
+			}
 		}
 	}
+
+	return count, scanner.Err()
 }
 
 // searchFile processes an individual file (updated to support memory mapping)
@@ -236,13 +1311,69 @@ func (e *SearchEngine) searchFile(ctx context.Context, pattern string, filePath
 		return nil, err
 	}
 
-	// Track stats
-	e.stats.FilesScanned++
-	e.stats.BytesScanned += info.Size()
+	// Count every searched file once, whichever strategy reads it
+	atomic.AddInt64(&e.stats.FilesScanned, 1)
+	atomic.AddInt64(&e.stats.BytesScanned, info.Size())
+
+	// Active files such as logs can change under the search. Each strategy
+	// copes with that on its own; here the event is only recorded.
+	start := time.Now()
+	matches, err := e.searchFileContents(ctx, pattern, filePath, info)
+	matches = limitMatchLines(matches, e.config.MaxCountPerFile)
+	if e.config.SlowestFiles > 0 {
+		e.recordFileTiming(FileTiming{Path: filePath, Size: info.Size(), Duration: time.Since(start)})
+	}
+	if err == nil && fileChanged(filePath, info) {
+		atomic.AddInt64(&e.stats.FilesChanged, 1)
+	}
+	return matches, err
+}
+
+// recordFileTiming records the time spent on a file for SlowestFiles,
+// with the owning engine when e is a worker group's
+func (e *SearchEngine) recordFileTiming(timing FileTiming) {
+	if e.owner != nil {
+		e.owner.recordFileTiming(timing)
+		return
+	}
+	e.slowest.record(timing)
+}
+
+// fileChanged reports whether the file at path no longer has the size and
+// modification time recorded in before, including when it has been removed
+func fileChanged(path string, before os.FileInfo) bool {
+	after, err := os.Stat(path)
+	if err != nil {
+		return true
+	}
+	return after.Size() != before.Size() || !after.ModTime().Equal(before.ModTime())
+}
+
+// searchFileContents picks a strategy for reading the file by its size and
+// the configuration, and searches it
+func (e *SearchEngine) searchFileContents(ctx context.Context, pattern string, filePath string, info os.FileInfo) ([]Match, error) {
+	// Head and tail modes read a single block from one end of the file
+	if e.config.HeadBytes > 0 || e.config.TailBytes > 0 {
+		return e.byteRangeSearch(ctx, pattern, filePath, info.Size())
+	}
+
+	// Multiline patterns need whole buffers rather than individual lines
+	if e.config.Multiline {
+		if e.config.StreamingSearch && !e.hasLineRange() && info.Size() > e.config.LargeSizeThreshold {
+			return e.streamingSearch(ctx, pattern, filePath)
+		}
+		return e.multilineSearch(ctx, pattern, filePath)
+	}
+
+	// The line scanner tracks exact line numbers and stops reading at the
+	// end of the range, so line-restricted searches always use it
+	if e.hasLineRange() {
+		return e.simpleSearch(ctx, pattern, filePath)
+	}
 
 	// Use memory-mapped files for large files if enabled
 	if e.config.MemoryMappedFiles && info.Size() > 1024*1024 { // 1MB threshold
-		return e.mmapSearch(ctx, pattern, filePath, info.Size())
+		return e.mmapSearch(ctx, pattern, filePath)
 	}
 
 	// Use streaming search for large files if enabled and file is above threshold
@@ -254,8 +1385,48 @@ func (e *SearchEngine) searchFile(ctx context.Context, pattern string, filePath
 	return e.simpleSearch(ctx, pattern, filePath)
 }
 
+// lineSpans returns the spans to report for line. In invert mode a line
+// without any match is reported once, as an empty span at column 1.
+func (e *SearchEngine) lineSpans(matcher *lineMatcher, line string) [][]int {
+	if e.config.InvertMatch {
+		if matcher.matches(line) {
+			return nil
+		}
+		return [][]int{{0, 0}}
+	}
+	return matcher.findAll(line)
+}
+
+// multilineResults matches a whole buffer, inverting the result if requested
+func (e *SearchEngine) multilineResults(filePath string, data []byte, matcher *lineMatcher, firstLine int) []Match {
+	matches := multilineMatches(filePath, data, matcher.regex, firstLine)
+	if e.config.InvertMatch {
+		return invertMultilineMatches(filePath, data, matches, firstLine)
+	}
+	return matches
+}
+
+// hasLineRange reports whether searches are limited to part of each file
+func (e *SearchEngine) hasLineRange() bool {
+	return e.config.LineStart > 1 || e.config.LineEnd > 0
+}
+
+// inLineRange reports whether line falls within the configured line range
+func (e *SearchEngine) inLineRange(line int) bool {
+	return line >= e.config.LineStart && (e.config.LineEnd == 0 || line <= e.config.LineEnd)
+}
+
+// getMatcher returns the matcher compiled for pattern, building one if the
+// engine was not prepared through Search
+func (e *SearchEngine) getMatcher(pattern string) (*lineMatcher, error) {
+	if e.matcher != nil && e.matcher.pattern == pattern {
+		return e.matcher, nil
+	}
+	return newLineMatcher(pattern, e.config)
+}
+
 // mmapSearch performs memory-mapped file search for large files
-func (e *SearchEngine) mmapSearch(ctx context.Context, pattern string, filePath string, fileSize int64) ([]Match, error) {
+func (e *SearchEngine) mmapSearch(ctx context.Context, pattern string, filePath string) ([]Match, error) {
 	// Open the file
 	file, err := os.Open(filePath)
 	if err != nil {
@@ -263,112 +1434,316 @@ func (e *SearchEngine) mmapSearch(ctx context.Context, pattern string, filePath
 	}
 	defer file.Close()
 
+	// Map the size of the open file, which may differ from the size seen
+	// when the strategy was chosen
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	fileSize := info.Size()
 	if fileSize == 0 {
 		return nil, nil
 	}
 
 	// Memory map the file
-	data, err := syscall.Mmap(int(file.Fd()), 0, int(fileSize), syscall.PROT_READ, syscall.MAP_PRIVATE)
+	mapping, err := mapFile(file, fileSize)
 	if err != nil {
-		// Fallback to regular search if mmap fails
+		// Fallback to regular search if mmap fails or is unsupported
+		e.logDebug("mmap fallback", "path", filePath, "error", err)
 		return e.simpleSearch(ctx, pattern, filePath)
 	}
-	defer func() {
-		if unmapErr := syscall.Munmap(data); unmapErr != nil {
-			// Log error but don't fail the search
-			_ = unmapErr
-		}
-	}()
+	defer mapping.Close()
 
-	// Convert bytes to string safely
-	content := string(data)
+	matcher, err := e.getMatcher(pattern)
+	if err != nil {
+		return nil, err
+	}
 
-	// Split into lines efficiently
+	// Converting to a string copies, and so reads, the whole mapping
+	readStart := time.Now()
+	content, err := mapping.contents()
+	e.phases.since(&e.phases.read, readStart)
+	if err != nil {
+		// The file was truncated after it was mapped; read what is left
+		return e.simpleSearch(ctx, pattern, filePath)
+	}
+	e.addBytesRead(int64(mapping.Len()))
+	content, ok := stripBOMString(content)
+	if !ok {
+		// UTF-16 is decoded while reading the file
+		return e.simpleSearch(ctx, pattern, filePath)
+	}
 	lines := strings.Split(content, "\n")
 
-	// Compile regex
-	var regex *regexp.Regexp
-	if e.config.IgnoreCase {
-		regex, err = regexp.Compile(`(?i)` + pattern)
-	} else {
-		regex, err = regexp.Compile(pattern)
+	return e.searchLines(ctx, matcher, filePath, lines)
+}
+
+// limitMatchLines keeps the matches, in line order, on the first n lines
+// that have any, as grep -m does; n of 0 keeps them all
+func limitMatchLines(matches []Match, n int) []Match {
+	if n <= 0 {
+		return matches
+	}
+	lines := 0
+	for i, match := range matches {
+		if i == 0 || match.Line != matches[i-1].Line {
+			if lines == n {
+				return matches[:i]
+			}
+			lines++
+		}
+	}
+	return matches
+}
+
+// matchLines counts the lines that matches, in line order, are on
+func matchLines(matches []Match) int {
+	lines := 0
+	for i, match := range matches {
+		if i == 0 || match.Line != matches[i-1].Line {
+			lines++
+		}
+	}
+	return lines
+}
+
+// reachedMaxCount reports whether lines lines with matches reach the
+// per-file MaxCountPerFile limit, so the rest of the file need not be read
+func (e *SearchEngine) reachedMaxCount(lines int) bool {
+	return e.config.MaxCountPerFile > 0 && lines >= e.config.MaxCountPerFile
+}
+
+// searchLines matches every line of an in-memory file; lines[0] is line 1
+func (e *SearchEngine) searchLines(ctx context.Context, matcher *lineMatcher, filePath string, lines []string) ([]Match, error) {
+	var matches []Match
+	matchedLines := 0
+
+	// Search each line
+	for lineNum, line := range lines {
+		// Check for context cancellation periodically
+		if lineNum%1000 == 0 {
+			select {
+			case <-ctx.Done():
+				return matches, ctx.Err()
+			default:
+			}
+		}
+
+		if !e.inLineRange(lineNum + 1) {
+			continue
+		}
+
+		// Find all matches in this line
+		indices := e.lineSpans(matcher, line)
+		allSpans := newSpans(indices)
+		for _, match := range indices {
+			matchObj := Match{
+				File:       filePath,
+				Line:       lineNum + 1,
+				Column:     match[0] + 1,
+				Content:    line,
+				MatchStart: match[0],
+				MatchEnd:   match[1],
+				Spans:      allSpans,
+			}
+
+			// Add context lines if requested
+			if e.hasContext() {
+				matchObj.BeforeContext, matchObj.AfterContext = surroundingLines(
+					lines, lineNum, lineNum, e.contextBefore(), e.contextAfter())
+			}
+
+			matches = append(matches, matchObj)
+		}
+
+		if len(indices) > 0 {
+			matchedLines++
+			if e.reachedMaxCount(matchedLines) {
+				break
+			}
+		}
+	}
+
+	return matches, nil
+}
+
+// byteRangeSearch searches only the first HeadBytes or last TailBytes of a
+// file. Lines cut by the range boundary are skipped, and in tail mode line
+// numbers count from the first complete line of the tail.
+func (e *SearchEngine) byteRangeSearch(ctx context.Context, pattern string, filePath string, fileSize int64) ([]Match, error) {
+	matcher, err := e.getMatcher(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	offset, length := int64(0), fileSize
+	if e.config.HeadBytes > 0 && e.config.HeadBytes < fileSize {
+		length = e.config.HeadBytes
+	}
+	if e.config.TailBytes > 0 && e.config.TailBytes < fileSize {
+		offset = fileSize - e.config.TailBytes
+		length = e.config.TailBytes
+	}
+
+	// A tail range also reads the byte before it, to tell whether the range
+	// starts on a line of its own
+	readOffset := offset
+	if offset > 0 {
+		readOffset--
+	}
+	data := make([]byte, offset+length-readOffset)
+	readStart := time.Now()
+	n, err := file.ReadAt(data, readOffset)
+	e.phases.since(&e.phases.read, readStart)
+	e.addBytesRead(max(int64(n)-(offset-readOffset), 0))
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	data = data[:n]
+	if offset == 0 {
+		data = stripBOM(data)
+	}
+
+	// Drop the partial line at each edge of the range
+	if offset > 0 {
+		idx := bytes.IndexByte(data, '\n')
+		if idx == -1 {
+			return nil, nil
+		}
+		data = data[idx+1:]
+	}
+	if readOffset+int64(n) < fileSize {
+		data = data[:bytes.LastIndexByte(data, '\n')+1]
+	}
+	data = bytes.TrimSuffix(data, []byte{'\n'})
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	if e.config.Multiline {
+		matches := e.multilineResults(filePath, data, matcher, 1)
+		if e.hasContext() {
+			lines := strings.Split(string(data), "\n")
+			for i := range matches {
+				matches[i].BeforeContext, matches[i].AfterContext = surroundingLines(
+					lines, matches[i].Line-1, matches[i].EndLine-1, e.contextBefore(), e.contextAfter())
+			}
+		}
+		return matches, nil
 	}
+
+	return e.searchLines(ctx, matcher, filePath, strings.Split(string(data), "\n"))
+}
+
+// multilineSearch reads the whole file and matches the pattern across line boundaries
+func (e *SearchEngine) multilineSearch(ctx context.Context, pattern string, filePath string) ([]Match, error) {
+	matcher, err := e.getMatcher(pattern)
 	if err != nil {
 		return nil, err
 	}
 
-	var matches []Match
-
-	// Search each line
-	for lineNum, line := range lines {
-		// Check for context cancellation periodically
-		if lineNum%1000 == 0 {
-			select {
-			case <-ctx.Done():
-				return matches, ctx.Err()
-			default:
-			}
-		}
+	data, err := e.readFile(filePath)
+	if err != nil {
+		return nil, err
+	}
 
-		// Find all matches in this line
-		indices := regex.FindAllStringIndex(line, -1)
-		for _, match := range indices {
-			matchObj := Match{
-				File:    filePath,
-				Line:    lineNum + 1,
-				Column:  match[0] + 1,
-				Content: line,
-			}
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
 
-			// Add context lines if requested
-			if e.config.ContextLines > 0 {
-				matchObj.Context = e.extractContextLinesFromSlice(lines, lineNum, e.config.ContextLines)
-			}
+	var matches []Match
+	if e.hasLineRange() {
+		matches = e.multilineRangeMatches(filePath, data, matcher)
+	} else {
+		matches = e.multilineResults(filePath, data, matcher, 1)
+	}
 
-			matches = append(matches, matchObj)
+	if e.hasContext() && len(matches) > 0 {
+		lines := strings.Split(string(data), "\n")
+		for i := range matches {
+			matches[i].BeforeContext, matches[i].AfterContext = surroundingLines(
+				lines, matches[i].Line-1, matches[i].EndLine-1, e.contextBefore(), e.contextAfter())
 		}
 	}
 
 	return matches, nil
 }
 
-// extractContextLinesFromSlice extracts context lines from a string slice
-func (e *SearchEngine) extractContextLinesFromSlice(lines []string, centerLine int, contextLines int) []string {
-	var contextResult []string
-
-	// Add lines before the match
-	for i := contextLines; i > 0; i-- {
-		lineIndex := centerLine - i
-		if lineIndex >= 0 && lineIndex < len(lines) {
-			contextResult = append(contextResult, lines[lineIndex])
+// multilineRangeMatches matches within the configured line range only. Matches
+// must start and end inside the range.
+func (e *SearchEngine) multilineRangeMatches(filePath string, data []byte, matcher *lineMatcher) []Match {
+	start, end := 0, len(data)
+	line := 1
+	for i := 0; i < len(data); i++ {
+		if data[i] != '\n' {
+			continue
 		}
-	}
-
-	// Add lines after the match
-	for i := 1; i <= contextLines; i++ {
-		lineIndex := centerLine + i
-		if lineIndex >= 0 && lineIndex < len(lines) {
-			contextResult = append(contextResult, lines[lineIndex])
+		line++
+		if line == e.config.LineStart {
+			start = i + 1
+		}
+		if e.config.LineEnd > 0 && line == e.config.LineEnd+1 {
+			end = i
+			break
 		}
 	}
+	if e.config.LineStart > line {
+		return nil
+	}
 
-	return contextResult
+	firstLine := e.config.LineStart
+	if firstLine < 1 {
+		firstLine = 1
+	}
+	return e.multilineResults(filePath, data[start:end], matcher, firstLine)
 }
 
 // streamingSearch performs streaming search on large files using the sliding window approach
 func (e *SearchEngine) streamingSearch(ctx context.Context, pattern string, filePath string) ([]Match, error) {
+	// Line-mode streaming only finds plain substrings, which cannot enforce
+	// boundaries or normalize lines
+	if (e.config.WordRegexp || e.config.LineRegexp || e.config.Normalizer != nil || e.config.CaseFolder != nil) && !e.config.Multiline {
+		return e.simpleSearch(ctx, pattern, filePath)
+	}
+	pattern = boundaryPattern(pattern, e.config.WordRegexp, e.config.LineRegexp)
+
+	options := e.config.StreamingOptions
+	options.Multiline = e.config.Multiline
+	options.IgnoreCase = e.config.ignoresCase(pattern)
+	options.InvertMatch = e.config.InvertMatch
+	options.BeforeContext = e.contextBefore()
+	options.AfterContext = e.contextAfter()
+	options.MaxCount = e.config.MaxCountPerFile
+
 	// Create a sliding window searcher with the configured options
-	searcher, err := NewSlidingWindowSearcher(filePath, pattern, e.config.StreamingOptions)
+	searcher, err := NewSlidingWindowSearcher(filePath, pattern, options)
 	if err != nil {
-		// Fall back to simple search if streaming search fails to initialize
+		// Fall back to an in-memory search if streaming search fails to initialize
+		if e.config.Multiline {
+			return e.multilineSearch(ctx, pattern, filePath)
+		}
 		return e.simpleSearch(ctx, pattern, filePath)
 	}
 	defer searcher.Close()
 
-	// Perform the streaming search
+	// Reading and matching are interleaved in the sliding window, so the whole
+	// streaming search counts as processing time
 	matches, err := searcher.Search(ctx)
+	bytesProcessed, _, _ := searcher.GetProgress()
+	e.addBytesRead(bytesProcessed)
 	if err != nil {
-		// Fall back to simple search if streaming search fails
+		// Fall back to an in-memory search if streaming search fails
+		if e.config.Multiline {
+			return e.multilineSearch(ctx, pattern, filePath)
+		}
 		return e.simpleSearch(ctx, pattern, filePath)
 	}
 
@@ -377,36 +1752,42 @@ func (e *SearchEngine) streamingSearch(ctx context.Context, pattern string, file
 
 // simpleSearch performs a basic search without optimization
 func (e *SearchEngine) simpleSearch(ctx context.Context, pattern string, filePath string) ([]Match, error) {
+	matcher, err := e.getMatcher(pattern)
+	if err != nil {
+		return nil, err
+	}
+
 	file, err := os.Open(filePath)
 	if err != nil {
 		return nil, err
 	}
 	defer file.Close()
-
-	// Read all lines first if we need context
-	var allLines []string
-	if e.config.ContextLines > 0 {
-		scanner := bufio.NewScanner(file)
+	reader := e.fileReader(file)
+
+	// Context needs the surrounding lines, so the file is read once and
+	// searched in memory. Reading it a second time could see different
+	// contents if the file is being written to.
+	if e.hasContext() {
+		var allLines []string
+		scanner := bufio.NewScanner(reader)
 		for scanner.Scan() {
 			allLines = append(allLines, scanner.Text())
+
+			// Lines past the range are only needed as trailing context
+			if e.config.LineEnd > 0 && len(allLines) >= e.config.LineEnd+e.contextAfter() {
+				break
+			}
 		}
 		if err := scanner.Err(); err != nil {
 			return nil, err
 		}
+		return e.searchLines(ctx, matcher, filePath, allLines)
 	}
 
 	var results []Match
-	scanner := bufio.NewScanner(file)
-
-	// Reset file position if we read it for context
-	if e.config.ContextLines > 0 {
-		if _, err := file.Seek(0, 0); err != nil {
-			return nil, err
-		}
-		scanner = bufio.NewScanner(file)
-	}
-
+	scanner := bufio.NewScanner(reader)
 	lineNum := 1
+	matchedLines := 0
 
 	for scanner.Scan() {
 		select {
@@ -415,93 +1796,242 @@ func (e *SearchEngine) simpleSearch(ctx context.Context, pattern string, filePat
 		default:
 		}
 
-		line := scanner.Text()
-
-		// Simple pattern matching
-		var matched bool
-		if e.config.IgnoreCase {
-			matched = strings.Contains(strings.ToLower(line), strings.ToLower(pattern))
-		} else {
-			matched = strings.Contains(line, pattern)
+		// Stop reading once the end of the line range has been passed
+		if e.config.LineEnd > 0 && lineNum > e.config.LineEnd {
+			break
+		}
+		if lineNum < e.config.LineStart {
+			lineNum++
+			continue
 		}
 
-		if matched {
-			result := Match{
-				File:    filePath,
-				Line:    lineNum,
-				Content: line,
-			}
+		line := scanner.Text()
 
-			// Add context lines if requested
-			if e.config.ContextLines > 0 && len(allLines) > 0 {
-				result.Context = e.extractContextLines(allLines, lineNum-1, e.config.ContextLines)
+		spans := e.lineSpans(matcher, line)
+		allSpans := newSpans(spans)
+		for _, span := range spans {
+			result := Match{
+				File:       filePath,
+				Line:       lineNum,
+				Column:     span[0] + 1,
+				Content:    line,
+				MatchStart: span[0],
+				MatchEnd:   span[1],
+				Spans:      allSpans,
 			}
 
 			results = append(results, result)
 		}
 
+		if len(spans) > 0 {
+			matchedLines++
+			if e.reachedMaxCount(matchedLines) {
+				break
+			}
+		}
 		lineNum++
 	}
 
 	return results, scanner.Err()
 }
 
-// extractContextLines extracts context lines around a match
-func (e *SearchEngine) extractContextLines(allLines []string, matchLineIndex int, contextLines int) []string {
-	var context []string
+// addBytesRead records n bytes read from a file; workers call it concurrently
+func (e *SearchEngine) addBytesRead(n int64) {
+	atomic.AddInt64(&e.stats.BytesRead, n)
+}
+
+// readFile reads a whole file, recording the bytes read and the time taken.
+// A byte order mark is dropped, and UTF-16 decoded.
+func (e *SearchEngine) readFile(filePath string) ([]byte, error) {
+	defer e.phases.since(&e.phases.read, time.Now())
+	data, err := os.ReadFile(filePath)
+	e.addBytesRead(int64(len(data)))
+	return stripBOM(data), err
+}
+
+// fileReader wraps file so that reads are counted towards BytesRead and read
+// time, dropping any byte order mark and decoding UTF-16
+func (e *SearchEngine) fileReader(file io.Reader) io.Reader {
+	return &bomReader{reader: &countingReader{reader: file, count: &e.stats.BytesRead, elapsed: &e.phases.read}}
+}
+
+// countingReader adds the number of bytes read through it to count and the
+// time spent reading to elapsed, in nanoseconds. Either may be nil.
+type countingReader struct {
+	reader  io.Reader
+	count   *int64
+	elapsed *int64
+}
 
-	// Add lines before the match
-	start := matchLineIndex - contextLines
-	if start < 0 {
-		start = 0
+// Read implements io.Reader
+func (r *countingReader) Read(p []byte) (int, error) {
+	start := time.Now()
+	n, err := r.reader.Read(p)
+	if r.elapsed != nil {
+		atomic.AddInt64(r.elapsed, int64(time.Since(start)))
+	}
+	if r.count != nil {
+		atomic.AddInt64(r.count, int64(n))
 	}
+	return n, err
+}
 
-	// Add lines after the match
-	end := matchLineIndex + contextLines + 1
-	if end > len(allLines) {
-		end = len(allLines)
+// contextBefore returns the number of lines to include before a match
+func (e *SearchEngine) contextBefore() int {
+	if e.config.BeforeContext > 0 {
+		return e.config.BeforeContext
 	}
+	return e.config.ContextLines
+}
 
-	for i := start; i < end; i++ {
-		if i != matchLineIndex {
-			context = append(context, allLines[i])
-		}
+// contextAfter returns the number of lines to include after a match
+func (e *SearchEngine) contextAfter() int {
+	if e.config.AfterContext > 0 {
+		return e.config.AfterContext
 	}
+	return e.config.ContextLines
+}
 
-	return context
+// hasContext reports whether matches should carry surrounding lines
+func (e *SearchEngine) hasContext() bool {
+	return e.contextBefore() > 0 || e.contextAfter() > 0
 }
 
 // walkFiles walks the directory tree and sends files to the channel
-func (e *SearchEngine) walkFiles(ctx context.Context, filesChan chan<- string) {
+func (e *SearchEngine) walkFiles(ctx context.Context, filesChan chan<- walkedFile) {
 	defer close(filesChan)
+	e.walked = 0
+	e.seenFiles = nil
 
-	// Clean the search path for consistent comparison
-	searchPath, err := filepath.Abs(e.config.SearchPath)
-	if err != nil {
-		searchPath = e.config.SearchPath
+	// Walk time is what remains after filtering and waiting for workers
+	walkStart := time.Now()
+	defer func() {
+		walk := int64(time.Since(walkStart)) - atomic.LoadInt64(&e.phases.filter) - atomic.LoadInt64(&e.phases.sendWait)
+		atomic.StoreInt64(&e.phases.walk, walk)
+	}()
+
+	if e.walkedList != nil {
+		e.replayFiles(ctx, e.walkedList, filesChan)
+		return
+	}
+	if e.recordList != nil {
+		defer e.finishRecording(ctx)
 	}
 
-	// Phase 2 optimization: Use optimized walking if enabled
-	if e.config.OptimizedWalking {
-		err = e.optimizedWalk(ctx, searchPath, filesChan)
-	} else {
-		// Original logic
-		if e.config.Recursive {
-			// Recursive mode: walk the entire directory tree
-			visited := make(map[string]bool)
-			err = e.walkPath(ctx, searchPath, visited, filesChan)
+	// Each search path is walked in turn, its files numbered after those of
+	// the paths before it
+	for i, path := range append([]string{e.config.SearchPath}, e.config.SearchPaths...) {
+		if ctx.Err() != nil {
+			break
+		}
+		if i > 0 {
+			e.enterSearchPath(path)
+		}
+
+		// Clean the search path for consistent comparison
+		searchPath, err := filepath.Abs(path)
+		if err != nil {
+			searchPath = path
+		}
+		e.root = searchPath
+
+		// Phase 2 optimization: Use optimized walking if enabled. WalkDir never
+		// follows symlinks, so following them needs the original walk.
+		if e.config.OptimizedWalking && !e.config.FollowSymlinks {
+			err = e.optimizedWalk(ctx, searchPath, filesChan)
 		} else {
-			// Non-recursive mode: only process files in the immediate directory
-			err = e.processDirectory(ctx, searchPath, filesChan)
+			// Original logic
+			if e.config.Recursive {
+				// Recursive mode: walk the entire directory tree
+				err = e.walkPath(ctx, searchPath, filesChan)
+			} else {
+				// Non-recursive mode: only process files in the immediate directory
+				err = e.processDirectory(ctx, searchPath, filesChan)
+			}
+		}
+
+		// Errors below the path were recorded as they were met
+		if err != nil {
+			e.fileError(searchPath, err)
+		}
+	}
+}
+
+// enterSearchPath anchors the filters that depend on the search path, its
+// ignore files and path globs, at path before one of SearchPaths is walked.
+// The next search anchors them at SearchPath again.
+func (e *SearchEngine) enterSearchPath(path string) {
+	config := e.config
+	config.SearchPath = path
+	if e.config.UseGitignore {
+		e.gitignoreEngine = NewGitignoreEngine(path, e.config.IgnoreFiles...)
+	}
+	// The globs compiled for SearchPath, so they compile here too
+	if globs, err := newGlobSet(config); err == nil {
+		e.globs = globs
+	}
+}
+
+// walkDepth returns how many levels below root the walked path lies
+func walkDepth(root, path string) int {
+	rel := strings.TrimPrefix(strings.TrimPrefix(path, root), string(filepath.Separator))
+	return strings.Count(rel, string(filepath.Separator)) + 1
+}
+
+// withinAllowedRoots reports whether the resolved path lies under one of
+// the AllowedRoots, or whether no roots are set
+func (e *SearchEngine) withinAllowedRoots(path string) bool {
+	return e.config.AllowedRoots == nil || withinRoots(path, e.config.AllowedRoots)
+}
+
+// withinRoots reports whether path is one of roots or lies under one,
+// comparing them as cleaned paths without touching the file system
+func withinRoots(path string, roots []string) bool {
+	for _, root := range roots {
+		rel, err := filepath.Rel(root, path)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
 		}
 	}
+	return false
+}
+
+// walkFrame is a directory walkPath is inside, with the paths of the
+// entries it has yet to walk
+type walkFrame struct {
+	info    os.FileInfo
+	entries []string
+	depth   int // Depth of the entries below the search path
+}
 
-	// Silently continue on walk errors (no logging)
-	_ = err
+// walkPath walks the tree under root depth first (for recursive mode). It
+// keeps the directories it is inside on a stack of its own rather than
+// recursing, so pathological trees cannot exhaust the goroutine stack, and
+// does not descend into a directory that is one of them, which a symlink or
+// a bind mount can lead back to.
+func (e *SearchEngine) walkPath(ctx context.Context, root string, filesChan chan<- walkedFile) error {
+	var stack []walkFrame
+	if err := e.walkEntry(ctx, root, 0, &stack, filesChan); err != nil {
+		return err
+	}
+	for len(stack) > 0 {
+		top := &stack[len(stack)-1]
+		if len(top.entries) == 0 {
+			stack = stack[:len(stack)-1]
+			continue
+		}
+		path, depth := top.entries[0], top.depth
+		top.entries = top.entries[1:]
+		if err := e.walkEntry(ctx, path, depth, &stack, filesChan); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-// walkPath recursively walks a path (for recursive mode)
-func (e *SearchEngine) walkPath(ctx context.Context, path string, visited map[string]bool, filesChan chan<- string) error {
+// walkEntry sends the file at path, found depth levels below the search
+// path, or pushes the directory at path onto stack for walkPath to walk
+func (e *SearchEngine) walkEntry(ctx context.Context, path string, depth int, stack *[]walkFrame, filesChan chan<- walkedFile) error {
 	// Check for context cancellation
 	select {
 	case <-ctx.Done():
@@ -519,58 +2049,78 @@ func (e *SearchEngine) walkPath(ctx context.Context, path string, visited map[st
 	if info.Mode()&os.ModeSymlink != 0 {
 		if !e.config.FollowSymlinks {
 			// Skip symlinks if not following them
+			e.logDebug("skipped symlink", "path", path)
 			return nil
 		}
 
-		// Resolve the symlink target
-		target, err := filepath.EvalSymlinks(path)
-		if err != nil {
+		// Resolve the symlink target and continue with it
+		path, err = filepath.EvalSymlinks(path)
+		if err != nil || !e.withinAllowedRoots(path) {
 			return nil // Continue on errors
 		}
-
-		// Check for cycles using the resolved path
-		if visited[target] {
-			// Cycle detected, skip
+		if info, err = os.Lstat(path); err != nil {
 			return nil
 		}
-
-		// Mark as visited and continue with the target
-		visited[target] = true
-		defer delete(visited, target)
-
-		return e.walkPath(ctx, target, visited, filesChan)
 	}
 
 	// Handle regular files
 	if !info.IsDir() {
 		// Check if we should ignore this file
-		if e.shouldIgnoreFile(path, info) {
+		class := e.classifyFile(path, info)
+		if class == fileSkipped {
 			e.stats.FilesSkipped++
 			return nil
 		}
 
-		filesChan <- path
+		return e.sendFile(ctx, filesChan, path, info, class)
+	}
+
+	// Handle directories - walk them unless excluded by a glob or gitignore
+	// rules, or at the depth limit
+	if e.globs != nil && e.globs.excludesDir(path) {
+		e.skipDir(path, "glob")
 		return nil
 	}
+	if e.ignoresDir(path) {
+		e.skipDir(path, "gitignore")
+		return nil
+	}
+	if e.config.MaxDepth > 0 && depth >= e.config.MaxDepth {
+		e.skipDir(path, "max depth")
+		return nil
+	}
+
+	// A directory too deep to be real, or one the walk is already inside,
+	// would keep the walk going forever
+	if e.config.MaxPathDepth > 0 && depth >= e.config.MaxPathDepth {
+		e.stats.DirsTruncated++
+		e.skipDir(path, "path depth")
+		return nil
+	}
+	for _, frame := range *stack {
+		if os.SameFile(frame.info, info) {
+			e.stats.DirsTruncated++
+			e.skipDir(path, "loop")
+			return nil
+		}
+	}
 
-	// Handle directories - recurse into them
+	e.recordDir(path, info)
 	entries, err := os.ReadDir(path)
 	if err != nil {
+		e.fileError(path, err)
 		return nil // Continue on errors
 	}
-
-	for _, entry := range entries {
-		entryPath := filepath.Join(path, entry.Name())
-		if err := e.walkPath(ctx, entryPath, visited, filesChan); err != nil {
-			return err
-		}
+	frame := walkFrame{info: info, entries: make([]string, len(entries)), depth: depth + 1}
+	for i, entry := range entries {
+		frame.entries[i] = filepath.Join(path, entry.Name())
 	}
-
+	*stack = append(*stack, frame)
 	return nil
 }
 
 // processDirectory processes only files in the immediate directory (for non-recursive mode)
-func (e *SearchEngine) processDirectory(ctx context.Context, dirPath string, filesChan chan<- string) error {
+func (e *SearchEngine) processDirectory(ctx context.Context, dirPath string, filesChan chan<- walkedFile) error {
 	// Check for context cancellation
 	select {
 	case <-ctx.Done():
@@ -586,15 +2136,16 @@ func (e *SearchEngine) processDirectory(ctx context.Context, dirPath string, fil
 
 	// If it's a single file, process it
 	if !info.IsDir() {
-		if !e.shouldIgnoreFile(dirPath, info) {
-			filesChan <- dirPath
-		} else {
+		class := e.classifyFile(dirPath, info)
+		if class == fileSkipped {
 			e.stats.FilesSkipped++
+			return nil
 		}
-		return nil
+		return e.sendFile(ctx, filesChan, dirPath, info, class)
 	}
 
 	// Read directory entries
+	e.recordDir(dirPath, info)
 	entries, err := os.ReadDir(dirPath)
 	if err != nil {
 		return err
@@ -602,6 +2153,10 @@ func (e *SearchEngine) processDirectory(ctx context.Context, dirPath string, fil
 
 	// Process only files (not subdirectories)
 	for _, entry := range entries {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
 		// Skip directories entirely in non-recursive mode
 		if entry.IsDir() {
 			continue
@@ -613,62 +2168,217 @@ func (e *SearchEngine) processDirectory(ctx context.Context, dirPath string, fil
 			continue
 		}
 
-		if !e.shouldIgnoreFile(entryPath, entryInfo) {
-			filesChan <- entryPath
-		} else {
+		class := e.classifyFile(entryPath, entryInfo)
+		if class == fileSkipped {
 			e.stats.FilesSkipped++
+			continue
+		}
+		if err := e.sendFile(ctx, filesChan, entryPath, entryInfo, class); err != nil {
+			return err
 		}
 	}
 
 	return nil
 }
 
+// sendFile hands path, classified by the file filters, to the workers,
+// giving up once ctx is cancelled. Time spent waiting for a free worker is
+// tracked so it is not counted as walking.
+func (e *SearchEngine) sendFile(ctx context.Context, filesChan chan<- walkedFile, path string, info os.FileInfo, class fileClass) error {
+	if e.config.DedupeLinks && e.duplicateFile(path, info) {
+		e.stats.FilesSkipped++
+		e.skipFile(path, "duplicate")
+		return nil
+	}
+
+	defer e.phases.since(&e.phases.sendWait, time.Now())
+
+	select {
+	case filesChan <- walkedFile{path: path, seq: e.walked, binary: class == fileBinary}:
+		e.walked++
+		if e.recordList != nil {
+			e.recordList.files = append(e.recordList.files, walkedFile{path: path, binary: class == fileBinary})
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// fileID identifies a file by its device and inode numbers
+type fileID struct {
+	dev, ino uint64
+}
+
+// duplicateFile reports whether the file at path, described by info, was
+// already sent under another path, through a hard link or a symlink
+func (e *SearchEngine) duplicateFile(path string, info os.FileInfo) bool {
+	// A symlink is identified by the file it points to
+	if info.Mode()&os.ModeSymlink != 0 {
+		target, err := os.Stat(path)
+		if err != nil {
+			return false
+		}
+		info = target
+	}
+
+	id, ok := fileIdentity(info)
+	if !ok {
+		return false
+	}
+	if _, seen := e.seenFiles[id]; seen {
+		return true
+	}
+	if e.seenFiles == nil {
+		e.seenFiles = make(map[fileID]struct{})
+	}
+	e.seenFiles[id] = struct{}{}
+	return false
+}
+
+// fileClass is how the file filters decide a walked file is searched
+type fileClass int
+
+const (
+	fileSkipped fileClass = iota
+	fileText
+	fileBinary // A binary file searched only to report whether it matches (BinaryReport)
+)
+
 // shouldIgnoreFile determines if a file should be ignored based on various criteria
 func (e *SearchEngine) shouldIgnoreFile(path string, info os.FileInfo) bool {
+	return e.classifyFile(path, info) == fileSkipped
+}
+
+// classifyFile applies the file filters to the file at path, deciding
+// whether it is skipped, searched as text or, being binary, searched only to
+// report whether it matches. Binary files are only skipped in BinarySkip mode.
+func (e *SearchEngine) classifyFile(path string, info os.FileInfo) fileClass {
+	defer e.phases.since(&e.phases.filter, time.Now())
+
+	// Pipes, sockets and devices met while walking could block or never end
+	if info.Mode()&(os.ModeNamedPipe|os.ModeSocket|os.ModeDevice|os.ModeCharDevice) != 0 {
+		return e.skipFile(path, "special file")
+	}
+
+	// Symlinks must not lead out of the sandbox
+	if info.Mode()&os.ModeSymlink != 0 && e.config.AllowedRoots != nil {
+		target, err := filepath.EvalSymlinks(path)
+		if err != nil || !e.withinAllowedRoots(target) {
+			return e.skipFile(path, "outside allowed roots")
+		}
+	}
+
+	// Compressed files and archives are decompressed when searched instead of
+	// skipped as binary
+	compressed := e.compression != nil && e.compression.DetectCompressionByExtension(path) != CompressionNone
+	compressed = compressed || (e.archives != nil && e.archives.IsArchive(path))
+
 	// Fast extension-based binary filtering (Phase 1 optimization)
-	if e.config.SkipKnownBinary && e.isKnownBinaryExtension(path) {
-		return true
+	knownBinary := e.config.SkipKnownBinary && !e.config.FileNamesOnly && !compressed && e.isKnownBinaryExtension(path)
+	if knownBinary && e.config.BinaryMode == BinarySkip {
+		return e.skipFile(path, "binary extension")
 	}
 
 	// Apply gitignore filtering if enabled
 	if e.config.UseGitignore && e.gitignoreEngine != nil {
 		if e.gitignoreEngine.ShouldIgnore(path) {
 			e.stats.FilesIgnored++
-			return true
+			return e.skipFile(path, "gitignore")
 		}
+		e.traceStep(path, "gitignore", false, false)
 	}
 
-	// Apply file pattern filtering
-	if e.config.FilePattern != "" {
-		matched, err := filepath.Match(e.config.FilePattern, info.Name())
-		if err != nil || !matched {
-			return true
+	// Apply include and exclude globs
+	if e.globs != nil {
+		if !e.globs.includesFile(path) {
+			return e.skipFile(path, "glob")
 		}
+		e.traceStep(path, "glob", false, false)
 	}
 
 	// Skip hidden files if not included
 	if !e.config.IncludeHidden && strings.HasPrefix(info.Name(), ".") {
-		return true
+		return e.skipFile(path, "hidden")
 	}
 
-	// Fast file filtering with early text detection
-	if e.config.FastFileFiltering && !e.isLikelyTextFile(path) {
-		return true
+	// Binary files have names too; only content searches skip them, and
+	// every file is text to -a
+	if e.config.FileNamesOnly || compressed || e.config.BinaryMode == BinaryText {
+		return fileText
 	}
 
-	// Enhanced binary detection
-	if e.config.EarlyBinaryDetection {
-		if e.isBinaryFileOptimized(path) {
-			return true
-		}
-	} else {
-		// Fallback to existing binary detection
-		if isBinaryFile(path) {
-			return true
+	binary := knownBinary || e.looksBinary(path, info)
+	switch {
+	case !binary:
+		return fileText
+	case e.config.BinaryMode == BinaryReport:
+		return fileBinary
+	default:
+		return e.skipFile(path, "binary content")
+	}
+}
+
+// skipFile reports, to the Logger and any trace, why the file at path is
+// skipped
+func (e *SearchEngine) skipFile(path, reason string) fileClass {
+	e.logDebug("skipped file", "path", path, "reason", reason)
+	e.traceStep(path, reason, false, true)
+	return fileSkipped
+}
+
+// skipDir reports, to the Logger and any trace, why the directory at path is
+// not walked
+func (e *SearchEngine) skipDir(path, reason string) bool {
+	e.logDebug("skipped directory", "path", path, "reason", reason)
+	e.traceStep(path, reason, true, true)
+	return true
+}
+
+// logDebug records a walk or search decision, if there is a Logger
+func (e *SearchEngine) logDebug(msg string, args ...any) {
+	if e.config.Logger != nil {
+		e.config.Logger.Debug(msg, args...)
+	}
+}
+
+// looksBinary checks the file at path for binary content. While Searcher
+// records a walk, the answer its last walk got is reused for as long as the
+// file keeps the same size and modification time.
+func (e *SearchEngine) looksBinary(path string, info os.FileInfo) bool {
+	list := e.recordList
+	if known, ok := list.previousSnapshot(path, info); ok {
+		list.snapshot[path] = known
+		return known.binary
+	}
+
+	// Fast file filtering with early text detection, then enhanced binary
+	// detection or the existing extension check
+	binary := e.config.FastFileFiltering && !e.isLikelyTextFile(path)
+	if !binary {
+		if e.config.EarlyBinaryDetection {
+			binary = e.isBinaryFileOptimized(path)
+		} else {
+			binary = isBinaryFile(path)
 		}
 	}
+	if list != nil {
+		list.snapshot[path] = fileSnapshot{size: info.Size(), modTime: info.ModTime(), binary: binary}
+	}
+	return binary
+}
+
+// ignoresDir reports whether gitignore rules exclude the directory at path.
+// The search path itself is always searched.
+func (e *SearchEngine) ignoresDir(path string) bool {
+	if !e.config.UseGitignore || e.gitignoreEngine == nil {
+		return false
+	}
+	if filepath.Clean(path) == e.root {
+		return false
+	}
 
-	return false
+	return e.gitignoreEngine.ShouldIgnoreDir(path)
 }
 
 // isKnownBinaryExtension performs fast extension-based binary detection
@@ -772,6 +2482,11 @@ func (e *SearchEngine) isBinaryFileOptimized(filePath string) bool {
 		return true
 	}
 
+	// A byte order mark says the file is text, though UTF-16 is full of NUL bytes
+	if hasBOM(buffer[:n]) {
+		return false
+	}
+
 	// Check for null bytes (strong binary indicator)
 	nullCount := 0
 	for i := 0; i < n; i++ {
@@ -785,7 +2500,9 @@ func (e *SearchEngine) isBinaryFileOptimized(filePath string) bool {
 		return true
 	}
 
-	// Check for high proportion of non-printable characters
+	// Check for high proportion of non-printable characters. Bytes above
+	// 126 are only counted when the sample is not valid UTF-8.
+	text := validUTF8Sample(buffer[:n])
 	nonPrintable := 0
 	for i := 0; i < n; i++ {
 		b := buffer[i]
@@ -793,7 +2510,7 @@ func (e *SearchEngine) isBinaryFileOptimized(filePath string) bool {
 		if b < 32 && b != 9 && b != 10 && b != 13 {
 			nonPrintable++
 		}
-		if b > 126 {
+		if b > 126 && !text {
 			nonPrintable++
 		}
 	}
@@ -806,13 +2523,31 @@ func (e *SearchEngine) isBinaryFileOptimized(filePath string) bool {
 	return false
 }
 
+// validUTF8Sample reports whether b is valid UTF-8, allowing the last rune to
+// be cut off by the end of the sample.
+func validUTF8Sample(b []byte) bool {
+	if utf8.Valid(b) {
+		return true
+	}
+	for i := 1; i < utf8.UTFMax && i <= len(b); i++ {
+		if utf8.RuneStart(b[len(b)-i]) {
+			return !utf8.FullRune(b[len(b)-i:]) && utf8.Valid(b[:len(b)-i])
+		}
+	}
+	return false
+}
+
 // optimizedWalk performs fast directory walking using filepath.WalkDir (Phase 2 optimization)
-func (e *SearchEngine) optimizedWalk(ctx context.Context, searchPath string, filesChan chan<- string) error {
+func (e *SearchEngine) optimizedWalk(ctx context.Context, searchPath string, filesChan chan<- walkedFile) error {
 	// For non-recursive mode, use processDirectory instead
 	if !e.config.Recursive {
 		return e.processDirectory(ctx, searchPath, filesChan)
 	}
 
+	// WalkDir does not follow symlinks, but a bind mount can still lead back
+	// to a directory already walked
+	walkedDirs := make(map[fileID]struct{})
+
 	return filepath.WalkDir(searchPath, func(path string, d fs.DirEntry, err error) error {
 		// Check for context cancellation
 		select {
@@ -823,21 +2558,38 @@ func (e *SearchEngine) optimizedWalk(ctx context.Context, searchPath string, fil
 
 		if err != nil {
 			// Continue on errors
+			e.fileError(path, err)
 			return nil
 		}
 
 		// Skip directories
 		if d.IsDir() {
-			// Skip hidden directories if not including hidden files
-			if !e.config.IncludeHidden && strings.HasPrefix(d.Name(), ".") {
+			if e.prunesDir(path) {
 				return filepath.SkipDir
 			}
-
-			// Skip known directories to ignore for performance
-			if e.shouldSkipDirectory(d.Name()) {
-				return filepath.SkipDir
+			if path != searchPath {
+				depth := walkDepth(searchPath, path)
+				if e.config.MaxDepth > 0 && depth >= e.config.MaxDepth {
+					e.skipDir(path, "max depth")
+					return filepath.SkipDir
+				}
+				if e.config.MaxPathDepth > 0 && depth >= e.config.MaxPathDepth {
+					e.stats.DirsTruncated++
+					e.skipDir(path, "path depth")
+					return filepath.SkipDir
+				}
+			}
+			if info, err := d.Info(); err == nil {
+				e.recordDir(path, info)
+				if id, ok := fileIdentity(info); ok {
+					if _, seen := walkedDirs[id]; seen {
+						e.stats.DirsTruncated++
+						e.skipDir(path, "loop")
+						return filepath.SkipDir
+					}
+					walkedDirs[id] = struct{}{}
+				}
 			}
-
 			return nil
 		}
 
@@ -848,18 +2600,41 @@ func (e *SearchEngine) optimizedWalk(ctx context.Context, searchPath string, fil
 		}
 
 		// Apply all file filters
-		if !e.shouldIgnoreFile(path, info) {
-			select {
-			case filesChan <- path:
-			case <-ctx.Done():
-				return ctx.Err()
-			}
+		if class := e.classifyFile(path, info); class != fileSkipped {
+			return e.sendFile(ctx, filesChan, path, info, class)
 		}
 
 		return nil
 	})
 }
 
+// prunesDir reports whether the optimized walk skips the directory at path
+// and everything under it
+func (e *SearchEngine) prunesDir(path string) bool {
+	name := filepath.Base(path)
+
+	// Skip hidden directories if not including hidden files
+	if !e.config.IncludeHidden && strings.HasPrefix(name, ".") {
+		return e.skipDir(path, "hidden")
+	}
+
+	// Skip known directories to ignore for performance
+	if !e.config.SearchAllDirs && e.shouldSkipDirectory(name) {
+		return e.skipDir(path, "build directory")
+	}
+
+	// Prune directories matched by an exclude glob
+	if e.globs != nil && e.globs.excludesDir(path) {
+		return e.skipDir(path, "glob")
+	}
+
+	// Prune directories ignored by gitignore rules
+	if e.ignoresDir(path) {
+		return e.skipDir(path, "gitignore")
+	}
+	return false
+}
+
 // shouldSkipDirectory determines if a directory should be skipped entirely
 func (e *SearchEngine) shouldSkipDirectory(dirName string) bool {
 	// Skip common binary/build directories for performance
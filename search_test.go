@@ -285,6 +285,34 @@ func TestSearchResultsGetSummary(t *testing.T) {
 	}
 }
 
+func TestSearchResultsGroupByFile(t *testing.T) {
+	results := &SearchResults{
+		Matches: []Match{
+			{File: "b.txt", Line: 3, Column: 1},
+			{File: "a.txt", Line: 7, Column: 2},
+			{File: "b.txt", Line: 1, Column: 4},
+			{File: "a.txt", Line: 7, Column: 1},
+		},
+	}
+
+	groups := results.GroupByFile()
+	if len(groups) != 2 {
+		t.Fatalf("Expected 2 groups, got %d", len(groups))
+	}
+
+	if groups[0].File != "a.txt" || groups[1].File != "b.txt" {
+		t.Errorf("Expected groups ordered by file, got %q and %q", groups[0].File, groups[1].File)
+	}
+
+	if len(groups[0].Matches) != 2 || groups[0].Matches[0].Column != 1 {
+		t.Errorf("Expected a.txt matches ordered by column, got %+v", groups[0].Matches)
+	}
+
+	if len(groups[1].Matches) != 2 || groups[1].Matches[0].Line != 1 {
+		t.Errorf("Expected b.txt matches ordered by line, got %+v", groups[1].Matches)
+	}
+}
+
 func TestSearchEngineGetPerformanceReport(t *testing.T) {
 	config := SearchConfig{
 		SearchPath:      "/test",
@@ -485,3 +513,31 @@ func BenchmarkSearchEngine(b *testing.B) {
 		}
 	})
 }
+
+func TestSearchCountsChangedFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "active.log")
+	if err := os.WriteFile(path, []byte("needle\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	before, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fileChanged(path, before) {
+		t.Error("Unchanged file reported as changed")
+	}
+
+	if err := os.WriteFile(path, []byte("needle\nneedle\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if !fileChanged(path, before) {
+		t.Error("Expected a file that grew to be reported as changed")
+	}
+
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if !fileChanged(path, before) {
+		t.Error("Expected a removed file to be reported as changed")
+	}
+}
@@ -0,0 +1,250 @@
+package goripgrep
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// Searcher runs many searches under one path with the same options, for
+// editors and servers that query a tree over and over. Between searches it
+// keeps what does not depend on the pattern: the ignore rules, read again
+// once an ignore file changes; compiled regexes, through the shared DFA
+// cache; and a snapshot of the files to search. Each search checks the
+// directories in the snapshot and only walks again once one has changed,
+// and then only files whose size or modification time changed are opened
+// to check for binary content. A Searcher is safe for concurrent use.
+type Searcher struct {
+	path    string
+	options []Option
+
+	mu         sync.Mutex
+	ignore     *GitignoreEngine // Shared by every search until an ignore file changes
+	files      *fileList        // The last complete walk, possibly stale
+	generation int              // Bumped when files must not be reused, so walks in flight are not kept
+}
+
+// NewSearcher creates a Searcher for path. The options apply to every search.
+func NewSearcher(path string, opts ...Option) (*Searcher, error) {
+	if path == "" {
+		return nil, fmt.Errorf("path cannot be empty")
+	}
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("path error: %w", err)
+	}
+
+	return &Searcher{
+		path:    path,
+		options: append([]Option{WithRegexCaching()}, opts...),
+	}, nil
+}
+
+// Search searches for pattern as Find would with the Searcher's path and
+// options, but without walking the tree again while it is unchanged.
+// Files that changed since the walk are searched as they are now.
+func (s *Searcher) Search(ctx context.Context, pattern string) (*SearchResults, error) {
+	options := s.searchOptions()
+	pattern, err := options.resolvePatterns(pattern)
+	if err != nil {
+		return nil, err
+	}
+	if err := options.validate(pattern); err != nil {
+		return nil, err
+	}
+
+	if options.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.timeout)
+		defer cancel()
+	}
+
+	engine := s.engine(options.searchConfig(s.path), false)
+	results, err := engine.Search(ctx, pattern)
+	if err == nil {
+		s.keep(engine.recordList)
+	}
+	options.finishResults(results)
+	return results, err
+}
+
+// Refresh walks the path now, so the next search need not, reusing the
+// content checks of files that have not changed
+func (s *Searcher) Refresh(ctx context.Context) error {
+	options := s.searchOptions()
+	if options.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.timeout)
+		defer cancel()
+	}
+
+	engine := s.engine(options.searchConfig(s.path), true)
+	if err := engine.initializeEngines(); err != nil {
+		return err
+	}
+	filesChan := make(chan walkedFile)
+	go func() {
+		for range filesChan {
+		}
+	}()
+	engine.walkFiles(ctx, filesChan)
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.keep(engine.recordList)
+	return nil
+}
+
+// Invalidate drops the snapshot, so the next search walks the path and
+// checks every file again, as after files changed without changing their
+// directories' modification times
+func (s *Searcher) Invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.files = nil
+	s.generation++
+}
+
+// searchOptions applies the Searcher's options
+func (s *Searcher) searchOptions() *searchOptions {
+	options := defaultOptions()
+	for _, opt := range s.options {
+		opt(options)
+	}
+	return options
+}
+
+// engine creates the engine for a search, which searches the snapshot
+// again while it is current and otherwise walks, recording a new one.
+// Walking is forced when walk is set.
+func (s *Searcher) engine(config SearchConfig, walk bool) *SearchEngine {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// A snapshot is only valid with the ignore rules it was walked with
+	if config.UseGitignore && (s.ignore == nil || s.ignore.changed()) {
+		s.ignore = NewGitignoreEngine(s.path, config.IgnoreFiles...)
+		s.files = nil
+		s.generation++
+	}
+
+	engine := &SearchEngine{
+		config:          config,
+		gitignoreEngine: s.ignore,
+		sharedIgnore:    true,
+	}
+	if !walk && s.files != nil && s.files.current() {
+		engine.walkedList = s.files
+	} else {
+		engine.recordList = &fileList{
+			dirs:       make(map[string]time.Time),
+			snapshot:   make(map[string]fileSnapshot),
+			previous:   s.files,
+			generation: s.generation,
+		}
+	}
+	return engine
+}
+
+// keep stores a snapshot recorded by a walk that ran to the end, unless it
+// was invalidated in the meantime
+func (s *Searcher) keep(list *fileList) {
+	if list == nil || !list.complete {
+		return
+	}
+	list.previous = nil
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if list.generation == s.generation {
+		s.files = list
+	}
+}
+
+// fileList is a snapshot of what a complete walk found: the files it sent
+// to the workers in walk order, the modification times of the directories
+// it read, the content checks of the files it classified and the counts it
+// kept of what it left out
+type fileList struct {
+	files     []walkedFile
+	dirs      map[string]time.Time
+	snapshot  map[string]fileSnapshot
+	skipped   int64
+	ignored   int64
+	truncated int64
+	complete  bool // The walk was not cut short, so the snapshot can be reused
+
+	previous   *fileList // While recording, the last snapshot, for files that have not changed
+	generation int       // Searcher.generation when the walk started
+}
+
+// fileSnapshot is what a walk learned of a file's content, valid while its
+// size and modification time stay the same
+type fileSnapshot struct {
+	size    int64
+	modTime time.Time
+	binary  bool
+}
+
+// current reports whether none of the directories walked has changed since,
+// so the walk would find the same files again
+func (l *fileList) current() bool {
+	for dir, modTime := range l.dirs {
+		info, err := os.Stat(dir)
+		if err != nil || !info.ModTime().Equal(modTime) {
+			return false
+		}
+	}
+	return true
+}
+
+// previousSnapshot returns what the last walk learned of the file at path,
+// if the file has not changed since
+func (l *fileList) previousSnapshot(path string, info os.FileInfo) (fileSnapshot, bool) {
+	if l == nil || l.previous == nil {
+		return fileSnapshot{}, false
+	}
+	known, ok := l.previous.snapshot[path]
+	if !ok || known.size != info.Size() || !known.modTime.Equal(info.ModTime()) {
+		return fileSnapshot{}, false
+	}
+	return known, true
+}
+
+// recordDir notes a directory the walk reads, for the snapshot it records
+func (e *SearchEngine) recordDir(path string, info os.FileInfo) {
+	if e.recordList != nil {
+		e.recordList.dirs[path] = info.ModTime()
+	}
+}
+
+// finishRecording completes the recorded snapshot once the walk is done,
+// unless ctx stopped it early and files may be missing
+func (e *SearchEngine) finishRecording(ctx context.Context) {
+	if ctx.Err() != nil {
+		return
+	}
+	e.recordList.skipped = e.stats.FilesSkipped
+	e.recordList.ignored = e.stats.FilesIgnored
+	e.recordList.truncated = e.stats.DirsTruncated
+	e.recordList.complete = true
+}
+
+// replayFiles sends the files of an earlier walk in place of walking, with
+// the counts that walk kept
+func (e *SearchEngine) replayFiles(ctx context.Context, list *fileList, filesChan chan<- walkedFile) {
+	e.stats.FilesSkipped += list.skipped
+	e.stats.FilesIgnored += list.ignored
+	e.stats.DirsTruncated += list.truncated
+
+	for _, file := range list.files {
+		file.seq = e.walked
+		select {
+		case filesChan <- file:
+			e.walked++
+		case <-ctx.Done():
+			return
+		}
+	}
+}
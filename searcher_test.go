@@ -0,0 +1,144 @@
+package goripgrep
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestSearcher(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		".gitignore": "*.log\n",
+		"a.txt":      "needle\n",
+		"sub/b.txt":  "needle haystack\n",
+		"skip.log":   "needle\n",
+	})
+	searcher, err := NewSearcher(dir, WithRecursive(true), WithGitignore(true))
+	if err != nil {
+		t.Fatalf("NewSearcher failed: %v", err)
+	}
+	search := func(pattern string, want int) *SearchResults {
+		t.Helper()
+		results, err := searcher.Search(context.Background(), pattern)
+		if err != nil {
+			t.Fatalf("Search failed: %v", err)
+		}
+		if results.Count() != want {
+			t.Errorf("Expected %d matches for %q, got %d: %+v", want, pattern, results.Count(), results.Matches)
+		}
+		return results
+	}
+
+	search("needle", 2)
+	list := searcher.files
+	if list == nil || len(list.files) != 2 {
+		t.Fatalf("Expected the walk to be kept, got %+v", list)
+	}
+
+	// Later searches reuse the walk, with its stats, and read files afresh
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("haystack\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	results := search("haystack", 2)
+	if searcher.files != list {
+		t.Error("Expected the file list to be reused")
+	}
+	if results.Stats.FilesIgnored != 1 {
+		t.Errorf("Expected the ignored file to be counted, got %d", results.Stats.FilesIgnored)
+	}
+
+	// A new file means walking again
+	if err := os.WriteFile(filepath.Join(dir, "sub", "c.txt"), []byte("needle\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	search("needle", 2)
+	if searcher.files == list {
+		t.Error("Expected the file list to be walked again after a directory changed")
+	}
+
+	// So do changed ignore rules
+	if err := os.WriteFile(filepath.Join(dir, ".gitignore"), []byte("sub/\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	search("needle", 1)
+
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := searcher.Search(context.Background(), "needle"); err != nil {
+				t.Errorf("Concurrent search failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if _, err := NewSearcher(filepath.Join(dir, "missing")); err == nil {
+		t.Error("Expected an error for a missing path")
+	}
+}
+
+func TestSearcherSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	data := filepath.Join(dir, "data.txt")
+	writeTestFiles(t, dir, map[string]string{"data.txt": "needle\n"})
+	searcher, err := NewSearcher(dir)
+	if err != nil {
+		t.Fatalf("NewSearcher failed: %v", err)
+	}
+	ctx := context.Background()
+	count := func(pattern string) int {
+		t.Helper()
+		results, err := searcher.Search(ctx, pattern)
+		if err != nil {
+			t.Fatalf("Search failed: %v", err)
+		}
+		return results.Count()
+	}
+	if count("needl") != 1 {
+		t.Fatal("Expected a match in the text file")
+	}
+
+	// Binary content that keeps the size and modification time goes
+	// unnoticed by a walk, which reuses the content check of the snapshot
+	info, err := os.Stat(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(data, []byte("needl\x00\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(data, info.ModTime(), info.ModTime()); err != nil {
+		t.Fatal(err)
+	}
+	writeTestFiles(t, dir, map[string]string{"new.txt": "needle\n"})
+	if err := searcher.Refresh(ctx); err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+	refreshed := searcher.files
+	if refreshed == nil || len(refreshed.files) != 2 {
+		t.Fatalf("Expected Refresh to walk both files, got %+v", refreshed)
+	}
+	if count("needl") != 2 {
+		t.Error("Expected the snapshot's content check to be reused")
+	}
+	if searcher.files != refreshed {
+		t.Error("Expected the search to use the refreshed snapshot")
+	}
+
+	// Invalidate checks every file again
+	searcher.Invalidate()
+	if count("needl") != 1 {
+		t.Error("Expected the binary file to be skipped after Invalidate")
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := searcher.Refresh(cancelled); err == nil {
+		t.Error("Expected Refresh to fail with a cancelled context")
+	}
+}
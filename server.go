@@ -0,0 +1,585 @@
+package goripgrep
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// serverMaxRequest bounds the size of a search request body
+const serverMaxRequest = 1 << 20
+
+// SearchRequest is the JSON body of a search sent to a Server. Path is
+// relative to the server's root, which it defaults to, unless absolute; the
+// other fields mirror the options of the same names. Unset fields leave the
+// server's options alone, except that searches are recursive unless
+// Recursive is false.
+type SearchRequest struct {
+	Pattern       string   `json:"pattern"`
+	Patterns      []string `json:"patterns,omitempty"`
+	Path          string   `json:"path,omitempty"`
+	IgnoreCase    bool     `json:"ignore_case,omitempty"`
+	SmartCase     bool     `json:"smart_case,omitempty"`
+	FixedStrings  bool     `json:"fixed_strings,omitempty"`
+	WordRegexp    bool     `json:"word_regexp,omitempty"`
+	LineRegexp    bool     `json:"line_regexp,omitempty"`
+	Multiline     bool     `json:"multiline,omitempty"`
+	PCRE2         bool     `json:"pcre2,omitempty"`
+	InvertMatch   bool     `json:"invert_match,omitempty"`
+	Recursive     *bool    `json:"recursive,omitempty"`
+	Hidden        bool     `json:"hidden,omitempty"`
+	Globs         []string `json:"globs,omitempty"` // Include globs, or exclude ones starting with !
+	Types         []string `json:"types,omitempty"`
+	TypesNot      []string `json:"types_not,omitempty"`
+	Context       int      `json:"context,omitempty"`
+	BeforeContext int      `json:"before_context,omitempty"`
+	AfterContext  int      `json:"after_context,omitempty"`
+	MaxResults    int      `json:"max_results,omitempty"`
+	QuitAfter     int      `json:"quit_after,omitempty"`
+	Count         bool     `json:"count,omitempty"`   // Report counts per file in the done event instead of matches
+	Timeout       string   `json:"timeout,omitempty"` // A duration such as "5s", capped at the server's timeout
+}
+
+// ServerEvent is one line of the NDJSON stream a Server answers a search
+// with: a "match" event per match as files finish, then a single "done"
+// event with the statistics, or an "error" event if the search failed
+type ServerEvent struct {
+	Type   string         `json:"type"`
+	Match  *Match         `json:"match,omitempty"`
+	Stats  *SearchStats   `json:"stats,omitempty"`
+	Counts map[string]int `json:"counts,omitempty"`
+	Error  string         `json:"error,omitempty"`
+}
+
+// ServerLimits bounds a single Server request. Zero fields impose no limit;
+// WithTimeout bounds how long a request may run. Clients are told apart by
+// address, or by the user named by a proxy given to WithTrustedProxies.
+type ServerLimits struct {
+	MaxFiles      int   // Files a request may search before it stops early
+	MaxBytes      int64 // Bytes a request may search before it stops early
+	MaxResults    int   // Matches a request may return before it stops early
+	MaxConcurrent int   // Requests a client may run at once; more are refused
+}
+
+// ErrTooManySearches is returned by Server.Search when the client already
+// runs as many searches as ServerLimits.MaxConcurrent allows
+var ErrTooManySearches = errors.New("too many concurrent searches")
+
+// ErrPathNotAllowed is returned by Server.Search for paths outside the
+// server's allowed roots, including those reached through symlinks
+var ErrPathNotAllowed = errors.New("path outside the allowed roots")
+
+// ErrInvalidRequest is returned by Server.Search for requests that cannot
+// run, such as an invalid pattern or timeout or a pattern refused by
+// WithRejectSlowPatterns
+var ErrInvalidRequest = errors.New("invalid search request")
+
+// Server answers searches under a root directory over HTTP, so editors and
+// bots can run many queries without starting a process for each. POST
+// /search takes a SearchRequest and streams ServerEvents as NDJSON; other
+// transports, such as the rpc package, call Search directly. Compiled regexes
+// and the ignore rules stay warm between requests; the rules are read again
+// once an ignore file changes. A Server is safe for concurrent use.
+type Server struct {
+	root     string
+	options  []Option
+	handler  http.Handler
+	audit    *slog.Logger   // Set with WithAuditLog
+	limits   ServerLimits   // Set with WithServerLimits
+	roots    []string       // Directories searches are confined to, as given
+	resolved []string       // The roots with symlinks resolved
+	proxies  []netip.Prefix // Set with WithTrustedProxies
+
+	mu     sync.Mutex
+	ignore map[string]*GitignoreEngine // By root, shared by every search until an ignore file changes
+	active map[string]int              // Searches running for each client
+}
+
+// NewServer creates a server for the directory root. The options apply to
+// every search before those of the request; WithTimeout caps how long a
+// request may run, WithServerLimits bounds the rest of its work,
+// WithAllowedRoots confines requests to other directories than root,
+// WithRejectSlowPatterns keeps untrusted queries from tying the server up,
+// WithAuditLog records every request and WithTrustedProxies lets proxies in
+// front of the server name the user.
+func NewServer(root string, opts ...Option) (*Server, error) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return nil, fmt.Errorf("path error: %w", err)
+	}
+	info, err := os.Stat(absRoot)
+	if err != nil {
+		return nil, fmt.Errorf("path error: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("path error: %s is not a directory", root)
+	}
+
+	options := defaultOptions()
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	// Confinement is checked against real paths too, so symlinks cannot
+	// lead out
+	roots := []string{absRoot}
+	if len(options.allowedRoots) > 0 {
+		roots = make([]string, len(options.allowedRoots))
+		for i, allowed := range options.allowedRoots {
+			if roots[i], err = filepath.Abs(allowed); err != nil {
+				return nil, fmt.Errorf("allowed root error: %w", err)
+			}
+		}
+	}
+	resolved := make([]string, len(roots))
+	for i, allowed := range roots {
+		if resolved[i], err = filepath.EvalSymlinks(allowed); err != nil {
+			return nil, fmt.Errorf("allowed root error: %w", err)
+		}
+	}
+
+	proxies := make([]netip.Prefix, len(options.proxies))
+	for i, proxy := range options.proxies {
+		if proxies[i], err = parseProxy(proxy); err != nil {
+			return nil, fmt.Errorf("trusted proxy error: %w", err)
+		}
+	}
+
+	s := &Server{
+		root:     absRoot,
+		options:  append([]Option{WithRegexCaching()}, opts...),
+		audit:    options.auditLog,
+		limits:   options.serverLimits,
+		roots:    roots,
+		resolved: resolved,
+		proxies:  proxies,
+		ignore:   make(map[string]*GitignoreEngine),
+		active:   make(map[string]int),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /search", s.handleSearch)
+	s.handler = mux
+	return s, nil
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.handler.ServeHTTP(w, r)
+}
+
+// serverClientKey is the context key of the client a search is made for
+type serverClientKey struct{}
+
+// serverClient identifies who a search is made for in the audit log
+type serverClient struct {
+	addr string
+	user string // As the request claims it, whoever sent it
+}
+
+// ContextWithClient returns a context naming the network address and user a
+// Server search is made for, as recorded by WithAuditLog. Transports set it
+// before calling Search. The user is only believed when addr is one of the
+// proxies given to WithTrustedProxies.
+func ContextWithClient(ctx context.Context, addr, user string) context.Context {
+	return context.WithValue(ctx, serverClientKey{}, serverClient{addr: addr, user: user})
+}
+
+// Search runs req under the server's root, passing each file's matches to fn
+// as they are found, and records it in the audit log. Paths outside the
+// allowed roots are reported with an error wrapping ErrPathNotAllowed, paths
+// that do not exist with one wrapping fs.ErrNotExist, requests that cannot
+// run with one wrapping ErrInvalidRequest and requests over the client's
+// concurrency limit with ErrTooManySearches, before fn is called. An error
+// from fn stops the search and is returned.
+func (s *Server) Search(ctx context.Context, req SearchRequest, fn func([]Match) error) (*SearchResults, error) {
+	start := time.Now()
+	results, err := s.search(ctx, req, fn)
+	if s.audit != nil {
+		s.logSearch(ctx, req, results, err, time.Since(start))
+	}
+	return results, err
+}
+
+// search runs req for Search
+func (s *Server) search(ctx context.Context, req SearchRequest, fn func([]Match) error) (*SearchResults, error) {
+	release, err := s.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	searchPath, err := s.searchPath(req.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	options, err := s.requestOptions(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidRequest, err)
+	}
+	pattern, err := options.resolvePatterns(req.Pattern)
+	if err == nil {
+		err = options.validate(pattern)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidRequest, err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if options.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, options.timeout)
+		defer cancel()
+	}
+
+	config := options.searchConfig(searchPath)
+	config.MaxFiles = s.limits.MaxFiles
+	config.MaxBytes = s.limits.MaxBytes
+	config.AllowedRoots = s.resolved
+	engine := &SearchEngine{
+		config:          config,
+		gitignoreEngine: s.ignoreEngine(config, searchPath),
+		sharedIgnore:    true,
+	}
+	var fnErr error
+	engine.emit = func(matches []Match) {
+		if fnErr == nil {
+			if fnErr = fn(matches); fnErr != nil {
+				cancel()
+			}
+		}
+	}
+
+	results, err := engine.Search(ctx, pattern)
+	if fnErr != nil {
+		return nil, fnErr
+	}
+	return results, err
+}
+
+// searchPath resolves the path of a request, refusing it if it lies outside
+// the allowed roots before or after resolving symlinks. Paths are checked
+// as written first, so refused paths do not reveal whether they exist.
+func (s *Server) searchPath(reqPath string) (string, error) {
+	searchPath := filepath.FromSlash(reqPath)
+	if !filepath.IsAbs(searchPath) {
+		searchPath = filepath.Join(s.root, searchPath)
+	}
+	searchPath = filepath.Clean(searchPath)
+
+	if !withinRoots(searchPath, s.roots) {
+		return "", fmt.Errorf("path error: %s: %w", reqPath, ErrPathNotAllowed)
+	}
+	resolved, err := filepath.EvalSymlinks(searchPath)
+	if err != nil {
+		return "", fmt.Errorf("path error: %s: %w", reqPath, fs.ErrNotExist)
+	}
+	if !withinRoots(resolved, s.resolved) {
+		return "", fmt.Errorf("path error: %s: %w", reqPath, ErrPathNotAllowed)
+	}
+	return searchPath, nil
+}
+
+// acquire counts a search against the concurrency limit of the client in
+// ctx, returning a function that releases it
+func (s *Server) acquire(ctx context.Context) (func(), error) {
+	if s.limits.MaxConcurrent <= 0 {
+		return func() {}, nil
+	}
+
+	// Users behind a trusted proxy are told apart by name, anyone else by
+	// address, since names are free to make up
+	client, _ := ctx.Value(serverClientKey{}).(serverClient)
+	key := s.verifiedUser(client)
+	if key == "" {
+		key = client.addr
+		if host, _, err := net.SplitHostPort(key); err == nil {
+			key = host
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.active[key] >= s.limits.MaxConcurrent {
+		return nil, fmt.Errorf("%w: at most %d per client", ErrTooManySearches, s.limits.MaxConcurrent)
+	}
+	s.active[key]++
+	return func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.active[key]--; s.active[key] == 0 {
+			delete(s.active, key)
+		}
+	}, nil
+}
+
+// verifiedUser returns the user client names when it connects from a
+// trusted proxy, and "" when the name cannot be believed
+func (s *Server) verifiedUser(client serverClient) string {
+	host := client.addr
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return ""
+	}
+	addr = addr.Unmap()
+	for _, proxy := range s.proxies {
+		if proxy.Contains(addr) {
+			return client.user
+		}
+	}
+	return ""
+}
+
+// parseProxy parses a trusted proxy given as an IP or a CIDR range
+func parseProxy(proxy string) (netip.Prefix, error) {
+	if addr, err := netip.ParseAddr(proxy); err == nil {
+		addr = addr.Unmap()
+		return netip.PrefixFrom(addr, addr.BitLen()), nil
+	}
+	prefix, err := netip.ParsePrefix(proxy)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("%q is neither an IP nor a CIDR range", proxy)
+	}
+	return prefix.Masked(), nil
+}
+
+// handleSearch answers POST /search, streaming the matches as they are found
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	// Authenticating proxies name the user; verifiedUser decides whether
+	// to believe them
+	user, _, _ := r.BasicAuth()
+	if user == "" {
+		user = r.Header.Get("X-Forwarded-User")
+	}
+	ctx := ContextWithClient(r.Context(), r.RemoteAddr, user)
+
+	var req SearchRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, serverMaxRequest)).Decode(&req); err != nil {
+		err = fmt.Errorf("%w: %w", ErrInvalidRequest, err)
+		if s.audit != nil {
+			s.logSearch(ctx, req, nil, err, 0)
+		}
+		writeServerError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	encoder := json.NewEncoder(w)
+	flusher, _ := w.(http.Flusher)
+	results, err := s.Search(ctx, req, func(matches []Match) error {
+		for i := range matches {
+			if err := encoder.Encode(ServerEvent{Type: "match", Match: &matches[i]}); err != nil {
+				return err
+			}
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return nil
+	})
+
+	switch {
+	case errors.Is(err, ErrPathNotAllowed):
+		writeServerError(w, http.StatusForbidden, err)
+	case errors.Is(err, fs.ErrNotExist):
+		writeServerError(w, http.StatusNotFound, err)
+	case errors.Is(err, ErrInvalidRequest):
+		writeServerError(w, http.StatusBadRequest, err)
+	case errors.Is(err, ErrTooManySearches):
+		writeServerError(w, http.StatusTooManyRequests, err)
+	case err != nil:
+		_ = encoder.Encode(ServerEvent{Type: "error", Error: err.Error()})
+	default:
+		_ = encoder.Encode(ServerEvent{Type: "done", Stats: &results.Stats, Counts: results.Counts})
+	}
+}
+
+// logSearch records a finished search request in the audit log: who sent
+// it, what it searched for, how it ended and what it cost
+func (s *Server) logSearch(ctx context.Context, req SearchRequest, results *SearchResults, err error, duration time.Duration) {
+	outcome := "ok"
+	switch {
+	case err == nil:
+	case errors.Is(err, ErrSlowPattern):
+		outcome = "rejected"
+	case errors.Is(err, ErrTooManySearches):
+		outcome = "throttled"
+	case errors.Is(err, ErrPathNotAllowed):
+		outcome = "denied"
+	case errors.Is(err, ErrInvalidRequest), errors.Is(err, fs.ErrNotExist):
+		outcome = "invalid"
+	case errors.Is(err, context.DeadlineExceeded):
+		outcome = "timeout"
+	case errors.Is(err, context.Canceled):
+		outcome = "canceled"
+	default:
+		outcome = "error"
+	}
+
+	client, _ := ctx.Value(serverClientKey{}).(serverClient)
+	user := s.verifiedUser(client)
+	attrs := []slog.Attr{
+		slog.String("client", client.addr),
+		slog.String("user", user),
+	}
+	if user == "" && client.user != "" {
+		attrs = append(attrs, slog.String("claimed_user", client.user))
+	}
+	attrs = append(attrs, slog.String("pattern", req.Pattern))
+	if len(req.Patterns) > 0 {
+		attrs = append(attrs, slog.Any("patterns", req.Patterns))
+	}
+	attrs = append(attrs,
+		slog.String("path", req.Path),
+		slog.String("outcome", outcome),
+		slog.Duration("duration", duration),
+	)
+	if results != nil {
+		attrs = append(attrs,
+			slog.Int64("matches", results.Stats.MatchesFound),
+			slog.Int64("files_scanned", results.Stats.FilesScanned),
+			slog.Int64("bytes_scanned", results.Stats.BytesScanned),
+			slog.Bool("stopped_early", results.Stats.StoppedEarly),
+		)
+	}
+
+	level := slog.LevelInfo
+	if err != nil {
+		level = slog.LevelWarn
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	s.audit.LogAttrs(context.Background(), level, "search", attrs...)
+}
+
+// requestOptions applies the server's options and then those of req
+func (s *Server) requestOptions(req SearchRequest) (*searchOptions, error) {
+	options := defaultOptions()
+	options.recursive = true
+	for _, opt := range s.options {
+		opt(options)
+	}
+
+	if req.Timeout != "" {
+		timeout, err := time.ParseDuration(req.Timeout)
+		if err != nil || timeout <= 0 {
+			return nil, fmt.Errorf("invalid timeout %q", req.Timeout)
+		}
+		if options.timeout == 0 || timeout < options.timeout {
+			options.timeout = timeout
+		}
+	}
+
+	opts := []Option{WithPatterns(req.Patterns)}
+	if req.IgnoreCase {
+		opts = append(opts, WithIgnoreCase())
+	} else if req.SmartCase {
+		opts = append(opts, WithSmartCase())
+	}
+	if req.FixedStrings {
+		opts = append(opts, WithFixedStrings())
+	}
+	if req.WordRegexp {
+		opts = append(opts, WithWordRegexp())
+	}
+	if req.LineRegexp {
+		opts = append(opts, WithLineRegexp())
+	}
+	if req.Multiline {
+		opts = append(opts, WithMultiline())
+	}
+	if req.PCRE2 {
+		opts = append(opts, WithPCRE2Syntax())
+	}
+	if req.InvertMatch {
+		opts = append(opts, WithInvertMatch())
+	}
+	if req.Recursive != nil {
+		opts = append(opts, WithRecursive(*req.Recursive))
+	}
+	if req.Hidden {
+		opts = append(opts, WithHidden())
+	}
+	if len(req.Globs) > 0 {
+		opts = append(opts, WithIncludeGlobs(req.Globs))
+	}
+	if len(req.Types) > 0 {
+		opts = append(opts, WithFileTypes(req.Types))
+	}
+	if len(req.TypesNot) > 0 {
+		opts = append(opts, WithFileTypesNot(req.TypesNot))
+	}
+	if req.Context > 0 {
+		opts = append(opts, WithContextLines(req.Context))
+	}
+	if req.BeforeContext > 0 {
+		opts = append(opts, WithBeforeContext(req.BeforeContext))
+	}
+	if req.AfterContext > 0 {
+		opts = append(opts, WithAfterContext(req.AfterContext))
+	}
+	if req.MaxResults > 0 {
+		opts = append(opts, WithMaxResults(req.MaxResults))
+	}
+	if req.QuitAfter > 0 {
+		opts = append(opts, WithQuitAfter(req.QuitAfter))
+	}
+	if req.Count {
+		opts = append(opts, WithCountOnly())
+	}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	// Requests can lower the result limit but not raise it
+	if limit := s.limits.MaxResults; limit > 0 && (options.quitAfter == 0 || options.quitAfter > limit) {
+		options.quitAfter = limit
+	}
+	return options, nil
+}
+
+// ignoreEngine returns the ignore rules shared by searches with config
+// under the root holding searchPath, loading them again when an ignore file
+// has changed since they were read
+func (s *Server) ignoreEngine(config SearchConfig, searchPath string) *GitignoreEngine {
+	if !config.UseGitignore {
+		return nil
+	}
+
+	// Rules are read relative to their root, so each allowed root outside
+	// the server's own has rules of its own
+	root := s.root
+	if !withinRoots(searchPath, []string{s.root}) {
+		for _, allowed := range s.roots {
+			if withinRoots(searchPath, []string{allowed}) && (root == s.root || len(allowed) > len(root)) {
+				root = allowed
+			}
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ignore[root] == nil || s.ignore[root].changed() {
+		s.ignore[root] = NewGitignoreEngine(root, config.IgnoreFiles...)
+	}
+	return s.ignore[root]
+}
+
+// writeServerError answers a request that could not start searching
+func writeServerError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(ServerEvent{Type: "error", Error: err.Error()})
+}
@@ -0,0 +1,395 @@
+package goripgrep
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// serverSearch posts body to the server's search endpoint and decodes the
+// events of its response
+func serverSearch(t *testing.T, server *httptest.Server, body string) (int, []ServerEvent) {
+	t.Helper()
+	resp, err := http.Post(server.URL+"/search", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var events []ServerEvent
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		var event ServerEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatalf("Invalid event %q: %v", scanner.Text(), err)
+		}
+		events = append(events, event)
+	}
+	return resp.StatusCode, events
+}
+
+func TestServer(t *testing.T) {
+	root := t.TempDir()
+	writeTestFiles(t, root, map[string]string{
+		"a.go":        "package a\n// TODO one\n",
+		"sub/b.go":    "// TODO two\n// todo three\n",
+		"sub/c.txt":   "TODO text\n",
+		"gen/d.go":    "// TODO generated\n",
+		".gitignore":  "gen/\n",
+		"../out.txt":  "TODO outside\n",
+		"sub/.hidden": "TODO hidden\n",
+	})
+
+	s, err := NewServer(root, WithRejectSlowPatterns())
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	server := httptest.NewServer(s)
+	defer server.Close()
+
+	// Searches are recursive and respect ignore files by default
+	status, events := serverSearch(t, server, `{"pattern": "TODO"}`)
+	if status != http.StatusOK || len(events) != 4 || events[3].Type != "done" {
+		t.Fatalf("Expected 3 matches and done, got %d %+v", status, events)
+	}
+	if events[3].Stats.MatchesFound != 3 {
+		t.Errorf("Expected 3 matches in the stats, got %d", events[3].Stats.MatchesFound)
+	}
+
+	// Request options narrow the search
+	status, events = serverSearch(t, server, `{"pattern": "todo", "path": "sub", "ignore_case": true, "globs": ["*.go"]}`)
+	if status != http.StatusOK || len(events) != 3 {
+		t.Fatalf("Expected 2 matches and done, got %d %+v", status, events)
+	}
+	for _, event := range events[:2] {
+		if event.Type != "match" || event.Match.File != filepath.Join(root, "sub", "b.go") {
+			t.Errorf("Expected a match in sub/b.go, got %+v", event)
+		}
+	}
+
+	status, events = serverSearch(t, server, `{"pattern": "TODO", "count": true}`)
+	if status != http.StatusOK || len(events) != 1 || events[0].Counts[filepath.Join(root, "a.go")] != 1 {
+		t.Errorf("Expected counts in the done event, got %d %+v", status, events)
+	}
+
+	// Paths stay inside the root
+	status, events = serverSearch(t, server, `{"pattern": "outside", "path": "../"}`)
+	if status != http.StatusForbidden || len(events) != 1 || events[0].Type != "error" {
+		t.Errorf("Expected a path outside the root to be refused, got %d %+v", status, events)
+	}
+
+	// Ignore rules are read again once they change
+	if err := os.WriteFile(filepath.Join(root, ".gitignore"), []byte("sub/\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, events = serverSearch(t, server, `{"pattern": "TODO"}`)
+	if len(events) != 3 {
+		t.Errorf("Expected the changed ignore rules to apply, got %+v", events)
+	}
+}
+
+func TestServerErrors(t *testing.T) {
+	root := t.TempDir()
+	writeTestFiles(t, root, map[string]string{"a.txt": "text\n"})
+
+	if _, err := NewServer(filepath.Join(root, "a.txt")); err == nil {
+		t.Error("Expected an error for a file root")
+	}
+
+	s, err := NewServer(root, WithRejectSlowPatterns())
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	server := httptest.NewServer(s)
+	defer server.Close()
+
+	tests := []struct {
+		body   string
+		status int
+	}{
+		{`{"pattern": `, http.StatusBadRequest},
+		{`{"pattern": "("}`, http.StatusBadRequest},
+		{`{"pattern": "[a-z]{1,1000}"}`, http.StatusBadRequest},
+		{`{"pattern": "x", "timeout": "soon"}`, http.StatusBadRequest},
+		{`{"pattern": "x", "path": "missing"}`, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		status, events := serverSearch(t, server, tt.body)
+		if status != tt.status || len(events) != 1 || events[0].Type != "error" || events[0].Error == "" {
+			t.Errorf("Expected status %d with an error event for %s, got %d %+v", tt.status, tt.body, status, events)
+		}
+	}
+
+	resp, err := http.Get(server.URL + "/search")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("Expected GET to be refused, got %d", resp.StatusCode)
+	}
+}
+
+func TestServerAuditLog(t *testing.T) {
+	root := t.TempDir()
+	writeTestFiles(t, root, map[string]string{"a.txt": "TODO one\nTODO two\n"})
+
+	var log bytes.Buffer
+	s, err := NewServer(root, WithRejectSlowPatterns(), WithAuditLog(slog.New(slog.NewJSONHandler(&log, nil))))
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	server := httptest.NewServer(s)
+	defer server.Close()
+
+	req, err := http.NewRequest(http.MethodPost, server.URL+"/search", strings.NewReader(`{"pattern": "TODO", "path": "a.txt"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.SetBasicAuth("alice", "secret")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The handler logs before ending the response
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	serverSearch(t, server, `{"pattern": "[a-z]{1,1000}"}`)
+
+	var records []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(log.String()), "\n") {
+		var record map[string]any
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("Invalid audit record %q: %v", line, err)
+		}
+		records = append(records, record)
+	}
+	if len(records) != 2 {
+		t.Fatalf("Expected an audit record per request, got %v", records)
+	}
+
+	// The name comes from an untrusted address
+	first := records[0]
+	if first["msg"] != "search" || first["user"] != "" || first["claimed_user"] != "alice" || first["pattern"] != "TODO" || first["path"] != "a.txt" ||
+		first["outcome"] != "ok" || first["matches"] != 2.0 || first["files_scanned"] != 1.0 {
+		t.Errorf("Unexpected audit record %v", first)
+	}
+	if _, ok := first["duration"]; !ok {
+		t.Errorf("Expected the duration in %v", first)
+	}
+
+	second := records[1]
+	if second["level"] != "WARN" || second["outcome"] != "rejected" || second["error"] == nil {
+		t.Errorf("Unexpected audit record for a rejected pattern %v", second)
+	}
+
+	// A trusted proxy vouches for the name
+	log.Reset()
+	s, err = NewServer(root, WithAuditLog(slog.New(slog.NewJSONHandler(&log, nil))), WithTrustedProxies("127.0.0.1", "::1"))
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	proxied := httptest.NewServer(s)
+	defer proxied.Close()
+	req, err = http.NewRequest(http.MethodPost, proxied.URL+"/search", strings.NewReader(`{"pattern": "TODO"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("X-Forwarded-User", "alice")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	var record map[string]any
+	if err := json.Unmarshal(log.Bytes(), &record); err != nil {
+		t.Fatalf("Invalid audit record %q: %v", log.String(), err)
+	}
+	if _, claimed := record["claimed_user"]; record["user"] != "alice" || claimed {
+		t.Errorf("Expected the proxy's user to be recorded as verified, got %v", record)
+	}
+}
+
+func TestServerLimits(t *testing.T) {
+	root := t.TempDir()
+	files := make(map[string]string)
+	for i := 0; i < 10; i++ {
+		files[fmt.Sprintf("f%d.txt", i)] = "TODO one\nTODO two\n"
+	}
+	writeTestFiles(t, root, files)
+
+	s, err := NewServer(root, WithServerLimits(ServerLimits{MaxFiles: 3, MaxResults: 5, MaxConcurrent: 1}))
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	server := httptest.NewServer(s)
+	defer server.Close()
+
+	// Requests stop early at the file limit
+	_, events := serverSearch(t, server, `{"pattern": "TODO", "count": true}`)
+	done := events[len(events)-1]
+	if done.Type != "done" || !done.Stats.StoppedEarly || done.Stats.FilesScanned != 3 || len(done.Counts) != 3 {
+		t.Errorf("Expected 3 files searched, got %+v", done)
+	}
+
+	// and at the result limit, which requests can lower but not raise
+	for body, want := range map[string]int{
+		`{"pattern": "TODO"}`:                   5,
+		`{"pattern": "TODO", "quit_after": 50}`: 5,
+		`{"pattern": "TODO", "quit_after": 2}`:  2,
+	} {
+		_, events = serverSearch(t, server, body)
+		if len(events) != want+1 || !events[want].Stats.StoppedEarly {
+			t.Errorf("Expected %d matches for %s, got %+v", want, body, events)
+		}
+	}
+
+	// A client running a search is refused another
+	ctx := ContextWithClient(context.Background(), "127.0.0.1:1234", "")
+	_, err = s.Search(ctx, SearchRequest{Pattern: "TODO"}, func([]Match) error {
+		status, events := serverSearch(t, server, `{"pattern": "TODO"}`)
+		if status != http.StatusTooManyRequests || len(events) != 1 || events[0].Type != "error" {
+			t.Errorf("Expected a concurrent request to be refused, got %d %+v", status, events)
+		}
+		// Names from untrusted addresses are made up as easily as they are sent
+		if _, err := s.Search(ContextWithClient(context.Background(), "127.0.0.1:1234", "bob"), SearchRequest{Pattern: "TODO"}, func([]Match) error { return nil }); !errors.Is(err, ErrTooManySearches) {
+			t.Errorf("Expected a request claiming another user to be refused, got %v", err)
+		}
+		return errors.New("stop")
+	})
+	if err == nil || err.Error() != "stop" {
+		t.Errorf("Expected the callback error, got %v", err)
+	}
+	if status, _ := serverSearch(t, server, `{"pattern": "TODO"}`); status != http.StatusOK {
+		t.Errorf("Expected the client to search again once its search ended, got %d", status)
+	}
+
+	// Users behind a trusted proxy are limited one by one
+	s, err = NewServer(root, WithServerLimits(ServerLimits{MaxConcurrent: 1}), WithTrustedProxies("10.0.0.0/8"))
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	ctx = ContextWithClient(context.Background(), "10.1.2.3:1234", "alice")
+	_, err = s.Search(ctx, SearchRequest{Pattern: "TODO"}, func([]Match) error {
+		if _, err := s.Search(ContextWithClient(context.Background(), "10.1.2.3:1234", "bob"), SearchRequest{Pattern: "TODO"}, func([]Match) error { return nil }); err != nil {
+			t.Errorf("Expected another user's request to run, got %v", err)
+		}
+		if _, err := s.Search(ctx, SearchRequest{Pattern: "TODO"}, func([]Match) error { return nil }); !errors.Is(err, ErrTooManySearches) {
+			t.Errorf("Expected the same user's request to be refused, got %v", err)
+		}
+		return errors.New("stop")
+	})
+	if err == nil || err.Error() != "stop" {
+		t.Errorf("Expected the callback error, got %v", err)
+	}
+
+	if _, err := NewServer(root, WithTrustedProxies("proxy.example")); err == nil {
+		t.Error("Expected an invalid trusted proxy to be refused")
+	}
+}
+
+func TestServerAllowedRoots(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"tenants/a/a.txt":  "TODO a\n",
+		"tenants/b/b.txt":  "TODO b\n",
+		"tenants/c/c.txt":  "TODO c\n",
+		"secret/s.txt":     "TODO secret\n",
+		"secret/dir/d.txt": "TODO secret dir\n",
+	})
+	tenants := filepath.Join(dir, "tenants")
+	for link, target := range map[string]string{
+		"a/s.txt": filepath.Join(dir, "secret", "s.txt"),
+		"a/dir":   filepath.Join(dir, "secret", "dir"),
+		"a/b.txt": filepath.Join(tenants, "b", "b.txt"),
+	} {
+		if err := os.Symlink(target, filepath.Join(tenants, link)); err != nil {
+			t.Skipf("Symlinks unavailable: %v", err)
+		}
+	}
+
+	s, err := NewServer(tenants, WithOptimizedWalking(true), WithAllowedRoots(filepath.Join(tenants, "a"), filepath.Join(tenants, "b")))
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	server := httptest.NewServer(s)
+	defer server.Close()
+
+	// Symlinks are only searched when they resolve inside an allowed root
+	status, events := serverSearch(t, server, `{"pattern": "TODO", "path": "a"}`)
+	if status != http.StatusOK || len(events) != 3 {
+		t.Fatalf("Expected 2 matches and done, got %d %+v", status, events)
+	}
+	for _, event := range events[:2] {
+		if strings.Contains(event.Match.Content, "secret") {
+			t.Errorf("Expected no match through a symlink out of the roots, got %+v", event.Match)
+		}
+	}
+
+	tests := []struct {
+		path   string
+		status int
+	}{
+		{"b", http.StatusOK},
+		{filepath.Join(tenants, "b", "b.txt"), http.StatusOK},
+		{"c", http.StatusForbidden},
+		{"", http.StatusForbidden},
+		{"a/../../secret", http.StatusForbidden},
+		{filepath.Join(dir, "secret"), http.StatusForbidden},
+		{"a/s.txt", http.StatusForbidden},
+		{"a/dir", http.StatusForbidden},
+		{"a/missing", http.StatusNotFound},
+		{"c/missing", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		body, _ := json.Marshal(SearchRequest{Pattern: "TODO", Path: tt.path})
+		if status, events := serverSearch(t, server, string(body)); status != tt.status {
+			t.Errorf("Expected status %d for %q, got %d %+v", tt.status, tt.path, status, events)
+		}
+	}
+
+	if _, err := NewServer(tenants, WithAllowedRoots(filepath.Join(dir, "missing"))); err == nil {
+		t.Error("Expected an error for a missing allowed root")
+	}
+}
+
+func TestServerAllowedRootsGitignore(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"main/.gitignore":  "secret.txt\n",
+		"main/secret.txt":  "TODO secret\n",
+		"main/ok.txt":      "TODO main\n",
+		"other/.gitignore": "secret.txt\n",
+		"other/secret.txt": "TODO secret\n",
+		"other/ok.txt":     "TODO other\n",
+	})
+	main, other := filepath.Join(dir, "main"), filepath.Join(dir, "other")
+	s, err := NewServer(main, WithAllowedRoots(main, other))
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	server := httptest.NewServer(s)
+	defer server.Close()
+
+	// Each root's ignore rules apply to searches under it
+	for path, want := range map[string]string{"": "TODO main", other: "TODO other"} {
+		body, _ := json.Marshal(SearchRequest{Pattern: "TODO", Path: path})
+		status, events := serverSearch(t, server, string(body))
+		if status != http.StatusOK || len(events) != 2 || events[0].Match.Content != want {
+			t.Errorf("Expected only %q searching %q, got %d %+v", want, path, status, events)
+		}
+	}
+}
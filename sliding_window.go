@@ -1,14 +1,16 @@
 package goripgrep
 
 import (
-	"bufio"
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"regexp"
 	"runtime"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -33,7 +35,15 @@ type SlidingWindowOptions struct {
 	MinChunkSize     int64 // Minimum allowed chunk size (default: 1MB)
 	AdaptiveResize   bool  // Enable adaptive chunk resizing based on memory pressure
 	UseMemoryMap     bool  // Use memory mapping when available and beneficial
-	MaxPatternLength int   // Maximum expected pattern length for overlap calculation (default: 1024)
+	MaxPatternLength int   // Minimum overlap reserved for matches, and the bound for unbounded regexes (default: 1024)
+	Multiline        bool  // Match the pattern as a regex across line boundaries
+	IgnoreCase       bool  // Case-insensitive matching (multiline mode)
+	InvertMatch      bool  // Report lines that do not contain the pattern (line mode only)
+	BeforeContext    int   // Lines of context to attach before each match
+	AfterContext     int   // Lines of context to attach after each match
+	MaxCount         int   // Stop after matches on this many lines, like grep -m (0 for no limit)
+	Workers          int   // Chunks read and searched at once in line mode (default: 1)
+	MemoryBudget     int64 // With Workers, the most bytes of chunks in flight at once (default: 256MB)
 	// Enhanced progress callback with comprehensive information
 	ProgressCallback func(bytesProcessed, totalBytes int64, percentage float64)
 	// Enhanced progress callback with detailed information
@@ -51,21 +61,30 @@ func DefaultSlidingWindowOptions() SlidingWindowOptions {
 		AdaptiveResize:   true,
 		UseMemoryMap:     true,
 		MaxPatternLength: 1024, // 1KB max pattern length
+		Workers:          1,
+		MemoryBudget:     256 * 1024 * 1024, // 256MB
 	}
 }
 
 // SlidingWindowSearcher handles chunked searching through very large files
 type SlidingWindowSearcher struct {
-	file          *os.File
-	fileSize      int64
-	options       SlidingWindowOptions
-	pattern       string
-	currentPos    int64
-	buffer        []byte
-	overlapBuffer []byte
-	// Backtracking state
-	lastChunkEnd    int64            // Byte position where last chunk ended
-	processedRanges []ProcessedRange // Track processed byte ranges to avoid duplicates
+	file       *os.File
+	mapping    *mappedFile // Set when UseMemoryMap mapped file, which is then read through it
+	reader     io.Reader   // Stream searched instead of file, of unknown size
+	name       string      // Reported as the File of every match
+	fileSize   int64       // Size of file, or -1 for a stream; lowered if the file is truncated while it is read
+	options    SlidingWindowOptions
+	pattern    string
+	currentPos int64
+	// Compiled pattern for multiline mode
+	multilineRegex *regexp.Regexp
+	// Longest possible match in bytes, or -1 if the pattern is unbounded
+	maxMatchLength int
+	// Line mode matching used in place of a substring search
+	lineSpans func(line string) [][]int
+	// Number of context lines attached to matches
+	beforeContext int
+	afterContext  int
 	// Progress tracking fields
 	startTime          time.Time // When the search started
 	chunkCount         int       // Number of chunks processed
@@ -73,12 +92,6 @@ type SlidingWindowSearcher struct {
 	lastProgressUpdate time.Time // Last time progress was reported
 }
 
-// ProcessedRange tracks a range of bytes that have been fully processed
-type ProcessedRange struct {
-	Start int64
-	End   int64
-}
-
 // NewSlidingWindowSearcher creates a new sliding window searcher
 func NewSlidingWindowSearcher(filepath string, pattern string, options SlidingWindowOptions) (*SlidingWindowSearcher, error) {
 	file, err := os.Open(filepath)
@@ -92,13 +105,59 @@ func NewSlidingWindowSearcher(filepath string, pattern string, options SlidingWi
 		return nil, fmt.Errorf("failed to get file info: %w", err)
 	}
 
-	fileSize := fileInfo.Size()
+	searcher, err := newSlidingWindowSearcher(pattern, options, nil)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	searcher.file = file
+	searcher.name = file.Name()
+	searcher.fileSize = fileInfo.Size()
+
+	// Files with a byte order mark are read as a stream, which drops the
+	// mark and decodes UTF-16, instead of at offsets
+	head := make([]byte, len(utf8BOM))
+	if n, _ := file.ReadAt(head, 0); hasBOM(head[:n]) {
+		searcher.reader = &bomReader{reader: io.NewSectionReader(file, 0, searcher.fileSize)}
+		return searcher, nil
+	}
+
+	// Where mapping is unsupported or fails the file is read as usual
+	if options.UseMemoryMap {
+		if mapping, err := mapFile(file, searcher.fileSize); err == nil {
+			searcher.mapping = mapping
+		}
+	}
+
+	return searcher, nil
+}
+
+// NewSlidingWindowReaderSearcher creates a sliding window searcher over a
+// stream of unknown, possibly unbounded length such as standard input. Matches
+// are reported with File set to name. Use SearchStream to handle matches as
+// they are found instead of collecting them.
+func NewSlidingWindowReaderSearcher(reader io.Reader, name string, pattern string, options SlidingWindowOptions) (*SlidingWindowSearcher, error) {
+	searcher, err := newSlidingWindowSearcher(pattern, options, nil)
+	if err != nil {
+		return nil, err
+	}
+	searcher.reader = reader
+	searcher.name = name
+	searcher.fileSize = -1
+
+	return searcher, nil
+}
 
+// newSlidingWindowSearcher validates options and compiles pattern. A non-nil
+// regex is used as the multiline regex instead of compiling pattern.
+func newSlidingWindowSearcher(pattern string, options SlidingWindowOptions, regex *regexp.Regexp) (*SlidingWindowSearcher, error) {
 	searcher := &SlidingWindowSearcher{
-		file:     file,
-		fileSize: fileSize,
-		options:  options,
-		pattern:  pattern,
+		options: options,
+		pattern: pattern,
+		// Line mode matches the pattern as a plain substring
+		maxMatchLength: len(pattern),
+		beforeContext:  options.BeforeContext,
+		afterContext:   options.AfterContext,
 		// Initialize progress tracking fields
 		startTime:          time.Now(),
 		chunkCount:         0,
@@ -106,11 +165,31 @@ func NewSlidingWindowSearcher(filepath string, pattern string, options SlidingWi
 		lastProgressUpdate: time.Now(),
 	}
 
+	if options.Multiline && options.InvertMatch {
+		return nil, fmt.Errorf("invert match is not supported for multiline streaming search")
+	}
+
+	if options.Multiline {
+		if regex == nil {
+			var err error
+			regex, err = compileMultilineRegex(pattern, options.IgnoreCase)
+			if err != nil {
+				return nil, fmt.Errorf("invalid regex pattern: %w", err)
+			}
+		}
+		searcher.multilineRegex = regex
+		searcher.maxMatchLength = maxMatchLength(regex.String())
+	}
+
 	return searcher, nil
 }
 
 // Close releases resources used by the searcher
 func (s *SlidingWindowSearcher) Close() error {
+	if s.mapping != nil {
+		s.mapping.Close()
+		s.mapping = nil
+	}
 	if s.file != nil {
 		return s.file.Close()
 	}
@@ -119,228 +198,800 @@ func (s *SlidingWindowSearcher) Close() error {
 
 // Search performs the sliding window search through the file
 func (s *SlidingWindowSearcher) Search(ctx context.Context) ([]Match, error) {
-	return s.slidingWindowSearch(ctx)
-}
+	// Streams are searched sequentially and their matches collected. So are
+	// files with context, which SearchStream keeps only as many lines of as
+	// the context needs, whatever the size of the file.
+	if s.reader != nil || s.hasContext() {
+		var matches []Match
+		err := s.SearchStream(ctx, func(match Match) error {
+			matches = append(matches, match)
+			return nil
+		})
+		return matches, err
+	}
 
-// slidingWindowSearch implements the core sliding window algorithm
-func (s *SlidingWindowSearcher) slidingWindowSearch(ctx context.Context) ([]Match, error) {
 	var matches []Match
+	var err error
+	switch {
+	case s.options.Multiline:
+		matches, err = s.multilineSearch(ctx)
+	case s.options.Workers > 1:
+		matches, err = s.parallelSearch(ctx)
+	default:
+		matches, err = s.slidingWindowSearch(ctx)
+	}
+	return limitMatchLines(matches, s.options.MaxCount), err
+}
+
+// reachedMaxCount reports whether matches cover MaxCount lines, so the rest
+// of the file need not be read
+func (s *SlidingWindowSearcher) reachedMaxCount(matches []Match) bool {
+	return s.options.MaxCount > 0 && matchLines(matches) >= s.options.MaxCount
+}
 
-	// Initialize buffer with pattern-aware overlap size
-	chunkSize := s.getOptimalChunkSize()
-	s.buffer = make([]byte, chunkSize)
+// hasContext reports whether matches carry surrounding lines
+func (s *SlidingWindowSearcher) hasContext() bool {
+	return s.beforeContext > 0 || s.afterContext > 0
+}
 
-	// Calculate optimal overlap size based on pattern length
-	optimalOverlap := s.calculateOptimalOverlap()
-	s.overlapBuffer = make([]byte, optimalOverlap)
+// multilineSearch matches the regex against a sliding window of whole-buffer
+// data. Matches starting in the trailing overlap are deferred to the next
+// window, and each window starts on a line boundary so anchors stay correct.
+func (s *SlidingWindowSearcher) multilineSearch(ctx context.Context) ([]Match, error) {
+	var matches []Match
 
-	defer func() {
-		// Report final progress
-		if s.options.ProgressCallback != nil {
-			s.options.ProgressCallback(s.fileSize, s.fileSize, 100.0)
-		}
-		if s.options.ProgressCallbackDetailed != nil {
-			finalInfo := s.GetProgressInfo()
-			finalInfo.Percentage = 100.0
-			finalInfo.BytesProcessed = s.fileSize
-			s.options.ProgressCallbackDetailed(finalInfo)
-		}
-	}()
+	overlap := int(s.calculateOptimalOverlap())
+	var window []byte
+	line := 1      // Line number of window[0]
+	skipUntil := 0 // Matches starting before this window offset were already reported
 
-	chunkCount := 0
 	for s.currentPos < s.fileSize {
-		// Check for context cancellation more frequently
 		select {
 		case <-ctx.Done():
 			return matches, ctx.Err()
 		default:
 		}
 
-		// Adapt chunk size if needed
-		if s.options.AdaptiveResize {
-			newChunkSize := s.getOptimalChunkSize()
-			if newChunkSize != int64(len(s.buffer)) {
-				s.buffer = make([]byte, newChunkSize)
-			}
+		readSize := s.getOptimalChunkSize()
+		if remaining := s.fileSize - s.currentPos; remaining < readSize {
+			readSize = remaining
 		}
 
-		// Store the position before reading for base offset calculation
-		chunkStartPos := s.currentPos
+		chunk := make([]byte, readSize)
+		n, err := s.readAt(chunk, s.currentPos)
+		if err != nil && err != io.EOF {
+			return matches, fmt.Errorf("failed to read chunk: %w", err)
+		}
+		if n == 0 {
+			break
+		}
+		s.currentPos += int64(n)
+		window = append(window, chunk[:n]...)
 
-		// Read chunk with enhanced overlap handling
-		chunk, actualSize, err := s.readChunkWithEnhancedOverlap()
-		if err != nil {
-			if err == io.EOF {
+		commitLimit := len(window)
+		if s.currentPos < s.fileSize {
+			commitLimit -= overlap
+			if commitLimit < 0 {
+				commitLimit = 0
+			}
+		}
+
+		var spans [][]int
+		consumed := commitLimit
+		for _, span := range s.multilineRegex.FindAllIndex(window, -1) {
+			if span[0] < skipUntil {
+				continue
+			}
+			if span[0] >= commitLimit {
 				break
 			}
-			return matches, fmt.Errorf("failed to read chunk: %w", err)
+			spans = append(spans, span)
+			if span[1] > consumed {
+				consumed = span[1]
+			}
+		}
+
+		chunkMatches := multilineMatchesFromSpans(s.file.Name(), window, spans, line)
+		matches = append(matches, chunkMatches...)
+		s.updateProgress(len(chunkMatches))
+		if s.reachedMaxCount(matches) {
+			break
 		}
 
-		// Check for context cancellation after reading
+		// Keep the tail of the window, restarting at the beginning of a line.
+		// Without a newline the whole window is kept so columns stay correct.
+		cut := bytes.LastIndexByte(window[:commitLimit], '\n') + 1
+		line += bytes.Count(window[:cut], []byte{'\n'})
+		skipUntil = consumed - cut
+		window = append([]byte(nil), window[cut:]...)
+	}
+
+	return matches, nil
+}
+
+// streamState carries line numbering and context between windows of a stream
+type streamState struct {
+	line      int      // Line number of the first byte of the window
+	skipUntil int      // Multiline matches starting before this window offset were already reported
+	before    []string // The last lines consumed, at most as many as the before context
+	pending   []Match  // Matches still collecting after context
+}
+
+// SearchStream reads the source sequentially and passes each match to fn as
+// soon as the lines it covers have been read, so it also works as a filter on
+// streams that never end. Memory is bounded by the chunk size, the overlap and
+// the longest line. Returning an error from fn stops the search with that error.
+func (s *SlidingWindowSearcher) SearchStream(ctx context.Context, fn func(Match) error) error {
+	if s.options.MaxCount > 0 {
+		err := s.searchStream(ctx, limitLines(fn, s.options.MaxCount))
+		if err == errMaxCount {
+			err = nil
+		}
+		return err
+	}
+	return s.searchStream(ctx, fn)
+}
+
+// errMaxCount stops a stream search once MaxCount lines have matched
+var errMaxCount = errors.New("max count reached")
+
+// limitLines passes the matches on the first n lines that have any on to fn,
+// returning errMaxCount at the first match on a line after them
+func limitLines(fn func(Match) error, n int) func(Match) error {
+	lines, last := 0, 0
+	return func(match Match) error {
+		if lines == 0 || match.Line != last {
+			if lines == n {
+				return errMaxCount
+			}
+			lines++
+			last = match.Line
+		}
+		return fn(match)
+	}
+}
+
+// searchStream is SearchStream without the MaxCount limit
+func (s *SlidingWindowSearcher) searchStream(ctx context.Context, fn func(Match) error) error {
+	source := s.reader
+	if source == nil {
+		source = io.NewSectionReader(readerAtFunc(s.readFileAt), 0, s.fileSize)
+	}
+
+	chunk := make([]byte, s.getOptimalChunkSize())
+	overlap := int(s.calculateOptimalOverlap())
+	state := &streamState{line: 1}
+	var window []byte
+
+	for {
 		select {
 		case <-ctx.Done():
-			return matches, ctx.Err()
+			return ctx.Err()
 		default:
 		}
 
-		// Search within the chunk with boundary tracking
-		chunkMatches, err := s.searchChunkWithBoundaryTracking(chunk[:actualSize], chunkStartPos)
-		if err != nil {
-			return matches, fmt.Errorf("failed to search chunk: %w", err)
+		// A single read returns whatever is available, so matches from a slow
+		// producer are reported without waiting for a full chunk
+		n, err := source.Read(chunk)
+		if err != nil && err != io.EOF {
+			return fmt.Errorf("failed to read stream: %w", err)
+		}
+		s.currentPos += int64(n)
+		window = append(window, chunk[:n]...)
+		atEOF := err == io.EOF
+
+		var consumed, found int
+		var searchErr error
+		if s.options.Multiline {
+			consumed, found, searchErr = s.streamMultiline(window, overlap, atEOF, state, fn)
+		} else {
+			consumed, found, searchErr = s.streamLines(window, atEOF, state, fn)
+		}
+		if n > 0 || atEOF {
+			s.updateProgress(found)
+		}
+		if searchErr != nil {
+			return searchErr
 		}
 
-		// Apply sophisticated duplicate filtering based on processed ranges
-		filteredMatches := s.filterDuplicateMatches(chunkMatches, chunkStartPos)
-		matches = append(matches, filteredMatches...)
+		// Shift the unconsumed tail to the front, reusing the window's memory
+		window = append(window[:0], window[consumed:]...)
 
-		// Update processed ranges
-		s.updateProcessedRanges(chunkStartPos, int64(actualSize))
+		if atEOF {
+			return nil
+		}
+	}
+}
 
-		// Update progress with enhanced tracking
-		s.updateProgress(len(filteredMatches))
+// streamLines matches every complete line of window, or every line at the end
+// of the stream, and returns the number of bytes consumed and matches reported
+func (s *SlidingWindowSearcher) streamLines(window []byte, atEOF bool, state *streamState, fn func(Match) error) (int, int, error) {
+	end := len(window)
+	if !atEOF {
+		end = bytes.LastIndexByte(window, '\n') + 1
+	}
 
-		chunkCount++
-		// Add a small delay every few chunks to allow context cancellation to work
-		if chunkCount%10 == 0 {
-			select {
-			case <-ctx.Done():
-				return matches, ctx.Err()
-			default:
+	found := 0
+	for start := 0; start < end; {
+		lineEnd := end
+		if idx := bytes.IndexByte(window[start:end], '\n'); idx != -1 {
+			lineEnd = start + idx
+		}
+		line := string(bytes.TrimSuffix(window[start:lineEnd], []byte{'\r'}))
+		start = lineEnd + 1
+
+		reported, err := s.streamLine(line, state, fn)
+		found += reported
+		if err != nil {
+			return end, found, err
+		}
+	}
+
+	// Matches at the end of the stream get whatever after context there is
+	if atEOF {
+		for _, match := range state.pending {
+			if err := fn(match); err != nil {
+				return end, found, err
 			}
 		}
+		state.pending = nil
 	}
 
-	return matches, nil
+	return end, found, nil
 }
 
-// readChunkWithEnhancedOverlap reads a chunk of data, handling overlap from the previous chunk
-func (s *SlidingWindowSearcher) readChunkWithEnhancedOverlap() ([]byte, int, error) {
-	// Calculate how much to read
-	remainingBytes := s.fileSize - s.currentPos
-	if remainingBytes <= 0 {
-		return nil, 0, io.EOF
+// streamLine matches a single line, attaching context to its matches and
+// reporting earlier matches whose after context is now complete
+func (s *SlidingWindowSearcher) streamLine(line string, state *streamState, fn func(Match) error) (int, error) {
+	lineNum := state.line
+	state.line++
+
+	if err := s.completePending(line, lineNum, state, fn); err != nil {
+		return 0, err
 	}
 
-	readSize := int64(len(s.buffer))
-	if remainingBytes < readSize {
-		readSize = remainingBytes
+	spans := s.matchLine(line)
+	allSpans := newSpans(spans)
+	for _, span := range spans {
+		match := Match{
+			File:       s.name,
+			Line:       lineNum,
+			Column:     span[0] + 1,
+			Content:    line,
+			MatchStart: span[0],
+			MatchEnd:   span[1],
+			Spans:      allSpans,
+		}
+		if len(state.before) > 0 {
+			match.BeforeContext = append([]string(nil), state.before...)
+		}
+
+		if s.afterContext > 0 {
+			state.pending = append(state.pending, match)
+		} else if err := fn(match); err != nil {
+			return len(spans), err
+		}
 	}
 
-	// For the first chunk, start from position 0
-	readPos := s.currentPos
-	if s.currentPos == 0 {
-		// First chunk - read directly
-		chunk := make([]byte, readSize)
-		n, err := s.file.ReadAt(chunk, readPos)
-		if err != nil && err != io.EOF {
-			return nil, 0, err
+	s.rememberLine(line, state)
+
+	return len(spans), nil
+}
+
+// completePending adds line, numbered lineNum, to the after context of the
+// pending matches it follows and reports those whose after context is now
+// complete. Matches end in order, so the completed ones come first.
+func (s *SlidingWindowSearcher) completePending(line string, lineNum int, state *streamState, fn func(Match) error) error {
+	ready := 0
+	for i := range state.pending {
+		match := &state.pending[i]
+		if lineNum <= max(match.Line, match.EndLine) {
+			break
+		}
+		match.AfterContext = append(match.AfterContext, line)
+		if len(match.AfterContext) == s.afterContext {
+			ready = i + 1
+		}
+	}
+	for _, match := range state.pending[:ready] {
+		if err := fn(match); err != nil {
+			return err
+		}
+	}
+	state.pending = state.pending[ready:]
+	return nil
+}
+
+// rememberLine keeps line as a possible before context line, dropping the
+// oldest once there are more than the before context needs
+func (s *SlidingWindowSearcher) rememberLine(line string, state *streamState) {
+	if s.beforeContext == 0 {
+		return
+	}
+	state.before = append(state.before, line)
+	if len(state.before) > s.beforeContext {
+		state.before = state.before[1:]
+	}
+}
+
+// linesBefore returns the before context of the line at index i of lines, the
+// lines of the window, drawing on lines consumed earlier when i is too small
+func (s *SlidingWindowSearcher) linesBefore(lines []string, i int, state *streamState) []string {
+	var before []string
+	if need := s.beforeContext - i; need > 0 {
+		before = append(before, state.before[max(len(state.before)-need, 0):]...)
+	}
+	return append(before, lines[max(i-s.beforeContext, 0):i]...)
+}
+
+// matchLine returns the spans matched in line, one for each occurrence of the
+// pattern. Inverted matches are reported as a single empty span at the start
+// of lines that do not match.
+func (s *SlidingWindowSearcher) matchLine(line string) [][]int {
+	if s.lineSpans != nil {
+		return s.lineSpans(line)
+	}
+
+	if s.options.InvertMatch {
+		if strings.Contains(line, s.pattern) {
+			return nil
 		}
+		return [][]int{{0, 0}}
+	}
 
-		// Save overlap for next iteration (if not at end of file)
-		if s.currentPos+int64(n) < s.fileSize && n > int(s.options.OverlapSize) {
-			overlapStart := n - int(s.options.OverlapSize)
-			copy(s.overlapBuffer, chunk[overlapStart:n])
+	var spans [][]int
+	for offset := 0; s.pattern != ""; {
+		idx := strings.Index(line[offset:], s.pattern)
+		if idx == -1 {
+			break
 		}
+		start := offset + idx
+		offset = start + len(s.pattern)
+		spans = append(spans, []int{start, offset})
+	}
+	return spans
+}
 
-		s.currentPos += int64(n)
-		return chunk, n, err
+// streamMultiline matches the regex against window, deferring matches that
+// start in the trailing overlap until more of the stream has been read. It
+// returns the number of bytes consumed and matches reported.
+func (s *SlidingWindowSearcher) streamMultiline(window []byte, overlap int, atEOF bool, state *streamState, fn func(Match) error) (int, int, error) {
+	commitLimit := len(window)
+	if !atEOF {
+		commitLimit -= overlap
+		if commitLimit < 0 {
+			commitLimit = 0
+		}
 	}
 
-	// Subsequent chunks - include overlap
-	totalSize := int64(len(s.overlapBuffer)) + readSize
-	chunk := make([]byte, totalSize)
+	var spans [][]int
+	consumed := commitLimit
+	for _, span := range s.multilineRegex.FindAllIndex(window, -1) {
+		if span[0] < state.skipUntil {
+			continue
+		}
+		if span[0] >= commitLimit {
+			break
+		}
+		spans = append(spans, span)
+		if span[1] > consumed {
+			consumed = span[1]
+		}
+	}
 
-	// Copy overlap from previous chunk
-	overlapSize := copy(chunk, s.overlapBuffer)
+	matches := multilineMatchesFromSpans(s.name, window, spans, state.line)
+	cut := bytes.LastIndexByte(window[:commitLimit], '\n') + 1
 
-	// Read new data
-	n, err := s.file.ReadAt(chunk[overlapSize:], readPos)
-	if err != nil && err != io.EOF {
-		return nil, 0, err
+	if !s.hasContext() {
+		for _, match := range matches {
+			if err := fn(match); err != nil {
+				return 0, len(matches), err
+			}
+		}
+	} else if err := s.multilineContext(window, cut, atEOF, matches, state, fn); err != nil {
+		return 0, len(matches), err
 	}
 
-	actualSize := overlapSize + n
+	// Keep the tail of the window, restarting at the beginning of a line
+	state.line += bytes.Count(window[:cut], []byte{'\n'})
+	state.skipUntil = consumed - cut
 
-	// Save overlap for next iteration (if not at end of file)
-	if s.currentPos+int64(n) < s.fileSize && n > int(s.options.OverlapSize) {
-		overlapStart := overlapSize + n - int(s.options.OverlapSize)
-		copy(s.overlapBuffer, chunk[overlapStart:actualSize])
+	return cut, len(matches), nil
+}
+
+// multilineContext attaches context to the matches found in window and
+// passes the lines consumed up to cut, plus the unterminated last line at the
+// end of the stream, through the pending matches. Every line before a match
+// ends before cut, so before context is always complete.
+func (s *SlidingWindowSearcher) multilineContext(window []byte, cut int, atEOF bool, matches []Match, state *streamState, fn func(Match) error) error {
+	lines := strings.Split(string(window[:cut]), "\n")
+	lines = lines[:len(lines)-1]
+	if atEOF && cut < len(window) {
+		lines = append(lines, string(window[cut:]))
 	}
 
-	s.currentPos += int64(n)
-	return chunk, actualSize, err
+	for _, match := range matches {
+		if s.beforeContext > 0 {
+			match.BeforeContext = s.linesBefore(lines, match.Line-state.line, state)
+		}
+		if s.afterContext == 0 {
+			if err := fn(match); err != nil {
+				return err
+			}
+			continue
+		}
+		state.pending = append(state.pending, match)
+	}
+
+	for i, line := range lines {
+		if err := s.completePending(line, state.line+i, state, fn); err != nil {
+			return err
+		}
+		s.rememberLine(line, state)
+	}
+
+	// Matches at the end of the stream get whatever after context there is
+	if atEOF {
+		for _, match := range state.pending {
+			if err := fn(match); err != nil {
+				return err
+			}
+		}
+		state.pending = nil
+	}
+	return nil
 }
 
-// searchChunk searches for patterns within a single chunk (simplified version)
-func (s *SlidingWindowSearcher) searchChunk(chunk []byte, baseOffset int64) ([]Match, error) {
+// matchKey identifies a match by its absolute byte offset in the file and
+// its length
+type matchKey struct {
+	offset int64
+	length int
+}
+
+// slidingWindowSearch reads the file in chunks and searches whole lines only.
+// Each window starts on a line boundary at least the overlap before the end
+// of the lines already searched, and a line cut by the end of a chunk waits
+// for the next one, so matches near a boundary are found twice. Every match
+// is recorded by its absolute byte offset and length and reported only the
+// first time, so each match is reported exactly once.
+func (s *SlidingWindowSearcher) slidingWindowSearch(ctx context.Context) ([]Match, error) {
 	var matches []Match
 
-	// Use a scanner to process line by line with a larger buffer
-	scanner := bufio.NewScanner(bytes.NewReader(chunk))
+	overlap := int(s.calculateOptimalOverlap())
+	seen := make(map[matchKey]struct{})
+	var window []byte
+	windowStart := int64(0) // File offset of window[0], always the start of a line
+	line := 1               // Line number of window[0]
+
+	defer s.reportFinalProgress()
+
+	for s.currentPos < s.fileSize {
+		select {
+		case <-ctx.Done():
+			return matches, ctx.Err()
+		default:
+		}
+
+		// The chunk size is looked up for every read so adaptive resizing
+		// takes effect as memory pressure changes
+		readSize := s.getOptimalChunkSize()
+		if remaining := s.fileSize - s.currentPos; remaining < readSize {
+			readSize = remaining
+		}
 
-	// Increase the scanner buffer size to handle long lines
-	buf := make([]byte, 0, 64*1024) // 64KB initial buffer
-	scanner.Buffer(buf, 1024*1024)  // 1MB max buffer
+		chunk := make([]byte, readSize)
+		n, err := s.readAt(chunk, s.currentPos)
+		if err != nil {
+			return matches, fmt.Errorf("failed to read chunk: %w", err)
+		}
+		if n == 0 {
+			break
+		}
+		s.currentPos += int64(n)
+		window = append(window, chunk[:n]...)
+
+		// Search up to the last complete line; at the end of the file the
+		// last line is complete whether or not it ends with a newline
+		end := len(window)
+		if s.currentPos < s.fileSize {
+			end = bytes.LastIndexByte(window, '\n') + 1
+			if end == 0 {
+				continue
+			}
+		}
 
-	lineNum := 1
-	lineOffset := int64(0)
+		found := s.searchWindowLines(window[:end], windowStart, line, seen, &matches)
+		s.updateProgress(found)
+		if s.reachedMaxCount(matches) {
+			break
+		}
 
-	for scanner.Scan() {
-		line := scanner.Text()
+		// Start the next window on the line holding the byte overlap bytes
+		// before end, so the lines after it are searched again
+		cut := 0
+		if keep := end - overlap; keep > 0 {
+			cut = bytes.LastIndexByte(window[:keep], '\n') + 1
+		}
+		line += bytes.Count(window[:cut], []byte{'\n'})
+		windowStart += int64(cut)
+		window = append(window[:0], window[cut:]...)
+
+		// Matches before the window cannot be found again
+		for key := range seen {
+			if key.offset < windowStart {
+				delete(seen, key)
+			}
+		}
+	}
 
-		// Simple string search for now (can be enhanced later)
-		if strings.Contains(line, s.pattern) {
-			match := Match{
-				File:    s.file.Name(),
-				Line:    lineNum,
-				Column:  1, // Simplified - would need proper column tracking
-				Content: line,
+	return matches, nil
+}
+
+// reportFinalProgress reports the whole file as processed
+func (s *SlidingWindowSearcher) reportFinalProgress() {
+	if s.options.ProgressCallback != nil {
+		s.options.ProgressCallback(s.fileSize, s.fileSize, 100.0)
+	}
+	if s.options.ProgressCallbackDetailed != nil {
+		finalInfo := s.GetProgressInfo()
+		finalInfo.Percentage = 100.0
+		finalInfo.BytesProcessed = s.fileSize
+		s.options.ProgressCallbackDetailed(finalInfo)
+	}
+}
+
+// chunkResult holds the matches of the lines starting in one chunk, with
+// line numbers counted from 1 at the first of them
+type chunkResult struct {
+	seq       int // Position of the chunk in the file
+	matches   []Match
+	lines     int   // Lines starting in the chunk
+	end       int64 // Offset just past the chunk, or the end of the file if it was truncated
+	truncated bool  // The file ended before the chunk did
+	err       error
+}
+
+// parallelSearch searches line mode chunks on Workers goroutines. Every
+// line belongs to the chunk it starts in and is read whole by that chunk's
+// worker, so no overlap or deduplication is needed. At most MemoryBudget
+// bytes of chunks are read ahead of the matches reported, and results are
+// reported in chunk order, which gives each chunk its first line number.
+func (s *SlidingWindowSearcher) parallelSearch(parent context.Context) ([]Match, error) {
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+	defer s.reportFinalProgress()
+
+	chunkSize := s.options.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = DefaultSlidingWindowOptions().ChunkSize
+	}
+	slots := s.options.Workers
+	if budget := s.options.MemoryBudget; budget > 0 {
+		slots = int(min(int64(slots), max(budget/chunkSize, 1)))
+	}
+
+	// A slot is taken for each chunk dispatched and given back once its
+	// result has been reported, bounding the chunks in memory
+	type chunkJob struct {
+		seq        int
+		start, end int64
+	}
+	jobs := make(chan chunkJob)
+	results := make(chan chunkResult, slots)
+	tokens := make(chan struct{}, slots)
+	size := s.fileSize
+
+	go func() {
+		defer close(jobs)
+		for seq, start := 0, int64(0); start < size; seq, start = seq+1, start+chunkSize {
+			select {
+			case tokens <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case jobs <- chunkJob{seq: seq, start: start, end: min(start+chunkSize, size)}:
+			case <-ctx.Done():
+				return
 			}
-			matches = append(matches, match)
 		}
+	}()
 
-		lineOffset += int64(len(scanner.Bytes())) + 1 // +1 for newline
-		lineNum++
+	var wg sync.WaitGroup
+	for range slots {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				result := s.searchChunk(ctx, job.start, job.end)
+				result.seq = job.seq
+				results <- result
+			}
+		}()
 	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	var matches []Match
+	var firstErr error
+	stopped := false
+	pending := make(map[int]chunkResult)
+	next, line := 0, 1
+	for result := range results {
+		pending[result.seq] = result
+		for {
+			r, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			next++
+			<-tokens
+			if firstErr != nil || stopped {
+				continue
+			}
+			if r.err != nil {
+				firstErr = r.err
+				cancel()
+				continue
+			}
 
-	if err := scanner.Err(); err != nil {
-		return matches, fmt.Errorf("error scanning chunk: %w", err)
+			for i := range r.matches {
+				r.matches[i].Line += line - 1
+			}
+			matches = append(matches, r.matches...)
+			line += r.lines
+			if r.truncated && r.end < s.fileSize {
+				s.fileSize = r.end
+			}
+			s.currentPos = min(r.end, s.fileSize)
+			s.updateProgress(len(r.matches))
+
+			// The chunks after have nothing more to add
+			if s.reachedMaxCount(matches) {
+				stopped = true
+				cancel()
+			}
+		}
 	}
 
-	return matches, nil
+	if firstErr == nil {
+		firstErr = parent.Err()
+	}
+	return matches, firstErr
 }
 
-// filterOverlapMatches removes matches that occur in the overlap region to avoid duplicates
-func (s *SlidingWindowSearcher) filterOverlapMatches(matches []Match, overlapStart int64) []Match {
-	// For now, we'll use a simple approach: only filter if we have a significant overlap
-	// In a more sophisticated implementation, we'd track byte offsets precisely
+// searchChunk matches the lines starting between start and end in the file,
+// reading past end to the end of the last of them
+func (s *SlidingWindowSearcher) searchChunk(ctx context.Context, start, end int64) chunkResult {
+	if err := ctx.Err(); err != nil {
+		return chunkResult{err: err}
+	}
 
-	// If overlap is small relative to chunk size, don't filter to avoid losing matches
-	if s.options.OverlapSize < s.options.ChunkSize/10 {
-		return matches
+	// The byte before the chunk tells whether a line starts at start
+	readStart := max(start-1, 0)
+	data := make([]byte, end-readStart)
+	n, err := s.readFileAt(data, readStart)
+	if err != nil && err != io.EOF {
+		return chunkResult{err: fmt.Errorf("failed to read chunk: %w", err)}
+	}
+	data = data[:n]
+	result := chunkResult{end: readStart + int64(n), truncated: readStart+int64(n) < end}
+	if n == 0 {
+		return result
 	}
 
-	// For line-based filtering, we'll be conservative and only filter the first few lines
-	// that are likely to be in the overlap region
-	var filtered []Match
-	overlapLines := int(s.options.OverlapSize / 50) // Estimate ~50 bytes per line
-	if overlapLines < 1 {
-		overlapLines = 1
+	// A line starts at the start of the file and after every newline
+	// before the last byte
+	result.lines = bytes.Count(data[:n-1], []byte{'\n'})
+	first := 0
+	if start == 0 {
+		result.lines++
+	} else {
+		first = bytes.IndexByte(data, '\n') + 1
+		if first == 0 || result.lines == 0 {
+			return result
+		}
 	}
 
-	for _, match := range matches {
-		// Keep matches that are beyond the estimated overlap region
-		if match.Line > overlapLines {
-			filtered = append(filtered, match)
+	// Finish the last line, which can run on past the chunk
+	for !result.truncated && data[len(data)-1] != '\n' {
+		more := make([]byte, s.calculateOptimalOverlap())
+		off := readStart + int64(len(data))
+		n, err := s.readFileAt(more, off)
+		if err != nil && err != io.EOF {
+			return chunkResult{err: fmt.Errorf("failed to read chunk: %w", err)}
+		}
+		if idx := bytes.IndexByte(more[:n], '\n'); idx != -1 {
+			n = idx + 1
+		}
+		data = append(data, more[:n]...)
+		if n == 0 || err == io.EOF {
+			break
 		}
 	}
 
-	// If we filtered too aggressively and have no matches, return all matches
-	if len(filtered) == 0 && len(matches) > 0 {
-		return matches
+	s.searchWindowLines(data[first:], readStart+int64(first), 1, nil, &result.matches)
+	return result
+}
+
+// searchWindowLines matches every line of data, which starts at offset in
+// the file on line firstLine, adding the matches not in seen to matches. A
+// nil seen adds every match. It returns the number of matches added.
+func (s *SlidingWindowSearcher) searchWindowLines(data []byte, offset int64, firstLine int, seen map[matchKey]struct{}, matches *[]Match) int {
+	found := 0
+	lineNum := firstLine
+	for start := 0; start < len(data); lineNum++ {
+		lineEnd := len(data)
+		if idx := bytes.IndexByte(data[start:], '\n'); idx != -1 {
+			lineEnd = start + idx
+		}
+		line := string(bytes.TrimSuffix(data[start:lineEnd], []byte{'\r'}))
+		lineStart := offset + int64(start)
+		start = lineEnd + 1
+
+		spans := s.matchLine(line)
+		allSpans := newSpans(spans)
+		for _, span := range spans {
+			if seen != nil {
+				key := matchKey{offset: lineStart + int64(span[0]), length: span[1] - span[0]}
+				if _, dup := seen[key]; dup {
+					continue
+				}
+				seen[key] = struct{}{}
+			}
+
+			*matches = append(*matches, Match{
+				File:       s.name,
+				Line:       lineNum,
+				Column:     span[0] + 1,
+				Content:    line,
+				MatchStart: span[0],
+				MatchEnd:   span[1],
+				Spans:      allSpans,
+			})
+			found++
+		}
 	}
+	return found
+}
 
-	return filtered
+// readAt reads from the file at off. A short read means the file was
+// truncated after it was opened, so the search ends at the new end of the
+// file instead of failing or waiting for bytes that no longer exist.
+func (s *SlidingWindowSearcher) readAt(p []byte, off int64) (int, error) {
+	n, err := s.readFileAt(p, off)
+	if err == io.EOF {
+		err = nil
+	}
+	if err == nil && n < len(p) && off+int64(n) < s.fileSize {
+		s.fileSize = off + int64(n)
+	}
+	return n, err
+}
+
+// readFileAt reads from the file at off through the mapping, if there is
+// one, falling back to reading the file when the mapping faults because the
+// file was truncated. It is safe for concurrent use.
+func (s *SlidingWindowSearcher) readFileAt(p []byte, off int64) (int, error) {
+	if s.mapping != nil {
+		n, err := s.mapping.ReadAt(p, off)
+		if err != errMapTruncated {
+			return n, err
+		}
+	}
+	return s.file.ReadAt(p, off)
+}
+
+// readerAtFunc adapts a function to io.ReaderAt
+type readerAtFunc func(p []byte, off int64) (int, error)
+
+// ReadAt calls f
+func (f readerAtFunc) ReadAt(p []byte, off int64) (int, error) {
+	return f(p, off)
 }
 
 // getOptimalChunkSize determines the optimal chunk size based on available memory and configuration
@@ -384,15 +1035,23 @@ func (s *SlidingWindowSearcher) GetMemoryUsage() (allocated, total uint64) {
 
 // GetProgress returns the current search progress
 func (s *SlidingWindowSearcher) GetProgress() (bytesProcessed, totalBytes int64, percentage float64) {
-	percentage = float64(s.currentPos) / float64(s.fileSize) * 100
-	return s.currentPos, s.fileSize, percentage
+	return s.currentPos, s.fileSize, s.percentage()
+}
+
+// percentage returns how much of the file has been processed, which is
+// always 0 for streams of unknown size
+func (s *SlidingWindowSearcher) percentage() float64 {
+	if s.fileSize <= 0 {
+		return 0
+	}
+	return float64(s.currentPos) / float64(s.fileSize) * 100
 }
 
 // GetProgressInfo returns comprehensive progress information including ETA
 func (s *SlidingWindowSearcher) GetProgressInfo() ProgressInfo {
 	elapsed := time.Since(s.startTime)
 	bytesProcessed := s.currentPos
-	percentage := float64(bytesProcessed) / float64(s.fileSize) * 100
+	percentage := s.percentage()
 
 	// Calculate processing rate (bytes per second)
 	var processingRate float64
@@ -402,7 +1061,7 @@ func (s *SlidingWindowSearcher) GetProgressInfo() ProgressInfo {
 
 	// Calculate estimated time remaining
 	var estimatedTimeLeft time.Duration
-	if processingRate > 0 && bytesProcessed > 0 {
+	if processingRate > 0 && bytesProcessed > 0 && s.fileSize >= 0 {
 		remainingBytes := s.fileSize - bytesProcessed
 		estimatedSeconds := float64(remainingBytes) / processingRate
 		estimatedTimeLeft = time.Duration(estimatedSeconds) * time.Second
@@ -427,9 +1086,7 @@ func (s *SlidingWindowSearcher) updateProgress(chunkMatches int) {
 
 	// Update progress callback (basic)
 	if s.options.ProgressCallback != nil {
-		bytesProcessed := s.currentPos
-		percentage := float64(bytesProcessed) / float64(s.fileSize) * 100
-		s.options.ProgressCallback(bytesProcessed, s.fileSize, percentage)
+		s.options.ProgressCallback(s.currentPos, s.fileSize, s.percentage())
 	}
 
 	// Update detailed progress callback
@@ -443,8 +1100,12 @@ func (s *SlidingWindowSearcher) updateProgress(chunkMatches int) {
 
 // calculateOptimalOverlap calculates the optimal overlap size based on the pattern length
 func (s *SlidingWindowSearcher) calculateOptimalOverlap() int64 {
-	// Ensure overlap is at least as large as the maximum pattern length
+	// The overlap must hold the longest possible match. Bounded patterns are
+	// measured directly; MaxPatternLength covers unbounded ones and is a floor.
 	minOverlap := int64(s.options.MaxPatternLength)
+	if s.maxMatchLength > s.options.MaxPatternLength {
+		minOverlap = int64(s.maxMatchLength)
+	}
 
 	// Use the larger of configured overlap or pattern-based overlap
 	if s.options.OverlapSize > minOverlap {
@@ -454,196 +1115,3 @@ func (s *SlidingWindowSearcher) calculateOptimalOverlap() int64 {
 	// Add some buffer for multi-line patterns
 	return minOverlap + 1024 // Add 1KB buffer for line boundaries
 }
-
-// searchChunkWithBoundaryTracking searches for patterns within a single chunk with boundary tracking
-func (s *SlidingWindowSearcher) searchChunkWithBoundaryTracking(chunk []byte, baseOffset int64) ([]Match, error) {
-	var matches []Match
-
-	// First, perform line-by-line search for most patterns
-	lineMatches, err := s.searchChunkByLines(chunk, baseOffset)
-	if err != nil {
-		return nil, err
-	}
-	matches = append(matches, lineMatches...)
-
-	// Then, perform boundary-aware search for patterns that might span lines/chunks
-	boundaryMatches, err := s.searchChunkBoundaries(chunk, baseOffset)
-	if err != nil {
-		return nil, err
-	}
-	matches = append(matches, boundaryMatches...)
-
-	return matches, nil
-}
-
-// searchChunkByLines performs line-by-line search within a chunk
-func (s *SlidingWindowSearcher) searchChunkByLines(chunk []byte, baseOffset int64) ([]Match, error) {
-	var matches []Match
-
-	// Use a scanner to process line by line with a larger buffer
-	scanner := bufio.NewScanner(bytes.NewReader(chunk))
-
-	// Increase the scanner buffer size to handle long lines
-	buf := make([]byte, 0, 64*1024) // 64KB initial buffer
-	scanner.Buffer(buf, 1024*1024)  // 1MB max buffer
-
-	// Calculate starting line number based on byte offset
-	// For simplicity, we'll estimate based on the base offset
-	// In a real implementation, we'd want to track this more precisely
-	var startingLineNum int
-	if baseOffset == 0 {
-		startingLineNum = 1
-	} else {
-		// Estimate line number by counting newlines in the file up to this point
-		// For this test scenario, we'll use a more accurate heuristic
-		// Assuming average line length of 19 bytes for "adaptive test line\n" (18 chars + 1 newline)
-		startingLineNum = int(baseOffset/19) + 1
-
-		// Adjust for potential overlap - be more conservative about line numbering
-		if startingLineNum > 1 {
-			startingLineNum -= 1 // Reduce by 1 to account for potential partial overlap
-		}
-	}
-
-	lineNum := startingLineNum
-	lineOffset := int64(0)
-
-	for scanner.Scan() {
-		line := scanner.Text()
-		lineBytes := scanner.Bytes()
-
-		// Search for pattern in this line (simplified)
-		if strings.Contains(line, s.pattern) {
-			match := Match{
-				File:    s.file.Name(),
-				Line:    lineNum,
-				Column:  strings.Index(line, s.pattern) + 1, // 1-indexed
-				Content: line,
-			}
-			matches = append(matches, match)
-		}
-
-		lineOffset += int64(len(lineBytes)) + 1 // +1 for newline
-		lineNum++
-	}
-
-	if err := scanner.Err(); err != nil {
-		return matches, fmt.Errorf("error scanning chunk: %w", err)
-	}
-
-	return matches, nil
-}
-
-// searchChunkBoundaries searches for patterns that might span chunk boundaries
-func (s *SlidingWindowSearcher) searchChunkBoundaries(chunk []byte, baseOffset int64) ([]Match, error) {
-	var matches []Match
-
-	// Only search boundaries if this is not the first chunk
-	if baseOffset == 0 {
-		return matches, nil
-	}
-
-	// Search in the overlap region for patterns that might span boundaries
-	overlapSize := len(s.overlapBuffer)
-	if overlapSize > 0 && len(chunk) > overlapSize {
-		// Create a boundary search region that includes the overlap
-		// Ensure we don't exceed the chunk boundaries
-		boundaryEnd := overlapSize * 2
-		if boundaryEnd > len(chunk) {
-			boundaryEnd = len(chunk)
-		}
-		boundaryRegion := chunk[:boundaryEnd] // Search in first part of chunk
-
-		// Perform byte-level search in the boundary region (simplified)
-		boundaryString := string(boundaryRegion)
-		if strings.Contains(boundaryString, s.pattern) {
-			matchStart := strings.Index(boundaryString, s.pattern)
-			matchEnd := matchStart + len(s.pattern)
-
-			if matchStart >= 0 && matchEnd <= len(boundaryString) {
-				match := Match{
-					File:    s.file.Name(),
-					Line:    1,              // Simplified - would need proper line tracking
-					Column:  matchStart + 1, // 1-indexed
-					Content: boundaryString[matchStart:matchEnd],
-				}
-				matches = append(matches, match)
-			}
-		}
-	}
-
-	return matches, nil
-}
-
-// filterDuplicateMatches applies accurate duplicate filtering based on content and position
-func (s *SlidingWindowSearcher) filterDuplicateMatches(matches []Match, chunkStartPos int64) []Match {
-	// For the first chunk, include all matches
-	if chunkStartPos == 0 {
-		return matches
-	}
-
-	// For subsequent chunks, we need to filter out duplicates that likely came from the overlap region
-	// We'll use a conservative approach: only filter matches that are very likely to be from overlap
-
-	var filtered []Match
-	overlapLines := s.calculateOverlapLines()
-
-	// Simple but effective approach: only filter the first few lines that are most likely to be overlap
-	// This is conservative to avoid over-filtering
-	for _, match := range matches {
-		// For small line numbers that are likely in the overlap region, be more careful
-		if match.Line <= overlapLines {
-			// For this specific test case, we know that each line has exactly one occurrence
-			// So if we see the same line number in multiple chunks, it's likely a duplicate
-			// Since we're processing chunks sequentially, we can be more aggressive about filtering early lines
-
-			// Skip matches in the first few lines of non-first chunks (likely overlap)
-			// But be conservative - only filter if we're confident it's overlap
-			if match.Line <= 2 && overlapLines > 0 {
-				continue // Skip this match as it's likely a duplicate from overlap
-			}
-		}
-
-		// Include all other matches
-		filtered = append(filtered, match)
-	}
-
-	return filtered
-}
-
-// calculateOverlapLines estimates the number of lines in the overlap region
-func (s *SlidingWindowSearcher) calculateOverlapLines() int {
-	// Estimate based on average line length
-	avgLineLength := int64(80) // Assume 80 characters per line on average
-	overlapLines := int(s.options.OverlapSize / avgLineLength)
-	if overlapLines < 1 {
-		overlapLines = 1
-	}
-	return overlapLines
-}
-
-// isMatchInProcessedRange checks if a match has already been processed in a previous chunk
-func (s *SlidingWindowSearcher) isMatchInProcessedRange(match Match, chunkStartPos int64) bool {
-	// This is a simplified implementation
-	// In practice, we'd need to track exact byte positions of matches
-	// For now, we'll use a conservative approach
-
-	// If the match is in the first few lines of the chunk and we have processed ranges,
-	// it might be a duplicate
-	if match.Line <= s.calculateOverlapLines() && len(s.processedRanges) > 0 {
-		// Check if this position might have been covered by previous chunks
-		for _, processedRange := range s.processedRanges {
-			if chunkStartPos <= processedRange.End {
-				return true // Likely a duplicate
-			}
-		}
-	}
-
-	return false
-}
-
-// updateProcessedRanges updates processed ranges based on the current chunk
-func (s *SlidingWindowSearcher) updateProcessedRanges(chunkStartPos, actualSize int64) {
-	// For now, we'll use a simple approach: mark the entire chunk as processed
-	s.processedRanges = append(s.processedRanges, ProcessedRange{Start: chunkStartPos, End: chunkStartPos + actualSize - 1})
-}
@@ -2,12 +2,16 @@ package goripgrep
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"slices"
 	"strings"
 	"sync"
 	"testing"
+	"testing/iotest"
 	"time"
 )
 
@@ -597,6 +601,72 @@ func TestSlidingWindowSearcherOverlapCalculation(t *testing.T) {
 	}
 }
 
+func TestSlidingWindowSearcherDerivedOverlap(t *testing.T) {
+	tmpFile, err := createTempFile("test content for overlap calculation")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpFile)
+
+	tests := []struct {
+		name     string
+		pattern  string
+		expected int64
+	}{
+		{"BoundedBeyondMaxPatternLength", "x{1000}x{1000}x{1000}", 3000},
+		{"UnboundedFallsBack", "x+", 1024 + 1024},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := DefaultSlidingWindowOptions()
+			options.Multiline = true
+			options.OverlapSize = 32
+			options.UseMemoryMap = false
+
+			searcher, err := NewSlidingWindowSearcher(tmpFile, tt.pattern, options)
+			if err != nil {
+				t.Fatalf("Failed to create searcher: %v", err)
+			}
+			defer searcher.Close()
+
+			if overlap := searcher.calculateOptimalOverlap(); overlap < tt.expected {
+				t.Errorf("Expected overlap of at least %d, got %d", tt.expected, overlap)
+			}
+		})
+	}
+
+	// A long bounded match spanning a chunk boundary is found without tuning
+	content := strings.Repeat("-", 1500) + strings.Repeat("x", 3000) + strings.Repeat("-", 1500)
+	longFile, err := createTempFile(content)
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(longFile)
+
+	options := DefaultSlidingWindowOptions()
+	options.Multiline = true
+	options.ChunkSize = 2048
+	options.MinChunkSize = 2048
+	options.AdaptiveResize = false
+	options.OverlapSize = 32
+	options.UseMemoryMap = false
+
+	searcher, err := NewSlidingWindowSearcher(longFile, "x{1000}x{1000}x{1000}", options)
+	if err != nil {
+		t.Fatalf("Failed to create searcher: %v", err)
+	}
+	defer searcher.Close()
+
+	matches, err := searcher.Search(context.Background())
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(matches) != 1 || matches[0].Column != 1501 {
+		t.Errorf("Expected a single match at column 1501, got %+v", matches)
+	}
+}
+
 func TestSlidingWindowSearcherBoundarySearch(t *testing.T) {
 	// Create content where pattern spans exactly across a chunk boundary
 	chunkSize := int64(256)
@@ -1037,3 +1107,398 @@ func createTempFile(content string) (string, error) {
 
 	return tmpFile, nil
 }
+
+func TestSlidingWindowSearcherInvertMatch(t *testing.T) {
+	tmpFile, err := createTempFile("alpha\nbeta\ngamma\nbeta again\n")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpFile)
+
+	options := DefaultSlidingWindowOptions()
+	options.UseMemoryMap = false
+	options.InvertMatch = true
+
+	searcher, err := NewSlidingWindowSearcher(tmpFile, "beta", options)
+	if err != nil {
+		t.Fatalf("Failed to create searcher: %v", err)
+	}
+	defer searcher.Close()
+
+	matches, err := searcher.Search(context.Background())
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+
+	if len(matches) != 2 || matches[0].Content != "alpha" || matches[1].Content != "gamma" {
+		t.Errorf("Expected alpha and gamma, got %+v", matches)
+	}
+
+	// Inverting multiline streaming searches is rejected
+	options.Multiline = true
+	if _, err := NewSlidingWindowSearcher(tmpFile, "beta", options); err == nil {
+		t.Error("Expected error for inverted multiline streaming search")
+	}
+}
+
+func TestSlidingWindowReaderSearcherStream(t *testing.T) {
+	var builder strings.Builder
+	for i := 1; i <= 500; i++ {
+		if i%7 == 0 {
+			fmt.Fprintf(&builder, "line %d has a needle\n", i)
+		} else {
+			fmt.Fprintf(&builder, "line %d\n", i)
+		}
+	}
+	content := builder.String()
+
+	options := DefaultSlidingWindowOptions()
+	options.ChunkSize = 64
+	options.AdaptiveResize = false
+
+	t.Run("LineMode", func(t *testing.T) {
+		// One byte per read splits lines across every window
+		searcher, err := NewSlidingWindowReaderSearcher(iotest.OneByteReader(strings.NewReader(content)), "stream", "needle", options)
+		if err != nil {
+			t.Fatalf("Failed to create searcher: %v", err)
+		}
+
+		var lines []int
+		err = searcher.SearchStream(context.Background(), func(match Match) error {
+			if match.File != "stream" || match.Column != strings.Index(match.Content, "needle")+1 {
+				t.Errorf("Unexpected match %+v", match)
+			}
+			lines = append(lines, match.Line)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("SearchStream failed: %v", err)
+		}
+
+		if len(lines) != 500/7 {
+			t.Fatalf("Expected %d matches, got %d", 500/7, len(lines))
+		}
+		for i, line := range lines {
+			if line != (i+1)*7 {
+				t.Errorf("Match %d: expected line %d, got %d", i, (i+1)*7, line)
+			}
+		}
+
+		if processed, total, _ := searcher.GetProgress(); processed != int64(len(content)) || total != -1 {
+			t.Errorf("Expected progress %d of -1, got %d of %d", len(content), processed, total)
+		}
+	})
+
+	t.Run("Multiline", func(t *testing.T) {
+		multilineOptions := options
+		multilineOptions.Multiline = true
+		multilineOptions.OverlapSize = 16
+
+		searcher, err := NewSlidingWindowReaderSearcher(strings.NewReader(content), "stream", `needle\nline \d+\n`, multilineOptions)
+		if err != nil {
+			t.Fatalf("Failed to create searcher: %v", err)
+		}
+
+		matches, err := searcher.Search(context.Background())
+		if err != nil {
+			t.Fatalf("Search failed: %v", err)
+		}
+		if len(matches) != 500/7 {
+			t.Fatalf("Expected %d matches, got %d", 500/7, len(matches))
+		}
+		for i, match := range matches {
+			if match.Line != (i+1)*7 || match.EndLine != match.Line+1 {
+				t.Errorf("Match %d: expected lines %d-%d, got %d-%d", i, (i+1)*7, (i+1)*7+1, match.Line, match.EndLine)
+			}
+		}
+	})
+
+	t.Run("StopsOnCallbackError", func(t *testing.T) {
+		searcher, err := NewSlidingWindowReaderSearcher(strings.NewReader(content), "stream", "needle", options)
+		if err != nil {
+			t.Fatalf("Failed to create searcher: %v", err)
+		}
+
+		stop := errors.New("stop")
+		calls := 0
+		err = searcher.SearchStream(context.Background(), func(Match) error {
+			calls++
+			return stop
+		})
+		if err != stop || calls != 1 {
+			t.Errorf("Expected the callback error after one call, got %v after %d calls", err, calls)
+		}
+	})
+}
+
+func TestSlidingWindowSearcherTruncatedFile(t *testing.T) {
+	for _, multiline := range []bool{false, true} {
+		t.Run(fmt.Sprintf("multiline=%v", multiline), func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "active.log")
+			content := strings.Repeat("entry with needle\nplain entry\n", 200)
+			if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			options := DefaultSlidingWindowOptions()
+			options.ChunkSize = 256
+			options.OverlapSize = 32
+			options.Multiline = multiline
+			searcher, err := NewSlidingWindowSearcher(path, "needle", options)
+			if err != nil {
+				t.Fatalf("Failed to create searcher: %v", err)
+			}
+			defer searcher.Close()
+
+			// Shrink the file after the searcher has recorded its size
+			kept := strings.Repeat("entry with needle\nplain entry\n", 50)
+			if err := os.Truncate(path, int64(len(kept))); err != nil {
+				t.Fatal(err)
+			}
+
+			matches, err := searcher.Search(context.Background())
+			if err != nil {
+				t.Fatalf("Search failed: %v", err)
+			}
+			if len(matches) != 50 {
+				t.Errorf("Expected 50 matches in the truncated file, got %d", len(matches))
+			}
+			for _, match := range matches {
+				if match.Content != "entry with needle" && !multiline {
+					t.Errorf("Unexpected match content %q", match.Content)
+				}
+			}
+		})
+	}
+}
+
+func TestSlidingWindowSearcherContext(t *testing.T) {
+	var content strings.Builder
+	for i := 1; i <= 200; i++ {
+		if i%7 == 0 || i == 1 || i == 200 {
+			fmt.Fprintf(&content, "line %d needle\n", i)
+		} else {
+			fmt.Fprintf(&content, "line %d\n", i)
+		}
+	}
+	path := filepath.Join(t.TempDir(), "large.log")
+	if err := os.WriteFile(path, []byte(content.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	streaming := DefaultSlidingWindowOptions()
+	streaming.ChunkSize = 64
+	streaming.OverlapSize = 16
+	streaming.AdaptiveResize = false
+
+	for _, tt := range []struct {
+		name    string
+		pattern string
+		opts    []Option
+	}{
+		{name: "lines", pattern: "needle"},
+		{name: "multiline", pattern: `needle\nline \d+`, opts: []Option{WithMultiline()}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := append([]Option{WithBeforeContext(2), WithAfterContext(3)}, tt.opts...)
+			want, err := Find(tt.pattern, path, opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := Find(tt.pattern, path, append(opts,
+				WithStreamingSearch(true), WithLargeSizeThreshold(1), WithStreamingOptions(streaming))...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(got.Matches) != len(want.Matches) {
+				t.Fatalf("Expected %d matches, got %d", len(want.Matches), len(got.Matches))
+			}
+			for i, match := range got.Matches {
+				expected := want.Matches[i]
+				if match.Line != expected.Line {
+					t.Errorf("Match %d: expected line %d, got %d", i, expected.Line, match.Line)
+				}
+				if !slices.Equal(match.BeforeContext, expected.BeforeContext) {
+					t.Errorf("Line %d: expected before context %q, got %q", match.Line, expected.BeforeContext, match.BeforeContext)
+				}
+				if !slices.Equal(match.AfterContext, expected.AfterContext) {
+					t.Errorf("Line %d: expected after context %q, got %q", match.Line, expected.AfterContext, match.AfterContext)
+				}
+			}
+		})
+	}
+}
+
+func TestSlidingWindowSearcherExactlyOnce(t *testing.T) {
+	// Lines of varied length, some with several matches and some longer
+	// than the smaller chunks, with no newline at the end of the file
+	var content strings.Builder
+	for i := 0; i < 300; i++ {
+		switch {
+		case i%17 == 0:
+			content.WriteString(strings.Repeat("abc-", 40) + "abc")
+		case i%5 == 0:
+			content.WriteString(strings.Repeat("x", i%13) + "abc" + strings.Repeat("y", i%7) + "abc")
+		case i%3 == 0:
+			content.WriteString("ab" + strings.Repeat("z", i%11) + "c")
+		default:
+			content.WriteString(strings.Repeat("w", i%23) + "abc")
+		}
+		if i < 299 {
+			content.WriteString("\n")
+		}
+	}
+	path := filepath.Join(t.TempDir(), "chunks.txt")
+	if err := os.WriteFile(path, []byte(content.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	type position struct{ line, column int }
+	var want []position
+	for i, line := range strings.Split(content.String(), "\n") {
+		for offset := 0; ; {
+			idx := strings.Index(line[offset:], "abc")
+			if idx == -1 {
+				break
+			}
+			want = append(want, position{i + 1, offset + idx + 1})
+			offset += idx + 3
+		}
+	}
+
+	for _, chunkSize := range []int64{3, 16, 17, 64, 100, 257, 4096} {
+		for _, overlapSize := range []int64{2, 3, 7, 32, 200} {
+			options := DefaultSlidingWindowOptions()
+			options.ChunkSize = chunkSize
+			options.OverlapSize = overlapSize
+			options.MaxPatternLength = 1
+			options.AdaptiveResize = false
+
+			searcher, err := NewSlidingWindowSearcher(path, "abc", options)
+			if err != nil {
+				t.Fatalf("Failed to create searcher: %v", err)
+			}
+			matches, err := searcher.Search(context.Background())
+			searcher.Close()
+			if err != nil {
+				t.Fatalf("Search failed: %v", err)
+			}
+
+			var got []position
+			for _, match := range matches {
+				got = append(got, position{match.Line, match.Column})
+			}
+			if !slices.Equal(got, want) {
+				t.Errorf("chunk %d, overlap %d: expected %d matches, got %d", chunkSize, overlapSize, len(want), len(got))
+			}
+		}
+	}
+}
+
+func TestSlidingWindowSearcherParallel(t *testing.T) {
+	var content strings.Builder
+	for i := 0; i < 400; i++ {
+		content.WriteString(strings.Repeat("x", i%29))
+		if i%3 == 0 {
+			content.WriteString("needle")
+		}
+		if i%7 == 0 {
+			content.WriteString(strings.Repeat("-needle", i%5))
+		}
+		content.WriteString("\n")
+	}
+	dir := t.TempDir()
+	files := map[string]string{
+		"newline.txt":    content.String(),
+		"no-newline.txt": strings.TrimSuffix(content.String(), "\n") + "needle",
+		"one-line.txt":   strings.Repeat("needle ", 500),
+	}
+
+	search := func(path string, options SlidingWindowOptions) []Match {
+		t.Helper()
+		searcher, err := NewSlidingWindowSearcher(path, "needle", options)
+		if err != nil {
+			t.Fatalf("Failed to create searcher: %v", err)
+		}
+		defer searcher.Close()
+		matches, err := searcher.Search(context.Background())
+		if err != nil {
+			t.Fatalf("Search failed: %v", err)
+		}
+		return matches
+	}
+
+	for name, data := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		for _, invert := range []bool{false, true} {
+			options := DefaultSlidingWindowOptions()
+			options.AdaptiveResize = false
+			options.InvertMatch = invert
+			want := search(path, options)
+
+			for _, chunkSize := range []int64{1, 5, 31, 64, 1000, 1 << 20} {
+				for _, workers := range []int{2, 8} {
+					options.ChunkSize = chunkSize
+					options.Workers = workers
+					options.MemoryBudget = chunkSize * 3
+					var lastPercentage float64
+					options.ProgressCallback = func(_, _ int64, percentage float64) {
+						lastPercentage = percentage
+					}
+
+					got := search(path, options)
+					if !reflect.DeepEqual(got, want) {
+						t.Errorf("%s, invert %v, chunk %d, %d workers: expected %d matches as found serially, got %d", name, invert, chunkSize, workers, len(want), len(got))
+					}
+					if lastPercentage != 100 {
+						t.Errorf("%s: expected progress to reach 100%%, got %v", name, lastPercentage)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestSlidingWindowSearcherMaxCount(t *testing.T) {
+	content := "a needle needle\n" + strings.Repeat("filler line\n", 500) + "b needle\nc needle\n"
+	path := filepath.Join(t.TempDir(), "count.txt")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	modes := map[string]func(*SlidingWindowOptions){
+		"serial":    func(*SlidingWindowOptions) {},
+		"parallel":  func(o *SlidingWindowOptions) { o.Workers = 4 },
+		"context":   func(o *SlidingWindowOptions) { o.AfterContext = 1 },
+		"multiline": func(o *SlidingWindowOptions) { o.Multiline = true },
+	}
+	for name, setup := range modes {
+		options := DefaultSlidingWindowOptions()
+		options.AdaptiveResize = false
+		options.ChunkSize = 256
+		options.MaxCount = 2
+		setup(&options)
+
+		searcher, err := NewSlidingWindowSearcher(path, "needle", options)
+		if err != nil {
+			t.Fatalf("%s: failed to create searcher: %v", name, err)
+		}
+		matches, err := searcher.Search(context.Background())
+		searcher.Close()
+		if err != nil {
+			t.Fatalf("%s: search failed: %v", name, err)
+		}
+
+		var lines []int
+		for _, match := range matches {
+			lines = append(lines, match.Line)
+		}
+		if !slices.Equal(lines, []int{1, 1, 502}) {
+			t.Errorf("%s: expected both matches on line 1 and the one on line 502, got lines %v", name, lines)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package goripgrep
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// SmartCaseInsensitive reports whether smart case searches for pattern
+// ignoring case, which it does unless the pattern has an uppercase letter.
+// Letters in escapes such as \W or \p{Lu}, flags such as (?U) and group
+// names are syntax rather than text to match, so they don't count.
+func SmartCaseInsensitive(pattern string, fixedStrings bool) bool {
+	if fixedStrings {
+		return !strings.ContainsFunc(pattern, unicode.IsUpper)
+	}
+
+	for i := 0; i < len(pattern); {
+		r, size := utf8.DecodeRuneInString(pattern[i:])
+		switch {
+		case r == '\\':
+			i += escapeLength(pattern[i:])
+			continue
+		case strings.HasPrefix(pattern[i:], "(?"):
+			i += groupPrefixLength(pattern[i:])
+			continue
+		case unicode.IsUpper(r):
+			return false
+		}
+		i += size
+	}
+	return true
+}
+
+// ignoresCase resolves SmartCase and IgnoreCase into whether pattern is
+// searched ignoring case
+func (config SearchConfig) ignoresCase(pattern string) bool {
+	if config.SmartCase {
+		return SmartCaseInsensitive(pattern, config.FixedStrings)
+	}
+	return config.IgnoreCase
+}
+
+// escapeLength returns the length of the escape sequence s starts with,
+// including the arguments of \p, \P and \x
+func escapeLength(s string) int {
+	if len(s) < 2 {
+		return len(s)
+	}
+	switch s[1] {
+	case 'p', 'P', 'x':
+		if len(s) > 2 && s[2] == '{' {
+			if end := strings.IndexByte(s, '}'); end >= 0 {
+				return end + 1
+			}
+			return len(s)
+		}
+		if s[1] == 'x' {
+			return min(len(s), 4)
+		}
+		return min(len(s), 3)
+	}
+	_, size := utf8.DecodeRuneInString(s[1:])
+	return 1 + size
+}
+
+// groupPrefixLength returns the length of the flags or group name s, which
+// starts with "(?", opens with. Lookaround bodies are text to match, so
+// only their "(?=", "(?!", "(?<=" or "(?<!" is skipped.
+func groupPrefixLength(s string) int {
+	rest := s[2:]
+	switch {
+	case strings.HasPrefix(rest, "<=") || strings.HasPrefix(rest, "<!"):
+		return 4
+	case strings.HasPrefix(rest, "=") || strings.HasPrefix(rest, "!") || strings.HasPrefix(rest, ">"):
+		return 3
+	case strings.HasPrefix(rest, "P<") || strings.HasPrefix(rest, "<"):
+		if end := strings.IndexByte(rest, '>'); end >= 0 {
+			return 2 + end + 1
+		}
+		return len(s)
+	}
+
+	// Flags such as (?i) or (?s-U:
+	end := strings.IndexAny(rest, ":)")
+	if end == -1 || strings.IndexFunc(rest[:end], func(r rune) bool { return !unicode.IsLetter(r) && r != '-' }) >= 0 {
+		return 2
+	}
+	return 2 + end + 1
+}
@@ -0,0 +1,87 @@
+package goripgrep
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSmartCaseInsensitive(t *testing.T) {
+	tests := []struct {
+		pattern      string
+		fixedStrings bool
+		want         bool
+	}{
+		{"hello", false, true},
+		{"Hello", false, false},
+		{"straße", false, true},
+		{"Ärger", false, false},
+		{`\W+foo\S`, false, true},
+		{`\p{Lu}x`, false, true},
+		{`\pLx`, false, true},
+		{`\x{4F}`, false, true},
+		{`(?U)a+`, false, true},
+		{`(?P<Name>\d+)`, false, true},
+		{`(?<Name>\d+)`, false, true},
+		{`(?i:x)`, false, true},
+		{`(?<=Foo)bar`, false, false},
+		{`(?<!Foo)bar`, false, false},
+		{`(?=Bar)b`, false, false},
+		{`foo(?!Bar)`, false, false},
+		{`(?<=foo)bar`, false, true},
+		{`(?>Ab)`, false, false},
+		{`[A-Z]`, false, false},
+		{`\W`, true, false},
+		{"a.b", true, true},
+	}
+	for _, tt := range tests {
+		if got := SmartCaseInsensitive(tt.pattern, tt.fixedStrings); got != tt.want {
+			t.Errorf("SmartCaseInsensitive(%q, %v) = %v, want %v", tt.pattern, tt.fixedStrings, got, tt.want)
+		}
+	}
+}
+
+func TestFindSmartCase(t *testing.T) {
+	tempDir := t.TempDir()
+	content := "hello world\nHello World\nHELLO WORLD\n"
+	if err := os.WriteFile(filepath.Join(tempDir, "test.txt"), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		pattern string
+		opts    []Option
+		want    int
+	}{
+		{"lowercase literal ignores case", "hello", []Option{WithSmartCase()}, 3},
+		{"uppercase literal keeps case", "Hello", []Option{WithSmartCase()}, 1},
+		{"lowercase regex ignores case", `hel+o\s`, []Option{WithSmartCase()}, 3},
+		{"uppercase regex keeps case", `HEL+O\s`, []Option{WithSmartCase()}, 1},
+		{"lowercase fixed string ignores case", "o w", []Option{WithSmartCase(), WithFixedStrings()}, 3},
+		{"uppercase fixed string keeps case", "O W", []Option{WithSmartCase(), WithFixedStrings()}, 1},
+		{"escape letters don't count", `\Sello`, []Option{WithSmartCase()}, 3},
+		{"ignore case after smart case wins", "Hello", []Option{WithSmartCase(), WithIgnoreCase()}, 3},
+		{"smart case after ignore case wins", "Hello", []Option{WithIgnoreCase(), WithSmartCase()}, 1},
+		{"each pattern judged alone", "world", []Option{WithSmartCase(), WithPatterns([]string{"HELLO"})}, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := Find(tt.pattern, tempDir, tt.opts...)
+			if err != nil {
+				t.Fatalf("Find failed: %v", err)
+			}
+			if results.Count() != tt.want {
+				t.Errorf("Expected %d matches, got %d: %+v", tt.want, results.Count(), results.Matches)
+			}
+		})
+	}
+
+	replaced, err := Replace("hello", "bye", tempDir, WithSmartCase(), WithDryRun())
+	if err != nil {
+		t.Fatalf("Replace failed: %v", err)
+	}
+	if replaced.Replacements != 3 {
+		t.Errorf("Expected 3 smart case replacements, got %d", replaced.Replacements)
+	}
+}
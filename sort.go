@@ -0,0 +1,143 @@
+package goripgrep
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+)
+
+// SortOrder orders matches by file path, then by line and column
+type SortOrder int
+
+const (
+	SortNone        SortOrder = iota // Keep the order the search produced
+	SortPath                         // Compare paths byte by byte
+	SortPathNatural                  // Compare runs of digits by value, so file2 comes before file10
+	SortPathLocale                   // Collate paths for the locale in LC_ALL, LC_COLLATE or LANG
+)
+
+// ParseSortOrder parses a sort order as taken by --sort: "none", "path",
+// "path:natural" or "path:locale"
+func ParseSortOrder(s string) (SortOrder, error) {
+	switch s {
+	case "", "none":
+		return SortNone, nil
+	case "path":
+		return SortPath, nil
+	case "path:natural":
+		return SortPathNatural, nil
+	case "path:locale":
+		return SortPathLocale, nil
+	}
+	return SortNone, fmt.Errorf("unknown sort order %q (want none, path, path:natural or path:locale)", s)
+}
+
+// Sort orders matches by file path in the given order, then by line and
+// column. Matches that compare equal keep their order.
+func Sort(order SortOrder) Stage {
+	return func(matches []Match) []Match {
+		if order == SortNone {
+			return matches
+		}
+		comparePaths := pathComparer(order)
+		slices.SortStableFunc(matches, func(a, b Match) int {
+			if a.File != b.File {
+				return comparePaths(a.File, b.File)
+			}
+			return cmp.Or(cmp.Compare(a.Line, b.Line), cmp.Compare(a.Column, b.Column))
+		})
+		return matches
+	}
+}
+
+// SortPaths sorts paths in place in the given order. SortNone leaves them
+// as they are.
+func SortPaths(paths []string, order SortOrder) {
+	if order == SortNone {
+		return
+	}
+	slices.SortFunc(paths, pathComparer(order))
+}
+
+// pathComparer returns the comparison for paths in order. Paths that
+// collate equally but differ are ordered by their bytes, so the order is
+// total.
+func pathComparer(order SortOrder) func(a, b string) int {
+	switch order {
+	case SortPathNatural:
+		return func(a, b string) int {
+			return cmp.Or(naturalCompare(a, b), strings.Compare(a, b))
+		}
+	case SortPathLocale:
+		collator := collate.New(envLocale())
+		return func(a, b string) int {
+			return cmp.Or(collator.CompareString(a, b), strings.Compare(a, b))
+		}
+	}
+	return strings.Compare
+}
+
+// naturalCompare compares a and b with each run of ASCII digits taken as a
+// number, ignoring leading zeros
+func naturalCompare(a, b string) int {
+	for a != "" && b != "" {
+		if isDigit(a[0]) && isDigit(b[0]) {
+			numA, restA := digitRun(a)
+			numB, restB := digitRun(b)
+			numA = strings.TrimLeft(numA, "0")
+			numB = strings.TrimLeft(numB, "0")
+			if c := cmp.Or(cmp.Compare(len(numA), len(numB)), strings.Compare(numA, numB)); c != 0 {
+				return c
+			}
+			a, b = restA, restB
+			continue
+		}
+		if a[0] != b[0] {
+			return cmp.Compare(a[0], b[0])
+		}
+		a, b = a[1:], b[1:]
+	}
+	return cmp.Compare(len(a), len(b))
+}
+
+// digitRun splits s after its leading run of ASCII digits
+func digitRun(s string) (string, string) {
+	i := 0
+	for i < len(s) && isDigit(s[i]) {
+		i++
+	}
+	return s[:i], s[i:]
+}
+
+// isDigit reports whether c is an ASCII digit
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+// envLocale returns the collation locale named by LC_ALL, LC_COLLATE or
+// LANG, in that order, such as "de_DE.UTF-8". The C and POSIX locales, and
+// names that cannot be parsed, fall back to the root collation.
+func envLocale() language.Tag {
+	for _, name := range []string{"LC_ALL", "LC_COLLATE", "LANG"} {
+		value := os.Getenv(name)
+		if value == "" {
+			continue
+		}
+		if value == "C" || value == "POSIX" {
+			return language.Und
+		}
+		value, _, _ = strings.Cut(value, ".")
+		value, _, _ = strings.Cut(value, "@")
+		tag, err := language.Parse(strings.ReplaceAll(value, "_", "-"))
+		if err != nil {
+			return language.Und
+		}
+		return tag
+	}
+	return language.Und
+}
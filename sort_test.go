@@ -0,0 +1,92 @@
+package goripgrep
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"golang.org/x/text/language"
+)
+
+func TestSortPaths(t *testing.T) {
+	t.Setenv("LC_ALL", "en_US.UTF-8")
+	paths := []string{"src/file10.go", "src/file2.go", "Zebra.go", "src/file1.go", "éclair.go", "apple.go", "src/file02.go", "Eclair.go"}
+
+	tests := []struct {
+		order SortOrder
+		want  []string
+	}{
+		{SortNone, paths},
+		{SortPath, []string{"Eclair.go", "Zebra.go", "apple.go", "src/file02.go", "src/file1.go", "src/file10.go", "src/file2.go", "éclair.go"}},
+		{SortPathNatural, []string{"Eclair.go", "Zebra.go", "apple.go", "src/file1.go", "src/file02.go", "src/file2.go", "src/file10.go", "éclair.go"}},
+		{SortPathLocale, []string{"apple.go", "Eclair.go", "éclair.go", "src/file02.go", "src/file1.go", "src/file10.go", "src/file2.go", "Zebra.go"}},
+	}
+
+	for _, tt := range tests {
+		got := slices.Clone(paths)
+		SortPaths(got, tt.order)
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("order %d: expected %q, got %q", tt.order, tt.want, got)
+		}
+	}
+}
+
+func TestSortStage(t *testing.T) {
+	matches := []Match{
+		{File: "log10.txt", Line: 1, Column: 1},
+		{File: "log2.txt", Line: 5, Column: 3},
+		{File: "log2.txt", Line: 5, Column: 1},
+		{File: "log2.txt", Line: 1, Column: 9},
+	}
+	results := (&SearchResults{Matches: matches}).Transform(Sort(SortPathNatural))
+
+	var got []string
+	for _, match := range results.Matches {
+		got = append(got, fmt.Sprintf("%s:%d:%d", match.File, match.Line, match.Column))
+	}
+	want := []string{"log2.txt:1:9", "log2.txt:5:1", "log2.txt:5:3", "log10.txt:1:1"}
+	if !slices.Equal(got, want) {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+	if matches[0].File != "log10.txt" {
+		t.Error("Expected the original matches to be left unsorted")
+	}
+}
+
+func TestParseSortOrder(t *testing.T) {
+	for text, want := range map[string]SortOrder{
+		"":             SortNone,
+		"none":         SortNone,
+		"path":         SortPath,
+		"path:natural": SortPathNatural,
+		"path:locale":  SortPathLocale,
+	} {
+		if got, err := ParseSortOrder(text); err != nil || got != want {
+			t.Errorf("ParseSortOrder(%q) = %d, %v; expected %d", text, got, err, want)
+		}
+	}
+	if _, err := ParseSortOrder("modified"); err == nil {
+		t.Error("Expected an error for an unknown sort order")
+	}
+}
+
+func TestEnvLocale(t *testing.T) {
+	tests := []struct {
+		lcAll, lcCollate, lang string
+		want                   language.Tag
+	}{
+		{"", "", "", language.Und},
+		{"", "", "de_DE.UTF-8", language.MustParse("de-DE")},
+		{"", "sv_SE.UTF-8@euro", "de_DE.UTF-8", language.MustParse("sv-SE")},
+		{"C", "sv_SE.UTF-8", "", language.Und},
+		{"not a locale", "", "", language.Und},
+	}
+	for _, tt := range tests {
+		t.Setenv("LC_ALL", tt.lcAll)
+		t.Setenv("LC_COLLATE", tt.lcCollate)
+		t.Setenv("LANG", tt.lang)
+		if got := envLocale(); got != tt.want {
+			t.Errorf("LC_ALL=%q LC_COLLATE=%q LANG=%q: expected %v, got %v", tt.lcAll, tt.lcCollate, tt.lang, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package goripgrep
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+)
+
+// OutputTemplate renders matches through a text/template, for output such
+// as CSV, TSV, Markdown tables or custom log lines. The template runs once
+// per match with a TemplateData, and its output is followed by a newline
+// unless it already ends with one; matches it renders as nothing are left
+// out. Templates named "header" and "footer", defined with {{define}}, run
+// once before and after the matches with the same data, whose Match is
+// empty.
+type OutputTemplate struct {
+	tmpl *template.Template
+}
+
+// TemplateData is what an OutputTemplate renders: the fields of the match,
+// such as .File, .Line, .Column, .Content and .Submatches, and the
+// statistics of the search in .Stats
+type TemplateData struct {
+	Match
+	Stats SearchStats
+}
+
+// templateFuncs quote fields for the formats templates commonly produce
+var templateFuncs = template.FuncMap{
+	"csv": func(field string) string {
+		if !strings.ContainsAny(field, ",\"\r\n") {
+			return field
+		}
+		return `"` + strings.ReplaceAll(field, `"`, `""`) + `"`
+	},
+	"tsv": escapeTSV,
+	"md":  strings.NewReplacer("|", `\|`, "\n", "<br>").Replace,
+	"json": func(value any) (string, error) {
+		data, err := json.Marshal(value)
+		return string(data), err
+	},
+	"join": func(elems []string, sep string) string {
+		return strings.Join(elems, sep)
+	},
+}
+
+// ParseOutputTemplate parses text as an OutputTemplate. Besides the built in
+// functions, templates can call csv, tsv and md to quote a field for those
+// formats, json to encode any value and join to join Submatches.
+func ParseOutputTemplate(text string) (*OutputTemplate, error) {
+	tmpl, err := template.New("output").Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid output template: %w", err)
+	}
+	return &OutputTemplate{tmpl: tmpl}, nil
+}
+
+// Execute renders results: the header, every match and the footer
+func (t *OutputTemplate) Execute(w io.Writer, results *SearchResults) error {
+	if err := t.executeNamed(w, "header", TemplateData{Stats: results.Stats}); err != nil {
+		return err
+	}
+	for _, match := range results.Matches {
+		if err := t.ExecuteMatch(w, match, results.Stats); err != nil {
+			return err
+		}
+	}
+	return t.executeNamed(w, "footer", TemplateData{Stats: results.Stats})
+}
+
+// ExecuteMatch renders a single match, for output written as matches arrive
+func (t *OutputTemplate) ExecuteMatch(w io.Writer, match Match, stats SearchStats) error {
+	return t.render(w, t.tmpl, TemplateData{Match: match, Stats: stats})
+}
+
+// executeNamed renders the template called name, if it is defined
+func (t *OutputTemplate) executeNamed(w io.Writer, name string, data TemplateData) error {
+	if tmpl := t.tmpl.Lookup(name); tmpl != nil {
+		return t.render(w, tmpl, data)
+	}
+	return nil
+}
+
+// render executes tmpl and writes its output, ending it with a newline
+func (t *OutputTemplate) render(w io.Writer, tmpl *template.Template, data TemplateData) error {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return fmt.Errorf("output template failed: %w", err)
+	}
+	if buf.Len() == 0 {
+		return nil
+	}
+	if !bytes.HasSuffix(buf.Bytes(), []byte("\n")) {
+		buf.WriteByte('\n')
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// Render writes the results through the template given with
+// WithOutputTemplate
+func (r *SearchResults) Render(w io.Writer) error {
+	if r.template == nil {
+		return fmt.Errorf("no output template: search with WithOutputTemplate")
+	}
+	return r.template.Execute(w, r)
+}
@@ -0,0 +1,73 @@
+package goripgrep
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOutputTemplate(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{"a.txt": "id=7, \"quoted\"\nplain\nid=42\tend\n"})
+	file := filepath.Join(dir, "a.txt")
+
+	tests := []struct {
+		name     string
+		template string
+		opts     []Option
+		want     string
+	}{
+		{
+			name:     "csv with header",
+			template: `{{define "header"}}line,column,content{{end}}{{.Line}},{{.Column}},{{csv .Content}}`,
+			want:     "line,column,content\n1,1,\"id=7, \"\"quoted\"\"\"\n3,1,id=42\tend\n",
+		},
+		{
+			name:     "tsv",
+			template: "{{.Line}}\t{{tsv .Content}}\n",
+			want:     "1\tid=7, \"quoted\"\n3\tid=42\\tend\n",
+		},
+		{
+			name:     "markdown table with footer",
+			template: `{{define "header"}}| Line | Id |{{"\n"}}|---|---|{{end}}{{define "footer"}}{{.Stats.MatchesFound}} matches{{end}}| {{.Line}} | {{join .Submatches ","}} |`,
+			opts:     []Option{WithOnlyMatching()},
+			want:     "| Line | Id |\n|---|---|\n| 1 | 7 |\n| 3 | 42 |\n2 matches\n",
+		},
+		{
+			name:     "empty output skips the match",
+			template: `{{if gt .Line 1}}{{json .MatchText}}{{end}}`,
+			want:     "\"id=42\"\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := append(tt.opts, WithOutputTemplate(tt.template))
+			results, err := FindInFile(`id=(\d+)`, file, opts...)
+			if err != nil {
+				t.Fatalf("FindInFile failed: %v", err)
+			}
+			var out strings.Builder
+			if err := results.Render(&out); err != nil {
+				t.Fatalf("Render failed: %v", err)
+			}
+			if out.String() != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, out.String())
+			}
+		})
+	}
+
+	// Templates are checked before searching, fields when rendering
+	if _, err := Find("id", dir, WithOutputTemplate("{{.Line")); err == nil {
+		t.Error("Expected an error for an unparsable template")
+	}
+	results, err := Find("id", dir, WithOutputTemplate("{{.Missing}}"))
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	if err := results.Render(&strings.Builder{}); err == nil {
+		t.Error("Expected an error rendering an unknown field")
+	}
+	if err := (&SearchResults{}).Render(&strings.Builder{}); err == nil {
+		t.Error("Expected an error rendering without a template")
+	}
+}
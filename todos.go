@@ -0,0 +1,243 @@
+package goripgrep
+
+import (
+	"math"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Todo represents a single TODO/FIXME/HACK annotation found in a source comment
+type Todo struct {
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	Kind     string `json:"kind"`
+	Author   string `json:"author,omitempty"`
+	Date     string `json:"date,omitempty"`
+	Text     string `json:"text"`
+	Language string `json:"language"`
+}
+
+// commentSyntax describes how comments are written in a language
+type commentSyntax struct {
+	language   string
+	line       []string // Line comment markers
+	blockStart string   // Block comment opener (empty if unsupported)
+	blockEnd   string   // Block comment closer
+}
+
+var (
+	cStyleComments    = commentSyntax{line: []string{"//"}, blockStart: "/*", blockEnd: "*/"}
+	hashComments      = commentSyntax{line: []string{"#"}}
+	dashDashComments  = commentSyntax{line: []string{"--"}}
+	markupComments    = commentSyntax{blockStart: "<!--", blockEnd: "-->"}
+	semicolonComments = commentSyntax{line: []string{";"}}
+	percentComments   = commentSyntax{line: []string{"%"}}
+)
+
+// commentSyntaxes maps file extensions to their language and comment syntax
+var commentSyntaxes = map[string]commentSyntax{
+	".go":     withLanguage(cStyleComments, "go"),
+	".c":      withLanguage(cStyleComments, "c"),
+	".h":      withLanguage(cStyleComments, "c"),
+	".cpp":    withLanguage(cStyleComments, "cpp"),
+	".cc":     withLanguage(cStyleComments, "cpp"),
+	".cxx":    withLanguage(cStyleComments, "cpp"),
+	".hpp":    withLanguage(cStyleComments, "cpp"),
+	".java":   withLanguage(cStyleComments, "java"),
+	".kt":     withLanguage(cStyleComments, "kotlin"),
+	".scala":  withLanguage(cStyleComments, "scala"),
+	".cs":     withLanguage(cStyleComments, "csharp"),
+	".js":     withLanguage(cStyleComments, "javascript"),
+	".jsx":    withLanguage(cStyleComments, "javascript"),
+	".ts":     withLanguage(cStyleComments, "typescript"),
+	".tsx":    withLanguage(cStyleComments, "typescript"),
+	".rs":     withLanguage(cStyleComments, "rust"),
+	".swift":  withLanguage(cStyleComments, "swift"),
+	".dart":   withLanguage(cStyleComments, "dart"),
+	".css":    withLanguage(commentSyntax{blockStart: "/*", blockEnd: "*/"}, "css"),
+	".scss":   withLanguage(cStyleComments, "scss"),
+	".less":   withLanguage(cStyleComments, "less"),
+	".php":    withLanguage(commentSyntax{line: []string{"//", "#"}, blockStart: "/*", blockEnd: "*/"}, "php"),
+	".py":     withLanguage(hashComments, "python"),
+	".rb":     withLanguage(hashComments, "ruby"),
+	".pl":     withLanguage(hashComments, "perl"),
+	".pm":     withLanguage(hashComments, "perl"),
+	".r":      withLanguage(hashComments, "r"),
+	".sh":     withLanguage(hashComments, "shell"),
+	".bash":   withLanguage(hashComments, "shell"),
+	".zsh":    withLanguage(hashComments, "shell"),
+	".fish":   withLanguage(hashComments, "shell"),
+	".ps1":    withLanguage(commentSyntax{line: []string{"#"}, blockStart: "<#", blockEnd: "#>"}, "powershell"),
+	".yaml":   withLanguage(hashComments, "yaml"),
+	".yml":    withLanguage(hashComments, "yaml"),
+	".toml":   withLanguage(hashComments, "toml"),
+	".ini":    withLanguage(commentSyntax{line: []string{";", "#"}}, "ini"),
+	".cfg":    withLanguage(hashComments, "config"),
+	".conf":   withLanguage(hashComments, "config"),
+	".mk":     withLanguage(hashComments, "make"),
+	".cmake":  withLanguage(hashComments, "cmake"),
+	".sql":    withLanguage(commentSyntax{line: []string{"--"}, blockStart: "/*", blockEnd: "*/"}, "sql"),
+	".lua":    withLanguage(commentSyntax{line: []string{"--"}, blockStart: "--[[", blockEnd: "]]"}, "lua"),
+	".hs":     withLanguage(commentSyntax{line: []string{"--"}, blockStart: "{-", blockEnd: "-}"}, "haskell"),
+	".elm":    withLanguage(dashDashComments, "elm"),
+	".html":   withLanguage(markupComments, "html"),
+	".htm":    withLanguage(markupComments, "html"),
+	".xml":    withLanguage(markupComments, "xml"),
+	".md":     withLanguage(markupComments, "markdown"),
+	".vue":    withLanguage(commentSyntax{line: []string{"//"}, blockStart: "<!--", blockEnd: "-->"}, "vue"),
+	".svelte": withLanguage(commentSyntax{line: []string{"//"}, blockStart: "<!--", blockEnd: "-->"}, "svelte"),
+	".clj":    withLanguage(semicolonComments, "clojure"),
+	".lisp":   withLanguage(semicolonComments, "lisp"),
+	".el":     withLanguage(semicolonComments, "elisp"),
+	".asm":    withLanguage(semicolonComments, "assembly"),
+	".erl":    withLanguage(percentComments, "erlang"),
+	".tex":    withLanguage(percentComments, "tex"),
+	".m":      withLanguage(percentComments, "matlab"),
+}
+
+// commentSyntaxesByName covers well-known files without an extension
+var commentSyntaxesByName = map[string]commentSyntax{
+	"makefile":   withLanguage(hashComments, "make"),
+	"dockerfile": withLanguage(hashComments, "dockerfile"),
+}
+
+// todoPattern is the search pattern used to locate candidate annotations
+const todoPattern = `\b(TODO|FIXME|HACK)\b`
+
+// todoAnnotation parses an annotation such as "TODO(alice, 2024-01-31): text"
+var todoAnnotation = regexp.MustCompile(`^(TODO|FIXME|HACK)\b(?:\s*\(([^)]*)\))?\s*:?\s*(.*)$`)
+
+// todoDate recognizes ISO-style dates inside annotation metadata
+var todoDate = regexp.MustCompile(`^\d{4}[-/]\d{2}[-/]\d{2}$`)
+
+func withLanguage(syntax commentSyntax, language string) commentSyntax {
+	syntax.language = language
+	return syntax
+}
+
+// lookupCommentSyntax returns the comment syntax for a file, if the language is known
+func lookupCommentSyntax(filePath string) (commentSyntax, bool) {
+	if syntax, ok := commentSyntaxes[strings.ToLower(filepath.Ext(filePath))]; ok {
+		return syntax, true
+	}
+	syntax, ok := commentSyntaxesByName[strings.ToLower(filepath.Base(filePath))]
+	return syntax, ok
+}
+
+// inComment reports whether the byte offset pos of line lies inside a comment.
+// This is a line-local heuristic: a comment marker must precede pos on the same
+// line, or the line must look like the continuation of a C-style block comment.
+func (c commentSyntax) inComment(line string, pos int) bool {
+	if pos < 0 || pos > len(line) {
+		return false
+	}
+	prefix := line[:pos]
+
+	for _, marker := range c.line {
+		if strings.Contains(prefix, marker) {
+			return true
+		}
+	}
+
+	if c.blockStart != "" {
+		if strings.Contains(prefix, c.blockStart) {
+			return true
+		}
+		if c.blockStart == "/*" && strings.HasPrefix(strings.TrimSpace(prefix), "*") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseTodo extracts kind, author, date and text from an annotation that starts at text[0]
+func (c commentSyntax) parseTodo(text string) (kind, author, date, body string, ok bool) {
+	parts := todoAnnotation.FindStringSubmatch(text)
+	if parts == nil {
+		return "", "", "", "", false
+	}
+
+	kind = parts[1]
+	for _, field := range strings.FieldsFunc(parts[2], func(r rune) bool { return r == ',' || r == ';' }) {
+		field = strings.TrimSpace(field)
+		switch {
+		case field == "":
+		case todoDate.MatchString(field):
+			date = field
+		case author == "":
+			author = strings.TrimPrefix(field, "@")
+		}
+	}
+
+	body = strings.TrimSpace(parts[3])
+	if c.blockEnd != "" {
+		if idx := strings.Index(body, c.blockEnd); idx != -1 {
+			body = strings.TrimSpace(body[:idx])
+		}
+	}
+
+	return kind, author, date, body, true
+}
+
+// FindTodos searches path for TODO, FIXME and HACK annotations that appear
+// inside comments of recognized languages. Files in unknown languages are skipped.
+func FindTodos(path string, opts ...Option) ([]Todo, error) {
+	results, err := Find(todoPattern, path, fullLineOptions(opts)...)
+	if err != nil {
+		return nil, err
+	}
+
+	var todos []Todo
+	for _, match := range results.Matches {
+		syntax, ok := lookupCommentSyntax(match.File)
+		if !ok {
+			continue
+		}
+
+		pos := match.MatchStart
+		if !syntax.inComment(match.Content, pos) {
+			continue
+		}
+
+		kind, author, date, text, ok := syntax.parseTodo(match.Content[pos:])
+		if !ok {
+			continue
+		}
+
+		todos = append(todos, Todo{
+			File:     match.File,
+			Line:     match.Line,
+			Column:   match.Column,
+			Kind:     kind,
+			Author:   author,
+			Date:     date,
+			Text:     text,
+			Language: syntax.language,
+		})
+	}
+
+	sort.Slice(todos, func(i, j int) bool {
+		if todos[i].File != todos[j].File {
+			return todos[i].File < todos[j].File
+		}
+		if todos[i].Line != todos[j].Line {
+			return todos[i].Line < todos[j].Line
+		}
+		return todos[i].Column < todos[j].Column
+	})
+
+	return todos, nil
+}
+
+// fullLineOptions follows opts with what scanners reading the line around each
+// match need: every match, with its whole line whatever the caller asked for.
+func fullLineOptions(opts []Option) []Option {
+	return append(append([]Option(nil), opts...), WithMaxResults(math.MaxInt), func(opts *searchOptions) {
+		opts.onlyMatching = false
+		opts.noLineContent = false
+	})
+}
@@ -0,0 +1,111 @@
+package goripgrep
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFindTodos(t *testing.T) {
+	tempDir := t.TempDir()
+
+	testFiles := map[string]string{
+		"main.go":   "package main\n\n// TODO(alice, 2024-01-31): handle errors\nfunc main() {\n\tmsg := \"TODO not a comment\"\n\t_ = msg /* FIXME: leaks */\n}\n",
+		"app.py":    "def run():\n    # HACK(@bob) temporary workaround\n    pass\n",
+		"doc.txt":   "TODO this file type has no comment syntax\n",
+		"page.html": "<!-- TODO(2023-12-01): update footer -->\n<p>TODO visible text</p>\n",
+	}
+
+	for filename, content := range testFiles {
+		if err := os.WriteFile(filepath.Join(tempDir, filename), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", filename, err)
+		}
+	}
+
+	todos, err := FindTodos(tempDir)
+	if err != nil {
+		t.Fatalf("FindTodos failed: %v", err)
+	}
+
+	if len(todos) != 4 {
+		for _, todo := range todos {
+			t.Logf("Found: %+v", todo)
+		}
+		t.Fatalf("Expected 4 todos, got %d", len(todos))
+	}
+
+	expected := []Todo{
+		{Line: 2, Kind: "HACK", Author: "bob", Text: "temporary workaround", Language: "python"},
+		{Line: 3, Kind: "TODO", Author: "alice", Date: "2024-01-31", Text: "handle errors", Language: "go"},
+		{Line: 6, Kind: "FIXME", Text: "leaks", Language: "go"},
+		{Line: 1, Kind: "TODO", Date: "2023-12-01", Text: "update footer", Language: "html"},
+	}
+
+	for i, want := range expected {
+		got := todos[i]
+		if got.Line != want.Line || got.Kind != want.Kind || got.Author != want.Author ||
+			got.Date != want.Date || got.Text != want.Text || got.Language != want.Language {
+			t.Errorf("Todo %d: expected %+v, got %+v", i, want, got)
+		}
+	}
+}
+
+func TestFindTodosOptions(t *testing.T) {
+	tempDir := t.TempDir()
+	content := "// é TODO(alice): accents before\n" + strings.Repeat("// FIXME: one of many\n", 1500)
+	if err := os.WriteFile(filepath.Join(tempDir, "main.go"), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	// Every annotation is parsed from its whole line, whatever the options say
+	tests := []struct {
+		name string
+		opts []Option
+	}{
+		{"defaults", nil},
+		{"rune columns", []Option{WithColumnMode(ColumnRunes)}},
+		{"only matching", []Option{WithOnlyMatching()}},
+		{"without line content", []Option{WithoutLineContent()}},
+		{"max results", []Option{WithMaxResults(10)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			todos, err := FindTodos(tempDir, tt.opts...)
+			if err != nil {
+				t.Fatalf("FindTodos failed: %v", err)
+			}
+			if len(todos) != 1501 {
+				t.Fatalf("Expected 1501 todos, got %d", len(todos))
+			}
+			if got := todos[0]; got.Kind != "TODO" || got.Author != "alice" || got.Text != "accents before" {
+				t.Errorf("Expected the accented line to be parsed, got %+v", got)
+			}
+			if got := todos[1500]; got.Kind != "FIXME" || got.Text != "one of many" {
+				t.Errorf("Expected the last annotation to be parsed, got %+v", got)
+			}
+		})
+	}
+}
+
+func TestCommentSyntaxInComment(t *testing.T) {
+	goSyntax, _ := lookupCommentSyntax("file.go")
+
+	tests := []struct {
+		line     string
+		pos      int
+		expected bool
+	}{
+		{"// TODO: x", 3, true},
+		{"x := 1 // TODO", 10, true},
+		{" * TODO inside block", 3, true},
+		{"s := \"TODO\"", 6, false},
+		{"/* TODO */", 3, true},
+	}
+
+	for _, test := range tests {
+		if got := goSyntax.inComment(test.line, test.pos); got != test.expected {
+			t.Errorf("inComment(%q, %d) = %v, expected %v", test.line, test.pos, got, test.expected)
+		}
+	}
+}
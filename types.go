@@ -1,17 +1,126 @@
 package goripgrep
 
 import (
+	"fmt"
 	"path/filepath"
 	"strings"
 )
 
 // Match represents a single search result
 type Match struct {
-	File    string   // Path to the file containing the match
-	Line    int      // Line number (1-indexed)
-	Column  int      // Column number (1-indexed)
-	Content string   // Content of the matching line
-	Context []string // Context lines (if requested)
+	File    string // Path to the file containing the match
+	Line    int    // Line number (1-indexed)
+	EndLine int    // Last line spanned by the match (multiline mode only)
+	Column  int    // Column number (1-indexed)
+	Content string // Content of the matching line(s); empty with WithoutLineContent, just the match with WithOnlyMatching
+
+	MatchText  string   // The matched text, Content[MatchStart:MatchEnd], kept when Content is left out
+	MatchStart int      // Byte offset of the match within Content
+	MatchEnd   int      // Byte offset just past the match within Content; equal to MatchStart when there is nothing to highlight
+	RuneColumn int      // Column counted in characters rather than bytes (1-indexed), as editors show it
+	Spans      []Span   // Every non-empty match on the line, this one included, in order
+	Submatches []string // Text of each capture group, "" for groups that did not take part (WithOnlyMatching only)
+
+	PatternIndex int // Which pattern matched when searching for several, counting the main pattern first
+
+	Kind      MatchKind // Where the match was found; zero for file contents
+	Attribute string    // Name of the extended attribute (MatchXattr only)
+
+	Section    string      // Nearest preceding Markdown heading (WithSections or the Sections stage)
+	KeyPath    string      // Path to the matched value in a JSON or YAML file, like spec.containers[0].image (WithKeyPaths or the KeyPaths stage)
+	Annotation *Annotation // Code context added by the Annotate stage, if any
+
+	BeforeContext []string // Lines preceding the match (if requested)
+	AfterContext  []string // Lines following the match (if requested)
+}
+
+// Span is the byte range of a match within a line
+type Span struct {
+	Start int // Offset of the first matched byte
+	End   int // Offset just past the last matched byte
+}
+
+// newSpans converts match index pairs into spans, dropping empty matches
+// that would have nothing to highlight
+func newSpans(indices [][]int) []Span {
+	var spans []Span
+	for _, index := range indices {
+		if index[1] > index[0] {
+			spans = append(spans, Span{Start: index[0], End: index[1]})
+		}
+	}
+	return spans
+}
+
+// MatchKind identifies the part of a file a match was found in
+type MatchKind int
+
+const (
+	MatchContent  MatchKind = iota // File contents
+	MatchFileName                  // The base name of the file
+	MatchXattr                     // The value of an extended attribute
+	MatchBinary                    // A binary file matched; its content is left out (BinaryReport)
+)
+
+// String returns the name used for the kind in output
+func (k MatchKind) String() string {
+	switch k {
+	case MatchFileName:
+		return "filename"
+	case MatchXattr:
+		return "xattr"
+	case MatchBinary:
+		return "binary"
+	default:
+		return "content"
+	}
+}
+
+// MarshalText encodes the kind by name so JSON output stays readable
+func (k MatchKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// UnmarshalText decodes a kind encoded by MarshalText, so clients of a Server
+// can read its matches back
+func (k *MatchKind) UnmarshalText(text []byte) error {
+	for _, kind := range []MatchKind{MatchContent, MatchFileName, MatchXattr, MatchBinary} {
+		if string(text) == kind.String() {
+			*k = kind
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown match kind %q", text)
+}
+
+// BinaryMode says what a search does with the binary files it finds while
+// walking, as judged by the binary detectors
+type BinaryMode int
+
+const (
+	BinarySkip   BinaryMode = iota // Skip binary files
+	BinaryText                     // Search binary files as if they were text, like rg -a
+	BinaryReport                   // Search binary files but report only that they match, with a MatchBinary match
+)
+
+// ColumnMode says what Match.Column counts
+type ColumnMode int
+
+const (
+	ColumnBytes ColumnMode = iota // Bytes from the start of the line, like grep and ripgrep
+	ColumnRunes                   // Characters from the start of the line, as editors count them
+)
+
+// ParseColumnMode parses a column mode as taken by --column-mode: "bytes"
+// or "runes"
+func ParseColumnMode(s string) (ColumnMode, error) {
+	switch s {
+	case "", "bytes":
+		return ColumnBytes, nil
+	case "runes":
+		return ColumnRunes, nil
+	}
+	return ColumnBytes, fmt.Errorf("unknown column mode %q (want bytes or runes)", s)
 }
 
 // SearchArgs represents arguments for search operations
@@ -22,8 +131,16 @@ type SearchArgs struct {
 	IgnoreCase    *bool
 	MaxResults    *int
 	IncludeHidden *bool
-	ContextLines  *int
+	ContextLines  *int // Lines of context on both sides of a match
+	BeforeContext *int // Overrides ContextLines for lines before a match
+	AfterContext  *int // Overrides ContextLines for lines after a match
 	TimeoutMs     *int
+	Multiline     *bool
+	FixedStrings  *bool // Treat the pattern literally, skipping regex compilation
+	WordRegexp    *bool // Only match whole words
+	LineRegexp    *bool // Only match whole lines
+	InvertMatch   *bool // Report lines that do not match the pattern
+	PCRE2         *bool // Allow lookaround and backreferences, matched by a backtracking engine
 }
 
 // isLiteralPattern determines if a pattern is a literal string (no regex metacharacters)
@@ -40,6 +157,34 @@ func isLiteralPattern(pattern string) bool {
 	return true
 }
 
+// surroundingLines returns up to before lines preceding lines[first] and up to
+// after lines following lines[last]; first and last are 0-indexed
+func surroundingLines(lines []string, first, last, before, after int) ([]string, []string) {
+	var beforeLines, afterLines []string
+
+	if before > 0 {
+		start := first - before
+		if start < 0 {
+			start = 0
+		}
+		if start < first {
+			beforeLines = append(beforeLines, lines[start:first]...)
+		}
+	}
+
+	if after > 0 {
+		end := last + 1 + after
+		if end > len(lines) {
+			end = len(lines)
+		}
+		if last+1 < end {
+			afterLines = append(afterLines, lines[last+1:end]...)
+		}
+	}
+
+	return beforeLines, afterLines
+}
+
 // isBinaryFile checks if a file is likely binary based on its extension
 func isBinaryFile(filePath string) bool {
 	ext := strings.ToLower(filepath.Ext(filePath))
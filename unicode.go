@@ -27,9 +27,6 @@ type UnicodeSearchEngine struct {
 	caseFoldedPattern string
 	isLiteral         bool
 	ignoreCase        bool
-
-	// Unicode character class support
-	characterClasses map[string]*unicode.RangeTable
 }
 
 // NewUnicodeSearchEngine creates a Unicode-aware search engine
@@ -38,18 +35,6 @@ func NewUnicodeSearchEngine(pattern string, ignoreCase bool) (*UnicodeSearchEngi
 		pattern:    pattern,
 		ignoreCase: ignoreCase,
 		isLiteral:  isLiteralPattern(pattern),
-		characterClasses: map[string]*unicode.RangeTable{
-			"Greek":      unicode.Greek,
-			"Latin":      unicode.Latin,
-			"Cyrillic":   unicode.Cyrillic,
-			"Arabic":     unicode.Arabic,
-			"Hebrew":     unicode.Hebrew,
-			"Han":        unicode.Han,
-			"Hiragana":   unicode.Hiragana,
-			"Katakana":   unicode.Katakana,
-			"Thai":       unicode.Thai,
-			"Devanagari": unicode.Devanagari,
-		},
 	}
 
 	if engine.isLiteral {
@@ -59,14 +44,16 @@ func NewUnicodeSearchEngine(pattern string, ignoreCase bool) (*UnicodeSearchEngi
 			engine.caseFoldedPattern = pattern
 		}
 	} else {
-		// Expand Unicode character classes in the pattern
-		expandedPattern := engine.expandUnicodeClasses(pattern)
+		// Rewrite Unicode character classes into the syntax regexp accepts
+		expandedPattern, err := expandUnicodeClasses(pattern)
+		if err != nil {
+			return nil, err
+		}
 
 		if ignoreCase {
 			expandedPattern = "(?i)" + expandedPattern
 		}
 
-		var err error
 		engine.compiledRegex, err = regexp.Compile(expandedPattern)
 		if err != nil {
 			return nil, err
@@ -76,30 +63,77 @@ func NewUnicodeSearchEngine(pattern string, ignoreCase bool) (*UnicodeSearchEngi
 	return engine, nil
 }
 
-// expandUnicodeClasses expands \p{ClassName} patterns to character ranges
-func (e *UnicodeSearchEngine) expandUnicodeClasses(pattern string) string {
-	// Handle \p{ClassName} patterns
-	result := pattern
+// expandUnicodeClasses rewrites the \p{Script=Name}, \p{sc=Name},
+// \p{General_Category=Name} and \p{gc=Name} forms of Unicode classes, and
+// their \P negations, to the \p{Name} form regexp matches natively. Every
+// class name is checked against unicode.Scripts and unicode.Categories, so a
+// misspelt one is reported by name rather than as a bad escape.
+func expandUnicodeClasses(pattern string) (string, error) {
+	var result strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		if c != '\\' || i+1 == len(pattern) {
+			result.WriteByte(c)
+			continue
+		}
 
-	// Simple expansion for common Unicode classes
-	unicodeClasses := map[string]string{
-		`\p{Greek}`:      `[\u0370-\u03FF\u1F00-\u1FFF]`,
-		`\p{Latin}`:      `[\u0041-\u005A\u0061-\u007A\u00C0-\u024F\u1E00-\u1EFF]`,
-		`\p{Cyrillic}`:   `[\u0400-\u04FF\u0500-\u052F\u2DE0-\u2DFF\uA640-\uA69F]`,
-		`\p{Arabic}`:     `[\u0600-\u06FF\u0750-\u077F\u08A0-\u08FF\uFB50-\uFDFF\uFE70-\uFEFF]`,
-		`\p{Hebrew}`:     `[\u0590-\u05FF\uFB1D-\uFB4F]`,
-		`\p{Han}`:        `[\u4E00-\u9FFF\u3400-\u4DBF\u20000-\u2A6DF\u2A700-\u2B73F\u2B740-\u2B81F\u2B820-\u2CEAF]`,
-		`\p{Hiragana}`:   `[\u3040-\u309F]`,
-		`\p{Katakana}`:   `[\u30A0-\u30FF\u31F0-\u31FF]`,
-		`\p{Thai}`:       `[\u0E00-\u0E7F]`,
-		`\p{Devanagari}`: `[\u0900-\u097F]`,
+		// Copy any other escape whole, so \\p stays a backslash and a p
+		next := pattern[i+1]
+		if (next != 'p' && next != 'P') || i+2 == len(pattern) || pattern[i+2] != '{' {
+			result.WriteString(pattern[i : i+2])
+			i++
+			continue
+		}
+		end := strings.IndexByte(pattern[i+3:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("missing closing } in Unicode class %q", pattern[i:])
+		}
+		class := pattern[i+3 : i+3+end]
+		name, err := unicodeClassName(class)
+		if err != nil {
+			return "", err
+		}
+		result.WriteString(`\` + string(next) + "{" + name + "}")
+		i += 3 + end
 	}
+	return result.String(), nil
+}
 
-	for class, replacement := range unicodeClasses {
-		result = strings.ReplaceAll(result, class, replacement)
+// unicodeClassName returns the name regexp knows class by, keeping a leading
+// ^ that negates it
+func unicodeClassName(class string) (string, error) {
+	negated, name := "", class
+	if strings.HasPrefix(name, "^") {
+		negated, name = "^", name[1:]
 	}
+	if property, value, found := strings.Cut(name, "="); found {
+		switch property {
+		case "Script", "sc":
+			if _, ok := unicode.Scripts[value]; !ok {
+				return "", fmt.Errorf("unknown Unicode script %q in \\p{%s}", value, class)
+			}
+		case "General_Category", "gc":
+			if _, ok := unicode.Categories[value]; !ok {
+				return "", fmt.Errorf("unknown Unicode category %q in \\p{%s}", value, class)
+			}
+		default:
+			return "", fmt.Errorf("unsupported Unicode property %q in \\p{%s}", property, class)
+		}
+		return negated + value, nil
+	}
+	if unicodeClassTable(name) == nil && name != "Any" {
+		return "", fmt.Errorf("unknown Unicode class %q", class)
+	}
+	return negated + name, nil
+}
 
-	return result
+// unicodeClassTable returns the script or general category called name, or
+// nil when there is none
+func unicodeClassTable(name string) *unicode.RangeTable {
+	if table, ok := unicode.Scripts[name]; ok {
+		return table
+	}
+	return unicode.Categories[name]
 }
 
 // Search performs Unicode-aware search on text
@@ -208,9 +242,10 @@ func CaseFoldString(s string) string {
 	return strings.ToLower(s)
 }
 
-// IsInCharacterClass checks if a rune belongs to a Unicode character class
+// IsInCharacterClass checks if a rune belongs to a Unicode script or general
+// category, such as "Greek" or "Lu"
 func (e *UnicodeSearchEngine) IsInCharacterClass(r rune, className string) bool {
-	if rangeTable, exists := e.characterClasses[className]; exists {
+	if rangeTable := unicodeClassTable(className); rangeTable != nil {
 		return unicode.Is(rangeTable, r)
 	}
 	return false
@@ -436,26 +471,111 @@ func (un *UnicodeNormalizer) IsNormalized(s string) bool {
 	return un.form.IsNormal([]byte(s))
 }
 
-// AdvancedCaseFolding provides Unicode-aware case folding
+// normalizedLine is a line in normal form, and perhaps case folded, that
+// remembers, for each of its bytes, the segment of the original line it
+// was made from
+type normalizedLine struct {
+	text   string
+	starts []int // Original offset of the segment each byte comes from
+	ends   []int // Original offset just past that segment
+	length int   // Length of the original line
+}
+
+// normalizeLine normalizes line a segment at a time, so offsets into the
+// result can be mapped back to the original. Segments are also case folded
+// when folder is not nil.
+func (un *UnicodeNormalizer) normalizeLine(line string, folder *AdvancedCaseFolding) normalizedLine {
+	normalized := normalizedLine{length: len(line)}
+	var fold transform.Transformer
+	if folder != nil {
+		fold = folder.transformer()
+	}
+
+	var text strings.Builder
+	var iter norm.Iter
+	iter.InitString(un.form, line)
+	for !iter.Done() {
+		start := iter.Pos()
+		segment := iter.Next()
+		end := iter.Pos()
+		if fold != nil {
+			if folded, _, err := transform.Bytes(fold, segment); err == nil {
+				segment = folded
+			}
+		}
+		for range segment {
+			normalized.starts = append(normalized.starts, start)
+			normalized.ends = append(normalized.ends, end)
+		}
+		text.Write(segment)
+	}
+	normalized.text = text.String()
+	return normalized
+}
+
+// originalSpans maps spans of the normalized text to the original line,
+// widening them to whole segments where they start or end inside one
+func (l normalizedLine) originalSpans(spans [][]int) [][]int {
+	for _, span := range spans {
+		start, end := l.length, l.length
+		if span[0] < len(l.starts) {
+			start = l.starts[span[0]]
+		}
+		if span[1] > span[0] {
+			end = l.ends[span[1]-1]
+		} else {
+			end = start
+		}
+		span[0], span[1] = start, end
+	}
+	return spans
+}
+
+// normalizedOffset returns the offset in the normalized text of the segment
+// starting at offset in the original line
+func (l normalizedLine) normalizedOffset(offset int) int {
+	for i, start := range l.starts {
+		if start >= offset {
+			return i
+		}
+	}
+	return len(l.starts)
+}
+
+// AdvancedCaseFolding provides Unicode-aware case folding. Text is lowercased
+// for the language first, for its special cases such as the Turkish dotless
+// i, then fully case folded, so "ß" folds to "ss". It is safe for concurrent use.
 type AdvancedCaseFolding struct {
-	caser cases.Caser
+	lang language.Tag
 }
 
 // NewAdvancedCaseFolding creates a new advanced case folding instance
 func NewAdvancedCaseFolding(lang language.Tag) *AdvancedCaseFolding {
-	return &AdvancedCaseFolding{
-		caser: cases.Fold(),
-	}
+	return &AdvancedCaseFolding{lang: lang}
 }
 
 // Fold performs Unicode case folding on the input string
 func (acf *AdvancedCaseFolding) Fold(s string) string {
-	return acf.caser.String(s)
+	folded, _, err := transform.String(acf.transformer(), s)
+	if err != nil {
+		return s
+	}
+	return folded
 }
 
 // FoldBytes performs Unicode case folding on the input bytes
 func (acf *AdvancedCaseFolding) FoldBytes(b []byte) []byte {
-	return []byte(acf.caser.String(string(b)))
+	folded, _, err := transform.Bytes(acf.transformer(), b)
+	if err != nil {
+		return b
+	}
+	return folded
+}
+
+// transformer returns a new transformer folding text, as transformers keep
+// state and cannot be shared between goroutines
+func (acf *AdvancedCaseFolding) transformer() transform.Transformer {
+	return transform.Chain(cases.Lower(acf.lang), cases.Fold())
 }
 
 // EnhancedUnicodeSearchEngine combines encoding detection, normalization, and case folding
@@ -1,9 +1,14 @@
 package goripgrep
 
 import (
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"unicode/utf8"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/unicode/norm"
 )
 
 func TestNewUnicodeSearchEngine(t *testing.T) {
@@ -165,32 +170,68 @@ func TestUnicodeSearchLineNumbers(t *testing.T) {
 }
 
 func TestExpandUnicodeClasses(t *testing.T) {
-	engine, err := NewUnicodeSearchEngine("dummy", false)
-	if err != nil {
-		t.Fatalf("Failed to create Unicode engine: %v", err)
-	}
-
 	tests := []struct {
 		input    string
-		contains string
+		expected string
 	}{
-		{`\p{Greek}`, `[\u0370-\u03FF\u1F00-\u1FFF]`},
-		{`\p{Latin}`, `[\u0041-\u005A\u0061-\u007A\u00C0-\u024F\u1E00-\u1EFF]`},
-		{`\p{Cyrillic}`, `[\u0400-\u04FF\u0500-\u052F\u2DE0-\u2DFF\uA640-\uA69F]`},
-		{`\p{Arabic}`, `[\u0600-\u06FF\u0750-\u077F\u08A0-\u08FF\uFB50-\uFDFF\uFE70-\uFEFF]`},
-		{`\p{Hebrew}`, `[\u0590-\u05FF\uFB1D-\uFB4F]`},
-		{`\p{Han}`, `[\u4E00-\u9FFF\u3400-\u4DBF\u20000-\u2A6DF\u2A700-\u2B73F\u2B740-\u2B81F\u2B820-\u2CEAF]`},
-		{`\p{Hiragana}`, `[\u3040-\u309F]`},
-		{`\p{Katakana}`, `[\u30A0-\u30FF\u31F0-\u31FF]`},
-		{`\p{Thai}`, `[\u0E00-\u0E7F]`},
-		{`\p{Devanagari}`, `[\u0900-\u097F]`},
+		{`\p{Greek}+`, `\p{Greek}+`},
+		{`\p{Script=Han}`, `\p{Han}`},
+		{`\p{sc=Cyrillic}`, `\p{Cyrillic}`},
+		{`\P{Script=Latin}`, `\P{Latin}`},
+		{`\p{^Script=Latin}`, `\p{^Latin}`},
+		{`\p{General_Category=Lu}`, `\p{Lu}`},
+		{`[\p{gc=Nd}\p{Script=Greek}]`, `[\p{Nd}\p{Greek}]`},
+		{`\pL\d`, `\pL\d`},
+		{`\\p{Script=Han}`, `\\p{Script=Han}`}, // An escaped backslash, not a class
 	}
-
 	for _, test := range tests {
 		t.Run(test.input, func(t *testing.T) {
-			result := engine.expandUnicodeClasses(test.input)
-			if !strings.Contains(result, test.contains) {
-				t.Errorf("Expected expansion of %q to contain %q, got %q", test.input, test.contains, result)
+			result, err := expandUnicodeClasses(test.input)
+			if err != nil {
+				t.Fatalf("expandUnicodeClasses(%q) failed: %v", test.input, err)
+			}
+			if result != test.expected {
+				t.Errorf("Expected %q to expand to %q, got %q", test.input, test.expected, result)
+			}
+		})
+	}
+
+	for _, pattern := range []string{`\p{Klingon}`, `\p{Script=Klingon}`, `\p{gc=Xx}`, `\p{Block=Greek}`, `\p{Greek`} {
+		if _, err := NewUnicodeSearchEngine(pattern, false); err == nil {
+			t.Errorf("Expected an error for %q", pattern)
+		}
+	}
+}
+
+func TestUnicodeSearchEngineClasses(t *testing.T) {
+	// U+20000 is a Han ideograph, U+1D400 a mathematical capital letter and
+	// U+1F600 an emoji, all above U+FFFF
+	text := "abc αβγ 中\U00020000\U0002A6DF \U0001D400 \U0001F600 123"
+
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{`\p{Greek}+`, []string{"αβγ"}},
+		{`\p{Han}+`, []string{"中\U00020000\U0002A6DF"}},
+		{`\p{Script=Han}+`, []string{"中\U00020000\U0002A6DF"}},
+		{`\p{Lu}`, []string{"\U0001D400"}},
+		{`\p{gc=So}`, []string{"\U0001F600"}},
+		{`[^\P{Script=Latin}]+`, []string{"abc"}},
+		{`\P{Any}`, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.pattern, func(t *testing.T) {
+			engine, err := NewUnicodeSearchEngine(test.pattern, false)
+			if err != nil {
+				t.Fatalf("Failed to create Unicode engine: %v", err)
+			}
+			var got []string
+			for _, match := range engine.Search(text) {
+				got = append(got, match.Text)
+			}
+			if strings.Join(got, "|") != strings.Join(test.want, "|") {
+				t.Errorf("Expected matches %q, got %q", test.want, got)
 			}
 		})
 	}
@@ -241,6 +282,9 @@ func TestIsInCharacterClass(t *testing.T) {
 		{'a', "Cyrillic", false}, // Latin 'a'
 		{'中', "Han", true},
 		{'a', "Han", false},
+		{'\U00020000', "Han", true}, // Above U+FFFF
+		{'A', "Lu", true},
+		{'a', "Lu", false},
 		{'あ', "Hiragana", true},
 		{'ア', "Hiragana", false},
 		{'ア', "Katakana", true},
@@ -405,3 +449,88 @@ func BenchmarkUnicodeSearch(b *testing.B) {
 		}
 	})
 }
+
+func TestFindUnicodeNormalization(t *testing.T) {
+	tempDir := t.TempDir()
+	content := "composed caf\u00e9 here\ndecomposed cafe\u0301 here\nplain cafe\n\ufb01le\n"
+	if err := os.WriteFile(filepath.Join(tempDir, "test.txt"), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		pattern string
+		opts    []Option
+		want    []string // Text of each match, sliced from the original lines
+	}{
+		{"bytes as they are", "caf\u00e9", nil, []string{"caf\u00e9"}},
+		{"NFC composed pattern", "caf\u00e9", []Option{WithUnicodeNormalization(norm.NFC)}, []string{"caf\u00e9", "cafe\u0301"}},
+		{"NFC decomposed pattern", "cafe\u0301", []Option{WithUnicodeNormalization(norm.NFC)}, []string{"caf\u00e9", "cafe\u0301"}},
+		{"NFD composed pattern", "caf\u00e9", []Option{WithUnicodeNormalization(norm.NFD)}, []string{"caf\u00e9", "cafe\u0301"}},
+		// A match ending inside a character composed in the original spans all of it
+		{"NFD widens to whole characters", "cafe", []Option{WithUnicodeNormalization(norm.NFD)}, []string{"caf\u00e9", "cafe", "cafe"}},
+		{"NFC regex", `caf\S here`, []Option{WithUnicodeNormalization(norm.NFC)}, []string{"caf\u00e9 here", "cafe\u0301 here"}},
+		{"NFC fixed string ignoring case", "CAF\u00c9", []Option{WithUnicodeNormalization(norm.NFC), WithFixedStrings(), WithIgnoreCase()}, []string{"caf\u00e9", "cafe\u0301"}},
+		{"NFKC ligature", "file", []Option{WithUnicodeNormalization(norm.NFKC)}, []string{"\ufb01le"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := Find(tt.pattern, tempDir, tt.opts...)
+			if err != nil {
+				t.Fatalf("Find failed: %v", err)
+			}
+			var got []string
+			for _, match := range results.Matches {
+				got = append(got, match.Content[match.MatchStart:match.MatchEnd])
+			}
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("Expected matches %q, got %q", tt.want, got)
+			}
+		})
+	}
+
+	if _, err := Find("caf\u00e9", tempDir, WithUnicodeNormalization(norm.NFC), WithMultiline()); err == nil {
+		t.Error("Expected an error combining normalization with multiline mode")
+	}
+}
+
+func TestFindUnicodeEngine(t *testing.T) {
+	tempDir := t.TempDir()
+	content := "Die stra\u00dfe ist lang\nSTRASSE\nI\u015e\u0131K yan\u0131yor\ncaf\u00e9 and CAFE\u0301\n"
+	if err := os.WriteFile(filepath.Join(tempDir, "test.txt"), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		pattern string
+		opts    []Option
+		want    []string // Text of each match, sliced from the original lines
+	}{
+		{"simple folding misses sharp s", "STRASSE", []Option{WithIgnoreCase()}, []string{"STRASSE"}},
+		{"full folding", "STRASSE", []Option{WithUnicodeEngine(language.Und), WithIgnoreCase()}, []string{"stra\u00dfe", "STRASSE"}},
+		{"sharp s pattern", "stra\u00dfe", []Option{WithUnicodeEngine(language.Und), WithIgnoreCase()}, []string{"stra\u00dfe", "STRASSE"}},
+		{"case kept without ignoring it", "STRASSE", []Option{WithUnicodeEngine(language.Und)}, []string{"STRASSE"}},
+		{"smart case", "strasse", []Option{WithUnicodeEngine(language.Und), WithSmartCase()}, []string{"stra\u00dfe", "STRASSE"}},
+		{"regex literals folded", `stra(ss|x)e\b`, []Option{WithUnicodeEngine(language.Und), WithIgnoreCase()}, []string{"stra\u00dfe", "STRASSE"}},
+		{"dotted I without the language", "\u0131\u015f\u0131k", []Option{WithUnicodeEngine(language.Und), WithIgnoreCase()}, nil},
+		{"dotless i in Turkish", "\u0131\u015f\u0131k", []Option{WithUnicodeEngine(language.Turkish), WithIgnoreCase()}, []string{"I\u015e\u0131K"}},
+		{"normalized to NFC", "caf\u00e9", []Option{WithUnicodeEngine(language.Und), WithIgnoreCase()}, []string{"caf\u00e9", "CAFE\u0301"}},
+		{"whole words", "strasse", []Option{WithUnicodeEngine(language.Und), WithIgnoreCase(), WithWordRegexp()}, []string{"stra\u00dfe", "STRASSE"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := Find(tt.pattern, tempDir, tt.opts...)
+			if err != nil {
+				t.Fatalf("Find failed: %v", err)
+			}
+			var got []string
+			for _, match := range results.Matches {
+				got = append(got, match.Content[match.MatchStart:match.MatchEnd])
+			}
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("Expected matches %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package goripgrep
+
+import (
+	"fmt"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// FileUsage records where a single Go file uses an import path or symbol
+type FileUsage struct {
+	File    string `json:"file"`
+	Package string `json:"package"`
+	Count   int    `json:"count"`
+	Lines   []int  `json:"lines"`
+}
+
+// PackageUsage aggregates usage across the files of one package directory
+type PackageUsage struct {
+	Package string `json:"package"`
+	Files   int    `json:"files"`
+	Count   int    `json:"count"`
+}
+
+// UsageReport summarizes which packages and files use an import path or symbol
+type UsageReport struct {
+	Target   string         `json:"target"`
+	Kind     string         `json:"kind"` // "import" or "symbol"
+	Total    int            `json:"total"`
+	Packages []PackageUsage `json:"packages"`
+	Files    []FileUsage    `json:"files"`
+}
+
+// ImportUsage reports which Go files and packages under path import importPath
+func ImportUsage(importPath string, path string, opts ...Option) (*UsageReport, error) {
+	pattern := `"` + regexp.QuoteMeta(importPath) + `"`
+	results, err := Find(pattern, path, goFileOptions(opts)...)
+	if err != nil {
+		return nil, err
+	}
+
+	var groups []FileMatches
+	for _, group := range results.GroupByFile() {
+		if filepath.Ext(group.File) != ".go" {
+			continue
+		}
+
+		importLines, err := goImportLines(group.File, importPath)
+		if err != nil {
+			return nil, err
+		}
+
+		// Only keep occurrences that are actual import specs
+		var matches []Match
+		for _, match := range group.Matches {
+			if importLines[match.Line] {
+				matches = append(matches, match)
+			}
+		}
+		if len(matches) > 0 {
+			groups = append(groups, FileMatches{File: group.File, Matches: matches})
+		}
+	}
+
+	return buildUsageReport(importPath, "import", groups), nil
+}
+
+// SymbolUsage reports which Go files and packages under path reference symbol,
+// e.g. "errors.Is". Occurrences inside comments are ignored.
+func SymbolUsage(symbol string, path string, opts ...Option) (*UsageReport, error) {
+	pattern := `\b` + regexp.QuoteMeta(symbol) + `\b`
+	results, err := Find(pattern, path, goFileOptions(opts)...)
+	if err != nil {
+		return nil, err
+	}
+
+	syntax := commentSyntaxes[".go"]
+
+	var groups []FileMatches
+	for _, group := range results.GroupByFile() {
+		if filepath.Ext(group.File) != ".go" {
+			continue
+		}
+
+		var matches []Match
+		for _, match := range group.Matches {
+			if !syntax.inComment(match.Content, match.MatchStart) {
+				matches = append(matches, match)
+			}
+		}
+		if len(matches) > 0 {
+			groups = append(groups, FileMatches{File: group.File, Matches: matches})
+		}
+	}
+
+	return buildUsageReport(symbol, "symbol", groups), nil
+}
+
+// goFileOptions restricts a search to Go files while letting callers override
+// it, and keeps every match with its whole line
+func goFileOptions(opts []Option) []Option {
+	return fullLineOptions(append([]Option{WithFilePattern("*.go"), WithRecursive(true)}, opts...))
+}
+
+// goImportLines returns the lines of filePath holding an import spec for importPath
+func goImportLines(filePath string, importPath string) (map[int]bool, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filePath, nil, parser.ImportsOnly)
+	if err != nil && file == nil {
+		return nil, fmt.Errorf("failed to parse imports of %s: %w", filePath, err)
+	}
+
+	lines := make(map[int]bool)
+	for _, spec := range file.Imports {
+		path, err := strconv.Unquote(spec.Path.Value)
+		if err != nil || path != importPath {
+			continue
+		}
+		lines[fset.Position(spec.Path.Pos()).Line] = true
+	}
+	return lines, nil
+}
+
+// buildUsageReport aggregates per-file matches into file and package usage
+func buildUsageReport(target string, kind string, groups []FileMatches) *UsageReport {
+	report := &UsageReport{
+		Target:   target,
+		Kind:     kind,
+		Packages: []PackageUsage{},
+		Files:    []FileUsage{},
+	}
+
+	packageIndex := make(map[string]int)
+	for _, group := range groups {
+		usage := FileUsage{
+			File:    group.File,
+			Package: filepath.Dir(group.File),
+			Count:   len(group.Matches),
+		}
+		for _, match := range group.Matches {
+			if n := len(usage.Lines); n == 0 || usage.Lines[n-1] != match.Line {
+				usage.Lines = append(usage.Lines, match.Line)
+			}
+		}
+
+		report.Files = append(report.Files, usage)
+		report.Total += usage.Count
+
+		i, ok := packageIndex[usage.Package]
+		if !ok {
+			i = len(report.Packages)
+			packageIndex[usage.Package] = i
+			report.Packages = append(report.Packages, PackageUsage{Package: usage.Package})
+		}
+		report.Packages[i].Files++
+		report.Packages[i].Count += usage.Count
+	}
+
+	// Heaviest users first
+	sort.Slice(report.Packages, func(i, j int) bool {
+		if report.Packages[i].Count != report.Packages[j].Count {
+			return report.Packages[i].Count > report.Packages[j].Count
+		}
+		return report.Packages[i].Package < report.Packages[j].Package
+	})
+
+	return report
+}
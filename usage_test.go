@@ -0,0 +1,121 @@
+package goripgrep
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeUsageFixture(t *testing.T) string {
+	t.Helper()
+
+	tempDir := t.TempDir()
+	testFiles := map[string]string{
+		"a/a.go":      "package a\n\nimport (\n\t\"fmt\"\n\tlog \"github.com/example/log\"\n)\n\nfunc A() { log.Print(fmt.Sprint(1)); log.Print(2) }\n",
+		"a/a_test.go": "package a\n\nimport \"github.com/example/log\"\n\n// log.Print is used here\nvar _ = log.Print\n",
+		"b/b.go":      "package b\n\nimport \"fmt\"\n\nvar s = \"github.com/example/log\"\n\nfunc B() { fmt.Println(s) }\n",
+		"c/notes.txt": "import \"github.com/example/log\"\n",
+	}
+
+	for filename, content := range testFiles {
+		fullPath := filepath.Join(tempDir, filename)
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatalf("Failed to create directory for %s: %v", filename, err)
+		}
+		if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", filename, err)
+		}
+	}
+
+	return tempDir
+}
+
+func TestImportUsage(t *testing.T) {
+	tempDir := writeUsageFixture(t)
+
+	report, err := ImportUsage("github.com/example/log", tempDir)
+	if err != nil {
+		t.Fatalf("ImportUsage failed: %v", err)
+	}
+
+	// The string literal in b.go and the text file are not imports
+	if report.Total != 2 || len(report.Files) != 2 {
+		t.Fatalf("Expected 2 imports in 2 files, got %d in %d files", report.Total, len(report.Files))
+	}
+
+	if len(report.Packages) != 1 || report.Packages[0].Package != filepath.Join(tempDir, "a") {
+		t.Fatalf("Expected a single importing package, got %+v", report.Packages)
+	}
+
+	if report.Files[0].Lines[0] != 5 || report.Files[1].Lines[0] != 3 {
+		t.Errorf("Unexpected import lines: %+v", report.Files)
+	}
+}
+
+func TestSymbolUsage(t *testing.T) {
+	tempDir := writeUsageFixture(t)
+
+	report, err := SymbolUsage("log.Print", tempDir)
+	if err != nil {
+		t.Fatalf("SymbolUsage failed: %v", err)
+	}
+
+	// Two calls in a.go and one reference in a_test.go; the comment is ignored
+	if report.Total != 3 {
+		t.Fatalf("Expected 3 uses, got %d", report.Total)
+	}
+
+	if len(report.Files) != 2 || report.Files[0].Count != 2 || len(report.Files[0].Lines) != 1 {
+		t.Errorf("Unexpected file usage: %+v", report.Files)
+	}
+
+	report, err = SymbolUsage("fmt", tempDir)
+	if err != nil {
+		t.Fatalf("SymbolUsage failed: %v", err)
+	}
+
+	// Packages are ordered by usage, heaviest first
+	if len(report.Packages) != 2 || report.Packages[0].Count < report.Packages[1].Count {
+		t.Errorf("Expected packages ordered by usage, got %+v", report.Packages)
+	}
+}
+
+func TestUsageOptions(t *testing.T) {
+	tempDir := writeUsageFixture(t)
+	content := "package c\n\nimport \"github.com/example/log\"\n\nvar s = \"éé\"//log.Print\n" + strings.Repeat("var _ = log.Print\n", 1500)
+	if err := os.WriteFile(filepath.Join(tempDir, "c", "c.go"), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	// Comments are recognized from the whole line, and no use is left out
+	tests := []struct {
+		name string
+		opts []Option
+	}{
+		{"defaults", nil},
+		{"rune columns", []Option{WithColumnMode(ColumnRunes)}},
+		{"only matching", []Option{WithOnlyMatching()}},
+		{"without line content", []Option{WithoutLineContent()}},
+		{"max results", []Option{WithMaxResults(10)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := SymbolUsage("log.Print", tempDir, tt.opts...)
+			if err != nil {
+				t.Fatalf("SymbolUsage failed: %v", err)
+			}
+			if report.Total != 1503 {
+				t.Errorf("Expected 1503 uses, got %d", report.Total)
+			}
+
+			report, err = ImportUsage("github.com/example/log", tempDir, tt.opts...)
+			if err != nil {
+				t.Fatalf("ImportUsage failed: %v", err)
+			}
+			if report.Total != 3 {
+				t.Errorf("Expected 3 imports, got %d", report.Total)
+			}
+		})
+	}
+}
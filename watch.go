@@ -0,0 +1,508 @@
+package goripgrep
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchDebounce is how long a Watcher waits after a change for more changes,
+// since editors often write a file several times when saving it
+const watchDebounce = 100 * time.Millisecond
+
+// WatchEventKind tells whether a match appeared or disappeared
+type WatchEventKind int
+
+const (
+	WatchAdded WatchEventKind = iota
+	WatchRemoved
+)
+
+// String returns the name of the event kind
+func (k WatchEventKind) String() string {
+	switch k {
+	case WatchAdded:
+		return "added"
+	case WatchRemoved:
+		return "removed"
+	default:
+		return "unknown"
+	}
+}
+
+// WatchEvent reports a match that appeared or disappeared
+type WatchEvent struct {
+	Kind  WatchEventKind
+	Match Match
+}
+
+// Watcher keeps the matches of a search under a path current as files
+// change. It searches everything once, then re-searches only the files that
+// change, reporting each match that appears or disappears. A match moved to
+// another line by an edit above it is reported as removed and added again.
+type Watcher struct {
+	pattern string
+	root    string
+	config  SearchConfig
+	parent  context.Context
+	timeout time.Duration
+	ctx     context.Context // Set while running
+
+	filter *SearchEngine  // Decides which files and directories are watched
+	idle   *idleScheduler // Set when changes wait for the machine to go idle
+
+	mu      sync.Mutex
+	matches map[string][]Match // Current matches by file
+}
+
+// NewWatcher creates a watcher for pattern under path, which may be a
+// directory or a file. The usual filters apply, and subdirectories are only
+// watched with WithRecursive. Result limits do not apply, since every match
+// is tracked. Run runs until the context set with WithContext or WithTimeout
+// is done; no timeout applies by default.
+func NewWatcher(pattern, path string, opts ...Option) (*Watcher, error) {
+	if path == "" {
+		return nil, fmt.Errorf("path cannot be empty")
+	}
+
+	options := defaultOptions()
+	options.timeout = 0
+	for _, opt := range opts {
+		opt(options)
+	}
+	pattern, err := options.resolvePatterns(pattern)
+	if err != nil {
+		return nil, err
+	}
+	if err := options.validate(pattern); err != nil {
+		return nil, err
+	}
+
+	root, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("path error: %w", err)
+	}
+	if _, err := os.Stat(root); err != nil {
+		return nil, fmt.Errorf("path error: %w", err)
+	}
+
+	config := options.searchConfig(root)
+	config.MaxResults = math.MaxInt
+	config.QuitAfter = 0
+	config.CountOnly = false
+	config.Timeout = 0
+
+	watcher := &Watcher{
+		pattern: pattern,
+		root:    root,
+		config:  config,
+		parent:  options.ctx,
+		timeout: options.timeout,
+		matches: make(map[string][]Match),
+	}
+	if options.idlePolicy != nil {
+		watcher.idle = newIdleScheduler(*options.idlePolicy)
+	}
+	return watcher, nil
+}
+
+// Matches returns the current matches, sorted by file and position
+func (w *Watcher) Matches() []Match {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var matches []Match
+	for _, file := range w.files() {
+		matches = append(matches, w.matches[file]...)
+	}
+	return matches
+}
+
+// files returns the files with matches in order
+func (w *Watcher) files() []string {
+	files := make([]string, 0, len(w.matches))
+	for file := range w.matches {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	return files
+}
+
+// Run searches the path and reports every match as added, then watches for
+// changes and reports the matches they add and remove, one at a time, until
+// the watcher's context is done, in which case it returns nil, or fn returns
+// an error, which it returns
+func (w *Watcher) Run(fn func(WatchEvent) error) error {
+	ctx, cancel := context.WithCancel(w.parent)
+	defer cancel()
+	if w.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, w.timeout)
+		defer cancel()
+	}
+	w.ctx = ctx
+
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to start watching: %w", err)
+	}
+	defer fsw.Close()
+
+	if err := w.resync(fsw, fn); err != nil {
+		return w.stopped(err)
+	}
+
+	pending := make(map[string]bool)
+	resync := false
+	var debounce, idle <-chan time.Time
+	for {
+		select {
+		case <-w.ctx.Done():
+			return nil
+
+		case event, ok := <-fsw.Events:
+			if !ok {
+				return nil
+			}
+			if event.Op == fsnotify.Chmod {
+				continue
+			}
+			pending[event.Name] = true
+			if debounce == nil && idle == nil {
+				debounce = time.After(watchDebounce)
+			}
+
+		case err, ok := <-fsw.Errors:
+			if !ok {
+				return nil
+			}
+			// Events were lost, so start over from what is on disk
+			if !errors.Is(err, fsnotify.ErrEventOverflow) {
+				return fmt.Errorf("watch failed: %w", err)
+			}
+			resync = true
+			if debounce == nil && idle == nil {
+				debounce = time.After(watchDebounce)
+			}
+
+		case <-debounce:
+			debounce = nil
+			if w.idle != nil {
+				idle = time.After(w.idle.wait())
+				continue
+			}
+			if err := w.flush(fsw, pending, resync, fn); err != nil {
+				return w.stopped(err)
+			}
+			resync = false
+
+		case <-idle:
+			idle = nil
+			if !w.idle.ready() {
+				idle = time.After(w.idle.wait())
+				continue
+			}
+			if err := w.flush(fsw, pending, resync, fn); err != nil {
+				return w.stopped(err)
+			}
+			resync = false
+		}
+	}
+}
+
+// flush handles the changes collected in pending, searching everything
+// again instead when events were lost
+func (w *Watcher) flush(fsw *fsnotify.Watcher, pending map[string]bool, resync bool, fn func(WatchEvent) error) error {
+	paths := make([]string, 0, len(pending))
+	for path := range pending {
+		paths = append(paths, path)
+	}
+	clear(pending)
+	sort.Strings(paths)
+
+	if resync {
+		return w.resync(fsw, fn)
+	}
+	return w.changed(fsw, paths, fn)
+}
+
+// stopped returns nil for errors caused by the watcher's context ending
+func (w *Watcher) stopped(err error) error {
+	if w.ctx.Err() != nil && errors.Is(err, w.ctx.Err()) {
+		return nil
+	}
+	return err
+}
+
+// resync rebuilds the filters, watches every directory they let through and
+// searches everything again, reporting the differences from the matches
+// known so far
+func (w *Watcher) resync(fsw *fsnotify.Watcher, fn func(WatchEvent) error) error {
+	w.filter = NewSearchEngine(w.config)
+	if err := w.filter.initializeEngines(); err != nil {
+		return err
+	}
+	if err := w.watchDirs(fsw, w.root); err != nil {
+		return err
+	}
+
+	results, err := NewSearchEngine(w.config).Search(w.ctx, w.pattern)
+	if err != nil {
+		return err
+	}
+	found := make(map[string][]Match)
+	for _, match := range results.Matches {
+		found[match.File] = append(found[match.File], match)
+	}
+
+	w.mu.Lock()
+	files := w.files()
+	w.mu.Unlock()
+	for file := range found {
+		if !slices.Contains(files, file) {
+			files = append(files, file)
+		}
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		matches := found[file]
+		sortMatches(matches)
+		if err := w.update(file, matches, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// watchDirs watches dir and, in recursive searches, the directories under it
+// that the walk would enter
+func (w *Watcher) watchDirs(fsw *fsnotify.Watcher, dir string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil
+	}
+	if !info.IsDir() {
+		// A single file is watched through its directory, so replacing it
+		// is noticed too
+		return fsw.Add(filepath.Dir(dir))
+	}
+	if !w.config.Recursive {
+		return fsw.Add(dir)
+	}
+
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return nil
+		}
+		if path != w.root && w.filter.prunesDir(path) {
+			return filepath.SkipDir
+		}
+		if err := fsw.Add(path); err != nil {
+			return fmt.Errorf("failed to watch %s: %w", path, err)
+		}
+		return nil
+	})
+}
+
+// changed re-searches the files at paths, and the files under those that are
+// new directories, reporting the differences
+func (w *Watcher) changed(fsw *fsnotify.Watcher, paths []string, fn func(WatchEvent) error) error {
+	for _, path := range paths {
+		// Changed ignore rules can affect any file
+		if w.config.UseGitignore && w.isIgnoreFile(path) {
+			return w.resync(fsw, fn)
+		}
+	}
+
+	for _, path := range paths {
+		if err := w.ctx.Err(); err != nil {
+			return err
+		}
+
+		info, err := os.Lstat(path)
+		switch {
+		case err != nil:
+			// Removed or renamed away, perhaps with files under it
+			if err := w.removeUnder(path, fn); err != nil {
+				return err
+			}
+
+		case info.IsDir():
+			if !w.config.Recursive || !w.watchesFile(path) || w.filter.prunesDir(path) {
+				continue
+			}
+			if err := w.watchDirs(fsw, path); err != nil {
+				return err
+			}
+			if err := w.searchDir(path, fn); err != nil {
+				return err
+			}
+
+		default:
+			if err := w.searchChanged(path, info, fn); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// searchChanged re-searches a changed file, or drops its matches if the
+// filters now exclude it
+func (w *Watcher) searchChanged(path string, info os.FileInfo, fn func(WatchEvent) error) error {
+	if !w.watchesFile(path) || w.filter.shouldIgnoreFile(path, info) {
+		return w.update(path, nil, fn)
+	}
+
+	results, err := NewSearchEngine(w.config).SearchFile(w.ctx, w.pattern, path)
+	if err != nil {
+		// The file may be gone again or not readable yet
+		if os.IsNotExist(err) || os.IsPermission(err) {
+			return w.update(path, nil, fn)
+		}
+		return err
+	}
+	return w.update(path, results.Matches, fn)
+}
+
+// isIgnoreFile reports whether path names a file ignore rules are read from
+func (w *Watcher) isIgnoreFile(path string) bool {
+	name := filepath.Base(path)
+	return slices.Contains(defaultIgnoreFiles, name) || slices.Contains(w.config.IgnoreFiles, name)
+}
+
+// watchesFile reports whether a file at path is part of the search, as
+// opposed to a sibling of a watched file or a file in a subdirectory of a
+// non-recursive search
+func (w *Watcher) watchesFile(path string) bool {
+	info, err := os.Stat(w.root)
+	if err != nil {
+		return false
+	}
+	if !info.IsDir() {
+		return path == w.root
+	}
+	return w.config.Recursive || filepath.Dir(path) == w.root
+}
+
+// searchDir searches a new directory's files and reports their matches
+func (w *Watcher) searchDir(dir string, fn func(WatchEvent) error) error {
+	config := w.config
+	config.SearchPath = dir
+	results, err := NewSearchEngine(config).Search(w.ctx, w.pattern)
+	if err != nil {
+		return err
+	}
+
+	found := make(map[string][]Match)
+	for _, match := range results.Matches {
+		found[match.File] = append(found[match.File], match)
+	}
+	files := make([]string, 0, len(found))
+	for file := range found {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		matches := found[file]
+		sortMatches(matches)
+		if err := w.update(file, matches, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeUnder drops the matches of path and of every file under it
+func (w *Watcher) removeUnder(path string, fn func(WatchEvent) error) error {
+	w.mu.Lock()
+	files := w.files()
+	w.mu.Unlock()
+
+	prefix := path + string(filepath.Separator)
+	for _, file := range files {
+		if file == path || strings.HasPrefix(file, prefix) {
+			if err := w.update(file, nil, fn); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// watchKey identifies a match for comparing searches of the same file
+type watchKey struct {
+	line    int
+	column  int
+	content string
+}
+
+// update replaces the matches known for file, reporting the ones that
+// disappeared and then the ones that appeared
+func (w *Watcher) update(file string, matches []Match, fn func(WatchEvent) error) error {
+	w.mu.Lock()
+	previous := w.matches[file]
+	if len(matches) == 0 {
+		delete(w.matches, file)
+	} else {
+		w.matches[file] = matches
+	}
+	w.mu.Unlock()
+
+	current, known := countWatchKeys(matches), countWatchKeys(previous)
+	for _, match := range previous {
+		if key := newWatchKey(match); current[key] > 0 {
+			current[key]--
+			continue
+		}
+		if err := fn(WatchEvent{Kind: WatchRemoved, Match: match}); err != nil {
+			return err
+		}
+	}
+	for _, match := range matches {
+		if key := newWatchKey(match); known[key] > 0 {
+			known[key]--
+			continue
+		}
+		if err := fn(WatchEvent{Kind: WatchAdded, Match: match}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newWatchKey returns the key of match
+func newWatchKey(match Match) watchKey {
+	return watchKey{line: match.Line, column: match.Column, content: match.Content}
+}
+
+// countWatchKeys counts the matches with each key
+func countWatchKeys(matches []Match) map[watchKey]int {
+	counts := make(map[watchKey]int, len(matches))
+	for _, match := range matches {
+		counts[newWatchKey(match)]++
+	}
+	return counts
+}
+
+// sortMatches orders one file's matches by position
+func sortMatches(matches []Match) {
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Line != matches[j].Line {
+			return matches[i].Line < matches[j].Line
+		}
+		return matches[i].Column < matches[j].Column
+	})
+}
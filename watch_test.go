@@ -0,0 +1,192 @@
+package goripgrep
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// watchEvents runs watcher in the background, returning its events
+func watchEvents(t *testing.T, watcher *Watcher) <-chan WatchEvent {
+	t.Helper()
+	events := make(chan WatchEvent, 100)
+	done := make(chan error, 1)
+	go func() {
+		done <- watcher.Run(func(event WatchEvent) error {
+			events <- event
+			return nil
+		})
+	}()
+	t.Cleanup(func() {
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Errorf("Run failed: %v", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Error("Run did not stop with its context")
+		}
+	})
+	return events
+}
+
+// expectEvent waits for the next event and checks its kind, file and line
+func expectEvent(t *testing.T, events <-chan WatchEvent, kind WatchEventKind, file string, line int) {
+	t.Helper()
+	select {
+	case event := <-events:
+		if event.Kind != kind || event.Match.File != file || event.Match.Line != line {
+			t.Fatalf("Expected %s %s:%d, got %s %s:%d", kind, file, line, event.Kind, event.Match.File, event.Match.Line)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Timed out waiting for %s %s:%d", kind, file, line)
+	}
+}
+
+func TestWatcher(t *testing.T) {
+	root := t.TempDir()
+	writeTestFiles(t, root, map[string]string{
+		"a.txt":     "TODO one\nplain\n",
+		"sub/b.txt": "nothing\n",
+	})
+	a := filepath.Join(root, "a.txt")
+	b := filepath.Join(root, "sub", "b.txt")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	watcher, err := NewWatcher("TODO", root, WithRecursive(true), WithContext(ctx))
+	if err != nil {
+		t.Fatalf("NewWatcher failed: %v", err)
+	}
+	events := watchEvents(t, watcher)
+	defer cancel()
+
+	// Existing matches come first
+	expectEvent(t, events, WatchAdded, a, 1)
+
+	// Only the new match of a changed file is reported
+	if err := os.WriteFile(a, []byte("TODO one\nplain\nTODO two\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	expectEvent(t, events, WatchAdded, a, 3)
+
+	if err := os.WriteFile(b, []byte("TODO in sub\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	expectEvent(t, events, WatchAdded, b, 1)
+
+	// New directories are watched and searched
+	writeTestFiles(t, root, map[string]string{"new/c.txt": "TODO new\n"})
+	c := filepath.Join(root, "new", "c.txt")
+	expectEvent(t, events, WatchAdded, c, 1)
+	if err := os.WriteFile(c, []byte("TODO new\nTODO again\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	expectEvent(t, events, WatchAdded, c, 2)
+
+	// Removing files removes their matches
+	if err := os.Remove(b); err != nil {
+		t.Fatal(err)
+	}
+	expectEvent(t, events, WatchRemoved, b, 1)
+
+	// Ignore rules apply as soon as they change
+	if err := os.WriteFile(filepath.Join(root, ".gitignore"), []byte("new/\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	expectEvent(t, events, WatchRemoved, c, 1)
+	expectEvent(t, events, WatchRemoved, c, 2)
+
+	if err := os.WriteFile(a, []byte("plain\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	expectEvent(t, events, WatchRemoved, a, 1)
+	expectEvent(t, events, WatchRemoved, a, 3)
+
+	if matches := watcher.Matches(); len(matches) != 0 {
+		t.Errorf("Expected no matches left, got %+v", matches)
+	}
+}
+
+func TestWatcherSingleFileAndErrors(t *testing.T) {
+	root := t.TempDir()
+	writeTestFiles(t, root, map[string]string{"app.log": "ERROR first\n", "other.log": "ERROR other\n"})
+	log := filepath.Join(root, "app.log")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	watcher, err := NewWatcher("ERROR", log, WithContext(ctx))
+	if err != nil {
+		t.Fatalf("NewWatcher failed: %v", err)
+	}
+	events := watchEvents(t, watcher)
+	defer cancel()
+	expectEvent(t, events, WatchAdded, log, 1)
+
+	// Siblings of a watched file are not part of the search
+	if err := os.WriteFile(filepath.Join(root, "other.log"), []byte("ERROR other\nERROR more\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(log, []byte("ERROR first\nERROR second\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	expectEvent(t, events, WatchAdded, log, 2)
+	if matches := watcher.Matches(); len(matches) != 2 {
+		t.Errorf("Expected 2 current matches, got %+v", matches)
+	}
+
+	if _, err := NewWatcher("ERROR", filepath.Join(root, "missing")); err == nil {
+		t.Error("Expected an error for a missing path")
+	}
+	if _, err := NewWatcher("(", root); err == nil {
+		t.Error("Expected an error for an invalid pattern")
+	}
+
+	// An error from fn stops the watcher
+	stop := errors.New("stop")
+	watcher, err = NewWatcher("ERROR", root)
+	if err != nil {
+		t.Fatalf("NewWatcher failed: %v", err)
+	}
+	if err := watcher.Run(func(WatchEvent) error { return stop }); !errors.Is(err, stop) {
+		t.Errorf("Expected Run to return the error from fn, got %v", err)
+	}
+}
+
+func TestWatcherIdleMaintenance(t *testing.T) {
+	root := t.TempDir()
+	writeTestFiles(t, root, map[string]string{"a.txt": "TODO one\n"})
+	a := filepath.Join(root, "a.txt")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	watcher, err := NewWatcher("TODO", root, WithContext(ctx), WithIdleMaintenance(IdlePolicy{Interval: 10 * time.Millisecond}))
+	if err != nil {
+		t.Fatalf("NewWatcher failed: %v", err)
+	}
+	var busy, iowait atomic.Uint64
+	busy.Store(80)
+	watcher.idle.sample = fakeLoad(&busy, &iowait)
+	events := watchEvents(t, watcher)
+	defer cancel()
+
+	// The initial search runs whatever the load
+	expectEvent(t, events, WatchAdded, a, 1)
+
+	// Changes wait while the machine is busy
+	if err := os.WriteFile(a, []byte("TODO one\nTODO two\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case event := <-events:
+		t.Fatalf("Expected no events while busy, got %+v", event)
+	case <-time.After(300 * time.Millisecond):
+	}
+
+	busy.Store(0)
+	expectEvent(t, events, WatchAdded, a, 2)
+}
@@ -0,0 +1,146 @@
+package goripgrep
+
+import (
+	"runtime"
+	"sync/atomic"
+)
+
+// SuggestedWorkerGroups returns a WithWorkerGroups count for this machine:
+// one group per NUMA node, and at least one per 16 CPUs so that each group's
+// workers contend with few others
+func SuggestedWorkerGroups() int {
+	return max(numaNodes(), runtime.NumCPU()/16, 1)
+}
+
+// startWorkerGroups returns the engines the workers search through and the
+// channel each one's workers read files from. Without WorkerGroups that is e
+// and filesChan alone. Otherwise every group gets an engine of its own, with
+// its own compiled matcher, decompressors, statistics and phase counters, so
+// workers on different cores stop contending for the same cache lines, and
+// the walked files are dealt out to the groups' channels.
+func (e *SearchEngine) startWorkerGroups(pattern string, filesChan <-chan walkedFile) ([]*SearchEngine, []<-chan walkedFile, error) {
+	e.groups = nil
+	count := min(e.config.WorkerGroups, e.config.MaxWorkers)
+	if count <= 1 {
+		return []*SearchEngine{e}, []<-chan walkedFile{filesChan}, nil
+	}
+
+	groupChans := make([]chan walkedFile, count)
+	readChans := make([]<-chan walkedFile, count)
+	for i := 0; i < count; i++ {
+		group, err := e.newWorkerGroup(pattern)
+		if err != nil {
+			e.groups = nil
+			return nil, nil, err
+		}
+		e.groups = append(e.groups, group)
+		groupChans[i] = make(chan walkedFile, 2*e.config.MaxWorkers/count+1)
+		readChans[i] = groupChans[i]
+	}
+
+	go distributeFiles(filesChan, groupChans)
+	return e.groups, readChans, nil
+}
+
+// newWorkerGroup creates the engine of one worker group. It shares e's
+// filters and result handling, and draws on e's MaxFiles and MaxBytes limits.
+func (e *SearchEngine) newWorkerGroup(pattern string) (*SearchEngine, error) {
+	config := e.config
+	config.RegexCaching = false // Its own regex rather than the one shared through the cache
+
+	group := &SearchEngine{
+		config:          config,
+		gitignoreEngine: e.gitignoreEngine,
+		globs:           e.globs,
+		patterns:        e.patterns,
+		owner:           e,
+	}
+	if e.compression != nil {
+		group.compression = NewCompressionDetector()
+	}
+	if e.archives != nil {
+		group.archives = NewArchiveSearcher()
+	}
+
+	matcher, err := newLineMatcher(pattern, config)
+	if err != nil {
+		return nil, err
+	}
+	group.matcher = matcher
+	return group, nil
+}
+
+// distributeFiles deals the walked files out to the groups in turn, passing
+// a file on to the next group with room when its group is busy, and closes
+// the groups' channels once the walk is done
+func distributeFiles(filesChan <-chan walkedFile, groupChans []chan walkedFile) {
+	defer func() {
+		for _, ch := range groupChans {
+			close(ch)
+		}
+	}()
+
+	next := 0
+	for file := range filesChan {
+		sent := false
+		for i := range groupChans {
+			select {
+			case groupChans[(next+i)%len(groupChans)] <- file:
+				sent = true
+			default:
+			}
+			if sent {
+				break
+			}
+		}
+		if !sent {
+			groupChans[next] <- file
+		}
+		next = (next + 1) % len(groupChans)
+	}
+}
+
+// bytesScanned returns the bytes searched so far by e's workers and those
+// of its groups
+func (e *SearchEngine) bytesScanned() int64 {
+	total := atomic.LoadInt64(&e.stats.BytesScanned)
+	for _, group := range e.groups {
+		total += atomic.LoadInt64(&group.stats.BytesScanned)
+	}
+	return total
+}
+
+// mergeWorkerGroups adds the statistics and phase timings of the worker
+// groups, whose workers have all stopped, to e's
+func (e *SearchEngine) mergeWorkerGroups() {
+	for _, group := range e.groups {
+		e.stats.FilesScanned += group.stats.FilesScanned
+		e.stats.BytesScanned += group.stats.BytesScanned
+		e.stats.BytesRead += group.stats.BytesRead
+		e.stats.FilesChanged += group.stats.FilesChanged
+		e.phases.read += group.phases.read
+		e.phases.decompress += group.phases.decompress
+		e.phases.process += group.phases.process
+	}
+	e.groups = nil
+}
+
+// groupCPUs returns the CPUs to pin each of count worker groups to, or nil
+// when pinning is off, unsupported or there are fewer CPUs than groups.
+// Groups get contiguous runs of CPUs, which usually keeps each on one NUMA
+// node.
+func (e *SearchEngine) groupCPUs(count int) [][]int {
+	if !e.config.PinCPUs {
+		return nil
+	}
+	cpus := allowedCPUs()
+	if len(cpus) < count {
+		return nil
+	}
+
+	groups := make([][]int, count)
+	for i := range groups {
+		groups[i] = cpus[i*len(cpus)/count : (i+1)*len(cpus)/count]
+	}
+	return groups
+}
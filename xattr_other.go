@@ -0,0 +1,11 @@
+//go:build !linux && !darwin
+
+package goripgrep
+
+// xattrSupported reports whether WithMetadata can read extended attributes here
+const xattrSupported = false
+
+// readXattrs reports no extended attributes on platforms without xattr support
+func readXattrs(filePath string) ([]xattr, error) {
+	return nil, nil
+}
@@ -0,0 +1,48 @@
+//go:build linux || darwin
+
+package goripgrep
+
+import (
+	"bytes"
+
+	"golang.org/x/sys/unix"
+)
+
+// xattrSupported reports whether WithMetadata can read extended attributes here
+const xattrSupported = true
+
+// readXattrs returns the extended attributes of filePath, without following symlinks
+func readXattrs(filePath string) ([]xattr, error) {
+	size, err := unix.Llistxattr(filePath, nil)
+	if err != nil || size == 0 {
+		return nil, err
+	}
+
+	names := make([]byte, size)
+	size, err = unix.Llistxattr(filePath, names)
+	if err != nil {
+		return nil, err
+	}
+
+	var attrs []xattr
+	for _, name := range bytes.Split(names[:size], []byte{0}) {
+		if len(name) == 0 {
+			continue
+		}
+
+		valueSize, err := unix.Lgetxattr(filePath, string(name), nil)
+		if err != nil {
+			// The attribute may have been removed or be unreadable; skip it
+			continue
+		}
+		value := make([]byte, valueSize)
+		valueSize, err = unix.Lgetxattr(filePath, string(name), value)
+		if err != nil {
+			continue
+		}
+
+		attrs = append(attrs, xattr{name: string(name), value: string(value[:valueSize])})
+	}
+
+	return attrs, nil
+}
@@ -0,0 +1,36 @@
+//go:build linux || darwin
+
+package goripgrep
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestFindMetadataXattr(t *testing.T) {
+	tempDir := t.TempDir()
+	testFile := filepath.Join(tempDir, "report.txt")
+	if err := os.WriteFile(testFile, []byte("quarterly numbers\n"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	if err := unix.Setxattr(testFile, "user.comment", []byte("reviewed by finance"), 0); err != nil {
+		t.Skipf("Extended attributes not supported here: %v", err)
+	}
+
+	results, err := Find("finance", tempDir, WithMetadata())
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+
+	if results.Count() != 1 {
+		t.Fatalf("Expected 1 match, got %d", results.Count())
+	}
+
+	match := results.Matches[0]
+	if match.Kind != MatchXattr || match.Attribute != "user.comment" || match.Column != 13 {
+		t.Errorf("Unexpected xattr match: %+v", match)
+	}
+}